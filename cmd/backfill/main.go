@@ -0,0 +1,100 @@
+// Command backfill fetches historical rates for a set of pairs and stores them
+// in the rate history store, seeding the time series and stats endpoints.
+//
+//	backfill -pairs USD-EUR,USD-GBP -start 2024-01-01 -end 2024-12-31
+//
+// It reads the same environment as the server (STORAGE_DRIVER, STORAGE_DSN,
+// RATE_PROVIDERS, API keys). Days already stored are skipped, so an
+// interrupted run is resumed by running it again.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/backfill"
+	"exchange-rate-service/internal/client"
+	"exchange-rate-service/internal/logging"
+	"exchange-rate-service/internal/storage"
+)
+
+func main() {
+	pairs := flag.String("pairs", "", "comma-separated pairs to backfill, e.g. USD-EUR,USD-GBP")
+	start := flag.String("start", "", "first day, YYYY-MM-DD")
+	end := flag.String("end", "", "last day, YYYY-MM-DD (before today)")
+	flag.Parse()
+
+	cfg := config.Load()
+	logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+
+	job, err := backfill.NewJob("cli", backfill.Request{
+		Pairs: strings.Split(*pairs, ","),
+		Start: *start,
+		End:   *end,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backfill: %v\n\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if cfg.StorageDriver == "none" {
+		fatal("Backfill needs a rate store, STORAGE_DRIVER is none", nil)
+	}
+	store, err := storage.Open(cfg.StorageDriver, cfg.StorageDSN)
+	if err != nil {
+		fatal("Failed to open rate store", err)
+	}
+	defer store.Close()
+
+	providers := make([]client.Provider, 0, len(config.RateProviders))
+	for _, name := range config.RateProviders {
+		provider, err := client.NewProviderByName(name)
+		if err != nil {
+			fatal("Invalid RATE_PROVIDERS config", err)
+		}
+		providers = append(providers, provider)
+	}
+	providerChain := client.NewProviderChain(providers...)
+	defer providerChain.Close()
+
+	// Ctrl-C stops after the current request - run again to resume
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	slog.Info("Starting backfill", "pairs", job.Pairs, "start", job.Start, "end", job.End,
+		"days", job.TotalDays, "providers", providerChain.Providers())
+
+	err = backfill.New(providerChain, store).Run(ctx, &job, func(progress backfill.Job) {
+		slog.Info("Backfill progress", "percent", progress.Percent,
+			"stored", progress.Stored, "skipped", progress.Skipped, "failed", progress.Failed)
+	})
+	if err != nil {
+		slog.Warn("Backfill interrupted, run again to resume", "stored", job.Stored, "error", err)
+		os.Exit(1)
+	}
+
+	for _, msg := range job.Errors {
+		slog.Warn("Backfill error", "error", msg)
+	}
+	slog.Info("Backfill finished", "status", job.Status, "stored", job.Stored, "skipped", job.Skipped, "failed", job.Failed)
+	if job.Status != backfill.StatusCompleted {
+		os.Exit(1)
+	}
+}
+
+func fatal(msg string, err error) {
+	if err != nil {
+		slog.Error(msg, "error", err)
+	} else {
+		slog.Error(msg)
+	}
+	os.Exit(1)
+}
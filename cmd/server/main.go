@@ -2,56 +2,614 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"exchange-rate-service/config"
+	"exchange-rate-service/internal/alerts"
+	"exchange-rate-service/internal/archive"
+	"exchange-rate-service/internal/audit"
+	"exchange-rate-service/internal/auth"
+	"exchange-rate-service/internal/backfill"
+	"exchange-rate-service/internal/broadcast"
 	"exchange-rate-service/internal/cache"
+	"exchange-rate-service/internal/calendar"
+	"exchange-rate-service/internal/certs"
 	"exchange-rate-service/internal/client"
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/docs"
+	"exchange-rate-service/internal/fees"
+	"exchange-rate-service/internal/graphqlapi"
+	"exchange-rate-service/internal/grpcserver"
 	"exchange-rate-service/internal/handlers"
+	"exchange-rate-service/internal/live"
+	"exchange-rate-service/internal/logging"
+	"exchange-rate-service/internal/metrics"
+	"exchange-rate-service/internal/middleware"
+	"exchange-rate-service/internal/receipts"
+	"exchange-rate-service/internal/requestlog"
 	"exchange-rate-service/internal/services"
+	"exchange-rate-service/internal/storage"
+	"exchange-rate-service/internal/tracing"
 
 	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
-	log.Println("Starting Exchange Rate Service...")
-
 	// load config
 	cfg := config.Load()
+	logging.Setup(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+
+	slog.Info("Starting Exchange Rate Service...")
+
+	// tracing - no-op unless OTEL_EXPORTER_OTLP_ENDPOINT is set
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.OTLPEndpoint, cfg.TraceServiceName, cfg.TraceSampleRatio)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+	if cfg.OTLPEndpoint != "" {
+		slog.Info("Tracing enabled", "endpoint", cfg.OTLPEndpoint, "sample_ratio", cfg.TraceSampleRatio)
+	}
 
 	// Ensure colon at the beginning of server address (deployment-safe)
 	if cfg.ServerAddress[0] != ':' {
 		cfg.ServerAddress = ":" + cfg.ServerAddress
 	}
 
-	log.Printf("Server will listen on %s", cfg.ServerAddress)
+	slog.Info("Server will listen", "address", cfg.ServerAddress)
+
+	// rate history store - every fetched rate is recorded, historical lookups check it first
+	var rateStore *storage.SQLStore
+	var rateHistory services.RateHistory
+	if cfg.StorageDriver != "none" {
+		store, err := storage.Open(cfg.StorageDriver, cfg.StorageDSN)
+		if err != nil {
+			fatal("Failed to open rate store", err)
+		}
+		defer store.Close()
+		rateStore = store
+		rateHistory = store
+		slog.Info("Rate history store opened", "driver", cfg.StorageDriver)
+	}
+
+	// setup api client - replicas get a stub that never calls the provider
+	var apiClient services.ExchangeRateAPIClient
+	var breakers services.CircuitBreakerReporter
+	var upstreamCheck services.HealthChecker
+	var providerProbe *client.ProviderProbe
+	var degradedMode *services.DegradedMode
+	var backfillSource backfill.Fetcher
+	assetCodes := config.CryptoAssets
+	if config.ReadOnlyMode {
+		apiClient = client.NewReadOnlyClient()
+		slog.Info("Read-only replica mode: upstream provider calls disabled")
+	} else {
+		providers := make([]client.Provider, 0, len(config.RateProviders))
+		for _, name := range config.RateProviders {
+			provider, err := client.NewProviderByName(name)
+			if err != nil {
+				fatal("Invalid RATE_PROVIDERS config", err)
+			}
+			providers = append(providers, provider)
+		}
+
+		providerChain := client.NewProviderChain(providers...)
+		defer providerChain.Close()
+		if rateStore != nil {
+			recorder := storage.NewRecorder(rateStore, 1024)
+			recorder.Start()
+			defer recorder.Stop()
+			providerChain.SetRecorder(recorder)
+		}
+		apiClient = providerChain
+		// backfills write rates themselves, synchronously - a chain without the recorder
+		backfillSource = client.NewProviderChain(providers...)
+		breakers = providerChain
+		upstreamCheck = services.NewHealthCheck("upstream", providerChain.CheckUpstream)
+		// a dead key or DNS failure shows up here before a user request hits it
+		if cfg.ProviderProbeInterval > 0 {
+			providerProbe = client.NewProviderProbe(providerChain, cfg.ProviderProbeInterval)
+			providerProbe.Start()
+			defer providerProbe.Stop()
+		}
+		// no usable provider - serve what's cached instead of queueing on dead upstreams
+		if cfg.DegradedModeEnabled {
+			degradedMode = services.NewDegradedMode(providerChain.CheckUpstream, cfg.DegradedModeCheckInterval)
+			degradedMode.Start()
+			defer degradedMode.Stop()
+		}
+		slog.Info("Exchange rate providers initialized", "failover_order", providerChain.Providers())
+
+		if len(assetCodes) > 0 && !providerChain.HasAssetProvider() {
+			slog.Warn("CRYPTO_ASSETS set but RATE_PROVIDERS has no crypto provider, crypto and metals disabled")
+			assetCodes = nil
+		}
+	}
+
+	// cache backend - redis lets replicas share rates and survive restarts
+	var cacheBackend cache.Cache
+	switch cfg.CacheBackend {
+	case "redis":
+		redisCache, err := cache.NewRedisCache(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+		if err != nil {
+			fatal("Failed to connect to redis cache", err)
+		}
+		defer redisCache.Close()
+		cacheBackend = redisCache
+		slog.Info("Using redis cache backend", "addr", cfg.RedisAddr)
+	case "memory":
+		cacheBackend = cache.NewMemoryCache()
+		slog.Info("Using in-memory cache backend")
+	default:
+		fatal("Unknown CACHE_BACKEND (expected memory or redis)", nil, "backend", cfg.CacheBackend)
+	}
+
+	rateCache := cache.NewExchangeRateCache(apiClient, cacheBackend, cfg.CacheKeyPrefix)
+	rateCache.TrackAssets(assetCodes)
+
+	// replicas sharing redis refresh once between them instead of once each
+	if cfg.CacheBackend == "redis" && cfg.RefreshCoordination && !config.ReadOnlyMode {
+		instanceID := cfg.InstanceID
+		if instanceID == "" {
+			hostname, _ := os.Hostname()
+			instanceID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+		}
+		if err := rateCache.EnableCoordination(instanceID, cfg.RefreshLeaseTTL); err != nil {
+			fatal("Invalid refresh coordination config", err)
+		}
+		slog.Info("Refresh coordination enabled", "instance", instanceID, "lease_ttl", cfg.RefreshLeaseTTL.String())
+	}
+
+	// memory cache snapshot - a deploy restarts from the last saved rates instead of an
+	// empty cache. Redis already outlives us, and replicas never own the cache
+	if cfg.CacheSnapshotFile != "" && cfg.CacheBackend == "memory" && !config.ReadOnlyMode {
+		loaded, stale, err := rateCache.LoadSnapshot(cfg.CacheSnapshotFile)
+		if err != nil {
+			slog.Warn("Could not load cache snapshot, starting cold", "path", cfg.CacheSnapshotFile, "error", err)
+		} else if loaded > 0 {
+			slog.Info("Cache restored from snapshot", "path", cfg.CacheSnapshotFile, "pairs", loaded, "stale", stale)
+		}
+		rateCache.StartSnapshots(cfg.CacheSnapshotFile, cfg.CacheSnapshotInterval)
+	}
+
+	// supported currencies - core list, then whatever the provider can quote,
+	// plus the crypto and metals
+	currencyRegistry := currency.NewRegistry(config.CoreCurrencies)
+	currencyRegistry.AddAssets(assetCodes)
+	if source, ok := apiClient.(currency.CodeSource); ok && config.CurrencyRefreshInterval > 0 {
+		if err := currencyRegistry.Refresh(context.Background(), source); err != nil {
+			slog.Warn("Could not fetch supported currencies, starting with core list", "error", err)
+		}
+		currencyRegistry.StartRefresh(source, config.CurrencyRefreshInterval)
+		defer currencyRegistry.Stop()
+	}
+	// operators' runtime additions and removals, kept in the rate store
+	var currencyManager *currency.Manager
+	if rateStore != nil {
+		currencyManager = currency.NewManager(currencyRegistry, rateStore, config.RefreshBaseCurrency)
+		loadCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		overrides, err := currencyManager.Load(loadCtx)
+		cancel()
+		if err != nil {
+			fatal("Failed to load currency overrides", err)
+		}
+		if overrides > 0 {
+			slog.Info("Currency overrides loaded from the rate store", "overrides", overrides)
+		}
+		rateCache.TrackCurrencies(currencyRegistry)
+	}
+	slog.Info("Currencies supported", "count", currencyRegistry.Len())
 
-	// setup api client
-	apiClient := client.NewRateClient()
-	log.Println("Exchange rate API client initialized")
+	// readiness - cache warm everywhere; writers also need a recent refresh and a usable provider
+	readinessChecks := []services.HealthChecker{services.NewHealthCheck("cache", rateCache.CheckWarm)}
 
-	// cache setup - auto refresh every hour
-	rateCache := cache.NewExchangeRateCache(apiClient)
-	rateCache.StartHourlyRefresh()
-	defer rateCache.Stop()
-	log.Println("Background rate refresh started")
+	// startup warmup - not ready until most core pairs are cached, so the first
+	// requests after a deploy don't race the initial refresh to the providers
+	var warmup *cache.Warmup
+	if cfg.WarmupTimeout > 0 {
+		warmup = cache.NewWarmup(rateCache, cfg.WarmupMinFraction, cfg.WarmupTimeout)
+		warmup.Start()
+		defer warmup.Stop()
+		readinessChecks = append(readinessChecks, services.NewHealthCheck("warmup", warmup.Check))
+		slog.Info("Startup warmup enabled", "min_fraction", cfg.WarmupMinFraction,
+			"timeout", cfg.WarmupTimeout.String(), "gate_requests", cfg.WarmupGateRequests)
+	}
+	if !config.ReadOnlyMode {
+		readinessChecks = append(readinessChecks,
+			services.NewHealthCheck("refresh", func(ctx context.Context) error {
+				if err := rateCache.CheckRefreshedWithin(cfg.ReadinessMaxRefreshAge); err != nil {
+					return err
+				}
+				return rateCache.CheckRefreshFailures(cfg.ReadinessMaxRefreshFailures)
+			}),
+			upstreamCheck,
+		)
+		if providerProbe != nil {
+			readinessChecks = append(readinessChecks, services.NewHealthCheck("provider_probe", providerProbe.Check))
+		}
+	}
+	if rateStore != nil {
+		readinessChecks = append(readinessChecks, services.NewHealthCheck("storage", rateStore.Ping))
+	}
 
 	// services
-	healthSvc := services.NewHealthService()
-	exchangeSvc := services.NewCurrencyExchangeService(rateCache, apiClient)
+	healthSvc := services.NewHealthService(breakers, readinessChecks...)
+	if providerProbe != nil {
+		healthSvc.SetProviderProbe(providerProbe)
+	}
+	exchangeSvc := services.NewCurrencyExchangeService(rateCache, apiClient, currencyRegistry, rateHistory)
+	if degradedMode != nil {
+		healthSvc.SetDegradedMode(degradedMode)
+		exchangeSvc.SetDegradedMode(degradedMode)
+	}
+
+	// conversion markup - a typo here would misprice every quote, so refuse to start
+	feeSchedule, err := fees.NewSchedule(cfg.FeeDefault, cfg.FeePairs)
+	if err != nil {
+		fatal("Invalid fee config", err)
+	}
+	exchangeSvc.SetFees(feeSchedule)
+	if cfg.FeeDefault != "" || feeSchedule.Len() > 0 {
+		slog.Info("Conversion fees enabled", "default", cfg.FeeDefault, "pair_rules", feeSchedule.Len())
+	}
 
-	// handlers
-	healthHandler := handlers.NewHealthHandler(healthSvc)
-	exchangeHandler := handlers.NewExchangeHandler(exchangeSvc)
+	// which days each market trades - a mistyped holiday would silently serve the wrong day's rate
+	markets, err := calendar.New(cfg.MarketTimezone, cfg.MarketTimezones, cfg.MarketHolidays)
+	if err != nil {
+		fatal("Invalid market calendar config", err)
+	}
+	exchangeSvc.SetCalendar(markets)
+
+	// rate alerts - checked after every refresh cycle, so writers only
+	var alertHandler *handlers.AlertHandler
+	if cfg.AlertsEnabled && !config.ReadOnlyMode {
+		alertStore, err := alerts.NewStore(cfg.AlertsFile)
+		if err != nil {
+			fatal("Failed to load alerts", err)
+		}
+		notifier := alerts.NewNotifier(cfg.AlertWebhookAllowPrivate)
+		defer notifier.Stop()
+
+		alertManager := alerts.NewManager(alertStore, exchangeSvc, currencyRegistry, notifier)
+		rateCache.OnRefresh(alertManager.Evaluate)
+		alertHandler = handlers.NewAlertHandler(alertManager)
+		slog.Info("Rate alerts enabled", "loaded", len(alertStore.List("")))
+	}
+
+	// rate change webhooks for downstream services - operator-configured URLs,
+	// so internal addresses are fine (writers only, like alerts)
+	if len(cfg.RateWebhookURLs) > 0 && !config.ReadOnlyMode {
+		pairs := cfg.RateWebhookPairs
+		if len(pairs) == 0 {
+			for _, code := range config.GetCoreCurrencies() {
+				if code != config.RefreshBaseCurrency {
+					pairs = append(pairs, config.RefreshBaseCurrency+"-"+code)
+				}
+			}
+		}
+		webhookSender := alerts.NewNotifier(true)
+		defer webhookSender.Stop()
+
+		broadcaster, err := broadcast.New(exchangeSvc, webhookSender, broadcast.Options{
+			URLs:             cfg.RateWebhookURLs,
+			Pairs:            pairs,
+			ThresholdPercent: cfg.RateWebhookThreshold,
+			Secret:           cfg.RateWebhookSecret,
+		})
+		if err != nil {
+			fatal("Invalid rate webhook config", err)
+		}
+		rateCache.OnRefresh(broadcaster.Publish)
+		slog.Info("Rate change webhooks enabled", "urls", len(cfg.RateWebhookURLs), "pairs", len(pairs),
+			"threshold_pct", cfg.RateWebhookThreshold, "signed", cfg.RateWebhookSecret != "")
+	}
+
+	// live rate push over WebSocket - driven by refresh cycles, so writers only
+	var liveHub *live.Hub
+	if cfg.WSEnabled && !config.ReadOnlyMode {
+		liveHub = live.NewHub(exchangeSvc, currencyRegistry, live.Options{
+			MaxConnections:  cfg.WSMaxConnections,
+			MaxPairs:        cfg.WSMaxPairs,
+			ChangeThreshold: cfg.WSChangeThreshold,
+			PingInterval:    cfg.WSPingInterval,
+		})
+		rateCache.OnRefresh(liveHub.Publish)
+		slog.Info("WebSocket rate subscriptions enabled", "max_connections", cfg.WSMaxConnections, "change_threshold_pct", cfg.WSChangeThreshold)
+	}
+
+	// cache setup - auto refresh, hot pairs more often (writers only)
+	if !config.ReadOnlyMode {
+		rateCache.StartRefresh()
+		defer rateCache.Stop()
+		slog.Info("Background rate refresh started")
+	}
+
+	// handlers - optional features stay nil when disabled
+	api := v1Handlers{
+		exchange: handlers.NewExchangeHandler(exchangeSvc),
+		alerts:   alertHandler,
+	}
+	if warmup != nil && cfg.WarmupGateRequests {
+		api.warmup = warmup
+	}
+	// ETag and Cache-Control on rate responses, fresh until the pair's next refresh
+	api.exchange.SetRefreshSchedule(rateCache)
+	if cfg.ResolveCurrencyInput {
+		api.exchange.SetCurrencyResolver(currencyRegistry)
+	}
+
+	// conversion audit trail - a compliance requirement when configured, so a bad sink is fatal
+	var auditLog *audit.Logger
+	if cfg.AuditSink != "" {
+		auditSink, err := audit.NewSink(cfg.AuditSink, cfg.AuditPath)
+		if err != nil {
+			fatal("Invalid audit config", err)
+		}
+		auditLog = audit.NewLogger(auditSink, 1024)
+		auditLog.Start()
+		defer auditLog.Stop()
+		api.exchange.SetAuditor(auditLog)
+		slog.Info("Conversion audit log enabled", "sink", cfg.AuditSink)
+	}
+
+	// signed conversion receipts - asked for per request, or always for some tenants
+	if len(cfg.ReceiptSigningKeys) > 0 {
+		receiptSigner, err := receipts.NewSigner(cfg.ReceiptSigningKeys, cfg.ReceiptSigningKeyID)
+		if err != nil {
+			fatal("Invalid receipt signing config", err)
+		}
+		api.exchange.SetReceiptSigner(receiptSigner)
+		api.receipts = handlers.NewReceiptHandler(receiptSigner)
+		slog.Info("Signed conversion receipts enabled", "keys", len(cfg.ReceiptSigningKeys))
+	}
+
+	// Idempotency-Key replays live in the cache backend too, shared like quotes
+	if cfg.IdempotencyTTL > 0 {
+		api.idempotency = middleware.NewIdempotency(cache.NewIdempotencyStore(cacheBackend, cfg.CacheKeyPrefix, cfg.IdempotencyTTL))
+		slog.Info("Idempotency-Key support enabled", "ttl", cfg.IdempotencyTTL.String())
+	}
+
+	// quotes live in the cache backend, so with redis any replica can execute them
+	if cfg.QuoteValidity > 0 {
+		quoteSvc := services.NewQuoteService(exchangeSvc, cache.NewQuoteStore(cacheBackend, cfg.CacheKeyPrefix), cfg.QuoteValidity)
+		api.quotes = handlers.NewQuoteHandler(quoteSvc)
+		if auditLog != nil {
+			api.quotes.SetAuditor(auditLog)
+		}
+		if cfg.ResolveCurrencyInput {
+			api.quotes.SetCurrencyResolver(currencyRegistry)
+		}
+	}
+
+	graphqlHandler, err := graphqlapi.NewHandler(exchangeSvc)
+	if err != nil {
+		fatal("Invalid GraphQL schema", err)
+	}
+	if auditLog != nil {
+		graphqlHandler.SetAuditor(auditLog)
+	}
+
+	// JWT bearer auth with reader/admin roles, next to (or instead of) API keys
+	var jwtVerifier *auth.JWTVerifier
+	if cfg.JWTSecret != "" || cfg.JWTJWKSURL != "" {
+		verifier, err := auth.NewJWTVerifier(auth.JWTConfig{
+			Secret:      cfg.JWTSecret,
+			JWKSURL:     cfg.JWTJWKSURL,
+			Issuer:      cfg.JWTIssuer,
+			Audience:    cfg.JWTAudience,
+			RolesClaim:  cfg.JWTRolesClaim,
+			Leeway:      cfg.JWTLeeway,
+			JWKSRefresh: cfg.JWTJWKSRefresh,
+		})
+		if err != nil {
+			fatal("Invalid JWT config", err)
+		}
+		api.jwtAuth = middleware.NewJWTAuth(verifier, publicPaths...)
+		jwtVerifier = verifier
+	}
+
+	// operator endpoints - own bearer tokens (or admin JWTs), on top of the API key when that is enabled
+	adminAuth := middleware.NewAdminAuth(cfg.AdminTokens)
+	if adminAuth.Enabled() || api.jwtAuth != nil {
+		api.admin = handlers.NewAdminHandler(rateCache)
+		api.adminAuth = adminAuth
+		if auditLog != nil {
+			api.audit = handlers.NewAuditHandler(auditLog)
+		}
+		if rateStore != nil && backfillSource != nil {
+			backfillManager, err := backfill.NewManager(backfill.New(backfillSource, rateStore), cfg.BackfillStateFile)
+			if err != nil {
+				fatal("Failed to load backfill state", err)
+			}
+			backfillManager.Start()
+			defer backfillManager.Stop()
+			api.backfill = handlers.NewBackfillHandler(backfillManager)
+		}
+		// changes go through the writer, whose refresh cycles pick them up
+		if currencyManager != nil && !config.ReadOnlyMode {
+			api.currencies = handlers.NewCurrencyAdminHandler(currencyManager)
+		}
+		slog.Info("Admin API enabled", "tokens", len(cfg.AdminTokens), "jwt", api.jwtAuth != nil)
+	}
+
+	if rateStore != nil {
+		api.analytics = handlers.NewAnalyticsHandler(services.NewAnalyticsService(rateStore, currencyRegistry))
+	}
+
+	// end-of-day rate tables for reconciliation - taken from the cache, so replicas
+	// sharing redis can take them too (a second copy of the day replaces the first)
+	if rateStore != nil && cfg.DailySnapshotTime != "" {
+		location, err := time.LoadLocation(cfg.DailySnapshotTimezone)
+		if err != nil {
+			fatal("Invalid DAILY_SNAPSHOT_TIMEZONE", err)
+		}
+		archiver, err := archive.New(rateCache, rateStore, cfg.DailySnapshotTime, location)
+		if err != nil {
+			fatal("Invalid DAILY_SNAPSHOT_TIME", err)
+		}
+		archiver.Start()
+		defer archiver.Stop()
+		api.snapshots = handlers.NewSnapshotHandler(archiver)
+		slog.Info("Daily rate snapshots enabled", "time", cfg.DailySnapshotTime, "timezone", location.String())
+	}
 
-	// setup routes
 	router := mux.NewRouter()
-	setupRoutes(router, healthHandler, exchangeHandler)
+
+	// full request/response records. Registered before setupRoutes so it wraps
+	// recovery too: a panicking request is recorded with the 500 recovery
+	// writes, as are timeouts, shed load and auth failures
+	if cfg.RequestLogSink != "" {
+		requestSink, err := requestlog.NewSink(cfg.RequestLogSink, cfg.RequestLogPath)
+		if err != nil {
+			fatal("Invalid request log config", err)
+		}
+		requestLog := requestlog.NewLogger(requestSink, 1024)
+		requestLog.Start()
+		defer requestLog.Stop()
+		router.Use(requestlog.NewCapture(requestLog, requestlog.Options{
+			SampleRate:      cfg.RequestLogSampleRate,
+			ErrorSampleRate: cfg.RequestLogErrorSampleRate,
+			MaxBodyBytes:    cfg.RequestLogMaxBodyBytes,
+			RedactHeaders:   cfg.RequestLogRedactHeaders,
+			Exempt:          []string{"/health", "/health/live", "/health/ready", "/metrics", "/ws"},
+		}).Middleware)
+		slog.Info("Request log enabled", "sink", cfg.RequestLogSink,
+			"sample_rate", cfg.RequestLogSampleRate, "error_sample_rate", cfg.RequestLogErrorSampleRate)
+	}
+
+	// setup routes
+	setupRoutes(router, handlers.NewHealthHandler(healthSvc), handlers.NewStatsHandler(rateCache), api, cfg)
+
+	// GraphQL evolves by deprecating fields rather than by URL version, so it sits outside /v1
+	router.Handle("/graphql", api.requireReader(graphqlHandler)).Methods("POST")
+	if liveHub != nil {
+		router.Handle("/ws", api.requireReader(liveHub)).Methods("GET")
+	}
+
+	// a structured 504 for slow requests, before WriteTimeout cuts the connection
+	// (WebSockets live as long as the subscriber stays)
+	if cfg.RequestTimeout > 0 {
+		if cfg.WriteTimeout > 0 && cfg.RequestTimeout >= cfg.WriteTimeout {
+			slog.Warn("REQUEST_TIMEOUT is not below WRITE_TIMEOUT, slow requests will be cut off without a response",
+				"request_timeout", cfg.RequestTimeout.String(), "write_timeout", cfg.WriteTimeout.String())
+		}
+		router.Use(middleware.Timeout(cfg.RequestTimeout, "/ws"))
+		slog.Info("Request timeout enabled", "timeout", cfg.RequestTimeout.String())
+	}
+
+	// network access control - runs before any auth
+	ipAccess, err := middleware.NewIPAccessControl(cfg.IPAllowlist, cfg.IPDenylist, cfg.TrustedProxies)
+	if err != nil {
+		fatal("Invalid IP access control config", err)
+	}
+	if ipAccess.Enabled() {
+		router.Use(ipAccess.Middleware)
+		slog.Info("IP access control enabled", "allow_rules", len(cfg.IPAllowlist), "deny_rules", len(cfg.IPDenylist))
+	}
+
+	// optional HMAC request signing for partners
+	signer := middleware.NewRequestSigner(cfg.SigningSecrets, cfg.SigningRequired, cfg.SigningMaxSkew)
+	if signer.Enabled() {
+		router.Use(signer.Middleware)
+		slog.Info("HMAC request signing enabled", "keys", len(cfg.SigningSecrets), "required", cfg.SigningRequired)
+	}
+
+	// JWTs are verified before API keys, which callers with a valid token skip
+	if api.jwtAuth != nil {
+		router.Use(api.jwtAuth.Middleware)
+		slog.Info("JWT authentication enabled", "hmac", cfg.JWTSecret != "", "jwks_url", cfg.JWTJWKSURL, "roles_claim", cfg.JWTRolesClaim)
+	}
+
+	// API key auth + per-key rate limits (health, metrics and root stay public)
+	keyStore, err := auth.LoadKeyStore(cfg.APIKeys, cfg.APIKeysFile, cfg.APIKeyDefaultRPM)
+	if err != nil {
+		fatal("Failed to load API keys", err)
+	}
+	// per-key policies kept in the rate store win over the keys file's
+	if rateStore != nil && keyStore.Len() > 0 {
+		policyCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		policies, err := rateStore.TenantPolicies(policyCtx)
+		cancel()
+		if err != nil {
+			fatal("Failed to load tenant policies", err)
+		}
+		if err := keyStore.ApplyPolicies(policies); err != nil {
+			fatal("Invalid tenant policy", err)
+		}
+		if len(policies) > 0 {
+			slog.Info("Tenant policies loaded from the rate store", "tenants", len(policies))
+		}
+	}
+	var apiKeyAuth *middleware.APIKeyAuth
+	if keyStore.Len() > 0 {
+		apiKeyAuth = middleware.NewAPIKeyAuth(keyStore, publicPaths...)
+		router.Use(apiKeyAuth.Middleware)
+		slog.Info("API key authentication enabled", "keys", keyStore.Len())
+	}
+
+	// shed load before it reaches the providers. Registered after the access
+	// and auth checks, so denied or unauthenticated floods can't take the slots
+	// legitimate callers need - probes stay answerable and long-lived
+	// WebSockets don't hold slots
+	concurrency := middleware.NewConcurrencyLimit(cfg.MaxInFlightRequests, "/health", "/health/live", "/health/ready", "/metrics", "/ws")
+	for path, limit := range cfg.RouteConcurrencyLimits {
+		concurrency.Add("/v1"+path, limit)
+		if cfg.LegacyRoutesEnabled {
+			concurrency.Add(path, limit)
+		}
+	}
+	if concurrency.Enabled() {
+		router.Use(concurrency.Middleware)
+		slog.Info("Concurrency limits enabled", "max_in_flight", cfg.MaxInFlightRequests, "routes", cfg.RouteConcurrencyLimits)
+	}
+
+	// symbols and names become ISO codes before validation and the handlers see them
+	if cfg.ResolveCurrencyInput {
+		currencyInput := middleware.NewCurrencyInput(currencyRegistry)
+		for route, rule := range v1QueryRules {
+			method, path, _ := strings.Cut(route, " ")
+			currencyInput.Add(method, "/v1"+path, rule)
+			if cfg.LegacyRoutesEnabled {
+				currencyInput.Add(method, path, rule)
+			}
+		}
+		router.Use(currencyInput.Middleware)
+	}
+
+	// strict query checks run last, so unauthenticated callers learn nothing about the API
+	if cfg.StrictQueryValidation {
+		queryValidator := middleware.NewQueryValidator(decimal.NewFromFloat(cfg.MaxAmount))
+		for route, rule := range v1QueryRules {
+			method, path, _ := strings.Cut(route, " ")
+			queryValidator.Add(method, "/v1"+path, rule)
+			if cfg.LegacyRoutesEnabled {
+				queryValidator.Add(method, path, rule)
+			}
+		}
+		router.Use(queryValidator.Middleware)
+		slog.Info("Strict query validation enabled", "max_amount", cfg.MaxAmount)
+	}
+
+	// optional reverse proxy for tools that talk to the provider directly
+	// (never on replicas - they don't hold provider credentials)
+	if cfg.ProxyEnabled && !config.ReadOnlyMode {
+		providerProxy := client.NewProviderProxy(cfg.ProxyCacheTTL, cfg.ProxyRateLimitRPM)
+		defer providerProxy.Close()
+
+		proxyHandler := handlers.NewProxyHandler(providerProxy, "/proxy")
+		router.PathPrefix("/proxy/").Handler(api.requireReader(http.HandlerFunc(proxyHandler.Forward))).Methods("GET")
+		slog.Info("Provider proxy mode enabled", "cache_ttl", cfg.ProxyCacheTTL.String(), "upstream_rpm", cfg.ProxyRateLimitRPM)
+	}
 
 	// add root path handler to prevent 404
 	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -59,68 +617,438 @@ func main() {
 		w.Write([]byte("Exchange Rate Service is running! Visit /health for status."))
 	}).Methods("GET")
 
-	// http server config
+	// compression and CORS wrap the router: preflights carry no API key and
+	// would otherwise hit mux's 405 for routes registered as GET only
+	handler := http.Handler(router)
+	if cfg.CompressionEnabled {
+		handler = middleware.Compress(cfg.CompressionMinBytes)(handler)
+		slog.Info("Response compression enabled", "min_bytes", cfg.CompressionMinBytes)
+	}
+	cors, err := middleware.NewCORS(middleware.CORSOptions{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		ExposedHeaders:   cfg.CORSExposedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	})
+	if err != nil {
+		fatal("Invalid CORS config", err)
+	}
+	if cors.Enabled() {
+		handler = cors.Middleware(handler)
+		slog.Info("CORS enabled", "origins", cfg.CORSAllowedOrigins, "credentials", cfg.CORSAllowCredentials)
+	}
+
+	// http server config - request ids wrap everything so even 404s carry one
+	inFlight := &middleware.InFlight{}
 	srv := &http.Server{
 		Addr:         cfg.ServerAddress,
-		Handler:      router,
+		Handler:      middleware.RequestID(inFlight.Middleware(handler)),
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
 		IdleTimeout:  cfg.IdleTimeout,
 	}
 
+	// HTTPS (with HTTP/2) when a certificate source is configured
+	tlsOpts := certs.Options{
+		CertFile:          cfg.TLSCertFile,
+		KeyFile:           cfg.TLSKeyFile,
+		AutocertDomains:   cfg.TLSAutocertDomains,
+		AutocertEmail:     cfg.TLSAutocertEmail,
+		AutocertCacheDir:  cfg.TLSAutocertCacheDir,
+		AutocertDirectory: cfg.TLSAutocertDirectory,
+	}
+	var certProvider *certs.Provider
+	if tlsOpts.Enabled() {
+		// HTTP-01 challenges arrive over plain HTTP on port 80
+		if len(cfg.TLSAutocertDomains) > 0 && cfg.HTTPRedirectAddress == "" {
+			fatal("TLS_AUTOCERT_DOMAINS needs HTTP_REDIRECT_ADDRESS (usually :80) to answer ACME challenges", nil)
+		}
+		certProvider, err = certs.New(tlsOpts)
+		if err != nil {
+			fatal("Invalid TLS config", err)
+		}
+		srv.TLSConfig = certProvider.TLSConfig()
+	}
+
 	// start server
 	go func() {
-		log.Printf("Starting exchange rate service on %s", cfg.ServerAddress)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server startup failed: %v", err)
+		slog.Info("Starting exchange rate service", "address", cfg.ServerAddress, "tls", certProvider != nil)
+		var err error
+		if certProvider != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("Server startup failed", err)
 		}
 	}()
 
+	// plain HTTP listener that only redirects to HTTPS (and answers ACME challenges)
+	var redirectSrv *http.Server
+	if cfg.HTTPRedirectAddress != "" {
+		if certProvider == nil {
+			fatal("HTTP_REDIRECT_ADDRESS needs TLS_CERT_FILE/TLS_KEY_FILE or TLS_AUTOCERT_DOMAINS", nil)
+		}
+		redirectSrv = &http.Server{
+			Addr:         cfg.HTTPRedirectAddress,
+			Handler:      certProvider.HTTPHandler(certs.RedirectHandler(cfg.ServerAddress)),
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+		}
+		go func() {
+			slog.Info("Starting HTTP redirect listener", "address", cfg.HTTPRedirectAddress)
+			if err := redirectSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("HTTP redirect listener failed", err)
+			}
+		}()
+	}
+
+	// gRPC API on a second port - shares the service, IP lists, request signing, the API keys
+	// (and their budgets) and JWT auth with http
+	var grpcSrv *grpc.Server
+	if cfg.GRPCEnabled {
+		var grpcOpts grpcserver.Options
+		if ipAccess.Enabled() {
+			grpcOpts.Access = ipAccess
+		}
+		if signer.Enabled() {
+			grpcOpts.Signer = signer
+		}
+		if apiKeyAuth != nil {
+			grpcOpts.KeyAuth = apiKeyAuth
+		}
+		if jwtVerifier != nil {
+			grpcOpts.Tokens = jwtVerifier
+		}
+		if auditLog != nil {
+			grpcOpts.Auditor = auditLog
+		}
+		grpcSrv = grpcserver.NewGRPCServer(exchangeSvc, grpcOpts)
+		reflection.Register(grpcSrv)
+
+		listener, err := net.Listen("tcp", cfg.GRPCAddress)
+		if err != nil {
+			fatal("Failed to listen for gRPC", err, "address", cfg.GRPCAddress)
+		}
+
+		go func() {
+			slog.Info("Starting gRPC server", "address", cfg.GRPCAddress)
+			if err := grpcSrv.Serve(listener); err != nil {
+				fatal("gRPC server failed", err)
+			}
+		}()
+	}
+
 	// wait for shutdown signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	draining := inFlight.Count()
+	slog.Info("Shutting down server...", "in_flight", draining, "timeout", cfg.ShutdownTimeout.String())
 
 	// shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
+	if grpcSrv != nil {
+		stopGRPC(ctx, grpcSrv)
+	}
+
+	// Shutdown doesn't wait for hijacked connections - close them ourselves
+	if liveHub != nil {
+		liveHub.Close()
+	}
+
+	if redirectSrv != nil {
+		if err := redirectSrv.Shutdown(ctx); err != nil {
+			slog.Warn("HTTP redirect listener forced to shutdown", "error", err)
+		}
+	}
+
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		abandoned := inFlight.Count()
+		fatal("Server forced to shutdown", err, "drained", max(draining-abandoned, 0), "abandoned", abandoned)
+	}
+	slog.Info("In-flight requests drained", "drained", draining)
+
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
+	}
+
+	slog.Info("Server exited")
+}
+
+// fatal logs a startup/shutdown failure and exits
+func fatal(msg string, err error, args ...any) {
+	if err != nil {
+		args = append(args, "error", err)
+	}
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// v1Handlers are the handlers behind the /v1 API - nil ones are disabled features
+type v1Handlers struct {
+	exchange    *handlers.ExchangeHandler
+	analytics   *handlers.AnalyticsHandler
+	alerts      *handlers.AlertHandler
+	admin       *handlers.AdminHandler
+	audit       *handlers.AuditHandler
+	backfill    *handlers.BackfillHandler
+	currencies  *handlers.CurrencyAdminHandler
+	snapshots   *handlers.SnapshotHandler
+	quotes      *handlers.QuoteHandler
+	receipts    *handlers.ReceiptHandler
+	adminAuth   *middleware.AdminAuth
+	jwtAuth     *middleware.JWTAuth
+	warmup      middleware.Warmer // gates the reader routes during startup
+	idempotency *middleware.Idempotency
+}
+
+// publicPaths never need credentials
+var publicPaths = []string{"/", "/health", "/health/live", "/health/ready", "/metrics", "/stats", "/openapi.json", "/docs"}
+
+// requireReader guards h with the reader role when JWT auth is enabled
+func (api v1Handlers) requireReader(h http.Handler) http.Handler {
+	if api.jwtAuth == nil {
+		return h
 	}
+	return api.jwtAuth.RequireRole(auth.RoleReader)(h)
+}
 
-	log.Println("Server exited")
+// idempotent honors Idempotency-Key on h when enabled
+func (api v1Handlers) idempotent(h http.HandlerFunc) http.Handler {
+	if api.idempotency == nil {
+		return h
+	}
+	return api.idempotency.Middleware(h)
 }
 
-func setupRoutes(router *mux.Router, healthHandler *handlers.HealthHandler, exchangeHandler *handlers.ExchangeHandler) {
+// setupRoutes registers the unversioned operational endpoints and each API version
+// A breaking response change ships as a registerV2 on /v2 - v1 routes and handlers stay as they are
+func setupRoutes(router *mux.Router, healthHandler *handlers.HealthHandler, statsHandler *handlers.StatsHandler, api v1Handlers, cfg *config.Config) {
 	// health endpoint
 	router.HandleFunc("/health", healthHandler.CheckHealth).Methods("GET")
+	router.HandleFunc("/health/live", healthHandler.CheckLiveness).Methods("GET")
+	router.HandleFunc("/health/ready", healthHandler.CheckReadiness).Methods("GET")
 
-	// exchange endpoints
-	router.HandleFunc("/convert", exchangeHandler.Convert).Methods("GET")
-	router.HandleFunc("/rate/latest", exchangeHandler.GetLatestRate).Methods("GET")
-	router.HandleFunc("/rate/historical", exchangeHandler.GetHistoricalRate).Methods("GET")
+	// prometheus scrape endpoint, and the same cache counters as plain JSON
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	router.HandleFunc("/stats", statsHandler.GetStats).Methods("GET")
+
+	// API docs
+	router.Handle("/openapi.json", docs.SpecHandler()).Methods("GET")
+	router.Handle("/docs", docs.UIHandler()).Methods("GET")
+
+	registerV1(router.PathPrefix("/v1").Subrouter(), api)
+
+	// the pre-versioning paths keep serving v1 (flagged deprecated) until clients have moved
+	if cfg.LegacyRoutesEnabled {
+		legacy := router.NewRoute().Subrouter()
+		legacy.Use(middleware.LegacyAlias("/v1", cfg.LegacyRoutesSunset))
+		registerV1(legacy, api)
+	}
 
-	// middleware
-	router.Use(loggingMiddleware)
+	// middleware - tracing first so access log lines carry the trace id
+	router.Use(middleware.Tracing)
+	router.Use(middleware.AccessLog)
 	router.Use(recoveryMiddleware)
+	router.Use(middleware.Metrics)
 }
 
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		log.Printf("%s %s %v", r.Method, r.URL.Path, time.Since(start))
-	})
+// registerV1 adds the v1 API routes to r
+func registerV1(r *mux.Router, api v1Handlers) {
+	// everything but /admin is for readers; admin routes check their own role
+	// and stay usable while the cache warms up
+	reader := r
+	if api.jwtAuth != nil || api.warmup != nil {
+		reader = r.NewRoute().Subrouter()
+	}
+	if api.jwtAuth != nil {
+		reader.Use(api.jwtAuth.RequireRole(auth.RoleReader))
+	}
+	if api.warmup != nil {
+		reader.Use(middleware.WarmupGate(api.warmup))
+	}
+	registerReaderRoutes(reader, api)
+
+	if api.admin != nil {
+		adminRouter := r.PathPrefix("/admin").Subrouter()
+		adminRouter.Use(api.adminAuth.Middleware)
+		// replicas share the writer's cache and store - changes go through the writer
+		if config.ReadOnlyMode {
+			adminRouter.Use(middleware.ReadOnlyReplica)
+		}
+		adminRouter.HandleFunc("/cache/stats", api.admin.CacheStats).Methods("GET")
+		adminRouter.HandleFunc("/cache/{pair}", api.admin.EvictPair).Methods("DELETE")
+		adminRouter.HandleFunc("/cache/refresh", api.admin.RefreshCache).Methods("POST")
+		if api.audit != nil {
+			adminRouter.HandleFunc("/audit", api.audit.List).Methods("GET")
+		}
+		if api.backfill != nil {
+			adminRouter.HandleFunc("/backfill", api.backfill.Start).Methods("POST")
+			adminRouter.HandleFunc("/backfill", api.backfill.List).Methods("GET")
+			adminRouter.HandleFunc("/backfill/{id}", api.backfill.Get).Methods("GET")
+			adminRouter.HandleFunc("/backfill/{id}", api.backfill.Cancel).Methods("DELETE")
+		}
+		if api.currencies != nil {
+			adminRouter.HandleFunc("/currencies", api.currencies.Add).Methods("POST")
+			adminRouter.HandleFunc("/currencies/{code}", api.currencies.Remove).Methods("DELETE")
+		}
+	}
+}
+
+// registerReaderRoutes adds the rate, quote, analytics and alert routes
+func registerReaderRoutes(r *mux.Router, api v1Handlers) {
+	// exchange endpoints
+	r.HandleFunc("/convert", api.exchange.Convert).Methods("GET")
+	r.Handle("/convert", api.idempotent(api.exchange.ConvertBody)).Methods("POST")
+	r.HandleFunc("/convert/multi", api.exchange.ConvertMulti).Methods("GET")
+	r.HandleFunc("/convert/table", api.exchange.ConvertTable).Methods("GET")
+	r.HandleFunc("/rate/latest", api.exchange.GetLatestRate).Methods("GET")
+	r.HandleFunc("/rate/historical", api.exchange.GetHistoricalRate).Methods("GET")
+	r.HandleFunc("/rate/timeseries", api.exchange.GetTimeSeries).Methods("GET")
+	r.HandleFunc("/rate/stats", api.exchange.GetRateStats).Methods("GET")
+	r.HandleFunc("/rate/trend", api.exchange.GetRateTrend).Methods("GET")
+	r.HandleFunc("/rates", api.exchange.GetRateTable).Methods("GET")
+	r.HandleFunc("/format", api.exchange.Format).Methods("GET")
+	r.HandleFunc("/currencies", api.exchange.ListCurrencies).Methods("GET")
+
+	if api.snapshots != nil {
+		r.HandleFunc("/rate/snapshot", api.snapshots.Get).Methods("GET")
+	}
+
+	if api.quotes != nil {
+		r.Handle("/quote", api.idempotent(api.quotes.Create)).Methods("POST")
+		r.Handle("/quote/{id}/execute", api.idempotent(api.quotes.Execute)).Methods("POST")
+	}
+
+	if api.receipts != nil {
+		r.HandleFunc("/receipts/verify", api.receipts.Verify).Methods("POST")
+	}
+
+	if api.analytics != nil {
+		r.HandleFunc("/analytics/history", api.analytics.History).Methods("GET")
+		r.HandleFunc("/analytics/summary", api.analytics.Summary).Methods("GET")
+	}
+
+	if api.alerts != nil {
+		r.HandleFunc("/alerts", api.alerts.Create).Methods("POST")
+		r.HandleFunc("/alerts", api.alerts.List).Methods("GET")
+		r.HandleFunc("/alerts/{id}", api.alerts.Get).Methods("GET")
+		r.HandleFunc("/alerts/{id}", api.alerts.Update).Methods("PUT")
+		r.HandleFunc("/alerts/{id}", api.alerts.Delete).Methods("DELETE")
+	}
+}
+
+// v1QueryRules lists the query parameters each v1 route reads ("format"
+// wherever the response format is negotiated)
+var v1QueryRules = map[string]middleware.QueryRule{
+	"GET /convert": {
+		Params:     []string{"from", "to", "amount", "date", "locale", "receipt", "format"},
+		Required:   []string{"from", "to", "amount"},
+		Currencies: []string{"from", "to"},
+		Amounts:    []string{"amount"},
+	},
+	"POST /convert": {
+		Params: []string{"receipt", "format"},
+	},
+	"GET /convert/multi": {
+		Params:        []string{"from", "to", "amount", "date", "format"},
+		Required:      []string{"from", "to", "amount"},
+		Currencies:    []string{"from"},
+		CurrencyLists: []string{"to"},
+		Amounts:       []string{"amount"},
+	},
+	"GET /convert/table": {
+		Params:      []string{"from", "to", "amounts", "min", "max", "step", "date", "format"},
+		Required:    []string{"from", "to"},
+		Currencies:  []string{"from", "to"},
+		Amounts:     []string{"min", "max", "step"},
+		AmountLists: []string{"amounts"},
+	},
+	"GET /rate/latest": {
+		Params:     []string{"from", "to", "format"},
+		Required:   []string{"from", "to"},
+		Currencies: []string{"from", "to"},
+	},
+	"GET /rate/historical": {
+		Params:     []string{"from", "to", "date", "format"},
+		Required:   []string{"from", "to", "date"},
+		Currencies: []string{"from", "to"},
+	},
+	"GET /rate/timeseries": {
+		Params:     []string{"from", "to", "start", "end", "stream", "format"},
+		Required:   []string{"from", "to", "start", "end"},
+		Currencies: []string{"from", "to"},
+	},
+	"GET /rate/stats": {
+		Params:     []string{"from", "to", "period"},
+		Required:   []string{"from", "to"},
+		Currencies: []string{"from", "to"},
+	},
+	"GET /rates": {
+		Params:     []string{"base", "format"},
+		Currencies: []string{"base"},
+	},
+	"GET /rate/trend": {
+		Params:     []string{"from", "to"},
+		Required:   []string{"from", "to"},
+		Currencies: []string{"from", "to"},
+	},
+	"GET /format": {
+		Params:     []string{"currency", "amount", "locale"},
+		Required:   []string{"currency", "amount"},
+		Currencies: []string{"currency"},
+		Amounts:    []string{"amount"},
+	},
+	"GET /currencies": {},
+	"GET /rate/snapshot": {
+		Params:   []string{"date", "format"},
+		Required: []string{"date"},
+	},
+	"POST /quote":              {},
+	"POST /quote/{id}/execute": {},
+	"GET /analytics/history": {
+		Params:     []string{"from", "to", "start", "end"},
+		Required:   []string{"from", "to"},
+		Currencies: []string{"from", "to"},
+	},
+	"GET /analytics/summary": {
+		Params:     []string{"from", "to", "start", "end"},
+		Required:   []string{"from", "to"},
+		Currencies: []string{"from", "to"},
+	},
+	"GET /admin/audit": {
+		Params: []string{"start", "end", "key", "limit"},
+	},
+}
+
+// stopGRPC drains in-flight calls, cutting open streams off once ctx expires
+func stopGRPC(ctx context.Context, grpcSrv *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		grpcSrv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcSrv.Stop()
+	}
 }
 
 func recoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("Panic recovered: %v", err)
+				slog.ErrorContext(r.Context(), "Panic recovered", "path", r.URL.Path, "panic", err)
 				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			}
 		}()
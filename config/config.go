@@ -1,7 +1,7 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
@@ -12,21 +12,160 @@ import (
 const (
 	DefaultServerPort     = "8080"
 	MaxAllowedHistoryDays = 90
-	CacheRefreshInterval  = time.Hour
 	DefaultAPITimeout     = 15 * time.Second
 )
 
-// supported currencies
-// todo: move to db?
-var SupportedCurrencyList = []string{"USD", "INR", "EUR", "JPY", "GBP"}
+// CoreCurrencies are always supported (even when the provider's list can't be
+// fetched) and are the set the background refresh keeps warm in the cache
+var CoreCurrencies = []string{"USD", "INR", "EUR", "JPY", "GBP"}
+
+// CacheRefreshInterval is how often the background refresh re-fetches the core set
+var CacheRefreshInterval = time.Hour
+
+// HotPairs ("FROM-TO") are re-fetched every HotPairRefreshInterval on top of the
+// full cycle, for the handful of pairs that carry most of the traffic
+var (
+	HotPairs               []string
+	HotPairRefreshInterval = time.Minute
+)
+
+// CryptoAssets (crypto and precious metals) are quoted against RefreshBaseCurrency
+// every CryptoRefreshInterval by the asset providers. Their prices move far
+// faster than fiat, so cached quotes also go stale after CryptoCacheTTL and
+// are evicted after CryptoCacheMaxAge.
+var (
+	CryptoAssets          = []string{"BTC", "ETH", "XAU", "XAG"}
+	CryptoRefreshInterval = time.Minute
+	CryptoCacheTTL        = 2 * time.Minute
+	CryptoCacheMaxAge     time.Duration
+)
+
+// RefreshBaseCurrency is the one currency the refresh fetches quotes against -
+// every other pair is derived from those, so a cycle costs N-1 upstream calls
+var RefreshBaseCurrency = "USD"
+
+// RoutingCurrencies are the intermediates tried, in order, for a pair no
+// provider quotes directly - FROM-TO is priced as FROM-X times X-TO
+var RoutingCurrencies = []string{"USD", "EUR"}
+
+// ISO 4217 minor units for currencies that don't use 2 decimals
+// Converted amounts are rounded to these
+var currencyMinorUnits = map[string]int32{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"CLP": 0,
+	"ISK": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"JOD": 3,
+	"TND": 3,
+	// crypto and metals trade in fractions far below a cent
+	"BTC":  8,
+	"ETH":  8,
+	"SOL":  8,
+	"LTC":  8,
+	"XRP":  6,
+	"ADA":  6,
+	"DOGE": 6,
+	"USDT": 6,
+	"USDC": 6,
+	"XAU":  6,
+	"XAG":  4,
+}
+
+// default minor units for everything not listed above
+const defaultMinorUnits = 2
+
+// DeprecatedCurrencies maps currency code -> sunset date. Deprecated codes keep
+// working (with a warning) until the sunset, then get rejected.
+var DeprecatedCurrencies = map[string]time.Time{}
 
 // Global config variables - loaded once at startup
 var (
 	ExternalAPIBaseURL string
 	ExchangeRateAPIKey string
 	MaxHistoricalDays  int
+
+	// ExchangeRateAPIKeys is the pool requests rotate across (ExchangeRateAPIKey is
+	// the first one). A key that hits its quota sits out ExchangeRateAPIKeyCooldown.
+	ExchangeRateAPIKeys        []string
+	ExchangeRateAPIKeyCooldown time.Duration
+
+	// ReadOnlyMode - replica never calls the provider, only serves cached rates
+	ReadOnlyMode bool
+
+	// ServiceRegion is where this instance runs, used to prefer nearby provider endpoints
+	ServiceRegion     string
+	ProviderEndpoints []ProviderEndpoint
+
+	// ExchangeAPIHistoryEnabled - history endpoint needs a paid exchangerate-api plan
+	ExchangeAPIHistoryEnabled bool
+
+	// CacheTTL is how long a cached rate counts as fresh - older entries are only
+	// served (flagged stale) when the upstream is unavailable. Past CacheMaxAge
+	// an entry is evicted instead, so the next lookup has to fetch (0 = never)
+	CacheTTL    time.Duration
+	CacheMaxAge time.Duration
+
+	// DeriveInverseRates answers a latest-rate miss for FROM-TO as 1/rate when
+	// TO-FROM is cached and fresh, instead of calling the upstream
+	DeriveInverseRates bool
+
+	// PairFailureThreshold is how many refresh cycles in a row a pair may fail
+	// before it is backed off (0 disables); the backoff starts at
+	// PairBackoffInitial and doubles with every further failure up to PairBackoffMax
+	PairFailureThreshold int
+	PairBackoffInitial   time.Duration
+	PairBackoffMax       time.Duration
+
+	// HistoricalFallbackDays is how far back a historical lookup may go for the
+	// last business day's rate when the requested day has none (0 disables)
+	HistoricalFallbackDays int
+
+	// HistoricalCacheSize bounds the in-process LRU of past days' rates (0 disables it)
+	HistoricalCacheSize int
+
+	// TimeSeriesWorkers bounds concurrent per-day fetches for time series requests
+	TimeSeriesWorkers int
+
+	// RefreshWorkers bounds concurrent upstream fetches during a refresh cycle;
+	// RefreshRateLimitRPM paces them (0 = no pacing)
+	RefreshWorkers      int
+	RefreshRateLimitRPM int
+
+	// CurrencyRefreshInterval - how often the supported currency list is re-fetched (0 = core list only)
+	CurrencyRefreshInterval time.Duration
+
+	// circuit breaker around the exchangerate-api client - 0 threshold disables it
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+
+	// retries of failed provider calls: network errors, 5xx and 429 only. Delays
+	// double from the base up to the max, spread by ±jitter (a fraction)
+	ProviderRetryAttempts  int
+	ProviderRetryBaseDelay time.Duration
+	ProviderRetryMaxDelay  time.Duration
+	ProviderRetryJitter    float64
+
+	// RateProviders lists upstream providers in failover order
+	RateProviders      []string
+	FrankfurterBaseURL string
+	ECBBaseURL         string
+	CoinGeckoBaseURL   string
+	CoinGeckoAPIKey    string
+
+	// MockRatesFile replaces the built-in fixtures of the "mock" provider
+	MockRatesFile string
 )
 
+// ProviderEndpoint is a provider base URL tagged with the region it lives in
+type ProviderEndpoint struct {
+	Region  string
+	BaseURL string
+}
+
 // Config holds all configuration for the exchange rate service
 type Config struct {
 	ServerAddress string
@@ -34,6 +173,210 @@ type Config struct {
 	WriteTimeout  time.Duration
 	IdleTimeout   time.Duration
 	LogLevel      string
+	// RequestTimeout bounds each request until its response starts - a 504
+	// with a timeout error instead of WriteTimeout cutting the connection (0 disables)
+	RequestTimeout time.Duration
+	// ShutdownTimeout is how long shutdown waits for in-flight requests
+	ShutdownTimeout time.Duration
+
+	// DegradedModeEnabled serves cached rates only, with no upstream calls from
+	// requests, while no provider can be called - re-checked every
+	// DegradedModeCheckInterval (writers only)
+	DegradedModeEnabled       bool
+	DegradedModeCheckInterval time.Duration
+
+	// MaxInFlightRequests caps the requests served at once, RouteConcurrencyLimits
+	// the ones per route path (without /v1) - over a cap is a 503 (0 / empty disables)
+	MaxInFlightRequests    int
+	RouteConcurrencyLimits map[string]int
+
+	// HTTPS - a certificate/key pair from files, or ones obtained from an ACME
+	// CA for TLSAutocertDomains. Plain HTTP when neither is set
+	TLSCertFile          string
+	TLSKeyFile           string
+	TLSAutocertDomains   []string
+	TLSAutocertEmail     string
+	TLSAutocertCacheDir  string
+	TLSAutocertDirectory string
+	// HTTPRedirectAddress is a plain HTTP listener that redirects to HTTPS (and
+	// answers ACME challenges), off when empty
+	HTTPRedirectAddress string
+	LogFormat           string
+
+	// tracing - spans go to the OTLP/HTTP collector at OTLPEndpoint, off when empty
+	OTLPEndpoint     string
+	TraceServiceName string
+	TraceSampleRatio float64
+
+	// reverse proxy mode - lets internal tools hit the provider through us
+	ProxyEnabled      bool
+	ProxyCacheTTL     time.Duration
+	ProxyRateLimitRPM int
+
+	// network access control (CIDR lists)
+	IPAllowlist    []string
+	IPDenylist     []string
+	TrustedProxies []string
+
+	// HMAC request signing for partners (key id -> shared secret)
+	SigningSecrets  map[string]string
+	SigningRequired bool
+	SigningMaxSkew  time.Duration
+
+	// signed conversion receipts (key id -> secret), signed with ReceiptSigningKeyID
+	// - optional with a single key. Off when no keys are set
+	ReceiptSigningKeys  map[string]string
+	ReceiptSigningKeyID string
+
+	// rate cache backend - "memory" or "redis"
+	CacheBackend   string
+	CacheKeyPrefix string
+	RedisAddr      string
+	RedisPassword  string
+	RedisDB        int
+
+	// memory backend only - the cache is saved to CacheSnapshotFile every
+	// CacheSnapshotInterval and on shutdown, and reloaded on startup (off when empty)
+	CacheSnapshotFile     string
+	CacheSnapshotInterval time.Duration
+
+	// redis backend only - replicas elect one refresh leader through a lease
+	// that lapses RefreshLeaseTTL after the leader stops renewing it.
+	// InstanceID names this replica (hostname-pid when empty)
+	RefreshCoordination bool
+	RefreshLeaseTTL     time.Duration
+	InstanceID          string
+
+	// API key auth - enabled when any key is configured
+	APIKeys          []string
+	APIKeysFile      string
+	APIKeyDefaultRPM int
+
+	// gRPC API on its own port
+	GRPCEnabled bool
+	GRPCAddress string
+
+	// WebSocket rate subscriptions at /ws - pushed after each refresh when a
+	// rate moved at least WSChangeThreshold percent since the last push
+	WSEnabled         bool
+	WSMaxConnections  int
+	WSMaxPairs        int
+	WSChangeThreshold float64
+	WSPingInterval    time.Duration
+
+	// rate alerts - evaluated after each refresh, delivered by webhook
+	AlertsEnabled            bool
+	AlertsFile               string
+	AlertWebhookAllowPrivate bool
+
+	// rate change webhooks - after each refresh, every URL gets the pairs that
+	// moved at least RateWebhookThreshold percent since last sent. Pairs
+	// default to every core currency against RefreshBaseCurrency
+	RateWebhookURLs      []string
+	RateWebhookPairs     []string
+	RateWebhookThreshold float64
+	RateWebhookSecret    string
+
+	// /health/ready fails once the last successful refresh is older than this
+	ReadinessMaxRefreshAge time.Duration
+	// ...and once this many refresh cycles in a row updated nothing (0 = never)
+	ReadinessMaxRefreshFailures int
+	// how often each provider is pinged in the background (0 = never)
+	ProviderProbeInterval time.Duration
+
+	// startup warmup - /health/ready (and, with WarmupGateRequests, the rate
+	// endpoints) answer 503 until WarmupMinFraction of the core pairs are
+	// cached, or WarmupTimeout has passed (0 = no warmup phase)
+	WarmupMinFraction  float64
+	WarmupTimeout      time.Duration
+	WarmupGateRequests bool
+
+	// conversion markup - FeeDefault applies to every pair without its own
+	// entry in FeePairs ("FROM-TO" -> rule); rules look like "1.5%", "2" or "1.5%+2"
+	FeeDefault string
+	FeePairs   map[string]string
+
+	// market calendar - MarketTimezone is where the service's business day
+	// turns over, MarketTimezones gives currencies their own market's zone
+	// (code -> IANA zone) and MarketHolidays the days they don't trade
+	// (code or "*" -> "YYYY-MM-DD|YYYY-MM-DD")
+	MarketTimezone  string
+	MarketTimezones map[string]string
+	MarketHolidays  map[string]string
+
+	// bearer tokens for the /admin endpoints (disabled when empty)
+	AdminTokens []string
+
+	// JWT bearer auth - enabled by a shared HMAC secret and/or a JWKS URL.
+	// Tokens need the "reader" role (from JWTRolesClaim) for the rate
+	// endpoints and "admin" for /admin
+	JWTSecret      string
+	JWTJWKSURL     string
+	JWTIssuer      string
+	JWTAudience    string
+	JWTRolesClaim  string
+	JWTLeeway      time.Duration
+	JWTJWKSRefresh time.Duration
+
+	// rate history store - "sqlite", "postgres" or "none"
+	StorageDriver string
+	StorageDSN    string
+
+	// JSON file backfill jobs are saved to, so an interrupted job resumes
+	// after a restart (memory only when empty)
+	BackfillStateFile string
+
+	// daily snapshot of the cached rate table into the store, at HH:MM in
+	// DailySnapshotTimezone (off when the time is empty or there is no store)
+	DailySnapshotTime     string
+	DailySnapshotTimezone string
+
+	// conversion audit trail - "stdout", "file" or "sqlite" (off when empty);
+	// AuditPath is the file or database, defaulting per sink
+	AuditSink string
+	AuditPath string
+
+	// full request/response records for debugging disputes - "stdout" or
+	// "file" (off when empty), sampled per RequestLogSampleRate with 4xx/5xx
+	// answers sampled per RequestLogErrorSampleRate; credential headers and
+	// RequestLogRedactHeaders are masked
+	RequestLogSink            string
+	RequestLogPath            string
+	RequestLogSampleRate      float64
+	RequestLogErrorSampleRate float64
+	RequestLogMaxBodyBytes    int
+	RequestLogRedactHeaders   []string
+
+	// how long a POST /quote rate stays executable (0 disables quotes)
+	QuoteValidity time.Duration
+	// how long responses to POST /convert and /quote with an Idempotency-Key
+	// are kept for replay (0 ignores the header)
+	IdempotencyTTL time.Duration
+
+	// reject unknown query parameters, malformed currency codes and amounts
+	// above MaxAmount (0 = no cap) before a handler runs
+	StrictQueryValidation bool
+	MaxAmount             float64
+	// accept symbols and names ("€", "euro") for currency parameters
+	ResolveCurrencyInput bool
+
+	// gzip/deflate for responses of at least CompressionMinBytes
+	CompressionEnabled  bool
+	CompressionMinBytes int
+
+	// browser access from other origins (off when no origin is allowed);
+	// origins may be "*" or subdomain patterns like "https://*.example.com"
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSExposedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+
+	// pre-/v1 paths kept as deprecated aliases; the sunset date (zero = none)
+	// is announced in the Sunset header
+	LegacyRoutesEnabled bool
+	LegacyRoutesSunset  time.Time
 }
 
 // Load reads configuration from environment variables with sensible defaults
@@ -48,6 +391,141 @@ func Load() *Config {
 		WriteTimeout:  getDurationEnv("WRITE_TIMEOUT", DefaultAPITimeout),
 		IdleTimeout:   getDurationEnv("IDLE_TIMEOUT", 60*time.Second),
 		LogLevel:      getEnv("LOG_LEVEL", "info"),
+
+		RequestTimeout:  getDurationEnv("REQUEST_TIMEOUT", 10*time.Second),
+		ShutdownTimeout: getPositiveDurationEnv("SHUTDOWN_TIMEOUT", 30*time.Second),
+
+		DegradedModeEnabled:       getBoolEnv("DEGRADED_MODE_ENABLED", true),
+		DegradedModeCheckInterval: getPositiveDurationEnv("DEGRADED_MODE_CHECK_INTERVAL", time.Second),
+
+		MaxInFlightRequests:    getIntEnv("MAX_IN_FLIGHT_REQUESTS", 0),
+		RouteConcurrencyLimits: parseRouteLimits(getMapEnv("ROUTE_CONCURRENCY_LIMITS")),
+
+		TLSCertFile:          getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:           getEnv("TLS_KEY_FILE", ""),
+		TLSAutocertDomains:   getListEnv("TLS_AUTOCERT_DOMAINS"),
+		TLSAutocertEmail:     getEnv("TLS_AUTOCERT_EMAIL", ""),
+		TLSAutocertCacheDir:  getEnv("TLS_AUTOCERT_CACHE_DIR", "certs"),
+		TLSAutocertDirectory: getEnv("TLS_AUTOCERT_DIRECTORY", ""),
+		HTTPRedirectAddress:  getEnv("HTTP_REDIRECT_ADDRESS", ""),
+		LogFormat:            getEnv("LOG_FORMAT", "text"),
+
+		OTLPEndpoint:     getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TraceServiceName: getEnv("OTEL_SERVICE_NAME", "exchange-rate-service"),
+		TraceSampleRatio: getFloatEnv("TRACE_SAMPLE_RATIO", 1),
+
+		ProxyEnabled:      getBoolEnv("PROXY_MODE_ENABLED", false),
+		ProxyCacheTTL:     getDurationEnv("PROXY_CACHE_TTL", 10*time.Minute),
+		ProxyRateLimitRPM: getIntEnv("PROXY_RATE_LIMIT_RPM", 60),
+
+		IPAllowlist:    getListEnv("IP_ALLOWLIST"),
+		IPDenylist:     getListEnv("IP_DENYLIST"),
+		TrustedProxies: getListEnv("TRUSTED_PROXIES"),
+
+		SigningSecrets:  getMapEnv("HMAC_SIGNING_KEYS"),
+		SigningRequired: getBoolEnv("HMAC_SIGNING_REQUIRED", false),
+		SigningMaxSkew:  getDurationEnv("HMAC_SIGNING_MAX_SKEW", 5*time.Minute),
+
+		ReceiptSigningKeys:  getMapEnv("RECEIPT_SIGNING_KEYS"),
+		ReceiptSigningKeyID: getEnv("RECEIPT_SIGNING_KEY_ID", ""),
+
+		CacheBackend:   strings.ToLower(getEnv("CACHE_BACKEND", "memory")),
+		CacheKeyPrefix: getEnv("CACHE_KEY_PREFIX", "exchange-rates:"),
+		RedisAddr:      getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:  getEnv("REDIS_PASSWORD", ""),
+		RedisDB:        getIntEnv("REDIS_DB", 0),
+
+		CacheSnapshotFile:     getEnv("CACHE_SNAPSHOT_FILE", ""),
+		CacheSnapshotInterval: getPositiveDurationEnv("CACHE_SNAPSHOT_INTERVAL", 5*time.Minute),
+
+		RefreshCoordination: getBoolEnv("REFRESH_COORDINATION", true),
+		RefreshLeaseTTL:     getPositiveDurationEnv("REFRESH_LEASE_TTL", 30*time.Second),
+		InstanceID:          getEnv("INSTANCE_ID", ""),
+
+		APIKeys:          getListEnv("API_KEYS"),
+		APIKeysFile:      getEnv("API_KEYS_FILE", ""),
+		APIKeyDefaultRPM: getIntEnv("API_KEY_DEFAULT_RPM", 60),
+
+		GRPCEnabled: getBoolEnv("GRPC_ENABLED", true),
+		GRPCAddress: getEnv("GRPC_ADDRESS", ":9090"),
+
+		WSEnabled:         getBoolEnv("WS_ENABLED", true),
+		WSMaxConnections:  getIntEnv("WS_MAX_CONNECTIONS", 1000),
+		WSMaxPairs:        getIntEnv("WS_MAX_PAIRS", 50),
+		WSChangeThreshold: getFloatEnv("WS_CHANGE_THRESHOLD", 0),
+		WSPingInterval:    getPositiveDurationEnv("WS_PING_INTERVAL", 30*time.Second),
+
+		AlertsEnabled:            getBoolEnv("ALERTS_ENABLED", true),
+		AlertsFile:               getEnv("ALERTS_FILE", ""),
+		AlertWebhookAllowPrivate: getBoolEnv("ALERT_WEBHOOK_ALLOW_PRIVATE", false),
+
+		RateWebhookURLs:      getListEnv("RATE_WEBHOOK_URLS"),
+		RateWebhookPairs:     getListEnv("RATE_WEBHOOK_PAIRS"),
+		RateWebhookThreshold: getFloatEnv("RATE_WEBHOOK_THRESHOLD", 0.5),
+		RateWebhookSecret:    getEnv("RATE_WEBHOOK_SECRET", ""),
+
+		ReadinessMaxRefreshAge:      getDurationEnv("READINESS_MAX_REFRESH_AGE", 2*CacheRefreshInterval),
+		ReadinessMaxRefreshFailures: getIntEnv("READINESS_MAX_REFRESH_FAILURES", 3),
+		ProviderProbeInterval:       getDurationEnv("PROVIDER_PROBE_INTERVAL", 30*time.Second),
+
+		WarmupMinFraction:  getFloatEnv("WARMUP_MIN_FRACTION", 0.9),
+		WarmupTimeout:      getDurationEnv("WARMUP_TIMEOUT", 2*time.Minute),
+		WarmupGateRequests: getBoolEnv("WARMUP_GATE_REQUESTS", false),
+
+		FeeDefault: getEnv("FEE_DEFAULT", ""),
+		FeePairs:   getMapEnv("FEE_PAIRS"),
+
+		MarketTimezone:  getEnv("MARKET_TIMEZONE", "UTC"),
+		MarketTimezones: getMapEnv("MARKET_TIMEZONES"),
+		MarketHolidays:  getMapEnv("MARKET_HOLIDAYS"),
+
+		AdminTokens: getListEnv("ADMIN_TOKENS"),
+
+		JWTSecret:      getEnv("JWT_SECRET", ""),
+		JWTJWKSURL:     getEnv("JWT_JWKS_URL", ""),
+		JWTIssuer:      getEnv("JWT_ISSUER", ""),
+		JWTAudience:    getEnv("JWT_AUDIENCE", ""),
+		JWTRolesClaim:  getEnv("JWT_ROLES_CLAIM", "roles"),
+		JWTLeeway:      getDurationEnv("JWT_LEEWAY", 30*time.Second),
+		JWTJWKSRefresh: getPositiveDurationEnv("JWT_JWKS_REFRESH", time.Hour),
+
+		StorageDriver: strings.ToLower(getEnv("STORAGE_DRIVER", "sqlite")),
+		StorageDSN:    getEnv("STORAGE_DSN", "rates.db"),
+
+		BackfillStateFile: getEnv("BACKFILL_STATE_FILE", ""),
+
+		DailySnapshotTime:     getEnv("DAILY_SNAPSHOT_TIME", "23:59"),
+		DailySnapshotTimezone: getEnv("DAILY_SNAPSHOT_TIMEZONE", "UTC"),
+
+		AuditSink: strings.ToLower(getEnv("AUDIT_SINK", "")),
+		AuditPath: getEnv("AUDIT_PATH", ""),
+
+		RequestLogSink:            strings.ToLower(getEnv("REQUEST_LOG_SINK", "")),
+		RequestLogPath:            getEnv("REQUEST_LOG_PATH", ""),
+		RequestLogSampleRate:      getFloatEnv("REQUEST_LOG_SAMPLE_RATE", 0.1),
+		RequestLogErrorSampleRate: getFloatEnv("REQUEST_LOG_ERROR_SAMPLE_RATE", 1),
+		RequestLogMaxBodyBytes:    getIntEnv("REQUEST_LOG_MAX_BODY_BYTES", 8192),
+		RequestLogRedactHeaders:   getListEnv("REQUEST_LOG_REDACT_HEADERS"),
+
+		QuoteValidity:  getDurationEnv("QUOTE_TTL", 60*time.Second),
+		IdempotencyTTL: getDurationEnv("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		StrictQueryValidation: getBoolEnv("STRICT_QUERY_VALIDATION", true),
+		MaxAmount:             getFloatEnv("MAX_AMOUNT", 1e15),
+		ResolveCurrencyInput:  getBoolEnv("RESOLVE_CURRENCY_INPUT", true),
+
+		CompressionEnabled:  getBoolEnv("COMPRESSION_ENABLED", true),
+		CompressionMinBytes: getIntEnv("COMPRESSION_MIN_BYTES", 1024),
+
+		CORSAllowedOrigins:   getListEnv("CORS_ALLOWED_ORIGINS"),
+		CORSAllowedMethods:   getListEnvDefault("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+		CORSAllowedHeaders:   getListEnvDefault("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "X-API-Key", "X-Request-ID", "X-Signature", "X-Signature-Key-Id", "X-Signature-Timestamp", "Idempotency-Key"}),
+		CORSExposedHeaders:   getListEnvDefault("CORS_EXPOSED_HEADERS", []string{"X-Request-ID", "Warning", "Deprecation", "Sunset", "Link", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "ETag", "Idempotent-Replayed"}),
+		CORSAllowCredentials: getBoolEnv("CORS_ALLOW_CREDENTIALS", false),
+		CORSMaxAge:           getDurationEnv("CORS_MAX_AGE", 10*time.Minute),
+
+		LegacyRoutesEnabled: getBoolEnv("LEGACY_ROUTES_ENABLED", true),
+		LegacyRoutesSunset:  getDateEnv("LEGACY_ROUTES_SUNSET"),
 	}
 }
 
@@ -55,12 +533,86 @@ func Load() *Config {
 // Keeping this separate since these values are used across multiple packages
 func initializeGlobalConfig() {
 	ExternalAPIBaseURL = getEnv("EXCHANGE_API_BASE_URL", "https://v6.exchangerate-api.com/v6")
-	ExchangeRateAPIKey = getEnv("EXCHANGE_API_KEY", "dc07747379a8a53ee8d3243c")
+	// EXCHANGE_API_KEYS wins; EXCHANGE_API_KEY may also hold a comma separated list
+	ExchangeRateAPIKeys = getListEnv("EXCHANGE_API_KEYS")
+	if len(ExchangeRateAPIKeys) == 0 {
+		ExchangeRateAPIKeys = getListEnv("EXCHANGE_API_KEY")
+	}
+	if len(ExchangeRateAPIKeys) == 0 {
+		ExchangeRateAPIKeys = []string{"dc07747379a8a53ee8d3243c"}
+	}
+	ExchangeRateAPIKey = ExchangeRateAPIKeys[0]
+	ExchangeRateAPIKeyCooldown = getPositiveDurationEnv("EXCHANGE_API_KEY_COOLDOWN", time.Hour)
 	MaxHistoricalDays = getIntEnv("MAX_HISTORICAL_DAYS", MaxAllowedHistoryDays)
+	ReadOnlyMode = getBoolEnv("READ_ONLY_MODE", false)
+	ServiceRegion = getEnv("SERVICE_REGION", "default")
+	ProviderEndpoints = parseProviderEndpoints(getEnv("PROVIDER_ENDPOINTS", ""), ServiceRegion, ExternalAPIBaseURL)
+	DeprecatedCurrencies = parseDeprecations(getMapEnv("DEPRECATED_CURRENCIES"))
 
-	// Basic validation - we need these to work
-	if ExchangeRateAPIKey == "" {
-		log.Fatal("EXCHANGE_API_KEY environment variable is required")
+	ExchangeAPIHistoryEnabled = getBoolEnv("EXCHANGE_API_HISTORY_ENABLED", false)
+	TimeSeriesWorkers = getIntEnv("TIMESERIES_WORKERS", 4)
+	HistoricalCacheSize = getIntEnv("HISTORICAL_CACHE_SIZE", 10000)
+	HistoricalFallbackDays = getIntEnv("HISTORICAL_FALLBACK_DAYS", 4)
+	RefreshWorkers = getIntEnv("REFRESH_WORKERS", 4)
+	RefreshRateLimitRPM = getIntEnv("REFRESH_RATE_LIMIT_RPM", 0)
+	CacheRefreshInterval = getPositiveDurationEnv("CACHE_REFRESH_INTERVAL", time.Hour)
+	HotPairs = getListEnv("HOT_PAIRS")
+	HotPairRefreshInterval = getPositiveDurationEnv("HOT_PAIR_REFRESH_INTERVAL", time.Minute)
+	CacheTTL = getDurationEnv("CACHE_TTL", 2*CacheRefreshInterval)
+	CacheMaxAge = maxAgeAtLeastTTL("CACHE_MAX_AGE", getDurationEnv("CACHE_MAX_AGE", 24*time.Hour), CacheTTL)
+	DeriveInverseRates = getBoolEnv("DERIVE_INVERSE_RATES", true)
+	PairFailureThreshold = getIntEnv("PAIR_FAILURE_THRESHOLD", 3)
+	PairBackoffInitial = getPositiveDurationEnv("PAIR_BACKOFF_INITIAL", 5*time.Minute)
+	PairBackoffMax = getPositiveDurationEnv("PAIR_BACKOFF_MAX", 24*time.Hour)
+	CurrencyRefreshInterval = getDurationEnv("CURRENCY_REFRESH_INTERVAL", 24*time.Hour)
+	if core := getListEnv("CORE_CURRENCIES"); len(core) > 0 {
+		CoreCurrencies = core
+	}
+	RefreshBaseCurrency = strings.ToUpper(strings.TrimSpace(getEnv("REFRESH_BASE_CURRENCY", "USD")))
+	CircuitBreakerThreshold = getIntEnv("CIRCUIT_BREAKER_THRESHOLD", 5)
+	CircuitBreakerCooldown = getDurationEnv("CIRCUIT_BREAKER_COOLDOWN", 30*time.Second)
+	ProviderRetryAttempts = getIntEnv("PROVIDER_RETRY_ATTEMPTS", 2)
+	ProviderRetryBaseDelay = getDurationEnv("PROVIDER_RETRY_BASE_DELAY", 500*time.Millisecond)
+	ProviderRetryMaxDelay = getDurationEnv("PROVIDER_RETRY_MAX_DELAY", 5*time.Second)
+	ProviderRetryJitter = getFloatEnv("PROVIDER_RETRY_JITTER", 0.2)
+	if ProviderRetryJitter < 0 || ProviderRetryJitter > 1 {
+		slog.Warn("PROVIDER_RETRY_JITTER must be between 0 and 1, using 0.2", "value", ProviderRetryJitter)
+		ProviderRetryJitter = 0.2
+	}
+
+	RateProviders = getListEnv("RATE_PROVIDERS")
+	if len(RateProviders) == 0 {
+		RateProviders = []string{"exchangerate-api", "frankfurter", "ecb", "coingecko"}
+	}
+	// PROVIDER=mock is shorthand for serving fixtures only - no key, no network
+	if strings.EqualFold(strings.TrimSpace(getEnv("PROVIDER", "")), "mock") {
+		RateProviders = []string{"mock"}
+	}
+	MockRatesFile = getEnv("MOCK_RATES_FILE", "")
+	FrankfurterBaseURL = getEnv("FRANKFURTER_BASE_URL", "https://api.frankfurter.app")
+	ECBBaseURL = getEnv("ECB_BASE_URL", "https://www.ecb.europa.eu/stats/eurofxref")
+	CoinGeckoBaseURL = getEnv("COINGECKO_BASE_URL", "https://api.coingecko.com/api/v3")
+	CoinGeckoAPIKey = getEnv("COINGECKO_API_KEY", "")
+
+	// set but empty turns crypto and metals off
+	if _, set := os.LookupEnv("CRYPTO_ASSETS"); set {
+		CryptoAssets = getListEnv("CRYPTO_ASSETS")
+	}
+	// set but empty turns multi-hop routing off
+	if _, set := os.LookupEnv("ROUTING_CURRENCIES"); set {
+		RoutingCurrencies = getListEnv("ROUTING_CURRENCIES")
+	}
+	for i, code := range RoutingCurrencies {
+		RoutingCurrencies[i] = strings.ToUpper(code)
+	}
+	CryptoRefreshInterval = getPositiveDurationEnv("CRYPTO_REFRESH_INTERVAL", time.Minute)
+	CryptoCacheTTL = getDurationEnv("CRYPTO_CACHE_TTL", 2*CryptoRefreshInterval)
+	CryptoCacheMaxAge = maxAgeAtLeastTTL("CRYPTO_CACHE_MAX_AGE", getDurationEnv("CRYPTO_CACHE_MAX_AGE", time.Hour), CryptoCacheTTL)
+
+	// Basic validation - we need these to work (replicas never use the key)
+	if ExchangeRateAPIKey == "" && !ReadOnlyMode {
+		slog.Error("EXCHANGE_API_KEY environment variable is required")
+		os.Exit(1)
 	}
 }
 
@@ -82,6 +634,27 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getPositiveDurationEnv is getDurationEnv for intervals that drive a ticker,
+// where zero or negative values would panic
+func getPositiveDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	duration := getDurationEnv(key, defaultValue)
+	if duration <= 0 {
+		slog.Warn("Ignoring non-positive interval", "key", key, "default", defaultValue.String())
+		return defaultValue
+	}
+	return duration
+}
+
+// maxAgeAtLeastTTL keeps a cache max age from undercutting the soft TTL -
+// entries would be evicted before they could ever be served stale
+func maxAgeAtLeastTTL(key string, maxAge, ttl time.Duration) time.Duration {
+	if maxAge > 0 && maxAge < ttl {
+		slog.Warn("Cache max age below its TTL, raising it to the TTL", "key", key, "ttl", ttl.String())
+		return ttl
+	}
+	return maxAge
+}
+
 // getIntEnv retrieves integer environment variable or returns default
 // Added this helper since we need it for MaxHistoricalDays config
 func getIntEnv(key string, defaultValue int) int {
@@ -93,29 +666,158 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
-// IsSupportedCurrency validates whether a currency code is in our supported list
-// We normalize the input to handle different cases and whitespace
-func IsSupportedCurrency(code string) bool {
-	cleanCode := strings.ToUpper(strings.TrimSpace(code))
+// getFloatEnv retrieves float environment variable or returns default
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
 
-	// Quick check for empty input
-	if cleanCode == "" {
-		return false
+// getBoolEnv retrieves boolean environment variable or returns default
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
 	}
+	return defaultValue
+}
 
-	// Linear search is fine for our small currency list
-	for _, supportedCode := range SupportedCurrencyList {
-		if supportedCode == cleanCode {
-			return true
+// getDateEnv parses a YYYY-MM-DD variable, zero when unset or invalid
+func getDateEnv(key string) time.Time {
+	value := os.Getenv(key)
+	if value == "" {
+		return time.Time{}
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		slog.Warn("Ignoring invalid date (expected YYYY-MM-DD)", "key", key, "value", value)
+		return time.Time{}
+	}
+	return date
+}
+
+// getListEnv retrieves a comma separated list, skipping empty items
+func getListEnv(key string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getListEnvDefault is getListEnv with a fallback for an unset or empty variable
+func getListEnvDefault(key string, defaultValue []string) []string {
+	if items := getListEnv(key); len(items) > 0 {
+		return items
+	}
+	return defaultValue
+}
+
+// getMapEnv parses "id:value,id:value" into a map
+func getMapEnv(key string) map[string]string {
+	values := make(map[string]string)
+	for _, item := range getListEnv(key) {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			slog.Warn("Ignoring malformed entry (expected id:value)", "key", key)
+			continue
+		}
+		values[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return values
+}
+
+// parseRouteLimits parses "/rate/timeseries:10" entries into route path -> limit
+func parseRouteLimits(raw map[string]string) map[string]int {
+	limits := make(map[string]int)
+	for path, value := range raw {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || !strings.HasPrefix(path, "/") {
+			slog.Warn("Ignoring invalid route concurrency limit", "route", path, "limit", value)
+			continue
+		}
+		limits[path] = limit
+	}
+	return limits
+}
+
+// parseProviderEndpoints parses "region=url,region=url" - falls back to the
+// single base URL tagged with our own region when nothing is configured
+func parseProviderEndpoints(raw, defaultRegion, defaultURL string) []ProviderEndpoint {
+	endpoints := make([]ProviderEndpoint, 0)
+
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			slog.Warn("Ignoring malformed provider endpoint (expected region=url)", "entry", item)
+			continue
+		}
+
+		endpoints = append(endpoints, ProviderEndpoint{
+			Region:  strings.TrimSpace(parts[0]),
+			BaseURL: strings.TrimRight(strings.TrimSpace(parts[1]), "/"),
+		})
+	}
+
+	if len(endpoints) == 0 {
+		endpoints = append(endpoints, ProviderEndpoint{Region: defaultRegion, BaseURL: defaultURL})
+	}
+
+	return endpoints
+}
+
+// parseDeprecations turns code -> "YYYY-MM-DD" into sunset times
+func parseDeprecations(raw map[string]string) map[string]time.Time {
+	deprecations := make(map[string]time.Time)
+	for code, dateStr := range raw {
+		sunset, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			slog.Warn("Ignoring deprecation with invalid sunset date", "currency", code, "date", dateStr)
+			continue
 		}
+		deprecations[strings.ToUpper(code)] = sunset
+	}
+	return deprecations
+}
+
+// GetCurrencySunset returns the sunset date if the currency is deprecated
+func GetCurrencySunset(code string) (time.Time, bool) {
+	sunset, deprecated := DeprecatedCurrencies[strings.ToUpper(strings.TrimSpace(code))]
+	return sunset, deprecated
+}
+
+// IsCurrencySunset reports whether a deprecated currency is past its sunset date
+func IsCurrencySunset(code string) bool {
+	sunset, deprecated := GetCurrencySunset(code)
+	return deprecated && !time.Now().Before(sunset)
+}
+
+// GetMinorUnits returns how many decimals amounts in this currency are rounded to
+func GetMinorUnits(code string) int32 {
+	if units, found := currencyMinorUnits[strings.ToUpper(strings.TrimSpace(code))]; found {
+		return units
 	}
-	return false
+	return defaultMinorUnits
 }
 
-// GetSupportedCurrencies returns a copy of the supported currency list
-// Using a function to prevent external modification of our internal slice
-func GetSupportedCurrencies() []string {
-	currencies := make([]string, len(SupportedCurrencyList))
-	copy(currencies, SupportedCurrencyList)
+// GetCoreCurrencies returns a normalized copy of the core currency list
+func GetCoreCurrencies() []string {
+	currencies := make([]string, 0, len(CoreCurrencies))
+	for _, code := range CoreCurrencies {
+		if cleanCode := strings.ToUpper(strings.TrimSpace(code)); cleanCode != "" {
+			currencies = append(currencies, cleanCode)
+		}
+	}
 	return currencies
 }
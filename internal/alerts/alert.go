@@ -0,0 +1,100 @@
+package alerts
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+)
+
+// alert directions
+const (
+	DirectionAbove = "above"
+	DirectionBelow = "below"
+)
+
+// Alert fires a webhook when a pair's rate crosses Threshold in Direction.
+// It is edge triggered: after firing it re-arms only once the rate is back on
+// the other side of the threshold, so a rate sitting above it doesn't spam.
+type Alert struct {
+	ID          string    `json:"id"`
+	Owner       string    `json:"-"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Threshold   float64   `json:"threshold"`
+	Direction   string    `json:"direction"`
+	CallbackURL string    `json:"callback_url"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// evaluation state
+	Triggered       bool       `json:"triggered"`
+	LastRate        float64    `json:"last_rate,omitempty"`
+	LastEvaluated   *time.Time `json:"last_evaluated,omitempty"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+}
+
+// storedAlert keeps the owner when persisting (it's hidden from API responses)
+type storedAlert struct {
+	Alert
+	Owner string `json:"owner,omitempty"`
+}
+
+// AlertRequest is the body of POST /alerts and PUT /alerts/{id}
+type AlertRequest struct {
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	Threshold   float64 `json:"threshold"`
+	Direction   string  `json:"direction"`
+	CallbackURL string  `json:"callback_url"`
+}
+
+// Notification is the JSON payload POSTed to the callback URL
+type Notification struct {
+	AlertID     string    `json:"alert_id"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Threshold   float64   `json:"threshold"`
+	Direction   string    `json:"direction"`
+	Rate        float64   `json:"rate"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// normalize cleans up the request fields
+func (r *AlertRequest) normalize() {
+	r.From = strings.ToUpper(strings.TrimSpace(r.From))
+	r.To = strings.ToUpper(strings.TrimSpace(r.To))
+	r.Direction = strings.ToLower(strings.TrimSpace(r.Direction))
+	r.CallbackURL = strings.TrimSpace(r.CallbackURL)
+}
+
+// validate checks everything except currency support (the manager does that)
+func (r *AlertRequest) validate() error {
+	if r.From == "" || r.To == "" {
+		return apperrors.New(apperrors.CodeInvalidRequest, "from and to are required")
+	}
+	if r.From == r.To {
+		return apperrors.New(apperrors.CodeInvalidRequest, "from and to must be different currencies")
+	}
+	if r.Threshold <= 0 {
+		return apperrors.New(apperrors.CodeInvalidRequest, "threshold must be positive")
+	}
+	if r.Direction != DirectionAbove && r.Direction != DirectionBelow {
+		return apperrors.New(apperrors.CodeInvalidRequest, "direction must be %q or %q", DirectionAbove, DirectionBelow)
+	}
+
+	callback, err := url.Parse(r.CallbackURL)
+	if err != nil || (callback.Scheme != "http" && callback.Scheme != "https") || callback.Host == "" {
+		return apperrors.New(apperrors.CodeInvalidRequest, "callback_url must be an absolute http(s) URL")
+	}
+
+	return nil
+}
+
+// conditionMet reports whether rate is on the alerting side of the threshold
+func (a *Alert) conditionMet(rate float64) bool {
+	if a.Direction == DirectionAbove {
+		return rate > a.Threshold
+	}
+	return rate < a.Threshold
+}
@@ -0,0 +1,221 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/utils"
+)
+
+// RateSource gives us the current rate for a pair
+type RateSource interface {
+	GetLatestRate(ctx context.Context, from, to string) (models.RateQuote, error)
+}
+
+// CurrencyChecker tells us which currency codes we accept
+type CurrencyChecker interface {
+	IsSupported(code string) bool
+}
+
+// Sender delivers a notification to a callback URL
+type Sender interface {
+	Notify(callbackURL string, notification Notification)
+}
+
+// Manager owns alert CRUD and evaluates alerts against current rates
+type Manager struct {
+	store      *Store
+	rates      RateSource
+	currencies CurrencyChecker
+	sender     Sender
+}
+
+// NewManager creates an alert manager
+func NewManager(store *Store, rates RateSource, currencies CurrencyChecker, sender Sender) *Manager {
+	return &Manager{
+		store:      store,
+		rates:      rates,
+		currencies: currencies,
+		sender:     sender,
+	}
+}
+
+// Create registers a new alert for owner
+func (m *Manager) Create(owner string, req AlertRequest) (Alert, error) {
+	if err := m.validate(&req); err != nil {
+		return Alert{}, err
+	}
+
+	id, err := utils.RandomID(8)
+	if err != nil {
+		return Alert{}, apperrors.Wrap(apperrors.CodeInternal, err, "failed to create alert")
+	}
+
+	alert := Alert{
+		ID:          id,
+		Owner:       owner,
+		From:        req.From,
+		To:          req.To,
+		Threshold:   req.Threshold,
+		Direction:   req.Direction,
+		CallbackURL: req.CallbackURL,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := m.store.Save(alert); err != nil {
+		return Alert{}, apperrors.Wrap(apperrors.CodeInternal, err, "failed to save alert")
+	}
+
+	return alert, nil
+}
+
+// Update replaces an alert's settings and re-arms it
+func (m *Manager) Update(owner, id string, req AlertRequest) (Alert, error) {
+	alert, err := m.Get(owner, id)
+	if err != nil {
+		return Alert{}, err
+	}
+	if err := m.validate(&req); err != nil {
+		return Alert{}, err
+	}
+
+	alert.From = req.From
+	alert.To = req.To
+	alert.Threshold = req.Threshold
+	alert.Direction = req.Direction
+	alert.CallbackURL = req.CallbackURL
+	alert.Triggered = false
+	alert.LastRate = 0
+	alert.LastEvaluated = nil
+
+	if err := m.store.Save(alert); err != nil {
+		return Alert{}, apperrors.Wrap(apperrors.CodeInternal, err, "failed to save alert")
+	}
+
+	return alert, nil
+}
+
+// Get returns one of owner's alerts - other owners' alerts look like they don't exist
+func (m *Manager) Get(owner, id string) (Alert, error) {
+	alert, found := m.store.Get(id)
+	if !found || alert.Owner != owner {
+		return Alert{}, apperrors.New(apperrors.CodeNotFound, "alert not found: %s", id)
+	}
+	return alert, nil
+}
+
+// List returns owner's alerts
+func (m *Manager) List(owner string) []Alert {
+	alerts := m.store.List("")
+	owned := make([]Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		if alert.Owner == owner {
+			owned = append(owned, alert)
+		}
+	}
+	return owned
+}
+
+// Delete removes one of owner's alerts
+func (m *Manager) Delete(owner, id string) error {
+	if _, err := m.Get(owner, id); err != nil {
+		return err
+	}
+	if err := m.store.Delete(id); err != nil {
+		return apperrors.Wrap(apperrors.CodeInternal, err, "failed to delete alert")
+	}
+	return nil
+}
+
+// Evaluate checks every alert against the current rate and notifies the ones
+// that just crossed their threshold. Meant to run after each cache refresh.
+func (m *Manager) Evaluate(ctx context.Context) {
+	alerts := m.store.List("")
+	if len(alerts) == 0 {
+		return
+	}
+
+	// several alerts usually watch the same pair - look each one up once
+	rates := make(map[string]float64)
+	fired := 0
+
+	for _, alert := range alerts {
+		if ctx.Err() != nil {
+			return
+		}
+
+		pair := alert.From + "-" + alert.To
+		rate, seen := rates[pair]
+		if !seen {
+			quote, err := m.rates.GetLatestRate(ctx, alert.From, alert.To)
+			if err != nil {
+				if !errors.Is(err, context.Canceled) {
+					slog.WarnContext(ctx, "Skipping alerts for pair", "pair", pair, "error", err)
+				}
+				continue
+			}
+			rate = quote.Rate
+			rates[pair] = rate
+		}
+
+		now := time.Now().UTC()
+		met := alert.conditionMet(rate)
+		fire := met && !alert.Triggered
+
+		alert.Triggered = met
+		alert.LastRate = rate
+		alert.LastEvaluated = &now
+		if fire {
+			alert.LastTriggeredAt = &now
+		}
+
+		// the alert may have been deleted or edited while we were fetching
+		if current, found := m.store.Get(alert.ID); !found || !sameSettings(current, alert) {
+			continue
+		}
+		if err := m.store.Save(alert); err != nil {
+			slog.ErrorContext(ctx, "Failed to save alert state", "alert_id", alert.ID, "error", err)
+		}
+
+		if fire {
+			fired++
+			m.sender.Notify(alert.CallbackURL, Notification{
+				AlertID:     alert.ID,
+				From:        alert.From,
+				To:          alert.To,
+				Threshold:   alert.Threshold,
+				Direction:   alert.Direction,
+				Rate:        rate,
+				TriggeredAt: now,
+			})
+		}
+	}
+
+	if fired > 0 {
+		slog.InfoContext(ctx, "Alert evaluation done", "triggered", fired, "alerts", len(alerts))
+	}
+}
+
+// validate normalizes and checks a request
+func (m *Manager) validate(req *AlertRequest) error {
+	req.normalize()
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	for _, code := range []string{req.From, req.To} {
+		if !m.currencies.IsSupported(code) {
+			return apperrors.New(apperrors.CodeUnsupportedCurrency, "unsupported currency: %s", code)
+		}
+	}
+	return nil
+}
+
+// sameSettings reports whether two versions of an alert watch the same thing
+func sameSettings(a, b Alert) bool {
+	return a.From == b.From && a.To == b.To && a.Threshold == b.Threshold &&
+		a.Direction == b.Direction && a.CallbackURL == b.CallbackURL
+}
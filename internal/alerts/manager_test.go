@@ -0,0 +1,177 @@
+package alerts
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+)
+
+type fakeRates struct {
+	mu    sync.Mutex
+	rates map[string]float64
+}
+
+func (f *fakeRates) set(pair string, rate float64) {
+	f.mu.Lock()
+	f.rates[pair] = rate
+	f.mu.Unlock()
+}
+
+func (f *fakeRates) GetLatestRate(ctx context.Context, from, to string) (models.RateQuote, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return models.RateQuote{Rate: f.rates[from+"-"+to]}, nil
+}
+
+type allCurrencies struct{}
+
+func (allCurrencies) IsSupported(code string) bool { return code != "XXX" }
+
+type recordingSender struct {
+	sent []Notification
+}
+
+func (r *recordingSender) Notify(callbackURL string, notification Notification) {
+	r.sent = append(r.sent, notification)
+}
+
+func newTestManager(t *testing.T, path string) (*Manager, *fakeRates, *recordingSender) {
+	t.Helper()
+
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	rates := &fakeRates{rates: map[string]float64{"USD-INR": 83}}
+	sender := &recordingSender{}
+	return NewManager(store, rates, allCurrencies{}, sender), rates, sender
+}
+
+func validRequest() AlertRequest {
+	return AlertRequest{From: "usd", To: "inr", Threshold: 84, Direction: "Above", CallbackURL: "https://example.com/hook"}
+}
+
+func TestManager_CreateValidates(t *testing.T) {
+	manager, _, _ := newTestManager(t, "")
+
+	tests := []struct {
+		name   string
+		modify func(*AlertRequest)
+		code   apperrors.Code
+	}{
+		{"same currency", func(r *AlertRequest) { r.To = "USD" }, apperrors.CodeInvalidRequest},
+		{"zero threshold", func(r *AlertRequest) { r.Threshold = 0 }, apperrors.CodeInvalidRequest},
+		{"bad direction", func(r *AlertRequest) { r.Direction = "sideways" }, apperrors.CodeInvalidRequest},
+		{"relative callback", func(r *AlertRequest) { r.CallbackURL = "/hook" }, apperrors.CodeInvalidRequest},
+		{"ftp callback", func(r *AlertRequest) { r.CallbackURL = "ftp://example.com" }, apperrors.CodeInvalidRequest},
+		{"unsupported currency", func(r *AlertRequest) { r.To = "XXX" }, apperrors.CodeUnsupportedCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRequest()
+			tt.modify(&req)
+			if _, err := manager.Create("alice", req); apperrors.CodeOf(err) != tt.code {
+				t.Errorf("expected %s, got %v", tt.code, err)
+			}
+		})
+	}
+
+	alert, err := manager.Create("alice", validRequest())
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if alert.From != "USD" || alert.To != "INR" || alert.Direction != DirectionAbove || alert.ID == "" {
+		t.Errorf("request should be normalized, got %+v", alert)
+	}
+}
+
+func TestManager_ScopedToOwner(t *testing.T) {
+	manager, _, _ := newTestManager(t, "")
+
+	alert, err := manager.Create("alice", validRequest())
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if _, err := manager.Get("bob", alert.ID); apperrors.CodeOf(err) != apperrors.CodeNotFound {
+		t.Errorf("other owners should get not_found, got %v", err)
+	}
+	if err := manager.Delete("bob", alert.ID); apperrors.CodeOf(err) != apperrors.CodeNotFound {
+		t.Errorf("other owners should not be able to delete, got %v", err)
+	}
+	if len(manager.List("bob")) != 0 || len(manager.List("alice")) != 1 {
+		t.Error("List should only return the caller's alerts")
+	}
+
+	if err := manager.Delete("alice", alert.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(manager.List("alice")) != 0 {
+		t.Error("alert should be gone after delete")
+	}
+}
+
+func TestManager_EvaluateIsEdgeTriggered(t *testing.T) {
+	manager, rates, sender := newTestManager(t, "")
+	ctx := context.Background()
+
+	alert, err := manager.Create("alice", validRequest())
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// below threshold - nothing
+	manager.Evaluate(ctx)
+	if len(sender.sent) != 0 {
+		t.Fatalf("expected no notifications, got %d", len(sender.sent))
+	}
+
+	// crosses - fires once
+	rates.set("USD-INR", 84.5)
+	manager.Evaluate(ctx)
+	manager.Evaluate(ctx)
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected exactly one notification while above, got %d", len(sender.sent))
+	}
+	if sender.sent[0].AlertID != alert.ID || sender.sent[0].Rate != 84.5 {
+		t.Errorf("unexpected notification: %+v", sender.sent[0])
+	}
+
+	// falls back and crosses again - re-armed
+	rates.set("USD-INR", 83.9)
+	manager.Evaluate(ctx)
+	rates.set("USD-INR", 85)
+	manager.Evaluate(ctx)
+	if len(sender.sent) != 2 {
+		t.Errorf("expected alert to re-arm after dropping below, got %d notifications", len(sender.sent))
+	}
+
+	stored, _ := manager.Get("alice", alert.ID)
+	if !stored.Triggered || stored.LastRate != 85 || stored.LastTriggeredAt == nil {
+		t.Errorf("evaluation state not saved: %+v", stored)
+	}
+}
+
+func TestStore_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+
+	manager, _, _ := newTestManager(t, path)
+	alert, err := manager.Create("alice", validRequest())
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	reloaded, _, _ := newTestManager(t, path)
+	got, err := reloaded.Get("alice", alert.ID)
+	if err != nil {
+		t.Fatalf("alert should survive a reload: %v", err)
+	}
+	if got.Threshold != 84 || got.CallbackURL != alert.CallbackURL {
+		t.Errorf("reloaded alert differs: %+v", got)
+	}
+}
@@ -0,0 +1,123 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Store keeps alerts in memory and, when a path is set, persists every change
+// to a JSON file so alerts survive restarts
+type Store struct {
+	path string
+
+	mu     sync.RWMutex
+	alerts map[string]Alert
+}
+
+// NewStore creates a store, loading existing alerts from path (empty = memory only)
+func NewStore(path string) (*Store, error) {
+	store := &Store{path: path, alerts: make(map[string]Alert)}
+	if path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alerts file: %w", err)
+	}
+
+	var stored []storedAlert
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse alerts file: %w", err)
+	}
+	for _, entry := range stored {
+		alert := entry.Alert
+		alert.Owner = entry.Owner
+		store.alerts[alert.ID] = alert
+	}
+
+	return store, nil
+}
+
+// List returns the alerts visible to owner ("" sees everything), oldest first
+func (s *Store) List(owner string) []Alert {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Alert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		if owner == "" || alert.Owner == owner {
+			list = append(list, alert)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// Get returns one alert
+func (s *Store) Get(id string) (Alert, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alert, found := s.alerts[id]
+	return alert, found
+}
+
+// Save inserts or replaces an alert
+func (s *Store) Save(alert Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.alerts[alert.ID] = alert
+	return s.persist()
+}
+
+// Delete removes an alert
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.alerts, id)
+	return s.persist()
+}
+
+// persist writes the whole set atomically (temp file + rename) - caller holds the lock
+func (s *Store) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	stored := make([]storedAlert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		stored = append(stored, storedAlert{Alert: alert, Owner: alert.Owner})
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].ID < stored[j].ID })
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode alerts: %w", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create alerts dir: %w", err)
+		}
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write alerts file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace alerts file: %w", err)
+	}
+	return nil
+}
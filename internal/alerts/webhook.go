@@ -0,0 +1,183 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// delivery tuning
+const (
+	webhookTimeout     = 10 * time.Second
+	webhookMaxAttempts = 5
+	webhookBaseBackoff = time.Second
+	webhookMaxBackoff  = 30 * time.Second
+)
+
+// errPrivateAddress is returned when a callback resolves to an internal address
+var errPrivateAddress = errors.New("callback address is not publicly routable")
+
+// Notifier POSTs alert notifications (and any other JSON webhook, see Send),
+// retrying failed deliveries with exponential backoff. Deliveries run in the
+// background so a slow callback never holds up rate evaluation.
+type Notifier struct {
+	client      *http.Client
+	maxAttempts int
+	baseBackoff time.Duration
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	inFlight sync.WaitGroup
+}
+
+// NewNotifier creates a notifier. Unless allowPrivate is set, callbacks that
+// resolve to loopback/private/link-local addresses are refused at dial time so
+// alerts can't be used to probe our internal network.
+func NewNotifier(allowPrivate bool) *Notifier {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivate {
+		dialer.Control = rejectPrivateAddresses
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Notifier{
+		client: &http.Client{
+			Timeout:   webhookTimeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			// a redirect could point anywhere - callbacks must answer directly
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxAttempts: webhookMaxAttempts,
+		baseBackoff: webhookBaseBackoff,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Notify queues a delivery and returns immediately
+func (n *Notifier) Notify(callbackURL string, notification Notification) {
+	n.inFlight.Add(1)
+	go func() {
+		defer n.inFlight.Done()
+		if err := n.deliver(n.ctx, callbackURL, notification); err != nil {
+			slog.Warn("Alert webhook delivery failed", "alert_id", notification.AlertID, "error", err)
+		}
+	}()
+}
+
+// Send queues delivery of an encoded JSON payload, with extra headers, and
+// returns immediately. logAttrs identify the delivery in failure logs
+func (n *Notifier) Send(callbackURL string, payload []byte, header http.Header, logAttrs ...any) {
+	n.inFlight.Add(1)
+	go func() {
+		defer n.inFlight.Done()
+		if err := n.deliverPayload(n.ctx, callbackURL, payload, header, logAttrs); err != nil {
+			slog.Warn("Webhook delivery failed", append(logAttrs, "url", callbackURL, "error", err)...)
+		}
+	}()
+}
+
+// Stop abandons pending retries and waits for in-flight deliveries
+func (n *Notifier) Stop() {
+	n.cancel()
+	n.inFlight.Wait()
+}
+
+// deliver posts the notification, retrying on network errors, 5xx and 429
+func (n *Notifier) deliver(ctx context.Context, callbackURL string, notification Notification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	return n.deliverPayload(ctx, callbackURL, payload, nil, []any{"alert_id", notification.AlertID})
+}
+
+// deliverPayload posts payload until it is accepted or the attempts run out
+func (n *Notifier) deliverPayload(ctx context.Context, callbackURL string, payload []byte, header http.Header, logAttrs []any) error {
+	backoff := n.baseBackoff
+	var lastErr error
+
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		retry, err := n.post(ctx, callbackURL, payload, header)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry || attempt == n.maxAttempts {
+			break
+		}
+
+		slog.Info("Webhook attempt failed, retrying",
+			append(logAttrs, "attempt", attempt, "backoff", backoff.String(), "error", err)...)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("gave up on shutdown: %w", lastErr)
+		}
+
+		backoff *= 2
+		if backoff > webhookMaxBackoff {
+			backoff = webhookMaxBackoff
+		}
+	}
+
+	return lastErr
+}
+
+// post does one delivery attempt - retry says whether another attempt could help
+func (n *Notifier) post(ctx context.Context, callbackURL string, payload []byte, header http.Header) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(payload))
+	if err != nil {
+		return false, fmt.Errorf("bad callback request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "exchange-rate-service-alerts/1.0")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return !errors.Is(err, errPrivateAddress), fmt.Errorf("callback request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("callback returned http %d", resp.StatusCode)
+	default:
+		// 4xx - the receiver rejected it, retrying won't change that
+		return false, fmt.Errorf("callback returned http %d", resp.StatusCode)
+	}
+}
+
+// rejectPrivateAddresses is a net.Dialer Control hook - it sees the resolved IP,
+// so DNS names pointing at internal hosts are caught too
+func rejectPrivateAddresses(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("%s: %w", host, errPrivateAddress)
+	}
+	return nil
+}
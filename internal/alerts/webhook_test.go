@@ -0,0 +1,97 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestNotifier allows loopback (httptest) and retries quickly
+func newTestNotifier() *Notifier {
+	notifier := NewNotifier(true)
+	notifier.baseBackoff = time.Millisecond
+	return notifier
+}
+
+func TestNotifier_RetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := newTestNotifier()
+	defer notifier.Stop()
+
+	if err := notifier.deliver(context.Background(), server.URL, Notification{AlertID: "a1"}); err != nil {
+		t.Fatalf("expected delivery to succeed after retries: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestNotifier_DoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	notifier := newTestNotifier()
+	defer notifier.Stop()
+
+	if err := notifier.deliver(context.Background(), server.URL, Notification{AlertID: "a1"}); err == nil {
+		t.Fatal("expected an error for a 410 response")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("4xx should not be retried, got %d attempts", got)
+	}
+}
+
+func TestNotifier_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	notifier := newTestNotifier()
+	defer notifier.Stop()
+
+	if err := notifier.deliver(context.Background(), server.URL, Notification{AlertID: "a1"}); err == nil {
+		t.Fatal("expected delivery to fail")
+	}
+	if got := atomic.LoadInt32(&calls); got != webhookMaxAttempts {
+		t.Errorf("expected %d attempts, got %d", webhookMaxAttempts, got)
+	}
+}
+
+func TestNotifier_RejectsPrivateAddresses(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(false)
+	defer notifier.Stop()
+
+	err := notifier.deliver(context.Background(), server.URL, Notification{AlertID: "a1"})
+	if !errors.Is(err, errPrivateAddress) {
+		t.Fatalf("expected loopback callback to be refused, got %v", err)
+	}
+	if atomic.LoadInt32(&calls) != 0 {
+		t.Error("request should never reach a loopback server")
+	}
+}
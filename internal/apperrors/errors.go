@@ -0,0 +1,164 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code is a stable, machine-readable error identifier - clients switch on these,
+// so never rename one once it has shipped
+type Code string
+
+const (
+	CodeInvalidRequest      Code = "invalid_request"
+	CodeUnsupportedCurrency Code = "unsupported_currency"
+	CodeCurrencySunset      Code = "currency_sunset"
+	CodeInvalidAmount       Code = "invalid_amount"
+	CodeInvalidDate         Code = "invalid_date"
+	CodeDateOutOfRange      Code = "date_out_of_range"
+	CodeUpstreamUnavailable Code = "upstream_unavailable"
+	CodeUpstreamRejected    Code = "upstream_rejected"
+	CodeReadOnlyReplica     Code = "read_only_replica"
+	CodePairUnavailable     Code = "pair_unavailable"
+	CodeUnauthorized        Code = "unauthorized"
+	CodeForbidden           Code = "forbidden"
+	CodeNotFound            Code = "not_found"
+	CodeConflict            Code = "conflict"
+	CodeRateLimited         Code = "rate_limited"
+	CodeTimeout             Code = "timeout"
+	CodeOverloaded          Code = "overloaded"
+	CodeDegraded            Code = "degraded"
+	CodeInternal            Code = "internal_error"
+)
+
+// Error is a service error carrying a Code and optionally the underlying cause
+type Error struct {
+	Code    Code
+	Message string
+	Err     error
+	// Upstream is the provider's own error type ("unsupported-code") when the
+	// error is a provider's answer
+	Upstream string
+}
+
+// Error returns the message plus the provider's error type and the cause, if any
+func (e *Error) Error() string {
+	msg := e.Message
+	if e.Upstream != "" {
+		msg += " (" + e.Upstream + ")"
+	}
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap exposes the cause to errors.Is / errors.As
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any *Error with the same code, so errors.Is(err, ErrUnsupportedCurrency)
+// holds for every unsupported currency error regardless of message
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// sentinels for errors.Is checks
+var (
+	ErrUnsupportedCurrency = &Error{Code: CodeUnsupportedCurrency, Message: "unsupported currency"}
+	ErrCurrencySunset      = &Error{Code: CodeCurrencySunset, Message: "currency retired"}
+	ErrInvalidAmount       = &Error{Code: CodeInvalidAmount, Message: "invalid amount"}
+	ErrInvalidDate         = &Error{Code: CodeInvalidDate, Message: "invalid date"}
+	ErrDateOutOfRange      = &Error{Code: CodeDateOutOfRange, Message: "date out of range"}
+	ErrUpstreamUnavailable = &Error{Code: CodeUpstreamUnavailable, Message: "upstream unavailable"}
+	ErrUpstreamRejected    = &Error{Code: CodeUpstreamRejected, Message: "upstream rejected the request"}
+	ErrReadOnlyReplica     = &Error{Code: CodeReadOnlyReplica, Message: "rate not available on read-only replica"}
+	ErrPairUnavailable     = &Error{Code: CodePairUnavailable, Message: "pair unavailable"}
+	ErrDegraded            = &Error{Code: CodeDegraded, Message: "degraded mode"}
+)
+
+// New creates an error with a formatted message
+func New(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap creates an error with a formatted message around a cause
+func Wrap(code Code, err error, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// CodeOf returns the code of the first *Error in err's chain, CodeInternal if there is none
+func CodeOf(err error) Code {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return CodeInternal
+}
+
+// UpstreamOf returns the provider's error type carried in err's chain, "" if there is none
+func UpstreamOf(err error) string {
+	var appErr *Error
+	for errors.As(err, &appErr) {
+		if appErr.Upstream != "" {
+			return appErr.Upstream
+		}
+		err = appErr.Err
+	}
+	return ""
+}
+
+// HTTPStatus maps a code to the status the HTTP API answers with
+func HTTPStatus(code Code) int {
+	switch code {
+	case CodeInvalidRequest, CodeUnsupportedCurrency, CodeInvalidAmount, CodeInvalidDate, CodeDateOutOfRange:
+		return http.StatusBadRequest
+	case CodeCurrencySunset:
+		return http.StatusGone
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeNotFound, CodePairUnavailable:
+		return http.StatusNotFound
+	case CodeConflict:
+		return http.StatusConflict
+	case CodeRateLimited:
+		return http.StatusTooManyRequests
+	case CodeUpstreamUnavailable, CodeReadOnlyReplica, CodeOverloaded, CodeDegraded:
+		return http.StatusServiceUnavailable
+	case CodeUpstreamRejected:
+		return http.StatusBadGateway
+	case CodeTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CodeForStatus picks a generic code for errors raised without one (middleware, proxy)
+func CodeForStatus(status int) Code {
+	switch {
+	case status == http.StatusUnauthorized:
+		return CodeUnauthorized
+	case status == http.StatusForbidden:
+		return CodeForbidden
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status == http.StatusConflict:
+		return CodeConflict
+	case status == http.StatusTooManyRequests:
+		return CodeRateLimited
+	case status == http.StatusGatewayTimeout:
+		return CodeTimeout
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable:
+		return CodeUpstreamUnavailable
+	case status >= 400 && status < 500:
+		return CodeInvalidRequest
+	default:
+		return CodeInternal
+	}
+}
@@ -0,0 +1,71 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorMatching(t *testing.T) {
+	err := fmt.Errorf("failed to get exchange rate: %w", New(CodeUnsupportedCurrency, "unsupported target currency: %s", "XYZ"))
+
+	if !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Error("wrapped error should match its sentinel by code")
+	}
+	if errors.Is(err, ErrInvalidDate) {
+		t.Error("error should not match a different code")
+	}
+	if CodeOf(err) != CodeUnsupportedCurrency {
+		t.Errorf("expected unsupported_currency, got %s", CodeOf(err))
+	}
+	if CodeOf(errors.New("boom")) != CodeInternal {
+		t.Error("untyped errors should map to internal_error")
+	}
+}
+
+func TestWrapKeepsCause(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := Wrap(CodeUpstreamUnavailable, cause, "failed to fetch rate")
+
+	if !errors.Is(err, cause) {
+		t.Error("cause should be reachable through Unwrap")
+	}
+	if err.Error() != "failed to fetch rate: connection refused" {
+		t.Errorf("unexpected message: %s", err.Error())
+	}
+}
+
+func TestUpstreamOf(t *testing.T) {
+	rejected := New(CodeUpstreamRejected, "exchange rate provider rejected our credentials")
+	rejected.Upstream = "invalid-key"
+	err := fmt.Errorf("failed to fetch rate: %w", Wrap(CodeUpstreamUnavailable, rejected, "all providers failed"))
+
+	if got := UpstreamOf(err); got != "invalid-key" {
+		t.Errorf("expected the provider's error type from deep in the chain, got %q", got)
+	}
+	if rejected.Error() != "exchange rate provider rejected our credentials (invalid-key)" {
+		t.Errorf("unexpected message: %s", rejected.Error())
+	}
+	if UpstreamOf(New(CodeInternal, "boom")) != "" || UpstreamOf(errors.New("boom")) != "" {
+		t.Error("errors without a provider answer have no upstream type")
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	cases := map[Code]int{
+		CodeUnsupportedCurrency: http.StatusBadRequest,
+		CodeDateOutOfRange:      http.StatusBadRequest,
+		CodeCurrencySunset:      http.StatusGone,
+		CodeUpstreamUnavailable: http.StatusServiceUnavailable,
+		CodeReadOnlyReplica:     http.StatusServiceUnavailable,
+		CodeUpstreamRejected:    http.StatusBadGateway,
+		CodePairUnavailable:     http.StatusNotFound,
+		CodeInternal:            http.StatusInternalServerError,
+	}
+	for code, want := range cases {
+		if got := HTTPStatus(code); got != want {
+			t.Errorf("HTTPStatus(%s) = %d, want %d", code, got, want)
+		}
+	}
+}
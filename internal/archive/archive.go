@@ -0,0 +1,157 @@
+// Package archive keeps end-of-day rate tables: once a day, at a configured
+// local time, the whole cached rate table is copied into the rate store, where
+// it can be downloaded later for reconciliation.
+package archive
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+)
+
+// RateSource is where the table comes from - the rate cache
+type RateSource interface {
+	CachedRates() ([]models.SnapshotRate, error)
+}
+
+// Store keeps one table per day
+type Store interface {
+	SaveRateSnapshot(ctx context.Context, snapshot models.RateSnapshot) error
+	RateSnapshot(ctx context.Context, date string) (models.RateSnapshot, bool, error)
+}
+
+// how long one snapshot may take to write
+const saveTimeout = time.Minute
+
+// Archiver takes the daily snapshot and serves the stored ones
+type Archiver struct {
+	source   RateSource
+	store    Store
+	hour     int
+	minute   int
+	location *time.Location
+
+	shutdownChannel   chan struct{}
+	backgroundWorkers sync.WaitGroup
+}
+
+// New creates an archiver that snapshots every day at "HH:MM" in location
+func New(source RateSource, store Store, at string, location *time.Location) (*Archiver, error) {
+	clock, err := time.Parse("15:04", at)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot time %q, expected HH:MM", at)
+	}
+	if location == nil {
+		location = time.UTC
+	}
+
+	return &Archiver{
+		source:          source,
+		store:           store,
+		hour:            clock.Hour(),
+		minute:          clock.Minute(),
+		location:        location,
+		shutdownChannel: make(chan struct{}),
+	}, nil
+}
+
+// Start runs the daily schedule until Stop
+func (a *Archiver) Start() {
+	a.backgroundWorkers.Add(1)
+	go func() {
+		defer a.backgroundWorkers.Done()
+
+		for {
+			next := a.nextRun(time.Now())
+			slog.Debug("Next daily rate snapshot scheduled", "at", next)
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+				ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+				a.snapshot(ctx)
+				cancel()
+			case <-a.shutdownChannel:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the schedule, waiting for a snapshot being written
+func (a *Archiver) Stop() {
+	close(a.shutdownChannel)
+	a.backgroundWorkers.Wait()
+}
+
+// Take snapshots the cached rates now, dated by the configured time zone's
+// calendar day, replacing an earlier snapshot of that day
+func (a *Archiver) Take(ctx context.Context) (models.RateSnapshot, error) {
+	rates, err := a.source.CachedRates()
+	if err != nil {
+		return models.RateSnapshot{}, err
+	}
+	if len(rates) == 0 {
+		return models.RateSnapshot{}, fmt.Errorf("no cached rates to snapshot")
+	}
+
+	now := time.Now()
+	snapshot := models.RateSnapshot{
+		Date:    now.In(a.location).Format("2006-01-02"),
+		TakenAt: now.UTC(),
+		Count:   len(rates),
+		Rates:   rates,
+	}
+	if err := a.store.SaveRateSnapshot(ctx, snapshot); err != nil {
+		return models.RateSnapshot{}, err
+	}
+	return snapshot, nil
+}
+
+// Snapshot returns the table archived for date (YYYY-MM-DD)
+func (a *Archiver) Snapshot(ctx context.Context, date string) (models.RateSnapshot, error) {
+	if date == "" {
+		return models.RateSnapshot{}, apperrors.New(apperrors.CodeInvalidDate, "date cannot be empty")
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return models.RateSnapshot{}, apperrors.New(apperrors.CodeInvalidDate, "invalid date format, expected YYYY-MM-DD: %s", date)
+	}
+
+	snapshot, found, err := a.store.RateSnapshot(ctx, date)
+	if err != nil {
+		return models.RateSnapshot{}, err
+	}
+	if !found {
+		return models.RateSnapshot{}, apperrors.New(apperrors.CodeNotFound, "no rate snapshot for %s", date)
+	}
+	return snapshot, nil
+}
+
+// snapshot is Take for the schedule - failures only get logged, the next day still runs
+func (a *Archiver) snapshot(ctx context.Context) {
+	start := time.Now()
+	snapshot, err := a.Take(ctx)
+	if err != nil {
+		slog.Error("Daily rate snapshot failed", "error", err)
+		return
+	}
+	slog.Info("Daily rate snapshot saved", "date", snapshot.Date, "pairs", snapshot.Count,
+		"duration_ms", time.Since(start).Milliseconds())
+}
+
+// nextRun is the first snapshot time after now. time.Date normalizes a
+// wall clock skipped by a DST change to the next valid instant
+func (a *Archiver) nextRun(now time.Time) time.Time {
+	local := now.In(a.location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), a.hour, a.minute, 0, 0, a.location)
+	if !next.After(now) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, a.hour, a.minute, 0, 0, a.location)
+	}
+	return next
+}
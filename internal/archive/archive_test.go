@@ -0,0 +1,119 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+)
+
+type fakeSource struct {
+	rates []models.SnapshotRate
+	err   error
+}
+
+func (s *fakeSource) CachedRates() ([]models.SnapshotRate, error) {
+	return s.rates, s.err
+}
+
+type fakeStore struct {
+	snapshots map[string]models.RateSnapshot
+}
+
+func (s *fakeStore) SaveRateSnapshot(ctx context.Context, snapshot models.RateSnapshot) error {
+	s.snapshots[snapshot.Date] = snapshot
+	return nil
+}
+
+func (s *fakeStore) RateSnapshot(ctx context.Context, date string) (models.RateSnapshot, bool, error) {
+	snapshot, found := s.snapshots[date]
+	return snapshot, found, nil
+}
+
+func TestNextRun(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		at       string
+		location *time.Location
+		now      time.Time
+		want     time.Time
+	}{
+		{"later today", "23:59", time.UTC, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC)},
+		{"already past", "23:59", time.UTC, time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC), time.Date(2024, 3, 2, 23, 59, 0, 0, time.UTC)},
+		{"month end", "00:30", time.UTC, time.Date(2024, 2, 29, 1, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 30, 0, 0, time.UTC)},
+		// 17:00 New York is 22:00 UTC in winter - at 23:00 UTC it's tomorrow's turn
+		{"other zone", "17:00", newYork, time.Date(2024, 1, 10, 23, 0, 0, 0, time.UTC), time.Date(2024, 1, 11, 22, 0, 0, 0, time.UTC)},
+		// and 21:00 UTC once daylight saving starts
+		{"across DST", "17:00", newYork, time.Date(2024, 3, 9, 23, 0, 0, 0, time.UTC), time.Date(2024, 3, 10, 21, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		archiver, err := New(&fakeSource{}, &fakeStore{}, tt.at, tt.location)
+		if err != nil {
+			t.Fatalf("%s: New failed: %v", tt.name, err)
+		}
+		if got := archiver.nextRun(tt.now); !got.Equal(tt.want) {
+			t.Errorf("%s: nextRun = %v, want %v", tt.name, got.UTC(), tt.want)
+		}
+	}
+}
+
+func TestNew_RejectsBadTime(t *testing.T) {
+	for _, at := range []string{"", "24:00", "7pm", "12:60"} {
+		if _, err := New(&fakeSource{}, &fakeStore{}, at, time.UTC); err == nil {
+			t.Errorf("%q: expected an error", at)
+		}
+	}
+}
+
+func TestTakeAndSnapshot(t *testing.T) {
+	source := &fakeSource{rates: []models.SnapshotRate{
+		{From: "USD", To: "EUR", Rate: 0.92, Source: "frankfurter", LastUpdated: time.Now().Add(-time.Minute)},
+	}}
+	store := &fakeStore{snapshots: make(map[string]models.RateSnapshot)}
+	archiver, err := New(source, store, "23:59", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	taken, err := archiver.Take(ctx)
+	if err != nil {
+		t.Fatalf("Take failed: %v", err)
+	}
+	if taken.Date != time.Now().UTC().Format("2006-01-02") || taken.Count != 1 {
+		t.Errorf("expected today's table of 1 pair, got %+v", taken)
+	}
+
+	got, err := archiver.Snapshot(ctx, taken.Date)
+	if err != nil || got.Count != 1 || got.Rates[0].Rate != 0.92 {
+		t.Errorf("Snapshot = %+v, %v", got, err)
+	}
+
+	if _, err := archiver.Snapshot(ctx, "2020-01-01"); apperrors.CodeOf(err) != apperrors.CodeNotFound {
+		t.Errorf("expected not_found for a day without a snapshot, got %v", err)
+	}
+	for _, date := range []string{"", "01/03/2024", "2024-13-01"} {
+		if _, err := archiver.Snapshot(ctx, date); apperrors.CodeOf(err) != apperrors.CodeInvalidDate {
+			t.Errorf("%q: expected invalid_date, got %v", date, err)
+		}
+	}
+
+	// nothing cached (or the cache down) leaves the day without a snapshot rather than an empty one
+	source.rates = nil
+	if _, err := archiver.Take(ctx); err == nil {
+		t.Error("expected an empty cache to fail the snapshot")
+	}
+	source.err = errors.New("redis down")
+	if _, err := archiver.Take(ctx); err == nil {
+		t.Error("expected a cache failure to fail the snapshot")
+	}
+}
@@ -0,0 +1,256 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/auth"
+	"exchange-rate-service/internal/logging"
+	"exchange-rate-service/internal/metrics"
+	"exchange-rate-service/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Entry is one audited conversion. Amounts are decimal strings so the trail
+// shows exactly what was quoted
+type Entry struct {
+	Time        time.Time `json:"time"`
+	RequestID   string    `json:"request_id,omitempty"`
+	Client      string    `json:"client,omitempty"` // API key name, empty when auth is off
+	Key         string    `json:"key,omitempty"`    // masked API key
+	Endpoint    string    `json:"endpoint"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Amount      string    `json:"amount"`
+	Date        string    `json:"date,omitempty"`
+	Rate        float64   `json:"rate,omitempty"`
+	AppliedRate float64   `json:"applied_rate,omitempty"`
+	Fee         string    `json:"fee,omitempty"`
+	Result      string    `json:"result,omitempty"` // converted amount
+	Source      string    `json:"source,omitempty"`
+	Cached      bool      `json:"cached"`
+	Stale       bool      `json:"stale,omitempty"`
+	Status      string    `json:"status"` // "success" or "error"
+	ErrorCode   string    `json:"error_code,omitempty"`
+}
+
+// NewEntry starts a successful entry for a call to endpoint, with the request
+// id and the caller - API key name or JWT subject - taken from ctx. Only the
+// masked API key is kept, the trail must not become a key store
+func NewEntry(ctx context.Context, endpoint string) Entry {
+	entry := Entry{
+		RequestID: logging.RequestID(ctx),
+		Endpoint:  endpoint,
+		Status:    "success",
+	}
+	if client, ok := auth.ClientFromContext(ctx); ok {
+		entry.Client = client.Name
+		entry.Key = client.MaskedKey()
+	} else if claims, ok := auth.ClaimsFromContext(ctx); ok {
+		entry.Client = claims.Subject
+	}
+	return entry
+}
+
+// SetConversion fills in what was converted and the outcome: the quote it was
+// made at, or the error code when it failed
+func (e *Entry) SetConversion(from, to string, amount decimal.Decimal, date string, result models.ConversionResult, err error) {
+	e.From = strings.ToUpper(from)
+	e.To = strings.ToUpper(to)
+	e.Amount = amount.String()
+	e.Date = date
+
+	if err != nil {
+		e.Status = "error"
+		e.ErrorCode = string(apperrors.CodeOf(err))
+		if errors.Is(err, context.DeadlineExceeded) {
+			e.ErrorCode = string(apperrors.CodeTimeout)
+		}
+		return
+	}
+	e.Rate = result.Quote.Rate
+	e.AppliedRate = result.AppliedRate
+	e.Fee = result.Fee.String()
+	e.Result = result.Amount.String()
+	e.Source = result.Quote.Source
+	e.Cached = result.Quote.Cached
+	e.Stale = result.Quote.Stale
+}
+
+// Filter narrows an audit query. Zero times leave that end open; Client
+// matches the API key name exactly
+type Filter struct {
+	Start  time.Time
+	End    time.Time
+	Client string
+	Limit  int
+}
+
+// matches reports whether entry passes the filter (Limit aside)
+func (f Filter) matches(entry Entry) bool {
+	if !f.Start.IsZero() && entry.Time.Before(f.Start) {
+		return false
+	}
+	if !f.End.IsZero() && !entry.Time.Before(f.End) {
+		return false
+	}
+	return f.Client == "" || entry.Client == f.Client
+}
+
+// Sink is where audit entries end up
+type Sink interface {
+	Write(entries []Entry) error
+	Close() error
+}
+
+// Querier is implemented by sinks that can be searched, newest entries first
+type Querier interface {
+	Query(ctx context.Context, filter Filter) ([]Entry, error)
+}
+
+// ErrNotQueryable is returned by Query when the sink only writes (stdout)
+var ErrNotQueryable = errors.New("audit sink can't be queried")
+
+// NewSink opens a sink by kind: "stdout" (JSON lines), "file" (JSON lines
+// appended to path) or "sqlite" (a table in the database at path)
+func NewSink(kind, path string) (Sink, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "stdout":
+		return NewStreamSink(nil), nil
+	case "file":
+		if path == "" {
+			path = "audit.log"
+		}
+		return NewFileSink(path)
+	case "sqlite":
+		if path == "" {
+			path = "audit.db"
+		}
+		return NewSQLiteSink(path)
+	default:
+		return nil, fmt.Errorf("unknown audit sink %q (expected stdout, file or sqlite)", kind)
+	}
+}
+
+// most entries written to the sink in one go
+const maxBatch = 100
+
+// Logger queues entries and writes them to the sink in the background, so a
+// conversion never waits on the audit trail. When the queue is full entries
+// are dropped rather than blocking the request - a slow sink must not take
+// the API down with it. Every drop is logged and counted in
+// exchange_rate_audit_entries_dropped_total, which is worth alerting on
+type Logger struct {
+	sink    Sink
+	queue   chan Entry
+	dropped atomic.Int64
+
+	shutdownChannel   chan struct{}
+	backgroundWorkers sync.WaitGroup
+}
+
+// NewLogger creates a logger with room for queueSize pending entries
+func NewLogger(sink Sink, queueSize int) *Logger {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	return &Logger{
+		sink:            sink,
+		queue:           make(chan Entry, queueSize),
+		shutdownChannel: make(chan struct{}),
+	}
+}
+
+// Record queues an entry, stamping the time when it has none
+func (l *Logger) Record(entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	entry.Time = entry.Time.UTC()
+
+	select {
+	case l.queue <- entry:
+	default:
+		l.dropped.Add(1)
+		metrics.RecordAuditDropped()
+		slog.Warn("Audit queue full, dropping entry", "pair", entry.From+"-"+entry.To, "request_id", entry.RequestID)
+	}
+}
+
+// Dropped is how many entries were lost to a full queue since the start
+func (l *Logger) Dropped() int64 {
+	return l.dropped.Load()
+}
+
+// Query searches the sink, newest first
+func (l *Logger) Query(ctx context.Context, filter Filter) ([]Entry, error) {
+	querier, ok := l.sink.(Querier)
+	if !ok {
+		return nil, ErrNotQueryable
+	}
+	return querier.Query(ctx, filter)
+}
+
+// Start runs the writer goroutine
+func (l *Logger) Start() {
+	l.backgroundWorkers.Add(1)
+	go l.writeLoop()
+}
+
+// Stop writes out queued entries, waits for the writer and closes the sink
+func (l *Logger) Stop() {
+	close(l.shutdownChannel)
+	l.backgroundWorkers.Wait()
+	if err := l.sink.Close(); err != nil {
+		slog.Warn("Failed to close audit sink", "error", err)
+	}
+}
+
+func (l *Logger) writeLoop() {
+	defer l.backgroundWorkers.Done()
+
+	for {
+		select {
+		case entry := <-l.queue:
+			l.write(l.batch(entry))
+		case <-l.shutdownChannel:
+			// drain whatever is left before exiting
+			for {
+				select {
+				case entry := <-l.queue:
+					l.write(l.batch(entry))
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// batch collects whatever else is already queued behind first
+func (l *Logger) batch(first Entry) []Entry {
+	entries := []Entry{first}
+	for len(entries) < maxBatch {
+		select {
+		case entry := <-l.queue:
+			entries = append(entries, entry)
+		default:
+			return entries
+		}
+	}
+	return entries
+}
+
+func (l *Logger) write(entries []Entry) {
+	if err := l.sink.Write(entries); err != nil {
+		slog.Error("Failed to write audit entries", "entries", len(entries), "error", err)
+	}
+}
@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/auth"
+	"exchange-rate-service/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func testEntries() []Entry {
+	day := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	return []Entry{
+		{Time: day, Client: "acme", From: "USD", To: "EUR", Amount: "100", Result: "92.00", Status: "success"},
+		{Time: day.Add(time.Hour), Client: "globex", From: "USD", To: "GBP", Amount: "50", Status: "error", ErrorCode: "unsupported_currency"},
+		{Time: day.AddDate(0, 0, 1), Client: "acme", From: "EUR", To: "JPY", Amount: "10", Result: "1620", Status: "success"},
+	}
+}
+
+func TestSinks_QueryFiltersNewestFirst(t *testing.T) {
+	dir := t.TempDir()
+	fileSink, err := NewFileSink(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+	sqliteSink, err := NewSQLiteSink(filepath.Join(dir, "audit.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteSink failed: %v", err)
+	}
+
+	day := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	for name, sink := range map[string]interface {
+		Sink
+		Querier
+	}{"file": fileSink, "sqlite": sqliteSink} {
+		t.Run(name, func(t *testing.T) {
+			defer sink.Close()
+			if err := sink.Write(testEntries()); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			ctx := context.Background()
+
+			all, err := sink.Query(ctx, Filter{})
+			if err != nil || len(all) != 3 || all[0].To != "JPY" || all[2].To != "EUR" {
+				t.Fatalf("expected all 3 entries newest first, got %+v, %v", all, err)
+			}
+
+			acme, _ := sink.Query(ctx, Filter{Client: "acme"})
+			if len(acme) != 2 {
+				t.Errorf("expected 2 acme entries, got %d", len(acme))
+			}
+
+			firstDay, _ := sink.Query(ctx, Filter{Start: day, End: day.AddDate(0, 0, 1)})
+			if len(firstDay) != 2 || firstDay[0].ErrorCode != "unsupported_currency" {
+				t.Errorf("expected the 2 entries of 2025-08-01, got %+v", firstDay)
+			}
+
+			latest, _ := sink.Query(ctx, Filter{Limit: 1})
+			if len(latest) != 1 || latest[0].To != "JPY" {
+				t.Errorf("expected only the newest entry, got %+v", latest)
+			}
+		})
+	}
+}
+
+func TestLogger_DrainsOnStop(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewLogger(NewStreamSink(&out), 16)
+	logger.Start()
+
+	for _, entry := range testEntries() {
+		logger.Record(entry)
+	}
+	logger.Stop()
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 JSON lines, got %d: %s", len(lines), out.String())
+	}
+	var entry Entry
+	if err := json.Unmarshal(lines[0], &entry); err != nil || entry.Client != "acme" {
+		t.Errorf("expected the first entry back, got %+v, %v", entry, err)
+	}
+
+	if _, err := logger.Query(context.Background(), Filter{}); !errors.Is(err, ErrNotQueryable) {
+		t.Errorf("stdout can't be searched - expected ErrNotQueryable, got %v", err)
+	}
+}
+
+func TestLogger_CountsDroppedEntries(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewLogger(NewStreamSink(&out), 1)
+
+	// not started, so nothing drains the one slot
+	for _, entry := range testEntries() {
+		logger.Record(entry)
+	}
+	if got := logger.Dropped(); got != 2 {
+		t.Errorf("expected 2 entries dropped, got %d", got)
+	}
+
+	logger.Start()
+	logger.Stop()
+	if lines := bytes.Count(out.Bytes(), []byte("\n")); lines != 1 {
+		t.Errorf("expected the queued entry written, got %d lines", lines)
+	}
+}
+
+func TestNewEntry_SetConversion(t *testing.T) {
+	ctx := auth.WithClient(context.Background(), &auth.Client{Name: "acme", Key: "sk_live_abcdefgh"})
+	entry := NewEntry(ctx, "grpc /exchange.v1.ExchangeService/Convert")
+	entry.SetConversion("usd", "eur", decimal.RequireFromString("100.10"), "", models.ConversionResult{
+		Amount:      decimal.RequireFromString("85.09"),
+		AppliedRate: 0.85,
+		Quote:       models.RateQuote{Rate: 0.85, Source: "ecb", Cached: true},
+	}, nil)
+	if entry.Client != "acme" || entry.Key == "sk_live_abcdefgh" || entry.Status != "success" {
+		t.Errorf("expected the caller with a masked key, got %+v", entry)
+	}
+	if entry.From != "USD" || entry.To != "EUR" || entry.Amount != "100.1" || entry.Result != "85.09" || entry.Source != "ecb" || !entry.Cached {
+		t.Errorf("unexpected conversion fields: %+v", entry)
+	}
+
+	failed := NewEntry(context.Background(), "graphql convert")
+	failed.SetConversion("USD", "XTS", decimal.NewFromInt(1), "", models.ConversionResult{},
+		fmt.Errorf("convert: %w", context.DeadlineExceeded))
+	if failed.Status != "error" || failed.ErrorCode != "timeout" || failed.Result != "" || failed.Client != "" {
+		t.Errorf("unexpected failed entry: %+v", failed)
+	}
+}
+
+func TestNewSink_RejectsUnknownKind(t *testing.T) {
+	if _, err := NewSink("kafka", ""); err == nil {
+		t.Error("expected an error for an unknown sink")
+	}
+}
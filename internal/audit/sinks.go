@@ -0,0 +1,247 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	// pure Go sqlite, same driver as the rate store
+	_ "modernc.org/sqlite"
+)
+
+// longest line the file sink reads back - far above any real entry
+const maxLineBytes = 64 << 10
+
+// StreamSink writes entries as JSON lines, for log shippers to pick up
+type StreamSink struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewStreamSink writes to out, stdout when nil
+func NewStreamSink(out io.Writer) *StreamSink {
+	if out == nil {
+		out = os.Stdout
+	}
+	return &StreamSink{out: out}
+}
+
+// Write encodes each entry on its own line
+func (s *StreamSink) Write(entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeLines(s.out, entries)
+}
+
+// Close does nothing - the stream isn't ours
+func (s *StreamSink) Close() error {
+	return nil
+}
+
+// FileSink appends JSON lines to a file and answers queries by scanning it,
+// which is fine for a trail rotated by the usual log tooling
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileSink opens (or creates) path for appending - owner read/write only
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return &FileSink{path: path, file: file}, nil
+}
+
+// Write appends entries, one JSON object per line
+func (s *FileSink) Write(entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeLines(s.file, entries)
+}
+
+// Query reads the file back - lines are in time order, so the last matches are the newest
+func (s *FileSink) Query(ctx context.Context, filter Filter) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer file.Close()
+
+	matches := make([]Entry, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineBytes)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// a torn last line after a crash shouldn't hide everything else
+			continue
+		}
+		if filter.matches(entry) {
+			matches = append(matches, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit file: %w", err)
+	}
+
+	if filter.Limit > 0 && len(matches) > filter.Limit {
+		matches = matches[len(matches)-filter.Limit:]
+	}
+	for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+		matches[i], matches[j] = matches[j], matches[i]
+	}
+	return matches, nil
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// SQLiteSink keeps entries in an indexed sqlite table
+type SQLiteSink struct {
+	db *sql.DB
+}
+
+// NewSQLiteSink opens the database at path and creates the table if needed
+func NewSQLiteSink(path string) (*SQLiteSink, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit database: %w", err)
+	}
+	// sqlite allows one writer - serialize instead of failing with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			logged_at BIGINT NOT NULL,
+			client TEXT NOT NULL,
+			entry TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_time ON audit_log (logged_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_client ON audit_log (client, logged_at)`,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate audit database: %w", err)
+		}
+	}
+
+	return &SQLiteSink{db: db}, nil
+}
+
+// Write inserts the batch in one transaction. The filterable columns are
+// stored on their own, the full entry as JSON so new fields need no migration
+func (s *SQLiteSink) Write(entries []Entry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin audit write: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit entry: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO audit_log (logged_at, client, entry) VALUES (?, ?, ?)`,
+			entry.Time.UnixMicro(), entry.Client, string(data)); err != nil {
+			return fmt.Errorf("failed to insert audit entry: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Query selects matching entries, newest first
+func (s *SQLiteSink) Query(ctx context.Context, filter Filter) ([]Entry, error) {
+	conditions := make([]string, 0, 3)
+	args := make([]interface{}, 0, 4)
+	if !filter.Start.IsZero() {
+		conditions = append(conditions, "logged_at >= ?")
+		args = append(args, filter.Start.UnixMicro())
+	}
+	if !filter.End.IsZero() {
+		conditions = append(conditions, "logged_at < ?")
+		args = append(args, filter.End.UnixMicro())
+	}
+	if filter.Client != "" {
+		conditions = append(conditions, "client = ?")
+		args = append(args, filter.Client)
+	}
+
+	query := "SELECT entry FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY logged_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Close closes the database
+func (s *SQLiteSink) Close() error {
+	return s.db.Close()
+}
+
+// writeLines encodes entries as JSON lines in a single write
+func writeLines(out io.Writer, entries []Entry) error {
+	var buf strings.Builder
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to encode audit entry: %w", err)
+		}
+	}
+	if _, err := io.WriteString(out, buf.String()); err != nil {
+		return fmt.Errorf("failed to write audit entries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,456 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers SHA-256 for crypto.Hash.New
+	_ "crypto/sha512" // registers SHA-384 and SHA-512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Roles a JWT can carry - admin implies reader
+const (
+	RoleReader = "reader"
+	RoleAdmin  = "admin"
+)
+
+// ErrInvalidToken is returned for every token that fails verification; the
+// wrapped message says why, for logs only
+var ErrInvalidToken = errors.New("invalid token")
+
+const (
+	// an unknown kid triggers a JWKS refetch at most this often, so junk
+	// tokens can't be used to hammer the identity provider
+	jwksMinRefetch = time.Minute
+	// JWKS documents are small - anything bigger is a misconfigured URL
+	maxJWKSBytes = 1 << 20
+)
+
+// Claims are the parts of a verified JWT the service uses
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Roles     []string
+	ExpiresAt time.Time
+}
+
+// HasRole reports whether the token grants role. Admins may also read
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role || (r == RoleAdmin && role == RoleReader) {
+			return true
+		}
+	}
+	return false
+}
+
+// JWTConfig configures a JWTVerifier. Secret verifies HS256/384/512 tokens and
+// JWKSURL RS256/384/512 and ES256/384/512 ones; at least one is required.
+// RolesClaim may be a dotted path, e.g. "realm_access.roles"
+type JWTConfig struct {
+	Secret      string
+	JWKSURL     string
+	Issuer      string // required iss when set
+	Audience    string // required aud entry when set
+	RolesClaim  string
+	Leeway      time.Duration // clock skew allowed on exp and nbf
+	JWKSRefresh time.Duration // how long fetched keys are trusted
+	HTTPClient  *http.Client
+}
+
+// JWTVerifier checks bearer tokens. JWKS keys are fetched on first use and
+// again after JWKSRefresh, or when a token names a key not seen yet
+type JWTVerifier struct {
+	cfg JWTConfig
+
+	mu         sync.RWMutex
+	keys       map[string]crypto.PublicKey // by kid
+	fetchedAt  time.Time
+	lastFetch  time.Time // last attempt, successful or not
+	fetchGroup singleflight.Group
+}
+
+// NewJWTVerifier creates a verifier
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	if cfg.Secret == "" && cfg.JWKSURL == "" {
+		return nil, errors.New("JWT auth needs a secret or a JWKS URL")
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	if cfg.JWKSRefresh <= 0 {
+		cfg.JWKSRefresh = time.Hour
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &JWTVerifier{cfg: cfg, keys: make(map[string]crypto.PublicKey)}, nil
+}
+
+// jwtHeader is the JOSE header of a compact JWS
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks token's signature and registered claims and returns its claims
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a compact JWT", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: bad header: %v", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad signature encoding", ErrInvalidToken)
+	}
+	if err := v.verifySignature(ctx, header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var payload map[string]interface{}
+	if err := decodeSegment(parts[1], &payload); err != nil {
+		return nil, fmt.Errorf("%w: bad payload: %v", ErrInvalidToken, err)
+	}
+	return v.checkClaims(payload)
+}
+
+// esCurves binds each ECDSA algorithm to its curve (RFC 7518 section 3.4)
+var esCurves = map[string]string{
+	"ES256": "P-256",
+	"ES384": "P-384",
+	"ES512": "P-521",
+}
+
+// verifySignature checks the signature with the key the algorithm calls for.
+// The algorithm family must match the key type, so an RSA public key can never
+// be used as an HMAC secret, and "none" is never accepted
+func (v *JWTVerifier) verifySignature(ctx context.Context, header jwtHeader, signed string, signature []byte) error {
+	if len(header.Alg) != 5 {
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, header.Alg)
+	}
+	family := header.Alg[:2]
+	var hashID crypto.Hash
+	switch header.Alg[2:] {
+	case "256":
+		hashID = crypto.SHA256
+	case "384":
+		hashID = crypto.SHA384
+	case "512":
+		hashID = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, header.Alg)
+	}
+
+	switch family {
+	case "HS":
+		if v.cfg.Secret == "" {
+			return fmt.Errorf("%w: HMAC tokens are not accepted", ErrInvalidToken)
+		}
+		mac := hmac.New(hashID.New, []byte(v.cfg.Secret))
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		return nil
+
+	case "RS":
+		key, err := v.publicKey(ctx, header.Kid)
+		if err != nil {
+			return err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key %q is not an RSA key", ErrInvalidToken, header.Kid)
+		}
+		digest := hashID.New()
+		digest.Write([]byte(signed))
+		if rsa.VerifyPKCS1v15(rsaKey, hashID, digest.Sum(nil), signature) != nil {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		return nil
+
+	case "ES":
+		key, err := v.publicKey(ctx, header.Kid)
+		if err != nil {
+			return err
+		}
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key %q is not an EC key", ErrInvalidToken, header.Kid)
+		}
+		if crv := ecKey.Curve.Params().Name; crv != esCurves[header.Alg] {
+			return fmt.Errorf("%w: alg %s can't be used with a %s key", ErrInvalidToken, header.Alg, crv)
+		}
+		// JWS signatures are r||s, each padded to the curve size
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("%w: bad signature length", ErrInvalidToken)
+		}
+		digest := hashID.New()
+		digest.Write([]byte(signed))
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest.Sum(nil), r, s) {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, header.Alg)
+}
+
+// checkClaims validates exp, nbf, iss and aud and extracts the roles
+func (v *JWTVerifier) checkClaims(payload map[string]interface{}) (*Claims, error) {
+	now := time.Now()
+
+	exp, ok := payload["exp"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: no exp claim", ErrInvalidToken)
+	}
+	expiresAt := time.Unix(int64(exp), 0)
+	if now.After(expiresAt.Add(v.cfg.Leeway)) {
+		return nil, fmt.Errorf("%w: expired at %s", ErrInvalidToken, expiresAt.UTC().Format(time.RFC3339))
+	}
+	if nbf, ok := payload["nbf"].(float64); ok && now.Add(v.cfg.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+
+	issuer, _ := payload["iss"].(string)
+	if v.cfg.Issuer != "" && issuer != v.cfg.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, issuer)
+	}
+	if v.cfg.Audience != "" && !containsString(stringList(payload["aud"]), v.cfg.Audience) {
+		return nil, fmt.Errorf("%w: audience does not include %q", ErrInvalidToken, v.cfg.Audience)
+	}
+
+	var roles interface{} = payload
+	for _, field := range strings.Split(v.cfg.RolesClaim, ".") {
+		object, _ := roles.(map[string]interface{})
+		roles = object[field]
+	}
+
+	subject, _ := payload["sub"].(string)
+	return &Claims{
+		Subject:   subject,
+		Issuer:    issuer,
+		Roles:     stringList(roles),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// publicKey returns the JWKS key for kid, refreshing the set when it is old or
+// the kid is unknown. A token without kid works when the set has one key
+func (v *JWTVerifier) publicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if v.cfg.JWKSURL == "" {
+		return nil, fmt.Errorf("%w: only HMAC tokens are accepted", ErrInvalidToken)
+	}
+
+	v.mu.RLock()
+	key, found := v.lookup(kid)
+	stale := time.Since(v.fetchedAt) > v.cfg.JWKSRefresh
+	canRefetch := time.Since(v.lastFetch) > jwksMinRefetch
+	v.mu.RUnlock()
+
+	if canRefetch && (stale || !found) {
+		if _, err, _ := v.fetchGroup.Do("jwks", func() (interface{}, error) { return nil, v.fetchKeys(ctx) }); err != nil {
+			// keep verifying with the keys we have while the provider is down
+			slog.WarnContext(ctx, "Failed to fetch JWKS", "url", v.cfg.JWKSURL, "error", err)
+		}
+		v.mu.RLock()
+		key, found = v.lookup(kid)
+		v.mu.RUnlock()
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
+
+// lookup finds kid in the key set - caller holds the lock
+func (v *JWTVerifier) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, found := v.keys[kid]
+	return key, found
+}
+
+// jwk is one key of a JWKS document - RSA (n, e) or EC (crv, x, y)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys replaces the key set with the JWKS document at JWKSURL
+func (v *JWTVerifier) fetchKeys(ctx context.Context) error {
+	v.mu.Lock()
+	v.lastFetch = time.Now()
+	v.mu.Unlock()
+
+	// detached from the request so one caller's cancellation doesn't fail the shared fetch
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodGet, v.cfg.JWKSURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS endpoint returned %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBytes)).Decode(&doc); err != nil {
+		return fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping JWKS key", "kid", k.Kid, "error", err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return errors.New("JWKS has no usable signing keys")
+	}
+
+	v.mu.Lock()
+	v.keys, v.fetchedAt = keys, time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+// publicKey decodes an RSA or EC key
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("bad modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31 {
+			return nil, errors.New("bad exponent")
+		}
+		if n.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key too short (%d bits)", n.BitLen())
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := decodeBigInt(k.X)
+		y, errY := decodeBigInt(k.Y)
+		if errX != nil || errY != nil || !curve.IsOnCurve(x, y) {
+			return nil, errors.New("bad EC point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// context plumbing for verified tokens, next to the API key client
+type claimsContextKey struct{}
+
+// WithClaims stores verified JWT claims in ctx
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the verified JWT claims, if the caller used one
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*Claims)
+	return claims, ok
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("bad base64url integer")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// stringList reads a claim that is a string or an array of strings. A string
+// is split on spaces, the way OAuth scope claims are written
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func encodeParts(t *testing.T, header, claims map[string]interface{}) string {
+	t.Helper()
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	return b64(h) + "." + b64(c)
+}
+
+func signHS256(t *testing.T, secret string, claims map[string]interface{}) string {
+	t.Helper()
+	signed := encodeParts(t, map[string]interface{}{"alg": "HS256", "typ": "JWT"}, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + b64(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	signed := encodeParts(t, map[string]interface{}{"alg": "RS256", "kid": kid}, claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + b64(sig)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	signed := encodeParts(t, map[string]interface{}{"alg": "ES256", "kid": kid}, claims)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signed + "." + b64(sig)
+}
+
+func validClaims(roles ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"sub":   "svc-billing",
+		"iss":   "https://idp.example.com",
+		"aud":   []string{"rates"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": roles,
+	}
+}
+
+// jwksServer serves the given keys and counts fetches
+func jwksServer(t *testing.T, keys *[]map[string]string, fetches *int32) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(fetches, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": *keys})
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func TestVerify_HMAC(t *testing.T) {
+	v, err := NewJWTVerifier(JWTConfig{Secret: "s3cret", Issuer: "https://idp.example.com", Audience: "rates"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	claims, err := v.Verify(ctx, signHS256(t, "s3cret", validClaims("reader")))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.Subject != "svc-billing" || !claims.HasRole(RoleReader) || claims.HasRole(RoleAdmin) {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	expired := validClaims("reader")
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongIssuer := validClaims("reader")
+	wrongIssuer["iss"] = "https://evil.example.com"
+	wrongAudience := validClaims("reader")
+	wrongAudience["aud"] = "other"
+	noExp := validClaims("reader")
+	delete(noExp, "exp")
+	notYet := validClaims("reader")
+	notYet["nbf"] = time.Now().Add(time.Hour).Unix()
+
+	unsigned := encodeParts(t, map[string]interface{}{"alg": "none"}, validClaims("admin")) + "."
+	cases := map[string]string{
+		"wrong secret":   signHS256(t, "other", validClaims("reader")),
+		"expired":        signHS256(t, "s3cret", expired),
+		"wrong issuer":   signHS256(t, "s3cret", wrongIssuer),
+		"wrong audience": signHS256(t, "s3cret", wrongAudience),
+		"no exp":         signHS256(t, "s3cret", noExp),
+		"not yet valid":  signHS256(t, "s3cret", notYet),
+		"alg none":       unsigned,
+		"garbage":        "a.b.c",
+	}
+	for name, token := range cases {
+		if _, err := v.Verify(ctx, token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func TestVerify_JWKSKeysAndRefetch(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keys := []map[string]string{
+		rsaJWK("rsa-1", &rsaKey.PublicKey),
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+	}
+	var fetches int32
+	v, _ := NewJWTVerifier(JWTConfig{JWKSURL: jwksServer(t, &keys, &fetches), RolesClaim: "realm_access.roles"})
+	ctx := context.Background()
+
+	nested := validClaims()
+	nested["realm_access"] = map[string]interface{}{"roles": []string{"admin"}}
+
+	claims, err := v.Verify(ctx, signRS256(t, rsaKey, "rsa-1", nested))
+	if err != nil || !claims.HasRole(RoleAdmin) || !claims.HasRole(RoleReader) {
+		t.Fatalf("RS256: %+v (%v)", claims, err)
+	}
+	if _, err := v.Verify(ctx, signES256(t, ecKey, "ec-1", nested)); err != nil {
+		t.Fatalf("ES256: %v", err)
+	}
+	// an RSA key must not verify a token claiming to be HMAC-signed with it
+	if _, err := v.Verify(ctx, signHS256(t, string(rsaKey.PublicKey.N.Bytes()), nested)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected HMAC tokens to be refused without a secret, got %v", err)
+	}
+
+	// a rotated-in key is picked up, but unknown kids can't force a refetch every request
+	rotated, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keys = append(keys, rsaJWK("rsa-2", &rotated.PublicKey))
+	if _, err := v.Verify(ctx, signRS256(t, rotated, "rsa-2", nested)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected the new kid to be unknown within the refetch interval, got %v", err)
+	}
+	if fetches != 1 {
+		t.Errorf("expected one JWKS fetch, got %d", fetches)
+	}
+
+	v.lastFetch = time.Now().Add(-2 * jwksMinRefetch)
+	if _, err := v.Verify(ctx, signRS256(t, rotated, "rsa-2", nested)); err != nil || fetches != 2 {
+		t.Errorf("expected the new kid after a refetch, got %v with %d fetches", err, fetches)
+	}
+}
+
+func TestVerify_ECDSAAlgorithmMustMatchCurve(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	keys := []map[string]string{
+		{"kty": "EC", "kid": "ec-384", "crv": "P-384", "x": b64(ecKey.X.FillBytes(make([]byte, 48))), "y": b64(ecKey.Y.FillBytes(make([]byte, 48)))},
+	}
+	var fetches int32
+	v, _ := NewJWTVerifier(JWTConfig{JWKSURL: jwksServer(t, &keys, &fetches)})
+
+	// a valid P-384 signature over a SHA-256 digest, labelled ES256
+	signed := encodeParts(t, map[string]interface{}{"alg": "ES256", "kid": "ec-384"}, validClaims())
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 96)
+	r.FillBytes(sig[:48])
+	s.FillBytes(sig[48:])
+
+	if _, err := v.Verify(context.Background(), signed+"."+b64(sig)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ES256 with a P-384 key to be refused, got %v", err)
+	}
+}
+
+func TestClaims_RolesFromScopeString(t *testing.T) {
+	v, _ := NewJWTVerifier(JWTConfig{Secret: "s3cret", RolesClaim: "scope"})
+	claims := validClaims()
+	claims["scope"] = "openid reader"
+
+	verified, err := v.Verify(context.Background(), signHS256(t, "s3cret", claims))
+	if err != nil || !verified.HasRole(RoleReader) {
+		t.Errorf("expected reader from a space-separated scope, got %+v (%v)", verified, err)
+	}
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"exchange-rate-service/internal/tenant"
+)
+
+// Client is an authenticated API consumer
+type Client struct {
+	Name              string `json:"name"`
+	Key               string `json:"key"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+
+	// per-key policy, written inline in the keys file entry
+	tenant.Config
+	policy *tenant.Policy
+}
+
+// Policy returns the client's tenant policy (nil = service-wide behaviour)
+func (c *Client) Policy() *tenant.Policy {
+	return c.policy
+}
+
+// setPolicy validates cfg and makes it the client's policy
+func (c *Client) setPolicy(cfg tenant.Config) error {
+	c.Config = cfg
+	c.policy = nil
+	if cfg.IsZero() {
+		return nil
+	}
+
+	policy, err := tenant.NewPolicy(c.Name, cfg)
+	if err != nil {
+		return err
+	}
+	c.policy = policy
+	return nil
+}
+
+// MaskedKey returns a log-safe version of the key
+func (c *Client) MaskedKey() string {
+	if len(c.Key) <= 4 {
+		return "****"
+	}
+	return "****" + c.Key[len(c.Key)-4:]
+}
+
+// KeyStore holds the known API keys
+type KeyStore struct {
+	clients map[string]*Client
+}
+
+// LoadKeyStore builds the store from inline "key[:rpm]" entries and an optional
+// JSON file of clients. Keys without a budget get defaultRPM.
+func LoadKeyStore(inline []string, filePath string, defaultRPM int) (*KeyStore, error) {
+	store := &KeyStore{clients: make(map[string]*Client)}
+
+	for i, entry := range inline {
+		parts := strings.SplitN(entry, ":", 2)
+		key := strings.TrimSpace(parts[0])
+		if key == "" {
+			continue
+		}
+
+		rpm := defaultRPM
+		if len(parts) == 2 {
+			parsed, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid requests per minute for API key #%d", i+1)
+			}
+			rpm = parsed
+		}
+
+		store.add(&Client{Name: fmt.Sprintf("key-%d", i+1), Key: key, RequestsPerMinute: rpm})
+	}
+
+	if filePath != "" {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API keys file: %w", err)
+		}
+
+		var clients []*Client
+		if err := json.Unmarshal(data, &clients); err != nil {
+			return nil, fmt.Errorf("failed to parse API keys file: %w", err)
+		}
+
+		for _, client := range clients {
+			if client.Key == "" {
+				return nil, fmt.Errorf("API keys file entry %q has no key", client.Name)
+			}
+			if client.RequestsPerMinute <= 0 {
+				client.RequestsPerMinute = defaultRPM
+			}
+			store.add(client)
+			if err := client.setPolicy(client.Config); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return store, nil
+}
+
+func (s *KeyStore) add(client *Client) {
+	if client.Name == "" {
+		client.Name = client.MaskedKey()
+	}
+	s.clients[client.Key] = client
+}
+
+// Lookup returns the client owning key
+func (s *KeyStore) Lookup(key string) (*Client, bool) {
+	client, found := s.clients[key]
+	return client, found
+}
+
+// ApplyPolicies replaces the policies of the named clients, e.g. with the ones
+// kept in the rate store. Names without a key are an error - a policy nobody
+// gets is most likely a typo
+func (s *KeyStore) ApplyPolicies(policies map[string]tenant.Config) error {
+	byName := make(map[string]*Client, len(s.clients))
+	for _, client := range s.clients {
+		byName[client.Name] = client
+	}
+
+	for name, cfg := range policies {
+		client, found := byName[name]
+		if !found {
+			return fmt.Errorf("tenant policy for unknown API key %q", name)
+		}
+		if err := client.setPolicy(cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of configured keys
+func (s *KeyStore) Len() int {
+	return len(s.clients)
+}
+
+// context plumbing so handlers/services can see who is calling
+type contextKey struct{}
+
+// WithClient stores the authenticated client in ctx, along with its tenant
+// policy for the services to apply
+func WithClient(ctx context.Context, client *Client) context.Context {
+	ctx = context.WithValue(ctx, contextKey{}, client)
+	if client.policy != nil {
+		ctx = tenant.WithPolicy(ctx, client.policy)
+	}
+	return ctx
+}
+
+// ClientFromContext returns the authenticated client, if any
+func ClientFromContext(ctx context.Context) (*Client, bool) {
+	client, ok := ctx.Value(contextKey{}).(*Client)
+	return client, ok
+}
+
+// CallerID identifies who is calling - the API key or JWT subject, or ""
+// (everyone) when auth is off. For scoping per-caller state like quotes
+func CallerID(ctx context.Context) string {
+	if client, ok := ClientFromContext(ctx); ok {
+		return "key:" + client.Name
+	}
+	if claims, ok := ClaimsFromContext(ctx); ok {
+		return "jwt:" + claims.Subject
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"exchange-rate-service/internal/tenant"
+)
+
+func TestLoadKeyStore_TenantPolicies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	os.WriteFile(path, []byte(`[
+		{"name": "partner-a", "key": "key-a", "allowed_pairs": ["USD-EUR"], "markup": "1%"},
+		{"name": "partner-b", "key": "key-b", "requests_per_minute": 10}
+	]`), 0o600)
+
+	store, err := LoadKeyStore([]string{"inline-key"}, path, 60)
+	if err != nil {
+		t.Fatalf("LoadKeyStore: %v", err)
+	}
+
+	a, _ := store.Lookup("key-a")
+	if a.Policy() == nil || a.Policy().Tenant != "partner-a" || a.Policy().AllowsPair("USD", "GBP") {
+		t.Errorf("expected partner-a's policy from the file, got %+v", a.Policy())
+	}
+	b, _ := store.Lookup("key-b")
+	if b.Policy() != nil {
+		t.Errorf("expected no policy for partner-b, got %+v", b.Policy())
+	}
+
+	// stored policies replace the file's, by key name
+	if err := store.ApplyPolicies(map[string]tenant.Config{"partner-a": {MaxHistoricalDays: 30}, "key-1": {Markup: "2%"}}); err != nil {
+		t.Fatalf("ApplyPolicies: %v", err)
+	}
+	if a.Policy().MaxHistoricalDays != 30 || !a.Policy().AllowsPair("USD", "GBP") {
+		t.Errorf("expected the stored policy to replace the file's, got %+v", a.Policy())
+	}
+	inline, _ := store.Lookup("inline-key")
+	if policy, ok := tenant.FromContext(WithClient(context.Background(), inline)); !ok || policy.Tenant != "key-1" {
+		t.Error("expected the client's policy in the request context")
+	}
+
+	if err := store.ApplyPolicies(map[string]tenant.Config{"nobody": {Markup: "1%"}}); err == nil {
+		t.Error("expected an error for a policy without a key")
+	}
+
+	os.WriteFile(path, []byte(`[{"name": "partner-a", "key": "key-a", "markup": "abc"}]`), 0o600)
+	if _, err := LoadKeyStore(nil, path, 60); err == nil {
+		t.Error("expected an invalid markup to fail loading")
+	}
+}
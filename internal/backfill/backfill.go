@@ -0,0 +1,320 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/models"
+)
+
+// Job statuses
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed" // finished, but some days couldn't be fetched
+	StatusCanceled  = "canceled"
+)
+
+const (
+	// longest range fetched in one upstream request
+	chunkDays = 31
+	// limits on one job
+	maxPairs = 50
+	maxDays  = 3660
+	// errors kept on a job - the counters carry the rest
+	maxJobErrors = 20
+)
+
+// Fetcher is the upstream a backfill pulls from - the provider chain
+type Fetcher interface {
+	GetRateWithSource(ctx context.Context, from, to, date string) (float64, string, error)
+}
+
+// RangeFetcher is optionally implemented by fetchers that can return a whole
+// date range in one call
+type RangeFetcher interface {
+	GetRateRangeWithSource(ctx context.Context, from, to, start, end string) (map[string]float64, string, error)
+}
+
+// Store is where backfilled rates are written
+type Store interface {
+	Series(ctx context.Context, from, to, start, end string) (map[string]float64, error)
+	Record(ctx context.Context, record models.RateRecord) error
+}
+
+// Request names the pairs ("USD-EUR") and the inclusive date range to backfill
+type Request struct {
+	Pairs []string `json:"pairs"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// Job is a backfill and its progress. Checkpoint holds the last day finished
+// per pair, so an interrupted job picks up where it stopped
+type Job struct {
+	ID         string            `json:"id"`
+	Pairs      []string          `json:"pairs"`
+	Start      string            `json:"start"`
+	End        string            `json:"end"`
+	Status     string            `json:"status"`
+	TotalDays  int               `json:"total_days"` // pairs x days in range
+	DoneDays   int               `json:"done_days"`
+	Stored     int               `json:"stored"`  // rates written
+	Skipped    int               `json:"skipped"` // days already in the store
+	Failed     int               `json:"failed"`  // days no provider could serve
+	Percent    float64           `json:"percent"` // done_days of total_days
+	Errors     []string          `json:"errors,omitempty"`
+	Checkpoint map[string]string `json:"checkpoint,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
+
+// NewJob validates req and returns a job ready to run. Pairs are normalized
+// and de-duplicated; the range must end before today, whose rate isn't final
+func NewJob(id string, req Request) (Job, error) {
+	pairs := make([]string, 0, len(req.Pairs))
+	seen := make(map[string]bool)
+	for _, pair := range req.Pairs {
+		pair = strings.ToUpper(strings.TrimSpace(pair))
+		from, to, ok := strings.Cut(pair, "-")
+		if !ok || !currency.ValidCode(from) || !currency.ValidCode(to) || from == to {
+			return Job{}, apperrors.New(apperrors.CodeInvalidRequest, "invalid pair %q, expected e.g. USD-EUR", pair)
+		}
+		if !seen[pair] {
+			seen[pair] = true
+			pairs = append(pairs, pair)
+		}
+	}
+	if len(pairs) == 0 {
+		return Job{}, apperrors.New(apperrors.CodeInvalidRequest, "at least one pair is required")
+	}
+	if len(pairs) > maxPairs {
+		return Job{}, apperrors.New(apperrors.CodeInvalidRequest, "too many pairs (max %d)", maxPairs)
+	}
+
+	start, err := time.Parse("2006-01-02", req.Start)
+	if err != nil {
+		return Job{}, apperrors.New(apperrors.CodeInvalidDate, "invalid start date %q, expected YYYY-MM-DD", req.Start)
+	}
+	end, err := time.Parse("2006-01-02", req.End)
+	if err != nil {
+		return Job{}, apperrors.New(apperrors.CodeInvalidDate, "invalid end date %q, expected YYYY-MM-DD", req.End)
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	switch {
+	case end.Before(start):
+		return Job{}, apperrors.New(apperrors.CodeDateOutOfRange, "invalid date range: end %s is before start %s", req.End, req.Start)
+	case !end.Before(today):
+		return Job{}, apperrors.New(apperrors.CodeDateOutOfRange, "end %s must be before today", req.End)
+	}
+	days := int(end.Sub(start).Hours()/24) + 1
+	if days > maxDays {
+		return Job{}, apperrors.New(apperrors.CodeDateOutOfRange, "range too long: %d days (max %d)", days, maxDays)
+	}
+
+	now := time.Now().UTC()
+	return Job{
+		ID:         id,
+		Pairs:      pairs,
+		Start:      req.Start,
+		End:        req.End,
+		Status:     StatusRunning,
+		TotalDays:  days * len(pairs),
+		Checkpoint: make(map[string]string),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}, nil
+}
+
+// Backfiller fetches historical rates and writes them to the store
+type Backfiller struct {
+	fetcher Fetcher
+	store   Store
+}
+
+// New creates a backfiller. fetcher should not record rates itself - the
+// backfiller writes every rate it gets
+func New(fetcher Fetcher, store Store) *Backfiller {
+	return &Backfiller{fetcher: fetcher, store: store}
+}
+
+// Run works through job chunk by chunk, updating its counters and checkpoint
+// and calling progress after each chunk. Days already stored are skipped, so
+// re-running a range only fetches what is missing. Returns ctx's error when
+// interrupted; the job is then still resumable
+func (b *Backfiller) Run(ctx context.Context, job *Job, progress func(Job)) error {
+	start, _ := time.Parse("2006-01-02", job.Start)
+	end, _ := time.Parse("2006-01-02", job.End)
+	if job.Checkpoint == nil {
+		job.Checkpoint = make(map[string]string)
+	}
+
+	for _, pair := range job.Pairs {
+		from, to, _ := strings.Cut(pair, "-")
+
+		chunkStart := start
+		if done, found := job.Checkpoint[pair]; found {
+			last, err := time.Parse("2006-01-02", done)
+			if err == nil {
+				chunkStart = last.AddDate(0, 0, 1)
+			}
+		}
+
+		for !chunkStart.After(end) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			chunkEnd := chunkStart.AddDate(0, 0, chunkDays-1)
+			if chunkEnd.After(end) {
+				chunkEnd = end
+			}
+			before := *job
+			b.runChunk(ctx, job, from, to, chunkStart, chunkEnd)
+			if err := ctx.Err(); err != nil {
+				// the chunk may be incomplete - leave the checkpoint and counters
+				// before it, a resumed run skips whatever was stored meanwhile
+				job.DoneDays, job.Stored, job.Skipped, job.Failed = before.DoneDays, before.Stored, before.Skipped, before.Failed
+				return err
+			}
+
+			job.Checkpoint[pair] = chunkEnd.Format("2006-01-02")
+			job.Percent = job.percentDone()
+			job.UpdatedAt = time.Now().UTC()
+			if progress != nil {
+				progress(*job)
+			}
+			chunkStart = chunkEnd.AddDate(0, 0, 1)
+		}
+	}
+
+	job.Status, job.Percent = StatusCompleted, 100
+	if job.Failed > 0 {
+		job.Status = StatusFailed
+	}
+	finished := time.Now().UTC()
+	job.UpdatedAt, job.FinishedAt = finished, &finished
+	return nil
+}
+
+// runChunk backfills one pair over [start, end]: one range request when the
+// fetcher supports it, day by day otherwise
+func (b *Backfiller) runChunk(ctx context.Context, job *Job, from, to string, start, end time.Time) {
+	startStr, endStr := start.Format("2006-01-02"), end.Format("2006-01-02")
+	days := int(end.Sub(start).Hours()/24) + 1
+
+	stored, err := b.store.Series(ctx, from, to, startStr, endStr)
+	if err != nil {
+		job.addError(fmt.Sprintf("%s-%s %s..%s: reading store: %v", from, to, startStr, endStr, err))
+		job.Failed += days
+		job.DoneDays += days
+		return
+	}
+
+	// fiat providers publish nothing on weekends; crypto trades every day
+	everyDay := currency.IsAssetPair(from, to)
+	missing := make([]string, 0, days)
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		_, found := stored[key]
+		switch {
+		case found:
+			job.Skipped++
+			job.DoneDays++
+		case !everyDay && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday):
+			job.DoneDays++
+		default:
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	if rangeFetcher, ok := b.fetcher.(RangeFetcher); ok {
+		series, source, err := rangeFetcher.GetRateRangeWithSource(ctx, from, to, startStr, endStr)
+		if err == nil && len(series) > 0 {
+			for _, day := range missing {
+				if rate, found := series[day]; found {
+					b.record(ctx, job, from, to, day, rate, source)
+				}
+				// a weekday the provider has nothing for is a holiday, not a failure
+				job.DoneDays++
+			}
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "Backfill range fetch failed, falling back to daily fetches",
+				"pair", from+"-"+to, "start", startStr, "end", endStr, "error", err)
+		}
+	}
+
+	for _, day := range missing {
+		if ctx.Err() != nil {
+			return
+		}
+		rate, source, err := b.fetcher.GetRateWithSource(ctx, from, to, day)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			job.Failed++
+			job.addError(fmt.Sprintf("%s-%s %s: %v", from, to, day, err))
+		} else {
+			b.record(ctx, job, from, to, day, rate, source)
+		}
+		job.DoneDays++
+	}
+}
+
+func (b *Backfiller) record(ctx context.Context, job *Job, from, to, day string, rate float64, source string) {
+	err := b.store.Record(ctx, models.RateRecord{
+		From:      from,
+		To:        to,
+		Date:      day,
+		Rate:      rate,
+		Source:    source,
+		FetchedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		job.Failed++
+		job.addError(fmt.Sprintf("%s-%s %s: %v", from, to, day, err))
+		return
+	}
+	job.Stored++
+}
+
+// addError keeps the first few errors - enough to see what went wrong
+func (j *Job) addError(msg string) {
+	if len(j.Errors) < maxJobErrors {
+		j.Errors = append(j.Errors, msg)
+	}
+}
+
+// percentDone is how much of the job is done, 0-100 with one decimal
+func (j Job) percentDone() float64 {
+	if j.TotalDays == 0 {
+		return 100
+	}
+	return math.Round(float64(j.DoneDays)*1000/float64(j.TotalDays)) / 10
+}
+
+// sortJobs orders jobs newest first
+func sortJobs(jobs []Job) {
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+}
@@ -0,0 +1,269 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+)
+
+type fakeStore struct {
+	mu      sync.Mutex
+	records map[string]float64 // "FROM-TO DATE"
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{records: make(map[string]float64)}
+}
+
+func (s *fakeStore) Series(ctx context.Context, from, to, start, end string) (map[string]float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	series := make(map[string]float64)
+	for key, rate := range s.records {
+		if key[:7] == from+"-"+to && key[8:] >= start && key[8:] <= end {
+			series[key[8:]] = rate
+		}
+	}
+	return series, nil
+}
+
+func (s *fakeStore) Record(ctx context.Context, record models.RateRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.From+"-"+record.To+" "+record.Date] = record.Rate
+	return nil
+}
+
+func (s *fakeStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// fakeFetcher answers every day with 1.1, optionally blocking until released
+type fakeFetcher struct {
+	mu        sync.Mutex
+	calls     int
+	failDates map[string]bool
+	block     chan struct{}
+}
+
+func (f *fakeFetcher) GetRateWithSource(ctx context.Context, from, to, date string) (float64, string, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	if f.block != nil {
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			return 0, "", ctx.Err()
+		}
+	}
+	if f.failDates[date] {
+		return 0, "", errors.New("provider down")
+	}
+	return 1.1, "fake", nil
+}
+
+// rangeFetcher serves weekdays in one call
+type rangeFetcher struct {
+	fakeFetcher
+	rangeCalls int
+}
+
+func (f *rangeFetcher) GetRateRangeWithSource(ctx context.Context, from, to, start, end string) (map[string]float64, string, error) {
+	f.rangeCalls++
+	series := make(map[string]float64)
+	first, _ := time.Parse("2006-01-02", start)
+	last, _ := time.Parse("2006-01-02", end)
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			series[day.Format("2006-01-02")] = 1.2
+		}
+	}
+	return series, "range", nil
+}
+
+func TestNewJob_Validation(t *testing.T) {
+	job, err := NewJob("1", Request{Pairs: []string{"usd-eur", "USD-EUR", " GBP-JPY"}, Start: "2024-01-01", End: "2024-01-31"})
+	if err != nil {
+		t.Fatalf("NewJob: %v", err)
+	}
+	if len(job.Pairs) != 2 || job.Pairs[0] != "USD-EUR" || job.TotalDays != 62 {
+		t.Errorf("unexpected job: %+v", job)
+	}
+
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+	cases := map[string]struct {
+		req  Request
+		code apperrors.Code
+	}{
+		"no pairs":       {Request{Start: "2024-01-01", End: "2024-01-02"}, apperrors.CodeInvalidRequest},
+		"bad pair":       {Request{Pairs: []string{"USDEUR"}, Start: "2024-01-01", End: "2024-01-02"}, apperrors.CodeInvalidRequest},
+		"same currency":  {Request{Pairs: []string{"USD-USD"}, Start: "2024-01-01", End: "2024-01-02"}, apperrors.CodeInvalidRequest},
+		"bad date":       {Request{Pairs: []string{"USD-EUR"}, Start: "01/01/2024", End: "2024-01-02"}, apperrors.CodeInvalidDate},
+		"reversed range": {Request{Pairs: []string{"USD-EUR"}, Start: "2024-02-01", End: "2024-01-02"}, apperrors.CodeDateOutOfRange},
+		"ends in future": {Request{Pairs: []string{"USD-EUR"}, Start: "2024-01-01", End: tomorrow}, apperrors.CodeDateOutOfRange},
+		"too long":       {Request{Pairs: []string{"USD-EUR"}, Start: "2000-01-01", End: "2024-01-02"}, apperrors.CodeDateOutOfRange},
+	}
+	for name, tc := range cases {
+		if _, err := NewJob("1", tc.req); apperrors.CodeOf(err) != tc.code {
+			t.Errorf("%s: expected %s, got %v", name, tc.code, err)
+		}
+	}
+}
+
+func TestRun_DailyFetchesSkipWeekendsAndStoredDays(t *testing.T) {
+	store := newFakeStore()
+	// Mon 2024-01-01 is already stored
+	store.Record(context.Background(), models.RateRecord{From: "USD", To: "EUR", Date: "2024-01-01", Rate: 0.9})
+	fetcher := &fakeFetcher{failDates: map[string]bool{"2024-01-03": true}}
+
+	job, _ := NewJob("1", Request{Pairs: []string{"USD-EUR"}, Start: "2024-01-01", End: "2024-01-07"})
+	progressCalls := 0
+	if err := New(fetcher, store).Run(context.Background(), &job, func(Job) { progressCalls++ }); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// Tue, Thu, Fri fetched and stored; Wed failed; weekend never asked for
+	if fetcher.calls != 4 || job.Stored != 3 || job.Skipped != 1 || job.Failed != 1 {
+		t.Errorf("unexpected counters: calls=%d %+v", fetcher.calls, job)
+	}
+	if job.DoneDays != job.TotalDays || job.Percent != 100 {
+		t.Errorf("expected the job to be done, got %d/%d", job.DoneDays, job.TotalDays)
+	}
+	if job.Status != StatusFailed || len(job.Errors) != 1 || job.FinishedAt == nil {
+		t.Errorf("expected failed status with one error, got %+v", job)
+	}
+	if progressCalls != 1 || job.Checkpoint["USD-EUR"] != "2024-01-07" {
+		t.Errorf("expected one chunk checkpointed, got %d calls and %v", progressCalls, job.Checkpoint)
+	}
+}
+
+func TestRun_UsesRangeFetchesPerChunk(t *testing.T) {
+	store := newFakeStore()
+	fetcher := &rangeFetcher{}
+
+	job, _ := NewJob("1", Request{Pairs: []string{"USD-EUR"}, Start: "2024-01-01", End: "2024-03-31"})
+	if err := New(fetcher, store).Run(context.Background(), &job, nil); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// 91 days in 31 day chunks, 65 weekdays
+	if fetcher.rangeCalls != 3 || fetcher.calls != 0 {
+		t.Errorf("expected 3 range calls and no daily ones, got %d and %d", fetcher.rangeCalls, fetcher.calls)
+	}
+	if job.Stored != 65 || store.count() != 65 || job.Status != StatusCompleted {
+		t.Errorf("unexpected result: stored %d (store has %d), status %s", job.Stored, store.count(), job.Status)
+	}
+}
+
+func TestRun_ResumesFromCheckpoint(t *testing.T) {
+	store := newFakeStore()
+	fetcher := &rangeFetcher{}
+
+	job, _ := NewJob("1", Request{Pairs: []string{"USD-EUR"}, Start: "2024-01-01", End: "2024-03-31"})
+	job.Checkpoint["USD-EUR"] = "2024-03-02"
+	if err := New(fetcher, store).Run(context.Background(), &job, nil); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if fetcher.rangeCalls != 1 || job.Stored != 20 {
+		t.Errorf("expected only March 3-31 fetched, got %d calls and %d stored", fetcher.rangeCalls, job.Stored)
+	}
+}
+
+func TestManager_RunsAndPersistsJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backfill.json")
+	store := newFakeStore()
+	manager, err := NewManager(New(&rangeFetcher{}, store), path)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	job, err := manager.Submit(Request{Pairs: []string{"USD-EUR"}, Start: "2024-01-01", End: "2024-01-31"})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	done, err := waitForJob(t, manager, job.ID)
+	if err != nil || done.Status != StatusCompleted || done.Stored != 23 {
+		t.Fatalf("expected completed job with 23 rates, got %+v (%v)", done, err)
+	}
+	if _, err := manager.Get("missing"); apperrors.CodeOf(err) != apperrors.CodeNotFound {
+		t.Errorf("expected not_found, got %v", err)
+	}
+
+	reloaded, err := NewManager(New(&rangeFetcher{}, store), path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if jobs := reloaded.List(); len(jobs) != 1 || jobs[0].Status != StatusCompleted {
+		t.Errorf("expected the finished job to be reloaded, got %+v", jobs)
+	}
+}
+
+func TestManager_OneJobAtATimeAndCancel(t *testing.T) {
+	fetcher := &fakeFetcher{block: make(chan struct{})}
+	manager, _ := NewManager(New(fetcher, newFakeStore()), "")
+
+	job, err := manager.Submit(Request{Pairs: []string{"USD-EUR"}, Start: "2024-01-01", End: "2024-01-31"})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if _, err := manager.Submit(Request{Pairs: []string{"USD-GBP"}, Start: "2024-01-01", End: "2024-01-31"}); apperrors.CodeOf(err) != apperrors.CodeConflict {
+		t.Errorf("expected conflict for a second job, got %v", err)
+	}
+
+	canceled, err := manager.Cancel(job.ID)
+	if err != nil || canceled.Status != StatusCanceled || canceled.FinishedAt == nil {
+		t.Fatalf("expected canceled job, got %+v (%v)", canceled, err)
+	}
+	if _, err := manager.Cancel(job.ID); apperrors.CodeOf(err) != apperrors.CodeConflict {
+		t.Errorf("expected conflict canceling a finished job, got %v", err)
+	}
+}
+
+func TestManager_ResumesInterruptedJob(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backfill.json")
+	store := newFakeStore()
+
+	blocked := &fakeFetcher{block: make(chan struct{})}
+	first, _ := NewManager(New(blocked, store), path)
+	job, _ := first.Submit(Request{Pairs: []string{"USD-EUR"}, Start: "2024-01-01", End: "2024-01-31"})
+	// shutdown mid-job: it must stay resumable
+	first.Stop()
+	if interrupted, _ := first.Get(job.ID); interrupted.Status != StatusRunning {
+		t.Fatalf("expected interrupted job to stay running, got %s", interrupted.Status)
+	}
+
+	second, _ := NewManager(New(&fakeFetcher{}, store), path)
+	second.Start()
+
+	resumed, err := waitForJob(t, second, job.ID)
+	if err != nil || resumed.Status != StatusCompleted || resumed.Stored != 23 {
+		t.Errorf("expected resumed job to complete with 23 rates, got %+v (%v)", resumed, err)
+	}
+}
+
+// waitForJob polls until the job is no longer running
+func waitForJob(t *testing.T, manager *Manager, id string) (Job, error) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := manager.Get(id)
+		if err != nil || job.Status != StatusRunning {
+			manager.Stop()
+			return job, err
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s still running", id)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
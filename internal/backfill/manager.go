@@ -0,0 +1,246 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/utils"
+)
+
+// Manager runs backfill jobs in the background, one at a time, and keeps
+// their progress. With a state path, jobs are saved after every chunk and a
+// job interrupted by a shutdown resumes on the next Start
+type Manager struct {
+	backfiller *Backfiller
+	path       string
+
+	mu        sync.Mutex
+	jobs      map[string]Job
+	running   string // id of the running job, empty when idle
+	cancel    context.CancelFunc
+	canceling bool // Cancel was called, as opposed to a shutdown
+
+	backgroundWorkers sync.WaitGroup
+}
+
+// NewManager creates a manager, loading earlier jobs from path (empty = memory only)
+func NewManager(backfiller *Backfiller, path string) (*Manager, error) {
+	m := &Manager{backfiller: backfiller, path: path, jobs: make(map[string]Job)}
+	if path == "" {
+		return m, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backfill state: %w", err)
+	}
+
+	var stored []Job
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse backfill state: %w", err)
+	}
+	for _, job := range stored {
+		m.jobs[job.ID] = job
+	}
+	return m, nil
+}
+
+// Start resumes a job a previous shutdown interrupted
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if job.Status == StatusRunning {
+			slog.Info("Resuming backfill", "job", job.ID, "done_days", job.DoneDays, "total_days", job.TotalDays)
+			m.launch(job)
+			return
+		}
+	}
+}
+
+// Stop interrupts the running job, leaving it resumable, and waits for it
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.mu.Unlock()
+	m.backgroundWorkers.Wait()
+}
+
+// Submit validates req and starts it in the background. Only one job runs at
+// a time - the upstream quota is shared with live traffic
+func (m *Manager) Submit(req Request) (Job, error) {
+	id, err := utils.RandomID(8)
+	if err != nil {
+		return Job{}, apperrors.Wrap(apperrors.CodeInternal, err, "failed to create backfill job")
+	}
+	job, err := NewJob(id, req)
+	if err != nil {
+		return Job{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running != "" {
+		return Job{}, apperrors.New(apperrors.CodeConflict, "backfill %s is already running", m.running)
+	}
+
+	m.jobs[job.ID] = job
+	if err := m.persist(); err != nil {
+		delete(m.jobs, job.ID)
+		return Job{}, apperrors.Wrap(apperrors.CodeInternal, err, "failed to save backfill job")
+	}
+	m.launch(job)
+	return job.clone(), nil
+}
+
+// Get returns one job
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, found := m.jobs[id]
+	if !found {
+		return Job{}, apperrors.New(apperrors.CodeNotFound, "backfill job not found: %s", id)
+	}
+	return job.clone(), nil
+}
+
+// List returns every job, newest first
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job.clone())
+	}
+	sortJobs(jobs)
+	return jobs
+}
+
+// Cancel stops a running job for good - it won't be resumed
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	job, found := m.jobs[id]
+	if !found {
+		m.mu.Unlock()
+		return Job{}, apperrors.New(apperrors.CodeNotFound, "backfill job not found: %s", id)
+	}
+	if m.running != id {
+		m.mu.Unlock()
+		return Job{}, apperrors.New(apperrors.CodeConflict, "backfill %s is not running (%s)", id, job.Status)
+	}
+	m.canceling = true
+	m.cancel()
+	m.mu.Unlock()
+
+	// the runner records the cancellation once it has stopped
+	m.backgroundWorkers.Wait()
+	return m.Get(id)
+}
+
+// launch runs job in the background - caller holds the lock
+func (m *Manager) launch(job Job) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.running, m.cancel, m.canceling = job.ID, cancel, false
+
+	m.backgroundWorkers.Add(1)
+	go func() {
+		defer m.backgroundWorkers.Done()
+		defer cancel()
+
+		work := job.clone()
+		err := m.backfiller.Run(ctx, &work, func(progress Job) {
+			slog.Info("Backfill progress", "job", progress.ID, "percent", progress.Percent,
+				"stored", progress.Stored, "skipped", progress.Skipped, "failed", progress.Failed)
+			m.update(progress)
+		})
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if err != nil && m.canceling {
+			finished := time.Now().UTC()
+			work = m.jobs[job.ID]
+			work.Status, work.UpdatedAt, work.FinishedAt = StatusCanceled, finished, &finished
+		}
+		if err == nil || m.canceling {
+			m.jobs[job.ID] = work.clone()
+			slog.Info("Backfill finished", "job", job.ID, "status", work.Status,
+				"stored", work.Stored, "skipped", work.Skipped, "failed", work.Failed)
+		}
+		// interrupted by Stop: the last checkpoint stays, status stays running
+		if err := m.persist(); err != nil {
+			slog.Warn("Failed to save backfill state", "error", err)
+		}
+		m.running, m.cancel = "", nil
+	}()
+}
+
+// update stores a progress snapshot and saves it
+func (m *Manager) update(progress Job) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.jobs[progress.ID] = progress.clone()
+	if err := m.persist(); err != nil {
+		slog.Warn("Failed to save backfill state", "error", err)
+	}
+}
+
+// persist writes every job atomically (temp file + rename) - caller holds the lock
+func (m *Manager) persist() error {
+	if m.path == "" {
+		return nil
+	}
+
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	sortJobs(jobs)
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backfill state: %w", err)
+	}
+
+	if dir := filepath.Dir(m.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create backfill state dir: %w", err)
+		}
+	}
+
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write backfill state: %w", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return fmt.Errorf("failed to replace backfill state: %w", err)
+	}
+	return nil
+}
+
+// clone copies the job's map and slices so the runner and readers don't share them
+func (j Job) clone() Job {
+	j.Pairs = append([]string(nil), j.Pairs...)
+	j.Errors = append([]string(nil), j.Errors...)
+	checkpoint := make(map[string]string, len(j.Checkpoint))
+	for pair, day := range j.Checkpoint {
+		checkpoint[pair] = day
+	}
+	j.Checkpoint = checkpoint
+	return j
+}
@@ -0,0 +1,219 @@
+// Package broadcast pushes rate changes to operator-configured webhooks after
+// every refresh cycle, so downstream services don't have to poll for them.
+// Unlike user alerts there is no per-subscriber state: every URL receives
+// the same diff of the pairs that moved past the threshold.
+package broadcast
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/utils"
+)
+
+// EventRatesChanged is the event name of every payload
+const EventRatesChanged = "rates.changed"
+
+// signature headers, named like the ones partners sign requests with
+const (
+	SignatureHeader          = "X-Signature"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+)
+
+// RateSource gives us the current rate for a pair
+type RateSource interface {
+	GetLatestRate(ctx context.Context, from, to string) (models.RateQuote, error)
+}
+
+// Sender delivers an encoded JSON payload to a URL in the background
+type Sender interface {
+	Send(callbackURL string, payload []byte, header http.Header, logAttrs ...any)
+}
+
+// Options configures a Broadcaster
+type Options struct {
+	URLs  []string
+	Pairs []string // "FROM-TO"
+	// minimum move, in percent of the rate last broadcast, worth sending (0 = any change)
+	ThresholdPercent float64
+	// signs each payload with HMAC-SHA256 when set
+	Secret string
+}
+
+// Change is one pair that moved
+type Change struct {
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	PreviousRate  float64 `json:"previous_rate"`
+	Rate          float64 `json:"rate"`
+	ChangePercent float64 `json:"change_percent"`
+	Source        string  `json:"source,omitempty"`
+}
+
+// Payload is the body POSTed to every webhook
+type Payload struct {
+	ID               string    `json:"id"`
+	Event            string    `json:"event"`
+	Timestamp        time.Time `json:"timestamp"`
+	ThresholdPercent float64   `json:"threshold_percent"`
+	Changes          []Change  `json:"changes"`
+}
+
+// Broadcaster compares each watched pair with the rate it last broadcast and
+// sends the pairs that moved. Only broadcast rates become the new baseline,
+// so slow drifts add up until they cross the threshold
+type Broadcaster struct {
+	rates  RateSource
+	sender Sender
+	opts   Options
+	pairs  [][2]string
+
+	mu       sync.Mutex
+	baseline map[string]float64 // pair -> rate last broadcast (or first seen)
+}
+
+// New validates opts and creates a broadcaster
+func New(rates RateSource, sender Sender, opts Options) (*Broadcaster, error) {
+	if len(opts.URLs) == 0 {
+		return nil, fmt.Errorf("no webhook URLs configured")
+	}
+	for _, raw := range opts.URLs {
+		parsed, err := url.Parse(raw)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid webhook URL %q", raw)
+		}
+	}
+	if opts.ThresholdPercent < 0 {
+		return nil, fmt.Errorf("threshold must not be negative, got %g", opts.ThresholdPercent)
+	}
+
+	b := &Broadcaster{rates: rates, sender: sender, opts: opts, baseline: make(map[string]float64)}
+	seen := make(map[string]bool)
+	for _, pair := range opts.Pairs {
+		pair = strings.ToUpper(strings.TrimSpace(pair))
+		from, to, ok := strings.Cut(pair, "-")
+		if !ok || !currency.ValidCode(from) || !currency.ValidCode(to) || from == to {
+			return nil, fmt.Errorf("invalid pair %q, expected e.g. USD-EUR", pair)
+		}
+		if !seen[pair] {
+			seen[pair] = true
+			b.pairs = append(b.pairs, [2]string{from, to})
+		}
+	}
+	if len(b.pairs) == 0 {
+		return nil, fmt.Errorf("no pairs to watch")
+	}
+	return b, nil
+}
+
+// Publish checks every watched pair and sends one payload per URL when any
+// moved. Meant to run after each cache refresh; the first run only records
+// the baseline
+func (b *Broadcaster) Publish(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var changes []Change
+	for _, pair := range b.pairs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		from, to := pair[0], pair[1]
+		quote, err := b.rates.GetLatestRate(ctx, from, to)
+		// a stale rate is the old one served again, not a move
+		if err != nil || quote.Stale || quote.Rate <= 0 {
+			if err != nil {
+				slog.DebugContext(ctx, "Skipping pair for rate webhooks", "pair", from+"-"+to, "error", err)
+			}
+			continue
+		}
+
+		key := from + "-" + to
+		previous, found := b.baseline[key]
+		if !found {
+			b.baseline[key] = quote.Rate
+			continue
+		}
+		if quote.Rate == previous {
+			continue
+		}
+		changePercent := (quote.Rate - previous) / previous * 100
+		if math.Abs(changePercent) < b.opts.ThresholdPercent {
+			continue
+		}
+
+		changes = append(changes, Change{
+			From:          from,
+			To:            to,
+			PreviousRate:  previous,
+			Rate:          quote.Rate,
+			ChangePercent: math.Round(changePercent*10000) / 10000,
+			Source:        quote.Source,
+		})
+		b.baseline[key] = quote.Rate
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	b.send(ctx, changes)
+}
+
+// send encodes the diff once and queues it for every URL
+func (b *Broadcaster) send(ctx context.Context, changes []Change) {
+	id, err := utils.RandomID(8)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create rate webhook id", "error", err)
+		return
+	}
+	payload, err := json.Marshal(Payload{
+		ID:               id,
+		Event:            EventRatesChanged,
+		Timestamp:        time.Now().UTC(),
+		ThresholdPercent: b.opts.ThresholdPercent,
+		Changes:          changes,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to encode rate webhook", "error", err)
+		return
+	}
+
+	header := http.Header{}
+	header.Set("User-Agent", "exchange-rate-service-webhooks/1.0")
+	header.Set("X-Webhook-Event", EventRatesChanged)
+	header.Set("X-Webhook-Id", id)
+	if b.opts.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		header.Set(SignatureTimestampHeader, timestamp)
+		header.Set(SignatureHeader, Sign(b.opts.Secret, timestamp, payload))
+	}
+
+	for _, target := range b.opts.URLs {
+		b.sender.Send(target, payload, header, "webhook_id", id)
+	}
+	slog.InfoContext(ctx, "Rate change webhooks queued", "webhook_id", id, "changes", len(changes), "urls", len(b.opts.URLs))
+}
+
+// Sign returns hex(HMAC-SHA256(secret, timestamp + "\n" + body)) - receivers
+// recompute it to check a payload came from us and wasn't altered
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
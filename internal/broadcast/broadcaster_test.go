@@ -0,0 +1,115 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"exchange-rate-service/internal/models"
+)
+
+type fakeRates struct {
+	rates map[string]models.RateQuote
+}
+
+func (f *fakeRates) GetLatestRate(ctx context.Context, from, to string) (models.RateQuote, error) {
+	quote, found := f.rates[from+"-"+to]
+	if !found {
+		return models.RateQuote{}, errors.New("no rate")
+	}
+	return quote, nil
+}
+
+type sent struct {
+	url     string
+	payload Payload
+	header  http.Header
+	body    []byte
+}
+
+type recordingSender struct {
+	sent []sent
+}
+
+func (s *recordingSender) Send(callbackURL string, payload []byte, header http.Header, logAttrs ...any) {
+	var decoded Payload
+	json.Unmarshal(payload, &decoded)
+	s.sent = append(s.sent, sent{url: callbackURL, payload: decoded, header: header, body: payload})
+}
+
+func TestNew_Validation(t *testing.T) {
+	cases := map[string]Options{
+		"no urls":            {Pairs: []string{"USD-EUR"}},
+		"bad url":            {URLs: []string{"ftp://example.com"}, Pairs: []string{"USD-EUR"}},
+		"no pairs":           {URLs: []string{"https://example.com/hook"}},
+		"bad pair":           {URLs: []string{"https://example.com/hook"}, Pairs: []string{"USDEUR"}},
+		"negative threshold": {URLs: []string{"https://example.com/hook"}, Pairs: []string{"USD-EUR"}, ThresholdPercent: -1},
+	}
+	for name, opts := range cases {
+		if _, err := New(&fakeRates{}, &recordingSender{}, opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPublish_SendsPairsPastThreshold(t *testing.T) {
+	rates := &fakeRates{rates: map[string]models.RateQuote{
+		"USD-EUR": {Rate: 0.9000},
+		"USD-GBP": {Rate: 0.8000},
+		"USD-JPY": {Rate: 150},
+	}}
+	sender := &recordingSender{}
+	b, err := New(rates, sender, Options{
+		URLs:             []string{"https://a.example.com/hook", "https://b.example.com/hook"},
+		Pairs:            []string{"usd-eur", "USD-GBP", "USD-JPY", "USD-EUR"},
+		ThresholdPercent: 0.5,
+		Secret:           "s3cret",
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	// first cycle only records the baseline
+	b.Publish(ctx)
+	if len(sender.sent) != 0 {
+		t.Fatalf("expected nothing on the first cycle, got %d", len(sender.sent))
+	}
+
+	rates.rates["USD-EUR"] = models.RateQuote{Rate: 0.9100, Source: "mock"} // +1.11%
+	rates.rates["USD-GBP"] = models.RateQuote{Rate: 0.8020}                 // +0.25%, below threshold
+	rates.rates["USD-JPY"] = models.RateQuote{Rate: 140, Stale: true}       // stale, ignored
+	b.Publish(ctx)
+
+	if len(sender.sent) != 2 || sender.sent[0].url != "https://a.example.com/hook" {
+		t.Fatalf("expected one payload per URL, got %+v", sender.sent)
+	}
+	payload := sender.sent[0].payload
+	if payload.Event != EventRatesChanged || len(payload.Changes) != 1 || sender.sent[1].payload.ID != payload.ID {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+	change := payload.Changes[0]
+	if change.From != "USD" || change.To != "EUR" || change.PreviousRate != 0.9 || change.ChangePercent != 1.1111 || change.Source != "mock" {
+		t.Errorf("unexpected change %+v", change)
+	}
+
+	header := sender.sent[0].header
+	if header.Get(SignatureHeader) != Sign("s3cret", header.Get(SignatureTimestampHeader), sender.sent[0].body) {
+		t.Error("expected the payload to be signed")
+	}
+
+	// GBP keeps drifting from its baseline until it crosses the threshold
+	rates.rates["USD-GBP"] = models.RateQuote{Rate: 0.8045}
+	b.Publish(ctx)
+	if len(sender.sent) != 4 || sender.sent[2].payload.Changes[0].To != "GBP" {
+		t.Errorf("expected the accumulated GBP drift to be sent, got %+v", sender.sent[2:])
+	}
+
+	// nothing moved
+	b.Publish(ctx)
+	if len(sender.sent) != 4 {
+		t.Errorf("expected no payload without changes, got %d", len(sender.sent))
+	}
+}
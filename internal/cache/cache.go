@@ -2,112 +2,523 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"exchange-rate-service/config"
+	"exchange-rate-service/internal/client"
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/metrics"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/ratelimit"
 )
 
+// ErrCacheMiss is returned by backends when a key isn't present
+var ErrCacheMiss = errors.New("cache miss")
+
+// how long a single backend call may take before we treat it as a miss
+const backendTimeout = 2 * time.Second
+
 // Cache defines the interface for caching operations
+// Implemented by MemoryCache (default) and RedisCache (shared between replicas)
 type Cache interface {
 	Get(ctx context.Context, key string) ([]byte, error)
 	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
 	Delete(ctx context.Context, key string) error
 	Exists(ctx context.Context, key string) (bool, error)
+	Keys(ctx context.Context, prefix string) ([]string, error)
 }
 
 // cache for exchange rates with bg refresh
 type ExchangeRateCache struct {
-	rateMutex sync.RWMutex
-	rateData  map[string]rateEntry
+	backend   Cache
+	keyPrefix string
 
 	// api client for fetching rates
 	exchangeAPIClient ExchangeRateAPIClient
 	shutdownChannel   chan struct{}
 	backgroundWorkers sync.WaitGroup
+
+	// stopMu orders on-demand workers' backgroundWorkers.Add against Stop's
+	// Wait - once stopped is set no new worker is started
+	stopMu  sync.Mutex
+	stopped bool
+
+	// canceled on Stop so an in-flight refresh doesn't hold up shutdown
+	refreshCtx    context.Context
+	cancelRefresh context.CancelFunc
+
+	// run after every refresh cycle (e.g. alert evaluation)
+	refreshHooks []func(ctx context.Context)
+
+	// crypto/metal pairs refreshed every config.CryptoRefreshInterval
+	assetPairs []currencyPair
+
+	// the codes refresh cycles fetch - nil means config's core currencies
+	coreSet CoreCurrencySet
+
+	// past days' rates - nil when config.HistoricalCacheSize is 0
+	historical *HistoricalCache
+
+	// paces refresh fetches (full and hot pairs) when REFRESH_RATE_LIMIT_RPM is set
+	upstreamLimiter *ratelimit.TokenBucket
+
+	// refresh leader election between replicas - nil when every instance refreshes on its own
+	coordinator *refreshCoordinator
+
+	// set while a refresh cycle runs so scheduled and on-demand cycles don't overlap
+	refreshing atomic.Bool
+	// unix nanos of the last cycle that updated at least one pair
+	lastRefresh atomic.Int64
+	// how long the last completed cycle took
+	lastRefreshDuration atomic.Int64
+
+	// cache-miss fetches, reported by the service - shared ones were deduplicated
+	upstreamFetches atomic.Int64
+	sharedFetches   atomic.Int64
+
+	// lookup, fallback and refresh counters behind Stats
+	counters cacheCounters
+
+	// pairs backed off after failing refresh after refresh
+	unavailable pairFailures
 }
 
 // rateEntry holds a single exchange rate with its timestamp
-
+// Serialized as JSON so every backend stores the same format
 type rateEntry struct {
-	exchangeRate float64
-	lastUpdated  time.Time
+	ExchangeRate float64   `json:"rate"`
+	LastUpdated  time.Time `json:"last_updated"`
+	Source       string    `json:"source,omitempty"`
+}
+
+// CoreCurrencySet lists the currencies refresh cycles fetch, when operators
+// can change them at runtime
+type CoreCurrencySet interface {
+	CoreCodes() []string
 }
 
 // ExchangeRateAPIClient defines what we need from our API client
 
 type ExchangeRateAPIClient interface {
-	GetRate(fromCurrency, toCurrency, dateStr string) (float64, error)
+	GetRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, error)
+}
+
+// sourcedRateClient is implemented by clients that can say which provider answered
+type sourcedRateClient interface {
+	GetRateWithSource(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, string, error)
 }
 
-// NewExchangeRateCache creates a new cache instance with the provided API client
-func NewExchangeRateCache(apiClient ExchangeRateAPIClient) *ExchangeRateCache {
+// NewExchangeRateCache creates a new cache instance on top of the given backend
+// keyPrefix namespaces our keys when the backend is shared (e.g. redis)
+func NewExchangeRateCache(apiClient ExchangeRateAPIClient, backend Cache, keyPrefix string) *ExchangeRateCache {
+	refreshCtx, cancelRefresh := context.WithCancel(context.Background())
+
+	var upstreamLimiter *ratelimit.TokenBucket
+	if config.RefreshRateLimitRPM > 0 {
+		upstreamLimiter = ratelimit.NewTokenBucket(config.RefreshRateLimitRPM, refreshWorkers())
+	}
+
+	var historical *HistoricalCache
+	if config.HistoricalCacheSize > 0 {
+		historical = NewHistoricalCache(config.HistoricalCacheSize)
+	}
+
 	return &ExchangeRateCache{
-		rateData:          make(map[string]rateEntry),
+		backend:           backend,
+		keyPrefix:         keyPrefix,
 		exchangeAPIClient: apiClient,
 		shutdownChannel:   make(chan struct{}),
+		refreshCtx:        refreshCtx,
+		cancelRefresh:     cancelRefresh,
+		upstreamLimiter:   upstreamLimiter,
+		historical:        historical,
 	}
 }
 
-// GetRate retrieves a cached exchange rate if it exists
-
+// GetRate retrieves a cached exchange rate if it exists and is still fresh
+// Backend errors are logged and treated as a miss so a flaky redis never fails requests
 func (cache *ExchangeRateCache) GetRate(fromCurrency, toCurrency string) (float64, bool) {
-	cacheKey := buildRateKey(fromCurrency, toCurrency)
+	quote, found := cache.GetRateEntry(fromCurrency, toCurrency)
+	if !found || quote.Stale {
+		return 0, false
+	}
 
-	cache.rateMutex.RLock()
-	entry, found := cache.rateData[cacheKey]
-	cache.rateMutex.RUnlock()
+	return quote.Rate, true
+}
 
+// GetRateEntry returns the cached rate, flagging it stale once it is older than
+// config.CacheTTL (config.CryptoCacheTTL for crypto and metal pairs) - lets
+// callers fall back to old data when the upstream is down. Past
+// config.CacheMaxAge (config.CryptoCacheMaxAge) the entry is evicted and
+// reported missing, so it is fetched again rather than served
+func (cache *ExchangeRateCache) GetRateEntry(fromCurrency, toCurrency string) (models.RateQuote, bool) {
+	start := time.Now()
+	cacheKey := cache.keyPrefix + buildRateKey(fromCurrency, toCurrency)
+	entry, found := cache.getEntry(cacheKey)
+	if found && pastMaxAge(fromCurrency, toCurrency, entry.LastUpdated) {
+		cache.evict(cacheKey, entry)
+		found = false
+	}
 	if !found {
-		return 0, false
+		cache.counters.recordLookup(false, false, time.Since(start))
+		metrics.RecordCacheLookup(false)
+		return models.RateQuote{}, false
 	}
 
-	return entry.exchangeRate, true
+	stale := time.Since(entry.LastUpdated) > ttlFor(fromCurrency, toCurrency)
+	cache.counters.recordLookup(true, stale, time.Since(start))
+	metrics.RecordCacheLookup(!stale)
+
+	return models.RateQuote{
+		Rate:        entry.ExchangeRate,
+		LastUpdated: entry.LastUpdated,
+		Stale:       stale,
+		Cached:      true,
+		Source:      entry.Source,
+	}, true
 }
 
 // SetRate stores an exchange rate in the cache with current timestamp
-func (cache *ExchangeRateCache) SetRate(fromCurrency, toCurrency string, rate float64) {
-	cacheKey := buildRateKey(fromCurrency, toCurrency)
+// source is the provider that supplied it (empty when unknown)
+func (cache *ExchangeRateCache) SetRate(fromCurrency, toCurrency string, rate float64, source string) {
+	cacheKey := cache.keyPrefix + buildRateKey(fromCurrency, toCurrency)
+
+	payload, err := json.Marshal(rateEntry{
+		ExchangeRate: rate,
+		LastUpdated:  time.Now(),
+		Source:       source,
+	})
+	if err != nil {
+		slog.Error("Failed to encode cache entry", "key", cacheKey, "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+
+	if err := cache.backend.Set(ctx, cacheKey, payload, 0); err != nil {
+		slog.Warn("Failed to store cache entry", "key", cacheKey, "error", err)
+	}
+	cache.unavailable.clear(currencyPair{From: strings.ToUpper(strings.TrimSpace(fromCurrency)), To: strings.ToUpper(strings.TrimSpace(toCurrency))})
+}
+
+// GetHistoricalRate returns a past day's rate from the historical LRU
+// Kept in process rather than in the backend: it is a read-through copy of
+// data that never changes, so replicas gain nothing from sharing it
+func (cache *ExchangeRateCache) GetHistoricalRate(fromCurrency, toCurrency, date string) (models.RateQuote, bool) {
+	if cache.historical == nil {
+		return models.RateQuote{}, false
+	}
+
+	quote, found := cache.historical.Get(fromCurrency, toCurrency, date)
+	if !found {
+		return models.RateQuote{}, false
+	}
+	quote.Cached = true
+	return quote, true
+}
+
+// SetHistoricalRate stores a past day's quote in the historical LRU
+func (cache *ExchangeRateCache) SetHistoricalRate(fromCurrency, toCurrency, date string, quote models.RateQuote) {
+	if cache.historical != nil {
+		cache.historical.Set(fromCurrency, toCurrency, date, quote)
+	}
+}
+
+// RecordFetch counts a fetch made after a cache miss and how long the caller waited for it
+// shared means the caller reused a concurrent identical fetch instead of calling upstream
+func (cache *ExchangeRateCache) RecordFetch(shared bool, latency time.Duration) {
+	cache.counters.fetchNanos.Add(int64(latency))
+	if shared {
+		cache.sharedFetches.Add(1)
+	} else {
+		cache.upstreamFetches.Add(1)
+	}
+	metrics.RecordRateFetch(shared)
+}
+
+// DeleteRate evicts a single pair - found is false when it wasn't cached
+func (cache *ExchangeRateCache) DeleteRate(fromCurrency, toCurrency string) (bool, error) {
+	cacheKey := cache.keyPrefix + buildRateKey(fromCurrency, toCurrency)
+
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+
+	exists, err := cache.backend.Exists(ctx, cacheKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to check cache entry %s: %w", cacheKey, err)
+	}
+	if !exists {
+		return false, nil
+	}
+
+	if err := cache.backend.Delete(ctx, cacheKey); err != nil {
+		return false, fmt.Errorf("failed to delete cache entry %s: %w", cacheKey, err)
+	}
+
+	slog.Info("Evicted cache entry", "key", cacheKey)
+	return true, nil
+}
+
+// EvictExpired removes every cached pair past its max age and returns how many
+// it removed. Refresh cycles run it so pairs nobody asks for anymore don't
+// linger; lookups evict the ones they run into on their own
+func (cache *ExchangeRateCache) EvictExpired() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	keys, err := cache.backend.Keys(ctx, cache.keyPrefix)
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list cache keys: %w", err)
+	}
+
+	evicted := 0
+	for _, key := range keys {
+		codes := strings.Split(strings.TrimPrefix(key, cache.keyPrefix), "-")
+		if len(codes) != 2 {
+			continue
+		}
+		if entry, found := cache.getEntry(key); found && pastMaxAge(codes[0], codes[1], entry.LastUpdated) {
+			if cache.evict(key, entry) {
+				evicted++
+			}
+		}
+	}
+	return evicted, nil
+}
+
+// evict deletes an entry found past its max age, unless a refresh has
+// replaced it since it was read
+func (cache *ExchangeRateCache) evict(cacheKey string, entry rateEntry) bool {
+	if current, found := cache.getEntry(cacheKey); !found || !current.LastUpdated.Equal(entry.LastUpdated) {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	if err := cache.backend.Delete(ctx, cacheKey); err != nil {
+		slog.Warn("Failed to evict expired cache entry", "key", cacheKey, "error", err)
+		return false
+	}
+
+	cache.counters.evicted.Add(1)
+	metrics.RecordCacheEviction()
+	slog.Info("Evicted cache entry past its max age", "key", cacheKey,
+		"age", time.Since(entry.LastUpdated).Round(time.Second).String())
+	return true
+}
+
+// TriggerRefresh starts a refresh cycle in the background right away
+// Returns false when a cycle is already running or the cache is stopping
+func (cache *ExchangeRateCache) TriggerRefresh() bool {
+	cache.stopMu.Lock()
+	defer cache.stopMu.Unlock()
+	if cache.stopped || !cache.refreshing.CompareAndSwap(false, true) {
+		return false
+	}
+
+	cache.backgroundWorkers.Add(1)
+	go func() {
+		defer cache.backgroundWorkers.Done()
+		defer cache.refreshing.Store(false)
+
+		slog.Info("On-demand exchange rate refresh started")
+		cache.refreshAllRates()
+	}()
+	return true
+}
+
+// LastRefresh returns when a refresh cycle last updated rates (zero if never)
+func (cache *ExchangeRateCache) LastRefresh() time.Time {
+	nanos := cache.lastRefresh.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// RefreshInterval is how often the refresh loops re-fetch from-to: crypto and
+// metals and hot pairs have their own, shorter intervals
+func (cache *ExchangeRateCache) RefreshInterval(from, to string) time.Duration {
+	if currency.IsAssetPair(from, to) {
+		return config.CryptoRefreshInterval
+	}
+	pair, inverse := strings.ToUpper(from+"-"+to), strings.ToUpper(to+"-"+from)
+	for _, entry := range config.HotPairs {
+		if entry = strings.ToUpper(strings.TrimSpace(entry)); entry == pair || entry == inverse {
+			return config.HotPairRefreshInterval
+		}
+	}
+	return config.CacheRefreshInterval
+}
+
+// CheckWarm fails while the backend is unreachable or the core pairs aren't cached yet
+// Probes a single base pair - refresh cycles write every core pair together
+func (cache *ExchangeRateCache) CheckWarm(ctx context.Context) error {
+	base := config.RefreshBaseCurrency
+	for _, code := range cache.coreCurrencies() {
+		if code == base || config.IsCurrencySunset(code) {
+			continue
+		}
+
+		exists, err := cache.backend.Exists(ctx, cache.keyPrefix+buildRateKey(base, code))
+		if err != nil {
+			return fmt.Errorf("cache backend unreachable: %w", err)
+		}
+		if !exists {
+			return errors.New("cache not warmed yet")
+		}
+		return nil
+	}
+	return nil
+}
+
+// CheckRefreshedWithin fails when no refresh cycle has updated rates in maxAge
+func (cache *ExchangeRateCache) CheckRefreshedWithin(maxAge time.Duration) error {
+	last := cache.LastRefresh()
+	if last.IsZero() {
+		return errors.New("no successful refresh yet")
+	}
+	if age := time.Since(last); age > maxAge {
+		return fmt.Errorf("last successful refresh %v ago (max %v)", age.Round(time.Second), maxAge)
+	}
+	return nil
+}
+
+// runScheduledRefresh runs a cycle unless an on-demand one is still going or
+// another replica is the refresh leader
+func (cache *ExchangeRateCache) runScheduledRefresh() {
+	if !cache.IsRefreshLeader() {
+		slog.Debug("Skipping scheduled refresh, another instance is the refresh leader")
+		return
+	}
+	if !cache.refreshing.CompareAndSwap(false, true) {
+		slog.Info("Skipping scheduled refresh, a refresh is already running")
+		return
+	}
+	defer cache.refreshing.Store(false)
+
+	cache.refreshAllRates()
+}
 
-	cache.rateMutex.Lock()
-	cache.rateData[cacheKey] = rateEntry{
-		exchangeRate: rate,
-		lastUpdated:  time.Now(),
+// getEntry loads and decodes a raw backend entry
+func (cache *ExchangeRateCache) getEntry(cacheKey string) (rateEntry, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+
+	payload, err := cache.backend.Get(ctx, cacheKey)
+	if err != nil {
+		if !errors.Is(err, ErrCacheMiss) {
+			slog.Warn("Cache backend read failed", "key", cacheKey, "error", err)
+		}
+		return rateEntry{}, false
+	}
+
+	var entry rateEntry
+	if err := json.Unmarshal(payload, &entry); err != nil {
+		slog.Warn("Corrupt cache entry", "key", cacheKey, "error", err)
+		return rateEntry{}, false
+	}
+
+	return entry, true
+}
+
+// OnRefresh registers fn to run after every full refresh cycle
+// Must be called before StartRefresh
+func (cache *ExchangeRateCache) OnRefresh(fn func(ctx context.Context)) {
+	cache.refreshHooks = append(cache.refreshHooks, fn)
+}
+
+// TrackAssets quotes each crypto/metal code against config.RefreshBaseCurrency
+// on its own config.CryptoRefreshInterval ticker. Must be called before StartRefresh
+func (cache *ExchangeRateCache) TrackAssets(codes []string) {
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code != "" && code != config.RefreshBaseCurrency {
+			cache.assetPairs = append(cache.assetPairs, currencyPair{From: code, To: config.RefreshBaseCurrency})
+		}
 	}
-	cache.rateMutex.Unlock()
 }
 
-// This runs in a separate goroutine to avoid blocking the main application
-func (cache *ExchangeRateCache) StartHourlyRefresh() {
+// TrackCurrencies has refresh cycles fetch set's codes instead of the
+// configured core currencies, read afresh every cycle. Must be called before
+// StartRefresh
+func (cache *ExchangeRateCache) TrackCurrencies(set CoreCurrencySet) {
+	cache.coreSet = set
+}
+
+// coreCurrencies is the set refresh cycles fetch right now
+func (cache *ExchangeRateCache) coreCurrencies() []string {
+	if cache.coreSet != nil {
+		return cache.coreSet.CoreCodes()
+	}
+	return config.GetCoreCurrencies()
+}
+
+// StartRefresh runs the full refresh every config.CacheRefreshInterval and,
+// when config.HotPairs is set, the hot pairs every config.HotPairRefreshInterval
+// Tracked assets get a third loop. All run in separate goroutines to avoid
+// blocking the main application. With coordination enabled the lease is
+// settled first, so only the leader runs the startup cycle
+func (cache *ExchangeRateCache) StartRefresh() {
+	if cache.coordinator != nil {
+		cache.coordinate()
+		cache.backgroundWorkers.Add(1)
+		go cache.coordinationLoop()
+	} else {
+		metrics.SetRefreshLeader(true)
+	}
+
 	cache.backgroundWorkers.Add(1)
 	go cache.refreshLoop()
+
+	if hotPairs := parseHotPairs(config.HotPairs); len(hotPairs) > 0 {
+		cache.backgroundWorkers.Add(1)
+		go cache.pairRefreshLoop(hotPairs, config.HotPairRefreshInterval, false)
+		slog.Info("Hot pair refresh started", "pairs", len(hotPairs), "interval", config.HotPairRefreshInterval.String())
+	}
+
+	if len(cache.assetPairs) > 0 {
+		cache.backgroundWorkers.Add(1)
+		go cache.pairRefreshLoop(cache.assetPairs, config.CryptoRefreshInterval, true)
+		slog.Info("Crypto/metal refresh started", "pairs", len(cache.assetPairs), "interval", config.CryptoRefreshInterval.String())
+	}
 }
 
 // Stop gracefully shuts down the refresh process and waits for completion
 func (cache *ExchangeRateCache) Stop() {
+	cache.stopMu.Lock()
+	cache.stopped = true
+	cache.stopMu.Unlock()
+
+	cache.cancelRefresh()
 	close(cache.shutdownChannel)
 	cache.backgroundWorkers.Wait()
+	cache.releaseLease()
 }
 
-// refreshLoop runs the hourly refresh cycle in the background
+// refreshLoop runs the full refresh cycle in the background
 // This is the main worker goroutine that keeps our exchange rates current
 func (cache *ExchangeRateCache) refreshLoop() {
 	defer cache.backgroundWorkers.Done()
 
-	// Use the configured refresh interval from our constants
+	// CACHE_REFRESH_INTERVAL, hourly by default
 	refreshTicker := time.NewTicker(config.CacheRefreshInterval)
 	defer refreshTicker.Stop()
 
 	// Do an initial refresh right away when we start up
-	cache.refreshAllRates()
+	cache.runScheduledRefresh()
 
 	for {
 		select {
 		case <-refreshTicker.C:
-			cache.refreshAllRates()
+			cache.runScheduledRefresh()
 		case <-cache.shutdownChannel:
 			return
 		}
@@ -115,52 +526,227 @@ func (cache *ExchangeRateCache) refreshLoop() {
 }
 
 // This is called periodically by the background refresh goroutine
+// Only base->X quotes are fetched (N-1 upstream calls); every other pair,
+// inverses included, is derived from them as a cross rate
 func (cache *ExchangeRateCache) refreshAllRates() {
-	supportedCurrencies := config.GetSupportedCurrencies()
-	successfulUpdates := 0
-	totalPairs := 0
-	failedPairs := make([]string, 0)
-
-	log.Printf("Starting exchange rate refresh for %d currencies", len(supportedCurrencies))
-
-	// Iterate through all currency pair combinations
-	for i, fromCurrency := range supportedCurrencies {
-		for j, toCurrency := range supportedCurrencies {
-			// Skip same-currency pairs (USD->USD doesn't make sense)
-			if i == j {
-				continue
-			}
+	cycleStart := time.Now()
+	base := config.RefreshBaseCurrency
+
+	// only the core set - the full provider list would be too much for a cycle
+	// Retired currencies can't be requested anymore - don't waste quota on them,
+	// nor on ones the provider keeps failing until their backoff is up
+	core := cache.coreCurrencies()
+	currencies := make([]string, 0, len(core))
+	backedOff := 0
+	for _, code := range core {
+		if config.IsCurrencySunset(code) {
+			continue
+		}
+		if _, found := cache.unavailable.backedOff(currencyPair{From: base, To: code}, cycleStart); found {
+			backedOff++
+			continue
+		}
+		currencies = append(currencies, code)
+	}
 
-			totalPairs++
-			pairIdentifier := fmt.Sprintf("%s-%s", fromCurrency, toCurrency)
+	slog.Info("Starting exchange rate refresh", "currencies", len(currencies), "base", base, "workers", refreshWorkers(), "backed_off", backedOff)
 
-			// Fetch the latest rate from our API client
-			exchangeRate, err := cache.exchangeAPIClient.GetRate(fromCurrency, toCurrency, "")
-			if err != nil {
-				log.Printf("Failed to fetch rate %s: %v", pairIdentifier, err)
-				failedPairs = append(failedPairs, pairIdentifier)
-				continue
-			}
+	baseRates, baseSources := cache.fetchBaseRates(base, currencies)
 
-			// Store the successful rate in our cache
-			cache.SetRate(fromCurrency, toCurrency, exchangeRate)
-			successfulUpdates++
+	// shutting down - leave the rest for the next instance
+	if cache.refreshCtx.Err() != nil {
+		slog.Warn("Exchange rate refresh aborted", "error", cache.refreshCtx.Err())
+		return
+	}
 
-			// Log the first few successful fetches for debugging
-			if successfulUpdates <= 3 {
-				log.Printf("Successfully fetched rate %s: %.6f", pairIdentifier, exchangeRate)
-			}
-		}
+	derived, missing := deriveRates(currencies, baseRates)
+	for pair, rate := range derived {
+		cache.SetRate(pair.From, pair.To, rate, derivedSource(baseSources[pair.From], baseSources[pair.To]))
 	}
 
+	failedPairs := make([]string, len(missing))
+	for i, pair := range missing {
+		failedPairs[i] = pair.String()
+	}
+	successfulUpdates := len(derived)
+	totalPairs := successfulUpdates + len(failedPairs)
+
 	// Report the final results of this refresh cycle
+	cycleDuration := time.Since(cycleStart)
+	cache.lastRefreshDuration.Store(int64(cycleDuration))
+	attrs := []any{
+		"updated", successfulUpdates,
+		"total", totalPairs,
+		"base_quotes", len(baseRates) - 1,
+		"duration_ms", cycleDuration.Milliseconds(),
+	}
 	if len(failedPairs) > 0 {
-		log.Printf("Exchange rate refresh completed: %d/%d pairs updated successfully. Failed pairs: %v",
-			successfulUpdates, totalPairs, failedPairs)
+		slog.Warn("Exchange rate refresh completed with failures", append(attrs, "failed_pairs", failedPairs)...)
 	} else {
-		log.Printf("Exchange rate refresh completed: %d/%d pairs updated successfully", successfulUpdates, totalPairs)
+		slog.Info("Exchange rate refresh completed", attrs...)
+	}
+
+	// whatever the cycle couldn't update may have aged out by now
+	if evicted, err := cache.EvictExpired(); err != nil {
+		slog.Warn("Failed to evict expired cache entries", "error", err)
+	} else if evicted > 0 {
+		slog.Warn("Evicted cache entries past their max age", "pairs", evicted)
+	}
+
+	metrics.ObserveRefreshCycle(cycleDuration, successfulUpdates, len(failedPairs))
+	cache.counters.recordCycle(successfulUpdates, len(failedPairs))
+	if successfulUpdates > 0 {
+		completedAt := time.Now()
+		cache.lastRefresh.Store(completedAt.UnixNano())
+		cache.publishRefresh(completedAt)
 	}
 
+	for _, hook := range cache.refreshHooks {
+		hook(cache.refreshCtx)
+	}
+}
+
+// fetchBaseRates fetches base->code for every currency through a pool of
+// REFRESH_WORKERS workers. A rate limited answer stops dispatching - the rest
+// of the cycle would only burn through the same exhausted quota
+// Also returns which provider supplied each quote
+func (cache *ExchangeRateCache) fetchBaseRates(base string, currencies []string) (map[string]float64, map[string]string) {
+	jobs := make(chan string)
+	rateLimited := make(chan struct{})
+	var (
+		mu          sync.Mutex
+		wg          sync.WaitGroup
+		limitOnce   sync.Once
+		baseRates   = map[string]float64{base: 1}
+		baseSources = make(map[string]string, len(currencies))
+		failures    = make(map[string]error)
+	)
+
+	for i := 0; i < refreshWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for code := range jobs {
+				if !cache.waitForUpstreamSlot() {
+					continue
+				}
+
+				exchangeRate, source, err := cache.fetchRate(base, code)
+				if err != nil {
+					slog.Warn("Failed to fetch rate", "pair", base+"-"+code, "error", err)
+					if errors.Is(err, client.ErrRateLimited) {
+						limitOnce.Do(func() { close(rateLimited) })
+					}
+					mu.Lock()
+					failures[code] = err
+					mu.Unlock()
+					continue
+				}
+
+				mu.Lock()
+				baseRates[code] = exchangeRate
+				baseSources[code] = source
+				mu.Unlock()
+			}
+		}()
+	}
+
+	skipped := 0
+dispatch:
+	for i, code := range currencies {
+		if code == base {
+			continue
+		}
+		// checked first so a free worker can't win the race against the limit
+		select {
+		case <-rateLimited:
+			skipped = len(currencies) - i
+			break dispatch
+		default:
+		}
+
+		select {
+		case jobs <- code:
+		case <-rateLimited:
+			skipped = len(currencies) - i
+			break dispatch
+		case <-cache.refreshCtx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if skipped > 0 {
+		slog.Warn("Provider rate limited, leaving remaining currencies for the next cycle", "skipped", skipped)
+	}
+
+	answered := len(baseRates) > 1
+	for code, err := range failures {
+		cache.recordPairFailure(currencyPair{From: base, To: code}, err, answered)
+	}
+
+	return baseRates, baseSources
+}
+
+// fetchRate gets the latest from->to rate for the refresh, with its provider when known
+func (cache *ExchangeRateCache) fetchRate(fromCurrency, toCurrency string) (float64, string, error) {
+	if sourced, ok := cache.exchangeAPIClient.(sourcedRateClient); ok {
+		return sourced.GetRateWithSource(cache.refreshCtx, fromCurrency, toCurrency, "")
+	}
+	rate, err := cache.exchangeAPIClient.GetRate(cache.refreshCtx, fromCurrency, toCurrency, "")
+	return rate, "", err
+}
+
+// waitForUpstreamSlot blocks until the refresh limiter allows another call
+// Returns false when the cache is stopping
+func (cache *ExchangeRateCache) waitForUpstreamSlot() bool {
+	if cache.upstreamLimiter == nil {
+		return cache.refreshCtx.Err() == nil
+	}
+
+	for {
+		allowed, wait := cache.upstreamLimiter.Reserve()
+		if allowed {
+			return true
+		}
+		select {
+		case <-time.After(wait):
+		case <-cache.refreshCtx.Done():
+			return false
+		}
+	}
+}
+
+// refreshWorkers is REFRESH_WORKERS, at least 1
+func refreshWorkers() int {
+	if config.RefreshWorkers < 1 {
+		return 1
+	}
+	return config.RefreshWorkers
+}
+
+// ttlFor is how long a cached pair stays fresh - crypto and metal quotes go
+// stale much sooner than fiat
+func ttlFor(from, to string) time.Duration {
+	if currency.IsAssetPair(from, to) {
+		return config.CryptoCacheTTL
+	}
+	return config.CacheTTL
+}
+
+// maxAgeFor is how old a cached pair may get before it is evicted (0 = never)
+func maxAgeFor(from, to string) time.Duration {
+	if currency.IsAssetPair(from, to) {
+		return config.CryptoCacheMaxAge
+	}
+	return config.CacheMaxAge
+}
+
+// pastMaxAge reports whether an entry updated at lastUpdated must be evicted
+func pastMaxAge(from, to string, lastUpdated time.Time) bool {
+	maxAge := maxAgeFor(from, to)
+	return maxAge > 0 && time.Since(lastUpdated) > maxAge
 }
 
 // buildRateKey creates a cache key for currency pair
@@ -172,30 +758,72 @@ func buildRateKey(from, to string) string {
 
 // GetCacheStats returns statistics about cached rates
 func (cache *ExchangeRateCache) GetCacheStats() map[string]interface{} {
-	cache.rateMutex.RLock()
-	defer cache.rateMutex.RUnlock()
-
 	stats := make(map[string]interface{})
-	stats["total_pairs"] = len(cache.rateData)
 
-	if len(cache.rateData) > 0 {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	keys, err := cache.backend.Keys(ctx, cache.keyPrefix)
+	cancel()
+	if err != nil {
+		slog.Warn("Failed to list cache keys", "error", err)
+		stats["error"] = "cache backend unavailable"
+		return stats
+	}
+
+	entries := make([]rateEntry, 0, len(keys))
+	for _, key := range keys {
+		if entry, found := cache.getEntry(key); found {
+			entries = append(entries, entry)
+		}
+	}
+	stats["total_pairs"] = len(entries)
+	if duration := cache.lastRefreshDuration.Load(); duration > 0 {
+		stats["last_refresh_duration_ms"] = time.Duration(duration).Milliseconds()
+	}
+	stats["ttl"] = ttlStats()
+	stats["evicted"] = cache.counters.evicted.Load()
+	stats["miss_fetches"] = map[string]int64{
+		"upstream":     cache.upstreamFetches.Load(),
+		"deduplicated": cache.sharedFetches.Load(),
+	}
+	if cache.historical != nil {
+		stats["historical"] = cache.historical.Stats()
+	}
+	if cache.coordinator != nil {
+		stats["refresh_coordination"] = cache.coordinationStats()
+	}
+	if unavailable := cache.unavailable.snapshot(time.Now()); len(unavailable) > 0 {
+		stats["unavailable_pairs"] = unavailable
+	}
+
+	// lets operators tell "cache is old" apart from "upstream is being skipped"
+	if reporter, ok := cache.exchangeAPIClient.(interface{ BreakerStates() map[string]string }); ok {
+		stats["circuit_breakers"] = reporter.BreakerStates()
+	}
+	// requests and quota errors per upstream API key
+	if reporter, ok := cache.exchangeAPIClient.(interface {
+		KeyUsage() map[string][]client.KeyUsage
+	}); ok {
+		stats["api_keys"] = reporter.KeyUsage()
+	}
+
+	if len(entries) > 0 {
 		var oldestUpdate time.Time
 		var newestUpdate time.Time
 
 		isFirstEntry := true
-		for _, entry := range cache.rateData {
+		for _, entry := range entries {
 			if isFirstEntry {
-				oldestUpdate = entry.lastUpdated
-				newestUpdate = entry.lastUpdated
+				oldestUpdate = entry.LastUpdated
+				newestUpdate = entry.LastUpdated
 				isFirstEntry = false
 				continue
 			}
 
-			if entry.lastUpdated.Before(oldestUpdate) {
-				oldestUpdate = entry.lastUpdated
+			if entry.LastUpdated.Before(oldestUpdate) {
+				oldestUpdate = entry.LastUpdated
 			}
-			if entry.lastUpdated.After(newestUpdate) {
-				newestUpdate = entry.lastUpdated
+			if entry.LastUpdated.After(newestUpdate) {
+				newestUpdate = entry.LastUpdated
 			}
 		}
 
@@ -0,0 +1,237 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+func TestMain(m *testing.M) {
+	// config.Load isn't called in tests
+	config.CacheTTL = time.Hour
+	os.Exit(m.Run())
+}
+
+// stubAPIClient returns a fixed rate for every pair
+type stubAPIClient struct {
+	rate float64
+}
+
+func (s *stubAPIClient) GetRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, error) {
+	return s.rate, nil
+}
+
+func TestExchangeRateCache_SetAndGet(t *testing.T) {
+	rateCache := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "test:")
+
+	if _, found := rateCache.GetRate("USD", "EUR"); found {
+		t.Fatal("expected miss on empty cache")
+	}
+
+	rateCache.SetRate("usd", " eur ", 0.92, "test")
+
+	rate, found := rateCache.GetRate("USD", "EUR")
+	if !found {
+		t.Fatal("expected hit after SetRate")
+	}
+	if rate != 0.92 {
+		t.Errorf("expected 0.92, got %f", rate)
+	}
+	if quote, _ := rateCache.GetRateEntry("USD", "EUR"); !quote.Cached || quote.Source != "test" {
+		t.Errorf("expected a cached quote from source test, got %+v", quote)
+	}
+
+	stats := rateCache.GetCacheStats()
+	if stats["total_pairs"] != 1 {
+		t.Errorf("expected 1 cached pair, got %v", stats["total_pairs"])
+	}
+}
+
+func TestExchangeRateCache_KeyPrefixIsolation(t *testing.T) {
+	backend := NewMemoryCache()
+	first := NewExchangeRateCache(&stubAPIClient{}, backend, "a:")
+	second := NewExchangeRateCache(&stubAPIClient{}, backend, "b:")
+
+	first.SetRate("USD", "EUR", 0.9, "")
+
+	if _, found := second.GetRate("USD", "EUR"); found {
+		t.Error("caches with different prefixes should not see each other's entries")
+	}
+}
+
+func TestMemoryCache_TTL(t *testing.T) {
+	backend := NewMemoryCache()
+	ctx := context.Background()
+
+	backend.Set(ctx, "short", []byte("x"), time.Millisecond)
+	backend.Set(ctx, "forever", []byte("y"), 0)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := backend.Get(ctx, "short"); err != ErrCacheMiss {
+		t.Errorf("expected expired key to miss, got %v", err)
+	}
+	if _, err := backend.Get(ctx, "forever"); err != nil {
+		t.Errorf("expected key without ttl to stay, got %v", err)
+	}
+
+	keys, _ := backend.Keys(ctx, "")
+	if len(keys) != 1 {
+		t.Errorf("expected only the live key to be listed, got %v", keys)
+	}
+}
+
+func TestExchangeRateCache_StaleEntries(t *testing.T) {
+	backend := NewMemoryCache()
+	rateCache := NewExchangeRateCache(&stubAPIClient{}, backend, "test:")
+
+	// write an entry that is older than the TTL directly into the backend
+	payload, _ := json.Marshal(rateEntry{ExchangeRate: 0.8, LastUpdated: time.Now().Add(-2 * time.Hour)})
+	backend.Set(context.Background(), "test:USD-EUR", payload, 0)
+
+	if _, found := rateCache.GetRate("USD", "EUR"); found {
+		t.Error("GetRate should not return entries past the TTL")
+	}
+
+	quote, found := rateCache.GetRateEntry("USD", "EUR")
+	if !found || !quote.Stale || quote.Rate != 0.8 {
+		t.Errorf("GetRateEntry should return the expired entry flagged stale, got %+v (found=%v)", quote, found)
+	}
+}
+
+func TestExchangeRateCache_EvictsPastMaxAge(t *testing.T) {
+	config.CacheMaxAge = 24 * time.Hour
+	defer func() { config.CacheMaxAge = 0 }()
+
+	backend := NewMemoryCache()
+	rateCache := NewExchangeRateCache(&stubAPIClient{}, backend, "test:")
+	for pair, age := range map[string]time.Duration{"USD-EUR": 2 * time.Hour, "USD-GBP": 25 * time.Hour, "USD-JPY": 30 * time.Hour} {
+		payload, _ := json.Marshal(rateEntry{ExchangeRate: 0.8, LastUpdated: time.Now().Add(-age)})
+		backend.Set(context.Background(), "test:"+pair, payload, 0)
+	}
+
+	// past the soft TTL only - still served, flagged stale
+	if quote, found := rateCache.GetRateEntry("USD", "EUR"); !found || !quote.Stale {
+		t.Errorf("expected the stale entry, got %+v (found=%v)", quote, found)
+	}
+
+	// past the max age - evicted on lookup
+	if _, found := rateCache.GetRateEntry("USD", "GBP"); found {
+		t.Error("expected an entry past the max age to be reported missing")
+	}
+	if exists, _ := backend.Exists(context.Background(), "test:USD-GBP"); exists {
+		t.Error("expected the entry past the max age to be evicted")
+	}
+
+	// the sweep gets the rest
+	if evicted, err := rateCache.EvictExpired(); err != nil || evicted != 1 {
+		t.Errorf("expected 1 eviction, got %d (%v)", evicted, err)
+	}
+	if exists, _ := backend.Exists(context.Background(), "test:USD-EUR"); !exists {
+		t.Error("expected the stale entry to survive the sweep")
+	}
+
+	stats := rateCache.Stats()
+	if stats.Lookups.Evicted != 2 || stats.Lookups.Misses != 1 {
+		t.Errorf("expected 2 evictions and 1 miss, got %+v", stats.Lookups)
+	}
+	if stats.TTL.Soft != "1h0m0s" || stats.TTL.Hard != "24h0m0s" {
+		t.Errorf("expected the configured thresholds, got %+v", stats.TTL)
+	}
+}
+
+func TestExchangeRateCache_DeleteRate(t *testing.T) {
+	rateCache := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "test:")
+	rateCache.SetRate("USD", "EUR", 0.92, "")
+	rateCache.SetRate("EUR", "USD", 1.087, "")
+
+	deleted, err := rateCache.DeleteRate("usd", "eur")
+	if err != nil || !deleted {
+		t.Fatalf("expected the pair to be evicted, got deleted=%v err=%v", deleted, err)
+	}
+	if _, found := rateCache.GetRate("USD", "EUR"); found {
+		t.Error("evicted pair should miss")
+	}
+	if _, found := rateCache.GetRate("EUR", "USD"); !found {
+		t.Error("only the requested direction should be evicted")
+	}
+
+	if deleted, _ := rateCache.DeleteRate("USD", "EUR"); deleted {
+		t.Error("deleting an uncached pair should report not found")
+	}
+}
+
+func TestExchangeRateCache_TriggerRefresh(t *testing.T) {
+	rateCache := NewExchangeRateCache(&stubAPIClient{rate: 2}, NewMemoryCache(), "test:")
+
+	if !rateCache.TriggerRefresh() {
+		t.Fatal("expected the first trigger to start a refresh")
+	}
+	rateCache.backgroundWorkers.Wait()
+
+	if _, found := rateCache.GetRate("USD", "EUR"); !found {
+		t.Error("expected rates to be cached after an on-demand refresh")
+	}
+
+	rateCache.refreshing.Store(true)
+	if rateCache.TriggerRefresh() {
+		t.Error("a trigger while a refresh runs should be refused")
+	}
+	rateCache.refreshing.Store(false)
+
+	rateCache.Stop()
+	if rateCache.TriggerRefresh() {
+		t.Error("a stopped cache should refuse to refresh")
+	}
+}
+
+func TestExchangeRateCache_TriggerRefreshDuringStop(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		rateCache := NewExchangeRateCache(&stubAPIClient{rate: 2}, NewMemoryCache(), "test:")
+
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 50; k++ {
+					rateCache.TriggerRefresh()
+				}
+			}()
+		}
+		rateCache.Stop()
+		wg.Wait()
+
+		if rateCache.TriggerRefresh() {
+			t.Fatal("a stopped cache should refuse to refresh")
+		}
+	}
+}
+
+func TestExchangeRateCache_ReadinessChecks(t *testing.T) {
+	rateCache := NewExchangeRateCache(&stubAPIClient{rate: 2}, NewMemoryCache(), "test:")
+	ctx := context.Background()
+
+	if err := rateCache.CheckWarm(ctx); err == nil {
+		t.Error("an empty cache should not be warm")
+	}
+	if err := rateCache.CheckRefreshedWithin(time.Hour); err == nil {
+		t.Error("a cache that never refreshed should fail the refresh check")
+	}
+
+	rateCache.refreshAllRates()
+
+	if err := rateCache.CheckWarm(ctx); err != nil {
+		t.Errorf("expected warm cache after a refresh, got %v", err)
+	}
+	if err := rateCache.CheckRefreshedWithin(time.Hour); err != nil {
+		t.Errorf("expected a recent refresh, got %v", err)
+	}
+	if err := rateCache.CheckRefreshedWithin(0); err == nil {
+		t.Error("expected the refresh check to fail once maxAge is exceeded")
+	}
+}
@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/metrics"
+)
+
+// Leaser is implemented by backends that can hand out a lease shared between
+// replicas - the refresh leader holds it and keeps renewing it
+type Leaser interface {
+	// AcquireLease takes key for owner, or extends it when owner already holds it
+	AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLease gives key up, only if owner still holds it
+	ReleaseLease(ctx context.Context, key, owner string) error
+}
+
+// refreshCoordinator makes replicas sharing a backend take turns: the one
+// holding the lease runs the refresh loops and publishes each completed cycle,
+// the others pick the published cycles up instead of calling the provider
+type refreshCoordinator struct {
+	leaser   Leaser
+	owner    string
+	leaseTTL time.Duration
+
+	leaseKey     string
+	publishedKey string
+
+	leader atomic.Bool
+	// false until the first lease attempt has settled our role
+	decided atomic.Bool
+}
+
+// publishedRefresh is what the leader writes after each completed cycle
+type publishedRefresh struct {
+	CompletedAt time.Time `json:"completed_at"`
+	Leader      string    `json:"leader"`
+}
+
+// EnableCoordination makes this instance compete for the refresh lease under
+// owner (unique per replica). Only the leaseholder refreshes; when it stops or
+// dies its lease runs out after leaseTTL and another replica takes over.
+// Must be called before StartRefresh
+func (cache *ExchangeRateCache) EnableCoordination(owner string, leaseTTL time.Duration) error {
+	leaser, ok := cache.backend.(Leaser)
+	if !ok {
+		return errors.New("cache backend doesn't support leases")
+	}
+	if owner == "" || leaseTTL <= 0 {
+		return fmt.Errorf("refresh coordination needs an instance id and a positive lease ttl")
+	}
+
+	// outside keyPrefix so they never show up among the cached rates
+	namespace := strings.TrimSuffix(cache.keyPrefix, ":")
+	cache.coordinator = &refreshCoordinator{
+		leaser:       leaser,
+		owner:        owner,
+		leaseTTL:     leaseTTL,
+		leaseKey:     "refresh-leader:" + namespace,
+		publishedKey: "refresh-published:" + namespace,
+	}
+	return nil
+}
+
+// IsRefreshLeader reports whether this instance runs the refresh loops -
+// always true without coordination
+func (cache *ExchangeRateCache) IsRefreshLeader() bool {
+	return cache.coordinator == nil || cache.coordinator.leader.Load()
+}
+
+// coordinationLoop renews (or competes for) the lease three times per lease
+// ttl, so a healthy leader never lets it lapse
+func (cache *ExchangeRateCache) coordinationLoop() {
+	defer cache.backgroundWorkers.Done()
+
+	ticker := time.NewTicker(cache.coordinator.leaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cache.coordinate()
+		case <-cache.shutdownChannel:
+			return
+		}
+	}
+}
+
+// coordinate settles this instance's role for the next period. Followers
+// adopt cycles the leader published; a replica taking over from a leader that
+// went away refreshes right away when the last published cycle is overdue
+func (cache *ExchangeRateCache) coordinate() {
+	coordinator := cache.coordinator
+
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	acquired, err := coordinator.leaser.AcquireLease(ctx, coordinator.leaseKey, coordinator.owner, coordinator.leaseTTL)
+	cancel()
+	if err != nil {
+		// keep the current role - nobody else can take the lease while the backend is down either
+		slog.Warn("Refresh lease check failed", "error", err)
+		return
+	}
+
+	wasLeader := coordinator.leader.Swap(acquired)
+	metrics.SetRefreshLeader(acquired)
+	firstDecision := !coordinator.decided.Swap(true)
+
+	switch {
+	case acquired && (firstDecision || !wasLeader):
+		slog.Info("Acting as refresh leader", "instance", coordinator.owner)
+		// StartRefresh runs the first cycle itself; later takeovers catch up only if due
+		if !firstDecision && time.Since(cache.lastPublished()) >= config.CacheRefreshInterval {
+			slog.Info("Previous leader's refresh is overdue, refreshing now")
+			cache.TriggerRefresh()
+		}
+	case !acquired && (firstDecision || wasLeader):
+		slog.Info("Following another instance's refreshes", "instance", coordinator.owner)
+	}
+
+	if !acquired {
+		cache.followRefresh()
+	}
+}
+
+// followRefresh adopts a cycle the leader completed since we last looked,
+// running the refresh hooks so this replica's alerts and subscribers see it
+func (cache *ExchangeRateCache) followRefresh() {
+	published := cache.lastPublished()
+	if published.IsZero() || !published.After(cache.LastRefresh()) {
+		return
+	}
+
+	cache.lastRefresh.Store(published.UnixNano())
+	slog.Debug("Adopted leader's refresh", "completed_at", published)
+
+	for _, hook := range cache.refreshHooks {
+		hook(cache.refreshCtx)
+	}
+}
+
+// publishRefresh announces a completed cycle to the followers
+func (cache *ExchangeRateCache) publishRefresh(completedAt time.Time) {
+	coordinator := cache.coordinator
+	if coordinator == nil {
+		return
+	}
+
+	payload, err := json.Marshal(publishedRefresh{CompletedAt: completedAt, Leader: coordinator.owner})
+	if err != nil {
+		slog.Error("Failed to encode published refresh", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	if err := cache.backend.Set(ctx, coordinator.publishedKey, payload, 0); err != nil {
+		slog.Warn("Failed to publish refresh", "error", err)
+	}
+}
+
+// lastPublished is when the leader last completed a cycle (zero if never)
+func (cache *ExchangeRateCache) lastPublished() time.Time {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+
+	payload, err := cache.backend.Get(ctx, cache.coordinator.publishedKey)
+	if err != nil {
+		if !errors.Is(err, ErrCacheMiss) {
+			slog.Warn("Failed to read published refresh", "error", err)
+		}
+		return time.Time{}
+	}
+
+	var published publishedRefresh
+	if err := json.Unmarshal(payload, &published); err != nil {
+		slog.Warn("Corrupt published refresh", "error", err)
+		return time.Time{}
+	}
+	return published.CompletedAt
+}
+
+// releaseLease hands leadership over on shutdown instead of making the
+// others wait out the ttl
+func (cache *ExchangeRateCache) releaseLease() {
+	coordinator := cache.coordinator
+	if coordinator == nil || !coordinator.leader.Load() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	if err := coordinator.leaser.ReleaseLease(ctx, coordinator.leaseKey, coordinator.owner); err != nil {
+		slog.Warn("Failed to release refresh lease", "error", err)
+		return
+	}
+	coordinator.leader.Store(false)
+	metrics.SetRefreshLeader(false)
+}
+
+// coordinationStats describes this instance's part in the refresh
+func (cache *ExchangeRateCache) coordinationStats() map[string]interface{} {
+	role := "follower"
+	if cache.coordinator.leader.Load() {
+		role = "leader"
+	}
+	stats := map[string]interface{}{
+		"role":     role,
+		"instance": cache.coordinator.owner,
+	}
+	if published := cache.lastPublished(); !published.IsZero() {
+		stats["last_published"] = published
+	}
+	return stats
+}
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+// two replicas sharing one backend, driven by hand instead of by the loops
+func TestCoordination_OnlyLeaderRefreshes(t *testing.T) {
+	withRefreshConfig(t, 3, 1)
+	backend := NewMemoryCache()
+
+	leaderClient := &slowClient{}
+	leader := NewExchangeRateCache(leaderClient, backend, "test:")
+	followerClient := &slowClient{}
+	follower := NewExchangeRateCache(followerClient, backend, "test:")
+
+	for owner, replica := range map[string]*ExchangeRateCache{"a": leader, "b": follower} {
+		if err := replica.EnableCoordination(owner, time.Minute); err != nil {
+			t.Fatalf("EnableCoordination failed: %v", err)
+		}
+	}
+
+	leader.coordinate()
+	follower.coordinate()
+	if !leader.IsRefreshLeader() || follower.IsRefreshLeader() {
+		t.Fatal("expected the first replica to lead and the second to follow")
+	}
+
+	hooks := 0
+	follower.OnRefresh(func(ctx context.Context) { hooks++ })
+
+	follower.runScheduledRefresh()
+	if followerClient.calls.Load() != 0 {
+		t.Errorf("follower called the provider %d times", followerClient.calls.Load())
+	}
+
+	leader.runScheduledRefresh()
+	if leaderClient.calls.Load() != 2 {
+		t.Errorf("expected 2 base quotes fetched by the leader, got %d", leaderClient.calls.Load())
+	}
+
+	// the follower serves the leader's rates and adopts its cycle
+	if _, found := follower.GetRate("USD", "X01"); !found {
+		t.Error("expected the leader's rates in the shared backend")
+	}
+	follower.coordinate()
+	if hooks != 1 || !follower.LastRefresh().Equal(leader.LastRefresh().Truncate(0)) {
+		t.Errorf("expected the follower to adopt the cycle once, hooks=%d last=%v leader=%v",
+			hooks, follower.LastRefresh(), leader.LastRefresh())
+	}
+	follower.coordinate()
+	if hooks != 1 {
+		t.Errorf("the same cycle was adopted twice")
+	}
+}
+
+func TestCoordination_TakeoverAfterRelease(t *testing.T) {
+	withRefreshConfig(t, 3, 1)
+	previousInterval := config.CacheRefreshInterval
+	defer func() { config.CacheRefreshInterval = previousInterval }()
+	config.CacheRefreshInterval = time.Hour
+
+	backend := NewMemoryCache()
+	leader := NewExchangeRateCache(&slowClient{}, backend, "test:")
+	followerClient := &slowClient{}
+	follower := NewExchangeRateCache(followerClient, backend, "test:")
+	leader.EnableCoordination("a", time.Minute)
+	follower.EnableCoordination("b", time.Minute)
+
+	leader.coordinate()
+	follower.coordinate()
+
+	// the leader shuts down without ever completing a cycle
+	leader.Stop()
+	if leader.IsRefreshLeader() {
+		t.Error("a stopped leader should give up the lease")
+	}
+
+	follower.coordinate()
+	if !follower.IsRefreshLeader() {
+		t.Fatal("expected the follower to take over the released lease")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for followerClient.calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if followerClient.calls.Load() != 2 {
+		t.Errorf("nothing was ever published - expected the new leader to refresh right away, got %d calls", followerClient.calls.Load())
+	}
+	follower.Stop()
+}
+
+func TestMemoryCache_Lease(t *testing.T) {
+	backend := NewMemoryCache()
+	ctx := context.Background()
+
+	if ok, _ := backend.AcquireLease(ctx, "lease", "a", 50*time.Millisecond); !ok {
+		t.Fatal("expected a free lease to be acquired")
+	}
+	if ok, _ := backend.AcquireLease(ctx, "lease", "b", time.Minute); ok {
+		t.Error("a held lease went to another owner")
+	}
+	if ok, _ := backend.AcquireLease(ctx, "lease", "a", 50*time.Millisecond); !ok {
+		t.Error("the holder should be able to renew")
+	}
+
+	backend.ReleaseLease(ctx, "lease", "b")
+	if ok, _ := backend.AcquireLease(ctx, "lease", "b", time.Minute); ok {
+		t.Error("only the holder may release the lease")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if ok, _ := backend.AcquireLease(ctx, "lease", "b", time.Minute); !ok {
+		t.Error("an expired lease should be up for grabs")
+	}
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/models"
+)
+
+// currencyPair is an ordered from/to pair
+type currencyPair struct {
+	From, To string
+}
+
+func (p currencyPair) String() string {
+	return p.From + "-" + p.To
+}
+
+// crossRate derives from->to out of two quotes against the same base:
+// base->to / base->from. Inverses are the special case where one side is the base.
+func crossRate(baseToFrom, baseToTo float64) (float64, error) {
+	if baseToFrom <= 0 || baseToTo <= 0 {
+		return 0, fmt.Errorf("invalid base rates %v / %v", baseToFrom, baseToTo)
+	}
+	return baseToTo / baseToFrom, nil
+}
+
+// derivedSource names the provider(s) behind a cross rate - the base leg has no
+// source of its own, and legs from different providers are joined with "+"
+func derivedSource(fromSource, toSource string) string {
+	switch {
+	case fromSource == "" || fromSource == toSource:
+		return toSource
+	case toSource == "":
+		return fromSource
+	default:
+		return fromSource + "+" + toSource
+	}
+}
+
+// deriveRates expands base quotes into every ordered pair of currencies.
+// baseRates maps code -> units of that code per one base unit and must include
+// the base itself at 1. Pairs touching a currency without a base quote are
+// returned in missing.
+func deriveRates(currencies []string, baseRates map[string]float64) (rates map[currencyPair]float64, missing []currencyPair) {
+	rates = make(map[currencyPair]float64, len(currencies)*len(currencies))
+
+	for _, from := range currencies {
+		for _, to := range currencies {
+			if from == to {
+				continue
+			}
+
+			pair := currencyPair{From: from, To: to}
+			fromRate, fromFound := baseRates[from]
+			toRate, toFound := baseRates[to]
+			if !fromFound || !toFound {
+				missing = append(missing, pair)
+				continue
+			}
+
+			rate, err := crossRate(fromRate, toRate)
+			if err != nil {
+				missing = append(missing, pair)
+				continue
+			}
+			rates[pair] = rate
+		}
+	}
+
+	return rates, missing
+}
+
+// QuotesFrom returns every cached base->X quote keyed by X, stale ones flagged,
+// in one key scan - what GET /rates rebases from. Lookups here don't count
+// towards the hit/miss stats, they aren't a request for any one pair
+func (cache *ExchangeRateCache) QuotesFrom(base string) (map[string]models.RateQuote, error) {
+	prefix := cache.keyPrefix + strings.ToUpper(strings.TrimSpace(base)) + "-"
+
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	keys, err := cache.backend.Keys(ctx, prefix)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache keys: %w", err)
+	}
+
+	quotes := make(map[string]models.RateQuote, len(keys))
+	for _, key := range keys {
+		to := strings.TrimPrefix(key, prefix)
+		if to == "" || strings.Contains(to, "-") {
+			continue
+		}
+		entry, found := cache.getEntry(key)
+		if !found || entry.ExchangeRate <= 0 || pastMaxAge(base, to, entry.LastUpdated) {
+			continue
+		}
+		quotes[to] = models.RateQuote{
+			Rate:        entry.ExchangeRate,
+			LastUpdated: entry.LastUpdated,
+			Stale:       time.Since(entry.LastUpdated) > ttlFor(base, to),
+			Cached:      true,
+			Source:      entry.Source,
+		}
+	}
+	return quotes, nil
+}
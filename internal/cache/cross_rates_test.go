@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+
+	"exchange-rate-service/config"
+)
+
+// USD-based quotes from one market snapshot
+var snapshotUSDRates = map[string]float64{
+	"INR": 83.1234,
+	"EUR": 0.9215,
+	"JPY": 149.87,
+	"GBP": 0.7893,
+}
+
+// direct quotes for cross pairs from the same snapshot - these carry their own
+// spread/rounding, so derived rates should match closely but not exactly
+var snapshotDirectQuotes = map[currencyPair]float64{
+	{"EUR", "GBP"}: 0.8565,
+	{"GBP", "EUR"}: 1.1675,
+	{"EUR", "INR"}: 90.205,
+	{"GBP", "JPY"}: 189.88,
+	{"JPY", "INR"}: 0.55464,
+	{"INR", "EUR"}: 0.011086,
+	{"USD", "EUR"}: 0.9215,
+	{"EUR", "USD"}: 1.0852,
+}
+
+// baseQuoteClient answers base->X from snapshotUSDRates and counts calls
+type baseQuoteClient struct {
+	mu    sync.Mutex
+	calls []currencyPair
+}
+
+func (c *baseQuoteClient) GetRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, error) {
+	c.mu.Lock()
+	c.calls = append(c.calls, currencyPair{fromCurrency, toCurrency})
+	c.mu.Unlock()
+	return snapshotUSDRates[toCurrency], nil
+}
+
+func TestCrossRate(t *testing.T) {
+	rate, err := crossRate(0.9215, 0.7893)
+	if err != nil {
+		t.Fatalf("crossRate failed: %v", err)
+	}
+	if math.Abs(rate-0.7893/0.9215) > 1e-15 {
+		t.Errorf("unexpected cross rate %v", rate)
+	}
+
+	if _, err := crossRate(0, 1); err == nil {
+		t.Error("expected an error for a zero base rate")
+	}
+}
+
+func TestDeriveRates_MatchesDirectQuotes(t *testing.T) {
+	currencies := []string{"USD", "INR", "EUR", "JPY", "GBP"}
+	baseRates := map[string]float64{"USD": 1}
+	for code, rate := range snapshotUSDRates {
+		baseRates[code] = rate
+	}
+
+	derived, missing := deriveRates(currencies, baseRates)
+	if len(missing) != 0 {
+		t.Fatalf("unexpected missing pairs: %v", missing)
+	}
+	if len(derived) != 20 {
+		t.Fatalf("expected all 20 ordered pairs, got %d", len(derived))
+	}
+
+	for pair, direct := range snapshotDirectQuotes {
+		got := derived[pair]
+		if relErr := math.Abs(got-direct) / direct; relErr > 5e-4 {
+			t.Errorf("%s: derived %.6f vs direct %.6f (%.4f%% off)", pair, got, direct, relErr*100)
+		}
+	}
+
+	// a pair and its inverse must multiply back to 1
+	for pair, rate := range derived {
+		inverse := derived[currencyPair{pair.To, pair.From}]
+		if math.Abs(rate*inverse-1) > 1e-12 {
+			t.Errorf("%s and its inverse are inconsistent: %v * %v", pair, rate, inverse)
+		}
+	}
+}
+
+func TestDerivedSource(t *testing.T) {
+	tests := []struct{ from, to, want string }{
+		{"", "ecb", "ecb"},
+		{"ecb", "", "ecb"},
+		{"ecb", "ecb", "ecb"},
+		{"frankfurter", "ecb", "frankfurter+ecb"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := derivedSource(tt.from, tt.to); got != tt.want {
+			t.Errorf("derivedSource(%q, %q) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestDeriveRates_MissingBaseQuote(t *testing.T) {
+	derived, missing := deriveRates([]string{"USD", "EUR", "GBP"}, map[string]float64{"USD": 1, "EUR": 0.92})
+
+	if len(derived) != 2 {
+		t.Errorf("expected only USD/EUR pairs, got %v", derived)
+	}
+	if len(missing) != 4 {
+		t.Errorf("expected the 4 GBP pairs to be missing, got %v", missing)
+	}
+}
+
+func TestRefreshAllRates_FetchesOnlyBaseQuotes(t *testing.T) {
+	client := &baseQuoteClient{}
+	rateCache := NewExchangeRateCache(client, NewMemoryCache(), "test:")
+
+	rateCache.refreshAllRates()
+
+	core := config.GetCoreCurrencies()
+	if len(client.calls) != len(core)-1 {
+		t.Fatalf("expected %d upstream calls, got %d: %v", len(core)-1, len(client.calls), client.calls)
+	}
+	for _, call := range client.calls {
+		if call.From != config.RefreshBaseCurrency {
+			t.Errorf("expected only %s-based calls, got %s", config.RefreshBaseCurrency, call)
+		}
+	}
+
+	stats := rateCache.GetCacheStats()
+	if stats["total_pairs"] != len(core)*(len(core)-1) {
+		t.Errorf("expected every ordered pair cached, got %v", stats["total_pairs"])
+	}
+
+	rate, found := rateCache.GetRate("GBP", "EUR")
+	if !found || math.Abs(rate-snapshotDirectQuotes[currencyPair{"GBP", "EUR"}]) > 1e-3 {
+		t.Errorf("expected derived GBP-EUR near the direct quote, got %v (found %v)", rate, found)
+	}
+}
+
+func TestExchangeRateCache_QuotesFrom(t *testing.T) {
+	rateCache := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "test:")
+	rateCache.SetRate("USD", "EUR", 0.9, "exchangerate-api")
+	rateCache.SetRate("USD", "INR", 83, "exchangerate-api")
+	rateCache.SetRate("EUR", "INR", 92, "exchangerate-api")
+
+	quotes, err := rateCache.QuotesFrom("usd")
+	if err != nil {
+		t.Fatalf("QuotesFrom failed: %v", err)
+	}
+	if len(quotes) != 2 || quotes["EUR"].Rate != 0.9 || quotes["INR"].Rate != 83 || quotes["EUR"].Stale {
+		t.Errorf("expected the two fresh USD quotes, got %+v", quotes)
+	}
+	if stats := rateCache.Stats(); stats.Lookups.Hits+stats.Lookups.Misses != 0 {
+		t.Errorf("expected the scan to stay out of the lookup stats, got %+v", stats)
+	}
+}
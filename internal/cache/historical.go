@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/metrics"
+	"exchange-rate-service/internal/models"
+)
+
+// HistoricalCache keeps past days' rates in process memory, least recently
+// used first out. A past day's rate never changes, so entries don't expire -
+// the size bound is the only thing that removes them.
+type HistoricalCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List // front = most recently used
+	entries map[string]*list.Element
+
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+type historicalEntry struct {
+	key   string
+	quote models.RateQuote
+}
+
+// HistoricalCacheStats is the historical cache section of the admin stats
+type HistoricalCacheStats struct {
+	Size      int     `json:"size"`
+	Capacity  int     `json:"capacity"`
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	Evictions int64   `json:"evictions"`
+	HitRatio  float64 `json:"hit_ratio"`
+}
+
+// NewHistoricalCache creates an LRU holding up to capacity pair+date entries
+func NewHistoricalCache(capacity int) *HistoricalCache {
+	return &HistoricalCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the quote for from-to on date and marks it recently used
+func (h *HistoricalCache) Get(from, to, date string) (models.RateQuote, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	element, found := h.entries[historicalKey(from, to, date)]
+	metrics.RecordHistoricalCacheLookup(found)
+	if !found {
+		h.misses++
+		return models.RateQuote{}, false
+	}
+
+	h.hits++
+	h.order.MoveToFront(element)
+	return element.Value.(*historicalEntry).quote, true
+}
+
+// Set stores the quote for a past day, evicting the least recently used entry when full
+// Today (UTC) and later are ignored - the day's rate isn't final until it's over
+func (h *HistoricalCache) Set(from, to, date string, quote models.RateQuote) {
+	if date >= time.Now().UTC().Format("2006-01-02") {
+		return
+	}
+
+	key := historicalKey(from, to, date)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if element, found := h.entries[key]; found {
+		element.Value.(*historicalEntry).quote = quote
+		h.order.MoveToFront(element)
+		return
+	}
+
+	h.entries[key] = h.order.PushFront(&historicalEntry{key: key, quote: quote})
+
+	for h.order.Len() > h.capacity {
+		oldest := h.order.Back()
+		h.order.Remove(oldest)
+		delete(h.entries, oldest.Value.(*historicalEntry).key)
+		h.evictions++
+	}
+}
+
+// Stats reports size and hit counters
+func (h *HistoricalCache) Stats() HistoricalCacheStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := HistoricalCacheStats{
+		Size:      h.order.Len(),
+		Capacity:  h.capacity,
+		Hits:      h.hits,
+		Misses:    h.misses,
+		Evictions: h.evictions,
+	}
+	if lookups := h.hits + h.misses; lookups > 0 {
+		stats.HitRatio = float64(h.hits) / float64(lookups)
+	}
+	return stats
+}
+
+func historicalKey(from, to, date string) string {
+	return buildRateKey(from, to) + "|" + date
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/models"
+)
+
+func TestHistoricalCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	lru := NewHistoricalCache(2)
+
+	lru.Set("USD", "EUR", "2024-01-01", models.RateQuote{Rate: 0.91, Source: "ecb"})
+	lru.Set("USD", "EUR", "2024-01-02", models.RateQuote{Rate: 0.92, Source: "ecb"})
+
+	// touching 01-01 makes 01-02 the oldest
+	if quote, found := lru.Get("usd", "eur", "2024-01-01"); !found || quote.Rate != 0.91 || quote.Source != "ecb" {
+		t.Fatalf("expected 0.91 from ecb, got %+v (found %v)", quote, found)
+	}
+	lru.Set("USD", "EUR", "2024-01-03", models.RateQuote{Rate: 0.93, Source: "ecb"})
+
+	if _, found := lru.Get("USD", "EUR", "2024-01-02"); found {
+		t.Error("expected the least recently used day to be evicted")
+	}
+	if _, found := lru.Get("USD", "EUR", "2024-01-01"); !found {
+		t.Error("expected the recently read day to survive")
+	}
+
+	stats := lru.Stats()
+	if stats.Size != 2 || stats.Capacity != 2 || stats.Evictions != 1 || stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestHistoricalCache_IgnoresToday(t *testing.T) {
+	lru := NewHistoricalCache(10)
+	today := time.Now().UTC().Format("2006-01-02")
+
+	lru.Set("USD", "EUR", today, models.RateQuote{Rate: 0.9})
+	if _, found := lru.Get("USD", "EUR", today); found {
+		t.Error("today's rate isn't final and must not be cached")
+	}
+}
+
+func TestExchangeRateCache_HistoricalStats(t *testing.T) {
+	defer func(size int) { config.HistoricalCacheSize = size }(config.HistoricalCacheSize)
+
+	config.HistoricalCacheSize = 0
+	disabled := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "test:")
+	disabled.SetHistoricalRate("USD", "EUR", "2024-01-01", models.RateQuote{Rate: 0.9})
+	if _, found := disabled.GetHistoricalRate("USD", "EUR", "2024-01-01"); found {
+		t.Error("a zero size should disable the historical cache")
+	}
+	if _, listed := disabled.GetCacheStats()["historical"]; listed {
+		t.Error("disabled historical cache should not appear in stats")
+	}
+
+	config.HistoricalCacheSize = 10
+	rateCache := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "test:")
+	rateCache.SetHistoricalRate("USD", "EUR", "2024-01-01", models.RateQuote{Rate: 0.9, Source: "frankfurter"})
+
+	quote, found := rateCache.GetHistoricalRate("USD", "EUR", "2024-01-01")
+	if !found || quote.Rate != 0.9 || !quote.Cached || quote.Source != "frankfurter" {
+		t.Errorf("unexpected historical quote: %+v (found %v)", quote, found)
+	}
+	if stats, ok := rateCache.GetCacheStats()["historical"].(HistoricalCacheStats); !ok || stats.Hits != 1 || stats.HitRatio != 1 {
+		t.Errorf("expected one hit in stats, got %+v", stats)
+	}
+}
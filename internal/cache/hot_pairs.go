@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/currency"
+)
+
+// parseHotPairs turns "FROM-TO" entries into pairs, dropping malformed ones
+// and duplicates
+func parseHotPairs(entries []string) []currencyPair {
+	pairs := make([]currencyPair, 0, len(entries))
+	seen := make(map[currencyPair]bool, len(entries))
+
+	for _, entry := range entries {
+		from, to, ok := strings.Cut(strings.ToUpper(strings.TrimSpace(entry)), "-")
+		if !ok || !currency.ValidCode(from) || !currency.ValidCode(to) || from == to {
+			slog.Warn("Ignoring malformed hot pair (expected FROM-TO)", "entry", entry)
+			continue
+		}
+
+		pair := currencyPair{From: from, To: to}
+		if !seen[pair] {
+			seen[pair] = true
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// pairRefreshLoop re-fetches pairs on their own, shorter interval. Hot pairs
+// are filled by the full cycle at startup so they wait a tick; crypto and
+// metals aren't part of it and are fetched right away (immediate)
+func (cache *ExchangeRateCache) pairRefreshLoop(pairs []currencyPair, interval time.Duration, immediate bool) {
+	defer cache.backgroundWorkers.Done()
+
+	pairTicker := time.NewTicker(interval)
+	defer pairTicker.Stop()
+
+	if immediate {
+		cache.refreshPairs(pairs)
+	}
+
+	for {
+		select {
+		case <-pairTicker.C:
+			cache.refreshPairs(pairs)
+		case <-cache.shutdownChannel:
+			return
+		}
+	}
+}
+
+// refreshPairs fetches each pair directly and caches it with its inverse
+// Followers leave this to the refresh leader - the rates land in the shared backend
+func (cache *ExchangeRateCache) refreshPairs(pairs []currencyPair) {
+	if !cache.IsRefreshLeader() {
+		return
+	}
+	cycleStart := time.Now()
+	updated := 0
+	failures := make(map[currencyPair]error)
+	// failures only count against pairs when other pairs of the batch came through
+	defer func() {
+		for pair, err := range failures {
+			cache.recordPairFailure(pair, err, updated > 0)
+		}
+	}()
+
+	for _, pair := range pairs {
+		if config.IsCurrencySunset(pair.From) || config.IsCurrencySunset(pair.To) {
+			continue
+		}
+		if _, backedOff := cache.unavailable.backedOff(pair, cycleStart); backedOff {
+			continue
+		}
+		if !cache.waitForUpstreamSlot() {
+			return
+		}
+
+		rate, source, err := cache.fetchRate(pair.From, pair.To)
+		if err == nil && rate <= 0 {
+			err = fmt.Errorf("invalid rate: %f", rate)
+		}
+		if err != nil {
+			slog.Warn("Failed to refresh pair", "pair", pair.String(), "error", err)
+			failures[pair] = err
+			continue
+		}
+
+		cache.SetRate(pair.From, pair.To, rate, source)
+		cache.SetRate(pair.To, pair.From, 1/rate, source)
+		updated++
+	}
+
+	slog.Debug("Pair refresh completed",
+		"updated", updated, "total", len(pairs), "latency_ms", time.Since(cycleStart).Milliseconds())
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+func TestParseHotPairs(t *testing.T) {
+	pairs := parseHotPairs([]string{"usd-eur", " EUR-GBP ", "USD-EUR", "USDEUR", "US-EUR", "USD-USD"})
+
+	if len(pairs) != 2 || pairs[0] != (currencyPair{"USD", "EUR"}) || pairs[1] != (currencyPair{"EUR", "GBP"}) {
+		t.Errorf("expected [USD-EUR EUR-GBP], got %v", pairs)
+	}
+}
+
+func TestRefreshPairs_CachesPairAndInverse(t *testing.T) {
+	client := &baseQuoteClient{}
+	rateCache := NewExchangeRateCache(client, NewMemoryCache(), "test:")
+
+	rateCache.refreshPairs([]currencyPair{{"USD", "INR"}})
+
+	if len(client.calls) != 1 || client.calls[0] != (currencyPair{"USD", "INR"}) {
+		t.Fatalf("expected one direct USD-INR call, got %v", client.calls)
+	}
+	if rate, found := rateCache.GetRate("USD", "INR"); !found || rate != snapshotUSDRates["INR"] {
+		t.Errorf("expected USD-INR %v, got %v (found %v)", snapshotUSDRates["INR"], rate, found)
+	}
+	if rate, found := rateCache.GetRate("INR", "USD"); !found || rate != 1/snapshotUSDRates["INR"] {
+		t.Errorf("expected inverse INR-USD, got %v (found %v)", rate, found)
+	}
+}
+
+func TestStartRefresh_HotPairsOnTheirOwnTicker(t *testing.T) {
+	defer func(pairs []string, hot, full time.Duration) {
+		config.HotPairs, config.HotPairRefreshInterval, config.CacheRefreshInterval = pairs, hot, full
+	}(config.HotPairs, config.HotPairRefreshInterval, config.CacheRefreshInterval)
+
+	config.HotPairs = []string{"USD-EUR"}
+	config.HotPairRefreshInterval = 10 * time.Millisecond
+	config.CacheRefreshInterval = time.Hour
+
+	client := &baseQuoteClient{}
+	rateCache := NewExchangeRateCache(client, NewMemoryCache(), "test:")
+	rateCache.StartRefresh()
+
+	deadline := time.Now().Add(2 * time.Second)
+	hotCalls := 0
+	for time.Now().Before(deadline) && hotCalls < 3 {
+		time.Sleep(10 * time.Millisecond)
+		client.mu.Lock()
+		hotCalls = 0
+		for _, call := range client.calls {
+			if call == (currencyPair{"USD", "EUR"}) {
+				hotCalls++
+			}
+		}
+		client.mu.Unlock()
+	}
+	rateCache.Stop()
+
+	// one call from the initial full cycle, the rest from the hot ticker
+	if hotCalls < 3 {
+		t.Errorf("expected the hot pair to be refreshed repeatedly, got %d calls", hotCalls)
+	}
+}
+
+func TestGetRateEntry_CryptoUsesItsOwnTTL(t *testing.T) {
+	defer func(fiat, crypto time.Duration) {
+		config.CacheTTL, config.CryptoCacheTTL = fiat, crypto
+	}(config.CacheTTL, config.CryptoCacheTTL)
+	config.CacheTTL = time.Hour
+	config.CryptoCacheTTL = 0
+
+	rateCache := NewExchangeRateCache(&baseQuoteClient{}, NewMemoryCache(), "test:")
+	rateCache.SetRate("USD", "EUR", 0.92, "test")
+	rateCache.SetRate("BTC", "USD", 60000, "test")
+
+	if quote, _ := rateCache.GetRateEntry("USD", "EUR"); quote.Stale {
+		t.Error("fiat pair should still be fresh under CACHE_TTL")
+	}
+	if quote, _ := rateCache.GetRateEntry("BTC", "USD"); !quote.Stale {
+		t.Error("crypto pair should be stale once past CRYPTO_CACHE_TTL")
+	}
+}
+
+func TestStartRefresh_FetchesTrackedAssetsRightAway(t *testing.T) {
+	defer func(crypto, full time.Duration) {
+		config.CryptoRefreshInterval, config.CacheRefreshInterval = crypto, full
+	}(config.CryptoRefreshInterval, config.CacheRefreshInterval)
+	config.CryptoRefreshInterval = time.Hour
+	config.CacheRefreshInterval = time.Hour
+
+	rateCache := NewExchangeRateCache(&stubAPIClient{rate: 60000}, NewMemoryCache(), "test:")
+	rateCache.TrackAssets([]string{"btc", config.RefreshBaseCurrency})
+	rateCache.StartRefresh()
+
+	deadline := time.Now().Add(2 * time.Second)
+	found := false
+	for time.Now().Before(deadline) && !found {
+		time.Sleep(10 * time.Millisecond)
+		_, found = rateCache.GetRateEntry("BTC", config.RefreshBaseCurrency)
+	}
+	rateCache.Stop()
+
+	if !found {
+		t.Error("expected the tracked asset to be fetched without waiting for a tick")
+	}
+	if len(rateCache.assetPairs) != 1 {
+		t.Errorf("the refresh base should not be tracked against itself, got %v", rateCache.assetPairs)
+	}
+}
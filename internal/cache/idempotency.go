@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// IdempotencyStore keeps the first response to each Idempotency-Key in the
+// cache backend for ttl, so retries get it replayed. Keys are scoped to the
+// caller like quotes are, and shared between replicas on redis. A short lease
+// marks a key whose first request is still running
+type IdempotencyStore struct {
+	backend   Cache
+	keyPrefix string
+	ttl       time.Duration
+}
+
+// NewIdempotencyStore stores responses in backend for ttl. Keys stay outside
+// keyPrefix so rate listings never see them
+func NewIdempotencyStore(backend Cache, keyPrefix string, ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		backend:   backend,
+		keyPrefix: "idempotency:" + keyPrefix,
+		ttl:       ttl,
+	}
+}
+
+// Lock claims owner's key for the request identified by token, until Unlock
+// or ttl. false means another request holds it. Backends without leases
+// can't lock, and let every request through
+func (s *IdempotencyStore) Lock(ctx context.Context, owner, key, token string, ttl time.Duration) (bool, error) {
+	leaser, ok := s.backend.(Leaser)
+	if !ok {
+		return true, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, backendTimeout)
+	defer cancel()
+	return leaser.AcquireLease(ctx, s.key(owner, key)+":lock", token, ttl)
+}
+
+// Unlock releases the lock token holds on owner's key
+func (s *IdempotencyStore) Unlock(ctx context.Context, owner, key, token string) error {
+	leaser, ok := s.backend.(Leaser)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, backendTimeout)
+	defer cancel()
+	return leaser.ReleaseLease(ctx, s.key(owner, key)+":lock", token)
+}
+
+// Get returns the response stored for owner's key, found is false when there
+// is none or it expired
+func (s *IdempotencyStore) Get(ctx context.Context, owner, key string) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, backendTimeout)
+	defer cancel()
+
+	data, err := s.backend.Get(ctx, s.key(owner, key))
+	if errors.Is(err, ErrCacheMiss) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Save stores the response to owner's key for the store's ttl
+func (s *IdempotencyStore) Save(ctx context.Context, owner, key string, response []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, backendTimeout)
+	defer cancel()
+	return s.backend.Set(ctx, s.key(owner, key), response, s.ttl)
+}
+
+// key hashes owner and the client's key, neither of which should land in the
+// backend as-is
+func (s *IdempotencyStore) key(owner, key string) string {
+	ownerSum := sha256.Sum256([]byte(owner))
+	keySum := sha256.Sum256([]byte(key))
+	return s.keyPrefix + hex.EncodeToString(ownerSum[:8]) + ":" + hex.EncodeToString(keySum[:16])
+}
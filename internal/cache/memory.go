@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryShards splits the keyspace so a reader never waits on a writer of
+// another key - a refresh cycle's writes, and sweeps, only lock the shard
+// they touch. A power of two, so a mask picks the shard
+const memoryShards = 64
+
+// every this many Sets to a shard, its expired items are swept out -
+// short-lived keys like quotes would otherwise pile up when nobody reads them again
+const memorySweepEvery = 64
+
+// MemoryCache is the default in-process Cache backend
+type MemoryCache struct {
+	shards [memoryShards]memoryShard
+}
+
+type memoryShard struct {
+	mu    sync.RWMutex
+	items map[string]memoryItem
+	sets  int
+
+	// keeps neighbouring shards' locks off the same cache line
+	_ [24]byte
+}
+
+type memoryItem struct {
+	value     []byte
+	expiresAt time.Time // zero means no expiry
+}
+
+// NewMemoryCache creates an empty in-memory backend
+func NewMemoryCache() *MemoryCache {
+	m := &MemoryCache{}
+	for i := range m.shards {
+		m.shards[i].items = make(map[string]memoryItem)
+	}
+	return m
+}
+
+// shard picks key's shard by its FNV-1a hash
+func (m *MemoryCache) shard(key string) *memoryShard {
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+	return &m.shards[hash&(memoryShards-1)]
+}
+
+// Get returns the value for key or ErrCacheMiss
+func (m *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	shard := m.shard(key)
+	shard.mu.RLock()
+	item, found := shard.items[key]
+	shard.mu.RUnlock()
+
+	if !found || item.expired(time.Now()) {
+		return nil, ErrCacheMiss
+	}
+
+	return item.value, nil
+}
+
+// Set stores value under key - ttl of 0 keeps it forever
+func (m *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	item := memoryItem{value: value}
+	if ttl > 0 {
+		item.expiresAt = time.Now().Add(ttl)
+	}
+
+	shard := m.shard(key)
+	shard.mu.Lock()
+	shard.items[key] = item
+	shard.sets++
+	if shard.sets%memorySweepEvery == 0 {
+		shard.sweep(time.Now())
+	}
+	shard.mu.Unlock()
+
+	return nil
+}
+
+// Take returns the value for key and removes it in one step, ErrCacheMiss when absent
+func (m *MemoryCache) Take(ctx context.Context, key string) ([]byte, error) {
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	item, found := shard.items[key]
+	if !found || item.expired(time.Now()) {
+		return nil, ErrCacheMiss
+	}
+	delete(shard.items, key)
+	return item.value, nil
+}
+
+// sweep drops expired items - caller holds the shard's write lock
+func (s *memoryShard) sweep(now time.Time) {
+	for key, item := range s.items {
+		if item.expired(now) {
+			delete(s.items, key)
+		}
+	}
+}
+
+// Delete removes key if present
+func (m *MemoryCache) Delete(ctx context.Context, key string) error {
+	shard := m.shard(key)
+	shard.mu.Lock()
+	delete(shard.items, key)
+	shard.mu.Unlock()
+
+	return nil
+}
+
+// Exists reports whether key holds a live value
+func (m *MemoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := m.Get(ctx, key)
+	return err == nil, nil
+}
+
+// Keys lists live keys starting with prefix, locking one shard at a time
+func (m *MemoryCache) Keys(ctx context.Context, prefix string) ([]string, error) {
+	now := time.Now()
+
+	keys := make([]string, 0)
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.RLock()
+		for key, item := range shard.items {
+			if strings.HasPrefix(key, prefix) && !item.expired(now) {
+				keys = append(keys, key)
+			}
+		}
+		shard.mu.RUnlock()
+	}
+
+	return keys, nil
+}
+
+// AcquireLease takes key for owner, or extends it when owner already holds it
+// Holds within this process only - enough for idempotency locks, while refresh
+// coordination only gets anything from it in tests
+func (m *MemoryCache) AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if item, found := shard.items[key]; found && !item.expired(now) && string(item.value) != owner {
+		return false, nil
+	}
+	shard.items[key] = memoryItem{value: []byte(owner), expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// ReleaseLease removes key if owner still holds it
+func (m *MemoryCache) ReleaseLease(ctx context.Context, key, owner string) error {
+	shard := m.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if item, found := shard.items[key]; found && string(item.value) == owner {
+		delete(shard.items, key)
+	}
+	return nil
+}
+
+func (item memoryItem) expired(now time.Time) bool {
+	return !item.expiresAt.IsZero() && now.After(item.expiresAt)
+}
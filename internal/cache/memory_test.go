@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache_SweepsExpiredItems(t *testing.T) {
+	backend := NewMemoryCache()
+	ctx := context.Background()
+
+	if err := backend.Set(ctx, "quote:1", []byte("x"), time.Nanosecond); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	// enough writes that every shard sweeps at least once
+	for i := 0; i < memoryShards*memorySweepEvery*4; i++ {
+		_ = backend.Set(ctx, fmt.Sprintf("rate:%d", i%500), []byte("1"), 0)
+	}
+
+	shard := backend.shard("quote:1")
+	shard.mu.RLock()
+	_, found := shard.items["quote:1"]
+	shard.mu.RUnlock()
+	if found {
+		t.Fatal("Expected the expired item to be swept out")
+	}
+
+	keys, _ := backend.Keys(ctx, "rate:")
+	if len(keys) != 500 {
+		t.Fatalf("Expected 500 live keys, got %d", len(keys))
+	}
+}
+
+// lockedMapCache is the single-RWMutex store MemoryCache used to be, kept as
+// the baseline the sharded one is benchmarked against
+type lockedMapCache struct {
+	mu    sync.RWMutex
+	items map[string]memoryItem
+}
+
+func (l *lockedMapCache) Get(ctx context.Context, key string) ([]byte, error) {
+	l.mu.RLock()
+	item, found := l.items[key]
+	l.mu.RUnlock()
+	if !found || item.expired(time.Now()) {
+		return nil, ErrCacheMiss
+	}
+	return item.value, nil
+}
+
+func (l *lockedMapCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	l.items[key] = memoryItem{value: value}
+	l.mu.Unlock()
+	return nil
+}
+
+type getSetter interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// benchmarkStores runs bench against the single-lock baseline and MemoryCache
+func benchmarkStores(b *testing.B, bench func(b *testing.B, store getSetter)) {
+	b.Run("single-lock", func(b *testing.B) {
+		bench(b, &lockedMapCache{items: make(map[string]memoryItem)})
+	})
+	b.Run("sharded", func(b *testing.B) {
+		bench(b, NewMemoryCache())
+	})
+}
+
+var benchKeys = func() []string {
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("exchange_rate:C%03d_USD", i)
+	}
+	return keys
+}()
+
+func seedStore(store getSetter) {
+	for _, key := range benchKeys {
+		_ = store.Set(context.Background(), key, []byte(`{"exchange_rate":1.1}`), 0)
+	}
+}
+
+// keepRefreshing rewrites every key in a loop, like a refresh cycle that never
+// ends, until the returned stop is called
+func keepRefreshing(store getSetter, writers int) (stop func()) {
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; !done.Load(); i++ {
+				_ = store.Set(context.Background(), benchKeys[i%len(benchKeys)], []byte(`{"exchange_rate":1.2}`), 0)
+			}
+		}(w)
+	}
+	return func() {
+		done.Store(true)
+		wg.Wait()
+	}
+}
+
+func BenchmarkMemoryCache_ParallelReads(b *testing.B) {
+	benchmarkStores(b, func(b *testing.B, store getSetter) {
+		seedStore(store)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			ctx := context.Background()
+			for i := 0; pb.Next(); i++ {
+				_, _ = store.Get(ctx, benchKeys[i%len(benchKeys)])
+			}
+		})
+	})
+}
+
+func BenchmarkMemoryCache_ReadsDuringRefresh(b *testing.B) {
+	benchmarkStores(b, func(b *testing.B, store getSetter) {
+		seedStore(store)
+		stop := keepRefreshing(store, 4)
+		defer stop()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			ctx := context.Background()
+			for i := 0; pb.Next(); i++ {
+				_, _ = store.Get(ctx, benchKeys[i%len(benchKeys)])
+			}
+		})
+	})
+}
+
+func BenchmarkExchangeRateCache_GetRateDuringRefresh(b *testing.B) {
+	rateCache := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "bench:")
+	for i := 0; i < 100; i++ {
+		rateCache.SetRate(fmt.Sprintf("C%02d", i), "USD", 1.1, "test")
+	}
+
+	var done atomic.Bool
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for i := 0; !done.Load(); i++ {
+			rateCache.SetRate(fmt.Sprintf("C%02d", i%100), "USD", 1.2, "test")
+		}
+	}()
+	defer func() {
+		done.Store(true)
+		<-finished
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			rateCache.GetRate(fmt.Sprintf("C%02d", i%100), "USD")
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/client"
+	"exchange-rate-service/internal/metrics"
+)
+
+// pairFailures is the negative cache: pairs the provider keeps failing (it
+// doesn't quote them, say) are backed off exponentially so the refresh loops
+// and cache misses stop asking every cycle. Kept in process - each replica
+// learns it from its own refreshes within a few cycles
+type pairFailures struct {
+	mu      sync.Mutex
+	entries map[currencyPair]*pairFailure
+}
+
+// pairFailure is a pair's run of failed refreshes
+type pairFailure struct {
+	failures int
+	retryAt  time.Time // zero until the run reaches config.PairFailureThreshold
+	lastErr  string
+}
+
+// record counts a failed refresh of pair, backing it off once the run reaches
+// the threshold. Returns the backoff when this failure started or extended one
+func (p *pairFailures) record(pair currencyPair, err error, now time.Time) (time.Duration, bool) {
+	if config.PairFailureThreshold <= 0 {
+		return 0, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.entries == nil {
+		p.entries = make(map[currencyPair]*pairFailure)
+	}
+	entry, found := p.entries[pair]
+	if !found {
+		entry = &pairFailure{}
+		p.entries[pair] = entry
+	}
+	entry.failures++
+	entry.lastErr = err.Error()
+
+	over := entry.failures - config.PairFailureThreshold
+	if over < 0 {
+		return 0, false
+	}
+	backoff := config.PairBackoffInitial
+	for i := 0; i < over && backoff < config.PairBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > config.PairBackoffMax {
+		backoff = config.PairBackoffMax
+	}
+	entry.retryAt = now.Add(backoff)
+	return backoff, true
+}
+
+// clear forgets pair's failures after it was fetched
+func (p *pairFailures) clear(pair currencyPair) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.entries, pair)
+}
+
+// backedOff returns pair's failure run while it is in backoff. Once the
+// backoff has passed the pair is tried again, and one more failure backs it
+// off for twice as long
+func (p *pairFailures) backedOff(pair currencyPair, now time.Time) (pairFailure, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, found := p.entries[pair]
+	if !found || entry.retryAt.IsZero() || !now.Before(entry.retryAt) {
+		return pairFailure{}, false
+	}
+	return *entry, true
+}
+
+// count is the number of pairs in backoff
+func (p *pairFailures) count(now time.Time) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	backedOff := 0
+	for _, entry := range p.entries {
+		if !entry.retryAt.IsZero() && now.Before(entry.retryAt) {
+			backedOff++
+		}
+	}
+	return backedOff
+}
+
+// snapshot lists the pairs in backoff, for stats
+func (p *pairFailures) snapshot(now time.Time) []map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pairs := make([]map[string]interface{}, 0)
+	for pair, entry := range p.entries {
+		if entry.retryAt.IsZero() || !now.Before(entry.retryAt) {
+			continue
+		}
+		pairs = append(pairs, map[string]interface{}{
+			"pair":       pair.String(),
+			"failures":   entry.failures,
+			"retry_at":   entry.retryAt,
+			"last_error": entry.lastErr,
+		})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i]["pair"].(string) < pairs[j]["pair"].(string) })
+	return pairs
+}
+
+// PairUnavailable reports whether from-to (or its inverse) is backed off after
+// failing refresh after refresh, and when it will next be tried
+func (cache *ExchangeRateCache) PairUnavailable(fromCurrency, toCurrency string) (time.Time, bool) {
+	now := time.Now()
+	pair := currencyPair{
+		From: strings.ToUpper(strings.TrimSpace(fromCurrency)),
+		To:   strings.ToUpper(strings.TrimSpace(toCurrency)),
+	}
+	for _, candidate := range []currencyPair{pair, {From: pair.To, To: pair.From}} {
+		if entry, found := cache.unavailable.backedOff(candidate, now); found {
+			return entry.retryAt, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// recordPairFailure counts err against pair. answered says whether the
+// provider quoted other pairs in the same batch - when it didn't, the failure
+// is an outage rather than the pair's fault and isn't counted
+func (cache *ExchangeRateCache) recordPairFailure(pair currencyPair, err error, answered bool) {
+	if !answered || !countsAgainstPair(err) {
+		return
+	}
+	if backoff, backedOff := cache.unavailable.record(pair, err, time.Now()); backedOff {
+		metrics.RecordPairBackoff()
+		slog.Warn("Pair keeps failing, backing it off", "pair", pair.String(), "backoff", backoff.String(), "error", err)
+	}
+}
+
+// countsAgainstPair leaves out failures that say nothing about the pair:
+// quota, an open breaker, a provider rejecting our key or plan, a replica
+// that doesn't fetch, shutdown
+func countsAgainstPair(err error) bool {
+	return !errors.Is(err, client.ErrRateLimited) &&
+		!errors.Is(err, client.ErrCircuitOpen) &&
+		!errors.Is(err, apperrors.ErrUpstreamRejected) &&
+		!errors.Is(err, apperrors.ErrReadOnlyReplica) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
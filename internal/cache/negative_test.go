@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+// pickyClient quotes every pair except those to currencies it doesn't know,
+// or nothing at all while down
+type pickyClient struct {
+	mu      sync.Mutex
+	unknown map[string]bool
+	down    bool
+	calls   map[string]int
+}
+
+func (c *pickyClient) GetRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[toCurrency]++
+	if c.down {
+		return 0, errors.New("connection refused")
+	}
+	if c.unknown[toCurrency] {
+		return 0, errors.New("api error: unsupported-code")
+	}
+	return 2, nil
+}
+
+func (c *pickyClient) callsTo(code string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[code]
+}
+
+// withPairBackoff sets the backoff config for one test
+func withPairBackoff(t *testing.T, threshold int, initial, max time.Duration) {
+	t.Helper()
+	previousThreshold, previousInitial, previousMax := config.PairFailureThreshold, config.PairBackoffInitial, config.PairBackoffMax
+	t.Cleanup(func() {
+		config.PairFailureThreshold, config.PairBackoffInitial, config.PairBackoffMax = previousThreshold, previousInitial, previousMax
+	})
+	config.PairFailureThreshold, config.PairBackoffInitial, config.PairBackoffMax = threshold, initial, max
+}
+
+func TestPairFailures_BackoffDoublesUpToMax(t *testing.T) {
+	withPairBackoff(t, 2, time.Minute, 5*time.Minute)
+	var failures pairFailures
+	pair := currencyPair{From: "USD", To: "XAA"}
+	now := time.Now()
+
+	want := []time.Duration{0, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, expected := range want {
+		backoff, backedOff := failures.record(pair, errors.New("boom"), now)
+		if backoff != expected || backedOff != (expected > 0) {
+			t.Errorf("failure %d: backoff = %v (%v), want %v", i+1, backoff, backedOff, expected)
+		}
+	}
+	if _, found := failures.backedOff(pair, now.Add(4*time.Minute)); !found {
+		t.Error("pair should still be backed off")
+	}
+	if _, found := failures.backedOff(pair, now.Add(6*time.Minute)); found {
+		t.Error("pair should be retried once the backoff has passed")
+	}
+
+	failures.clear(pair)
+	if backoff, _ := failures.record(pair, errors.New("boom"), now); backoff != 0 {
+		t.Errorf("a fetch should reset the run, got backoff %v", backoff)
+	}
+}
+
+func TestRefreshAllRates_BacksOffFailingPair(t *testing.T) {
+	withRefreshConfig(t, 4, 2)
+	withPairBackoff(t, 2, time.Hour, time.Hour)
+	apiClient := &pickyClient{unknown: map[string]bool{"X02": true}}
+	rateCache := NewExchangeRateCache(apiClient, NewMemoryCache(), "test:")
+
+	for i := 0; i < 4; i++ {
+		rateCache.refreshAllRates()
+	}
+
+	if calls := apiClient.callsTo("X02"); calls != 2 {
+		t.Errorf("expected X02 to be asked for twice before backing off, got %d", calls)
+	}
+	if calls := apiClient.callsTo("X01"); calls != 4 {
+		t.Errorf("expected the working pairs every cycle, got %d", calls)
+	}
+	if _, unavailable := rateCache.PairUnavailable("X02", "USD"); !unavailable {
+		t.Error("the inverse of a backed off pair should be unavailable too")
+	}
+	if stats := rateCache.Stats(); stats.Refresh.PairsBackedOff != 1 {
+		t.Errorf("pairs_backed_off = %d, want 1", stats.Refresh.PairsBackedOff)
+	}
+
+	// a rate fetched on another path clears the backoff
+	rateCache.SetRate("USD", "X02", 3, "")
+	if _, unavailable := rateCache.PairUnavailable("USD", "X02"); unavailable {
+		t.Error("a cached rate should end the backoff")
+	}
+}
+
+func TestRefreshAllRates_OutageDoesNotBackOffPairs(t *testing.T) {
+	withRefreshConfig(t, 3, 1)
+	withPairBackoff(t, 1, time.Hour, time.Hour)
+	apiClient := &pickyClient{down: true}
+	rateCache := NewExchangeRateCache(apiClient, NewMemoryCache(), "test:")
+
+	rateCache.refreshAllRates()
+	rateCache.refreshAllRates()
+
+	if _, unavailable := rateCache.PairUnavailable("USD", "X01"); unavailable {
+		t.Error("a provider outage should not be held against the pairs")
+	}
+	if calls := apiClient.callsTo("X01"); calls != 2 {
+		t.Errorf("expected every cycle to retry during an outage, got %d calls", calls)
+	}
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"exchange-rate-service/internal/models"
+)
+
+// Taker is implemented by backends that can read and delete a key atomically,
+// so a quote can't be executed twice by concurrent requests
+type Taker interface {
+	Take(ctx context.Context, key string) ([]byte, error)
+}
+
+// QuoteStore keeps conversion quotes in the cache backend until they expire.
+// Each quote can be taken once, and only by the caller it was issued to - the
+// owner is part of the key, so another caller's id simply isn't found. On
+// redis the quotes are shared, so any replica can execute them
+type QuoteStore struct {
+	backend   Cache
+	keyPrefix string
+}
+
+// NewQuoteStore stores quotes in backend. Keys stay outside keyPrefix so rate
+// listings (stats, snapshots) never see them
+func NewQuoteStore(backend Cache, keyPrefix string) *QuoteStore {
+	return &QuoteStore{
+		backend:   backend,
+		keyPrefix: "quote:" + keyPrefix,
+	}
+}
+
+// Save stores quote for owner until its ExpiresAt
+func (s *QuoteStore) Save(ctx context.Context, owner string, quote models.Quote) error {
+	ttl := time.Until(quote.ExpiresAt)
+	if ttl <= 0 {
+		return errors.New("quote already expired")
+	}
+
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return fmt.Errorf("failed to encode quote: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, backendTimeout)
+	defer cancel()
+	return s.backend.Set(ctx, s.key(owner, quote.ID), data, ttl)
+}
+
+// Take returns owner's quote id and removes it. found is false when there is
+// no such quote, it expired or it was already taken
+func (s *QuoteStore) Take(ctx context.Context, owner, id string) (models.Quote, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, backendTimeout)
+	defer cancel()
+
+	key := s.key(owner, id)
+	var data []byte
+	var err error
+	if taker, ok := s.backend.(Taker); ok {
+		data, err = taker.Take(ctx, key)
+	} else {
+		// not atomic - good enough for backends only tests use
+		data, err = s.backend.Get(ctx, key)
+		if err == nil {
+			err = s.backend.Delete(ctx, key)
+		}
+	}
+	if errors.Is(err, ErrCacheMiss) {
+		return models.Quote{}, false, nil
+	}
+	if err != nil {
+		return models.Quote{}, false, err
+	}
+
+	var quote models.Quote
+	if err := json.Unmarshal(data, &quote); err != nil {
+		return models.Quote{}, false, fmt.Errorf("failed to decode quote: %w", err)
+	}
+	// the backend's expiry may lag by a moment
+	if time.Now().After(quote.ExpiresAt) {
+		return models.Quote{}, false, nil
+	}
+	return quote, true, nil
+}
+
+// key hashes owner so API key names never land in the backend as-is
+func (s *QuoteStore) key(owner, id string) string {
+	sum := sha256.Sum256([]byte(owner))
+	return s.keyPrefix + hex.EncodeToString(sum[:8]) + ":" + id
+}
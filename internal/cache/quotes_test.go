@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestQuoteStore_TakeOnceByOwner(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryCache()
+	store := NewQuoteStore(backend, "test:")
+
+	quote := models.Quote{
+		ID:             "q1",
+		From:           "USD",
+		To:             "EUR",
+		OriginalAmount: models.NewAmount(decimal.RequireFromString("1234567890.12")),
+		Amount:         models.NewAmount(decimal.RequireFromString("1135802458.91")),
+		Rate:           0.92,
+		ExpiresAt:      time.Now().Add(time.Minute),
+	}
+	if err := store.Save(ctx, "partner-a", quote); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// quotes stay out of the rate key space
+	if keys, _ := backend.Keys(ctx, "test:"); len(keys) != 0 {
+		t.Errorf("expected no keys under the rate prefix, got %v", keys)
+	}
+
+	if _, found, _ := store.Take(ctx, "partner-b", "q1"); found {
+		t.Error("another owner must not find the quote")
+	}
+
+	// concurrent executions - exactly one wins
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			taken, found, err := store.Take(ctx, "partner-a", "q1")
+			if err != nil {
+				t.Errorf("Take failed: %v", err)
+			}
+			if found {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+				if !taken.Amount.Equal(quote.Amount.Decimal) || !taken.OriginalAmount.Equal(quote.OriginalAmount.Decimal) {
+					t.Errorf("amounts should round-trip exactly, got %+v", taken)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if winners != 1 {
+		t.Errorf("expected the quote to be taken exactly once, got %d", winners)
+	}
+}
+
+func TestQuoteStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewQuoteStore(NewMemoryCache(), "test:")
+
+	if err := store.Save(ctx, "", models.Quote{ID: "old", ExpiresAt: time.Now().Add(-time.Second)}); err == nil {
+		t.Error("expected an already expired quote to be refused")
+	}
+
+	store.Save(ctx, "", models.Quote{ID: "short", ExpiresAt: time.Now().Add(20 * time.Millisecond)})
+	time.Sleep(40 * time.Millisecond)
+	if _, found, _ := store.Take(ctx, "", "short"); found {
+		t.Error("expected the quote to be gone after it expired")
+	}
+}
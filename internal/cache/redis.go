@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache is a Cache backend shared between service replicas
+type RedisCache struct {
+	client *redis.Client
+}
+
+// NewRedisCache connects to redis and verifies the connection with a PING
+func NewRedisCache(addr, password string, db int) (*RedisCache, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("redis ping failed: %w", err)
+	}
+
+	return &RedisCache{client: client}, nil
+}
+
+// Get returns the value for key or ErrCacheMiss
+func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis get failed: %w", err)
+	}
+	return value, nil
+}
+
+// Set stores value under key - ttl of 0 keeps it forever
+func (r *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := r.client.Set(ctx, key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("redis set failed: %w", err)
+	}
+	return nil
+}
+
+// Take returns the value for key and removes it in one step (GETDEL), ErrCacheMiss when absent
+func (r *RedisCache) Take(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.GetDel(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis getdel failed: %w", err)
+	}
+	return value, nil
+}
+
+// Delete removes key if present
+func (r *RedisCache) Delete(ctx context.Context, key string) error {
+	if err := r.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("redis del failed: %w", err)
+	}
+	return nil
+}
+
+// Exists reports whether key is set
+func (r *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
+	count, err := r.client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis exists failed: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Keys lists keys starting with prefix - uses SCAN so we never block redis
+func (r *RedisCache) Keys(ctx context.Context, prefix string) ([]string, error) {
+	keys := make([]string, 0)
+
+	iter := r.client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("redis scan failed: %w", err)
+	}
+
+	return keys, nil
+}
+
+// lease scripts check the holder and act in one step, so a lease that expired
+// and went to another replica in between is never extended or deleted
+var (
+	renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// AcquireLease takes key for owner (SET NX PX), or extends it when owner already holds it
+func (r *RedisCache) AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	acquired, err := r.client.SetNX(ctx, key, owner, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis lease acquire failed: %w", err)
+	}
+	if acquired {
+		return true, nil
+	}
+
+	renewed, err := renewLeaseScript.Run(ctx, r.client, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("redis lease renew failed: %w", err)
+	}
+	return renewed == 1, nil
+}
+
+// ReleaseLease deletes key if owner still holds it
+func (r *RedisCache) ReleaseLease(ctx context.Context, key, owner string) error {
+	if err := releaseLeaseScript.Run(ctx, r.client, []string{key}, owner).Err(); err != nil {
+		return fmt.Errorf("redis lease release failed: %w", err)
+	}
+	return nil
+}
+
+// Close releases the connection pool
+func (r *RedisCache) Close() error {
+	return r.client.Close()
+}
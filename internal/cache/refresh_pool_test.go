@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/client"
+)
+
+// slowClient takes a while per call and tracks how many calls overlap
+type slowClient struct {
+	delay       time.Duration
+	rateLimited bool
+
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+	calls       atomic.Int32
+}
+
+func (c *slowClient) GetRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, error) {
+	c.calls.Add(1)
+	current := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		peak := c.maxInFlight.Load()
+		if current <= peak || c.maxInFlight.CompareAndSwap(peak, current) {
+			break
+		}
+	}
+
+	time.Sleep(c.delay)
+	if c.rateLimited {
+		return 0, fmt.Errorf("all providers failed: %w", client.ErrRateLimited)
+	}
+	return 1.5, nil
+}
+
+// withRefreshConfig swaps the core set and worker count for one test
+func withRefreshConfig(t *testing.T, currencies int, workers int) {
+	t.Helper()
+	core, base, previousWorkers := config.CoreCurrencies, config.RefreshBaseCurrency, config.RefreshWorkers
+	t.Cleanup(func() {
+		config.CoreCurrencies, config.RefreshBaseCurrency, config.RefreshWorkers = core, base, previousWorkers
+	})
+
+	config.RefreshBaseCurrency = "USD"
+	config.CoreCurrencies = []string{"USD"}
+	for i := 1; i < currencies; i++ {
+		config.CoreCurrencies = append(config.CoreCurrencies, fmt.Sprintf("X%02d", i))
+	}
+	config.RefreshWorkers = workers
+}
+
+func TestRefreshAllRates_BoundedConcurrency(t *testing.T) {
+	withRefreshConfig(t, 13, 4)
+	apiClient := &slowClient{delay: 20 * time.Millisecond}
+	rateCache := NewExchangeRateCache(apiClient, NewMemoryCache(), "test:")
+
+	rateCache.refreshAllRates()
+
+	if apiClient.calls.Load() != 12 {
+		t.Errorf("expected 12 base quotes fetched, got %d", apiClient.calls.Load())
+	}
+	if peak := apiClient.maxInFlight.Load(); peak < 2 || peak > 4 {
+		t.Errorf("expected between 2 and 4 concurrent fetches, peak was %d", peak)
+	}
+	if stats := rateCache.GetCacheStats(); stats["total_pairs"] != 13*12 {
+		t.Errorf("expected every pair cached, got %v", stats["total_pairs"])
+	}
+	if _, found := rateCache.GetCacheStats()["last_refresh_duration_ms"]; !found {
+		t.Error("expected the cycle duration in the stats")
+	}
+}
+
+func TestRefreshAllRates_StopsOnRateLimit(t *testing.T) {
+	withRefreshConfig(t, 21, 2)
+	apiClient := &slowClient{delay: 5 * time.Millisecond, rateLimited: true}
+	rateCache := NewExchangeRateCache(apiClient, NewMemoryCache(), "test:")
+
+	rateCache.refreshAllRates()
+
+	// the workers already holding a job finish it, nothing new is dispatched
+	if calls := apiClient.calls.Load(); calls > 4 {
+		t.Errorf("expected dispatch to stop after a rate limit, got %d calls", calls)
+	}
+}
+
+func TestWaitForUpstreamSlot_Paces(t *testing.T) {
+	previous := config.RefreshRateLimitRPM
+	defer func() { config.RefreshRateLimitRPM = previous }()
+	config.RefreshRateLimitRPM = 600 // one every 100ms after the burst
+
+	withRefreshConfig(t, 2, 1)
+	rateCache := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "test:")
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rateCache.waitForUpstreamSlot()
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected calls beyond the burst to wait, took %v", elapsed)
+	}
+
+	rateCache.Stop()
+	if rateCache.waitForUpstreamSlot() {
+		t.Error("a stopped cache should not hand out slots")
+	}
+}
+
+// fixedCurrencySet is a runtime currency set tests can change between cycles
+type fixedCurrencySet []string
+
+func (s *fixedCurrencySet) CoreCodes() []string { return *s }
+
+func TestRefreshAllRates_PicksUpCurrencySetChanges(t *testing.T) {
+	withRefreshConfig(t, 3, 2)
+	apiClient := &pickyClient{}
+	rateCache := NewExchangeRateCache(apiClient, NewMemoryCache(), "test:")
+	set := fixedCurrencySet{"USD", "X01"}
+	rateCache.TrackCurrencies(&set)
+
+	rateCache.refreshAllRates()
+	if apiClient.callsTo("X01") != 1 || apiClient.callsTo("X02") != 0 {
+		t.Errorf("expected the set's codes only, got calls %v", apiClient.calls)
+	}
+
+	set = fixedCurrencySet{"USD", "X02"}
+	rateCache.refreshAllRates()
+	if apiClient.callsTo("X01") != 1 || apiClient.callsTo("X02") != 1 {
+		t.Errorf("expected the next cycle to follow the changed set, got calls %v", apiClient.calls)
+	}
+}
@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/models"
+)
+
+// snapshotVersion is bumped whenever the file layout changes
+const snapshotVersion = 1
+
+// snapshotFile is the on-disk copy of the rate cache. Entries keep their
+// original last_updated, so a rate saved hours ago loads stale rather than fresh
+type snapshotFile struct {
+	Version int                  `json:"version"`
+	SavedAt time.Time            `json:"saved_at"`
+	Rates   map[string]rateEntry `json:"rates"`
+}
+
+// SaveSnapshot writes every cached pair to path atomically (temp file + rename)
+// and returns how many were written
+func (cache *ExchangeRateCache) SaveSnapshot(path string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	keys, err := cache.backend.Keys(ctx, cache.keyPrefix)
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list cache keys: %w", err)
+	}
+
+	snapshot := snapshotFile{
+		Version: snapshotVersion,
+		SavedAt: time.Now().UTC(),
+		Rates:   make(map[string]rateEntry, len(keys)),
+	}
+	for _, key := range keys {
+		if entry, found := cache.getEntry(key); found {
+			snapshot.Rates[strings.TrimPrefix(key, cache.keyPrefix)] = entry
+		}
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode cache snapshot: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return 0, fmt.Errorf("failed to create snapshot dir: %w", err)
+		}
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return 0, fmt.Errorf("failed to write cache snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, fmt.Errorf("failed to replace cache snapshot: %w", err)
+	}
+
+	return len(snapshot.Rates), nil
+}
+
+// CachedRates returns every cached pair, stale ones included, sorted by pair -
+// the table the daily rate snapshot archives
+func (cache *ExchangeRateCache) CachedRates() ([]models.SnapshotRate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	keys, err := cache.backend.Keys(ctx, cache.keyPrefix)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache keys: %w", err)
+	}
+
+	rates := make([]models.SnapshotRate, 0, len(keys))
+	for _, key := range keys {
+		codes := strings.Split(strings.TrimPrefix(key, cache.keyPrefix), "-")
+		if len(codes) != 2 {
+			continue
+		}
+		if entry, found := cache.getEntry(key); found {
+			rates = append(rates, models.SnapshotRate{
+				From:        codes[0],
+				To:          codes[1],
+				Rate:        entry.ExchangeRate,
+				Source:      entry.Source,
+				LastUpdated: entry.LastUpdated.UTC(),
+			})
+		}
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].From != rates[j].From {
+			return rates[i].From < rates[j].From
+		}
+		return rates[i].To < rates[j].To
+	})
+	return rates, nil
+}
+
+// LoadSnapshot restores the pairs saved at path and reports how many were
+// loaded and how many of those are already past their TTL. A missing file is
+// not an error - there is simply nothing to restore. Pairs the cache already
+// holds a newer rate for are left alone, and so are ones past their max age
+func (cache *ExchangeRateCache) LoadSnapshot(path string) (loaded, stale int, err error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read cache snapshot: %w", err)
+	}
+
+	var snapshot snapshotFile
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse cache snapshot: %w", err)
+	}
+	if snapshot.Version != snapshotVersion {
+		return 0, 0, fmt.Errorf("unsupported cache snapshot version %d", snapshot.Version)
+	}
+
+	for pair, entry := range snapshot.Rates {
+		codes := strings.Split(pair, "-")
+		if len(codes) != 2 || entry.ExchangeRate <= 0 || entry.LastUpdated.IsZero() {
+			slog.Warn("Skipping invalid cache snapshot entry", "pair", pair)
+			continue
+		}
+		if pastMaxAge(codes[0], codes[1], entry.LastUpdated) {
+			continue
+		}
+
+		cacheKey := cache.keyPrefix + buildRateKey(codes[0], codes[1])
+		if current, found := cache.getEntry(cacheKey); found && !current.LastUpdated.Before(entry.LastUpdated) {
+			continue
+		}
+
+		payload, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+		err = cache.backend.Set(ctx, cacheKey, payload, 0)
+		cancel()
+		if err != nil {
+			return loaded, stale, fmt.Errorf("failed to restore %s: %w", pair, err)
+		}
+
+		loaded++
+		// flagged stale by GetRateEntry from here on - counted so startup logs say how old the restore is
+		if time.Since(entry.LastUpdated) > ttlFor(codes[0], codes[1]) {
+			stale++
+		}
+	}
+
+	return loaded, stale, nil
+}
+
+// StartSnapshots saves the cache to path every interval and once more on Stop,
+// so a deploy restarts from rates at most one interval old
+func (cache *ExchangeRateCache) StartSnapshots(path string, interval time.Duration) {
+	cache.backgroundWorkers.Add(1)
+	go func() {
+		defer cache.backgroundWorkers.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				cache.saveSnapshot(path)
+			case <-cache.shutdownChannel:
+				cache.saveSnapshot(path)
+				return
+			}
+		}
+	}()
+}
+
+// saveSnapshot is SaveSnapshot for the background loop - failures only get logged
+func (cache *ExchangeRateCache) saveSnapshot(path string) {
+	saved, err := cache.SaveSnapshot(path)
+	if err != nil {
+		slog.Warn("Failed to save cache snapshot", "path", path, "error", err)
+		return
+	}
+	slog.Debug("Cache snapshot saved", "path", path, "pairs", saved)
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshot_RoundTripKeepsAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots", "rates.json")
+
+	source := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "old:")
+	source.SetRate("USD", "EUR", 0.92, "frankfurter")
+
+	// an entry saved long ago must come back stale, not fresh
+	backend := source.backend
+	payload, _ := json.Marshal(rateEntry{ExchangeRate: 83.1, LastUpdated: time.Now().Add(-3 * time.Hour)})
+	backend.Set(context.Background(), "old:USD-INR", payload, 0)
+
+	saved, err := source.SaveSnapshot(path)
+	if err != nil || saved != 2 {
+		t.Fatalf("SaveSnapshot = %d, %v; want 2 pairs", saved, err)
+	}
+
+	// a different prefix on the new instance - keys are stored without it
+	restored := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "new:")
+	loaded, stale, err := restored.LoadSnapshot(path)
+	if err != nil || loaded != 2 || stale != 1 {
+		t.Fatalf("LoadSnapshot = %d loaded, %d stale, %v; want 2, 1", loaded, stale, err)
+	}
+
+	quote, found := restored.GetRateEntry("USD", "EUR")
+	if !found || quote.Stale || quote.Rate != 0.92 || quote.Source != "frankfurter" {
+		t.Errorf("USD-EUR should load fresh with its source, got %+v", quote)
+	}
+
+	quote, found = restored.GetRateEntry("USD", "INR")
+	if !found || !quote.Stale || quote.Rate != 83.1 {
+		t.Errorf("USD-INR should load flagged stale, got %+v", quote)
+	}
+	if _, fresh := restored.GetRate("USD", "INR"); fresh {
+		t.Error("a stale restored entry must not be served as fresh")
+	}
+}
+
+func TestSnapshot_KeepsNewerEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+
+	source := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "test:")
+	source.SetRate("USD", "EUR", 0.90, "")
+	if _, err := source.SaveSnapshot(path); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+
+	restored := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "test:")
+	restored.SetRate("USD", "EUR", 0.95, "")
+
+	if loaded, _, err := restored.LoadSnapshot(path); err != nil || loaded != 0 {
+		t.Fatalf("LoadSnapshot = %d, %v; expected the newer entry to win", loaded, err)
+	}
+	if rate, _ := restored.GetRate("USD", "EUR"); rate != 0.95 {
+		t.Errorf("expected the newer 0.95 to survive, got %f", rate)
+	}
+}
+
+func TestSnapshot_MissingAndCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	rateCache := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "test:")
+
+	if loaded, _, err := rateCache.LoadSnapshot(filepath.Join(dir, "missing.json")); err != nil || loaded != 0 {
+		t.Errorf("a missing snapshot should load nothing without error, got %d, %v", loaded, err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	os.WriteFile(corrupt, []byte("{not json"), 0o600)
+	if _, _, err := rateCache.LoadSnapshot(corrupt); err == nil {
+		t.Error("expected an error for a corrupt snapshot")
+	}
+
+	future := filepath.Join(dir, "future.json")
+	os.WriteFile(future, []byte(`{"version": 99, "rates": {}}`), 0o600)
+	if _, _, err := rateCache.LoadSnapshot(future); err == nil {
+		t.Error("expected an error for an unknown snapshot version")
+	}
+}
+
+func TestSnapshot_SavedOnStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+
+	rateCache := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "test:")
+	rateCache.StartSnapshots(path, time.Hour)
+	rateCache.SetRate("USD", "EUR", 0.92, "")
+	rateCache.Stop()
+
+	restored := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "test:")
+	if loaded, _, err := restored.LoadSnapshot(path); err != nil || loaded != 1 {
+		t.Errorf("expected Stop to write a final snapshot, got %d, %v", loaded, err)
+	}
+}
+
+func TestCachedRates_SortedWithStaleEntries(t *testing.T) {
+	cache := NewExchangeRateCache(&stubAPIClient{}, NewMemoryCache(), "test:")
+	cache.SetRate("USD", "INR", 83.1, "exchangerate-api")
+	cache.SetRate("EUR", "USD", 1.09, "frankfurter")
+
+	payload, _ := json.Marshal(rateEntry{ExchangeRate: 0.92, LastUpdated: time.Now().Add(-3 * time.Hour)})
+	cache.backend.Set(context.Background(), "test:USD-EUR", payload, 0)
+
+	rates, err := cache.CachedRates()
+	if err != nil {
+		t.Fatalf("CachedRates failed: %v", err)
+	}
+	if len(rates) != 3 {
+		t.Fatalf("expected 3 pairs, got %+v", rates)
+	}
+	for i, pair := range []string{"EUR-USD", "USD-EUR", "USD-INR"} {
+		if got := rates[i].From + "-" + rates[i].To; got != pair {
+			t.Errorf("rates[%d] = %s, want %s", i, got, pair)
+		}
+	}
+	if rates[1].Rate != 0.92 || time.Since(rates[1].LastUpdated) < 3*time.Hour {
+		t.Errorf("the stale USD-EUR should keep its rate and age, got %+v", rates[1])
+	}
+	if rates[0].Source != "frankfurter" {
+		t.Errorf("expected sources to be kept, got %+v", rates[0])
+	}
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/metrics"
+	"exchange-rate-service/internal/models"
+)
+
+// cacheCounters are the running totals behind Stats
+type cacheCounters struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+	stale  atomic.Int64
+
+	lookupNanos    atomic.Int64
+	maxLookupNanos atomic.Int64
+
+	staleFallbacks atomic.Int64
+	evicted        atomic.Int64
+	derived        atomic.Int64
+	fetchNanos     atomic.Int64
+
+	refreshSucceeded    atomic.Int64
+	refreshFailed       atomic.Int64
+	consecutiveFailures atomic.Int64
+	pairsUpdated        atomic.Int64
+	pairsFailed         atomic.Int64
+}
+
+// recordLookup counts one latest-rate lookup and how long the backend took
+func (c *cacheCounters) recordLookup(found, stale bool, latency time.Duration) {
+	switch {
+	case !found:
+		c.misses.Add(1)
+	case stale:
+		c.stale.Add(1)
+	default:
+		c.hits.Add(1)
+	}
+
+	nanos := int64(latency)
+	c.lookupNanos.Add(nanos)
+	for {
+		peak := c.maxLookupNanos.Load()
+		if nanos <= peak || c.maxLookupNanos.CompareAndSwap(peak, nanos) {
+			break
+		}
+	}
+	metrics.ObserveCacheLookup(latency)
+}
+
+// recordCycle counts a finished refresh cycle
+func (c *cacheCounters) recordCycle(updated, failed int) {
+	c.pairsUpdated.Add(int64(updated))
+	c.pairsFailed.Add(int64(failed))
+	if updated > 0 {
+		c.refreshSucceeded.Add(1)
+		c.consecutiveFailures.Store(0)
+		return
+	}
+	c.refreshFailed.Add(1)
+	c.consecutiveFailures.Add(1)
+}
+
+// RecordStaleFallback counts an expired entry served because the upstream failed
+func (cache *ExchangeRateCache) RecordStaleFallback() {
+	cache.counters.staleFallbacks.Add(1)
+	metrics.RecordStaleFallback()
+}
+
+// RecordDerived counts a miss answered by inverting the cached opposite pair
+func (cache *ExchangeRateCache) RecordDerived() {
+	cache.counters.derived.Add(1)
+	metrics.RecordDerivedRate()
+}
+
+// Stats returns the lookup, fetch and refresh counters since startup. Unlike
+// GetCacheStats it never touches the backend, so it is cheap enough to poll
+func (cache *ExchangeRateCache) Stats() models.CacheStats {
+	counters := &cache.counters
+	stats := models.CacheStats{
+		Lookups: models.CacheLookupStats{
+			Hits:         counters.hits.Load(),
+			Misses:       counters.misses.Load(),
+			Stale:        counters.stale.Load(),
+			Evicted:      counters.evicted.Load(),
+			MaxLatencyMs: milliseconds(counters.maxLookupNanos.Load()),
+		},
+		TTL: ttlStats(),
+		Fetches: models.RateFetchStats{
+			Upstream:       cache.upstreamFetches.Load(),
+			Deduplicated:   cache.sharedFetches.Load(),
+			StaleFallbacks: counters.staleFallbacks.Load(),
+			Derived:        counters.derived.Load(),
+		},
+		Refresh: models.RefreshStats{
+			Succeeded:           counters.refreshSucceeded.Load(),
+			Failed:              counters.refreshFailed.Load(),
+			ConsecutiveFailures: counters.consecutiveFailures.Load(),
+			PairsUpdated:        counters.pairsUpdated.Load(),
+			PairsFailed:         counters.pairsFailed.Load(),
+			PairsBackedOff:      cache.unavailable.count(time.Now()),
+			LastDurationMs:      time.Duration(cache.lastRefreshDuration.Load()).Milliseconds(),
+		},
+	}
+
+	if lookups := stats.Lookups.Hits + stats.Lookups.Misses + stats.Lookups.Stale; lookups > 0 {
+		stats.Lookups.HitRatio = float64(stats.Lookups.Hits) / float64(lookups)
+		stats.Lookups.AvgLatencyMs = milliseconds(counters.lookupNanos.Load() / lookups)
+	}
+	if fetches := stats.Fetches.Upstream + stats.Fetches.Deduplicated; fetches > 0 {
+		stats.Fetches.AvgLatencyMs = milliseconds(counters.fetchNanos.Load() / fetches)
+	}
+	if last := cache.LastRefresh(); !last.IsZero() {
+		last = last.UTC()
+		stats.Refresh.LastRefresh = &last
+	}
+	return stats
+}
+
+// ttlStats reports the configured stale and eviction thresholds
+func ttlStats() models.CacheTTLStats {
+	return models.CacheTTLStats{
+		Soft:       config.CacheTTL.String(),
+		Hard:       config.CacheMaxAge.String(),
+		CryptoSoft: config.CryptoCacheTTL.String(),
+		CryptoHard: config.CryptoCacheMaxAge.String(),
+	}
+}
+
+// CheckRefreshFailures fails once maxConsecutive cycles in a row updated
+// nothing (0 disables) - the provider is down or rejecting us, even if the
+// rates we still hold are recent enough
+func (cache *ExchangeRateCache) CheckRefreshFailures(maxConsecutive int) error {
+	if maxConsecutive <= 0 {
+		return nil
+	}
+	if failures := cache.counters.consecutiveFailures.Load(); failures >= int64(maxConsecutive) {
+		return fmt.Errorf("last %d refresh cycles updated no rates", failures)
+	}
+	return nil
+}
+
+// milliseconds converts nanos to fractional milliseconds
+func milliseconds(nanos int64) float64 {
+	return float64(nanos) / float64(time.Millisecond)
+}
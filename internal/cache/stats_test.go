@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats_CountsLookupsAndCycles(t *testing.T) {
+	withRefreshConfig(t, 3, 1)
+	rateCache := NewExchangeRateCache(&slowClient{}, NewMemoryCache(), "test:")
+
+	rateCache.GetRateEntry("USD", "EUR")
+	rateCache.SetRate("USD", "EUR", 0.9, "test")
+	rateCache.GetRateEntry("USD", "EUR")
+	rateCache.GetRateEntry("USD", "EUR")
+	rateCache.RecordFetch(false, 30*time.Millisecond)
+	rateCache.RecordFetch(true, 10*time.Millisecond)
+	rateCache.RecordStaleFallback()
+
+	stats := rateCache.Stats()
+	if stats.Lookups.Hits != 2 || stats.Lookups.Misses != 1 || stats.Lookups.Stale != 0 {
+		t.Errorf("unexpected lookup counts: %+v", stats.Lookups)
+	}
+	if ratio := stats.Lookups.HitRatio; ratio < 0.66 || ratio > 0.67 {
+		t.Errorf("expected a 2/3 hit ratio, got %v", ratio)
+	}
+	if stats.Fetches.Upstream != 1 || stats.Fetches.Deduplicated != 1 || stats.Fetches.StaleFallbacks != 1 || stats.Fetches.AvgLatencyMs != 20 {
+		t.Errorf("unexpected fetch stats: %+v", stats.Fetches)
+	}
+
+	rateCache.refreshAllRates()
+	if stats := rateCache.Stats(); stats.Refresh.Succeeded != 1 || stats.Refresh.PairsUpdated != 6 || stats.Refresh.LastRefresh == nil {
+		t.Errorf("expected one successful cycle of 6 pairs, got %+v", stats.Refresh)
+	}
+}
+
+func TestCheckRefreshFailures(t *testing.T) {
+	withRefreshConfig(t, 3, 1)
+	rateCache := NewExchangeRateCache(&slowClient{rateLimited: true}, NewMemoryCache(), "test:")
+
+	rateCache.refreshAllRates()
+	if err := rateCache.CheckRefreshFailures(2); err != nil {
+		t.Errorf("one failed cycle is under the limit: %v", err)
+	}
+	rateCache.refreshAllRates()
+	if err := rateCache.CheckRefreshFailures(2); err == nil {
+		t.Error("expected two failed cycles in a row to fail the check")
+	}
+	if err := rateCache.CheckRefreshFailures(0); err != nil {
+		t.Errorf("0 disables the check, got %v", err)
+	}
+
+	rateCache.exchangeAPIClient = &slowClient{}
+	rateCache.refreshAllRates()
+	if err := rateCache.CheckRefreshFailures(2); err != nil || rateCache.Stats().Refresh.Failed != 2 {
+		t.Errorf("a successful cycle should reset the streak: %v, %+v", err, rateCache.Stats().Refresh)
+	}
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+// how often the warmup re-counts the cached pairs
+const warmupPollInterval = time.Second
+
+// CoreCoverage counts the core pairs (RefreshBaseCurrency against each core
+// currency) that are in the cache right now
+func (cache *ExchangeRateCache) CoreCoverage(ctx context.Context) (cached, total int, err error) {
+	base := config.RefreshBaseCurrency
+	for _, code := range cache.coreCurrencies() {
+		if code == base || config.IsCurrencySunset(code) {
+			continue
+		}
+		total++
+
+		exists, err := cache.backend.Exists(ctx, cache.keyPrefix+buildRateKey(base, code))
+		if err != nil {
+			return 0, 0, fmt.Errorf("cache backend unreachable: %w", err)
+		}
+		if exists {
+			cached++
+		}
+	}
+	return cached, total, nil
+}
+
+// Warmup is the startup phase before enough core pairs are cached to serve
+// traffic without sending it to the providers. It ends once minFraction of
+// the core pairs are cached or timeout has passed, whichever comes first, and
+// never starts again - a cache emptied later is a readiness problem, not a
+// warmup one
+type Warmup struct {
+	cache       *ExchangeRateCache
+	minFraction float64
+	deadline    time.Time
+
+	warm     atomic.Bool
+	mu       sync.Mutex
+	coverage string // last count, for Check
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// NewWarmup starts the warmup clock for cache
+func NewWarmup(cache *ExchangeRateCache, minFraction float64, timeout time.Duration) *Warmup {
+	return &Warmup{
+		cache:       cache,
+		minFraction: minFraction,
+		deadline:    time.Now().Add(timeout),
+		coverage:    "not checked yet",
+		stop:        make(chan struct{}),
+	}
+}
+
+// Start polls the cache in the background until the warmup ends or Stop
+func (w *Warmup) Start() {
+	w.done.Add(1)
+	go func() {
+		defer w.done.Done()
+
+		ticker := time.NewTicker(warmupPollInterval)
+		defer ticker.Stop()
+
+		for !w.Poll(context.Background()) {
+			select {
+			case <-ticker.C:
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the polling
+func (w *Warmup) Stop() {
+	close(w.stop)
+	w.done.Wait()
+}
+
+// Poll counts the cached core pairs once, ending the warmup when there are
+// enough or the deadline has passed. Reports whether the warmup is over
+func (w *Warmup) Poll(ctx context.Context) bool {
+	if w.warm.Load() {
+		return true
+	}
+
+	cached, total, err := w.cache.CoreCoverage(ctx)
+	switch {
+	case err == nil && (total == 0 || float64(cached)/float64(total) >= w.minFraction):
+		w.warm.Store(true)
+		slog.Info("Cache warm, warmup over", "cached_pairs", cached, "core_pairs", total)
+		return true
+	case time.Now().After(w.deadline):
+		w.warm.Store(true)
+		slog.Warn("Warmup deadline passed, serving with a partly cached set",
+			"cached_pairs", cached, "core_pairs", total, "min_fraction", w.minFraction, "error", err)
+		return true
+	}
+
+	w.mu.Lock()
+	if err != nil {
+		w.coverage = err.Error()
+	} else {
+		w.coverage = fmt.Sprintf("%d of %d core pairs cached", cached, total)
+	}
+	w.mu.Unlock()
+	return false
+}
+
+// Warm reports whether the warmup is over
+func (w *Warmup) Warm() bool {
+	return w.warm.Load()
+}
+
+// Check fails until the warmup is over
+func (w *Warmup) Check(ctx context.Context) error {
+	if w.Warm() {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return fmt.Errorf("warming up: %s, need %.0f%% (until %s)", w.coverage, w.minFraction*100, w.deadline.UTC().Format(time.RFC3339))
+}
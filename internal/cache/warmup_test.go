@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWarmup_EndsOnceEnoughPairsAreCached(t *testing.T) {
+	rateCache := NewExchangeRateCache(&stubAPIClient{rate: 2}, NewMemoryCache(), "test:")
+	set := fixedCurrencySet{"USD", "EUR", "GBP", "JPY", "CHF"}
+	rateCache.TrackCurrencies(&set)
+	warmup := NewWarmup(rateCache, 0.75, time.Hour)
+	ctx := context.Background()
+
+	rateCache.SetRate("USD", "EUR", 0.9, "test")
+	rateCache.SetRate("USD", "GBP", 0.8, "test")
+	if warmup.Poll(ctx) || warmup.Warm() {
+		t.Fatal("2 of 4 pairs cached should not end the warmup")
+	}
+	if err := warmup.Check(ctx); err == nil || !strings.Contains(err.Error(), "2 of 4 core pairs cached") {
+		t.Errorf("expected the check to report the coverage, got %v", err)
+	}
+
+	rateCache.SetRate("USD", "JPY", 150, "test")
+	if !warmup.Poll(ctx) || warmup.Check(ctx) != nil {
+		t.Fatal("3 of 4 pairs cached should end the warmup")
+	}
+
+	// a cache emptied later doesn't bring the warmup back
+	rateCache.DeleteRate("USD", "EUR")
+	if !warmup.Poll(ctx) {
+		t.Error("the warmup should stay over")
+	}
+}
+
+func TestWarmup_DeadlineEndsIt(t *testing.T) {
+	rateCache := NewExchangeRateCache(&stubAPIClient{rate: 2}, NewMemoryCache(), "test:")
+	warmup := NewWarmup(rateCache, 1, -time.Second)
+
+	if !warmup.Poll(context.Background()) || !warmup.Warm() {
+		t.Error("a passed deadline should end the warmup with nothing cached")
+	}
+}
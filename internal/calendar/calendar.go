@@ -0,0 +1,131 @@
+// Package calendar knows which days each currency's market trades and where
+// its day turns over, so historical lookups can tell a day nobody published a
+// rate for from an outage, and "today" means today where the rate is fixed
+// rather than today in UTC.
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/currency"
+)
+
+// why a market didn't trade on a day
+const (
+	Weekend = "weekend"
+	Holiday = "holiday"
+)
+
+// AllCurrencies keys holidays every fiat market observes
+const AllCurrencies = "*"
+
+// Calendar is read-only once built, safe for concurrent use
+type Calendar struct {
+	location *time.Location            // where the service's own business day turns over
+	zones    map[string]*time.Location // currency -> its market's zone
+	holidays map[string]map[string]bool
+	now      func() time.Time
+}
+
+// New builds a calendar. location is the zone of the service's own business
+// day (UTC when empty), zones maps a currency to its market's IANA zone and
+// holidays a currency (or AllCurrencies) to "YYYY-MM-DD|YYYY-MM-DD|..."
+func New(location string, zones, holidays map[string]string) (*Calendar, error) {
+	c := &Calendar{
+		location: time.UTC,
+		zones:    make(map[string]*time.Location, len(zones)),
+		holidays: make(map[string]map[string]bool, len(holidays)),
+		now:      time.Now,
+	}
+
+	if location != "" {
+		loc, err := time.LoadLocation(location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid market timezone %q: %w", location, err)
+		}
+		c.location = loc
+	}
+	for code, zone := range zones {
+		loc, err := time.LoadLocation(strings.TrimSpace(zone))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q for %s: %w", zone, code, err)
+		}
+		c.zones[normalize(code)] = loc
+	}
+	for code, days := range holidays {
+		set := make(map[string]bool)
+		for _, day := range strings.Split(days, "|") {
+			day = strings.TrimSpace(day)
+			if _, err := time.Parse("2006-01-02", day); err != nil {
+				return nil, fmt.Errorf("invalid holiday %q for %s, expected YYYY-MM-DD", day, code)
+			}
+			set[day] = true
+		}
+		c.holidays[normalize(code)] = set
+	}
+
+	return c, nil
+}
+
+// Default is Saturday/Sunday weekends, no holidays, business days in UTC
+func Default() *Calendar {
+	c, _ := New("", nil, nil)
+	return c
+}
+
+// Today is the current date, as midnight UTC like a parsed YYYY-MM-DD, in
+// whichever market of codes is furthest ahead - Tokyo publishes its fixing
+// for a date while it is still the day before in UTC. Currencies without a
+// zone of their own follow the service's zone, as does a call without codes
+func (c *Calendar) Today(codes ...string) time.Time {
+	now := c.now()
+	today := dateOf(now.In(c.location))
+	for _, code := range codes {
+		if loc, found := c.zones[normalize(code)]; found {
+			if local := dateOf(now.In(loc)); local.After(today) {
+				today = local
+			}
+		}
+	}
+	return today
+}
+
+// Closure says why code's market didn't trade on day - Weekend, Holiday, or
+// empty when it did. Crypto and metals trade every day
+func (c *Calendar) Closure(code string, day time.Time) string {
+	if currency.ClassOf(code) != currency.AssetFiat {
+		return ""
+	}
+	if weekday := day.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return Weekend
+	}
+	date := day.Format("2006-01-02")
+	if c.holidays[normalize(code)][date] || c.holidays[AllCurrencies][date] {
+		return Holiday
+	}
+	return ""
+}
+
+// PairClosure is Closure for a pair - a rate needs both markets open, except
+// that pairs with a crypto or metal side are quoted every day
+func (c *Calendar) PairClosure(from, to string, day time.Time) string {
+	if currency.IsAssetPair(from, to) {
+		return ""
+	}
+	if closure := c.Closure(from, day); closure != "" {
+		return closure
+	}
+	return c.Closure(to, day)
+}
+
+// dateOf truncates a local time to its date at midnight UTC
+func dateOf(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,62 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendar_Today(t *testing.T) {
+	c, err := New("America/New_York", map[string]string{"JPY": "Asia/Tokyo"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 02:00 UTC on the 15th: still the 14th in New York, already the 15th in Tokyo
+	c.now = func() time.Time { return time.Date(2025, 8, 15, 2, 0, 0, 0, time.UTC) }
+
+	if today := c.Today("USD", "EUR").Format("2006-01-02"); today != "2025-08-14" {
+		t.Errorf("expected New York's date, got %s", today)
+	}
+	if today := c.Today("USD", "JPY"); today != time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("expected Tokyo's date at midnight UTC, got %s", today)
+	}
+}
+
+func TestCalendar_Closure(t *testing.T) {
+	c, err := New("", nil, map[string]string{"USD": "2025-07-04", "*": "2025-01-01|2025-12-25"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(s string) time.Time {
+		parsed, _ := time.Parse("2006-01-02", s)
+		return parsed
+	}
+
+	cases := []struct {
+		from, to, date, want string
+	}{
+		{"USD", "EUR", "2025-07-03", ""},
+		{"USD", "EUR", "2025-07-04", Holiday},
+		{"EUR", "GBP", "2025-07-04", ""},
+		{"eur", "gbp", "2025-12-25", Holiday},
+		{"USD", "EUR", "2025-07-05", Weekend},
+		{"BTC", "USD", "2025-07-05", ""},
+		{"XAU", "USD", "2025-07-04", ""},
+	}
+	for _, tc := range cases {
+		if got := c.PairClosure(tc.from, tc.to, day(tc.date)); got != tc.want {
+			t.Errorf("%s-%s on %s: expected %q, got %q", tc.from, tc.to, tc.date, tc.want, got)
+		}
+	}
+}
+
+func TestNew_RejectsBadConfig(t *testing.T) {
+	if _, err := New("Mars/Olympus", nil, nil); err == nil {
+		t.Error("expected an unknown zone to fail")
+	}
+	if _, err := New("", map[string]string{"JPY": "Tokyo"}, nil); err == nil {
+		t.Error("expected an unknown currency zone to fail")
+	}
+	if _, err := New("", nil, map[string]string{"USD": "2025-07-04|07/05/2025"}); err == nil {
+		t.Error("expected a malformed holiday to fail")
+	}
+}
@@ -0,0 +1,217 @@
+// Package certs serves the API over HTTPS without a proxy in front: either a
+// certificate and key from files (reloaded when they change on disk) or ones
+// obtained and renewed automatically by autocert from an ACME CA such as
+// Let's Encrypt.
+package certs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Options configures HTTPS - set the file pair or the autocert domains, not both
+type Options struct {
+	CertFile string
+	KeyFile  string
+
+	// domains to obtain certificates for - any other SNI name is refused, so
+	// nobody can make us request certificates for names of their choosing
+	AutocertDomains   []string
+	AutocertEmail     string
+	AutocertCacheDir  string
+	AutocertDirectory string // ACME directory URL, Let's Encrypt when empty
+}
+
+// Enabled reports whether HTTPS is configured at all
+func (o Options) Enabled() bool {
+	return o.CertFile != "" || o.KeyFile != "" || len(o.AutocertDomains) > 0
+}
+
+// Provider supplies the server's certificates
+type Provider struct {
+	tlsConfig *tls.Config
+	autocert  *autocert.Manager // nil with file certificates
+}
+
+// New checks opts and loads the file certificate, or prepares the ACME manager
+func New(opts Options) (*Provider, error) {
+	files := opts.CertFile != "" || opts.KeyFile != ""
+	switch {
+	case files && len(opts.AutocertDomains) > 0:
+		return nil, fmt.Errorf("set either TLS_CERT_FILE/TLS_KEY_FILE or TLS_AUTOCERT_DOMAINS, not both")
+	case files && (opts.CertFile == "" || opts.KeyFile == ""):
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	case !opts.Enabled():
+		return nil, fmt.Errorf("no certificate source configured")
+	}
+
+	if !files {
+		manager, err := newAutocertManager(opts)
+		if err != nil {
+			return nil, err
+		}
+		// autocert's config also answers TLS-ALPN-01 challenges
+		tlsConfig := manager.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return &Provider{tlsConfig: tlsConfig, autocert: manager}, nil
+	}
+
+	fileCert, err := newFileCertificate(opts.CertFile, opts.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Provider{tlsConfig: &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: fileCert.GetCertificate,
+		// net/http speaks HTTP/2 to clients that offer it over ALPN
+		NextProtos: []string{"h2", "http/1.1"},
+	}}, nil
+}
+
+// newAutocertManager only requests certificates for the configured domains,
+// so nobody can make us ask the CA for names of their choosing. Certificates
+// and the account key are cached so restarts don't hit the CA's rate limits
+func newAutocertManager(opts Options) (*autocert.Manager, error) {
+	domains := make([]string, 0, len(opts.AutocertDomains))
+	for _, domain := range opts.AutocertDomains {
+		domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+		if domain == "" || strings.ContainsAny(domain, "*/: ") {
+			return nil, fmt.Errorf("invalid autocert domain %q", domain)
+		}
+		domains = append(domains, domain)
+	}
+
+	cacheDir := opts.AutocertCacheDir
+	if cacheDir == "" {
+		cacheDir = "certs"
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      opts.AutocertEmail,
+	}
+	if opts.AutocertDirectory != "" {
+		manager.Client = &acme.Client{DirectoryURL: opts.AutocertDirectory}
+	}
+	return manager, nil
+}
+
+// TLSConfig is the config for the HTTPS server
+func (p *Provider) TLSConfig() *tls.Config {
+	return p.tlsConfig
+}
+
+// HTTPHandler wraps the plain HTTP listener's handler: ACME HTTP-01 challenges
+// are answered there, everything else goes to fallback
+func (p *Provider) HTTPHandler(fallback http.Handler) http.Handler {
+	if p.autocert == nil {
+		return fallback
+	}
+	return p.autocert.HTTPHandler(fallback)
+}
+
+// RedirectHandler sends plain HTTP requests to the same URL over HTTPS on the
+// port of httpsAddr. GET and HEAD get a 301; other methods a 308 so clients
+// repeat them with the same method and body
+func RedirectHandler(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		status := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			status = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
+
+// how often a file certificate is checked for changes on disk
+const fileCheckInterval = 30 * time.Second
+
+// fileCertificate serves a certificate from disk and picks up a renewed one
+// (certbot, cert-manager) without a restart
+type fileCertificate struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func newFileCertificate(certFile, keyFile string) (*fileCertificate, error) {
+	f := &fileCertificate{certFile: certFile, keyFile: keyFile}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// load reads the pair when either file changed since the last load
+func (f *fileCertificate) load() error {
+	modTime, err := latestModTime(f.certFile, f.keyFile)
+	if err != nil {
+		return err
+	}
+	if f.cert != nil && modTime.Equal(f.modTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	f.cert, f.modTime = &cert, modTime
+	return nil
+}
+
+// GetCertificate returns the current certificate, reloading it when the files
+// changed. A broken replacement keeps the previous certificate in service
+func (f *fileCertificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if time.Since(f.checked) >= fileCheckInterval {
+		f.checked = time.Now()
+		previous := f.cert
+		if err := f.load(); err != nil {
+			slog.Error("Failed to reload TLS certificate, keeping the current one", "cert_file", f.certFile, "error", err)
+		} else if f.cert != previous {
+			slog.Info("TLS certificate reloaded", "cert_file", f.certFile)
+		}
+	}
+	return f.cert, nil
+}
+
+func latestModTime(paths ...string) (time.Time, error) {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to read TLS certificate: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
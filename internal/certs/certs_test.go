@@ -0,0 +1,228 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA signs leaf certificates for the file tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// sign issues a PEM leaf for pub valid for validity
+func (ca *testCA) sign(t *testing.T, pub interface{}, names []string, validity time.Duration) []byte {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, pub, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// writePair writes a fresh key and certificate for localhost to dir
+func (ca *testCA) writePair(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalECPrivateKey(key)
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, ca.sign(t, &key.PublicKey, []string{"localhost"}, 24*time.Hour), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestNewValidatesOptions(t *testing.T) {
+	tests := map[string]Options{
+		"nothing":       {},
+		"cert only":     {CertFile: "tls.crt"},
+		"both sources":  {CertFile: "tls.crt", KeyFile: "tls.key", AutocertDomains: []string{"api.example.com"}},
+		"wildcard":      {AutocertDomains: []string{"*.example.com"}, AutocertCacheDir: t.TempDir()},
+		"missing files": {CertFile: "/nonexistent/tls.crt", KeyFile: "/nonexistent/tls.key"},
+	}
+	for name, opts := range tests {
+		if _, err := New(opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestFileCertificateReload(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.writePair(t, dir)
+
+	f, err := newFileCertificate(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := f.GetCertificate(nil)
+
+	// replace the pair and move the clock past the check interval
+	ca.writePair(t, dir)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	f.checked = time.Time{}
+
+	second, _ := f.GetCertificate(nil)
+	if second == first {
+		t.Fatal("expected the replaced certificate to be loaded")
+	}
+
+	// a broken replacement keeps the working certificate in service
+	os.WriteFile(keyFile, []byte("not a key"), 0o600)
+	later = later.Add(time.Minute)
+	os.Chtimes(keyFile, later, later)
+	f.checked = time.Time{}
+
+	third, _ := f.GetCertificate(nil)
+	if third != second {
+		t.Fatal("expected the previous certificate after a failed reload")
+	}
+}
+
+func TestServesHTTP2(t *testing.T) {
+	ca := newTestCA(t)
+	certFile, keyFile := ca.writePair(t, t.TempDir())
+
+	provider, err := New(Options{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, r.Proto)
+		}),
+		TLSConfig: provider.TLSConfig(),
+	}
+	go srv.ServeTLS(ln, "", "")
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: ca.pool(), ServerName: "localhost"},
+		ForceAttemptHTTP2: true,
+	}}
+	resp, err := client.Get("https://" + ln.Addr().String() + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2, got %s", resp.Proto)
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		addr, method, target string
+		status               int
+		location             string
+	}{
+		{":443", http.MethodGet, "http://api.example.com/api/v1/rates?base=USD", http.StatusMovedPermanently, "https://api.example.com/api/v1/rates?base=USD"},
+		{":8443", http.MethodGet, "http://api.example.com:8080/health", http.StatusMovedPermanently, "https://api.example.com:8443/health"},
+		{":443", http.MethodPost, "http://api.example.com/api/v1/convert", http.StatusPermanentRedirect, "https://api.example.com/api/v1/convert"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		RedirectHandler(tt.addr).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if rec.Code != tt.status || rec.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s: got %d %q, want %d %q", tt.method, tt.target, rec.Code, rec.Header().Get("Location"), tt.status, tt.location)
+		}
+	}
+}
+
+func TestAutocertRefusesUnknownHosts(t *testing.T) {
+	provider, err := New(Options{AutocertDomains: []string{"API.example.com."}, AutocertCacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the host policy is checked before the CA is contacted
+	for _, name := range []string{"", "evil.example.com", "127.0.0.1"} {
+		if _, err := provider.TLSConfig().GetCertificate(&tls.ClientHelloInfo{ServerName: name}); err == nil {
+			t.Errorf("%q: expected the handshake to be refused", name)
+		}
+	}
+	if config := provider.TLSConfig(); config.MinVersion != tls.VersionTLS12 || config.NextProtos[0] != "h2" {
+		t.Errorf("expected TLS 1.2+ with HTTP/2, got %#x %v", config.MinVersion, config.NextProtos)
+	}
+}
+
+func TestAutocertHTTPHandlerFallsThrough(t *testing.T) {
+	provider, err := New(Options{AutocertDomains: []string{"api.example.com"}, AutocertCacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := provider.HTTPHandler(RedirectHandler(":443"))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://api.example.com/health", nil))
+	if rec.Code != http.StatusMovedPermanently || !strings.HasPrefix(rec.Header().Get("Location"), "https://") {
+		t.Errorf("expected a redirect, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
@@ -3,28 +3,47 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"exchange-rate-service/config"
 )
 
+// ErrHistoricalUnsupported is returned for dated requests to a provider
+// without history access (exchangerate-api on the free plan)
+var ErrHistoricalUnsupported = errors.New("historical rates not available on this plan")
+
+func init() {
+	RegisterProvider("exchangerate-api", func() (RateProvider, error) {
+		return NewRateClient(), nil
+	})
+}
+
 // RateClient wraps http calls to exchange api
 type RateClient struct {
-	client  *HTTPClient
-	baseurl string
+	endpoints      *EndpointSelector
+	keys           *KeyPool
+	historyEnabled bool
+	breaker        *CircuitBreaker
+	retry          RetryPolicy
 }
 
 // NewRateClient init new client
 func NewRateClient() *RateClient {
 	timeout := config.DefaultAPITimeout
-	httpclient := NewHTTPClient(config.ExternalAPIBaseURL, timeout)
+	selector := NewEndpointSelector(config.ServiceRegion, config.ProviderEndpoints, timeout)
 
 	return &RateClient{
-		client:  httpclient,
-		baseurl: config.ExternalAPIBaseURL,
+		endpoints:      selector,
+		keys:           NewKeyPool(config.ExchangeRateAPIKeys, config.ExchangeRateAPIKeyCooldown),
+		historyEnabled: config.ExchangeAPIHistoryEnabled,
+		breaker:        NewCircuitBreaker("exchangerate-api", config.CircuitBreakerThreshold, config.CircuitBreakerCooldown),
+		retry:          DefaultRetryPolicy(),
 	}
 }
 
@@ -41,45 +60,180 @@ type apiResp struct {
 	TargetCode         string  `json:"target_code"`
 	ConversionRate     float64 `json:"conversion_rate"`
 	ConversionResult   float64 `json:"conversion_result"`
+	ErrorType          string  `json:"error-type"`
+
+	// history endpoint returns the whole table for the base currency
+	ConversionRates map[string]float64 `json:"conversion_rates"`
+}
+
+// Name of the provider
+func (c *RateClient) Name() string {
+	return "exchangerate-api"
+}
+
+// SupportsHistorical is true only on plans with the history endpoint
+func (c *RateClient) SupportsHistorical() bool {
+	return c.historyEnabled
+}
+
+// GetRates quotes each target through GetRate - every call goes through the
+// key pool, retries and the circuit breaker like a single rate
+func (c *RateClient) GetRates(ctx context.Context, base string, targets []string, date string) (map[string]float64, error) {
+	return ratesPerTarget(ctx, c, base, targets, date)
 }
 
-// GetRate gets exchange rate with retry
-func (c *RateClient) GetRate(from, to, date string) (float64, error) {
-	maxRetries := 2
-	retryDelay := 500
+// GetRate gets exchange rate, retrying outages (network errors, 5xx, 429) as
+// the retry policy allows. A Retry-After from the provider is honored when it
+// fits within the policy's max delay; otherwise we give up so the chain can
+// fail over. While the circuit breaker is open we fail fast so the chain can
+// fail over (or the service can fall back to cache) without waiting on a dead upstream
+func (c *RateClient) GetRate(ctx context.Context, from, to, date string) (float64, error) {
+	// don't spend quota on a call we know the plan will reject - let the chain fail over
+	if date != "" && !c.historyEnabled {
+		return 0, ErrHistoricalUnsupported
+	}
 
 	var lastErr error
+	attempt := 1
 
-	for i := 1; i <= maxRetries; i++ {
-		rate, err := c.doAPICall(from, to, date)
+	for ; ; attempt++ {
+		if err := c.breaker.Allow(); err != nil {
+			if lastErr != nil {
+				return 0, fmt.Errorf("failed after %d tries: %w", attempt-1, lastErr)
+			}
+			return 0, err
+		}
+
+		rate, upstreamDown, err := c.doAPICall(ctx, from, to, date)
 		if err == nil {
+			c.breaker.RecordSuccess()
 			return rate, nil
 		}
 
+		// only outages count - the provider rejecting a bad pair is still a working provider
+		switch {
+		case ctx.Err() != nil:
+			// caller gave up (or ran out of deadline) - no verdict, and no retry on its behalf
+			c.breaker.Release()
+			return 0, err
+		case upstreamDown:
+			c.breaker.RecordFailure()
+		default:
+			// the provider answered (bad pair, every key out of quota, garbled
+			// body) - asking again won't change its mind
+			c.breaker.RecordSuccess()
+			return 0, err
+		}
+
 		lastErr = err
+		if attempt >= c.retry.attempts() {
+			break
+		}
+
+		delay, ok := c.retry.delay(ctx, attempt, err)
+		if !ok {
+			break
+		}
+		slog.InfoContext(ctx, "Provider call failed, retrying",
+			"pair", from+"-"+to, "attempt", attempt, "delay", delay.String(), "error", err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return 0, err
+		}
+	}
+
+	return 0, fmt.Errorf("failed after %d tries: %w", attempt, lastErr)
+}
+
+// doAPICall single logical request with the next key from the pool. A key
+// whose quota is used up is marked and the call repeated with the next one,
+// until a key answers or the pool runs dry - then the provider's last quota
+// answer is the error
+func (c *RateClient) doAPICall(ctx context.Context, from, to, dt string) (float64, bool, error) {
+	var quotaErr error
+	for {
+		key, err := c.keys.Acquire()
+		if err != nil {
+			if quotaErr != nil {
+				return 0, false, quotaErr
+			}
+			return 0, false, err
+		}
+
+		rate, upstreamDown, err := c.callWithKey(ctx, key, from, to, dt)
+		if !errors.Is(err, ErrQuotaExhausted) {
+			return rate, upstreamDown, err
+		}
+		c.keys.MarkExhausted(key)
+		quotaErr = err
+	}
+}
+
+// callWithKey walks the endpoints in preference order and fails over to the
+// next region when one is down. upstreamDown is true when no endpoint gave a
+// real answer
+func (c *RateClient) callWithKey(ctx context.Context, key, from, to, dt string) (float64, bool, error) {
+	endpoint := c.buildEndpoint(key, from, to, dt)
+
+	var lastErr error
+	for i, ep := range c.endpoints.ordered() {
+		rate, failover, err := c.callEndpoint(ctx, ep, endpoint, to)
+		if err == nil {
+			if i > 0 {
+				slog.InfoContext(ctx, "Served from failover endpoint", "pair", from+"-"+to, "region", ep.region)
+			}
+			return rate, false, nil
+		}
 
-		if i < maxRetries {
-			time.Sleep(time.Duration(retryDelay) * time.Millisecond)
+		lastErr = err
+		if !failover {
+			// the provider answered - another region won't answer differently
+			return 0, false, err
 		}
+		slog.WarnContext(ctx, "Provider endpoint failed", "pair", from+"-"+to, "region", ep.region, "error", err)
 	}
 
-	return 0, fmt.Errorf("failed after %d tries: %w", maxRetries, lastErr)
+	return 0, true, lastErr
 }
 
-// doAPICall single http req
-func (c *RateClient) doAPICall(from, to, dt string) (float64, error) {
+// callEndpoint does the http req against one endpoint. failover is true when
+// the error is about the endpoint itself (network, 5xx, 429) rather than the request
+func (c *RateClient) callEndpoint(parent context.Context, ep *regionalEndpoint, endpoint, to string) (float64, bool, error) {
 	timeout := 12 * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
-	endpoint := c.buildEndpoint(from, to, dt)
-
-	resp, err := c.client.Get(ctx, endpoint)
+	start := time.Now()
+	resp, err := ep.client.Get(ctx, endpoint)
 	if err != nil {
-		return 0, fmt.Errorf("http req failed: %w", err)
+		// the caller going away says nothing about the endpoint's health
+		if parent.Err() != nil {
+			return 0, false, fmt.Errorf("http req aborted: %w", parent.Err())
+		}
+		ep.recordFailure()
+		return 0, true, fmt.Errorf("http req failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		ep.recordFailure()
+		return 0, true, withRetryAfter(fmt.Errorf("api http %d: %w", resp.StatusCode, ErrRateLimited), resp)
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		ep.recordFailure()
+		body, _ := io.ReadAll(resp.Body)
+		return 0, true, withRetryAfter(fmt.Errorf("api http %d: %s", resp.StatusCode, string(body)), resp)
+	}
+	ep.recordSuccess(time.Since(start))
+
+	rate, err := parseRateResponse(resp, to)
+	return rate, false, err
+}
+
+// parseRateResponse decodes a pair or history response from exchangerate-api
+func parseRateResponse(resp *http.Response, to string) (float64, error) {
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return 0, fmt.Errorf("api http %d: %s", resp.StatusCode, string(body))
@@ -96,23 +250,48 @@ func (c *RateClient) doAPICall(from, to, dt string) (float64, error) {
 	}
 
 	if response.Result != "success" {
-		return 0, fmt.Errorf("api error: %s", response.Result)
+		return 0, providerError(response.ErrorType)
+	}
+
+	rate := response.ConversionRate
+	if response.ConversionRates != nil {
+		rate = response.ConversionRates[strings.ToUpper(to)]
 	}
 
-	if response.ConversionRate <= 0 {
-		return 0, fmt.Errorf("invalid rate: %f", response.ConversionRate)
+	if rate <= 0 {
+		return 0, fmt.Errorf("invalid rate: %f", rate)
 	}
 
-	return response.ConversionRate, nil
+	return rate, nil
+}
+
+// buildEndpoint makes url path - pair endpoint for latest, history endpoint for a date
+func (c *RateClient) buildEndpoint(key, from, to, dt string) string {
+	if dt != "" {
+		if date, err := time.Parse("2006-01-02", dt); err == nil {
+			return fmt.Sprintf("/%s/history/%s/%d/%d/%d", key, from, date.Year(), int(date.Month()), date.Day())
+		}
+	}
+
+	return fmt.Sprintf("/%s/pair/%s/%s/1", key, from, to)
+}
+
+// EndpointStatus reports latency and health for each configured provider endpoint
+func (c *RateClient) EndpointStatus() []EndpointStatus {
+	return c.endpoints.Status()
+}
+
+// KeyUsage reports request and quota counters for each API key in the pool
+func (c *RateClient) KeyUsage() []KeyUsage {
+	return c.keys.Usage()
 }
 
-// buildEndpoint makes url path
-func (c *RateClient) buildEndpoint(from, to, dt string) string {
-	// ignore date for now - need paid plan
-	return fmt.Sprintf("/%s/pair/%s/%s/1", config.ExchangeRateAPIKey, from, to)
+// BreakerState reports the circuit breaker state (closed, open or half-open)
+func (c *RateClient) BreakerState() string {
+	return c.breaker.State()
 }
 
 // Close cleanup
 func (c *RateClient) Close() {
-	c.client.Close()
+	c.endpoints.Close()
 }
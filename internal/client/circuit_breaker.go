@@ -0,0 +1,128 @@
+package client
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling upstream while the breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// breaker states as reported in /health and cache stats
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// CircuitBreaker stops calls to a failing upstream. After threshold consecutive
+// failures it opens for cooldown; then one probe call is let through (half-open)
+// and its result decides whether the circuit closes or opens again.
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu            sync.Mutex
+	state         string
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+}
+
+// NewCircuitBreaker creates a closed breaker - threshold <= 0 disables it
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+	}
+}
+
+// Allow reports whether a call may go upstream right now
+func (b *CircuitBreaker) Allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probeInFlight = true
+		slog.Info("Circuit breaker half-open, probing upstream", "breaker", b.name)
+		return nil
+	case BreakerHalfOpen:
+		// only the one probe - everyone else waits for its verdict
+		if b.probeInFlight {
+			return ErrCircuitOpen
+		}
+		b.probeInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess closes the circuit
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerClosed {
+		slog.Info("Circuit breaker closed, upstream recovered", "breaker", b.name)
+	}
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probeInFlight = false
+}
+
+// RecordFailure counts an upstream failure, opening the circuit at the threshold
+// or straight away when the half-open probe fails
+func (b *CircuitBreaker) RecordFailure() {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probeInFlight = false
+
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		slog.Warn("Circuit breaker opened, pausing calls",
+			"breaker", b.name, "failures", b.failures, "cooldown", b.cooldown.String())
+	}
+}
+
+// Release gives back a half-open probe slot without a verdict, e.g. when the
+// caller canceled before the upstream answered
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probeInFlight = false
+}
+
+// State returns closed, open or half-open. An open breaker whose cooldown has
+// passed reports half-open since the next call will be a probe
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
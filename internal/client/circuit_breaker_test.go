@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	breaker := NewCircuitBreaker("test", 3, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		breaker.RecordFailure()
+	}
+	if breaker.State() != BreakerClosed || breaker.Allow() != nil {
+		t.Fatal("breaker should stay closed below the threshold")
+	}
+
+	breaker.RecordFailure()
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("expected open after 3 failures, got %s", breaker.State())
+	}
+	if !errors.Is(breaker.Allow(), ErrCircuitOpen) {
+		t.Error("open breaker should reject calls")
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	breaker := NewCircuitBreaker("test", 1, 10*time.Millisecond)
+	breaker.RecordFailure()
+
+	time.Sleep(20 * time.Millisecond)
+	if breaker.State() != BreakerHalfOpen {
+		t.Fatalf("expected half-open after cooldown, got %s", breaker.State())
+	}
+
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("first call after cooldown should be let through as a probe: %v", err)
+	}
+	if !errors.Is(breaker.Allow(), ErrCircuitOpen) {
+		t.Error("only one probe should be in flight")
+	}
+
+	// failed probe reopens straight away
+	breaker.RecordFailure()
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("failed probe should reopen the breaker, got %s", breaker.State())
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	breaker.Allow()
+	breaker.RecordSuccess()
+	if breaker.State() != BreakerClosed || breaker.Allow() != nil {
+		t.Error("successful probe should close the breaker")
+	}
+}
+
+func TestRateClient_BreakerStopsUpstreamCalls(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := newTestRateClient(server.URL, false)
+	client.breaker = NewCircuitBreaker("test", 2, time.Hour)
+
+	// first GetRate burns both retries and trips the breaker
+	if _, err := client.GetRate(context.Background(), "USD", "EUR", ""); err == nil {
+		t.Fatal("expected error from failing upstream")
+	}
+	if client.BreakerState() != BreakerOpen {
+		t.Fatalf("expected open breaker, got %s", client.BreakerState())
+	}
+
+	before := atomic.LoadInt32(&calls)
+	if _, err := client.GetRate(context.Background(), "USD", "EUR", ""); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if atomic.LoadInt32(&calls) != before {
+		t.Error("open breaker should not call the upstream")
+	}
+}
+
+func TestRateClient_ProviderErrorsDontTripBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"error","error-type":"unsupported-code"}`))
+	}))
+	defer server.Close()
+
+	client := newTestRateClient(server.URL, false)
+	client.breaker = NewCircuitBreaker("test", 1, time.Hour)
+
+	client.GetRate(context.Background(), "USD", "XXX", "")
+	if client.BreakerState() != BreakerClosed {
+		t.Errorf("a provider answering with an error is not an outage, got %s", client.BreakerState())
+	}
+}
+
+func TestRateClient_CanceledRequestStopsEarly(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := newTestRateClient(server.URL, false)
+	client.breaker = NewCircuitBreaker("test", 1, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := client.GetRate(ctx, "USD", "EUR", ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the caller's deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetRate should return with the caller's deadline, took %v", elapsed)
+	}
+	if client.BreakerState() != BreakerClosed {
+		t.Errorf("a canceled caller is not an upstream failure, breaker is %s", client.BreakerState())
+	}
+}
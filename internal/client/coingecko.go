@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+func init() {
+	RegisterProvider("coingecko", func() (RateProvider, error) {
+		return NewCoinGeckoProvider(), nil
+	})
+}
+
+// coingeckoIDs maps the tickers we accept to CoinGecko coin ids
+var coingeckoIDs = map[string]string{
+	"BTC":  "bitcoin",
+	"ETH":  "ethereum",
+	"SOL":  "solana",
+	"XRP":  "ripple",
+	"LTC":  "litecoin",
+	"ADA":  "cardano",
+	"DOGE": "dogecoin",
+	"USDT": "tether",
+	"USDC": "usd-coin",
+}
+
+// coingeckoBridge prices pairs where neither side is a coin (XAU-USD): CoinGecko
+// only quotes coins, so both sides are priced in bitcoin and divided
+const coingeckoBridge = "BTC"
+
+// CoinGeckoProvider fetches crypto and precious metal prices from CoinGecko
+// Metals are only available as quote currencies there, hence the bridge coin
+type CoinGeckoProvider struct {
+	client *HTTPClient
+}
+
+// NewCoinGeckoProvider init new provider - the API key is optional (demo plan key)
+func NewCoinGeckoProvider() *CoinGeckoProvider {
+	client := NewHTTPClient(config.CoinGeckoBaseURL, config.DefaultAPITimeout)
+	if config.CoinGeckoAPIKey != "" {
+		client.SetHeader("x-cg-demo-api-key", config.CoinGeckoAPIKey)
+	}
+	return &CoinGeckoProvider{client: client}
+}
+
+// coingeckoHistoryResp from /coins/{id}/history
+type coingeckoHistoryResp struct {
+	MarketData struct {
+		CurrentPrice map[string]float64 `json:"current_price"`
+	} `json:"market_data"`
+}
+
+// Name of the provider
+func (p *CoinGeckoProvider) Name() string {
+	return "coingecko"
+}
+
+// QuotesAssets marks this as a crypto/metals provider for the chain
+func (p *CoinGeckoProvider) QuotesAssets() bool {
+	return true
+}
+
+// SupportsHistorical - /coins/{id}/history has daily prices
+func (p *CoinGeckoProvider) SupportsHistorical() bool {
+	return true
+}
+
+// GetRates prices each pair on its own, since the coin a pair is priced in
+// depends on both sides
+func (p *CoinGeckoProvider) GetRates(ctx context.Context, base string, targets []string, date string) (map[string]float64, error) {
+	return ratesPerTarget(ctx, p, base, targets, date)
+}
+
+// SupportedCurrencies lists the coins and metals we map to CoinGecko
+func (p *CoinGeckoProvider) SupportedCurrencies(ctx context.Context) (map[string]string, error) {
+	codes := make(map[string]string, len(coingeckoIDs)+2)
+	for code := range coingeckoIDs {
+		codes[code] = ""
+	}
+	codes["XAU"] = ""
+	codes["XAG"] = ""
+	return codes, nil
+}
+
+// GetRate gets the latest or historical rate for a pair with at least one coin
+// or metal in it
+func (p *CoinGeckoProvider) GetRate(ctx context.Context, from, to, date string) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 12*time.Second)
+	defer cancel()
+
+	from = strings.ToUpper(from)
+	to = strings.ToUpper(to)
+
+	// price both sides in one coin: from's, else to's, else the bridge
+	coin := from
+	if _, found := coingeckoIDs[coin]; !found {
+		coin = to
+	}
+	if _, found := coingeckoIDs[coin]; !found {
+		coin = coingeckoBridge
+	}
+
+	prices, err := p.prices(ctx, coin, date, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	priceOf := func(code string) float64 {
+		if code == coin {
+			return 1
+		}
+		return prices[strings.ToLower(code)]
+	}
+	fromPrice, toPrice := priceOf(from), priceOf(to)
+	if fromPrice <= 0 || toPrice <= 0 {
+		return 0, fmt.Errorf("no rate for %s-%s in response", from, to)
+	}
+
+	return toPrice / fromPrice, nil
+}
+
+// prices returns coin's price in each of codes (lowercase keys, as CoinGecko sends them)
+func (p *CoinGeckoProvider) prices(ctx context.Context, coin, date string, codes ...string) (map[string]float64, error) {
+	id := coingeckoIDs[coin]
+
+	if date == "" {
+		vsCurrencies := make([]string, 0, len(codes))
+		for _, code := range codes {
+			if code != coin {
+				vsCurrencies = append(vsCurrencies, strings.ToLower(code))
+			}
+		}
+		endpoint := fmt.Sprintf("/simple/price?ids=%s&vs_currencies=%s", id, url.QueryEscape(strings.Join(vsCurrencies, ",")))
+
+		var response map[string]map[string]float64
+		if err := p.get(ctx, endpoint, &response); err != nil {
+			return nil, err
+		}
+		return response[id], nil
+	}
+
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", date, err)
+	}
+	endpoint := fmt.Sprintf("/coins/%s/history?date=%s&localization=false", id, day.Format("02-01-2006"))
+
+	var response coingeckoHistoryResp
+	if err := p.get(ctx, endpoint, &response); err != nil {
+		return nil, err
+	}
+	return response.MarketData.CurrentPrice, nil
+}
+
+// get does a GET and decodes a 200 response into out
+func (p *CoinGeckoProvider) get(ctx context.Context, endpoint string, out interface{}) error {
+	resp, err := p.client.Get(ctx, endpoint)
+	if err != nil {
+		return fmt.Errorf("http req failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read body failed: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("api http %d: %w", resp.StatusCode, ErrRateLimited)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("api http %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("json parse failed: %w", err)
+	}
+	return nil
+}
+
+// Ping calls /ping, which costs nothing against the rate limit's quota
+func (p *CoinGeckoProvider) Ping(ctx context.Context) error {
+	var pong struct {
+		GeckoSays string `json:"gecko_says"`
+	}
+	return p.get(ctx, "/ping", &pong)
+}
+
+// Close cleanup
+func (p *CoinGeckoProvider) Close() {
+	p.client.Close()
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"exchange-rate-service/config"
+)
+
+// newTestCoinGecko points a CoinGeckoProvider at a fake server
+func newTestCoinGecko(t *testing.T, handler http.HandlerFunc) *CoinGeckoProvider {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	defer func(baseURL string) { config.CoinGeckoBaseURL = baseURL }(config.CoinGeckoBaseURL)
+	config.CoinGeckoBaseURL = server.URL
+	return NewCoinGeckoProvider()
+}
+
+func TestCoinGecko_LatestRates(t *testing.T) {
+	var queries []string
+	provider := newTestCoinGecko(t, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Path+"?"+r.URL.RawQuery)
+		w.Write([]byte(`{"bitcoin":{"usd":60000,"xau":25,"eur":55000}}`))
+	})
+
+	tests := []struct {
+		from, to string
+		want     float64
+	}{
+		{"BTC", "USD", 60000},
+		{"usd", "btc", 1.0 / 60000},
+		// neither side is a coin - priced through bitcoin
+		{"XAU", "USD", 2400},
+	}
+	for _, tt := range tests {
+		rate, err := provider.GetRate(context.Background(), tt.from, tt.to, "")
+		if err != nil || math.Abs(rate-tt.want) > 1e-9 {
+			t.Errorf("%s-%s: expected %v, got %v (%v)", tt.from, tt.to, tt.want, rate, err)
+		}
+	}
+
+	if queries[0] != "/simple/price?ids=bitcoin&vs_currencies=usd" {
+		t.Errorf("unexpected latest query %s", queries[0])
+	}
+	if queries[2] != "/simple/price?ids=bitcoin&vs_currencies=xau%2Cusd" {
+		t.Errorf("unexpected bridged query %s", queries[2])
+	}
+}
+
+func TestCoinGecko_Historical(t *testing.T) {
+	var requested string
+	provider := newTestCoinGecko(t, func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path + "?" + r.URL.RawQuery
+		w.Write([]byte(`{"market_data":{"current_price":{"usd":3000,"eur":2750}}}`))
+	})
+
+	rate, err := provider.GetRate(context.Background(), "ETH", "EUR", "2024-01-15")
+	if err != nil || rate != 2750 {
+		t.Fatalf("expected 2750, got %v (%v)", rate, err)
+	}
+	if requested != "/coins/ethereum/history?date=15-01-2024&localization=false" {
+		t.Errorf("unexpected history query %s", requested)
+	}
+}
+
+func TestCoinGecko_Errors(t *testing.T) {
+	limited := newTestCoinGecko(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	if _, err := limited.GetRate(context.Background(), "BTC", "USD", ""); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected a rate limit error, got %v", err)
+	}
+
+	missing := newTestCoinGecko(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"bitcoin":{}}`))
+	})
+	if _, err := missing.GetRate(context.Background(), "BTC", "XYZ", ""); err == nil {
+		t.Error("expected an error when the quote currency is missing")
+	}
+}
@@ -0,0 +1,250 @@
+package client
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+// the ECB publishes once a working day, so re-downloading per pair is wasteful
+const (
+	ecbLatestTTL  = 10 * time.Minute
+	ecbHistoryTTL = time.Hour
+)
+
+func init() {
+	RegisterProvider("ecb", func() (RateProvider, error) {
+		return NewECBProvider(), nil
+	})
+}
+
+// ECBProvider reads the European Central Bank reference rate feeds
+// All rates are EUR based, other pairs are derived as cross rates
+type ECBProvider struct {
+	client *HTTPClient
+
+	mu        sync.Mutex
+	latest    *ecbSnapshot
+	history   *ecbSnapshot
+	latestDay string
+}
+
+// ecbSnapshot is a parsed feed: date -> currency -> rate against EUR
+type ecbSnapshot struct {
+	days      map[string]map[string]float64
+	fetchedAt time.Time
+}
+
+// ecbEnvelope matches the eurofxref xml layout
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string  `xml:"currency,attr"`
+			Rate     float64 `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// NewECBProvider init new provider
+func NewECBProvider() *ECBProvider {
+	return &ECBProvider{
+		client: NewHTTPClient(config.ECBBaseURL, config.DefaultAPITimeout),
+	}
+}
+
+// Name of the provider
+func (p *ECBProvider) Name() string {
+	return "ecb"
+}
+
+// SupportsHistorical - only the last 90 days, which is what the history feed holds
+func (p *ECBProvider) SupportsHistorical() bool {
+	return true
+}
+
+// GetRate derives the pair rate from the EUR reference rates
+func (p *ECBProvider) GetRate(ctx context.Context, from, to, date string) (float64, error) {
+	rates, err := p.GetRates(ctx, from, []string{to}, date)
+	if err != nil {
+		return 0, err
+	}
+	return rates[strings.ToUpper(to)], nil
+}
+
+// GetRates derives every target from one day's EUR reference rates
+func (p *ECBProvider) GetRates(ctx context.Context, base string, targets []string, date string) (map[string]float64, error) {
+	day, eurRates, err := p.ratesFor(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	baseRate, ok := eurRate(eurRates, base)
+	if !ok {
+		return nil, fmt.Errorf("ecb has no rate for %s on %s: %w", base, day, ErrPairNotQuoted)
+	}
+
+	rates := make(map[string]float64, len(targets))
+	for _, target := range targets {
+		targetRate, ok := eurRate(eurRates, target)
+		if !ok {
+			return nil, fmt.Errorf("ecb has no rate for %s on %s: %w", target, day, ErrPairNotQuoted)
+		}
+		rates[strings.ToUpper(target)] = targetRate / baseRate
+	}
+
+	return rates, nil
+}
+
+// SupportedCurrencies lists the currencies in the latest fixing - the feed
+// carries no names
+func (p *ECBProvider) SupportedCurrencies(ctx context.Context) (map[string]string, error) {
+	_, eurRates, err := p.ratesFor(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	codes := map[string]string{"EUR": ""}
+	for code := range eurRates {
+		codes[code] = ""
+	}
+	return codes, nil
+}
+
+// GetRateRange derives the pair rate for every fixing day in [start, end]
+// Only covers the last 90 days since that's what the history feed holds
+func (p *ECBProvider) GetRateRange(ctx context.Context, from, to, start, end string) (map[string]float64, error) {
+	// prime the history snapshot through the normal path
+	if _, _, err := p.ratesFor(ctx, start); err != nil && !strings.Contains(err.Error(), "no rates for") {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	series := make(map[string]float64)
+	for day, rates := range p.history.days {
+		if day < start || day > end {
+			continue
+		}
+
+		fromRate, okFrom := eurRate(rates, from)
+		toRate, okTo := eurRate(rates, to)
+		if okFrom && okTo {
+			series[day] = toRate / fromRate
+		}
+	}
+
+	if len(series) == 0 {
+		return nil, fmt.Errorf("ecb has no rates between %s and %s", start, end)
+	}
+
+	return series, nil
+}
+
+// ratesFor returns the EUR based rates for date (latest when empty)
+func (p *ECBProvider) ratesFor(ctx context.Context, date string) (string, map[string]float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if date == "" {
+		if p.latest == nil || time.Since(p.latest.fetchedAt) > ecbLatestTTL {
+			snapshot, err := p.fetch(ctx, "/eurofxref-daily.xml")
+			if err != nil {
+				return "", nil, err
+			}
+			p.latest = snapshot
+			for day := range snapshot.days {
+				p.latestDay = day
+			}
+		}
+
+		rates, ok := p.latest.days[p.latestDay]
+		if !ok {
+			return "", nil, fmt.Errorf("ecb daily feed was empty")
+		}
+		return p.latestDay, rates, nil
+	}
+
+	if p.history == nil || time.Since(p.history.fetchedAt) > ecbHistoryTTL {
+		snapshot, err := p.fetch(ctx, "/eurofxref-hist-90d.xml")
+		if err != nil {
+			return "", nil, err
+		}
+		p.history = snapshot
+	}
+
+	rates, ok := p.history.days[date]
+	if !ok {
+		// weekends and TARGET holidays have no fixing
+		return "", nil, fmt.Errorf("ecb has no rates for %s", date)
+	}
+	return date, rates, nil
+}
+
+// fetch downloads and parses one of the eurofxref feeds
+func (p *ECBProvider) fetch(ctx context.Context, endpoint string) (*ecbSnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, 12*time.Second)
+	defer cancel()
+
+	resp, err := p.client.Get(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("http req failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("api http %d: %w", resp.StatusCode, ErrRateLimited)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("api http %d: %s", resp.StatusCode, string(body))
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("xml parse failed: %w", err)
+	}
+
+	snapshot := &ecbSnapshot{
+		days:      make(map[string]map[string]float64, len(envelope.Days)),
+		fetchedAt: time.Now(),
+	}
+	for _, day := range envelope.Days {
+		rates := make(map[string]float64, len(day.Rates))
+		for _, r := range day.Rates {
+			rates[r.Currency] = r.Rate
+		}
+		snapshot.days[day.Time] = rates
+	}
+
+	return snapshot, nil
+}
+
+// eurRate looks up a currency against EUR (EUR itself is always 1)
+func eurRate(rates map[string]float64, code string) (float64, bool) {
+	code = strings.ToUpper(code)
+	if code == "EUR" {
+		return 1, true
+	}
+	rate, ok := rates[code]
+	return rate, ok && rate > 0
+}
+
+// Ping downloads the daily feed (a couple of KB) without touching the parsed copy
+func (p *ECBProvider) Ping(ctx context.Context) error {
+	_, err := getBody(ctx, p.client, "/eurofxref-daily.xml")
+	return err
+}
+
+// Close cleanup
+func (p *ECBProvider) Close() {
+	p.client.Close()
+}
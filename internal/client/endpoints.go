@@ -0,0 +1,157 @@
+package client
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+// endpoint health tuning
+const (
+	endpointFailureThreshold = 3
+	endpointCooldown         = 30 * time.Second
+	latencySmoothing         = 0.3 // weight of the newest sample in the moving average
+)
+
+// regionalEndpoint is one provider base URL plus what we've learned about it
+type regionalEndpoint struct {
+	region  string
+	baseURL string
+	client  *HTTPClient
+
+	mu                  sync.Mutex
+	avgLatency          time.Duration
+	consecutiveFailures int
+	unhealthyUntil      time.Time
+}
+
+// EndpointStatus is a snapshot of an endpoint for health/stats output
+type EndpointStatus struct {
+	Region     string        `json:"region"`
+	BaseURL    string        `json:"base_url"`
+	AvgLatency time.Duration `json:"avg_latency_ns"`
+	Healthy    bool          `json:"healthy"`
+}
+
+// EndpointSelector orders provider endpoints: healthy same-region first,
+// then healthy cross-region, fastest first within each group. Endpoints that
+// keep failing are parked for a cooldown and only tried as a last resort.
+type EndpointSelector struct {
+	localRegion string
+	endpoints   []*regionalEndpoint
+}
+
+// NewEndpointSelector builds a selector from the configured endpoints
+func NewEndpointSelector(localRegion string, endpoints []config.ProviderEndpoint, timeout time.Duration) *EndpointSelector {
+	selector := &EndpointSelector{localRegion: localRegion}
+
+	for _, ep := range endpoints {
+		selector.endpoints = append(selector.endpoints, &regionalEndpoint{
+			region:  ep.Region,
+			baseURL: ep.BaseURL,
+			client:  NewHTTPClient(ep.BaseURL, timeout),
+		})
+	}
+
+	return selector
+}
+
+// ordered returns endpoints in the order they should be tried
+func (s *EndpointSelector) ordered() []*regionalEndpoint {
+	now := time.Now()
+
+	type candidate struct {
+		ep      *regionalEndpoint
+		healthy bool
+		local   bool
+		latency time.Duration
+		parked  time.Time
+	}
+
+	candidates := make([]candidate, 0, len(s.endpoints))
+	for _, ep := range s.endpoints {
+		ep.mu.Lock()
+		candidates = append(candidates, candidate{
+			ep:      ep,
+			healthy: now.After(ep.unhealthyUntil),
+			local:   ep.region == s.localRegion,
+			latency: ep.avgLatency,
+			parked:  ep.unhealthyUntil,
+		})
+		ep.mu.Unlock()
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.healthy != b.healthy {
+			return a.healthy
+		}
+		if !a.healthy {
+			// both parked - whichever comes back soonest goes first
+			return a.parked.Before(b.parked)
+		}
+		if a.local != b.local {
+			return a.local
+		}
+		return a.latency < b.latency
+	})
+
+	result := make([]*regionalEndpoint, len(candidates))
+	for i, c := range candidates {
+		result[i] = c.ep
+	}
+	return result
+}
+
+// Status returns a snapshot of every endpoint
+func (s *EndpointSelector) Status() []EndpointStatus {
+	now := time.Now()
+	statuses := make([]EndpointStatus, 0, len(s.endpoints))
+
+	for _, ep := range s.endpoints {
+		ep.mu.Lock()
+		statuses = append(statuses, EndpointStatus{
+			Region:     ep.region,
+			BaseURL:    ep.baseURL,
+			AvgLatency: ep.avgLatency,
+			Healthy:    now.After(ep.unhealthyUntil),
+		})
+		ep.mu.Unlock()
+	}
+
+	return statuses
+}
+
+// Close releases idle connections on every endpoint
+func (s *EndpointSelector) Close() {
+	for _, ep := range s.endpoints {
+		ep.client.Close()
+	}
+}
+
+// recordSuccess folds the latency sample into the moving average and resets failures
+func (ep *regionalEndpoint) recordSuccess(latency time.Duration) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	if ep.avgLatency == 0 {
+		ep.avgLatency = latency
+	} else {
+		ep.avgLatency = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(ep.avgLatency))
+	}
+	ep.consecutiveFailures = 0
+	ep.unhealthyUntil = time.Time{}
+}
+
+// recordFailure counts a failure and parks the endpoint once it crosses the threshold
+func (ep *regionalEndpoint) recordFailure() {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	ep.consecutiveFailures++
+	if ep.consecutiveFailures >= endpointFailureThreshold {
+		ep.unhealthyUntil = time.Now().Add(endpointCooldown)
+	}
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+func TestEndpointSelector_PrefersLocalRegion(t *testing.T) {
+	selector := NewEndpointSelector("ap-south-1", []config.ProviderEndpoint{
+		{Region: "us-east-1", BaseURL: "http://us.example"},
+		{Region: "ap-south-1", BaseURL: "http://ap.example"},
+	}, time.Second)
+
+	// Remote is faster, but local should still be preferred while healthy
+	selector.endpoints[0].recordSuccess(10 * time.Millisecond)
+	selector.endpoints[1].recordSuccess(200 * time.Millisecond)
+
+	order := selector.ordered()
+	if order[0].region != "ap-south-1" {
+		t.Errorf("expected local region first, got %s", order[0].region)
+	}
+}
+
+func TestEndpointSelector_FailsOverWhenLocalUnhealthy(t *testing.T) {
+	selector := NewEndpointSelector("ap-south-1", []config.ProviderEndpoint{
+		{Region: "ap-south-1", BaseURL: "http://ap.example"},
+		{Region: "us-east-1", BaseURL: "http://us.example"},
+	}, time.Second)
+
+	for i := 0; i < endpointFailureThreshold; i++ {
+		selector.endpoints[0].recordFailure()
+	}
+
+	order := selector.ordered()
+	if order[0].region != "us-east-1" {
+		t.Errorf("expected failover to us-east-1, got %s", order[0].region)
+	}
+	if len(order) != 2 {
+		t.Errorf("parked endpoints should still be tried last, got %d endpoints", len(order))
+	}
+}
+
+func TestEndpointSelector_LatencyOrderingAcrossRegions(t *testing.T) {
+	selector := NewEndpointSelector("eu-west-1", []config.ProviderEndpoint{
+		{Region: "us-east-1", BaseURL: "http://us.example"},
+		{Region: "ap-south-1", BaseURL: "http://ap.example"},
+	}, time.Second)
+
+	selector.endpoints[0].recordSuccess(300 * time.Millisecond)
+	selector.endpoints[1].recordSuccess(50 * time.Millisecond)
+
+	order := selector.ordered()
+	if order[0].region != "ap-south-1" {
+		t.Errorf("expected fastest remote endpoint first, got %s", order[0].region)
+	}
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+func init() {
+	RegisterProvider("frankfurter", func() (RateProvider, error) {
+		return NewFrankfurterProvider(), nil
+	})
+}
+
+// FrankfurterProvider fetches rates from frankfurter.app (ECB data, free, no key)
+type FrankfurterProvider struct {
+	client *HTTPClient
+}
+
+// NewFrankfurterProvider init new provider
+func NewFrankfurterProvider() *FrankfurterProvider {
+	return &FrankfurterProvider{
+		client: NewHTTPClient(config.FrankfurterBaseURL, config.DefaultAPITimeout),
+	}
+}
+
+// frankfurterResp from api.frankfurter.app
+type frankfurterResp struct {
+	Amount float64            `json:"amount"`
+	Base   string             `json:"base"`
+	Date   string             `json:"date"`
+	Rates  map[string]float64 `json:"rates"`
+}
+
+// Name of the provider
+func (p *FrankfurterProvider) Name() string {
+	return "frankfurter"
+}
+
+// SupportsHistorical - every published day back to 1999 is available
+func (p *FrankfurterProvider) SupportsHistorical() bool {
+	return true
+}
+
+// GetRate gets the latest or historical rate for a pair
+func (p *FrankfurterProvider) GetRate(ctx context.Context, from, to, date string) (float64, error) {
+	rates, err := p.GetRates(ctx, from, []string{to}, date)
+	if err != nil {
+		return 0, err
+	}
+	return rates[strings.ToUpper(to)], nil
+}
+
+// GetRates gets the latest or historical rates for several targets in one call
+func (p *FrankfurterProvider) GetRates(ctx context.Context, base string, targets []string, date string) (map[string]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 12*time.Second)
+	defer cancel()
+
+	base = strings.ToUpper(base)
+	codes := make([]string, len(targets))
+	for i, target := range targets {
+		codes[i] = strings.ToUpper(target)
+	}
+
+	path := "latest"
+	if date != "" {
+		path = date
+	}
+	endpoint := fmt.Sprintf("/%s?from=%s&to=%s", path, url.QueryEscape(base), url.QueryEscape(strings.Join(codes, ",")))
+
+	resp, err := p.client.Get(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("http req failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body failed: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("api http %d: %w", resp.StatusCode, ErrRateLimited)
+	}
+	// frankfurter answers an unknown currency with a 404
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("api http %d: %s: %w", resp.StatusCode, string(body), ErrPairNotQuoted)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("api http %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response frankfurterResp
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("json parse failed: %w", err)
+	}
+
+	rates := make(map[string]float64, len(codes))
+	for _, code := range codes {
+		rate, found := response.Rates[code]
+		if !found || rate <= 0 {
+			return nil, fmt.Errorf("no rate for %s-%s in response: %w", base, code, ErrPairNotQuoted)
+		}
+		rates[code] = rate
+	}
+
+	return rates, nil
+}
+
+// frankfurterRangeResp from the /start..end time series endpoint
+type frankfurterRangeResp struct {
+	Base  string                        `json:"base"`
+	Rates map[string]map[string]float64 `json:"rates"`
+}
+
+// GetRateRange fetches a whole date range in one call - days without a fixing are absent
+func (p *FrankfurterProvider) GetRateRange(ctx context.Context, from, to, start, end string) (map[string]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	from = strings.ToUpper(from)
+	to = strings.ToUpper(to)
+	endpoint := fmt.Sprintf("/%s..%s?from=%s&to=%s", start, end, url.QueryEscape(from), url.QueryEscape(to))
+
+	resp, err := p.client.Get(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("http req failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body failed: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("api http %d: %w", resp.StatusCode, ErrRateLimited)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("api http %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response frankfurterRangeResp
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("json parse failed: %w", err)
+	}
+
+	series := make(map[string]float64, len(response.Rates))
+	for day, rates := range response.Rates {
+		if rate, found := rates[to]; found && rate > 0 {
+			series[day] = rate
+		}
+	}
+
+	return series, nil
+}
+
+// Ping fetches the currency list - small, and Frankfurter has no quota
+func (p *FrankfurterProvider) Ping(ctx context.Context) error {
+	_, err := getBody(ctx, p.client, "/currencies")
+	return err
+}
+
+// Close cleanup
+func (p *FrankfurterProvider) Close() {
+	p.client.Close()
+}
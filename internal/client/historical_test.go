@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+// newTestRateClient points a RateClient at a fake exchangerate-api server
+func newTestRateClient(baseURL string, historyEnabled bool) *RateClient {
+	return &RateClient{
+		endpoints: NewEndpointSelector("test", []config.ProviderEndpoint{
+			{Region: "test", BaseURL: baseURL},
+		}, time.Second),
+		keys:           NewKeyPool([]string{"test-key"}, time.Minute),
+		historyEnabled: historyEnabled,
+		breaker:        NewCircuitBreaker("test", 0, 0),
+		retry:          RetryPolicy{MaxAttempts: 2, BaseDelay: 10 * time.Millisecond},
+	}
+}
+
+func TestRateClient_HistoricalUsesHistoryEndpoint(t *testing.T) {
+	var requestedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		w.Write([]byte(`{"result":"success","base_code":"USD","conversion_rates":{"EUR":0.9123,"INR":83.1}}`))
+	}))
+	defer server.Close()
+
+	rate, err := newTestRateClient(server.URL, true).GetRate(context.Background(), "USD", "EUR", "2024-01-15")
+	if err != nil {
+		t.Fatalf("historical GetRate failed: %v", err)
+	}
+
+	if !strings.HasSuffix(requestedPath, "/history/USD/2024/1/15") {
+		t.Errorf("expected history endpoint, got %s", requestedPath)
+	}
+	if rate != 0.9123 {
+		t.Errorf("expected 0.9123 for 2024-01-15, got %f", rate)
+	}
+}
+
+func TestRateClient_LatestStillUsesPairEndpoint(t *testing.T) {
+	var requestedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		w.Write([]byte(`{"result":"success","conversion_rate":0.95}`))
+	}))
+	defer server.Close()
+
+	rate, err := newTestRateClient(server.URL, true).GetRate(context.Background(), "USD", "EUR", "")
+	if err != nil || rate != 0.95 {
+		t.Fatalf("expected 0.95, got %f (%v)", rate, err)
+	}
+	if !strings.HasSuffix(requestedPath, "/pair/USD/EUR/1") {
+		t.Errorf("expected pair endpoint, got %s", requestedPath)
+	}
+}
+
+func TestProviderChain_HistoricalFallsBackWhenPlanHasNoHistory(t *testing.T) {
+	var exchangeAPICalls int32
+	exchangeAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&exchangeAPICalls, 1)
+		w.Write([]byte(`{"result":"error","error-type":"plan-upgrade-required"}`))
+	}))
+	defer exchangeAPI.Close()
+
+	frankfurter := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/2024-01-10":
+			w.Write([]byte(`{"amount":1,"base":"USD","date":"2024-01-10","rates":{"EUR":0.9140}}`))
+		case "/2024-01-13":
+			// Saturday - frankfurter answers with Friday's fixing
+			w.Write([]byte(`{"amount":1,"base":"USD","date":"2024-01-12","rates":{"EUR":0.9131}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer frankfurter.Close()
+
+	chain := NewProviderChain(
+		newTestRateClient(exchangeAPI.URL, false),
+		&FrankfurterProvider{client: NewHTTPClient(frankfurter.URL, time.Second)},
+	)
+
+	weekday, err := chain.GetRate(context.Background(), "USD", "EUR", "2024-01-10")
+	if err != nil || weekday != 0.9140 {
+		t.Errorf("expected past weekday rate 0.9140, got %f (%v)", weekday, err)
+	}
+
+	weekend, err := chain.GetRate(context.Background(), "USD", "EUR", "2024-01-13")
+	if err != nil || weekend != 0.9131 {
+		t.Errorf("expected weekend to resolve to Friday's rate 0.9131, got %f (%v)", weekend, err)
+	}
+
+	if atomic.LoadInt32(&exchangeAPICalls) != 0 {
+		t.Errorf("plan without history should not spend quota on dated calls, got %d calls", exchangeAPICalls)
+	}
+}
@@ -5,6 +5,11 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"exchange-rate-service/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // HTTPClient wraps the standard HTTP client with additional functionality
@@ -57,11 +62,19 @@ func (c *HTTPClient) doRequest(ctx context.Context, method, endpoint string, bod
 	// Set common headers
 	req.Header.Set("User-Agent", "exchange-rate-service/1.0.0")
 	req.Header.Set("Accept", "application/json")
-	
+
+	// no url.full - some providers put the API key in the path
+	_, span := tracing.StartKind(ctx, method, trace.SpanKindClient,
+		attribute.String("http.request.method", method),
+		attribute.String("server.address", req.URL.Host),
+	)
 	resp, err := c.client.Do(req)
 	if err != nil {
+		tracing.End(span, err)
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	span.End()
 	
 	return resp, nil
 }
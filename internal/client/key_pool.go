@@ -0,0 +1,131 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExhausted is wrapped when an upstream API key has used up its quota
+// It is a rate limit as far as the provider chain is concerned
+var ErrQuotaExhausted = fmt.Errorf("api key quota reached: %w", ErrRateLimited)
+
+// errNoKeys is returned by a pool built without keys
+var errNoKeys = errors.New("no upstream api keys configured")
+
+// KeyUsage is one pool key as shown in admin stats - never the full key
+type KeyUsage struct {
+	Key            string     `json:"key"`
+	Requests       int64      `json:"requests"`
+	QuotaErrors    int64      `json:"quota_errors"`
+	Exhausted      bool       `json:"exhausted"`
+	ExhaustedUntil *time.Time `json:"exhausted_until,omitempty"`
+}
+
+type poolKey struct {
+	key            string
+	requests       int64
+	quotaErrors    int64
+	exhaustedUntil time.Time
+}
+
+// KeyPool spreads upstream requests across several API keys round robin.
+// A key that reports its quota used up is skipped for the cooldown, then
+// tried again - if the quota hasn't reset yet it is simply marked again.
+type KeyPool struct {
+	cooldown time.Duration
+
+	mu   sync.Mutex
+	keys []*poolKey
+	next int
+}
+
+// NewKeyPool creates a pool - empty keys are dropped
+func NewKeyPool(keys []string, cooldown time.Duration) *KeyPool {
+	pool := &KeyPool{cooldown: cooldown}
+	for _, key := range keys {
+		if key != "" {
+			pool.keys = append(pool.keys, &poolKey{key: key})
+		}
+	}
+	return pool
+}
+
+// Acquire returns the next key that isn't exhausted and counts a request on it
+// Fails with ErrQuotaExhausted when every key is sitting out its cooldown
+func (p *KeyPool) Acquire() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.keys) == 0 {
+		return "", errNoKeys
+	}
+
+	now := time.Now()
+	for i := 0; i < len(p.keys); i++ {
+		candidate := p.keys[(p.next+i)%len(p.keys)]
+		if now.Before(candidate.exhaustedUntil) {
+			continue
+		}
+		p.next = (p.next + i + 1) % len(p.keys)
+		candidate.requests++
+		return candidate.key, nil
+	}
+
+	return "", fmt.Errorf("all %d keys exhausted: %w", len(p.keys), ErrQuotaExhausted)
+}
+
+// MarkExhausted takes key out of rotation for the pool cooldown
+func (p *KeyPool) MarkExhausted(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, candidate := range p.keys {
+		if candidate.key == key {
+			candidate.quotaErrors++
+			candidate.exhaustedUntil = time.Now().Add(p.cooldown)
+			slog.Warn("Upstream API key quota reached, rotating to the next key",
+				"key", maskKey(key), "retry_after", p.cooldown.String())
+			return
+		}
+	}
+}
+
+// Len returns the number of keys in the pool
+func (p *KeyPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.keys)
+}
+
+// Usage reports per-key counters, keys masked
+func (p *KeyPool) Usage() []KeyUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	usage := make([]KeyUsage, len(p.keys))
+	for i, candidate := range p.keys {
+		usage[i] = KeyUsage{
+			Key:         maskKey(candidate.key),
+			Requests:    candidate.requests,
+			QuotaErrors: candidate.quotaErrors,
+		}
+		if now.Before(candidate.exhaustedUntil) {
+			until := candidate.exhaustedUntil
+			usage[i].Exhausted = true
+			usage[i].ExhaustedUntil = &until
+		}
+	}
+	return usage
+}
+
+// maskKey keeps the last 4 characters - enough to tell keys apart in logs
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
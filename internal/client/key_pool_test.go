@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyPool_RotatesAndSkipsExhausted(t *testing.T) {
+	pool := NewKeyPool([]string{"key-aaaa", "", "key-bbbb", "key-cccc"}, time.Hour)
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		key, _ := pool.Acquire()
+		got = append(got, key)
+	}
+	if strings.Join(got, ",") != "key-aaaa,key-bbbb,key-cccc,key-aaaa" {
+		t.Errorf("expected round robin over non-empty keys, got %v", got)
+	}
+
+	pool.MarkExhausted("key-bbbb")
+	for i := 0; i < 4; i++ {
+		if key, _ := pool.Acquire(); key == "key-bbbb" {
+			t.Fatal("exhausted key should sit out its cooldown")
+		}
+	}
+
+	pool.MarkExhausted("key-aaaa")
+	pool.MarkExhausted("key-cccc")
+	if _, err := pool.Acquire(); !errors.Is(err, ErrQuotaExhausted) || !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected a quota (rate limit) error with every key exhausted, got %v", err)
+	}
+}
+
+func TestKeyPool_KeyReturnsAfterCooldown(t *testing.T) {
+	pool := NewKeyPool([]string{"only-key"}, 10*time.Millisecond)
+	pool.MarkExhausted("only-key")
+
+	if _, err := pool.Acquire(); err == nil {
+		t.Fatal("expected the exhausted key to be unavailable")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if key, err := pool.Acquire(); err != nil || key != "only-key" {
+		t.Errorf("expected the key back after the cooldown, got %q (%v)", key, err)
+	}
+}
+
+func TestKeyPool_UsageMasksKeys(t *testing.T) {
+	pool := NewKeyPool([]string{"secret-1234", "secret-5678"}, time.Hour)
+	pool.Acquire()
+	pool.Acquire()
+	pool.Acquire()
+	pool.MarkExhausted("secret-5678")
+
+	usage := pool.Usage()
+	if usage[0].Key != "****1234" || usage[0].Requests != 2 || usage[0].Exhausted {
+		t.Errorf("unexpected usage for first key: %+v", usage[0])
+	}
+	if usage[1].Key != "****5678" || usage[1].QuotaErrors != 1 || !usage[1].Exhausted || usage[1].ExhaustedUntil == nil {
+		t.Errorf("unexpected usage for exhausted key: %+v", usage[1])
+	}
+}
+
+func TestRateClient_RotatesPastExhaustedKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/spent-key/") {
+			w.Write([]byte(`{"result":"error","error-type":"quota-reached"}`))
+			return
+		}
+		w.Write([]byte(`{"result":"success","conversion_rate":0.95}`))
+	}))
+	defer server.Close()
+
+	rateClient := newTestRateClient(server.URL, false)
+	rateClient.keys = NewKeyPool([]string{"spent-key", "fresh-key"}, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		rate, err := rateClient.GetRate(context.Background(), "USD", "EUR", "")
+		if err != nil || rate != 0.95 {
+			t.Fatalf("call %d: expected 0.95 from the fresh key, got %f (%v)", i, rate, err)
+		}
+	}
+
+	usage := rateClient.KeyUsage()
+	if usage[0].Requests != 1 || !usage[0].Exhausted {
+		t.Errorf("spent key should be tried once then parked, got %+v", usage[0])
+	}
+	if usage[1].Requests != 3 {
+		t.Errorf("fresh key should serve every call, got %+v", usage[1])
+	}
+
+	rateClient.keys.MarkExhausted("fresh-key")
+	if _, err := rateClient.GetRate(context.Background(), "USD", "EUR", ""); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected a rate limit error once every key is spent, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+// built-in fixture set, used unless MOCK_RATES_FILE points at another one
+//
+//go:embed mock_rates.json
+var defaultMockRates []byte
+
+// mockFixtures is the fixture file layout: rates against base, plus optional
+// exact rates for specific days (YYYY-MM-DD -> currency -> rate against base)
+type mockFixtures struct {
+	Base    string                        `json:"base"`
+	Rates   map[string]float64            `json:"rates"`
+	History map[string]map[string]float64 `json:"history"`
+}
+
+func init() {
+	RegisterProvider("mock", func() (RateProvider, error) {
+		return NewMockProvider(config.MockRatesFile)
+	})
+}
+
+// MockProvider serves deterministic rates from fixtures without touching the
+// network, for local development and CI. Latest rates are the fixture rates;
+// a historical day uses the fixture for that day when there is one, otherwise
+// the latest rate nudged by a small drift derived from the date, so time
+// series look plausible and are the same on every run
+type MockProvider struct {
+	fixtures mockFixtures
+}
+
+// NewMockProvider loads fixtures from path, or the built-in set when path is empty
+func NewMockProvider(path string) (*MockProvider, error) {
+	data := defaultMockRates
+	if path != "" {
+		fileData, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mock rates: %w", err)
+		}
+		data = fileData
+	}
+
+	var fixtures mockFixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse mock rates: %w", err)
+	}
+	if fixtures.Base == "" || len(fixtures.Rates) == 0 {
+		return nil, fmt.Errorf("mock rates need a base currency and rates against it")
+	}
+
+	fixtures.Base = strings.ToUpper(fixtures.Base)
+	fixtures.Rates = upperKeys(fixtures.Rates)
+	fixtures.Rates[fixtures.Base] = 1
+	for day, rates := range fixtures.History {
+		fixtures.History[day] = upperKeys(rates)
+	}
+
+	return &MockProvider{fixtures: fixtures}, nil
+}
+
+// Name of the provider
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+// SupportsHistorical - past days come from the history fixtures or the drift
+func (p *MockProvider) SupportsHistorical() bool {
+	return true
+}
+
+// GetRates derives each target from the fixture rates
+func (p *MockProvider) GetRates(ctx context.Context, base string, targets []string, date string) (map[string]float64, error) {
+	return ratesPerTarget(ctx, p, base, targets, date)
+}
+
+// GetRate derives the pair from the fixture rates against the base currency
+func (p *MockProvider) GetRate(ctx context.Context, from, to, date string) (float64, error) {
+	fromRate, err := p.baseRate(strings.ToUpper(from), date)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := p.baseRate(strings.ToUpper(to), date)
+	if err != nil {
+		return 0, err
+	}
+	return toRate / fromRate, nil
+}
+
+// GetRateRange returns a rate for every day in [start, end]
+func (p *MockProvider) GetRateRange(ctx context.Context, from, to, start, end string) (map[string]float64, error) {
+	startDay, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date: %s", start)
+	}
+	endDay, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date: %s", end)
+	}
+
+	series := make(map[string]float64)
+	for day := startDay; !day.After(endDay); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		rate, err := p.GetRate(ctx, from, to, date)
+		if err != nil {
+			return nil, err
+		}
+		series[date] = rate
+	}
+	return series, nil
+}
+
+// Ping always succeeds - the fixtures are in memory
+func (p *MockProvider) Ping(ctx context.Context) error {
+	return nil
+}
+
+// SupportedCurrencies lists the fixture currencies - names come from the registry's metadata
+func (p *MockProvider) SupportedCurrencies(ctx context.Context) (map[string]string, error) {
+	codes := make(map[string]string, len(p.fixtures.Rates))
+	for code := range p.fixtures.Rates {
+		codes[code] = ""
+	}
+	return codes, nil
+}
+
+// baseRate is code against the base currency on date (latest when empty)
+func (p *MockProvider) baseRate(code, date string) (float64, error) {
+	latest, found := p.fixtures.Rates[code]
+	if !found || latest <= 0 {
+		return 0, fmt.Errorf("mock has no rate for %s: %w", code, ErrPairNotQuoted)
+	}
+	if date == "" || code == p.fixtures.Base {
+		return latest, nil
+	}
+
+	if rate, found := p.fixtures.History[date][code]; found && rate > 0 {
+		return rate, nil
+	}
+
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date: %s", date)
+	}
+	return latest * (1 + mockDrift(code, day)), nil
+}
+
+// mockDrift is a repeatable wobble within ±2% - a slow wave per currency
+// with its own phase, so pairs don't all move together
+func mockDrift(code string, day time.Time) float64 {
+	hash := fnv.New32a()
+	hash.Write([]byte(code))
+	phase := float64(hash.Sum32()%360) * math.Pi / 180
+
+	days := float64(day.Unix() / 86400)
+	return 0.02 * math.Sin(days/17+phase)
+}
+
+func upperKeys(rates map[string]float64) map[string]float64 {
+	upper := make(map[string]float64, len(rates))
+	for code, rate := range rates {
+		upper[strings.ToUpper(code)] = rate
+	}
+	return upper
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMockProvider_BuiltinFixtures(t *testing.T) {
+	provider, err := NewMockProvider("")
+	if err != nil {
+		t.Fatalf("built-in fixtures should load: %v", err)
+	}
+	ctx := context.Background()
+
+	if rate, _ := provider.GetRate(ctx, "usd", "eur", ""); rate != 0.8606 {
+		t.Errorf("expected the fixture rate 0.8606, got %v", rate)
+	}
+	// cross rate through the base
+	if rate, _ := provider.GetRate(ctx, "EUR", "INR", ""); math.Abs(rate-87.6968/0.8606) > 1e-9 {
+		t.Errorf("expected EUR-INR derived from USD rates, got %v", rate)
+	}
+	// a day with its own fixture
+	if rate, _ := provider.GetRate(ctx, "USD", "EUR", "2024-01-15"); rate != 0.9123 {
+		t.Errorf("expected the 2024-01-15 fixture, got %v", rate)
+	}
+	if _, err := provider.GetRate(ctx, "USD", "XYZ", ""); err == nil {
+		t.Error("expected an error for a currency without fixtures")
+	}
+}
+
+func TestMockProvider_DerivedHistoryIsDeterministic(t *testing.T) {
+	provider, _ := NewMockProvider("")
+	ctx := context.Background()
+
+	series, err := provider.GetRateRange(ctx, "USD", "JPY", "2023-03-01", "2023-03-31")
+	if err != nil || len(series) != 31 {
+		t.Fatalf("expected 31 days, got %d (%v)", len(series), err)
+	}
+
+	distinct := make(map[float64]bool)
+	for day, rate := range series {
+		again, _ := provider.GetRate(ctx, "USD", "JPY", day)
+		if again != rate {
+			t.Fatalf("%s: rate changed between calls: %v then %v", day, rate, again)
+		}
+		if math.Abs(rate/147.38-1) > 0.02+1e-9 {
+			t.Errorf("%s: %v drifted more than 2%% from the latest rate", day, rate)
+		}
+		distinct[rate] = true
+	}
+	if len(distinct) < 2 {
+		t.Error("a month of derived history should not be flat")
+	}
+}
+
+func TestMockProvider_FixtureFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	os.WriteFile(path, []byte(`{"base":"eur","rates":{"usd":1.1,"gbp":0.85}}`), 0o644)
+
+	provider, err := NewMockProvider(path)
+	if err != nil {
+		t.Fatalf("fixture file should load: %v", err)
+	}
+	if rate, _ := provider.GetRate(context.Background(), "EUR", "USD", ""); rate != 1.1 {
+		t.Errorf("expected 1.1 from the file, got %v", rate)
+	}
+	codes, _ := provider.SupportedCurrencies(context.Background())
+	if len(codes) != 3 {
+		t.Errorf("expected EUR, USD and GBP, got %v", codes)
+	}
+
+	os.WriteFile(path, []byte(`{"rates":{"USD":1}}`), 0o644)
+	if _, err := NewMockProvider(path); err == nil {
+		t.Error("expected an error for fixtures without a base")
+	}
+}
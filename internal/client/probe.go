@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/metrics"
+	"exchange-rate-service/internal/models"
+)
+
+// how long one probe may take before it counts as failed
+const probeTimeout = 5 * time.Second
+
+// Pinger is implemented by providers with a cheap call that proves they are
+// reachable and accept our credentials, without spending rate quota
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// ProviderProbe pings every provider of a chain in the background, so a dead
+// API key or a DNS failure shows up in readiness and metrics before a user
+// request runs into it. Providers without a Ping aren't probed
+type ProviderProbe struct {
+	providers []Provider
+	interval  time.Duration
+
+	mu      sync.Mutex
+	results map[string]models.ProviderHealth
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// NewProviderProbe probes chain's providers every interval
+func NewProviderProbe(chain *ProviderChain, interval time.Duration) *ProviderProbe {
+	providers := make([]Provider, 0, len(chain.providers))
+	for _, provider := range chain.providers {
+		if _, ok := provider.(Pinger); ok {
+			providers = append(providers, provider)
+		}
+	}
+
+	return &ProviderProbe{
+		providers: providers,
+		interval:  interval,
+		results:   make(map[string]models.ProviderHealth, len(providers)),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start probes right away, then every interval until Stop
+func (p *ProviderProbe) Start() {
+	p.done.Add(1)
+	go func() {
+		defer p.done.Done()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		p.ProbeAll(context.Background())
+		for {
+			select {
+			case <-ticker.C:
+				p.ProbeAll(context.Background())
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the probe loop
+func (p *ProviderProbe) Stop() {
+	close(p.stop)
+	p.done.Wait()
+}
+
+// ProbeAll pings every provider concurrently and records the outcomes
+func (p *ProviderProbe) ProbeAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, provider := range p.providers {
+		wg.Add(1)
+		go func(provider Provider) {
+			defer wg.Done()
+			p.probe(ctx, provider)
+		}(provider)
+	}
+	wg.Wait()
+}
+
+// probe pings one provider, logging when it goes down or comes back
+func (p *ProviderProbe) probe(ctx context.Context, provider Provider) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := provider.(Pinger).Ping(ctx)
+	latency := time.Since(start)
+	metrics.RecordProviderProbe(provider.Name(), err, latency)
+
+	p.mu.Lock()
+	previous, probed := p.results[provider.Name()]
+	result := models.ProviderHealth{
+		Name:      provider.Name(),
+		Status:    "up",
+		LatencyMs: float64(latency) / float64(time.Millisecond),
+		CheckedAt: start.UTC(),
+	}
+	if err != nil {
+		result.Status = "down"
+		result.Error = err.Error()
+		result.Failures = previous.Failures + 1
+	}
+	p.results[provider.Name()] = result
+	p.mu.Unlock()
+
+	switch {
+	case err != nil && (!probed || previous.Status == "up"):
+		slog.Warn("Provider probe failed", "provider", provider.Name(), "latency_ms", latency.Milliseconds(), "error", err)
+	case err == nil && probed && previous.Status == "down":
+		slog.Info("Provider probe recovered", "provider", provider.Name(), "latency_ms", latency.Milliseconds(), "failed_probes", previous.Failures)
+	}
+}
+
+// Results returns the last probe of each provider, in failover order
+func (p *ProviderProbe) Results() []models.ProviderHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	results := make([]models.ProviderHealth, 0, len(p.results))
+	for _, provider := range p.providers {
+		if result, found := p.results[provider.Name()]; found {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Check fails when every fiat provider failed its last probe - requests
+// would have nowhere to go on a cache miss. Passes until the first probes
+// are in, and when some fiat provider can't be probed
+func (p *ProviderProbe) Check(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	failures := make([]string, 0, len(p.providers))
+	for _, provider := range p.providers {
+		if isAssetProvider(provider) {
+			continue
+		}
+		result, found := p.results[provider.Name()]
+		if !found || result.Status == "up" {
+			return nil
+		}
+		failures = append(failures, provider.Name()+": "+result.Error)
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("every provider failed its last probe (%s)", strings.Join(failures, "; "))
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakePinger is a fakeProvider with a Ping
+type fakePinger struct {
+	fakeProvider
+	pingErr error
+}
+
+func (f *fakePinger) Ping(ctx context.Context) error { return f.pingErr }
+
+// fakeAssetPinger is a pingable crypto provider
+type fakeAssetPinger struct {
+	fakePinger
+}
+
+func (f *fakeAssetPinger) QuotesAssets() bool { return true }
+
+func TestProviderProbe_RecordsResults(t *testing.T) {
+	primary := &fakePinger{fakeProvider: fakeProvider{name: "primary"}}
+	secondary := &fakePinger{fakeProvider: fakeProvider{name: "secondary"}, pingErr: errors.New("invalid key")}
+	unprobed := &fakeProvider{name: "unprobed"}
+	probe := NewProviderProbe(NewProviderChain(primary, unprobed, secondary), 0)
+
+	if results := probe.Results(); len(results) != 0 {
+		t.Fatalf("expected no results before the first probe, got %+v", results)
+	}
+
+	probe.ProbeAll(context.Background())
+	probe.ProbeAll(context.Background())
+
+	results := probe.Results()
+	if len(results) != 2 || results[0].Name != "primary" || results[1].Name != "secondary" {
+		t.Fatalf("expected pingable providers in failover order, got %+v", results)
+	}
+	if results[0].Status != "up" || results[0].Failures != 0 || results[0].Error != "" || results[0].CheckedAt.IsZero() {
+		t.Errorf("unexpected result for the healthy provider: %+v", results[0])
+	}
+	if results[1].Status != "down" || results[1].Failures != 2 || results[1].Error != "invalid key" {
+		t.Errorf("unexpected result for the failing provider: %+v", results[1])
+	}
+
+	secondary.pingErr = nil
+	probe.ProbeAll(context.Background())
+	if recovered := probe.Results()[1]; recovered.Status != "up" || recovered.Failures != 0 {
+		t.Errorf("expected the provider to recover, got %+v", recovered)
+	}
+}
+
+func TestProviderProbe_Check(t *testing.T) {
+	primary := &fakePinger{fakeProvider: fakeProvider{name: "primary"}, pingErr: errors.New("no such host")}
+	secondary := &fakePinger{fakeProvider: fakeProvider{name: "secondary"}}
+	crypto := &fakeAssetPinger{fakePinger{fakeProvider: fakeProvider{name: "crypto"}}}
+	probe := NewProviderProbe(NewProviderChain(primary, secondary, crypto), 0)
+
+	if err := probe.Check(context.Background()); err != nil {
+		t.Errorf("check should pass before the first probe, got %v", err)
+	}
+
+	probe.ProbeAll(context.Background())
+	if err := probe.Check(context.Background()); err != nil {
+		t.Errorf("check should pass while one fiat provider is up, got %v", err)
+	}
+
+	// the crypto provider being up doesn't help fiat conversions
+	secondary.pingErr = errors.New("timeout")
+	probe.ProbeAll(context.Background())
+	err := probe.Check(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "primary: no such host") || !strings.Contains(err.Error(), "secondary: timeout") {
+		t.Errorf("expected the check to fail with every provider's error, got %v", err)
+	}
+}
+
+func TestRateClient_Ping(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/revoked-key/quota" {
+			w.Write([]byte(`{"result":"error","error-type":"invalid-key"}`))
+			return
+		}
+		w.Write([]byte(`{"result":"success","requests_remaining":1200}`))
+	}))
+	defer server.Close()
+
+	rateClient := newTestRateClient(server.URL, false)
+	rateClient.keys = NewKeyPool([]string{"good-key"}, time.Hour)
+	if err := rateClient.Ping(context.Background()); err != nil {
+		t.Errorf("expected a working key to ping, got %v", err)
+	}
+
+	rateClient.keys = NewKeyPool([]string{"revoked-key"}, time.Hour)
+	if err := rateClient.Ping(context.Background()); err == nil || !strings.Contains(err.Error(), "invalid-key") {
+		t.Errorf("expected a revoked key to fail the ping, got %v", err)
+	}
+}
@@ -0,0 +1,359 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/metrics"
+	"exchange-rate-service/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrRateLimited is wrapped by providers when the upstream rejects us for quota reasons
+var ErrRateLimited = errors.New("provider rate limit reached")
+
+// ErrPairNotQuoted is wrapped by providers that don't quote one of a pair's
+// currencies, and by the chain when none of its providers does - the service
+// can then route the pair through an intermediate currency
+var ErrPairNotQuoted = apperrors.New(apperrors.CodePairUnavailable, "no provider quotes the pair")
+
+// how long a rate limited provider is skipped before we try it again
+const providerCooldown = time.Minute
+
+// Provider is one upstream source of exchange rates
+// An empty date means latest, otherwise YYYY-MM-DD
+type Provider interface {
+	Name() string
+	GetRate(ctx context.Context, from, to, date string) (float64, error)
+}
+
+// RateProvider is the full contract of a pluggable provider - what a new
+// provider file implements and registers with RegisterProvider. The chain
+// itself only needs Provider, so test fakes can stay small.
+type RateProvider interface {
+	Provider
+	// SupportsHistorical reports whether GetRate accepts a date - the chain
+	// skips providers that don't for dated requests
+	SupportsHistorical() bool
+	// GetRates quotes every target against base in as few upstream calls as
+	// the provider allows. Fails unless every target could be quoted
+	GetRates(ctx context.Context, base string, targets []string, date string) (map[string]float64, error)
+	// SupportedCurrencies lists the codes the provider quotes (code -> name,
+	// empty names are filled in from the currency metadata)
+	SupportedCurrencies(ctx context.Context) (map[string]string, error)
+}
+
+// RangeProvider is implemented by providers that can return a whole date range
+// in one request. Keys are YYYY-MM-DD; non-trading days may be missing.
+type RangeProvider interface {
+	GetRateRange(ctx context.Context, from, to, start, end string) (map[string]float64, error)
+}
+
+// AssetProvider is implemented by providers that quote crypto and precious
+// metals. The chain sends them only pairs involving those assets, and never
+// sends such pairs to the fiat providers.
+type AssetProvider interface {
+	QuotesAssets() bool
+}
+
+// RateRecorder is told about every rate a provider returns, with the provider's name
+type RateRecorder interface {
+	RecordRate(source, from, to, date string, rate float64)
+}
+
+// ProviderChain tries providers in order and fails over to the next one when
+// a provider errors. Rate limited providers are skipped for a cooldown window
+// so we stop burning requests on them.
+type ProviderChain struct {
+	providers []Provider
+	recorder  RateRecorder
+
+	mu            sync.Mutex
+	cooldownUntil map[string]time.Time
+}
+
+// NewProviderChain creates a failover chain - order is priority
+func NewProviderChain(providers ...Provider) *ProviderChain {
+	return &ProviderChain{
+		providers:     providers,
+		cooldownUntil: make(map[string]time.Time),
+	}
+}
+
+// SetRecorder makes the chain report every successful fetch to recorder
+// Must be called before the chain is used
+func (c *ProviderChain) SetRecorder(recorder RateRecorder) {
+	c.recorder = recorder
+}
+
+// GetRate asks each provider in turn until one answers
+// Stops early once ctx is done - nobody is waiting for the answer anymore
+func (c *ProviderChain) GetRate(ctx context.Context, from, to, date string) (float64, error) {
+	rate, _, err := c.GetRateWithSource(ctx, from, to, date)
+	return rate, err
+}
+
+// GetRateWithSource is GetRate plus the name of the provider that answered
+func (c *ProviderChain) GetRateWithSource(ctx context.Context, from, to, date string) (float64, string, error) {
+	failures := make([]string, 0, len(c.providers))
+	var lastErr error
+
+	attempted, notQuoted := 0, 0
+	for _, provider := range c.providers {
+		if err := ctx.Err(); err != nil {
+			return 0, "", err
+		}
+		if !quotesPair(provider, from, to) {
+			continue
+		}
+		attempted++
+		if c.inCooldown(provider.Name()) {
+			failures = append(failures, provider.Name()+": cooling down after rate limit")
+			continue
+		}
+		if date != "" && !supportsHistorical(provider) {
+			// don't spend a call (or quota) on a request the provider can't answer
+			lastErr = ErrHistoricalUnsupported
+			failures = append(failures, provider.Name()+": "+ErrHistoricalUnsupported.Error())
+			continue
+		}
+
+		callCtx, span := tracing.Start(ctx, "provider.GetRate",
+			append(tracing.Pair(from, to, date), attribute.String("provider", provider.Name()))...)
+		start := time.Now()
+		rate, err := provider.GetRate(callCtx, from, to, date)
+		latency := time.Since(start)
+		tracing.End(span, err)
+		metrics.RecordUpstreamCall(provider.Name(), err, latency)
+		slog.DebugContext(ctx, "Upstream rate call", "provider", provider.Name(), "pair", from+"-"+to,
+			"date", date, "latency_ms", latency.Milliseconds(), "status", callStatus(err))
+		if err == nil {
+			if attempted > 1 {
+				slog.InfoContext(ctx, "Rate served by fallback provider", "pair", from+"-"+to, "provider", provider.Name())
+			}
+			if c.recorder != nil {
+				c.recorder.RecordRate(provider.Name(), from, to, date, rate)
+			}
+			return rate, provider.Name(), nil
+		}
+
+		lastErr = err
+		failures = append(failures, fmt.Sprintf("%s: %v", provider.Name(), err))
+		if pairNotQuoted(err) {
+			notQuoted++
+		}
+
+		if errors.Is(err, ErrRateLimited) {
+			c.startCooldown(provider.Name())
+			slog.WarnContext(ctx, "Provider rate limited, skipping it",
+				"provider", provider.Name(), "cooldown", providerCooldown.String())
+		}
+	}
+
+	if attempted == 0 {
+		return 0, "", apperrors.Wrap(apperrors.CodePairUnavailable, ErrPairNotQuoted, "no provider quotes %s-%s", from, to)
+	}
+	if notQuoted == attempted {
+		if apperrors.UpstreamOf(lastErr) != "" {
+			// lastErr first, so a provider's own error code still decides the status
+			return 0, "", fmt.Errorf("api request failed: no provider quotes %s-%s (%s): %w: %w",
+				from, to, strings.Join(failures, "; "), lastErr, ErrPairNotQuoted)
+		}
+		// the message is what clients see; the per-provider trail is only for the logs
+		return 0, "", apperrors.Wrap(apperrors.CodePairUnavailable,
+			errors.New(strings.Join(failures, "; ")), "no provider quotes %s-%s", from, to)
+	}
+	if lastErr == nil {
+		lastErr = ErrRateLimited
+	}
+
+	return 0, "", fmt.Errorf("api request failed: all providers failed (%s): %w", strings.Join(failures, "; "), lastErr)
+}
+
+// GetRateRange asks range-capable providers in order. Callers should fall back
+// to per-day GetRate calls when this fails.
+func (c *ProviderChain) GetRateRange(ctx context.Context, from, to, start, end string) (map[string]float64, error) {
+	series, _, err := c.GetRateRangeWithSource(ctx, from, to, start, end)
+	return series, err
+}
+
+// GetRateRangeWithSource is GetRateRange plus the name of the provider that answered
+func (c *ProviderChain) GetRateRangeWithSource(ctx context.Context, from, to, start, end string) (map[string]float64, string, error) {
+	failures := make([]string, 0, len(c.providers))
+
+	for _, provider := range c.providers {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		rangeProvider, ok := provider.(RangeProvider)
+		if !ok || !quotesPair(provider, from, to) || !supportsHistorical(provider) || c.inCooldown(provider.Name()) {
+			continue
+		}
+
+		startedAt := time.Now()
+		series, err := rangeProvider.GetRateRange(ctx, from, to, start, end)
+		latency := time.Since(startedAt)
+		metrics.RecordUpstreamCall(provider.Name(), err, latency)
+		slog.DebugContext(ctx, "Upstream range call", "provider", provider.Name(), "pair", from+"-"+to,
+			"start", start, "end", end, "latency_ms", latency.Milliseconds(), "status", callStatus(err))
+		if err == nil {
+			if c.recorder != nil {
+				for day, rate := range series {
+					c.recorder.RecordRate(provider.Name(), from, to, day, rate)
+				}
+			}
+			return series, provider.Name(), nil
+		}
+
+		failures = append(failures, fmt.Sprintf("%s: %v", provider.Name(), err))
+		if errors.Is(err, ErrRateLimited) {
+			c.startCooldown(provider.Name())
+		}
+	}
+
+	return nil, "", fmt.Errorf("no provider could serve range (%s)", strings.Join(failures, "; "))
+}
+
+// CheckUpstream fails when no fiat provider can currently be tried - every one
+// is cooling down after a rate limit, has an open circuit breaker or has used
+// up the quota of every API key. Doesn't call the providers, so probes don't
+// eat into quota.
+func (c *ProviderChain) CheckUpstream(ctx context.Context) error {
+	unavailable := make([]string, 0, len(c.providers))
+	for _, provider := range c.providers {
+		if isAssetProvider(provider) {
+			continue
+		}
+		if c.inCooldown(provider.Name()) {
+			unavailable = append(unavailable, provider.Name()+": rate limited")
+			continue
+		}
+		if breaker, ok := provider.(interface{ BreakerState() string }); ok && breaker.BreakerState() == BreakerOpen {
+			unavailable = append(unavailable, provider.Name()+": circuit open")
+			continue
+		}
+		if pool, ok := provider.(interface{ KeyUsage() []KeyUsage }); ok && allExhausted(pool.KeyUsage()) {
+			unavailable = append(unavailable, provider.Name()+": quota exhausted")
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("no provider available (%s)", strings.Join(unavailable, "; "))
+}
+
+// allExhausted reports whether every key of a non-empty pool is out of quota
+func allExhausted(keys []KeyUsage) bool {
+	for _, key := range keys {
+		if !key.Exhausted {
+			return false
+		}
+	}
+	return len(keys) > 0
+}
+
+// HasAssetProvider reports whether any provider can quote crypto and metals
+func (c *ProviderChain) HasAssetProvider() bool {
+	for _, provider := range c.providers {
+		if isAssetProvider(provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// Providers returns the provider names in priority order
+func (c *ProviderChain) Providers() []string {
+	names := make([]string, len(c.providers))
+	for i, provider := range c.providers {
+		names[i] = provider.Name()
+	}
+	return names
+}
+
+// BreakerStates returns the circuit breaker state of every provider that has one
+func (c *ProviderChain) BreakerStates() map[string]string {
+	states := make(map[string]string)
+	for _, provider := range c.providers {
+		if breaker, ok := provider.(interface{ BreakerState() string }); ok {
+			states[provider.Name()] = breaker.BreakerState()
+		}
+	}
+	return states
+}
+
+// KeyUsage returns API key pool usage of every provider that rotates keys
+func (c *ProviderChain) KeyUsage() map[string][]KeyUsage {
+	usage := make(map[string][]KeyUsage)
+	for _, provider := range c.providers {
+		if pool, ok := provider.(interface{ KeyUsage() []KeyUsage }); ok {
+			usage[provider.Name()] = pool.KeyUsage()
+		}
+	}
+	return usage
+}
+
+// Close releases resources held by providers that have any
+func (c *ProviderChain) Close() {
+	for _, provider := range c.providers {
+		if closer, ok := provider.(interface{ Close() }); ok {
+			closer.Close()
+		}
+	}
+}
+
+// quotesPair matches pairs to providers by asset class: crypto/metal pairs
+// go to asset providers only, fiat pairs to everyone else
+func quotesPair(provider Provider, from, to string) bool {
+	return isAssetProvider(provider) == currency.IsAssetPair(from, to)
+}
+
+// pairNotQuoted is true when a provider answered that it doesn't quote the
+// pair, its own way (exchangerate-api's unsupported-code) or ErrPairNotQuoted
+func pairNotQuoted(err error) bool {
+	return errors.Is(err, ErrPairNotQuoted) || errors.Is(err, apperrors.ErrUnsupportedCurrency)
+}
+
+// supportsHistorical is true unless the provider says it can't do dated requests
+func supportsHistorical(provider Provider) bool {
+	historical, ok := provider.(interface{ SupportsHistorical() bool })
+	return !ok || historical.SupportsHistorical()
+}
+
+func isAssetProvider(provider Provider) bool {
+	asset, ok := provider.(AssetProvider)
+	return ok && asset.QuotesAssets()
+}
+
+// callStatus is the status field logged for one provider call
+func callStatus(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	default:
+		return "error"
+	}
+}
+
+func (c *ProviderChain) inCooldown(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	until, found := c.cooldownUntil[name]
+	return found && time.Now().Before(until)
+}
+
+func (c *ProviderChain) startCooldown(name string) {
+	c.mu.Lock()
+	c.cooldownUntil[name] = time.Now().Add(providerCooldown)
+	c.mu.Unlock()
+}
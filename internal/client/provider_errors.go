@@ -0,0 +1,41 @@
+package client
+
+import (
+	"exchange-rate-service/internal/apperrors"
+)
+
+// providerErrors classifies exchangerate-api's error types by whose problem
+// they are: the caller's request (4xx), our quota (429), or our account and
+// plan (502). Types not listed are treated as the latter. A day without data
+// stays upstream_unavailable, so historical lookups fall back to the prior
+// business day
+var providerErrors = map[string]struct {
+	code    apperrors.Code
+	message string
+}{
+	"unsupported-code":      {apperrors.CodeUnsupportedCurrency, "currency not supported by the exchange rate provider"},
+	"malformed-request":     {apperrors.CodeInvalidRequest, "exchange rate provider rejected the request as malformed"},
+	"no-data-available":     {apperrors.CodeUpstreamUnavailable, "exchange rate provider has no data for the requested date"},
+	"quota-reached":         {apperrors.CodeRateLimited, "exchange rate provider quota reached"},
+	"invalid-key":           {apperrors.CodeUpstreamRejected, "exchange rate provider rejected our credentials"},
+	"inactive-account":      {apperrors.CodeUpstreamRejected, "exchange rate provider rejected our credentials"},
+	"plan-upgrade-required": {apperrors.CodeUpstreamRejected, "request not covered by our exchange rate provider plan"},
+	"base-code-only-on-pro": {apperrors.CodeUpstreamRejected, "request not covered by our exchange rate provider plan"},
+}
+
+// providerError turns an error payload's type into a typed error clients get
+// a distinct status for, with the type as the upstream error. Quota errors
+// wrap ErrQuotaExhausted, so the key pool moves on to the next key
+func providerError(errorType string) *apperrors.Error {
+	known, found := providerErrors[errorType]
+	if !found {
+		known.code, known.message = apperrors.CodeUpstreamRejected, "exchange rate provider returned an error"
+	}
+
+	err := apperrors.New(known.code, "%s", known.message)
+	if known.code == apperrors.CodeRateLimited {
+		err.Err = ErrQuotaExhausted
+	}
+	err.Upstream = errorType
+	return err
+}
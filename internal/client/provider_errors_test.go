@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"exchange-rate-service/internal/apperrors"
+)
+
+func TestRateClient_ClassifiesProviderErrors(t *testing.T) {
+	tests := []struct {
+		errorType string
+		code      apperrors.Code
+	}{
+		{"unsupported-code", apperrors.CodeUnsupportedCurrency},
+		{"malformed-request", apperrors.CodeInvalidRequest},
+		{"no-data-available", apperrors.CodeUpstreamUnavailable},
+		{"quota-reached", apperrors.CodeRateLimited},
+		{"invalid-key", apperrors.CodeUpstreamRejected},
+		{"plan-upgrade-required", apperrors.CodeUpstreamRejected},
+		{"something-new", apperrors.CodeUpstreamRejected},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":"error","error-type":"` + tt.errorType + `"}`))
+		}))
+
+		_, err := newTestRateClient(server.URL, false).GetRate(context.Background(), "USD", "EUR", "")
+		server.Close()
+
+		if code := apperrors.CodeOf(err); code != tt.code {
+			t.Errorf("%s: expected %s, got %s (%v)", tt.errorType, tt.code, code, err)
+		}
+		if upstream := apperrors.UpstreamOf(err); upstream != tt.errorType {
+			t.Errorf("%s: expected the provider's type kept, got %q", tt.errorType, upstream)
+		}
+	}
+}
+
+func TestProviderError_QuotaStillRotatesKeys(t *testing.T) {
+	err := providerError("quota-reached")
+	if !errors.Is(err, ErrQuotaExhausted) || !errors.Is(err, ErrRateLimited) {
+		t.Errorf("quota errors must stay recognizable to the key pool and the chain, got %v", err)
+	}
+	if errors.Is(providerError("invalid-key"), ErrRateLimited) {
+		t.Error("a rejected key is not a rate limit")
+	}
+}
@@ -0,0 +1,238 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+)
+
+// fakeProvider returns a canned result and counts calls
+type fakeProvider struct {
+	name  string
+	rate  float64
+	err   error
+	calls int
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) GetRate(ctx context.Context, from, to, date string) (float64, error) {
+	f.calls++
+	return f.rate, f.err
+}
+
+func TestProviderChain_FailsOverOnError(t *testing.T) {
+	primary := &fakeProvider{name: "primary", err: errors.New("boom")}
+	secondary := &fakeProvider{name: "secondary", rate: 0.91}
+
+	rate, source, err := NewProviderChain(primary, secondary).GetRateWithSource(context.Background(), "USD", "EUR", "")
+	if err != nil {
+		t.Fatalf("expected failover to succeed, got %v", err)
+	}
+	if rate != 0.91 || source != "secondary" {
+		t.Errorf("expected secondary rate 0.91, got %f from %q", rate, source)
+	}
+}
+
+func TestProviderChain_SkipsRateLimitedProvider(t *testing.T) {
+	primary := &fakeProvider{name: "primary", err: fmt.Errorf("quota: %w", ErrRateLimited)}
+	secondary := &fakeProvider{name: "secondary", rate: 0.91}
+	chain := NewProviderChain(primary, secondary)
+
+	chain.GetRate(context.Background(), "USD", "EUR", "")
+	chain.GetRate(context.Background(), "USD", "EUR", "")
+
+	if primary.calls != 1 {
+		t.Errorf("rate limited provider should be skipped during cooldown, called %d times", primary.calls)
+	}
+	if secondary.calls != 2 {
+		t.Errorf("expected secondary to serve both calls, called %d times", secondary.calls)
+	}
+}
+
+func TestProviderChain_CheckUpstream(t *testing.T) {
+	primary := &fakeProvider{name: "primary", err: fmt.Errorf("quota: %w", ErrRateLimited)}
+	secondary := &fakeProvider{name: "secondary", err: fmt.Errorf("quota: %w", ErrRateLimited)}
+	chain := NewProviderChain(primary, secondary)
+
+	if err := chain.CheckUpstream(context.Background()); err != nil {
+		t.Fatalf("fresh chain should be available, got %v", err)
+	}
+
+	chain.GetRate(context.Background(), "USD", "EUR", "")
+	err := chain.CheckUpstream(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "primary: rate limited") {
+		t.Errorf("expected unavailable with every provider cooling down, got %v", err)
+	}
+	if primary.calls+secondary.calls != 2 {
+		t.Errorf("CheckUpstream must not call providers, got %d calls", primary.calls+secondary.calls)
+	}
+}
+
+// pooledProvider reports key pool usage like a RateClient
+type pooledProvider struct {
+	fakeProvider
+	keys []KeyUsage
+}
+
+func (p *pooledProvider) KeyUsage() []KeyUsage { return p.keys }
+
+func TestProviderChain_CheckUpstream_QuotaExhausted(t *testing.T) {
+	pooled := &pooledProvider{fakeProvider: fakeProvider{name: "pooled"}, keys: []KeyUsage{{Key: "****a"}, {Key: "****b", Exhausted: true}}}
+	chain := NewProviderChain(pooled)
+
+	if err := chain.CheckUpstream(context.Background()); err != nil {
+		t.Fatalf("expected a pool with a key left to be available, got %v", err)
+	}
+	pooled.keys[0].Exhausted = true
+	if err := chain.CheckUpstream(context.Background()); err == nil || !strings.Contains(err.Error(), "pooled: quota exhausted") {
+		t.Errorf("expected unavailable with every key out of quota, got %v", err)
+	}
+}
+
+func TestProviderChain_AllFail(t *testing.T) {
+	chain := NewProviderChain(
+		&fakeProvider{name: "a", err: errors.New("down")},
+		&fakeProvider{name: "b", err: errors.New("also down")},
+	)
+
+	if _, err := chain.GetRate(context.Background(), "USD", "EUR", ""); err == nil {
+		t.Fatal("expected error when every provider fails")
+	}
+}
+
+func TestProviderChain_PairNotQuoted(t *testing.T) {
+	unsupported := providerError("unsupported-code")
+	chain := NewProviderChain(
+		&fakeProvider{name: "a", err: fmt.Errorf("ecb has no rate for XOF: %w", ErrPairNotQuoted)},
+		&fakeProvider{name: "b", err: unsupported},
+	)
+
+	_, err := chain.GetRate(context.Background(), "USD", "XOF", "")
+	if !errors.Is(err, ErrPairNotQuoted) {
+		t.Errorf("expected ErrPairNotQuoted when no provider quotes the pair, got %v", err)
+	}
+	// the last provider's own code still decides the status
+	if code := apperrors.CodeOf(err); code != apperrors.CodeUnsupportedCurrency {
+		t.Errorf("expected code %s, got %s", apperrors.CodeUnsupportedCurrency, code)
+	}
+
+	// one provider down rather than not quoting - an outage, not a routing case
+	chain = NewProviderChain(
+		&fakeProvider{name: "a", err: unsupported},
+		&fakeProvider{name: "b", err: errors.New("down")},
+	)
+	if _, err := chain.GetRate(context.Background(), "USD", "XOF", ""); errors.Is(err, ErrPairNotQuoted) {
+		t.Errorf("expected a plain failure while a provider is down, got %v", err)
+	}
+}
+
+const ecbFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time="2024-01-15">
+			<Cube currency="USD" rate="1.0950"/>
+			<Cube currency="INR" rate="90.90"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestECBProvider_CrossRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ecbFixture))
+	}))
+	defer server.Close()
+
+	provider := &ECBProvider{client: NewHTTPClient(server.URL, time.Second)}
+
+	eurUSD, err := provider.GetRate(context.Background(), "EUR", "USD", "")
+	if err != nil || eurUSD != 1.095 {
+		t.Errorf("expected EUR-USD 1.095, got %f (%v)", eurUSD, err)
+	}
+
+	usdINR, err := provider.GetRate(context.Background(), "USD", "INR", "2024-01-15")
+	if err != nil {
+		t.Fatalf("historical cross rate failed: %v", err)
+	}
+	if diff := usdINR - 90.90/1.095; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("unexpected USD-INR cross rate %f", usdINR)
+	}
+
+	if _, err := provider.GetRate(context.Background(), "USD", "INR", "2024-01-13"); err == nil {
+		t.Error("expected error for a date without a fixing")
+	}
+}
+
+func TestSupportedCodes(t *testing.T) {
+	exchangeAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/codes") {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"result":"success","supported_codes":[["AED","UAE Dirham"],["usd","United States Dollar"]]}`))
+	}))
+	defer exchangeAPI.Close()
+
+	codes, err := newTestRateClient(exchangeAPI.URL, false).SupportedCurrencies(context.Background())
+	if err != nil {
+		t.Fatalf("SupportedCurrencies failed: %v", err)
+	}
+	if codes["AED"] != "UAE Dirham" || codes["USD"] != "United States Dollar" {
+		t.Errorf("unexpected codes: %v", codes)
+	}
+
+	frankfurter := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"AUD":"Australian Dollar","EUR":"Euro"}`))
+	}))
+	defer frankfurter.Close()
+
+	provider := &FrankfurterProvider{client: NewHTTPClient(frankfurter.URL, time.Second)}
+	chain := NewProviderChain(&fakeProvider{name: "no-codes"}, provider)
+
+	codes, err = chain.SupportedCodes(context.Background())
+	if err != nil {
+		t.Fatalf("chain SupportedCodes failed: %v", err)
+	}
+	if codes["EUR"] != "Euro" || len(codes) != 2 {
+		t.Errorf("unexpected codes from chain: %v", codes)
+	}
+}
+
+// fakeAssetProvider is a fakeProvider that quotes crypto and metals
+type fakeAssetProvider struct {
+	fakeProvider
+}
+
+func (f *fakeAssetProvider) QuotesAssets() bool { return true }
+
+func TestProviderChain_RoutesByAssetClass(t *testing.T) {
+	fiat := &fakeProvider{name: "fiat", rate: 0.91}
+	crypto := &fakeAssetProvider{fakeProvider{name: "crypto", rate: 65000}}
+	chain := NewProviderChain(fiat, crypto)
+
+	if _, source, err := chain.GetRateWithSource(context.Background(), "BTC", "USD", ""); err != nil || source != "crypto" {
+		t.Errorf("expected BTC-USD from the asset provider, got %q (%v)", source, err)
+	}
+	if _, source, err := chain.GetRateWithSource(context.Background(), "USD", "XAU", ""); err != nil || source != "crypto" {
+		t.Errorf("expected USD-XAU from the asset provider, got %q (%v)", source, err)
+	}
+	if _, source, err := chain.GetRateWithSource(context.Background(), "USD", "EUR", ""); err != nil || source != "fiat" {
+		t.Errorf("expected USD-EUR from the fiat provider, got %q (%v)", source, err)
+	}
+	if fiat.calls != 1 || crypto.calls != 2 {
+		t.Errorf("expected 1 fiat and 2 asset calls, got %d and %d", fiat.calls, crypto.calls)
+	}
+
+	if _, err := NewProviderChain(fiat).GetRate(context.Background(), "BTC", "USD", ""); err == nil {
+		t.Error("expected an error when no provider quotes crypto")
+	}
+	if !chain.HasAssetProvider() || NewProviderChain(fiat).HasAssetProvider() {
+		t.Error("HasAssetProvider should only be true with an asset provider in the chain")
+	}
+}
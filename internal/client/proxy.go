@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/ratelimit"
+)
+
+// ErrProxyRateLimited is returned when the shared upstream quota is used up
+var ErrProxyRateLimited = errors.New("upstream rate limit reached")
+
+// ErrInvalidProxyPath is returned when the requested path names no provider endpoint
+var ErrInvalidProxyPath = errors.New("invalid proxy path")
+
+// maxProxyEntries bounds the response cache - callers pick the query strings,
+// so distinct keys alone could otherwise grow it without limit within one TTL
+const maxProxyEntries = 1000
+
+// ProxyResponse is a captured upstream response we can replay from cache
+type ProxyResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	FetchedAt   time.Time
+	FromCache   bool
+}
+
+// ProviderProxy forwards arbitrary provider endpoints with key injection,
+// response caching and a shared rate limit on upstream calls
+type ProviderProxy struct {
+	client  *HTTPClient
+	limiter *ratelimit.TokenBucket
+	ttl     time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]*ProxyResponse
+}
+
+// NewProviderProxy creates a proxy with the given cache ttl and upstream budget
+func NewProviderProxy(ttl time.Duration, ratePerMinute int) *ProviderProxy {
+	return &ProviderProxy{
+		client:  NewHTTPClient(config.ExternalAPIBaseURL, config.DefaultAPITimeout),
+		limiter: ratelimit.NewTokenBucket(ratePerMinute, 0),
+		ttl:     ttl,
+		entries: make(map[string]*ProxyResponse),
+	}
+}
+
+// Fetch returns the provider response for the given path (without api key)
+// serving from cache when possible
+func (p *ProviderProxy) Fetch(ctx context.Context, providerPath, rawQuery string) (*ProxyResponse, time.Duration, error) {
+	cleanPath, err := sanitizeProxyPath(providerPath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cacheKey := cleanPath
+	if rawQuery != "" {
+		cacheKey += "?" + rawQuery
+	}
+
+	if cached, ok := p.lookup(cacheKey); ok {
+		return cached, 0, nil
+	}
+
+	// only spend quota on actual upstream calls
+	if ok, wait := p.limiter.Reserve(); !ok {
+		return nil, wait, ErrProxyRateLimited
+	}
+
+	resp, err := p.client.Get(ctx, "/"+config.ExchangeRateAPIKey+cacheKey)
+	if err != nil {
+		return nil, 0, fmt.Errorf("api request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read body failed: %w", err)
+	}
+
+	result := &ProxyResponse{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+		FetchedAt:   time.Now(),
+	}
+
+	// don't cache errors - next caller should get a fresh attempt
+	if resp.StatusCode == http.StatusOK {
+		p.store(cacheKey, result)
+	}
+
+	return result, 0, nil
+}
+
+// lookup returns a cached response if it hasn't expired
+func (p *ProviderProxy) lookup(key string) (*ProxyResponse, bool) {
+	p.mu.RLock()
+	entry, found := p.entries[key]
+	p.mu.RUnlock()
+
+	if !found || time.Since(entry.FetchedAt) > p.ttl {
+		return nil, false
+	}
+
+	cached := *entry
+	cached.FromCache = true
+	return &cached, true
+}
+
+// store saves a response and drops expired entries so the map doesn't grow
+// forever. A cache still full of live entries makes room by dropping the oldest
+func (p *ProviderProxy) store(key string, resp *ProxyResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var oldestKey string
+	var oldest time.Time
+	for k, entry := range p.entries {
+		if time.Since(entry.FetchedAt) > p.ttl {
+			delete(p.entries, k)
+			continue
+		}
+		if oldestKey == "" || entry.FetchedAt.Before(oldest) {
+			oldestKey, oldest = k, entry.FetchedAt
+		}
+	}
+
+	if _, found := p.entries[key]; !found && len(p.entries) >= maxProxyEntries {
+		delete(p.entries, oldestKey)
+	}
+	p.entries[key] = resp
+}
+
+// sanitizeProxyPath normalizes the client path and strips any api key the
+// caller may have included - we always inject our own
+func sanitizeProxyPath(providerPath string) (string, error) {
+	cleaned := path.Clean("/" + strings.TrimPrefix(providerPath, "/"))
+	if cleaned == "/" {
+		return "", fmt.Errorf("%w: empty", ErrInvalidProxyPath)
+	}
+
+	segments := strings.Split(strings.TrimPrefix(cleaned, "/"), "/")
+	if len(segments) > 1 && looksLikeAPIKey(segments[0]) {
+		segments = segments[1:]
+	}
+
+	return "/" + strings.Join(segments, "/"), nil
+}
+
+// looksLikeAPIKey - exchangerate-api keys are 24 char hex strings
+func looksLikeAPIKey(segment string) bool {
+	if len(segment) != 24 {
+		return false
+	}
+	for _, ch := range segment {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// Close cleanup
+func (p *ProviderProxy) Close() {
+	p.client.Close()
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/ratelimit"
+)
+
+// newTestProxy points a ProviderProxy at a fake provider
+func newTestProxy(baseURL string, ttl time.Duration, ratePerMinute int) *ProviderProxy {
+	return &ProviderProxy{
+		client:  NewHTTPClient(baseURL, time.Second),
+		limiter: ratelimit.NewTokenBucket(ratePerMinute, 0),
+		ttl:     ttl,
+		entries: make(map[string]*ProxyResponse),
+	}
+}
+
+func TestProviderProxy_CachesAndExpires(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"result":"success"}`))
+	}))
+	defer server.Close()
+	proxy := newTestProxy(server.URL, time.Minute, 60)
+	ctx := context.Background()
+
+	first, _, err := proxy.Fetch(ctx, "/latest/USD", "")
+	if err != nil || first.FromCache || first.StatusCode != http.StatusOK {
+		t.Fatalf("expected an upstream response, got %+v err=%v", first, err)
+	}
+	second, _, err := proxy.Fetch(ctx, "latest/USD", "")
+	if err != nil || !second.FromCache || string(second.Body) != `{"result":"success"}` {
+		t.Fatalf("expected the cached response, got %+v err=%v", second, err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected one upstream call, got %d", calls.Load())
+	}
+
+	// past the ttl it is fetched again
+	proxy.mu.Lock()
+	proxy.entries["/latest/USD"].FetchedAt = time.Now().Add(-2 * time.Minute)
+	proxy.mu.Unlock()
+	if third, _, err := proxy.Fetch(ctx, "/latest/USD", ""); err != nil || third.FromCache {
+		t.Fatalf("expected an expired entry to be refetched, got %+v err=%v", third, err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected a second upstream call after expiry, got %d", calls.Load())
+	}
+}
+
+func TestProviderProxy_UpstreamErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	proxy := newTestProxy(server.URL, time.Minute, 60)
+	ctx := context.Background()
+
+	// error responses are passed on but never cached
+	for i := 0; i < 2; i++ {
+		resp, _, err := proxy.Fetch(ctx, "/latest/USD", "")
+		if err != nil || resp.StatusCode != http.StatusBadGateway || resp.FromCache {
+			t.Fatalf("expected the upstream 502 passed on, got %+v err=%v", resp, err)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected each error retried upstream, got %d calls", calls.Load())
+	}
+
+	server.Close()
+	if _, _, err := proxy.Fetch(ctx, "/latest/EUR", ""); err == nil {
+		t.Error("expected an unreachable upstream to fail")
+	}
+}
+
+func TestProviderProxy_RateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	proxy := newTestProxy(server.URL, time.Minute, 1)
+	ctx := context.Background()
+
+	if _, _, err := proxy.Fetch(ctx, "/latest/USD", ""); err != nil {
+		t.Fatalf("expected the first call within budget, got %v", err)
+	}
+	// cache hits don't spend quota
+	if _, _, err := proxy.Fetch(ctx, "/latest/USD", ""); err != nil {
+		t.Fatalf("expected a cache hit without quota, got %v", err)
+	}
+	_, wait, err := proxy.Fetch(ctx, "/latest/EUR", "")
+	if !errors.Is(err, ErrProxyRateLimited) || wait <= 0 {
+		t.Errorf("expected ErrProxyRateLimited with a wait, got %v wait=%v", err, wait)
+	}
+}
+
+func TestProviderProxy_InvalidPath(t *testing.T) {
+	proxy := newTestProxy("http://unused", time.Minute, 60)
+
+	for _, providerPath := range []string{"", "/", "/../.."} {
+		if _, _, err := proxy.Fetch(context.Background(), providerPath, ""); !errors.Is(err, ErrInvalidProxyPath) {
+			t.Errorf("path %q: expected ErrInvalidProxyPath, got %v", providerPath, err)
+		}
+	}
+}
+
+func TestProviderProxy_BoundsCache(t *testing.T) {
+	proxy := newTestProxy("http://unused", time.Hour, 60)
+	start := time.Now().Add(-time.Minute)
+
+	for i := 0; i <= maxProxyEntries; i++ {
+		proxy.store(fmt.Sprintf("/latest/USD?q=%d", i), &ProxyResponse{FetchedAt: start.Add(time.Duration(i) * time.Millisecond)})
+	}
+
+	if len(proxy.entries) != maxProxyEntries {
+		t.Errorf("expected the cache capped at %d entries, got %d", maxProxyEntries, len(proxy.entries))
+	}
+	if _, found := proxy.entries["/latest/USD?q=0"]; found {
+		t.Error("expected the oldest entry to make room")
+	}
+	if _, found := proxy.entries[fmt.Sprintf("/latest/USD?q=%d", maxProxyEntries)]; !found {
+		t.Error("expected the newest entry to be cached")
+	}
+}
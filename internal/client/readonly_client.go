@@ -0,0 +1,24 @@
+package client
+
+import (
+	"context"
+
+	"exchange-rate-service/internal/apperrors"
+)
+
+// ErrReadOnlyReplica is returned for any rate that isn't already in the shared cache
+var ErrReadOnlyReplica = apperrors.ErrReadOnlyReplica
+
+// ReadOnlyClient stands in for RateClient on replica instances - it never
+// talks to the provider, so replicas don't need credentials at all
+type ReadOnlyClient struct{}
+
+// NewReadOnlyClient creates the no-op upstream client
+func NewReadOnlyClient() *ReadOnlyClient {
+	return &ReadOnlyClient{}
+}
+
+// GetRate always fails - replicas only serve what a writer instance cached
+func (c *ReadOnlyClient) GetRate(ctx context.Context, from, to, date string) (float64, error) {
+	return 0, ErrReadOnlyReplica
+}
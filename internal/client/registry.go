@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ProviderFactory builds a provider from the loaded config
+type ProviderFactory func() (RateProvider, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]ProviderFactory)
+)
+
+// RegisterProvider makes a provider selectable by name in RATE_PROVIDERS.
+// Providers register themselves from an init func in their own file, so
+// adding one doesn't touch the chain or main. Registering the same name twice
+// is a programming error and panics
+func RegisterProvider(name string, factory ProviderFactory) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || factory == nil {
+		panic("client: RegisterProvider needs a name and a factory")
+	}
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, found := factories[name]; found {
+		panic("client: provider registered twice: " + name)
+	}
+	factories[name] = factory
+}
+
+// RegisteredProviders returns the names RATE_PROVIDERS accepts, sorted
+func RegisteredProviders() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProviderByName builds a registered provider from its config name
+func NewProviderByName(name string) (RateProvider, error) {
+	factoriesMu.RLock()
+	factory, found := factories[strings.ToLower(strings.TrimSpace(name))]
+	factoriesMu.RUnlock()
+
+	if !found {
+		return nil, fmt.Errorf("unknown rate provider: %s (available: %s)", name, strings.Join(RegisteredProviders(), ", "))
+	}
+	return factory()
+}
+
+// ratesPerTarget is GetRates for providers whose upstream quotes one pair per call
+func ratesPerTarget(ctx context.Context, provider Provider, base string, targets []string, date string) (map[string]float64, error) {
+	rates := make(map[string]float64, len(targets))
+	for _, target := range targets {
+		target = strings.ToUpper(target)
+		if _, done := rates[target]; done {
+			continue
+		}
+		rate, err := provider.GetRate(ctx, base, target, date)
+		if err != nil {
+			return nil, fmt.Errorf("%s-%s: %w", strings.ToUpper(base), target, err)
+		}
+		rates[target] = rate
+	}
+	return rates, nil
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// staticProvider is a complete RateProvider answering every pair with one rate
+type staticProvider struct {
+	fakeProvider
+	historical bool
+}
+
+func (p *staticProvider) SupportsHistorical() bool { return p.historical }
+
+func (p *staticProvider) GetRates(ctx context.Context, base string, targets []string, date string) (map[string]float64, error) {
+	return ratesPerTarget(ctx, p, base, targets, date)
+}
+
+func (p *staticProvider) SupportedCurrencies(ctx context.Context) (map[string]string, error) {
+	return map[string]string{"USD": "", "EUR": ""}, nil
+}
+
+func TestRegisterProvider(t *testing.T) {
+	for _, name := range []string{"exchangerate-api", "frankfurter", "ecb", "coingecko", "mock"} {
+		if _, err := NewProviderByName(" " + strings.ToUpper(name)); err != nil {
+			t.Errorf("built-in provider %s not registered: %v", name, err)
+		}
+	}
+
+	RegisterProvider("Static-Test", func() (RateProvider, error) {
+		return &staticProvider{fakeProvider: fakeProvider{name: "static-test", rate: 2}}, nil
+	})
+	provider, err := NewProviderByName("static-test")
+	if err != nil {
+		t.Fatalf("registered provider not found: %v", err)
+	}
+	if rate, err := provider.GetRate(context.Background(), "USD", "EUR", ""); err != nil || rate != 2 {
+		t.Errorf("expected rate 2 from the registered provider, got %f (%v)", rate, err)
+	}
+
+	_, err = NewProviderByName("nope")
+	if err == nil || !strings.Contains(err.Error(), "available: coingecko, ecb, exchangerate-api, frankfurter, mock, static-test") {
+		t.Errorf("expected the unknown-provider error to list registered names, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice should panic")
+		}
+	}()
+	RegisterProvider("static-test", func() (RateProvider, error) { return nil, nil })
+}
+
+func TestProviderChain_SkipsProvidersWithoutHistory(t *testing.T) {
+	latestOnly := &staticProvider{fakeProvider: fakeProvider{name: "latest-only", rate: 1.1}}
+	historical := &staticProvider{fakeProvider: fakeProvider{name: "historical", rate: 1.2}, historical: true}
+	chain := NewProviderChain(latestOnly, historical)
+
+	if _, source, err := chain.GetRateWithSource(context.Background(), "USD", "EUR", "2024-01-15"); err != nil || source != "historical" {
+		t.Errorf("expected the dated request to go to the historical provider, got %q (%v)", source, err)
+	}
+	if _, source, _ := chain.GetRateWithSource(context.Background(), "USD", "EUR", ""); source != "latest-only" {
+		t.Errorf("expected latest rates from the first provider, got %q", source)
+	}
+	if latestOnly.calls != 1 {
+		t.Errorf("expected the latest-only provider to be called once, got %d", latestOnly.calls)
+	}
+
+	_, err := NewProviderChain(latestOnly).GetRate(context.Background(), "USD", "EUR", "2024-01-15")
+	if !errors.Is(err, ErrHistoricalUnsupported) {
+		t.Errorf("expected ErrHistoricalUnsupported, got %v", err)
+	}
+}
+
+func TestFrankfurterProvider_GetRatesInOneCall(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if got := r.URL.Query().Get("to"); calls == 1 && got != "EUR,INR" {
+			t.Errorf("expected to=EUR,INR, got %q", got)
+		}
+		w.Write([]byte(`{"amount":1,"base":"USD","date":"2024-01-15","rates":{"EUR":0.91,"INR":83.1}}`))
+	}))
+	defer server.Close()
+
+	provider := &FrankfurterProvider{client: NewHTTPClient(server.URL, time.Second)}
+	rates, err := provider.GetRates(context.Background(), "usd", []string{"eur", "INR"}, "")
+	if err != nil {
+		t.Fatalf("GetRates failed: %v", err)
+	}
+	if calls != 1 || rates["EUR"] != 0.91 || rates["INR"] != 83.1 {
+		t.Errorf("expected both rates from one call, got %v after %d calls", rates, calls)
+	}
+
+	if _, err := provider.GetRates(context.Background(), "USD", []string{"EUR", "XYZ"}, ""); err == nil {
+		t.Error("expected an error when a target is missing from the response")
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"exchange-rate-service/config"
+)
+
+// RetryPolicy decides how often and how long to wait before a failed upstream
+// call is tried again. Delays double from BaseDelay up to MaxDelay, and Jitter
+// (a fraction, 0.2 = ±20%) spreads them so clients don't retry in lockstep
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      float64
+}
+
+// DefaultRetryPolicy builds the policy from the PROVIDER_RETRY_* settings
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: config.ProviderRetryAttempts,
+		BaseDelay:   config.ProviderRetryBaseDelay,
+		MaxDelay:    config.ProviderRetryMaxDelay,
+		Jitter:      config.ProviderRetryJitter,
+	}
+}
+
+// attempts is MaxAttempts, but always at least the one call
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff is the delay after the given failed attempt (1-based), jittered
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 && delay > 0 {
+		spread := float64(delay) * p.Jitter
+		delay += time.Duration(spread * (2*rand.Float64() - 1))
+	}
+	return delay
+}
+
+// delay is how long to wait before the next attempt after err, and false when
+// waiting isn't worth it: the provider asked for longer than MaxDelay, or the
+// caller's deadline would pass before the wait is over
+func (p RetryPolicy) delay(ctx context.Context, attempt int, err error) (time.Duration, bool) {
+	delay := p.backoff(attempt)
+	if after := retryAfterOf(err); after > 0 {
+		if p.MaxDelay > 0 && after > p.MaxDelay {
+			return 0, false
+		}
+		if after > delay {
+			delay = after
+		}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return 0, false
+	}
+	return delay, true
+}
+
+// retryAfterError carries the wait a 429 or 503 asked for in Retry-After
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// withRetryAfter attaches the response's Retry-After hint to err, if it has one
+func withRetryAfter(err error, resp *http.Response) error {
+	after := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if after <= 0 {
+		return err
+	}
+	return &retryAfterError{err: err, after: after}
+}
+
+// retryAfterOf returns the Retry-After hint carried by err, or 0
+func retryAfterOf(err error) time.Duration {
+	var hinted *retryAfterError
+	if errors.As(err, &hinted) {
+		return hinted.after
+	}
+	return 0
+}
+
+// parseRetryAfter reads a Retry-After value - delay seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
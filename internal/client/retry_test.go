@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_BackoffDoublesUpToMax(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, ms := range want {
+		if got := policy.backoff(i + 1); got != ms*time.Millisecond {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, ms*time.Millisecond, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(2); got < 100*time.Millisecond || got > 300*time.Millisecond {
+			t.Fatalf("jittered delay %v outside 200ms ±50%%", got)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := map[string]time.Duration{
+		"":                              0,
+		"7":                             7 * time.Second,
+		"-3":                            0,
+		"soon":                          0,
+		"Fri, 01 Aug 2025 12:00:30 GMT": 30 * time.Second,
+		"Fri, 01 Aug 2025 11:59:00 GMT": 0, // already passed
+	}
+	for value, want := range cases {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Errorf("%q: expected %v, got %v", value, want, got)
+		}
+	}
+}
+
+func TestRateClient_RetriesOutagesHonoringRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"result":"success","conversion_rate":0.9}`))
+	}))
+	defer server.Close()
+
+	client := newTestRateClient(server.URL, false)
+	client.retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}
+
+	start := time.Now()
+	rate, err := client.GetRate(context.Background(), "USD", "EUR", "")
+	if err != nil || rate != 0.9 {
+		t.Fatalf("expected the retry to succeed, got %v, %v", rate, err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected to wait out Retry-After, retried after %v", elapsed)
+	}
+}
+
+func TestRateClient_GivesUpOnLongRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := newTestRateClient(server.URL, false)
+	client.retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+
+	if _, err := client.GetRate(context.Background(), "USD", "EUR", ""); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("waiting an hour isn't worth it - expected 1 call, got %d", got)
+	}
+}
+
+func TestRateClient_DoesNotRetryRejectedRequests(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := newTestRateClient(server.URL, false)
+	client.retry = RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}
+
+	if _, err := client.GetRate(context.Background(), "USD", "EUR", ""); err == nil {
+		t.Fatal("expected an error for a 400")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("a 400 won't change on retry - expected 1 call, got %d", got)
+	}
+}
+
+func TestRateClient_NoRetryPastCallerDeadline(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := newTestRateClient(server.URL, false)
+	client.retry = RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := client.GetRate(ctx, "USD", "EUR", ""); err == nil {
+		t.Fatal("expected an error from the failing upstream")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("the backoff outlasts the deadline - expected 1 call, got %d", got)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("should give up without sleeping, took %v", elapsed)
+	}
+}
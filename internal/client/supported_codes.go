@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// codesResp from exchangerate-api's /codes endpoint - pairs of [code, name]
+type codesResp struct {
+	Result         string     `json:"result"`
+	ErrorType      string     `json:"error-type"`
+	SupportedCodes [][]string `json:"supported_codes"`
+}
+
+// SupportedCurrencies lists every currency exchangerate-api can quote (code -> name)
+func (c *RateClient) SupportedCurrencies(ctx context.Context) (map[string]string, error) {
+	key, err := c.keys.Acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ep := range c.endpoints.ordered() {
+		body, err := getBody(ctx, ep.client, "/"+key+"/codes")
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		var response codesResp
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("json parse failed: %w", err)
+		}
+		if response.Result != "success" {
+			return nil, providerError(response.ErrorType)
+		}
+
+		codes := make(map[string]string, len(response.SupportedCodes))
+		for _, pair := range response.SupportedCodes {
+			if len(pair) == 2 {
+				codes[strings.ToUpper(pair[0])] = pair[1]
+			}
+		}
+		return codes, nil
+	}
+
+	return nil, lastErr
+}
+
+// quotaResp from exchangerate-api's /quota endpoint
+type quotaResp struct {
+	Result            string `json:"result"`
+	ErrorType         string `json:"error-type"`
+	RequestsRemaining int    `json:"requests_remaining"`
+}
+
+// Ping asks exchangerate-api for the key's quota - a request that doesn't
+// count against it, but still fails for a dead key or an unreachable host.
+// Succeeds when any endpoint answers
+func (c *RateClient) Ping(ctx context.Context) error {
+	key, err := c.keys.Acquire()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, ep := range c.endpoints.ordered() {
+		resp, err := ep.client.Get(ctx, "/"+key+"/quota")
+		if err != nil {
+			lastErr = fmt.Errorf("http req failed: %w", err)
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("read body failed: %w", err)
+			continue
+		}
+
+		// a bad key is answered with an error body, whatever the status
+		var response quotaResp
+		if json.Unmarshal(body, &response) == nil && response.Result != "" {
+			if response.Result != "success" {
+				return providerError(response.ErrorType)
+			}
+			return nil
+		}
+		lastErr = fmt.Errorf("api http %d: %s", resp.StatusCode, string(body))
+	}
+	return lastErr
+}
+
+// SupportedCurrencies lists the currencies Frankfurter (ECB) publishes (code -> name)
+func (p *FrankfurterProvider) SupportedCurrencies(ctx context.Context) (map[string]string, error) {
+	body, err := getBody(ctx, p.client, "/currencies")
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make(map[string]string)
+	if err := json.Unmarshal(body, &codes); err != nil {
+		return nil, fmt.Errorf("json parse failed: %w", err)
+	}
+	return codes, nil
+}
+
+// SupportedCodes returns the list from the first fiat provider that can give
+// one - it feeds the currency registry, which takes crypto and metals from
+// CRYPTO_ASSETS instead
+func (c *ProviderChain) SupportedCodes(ctx context.Context) (map[string]string, error) {
+	failures := make([]string, 0, len(c.providers))
+
+	for _, provider := range c.providers {
+		source, ok := provider.(interface {
+			SupportedCurrencies(ctx context.Context) (map[string]string, error)
+		})
+		if !ok || isAssetProvider(provider) || c.inCooldown(provider.Name()) {
+			continue
+		}
+
+		codes, err := source.SupportedCurrencies(ctx)
+		if err == nil && len(codes) > 0 {
+			return codes, nil
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", provider.Name(), err))
+		}
+	}
+
+	return nil, fmt.Errorf("no provider could list supported codes (%s)", strings.Join(failures, "; "))
+}
+
+// getBody does a GET and returns the body of a 200 response
+func getBody(ctx context.Context, client *HTTPClient, endpoint string) ([]byte, error) {
+	resp, err := client.Get(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("http req failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("api http %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
@@ -0,0 +1,44 @@
+package currency
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// plain decimal amounts only - no exponents, no thousands separators, no NaN/Inf
+var amountPattern = regexp.MustCompile(`^-?([0-9]+)(\.[0-9]+)?$`)
+
+// MaxAmountDigits bounds the integer part - enough for any real amount, and
+// keeps a query string of nines from turning into an enormous decimal
+const MaxAmountDigits = 18
+
+// ParseAmount parses an amount given as a string ("100", "2500.75"). It is
+// strict where a float parser is lenient: "1e308", "NaN", "Inf", "0x10",
+// "1,000" and ".5" are all refused. Amounts of code may have at most
+// maxDecimals significant decimal places ("10.50" counts as one); a negative
+// maxDecimals skips that check. Negative amounts parse - whether they are
+// allowed is the caller's call. Errors read as the end of "amount ..."
+func ParseAmount(raw, code string, maxDecimals int32) (decimal.Decimal, error) {
+	raw = strings.TrimSpace(raw)
+	match := amountPattern.FindStringSubmatch(raw)
+	if match == nil {
+		return decimal.Decimal{}, fmt.Errorf("must be a decimal number like \"100.25\"")
+	}
+	if len(strings.TrimLeft(match[1], "0")) > MaxAmountDigits {
+		return decimal.Decimal{}, fmt.Errorf("must have at most %d digits before the decimal point", MaxAmountDigits)
+	}
+
+	fraction := strings.TrimRight(strings.TrimPrefix(match[2], "."), "0")
+	if maxDecimals >= 0 && int32(len(fraction)) > maxDecimals {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if maxDecimals == 0 {
+			return decimal.Decimal{}, fmt.Errorf("must be a whole number for %s", code)
+		}
+		return decimal.Decimal{}, fmt.Errorf("must have at most %d decimal places for %s", maxDecimals, code)
+	}
+
+	return decimal.NewFromString(raw)
+}
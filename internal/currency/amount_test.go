@@ -0,0 +1,52 @@
+package currency
+
+import "testing"
+
+func TestParseAmount(t *testing.T) {
+	valid := []struct {
+		raw         string
+		code        string
+		maxDecimals int32
+		want        string
+	}{
+		{"100", "USD", 2, "100"},
+		{" 2500.75 ", "USD", 2, "2500.75"},
+		{"10.500", "USD", 2, "10.5"}, // trailing zeros aren't precision
+		{"-3.25", "USD", 2, "-3.25"},
+		{"1000", "JPY", 0, "1000"},
+		{"0.00000001", "BTC", 8, "0.00000001"},
+		{"12345.6789", "JPY", -1, "12345.6789"},
+		{"000000000000000000001", "USD", 2, "1"},
+	}
+	for _, tt := range valid {
+		amount, err := ParseAmount(tt.raw, tt.code, tt.maxDecimals)
+		if err != nil || amount.String() != tt.want {
+			t.Errorf("%q: expected %s, got %s (%v)", tt.raw, tt.want, amount, err)
+		}
+	}
+
+	invalid := map[string]struct {
+		raw         string
+		code        string
+		maxDecimals int32
+		want        string
+	}{
+		"exponent":         {"1e308", "USD", 2, `must be a decimal number like "100.25"`},
+		"NaN":              {"NaN", "USD", 2, `must be a decimal number like "100.25"`},
+		"infinity":         {"Inf", "USD", 2, `must be a decimal number like "100.25"`},
+		"hex":              {"0x10", "USD", 2, `must be a decimal number like "100.25"`},
+		"separator":        {"1,000", "USD", 2, `must be a decimal number like "100.25"`},
+		"leading dot":      {".5", "USD", 2, `must be a decimal number like "100.25"`},
+		"plus sign":        {"+5", "USD", 2, `must be a decimal number like "100.25"`},
+		"empty":            {"", "USD", 2, `must be a decimal number like "100.25"`},
+		"too many digits":  {"1234567890123456789", "USD", 2, "must have at most 18 digits before the decimal point"},
+		"too precise":      {"10.125", "usd", 2, "must have at most 2 decimal places for USD"},
+		"fraction of yen":  {"100.5", "JPY", 0, "must be a whole number for JPY"},
+		"too precise coin": {"0.000000001", "BTC", 8, "must have at most 8 decimal places for BTC"},
+	}
+	for name, tt := range invalid {
+		if _, err := ParseAmount(tt.raw, tt.code, tt.maxDecimals); err == nil || err.Error() != tt.want {
+			t.Errorf("%s: expected %q, got %v", name, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package currency
+
+// AssetClass tells fiat currencies apart from crypto and precious metals -
+// they come from different providers and move at very different speeds
+type AssetClass string
+
+const (
+	AssetFiat   AssetClass = "fiat"
+	AssetCrypto AssetClass = "crypto"
+	AssetMetal  AssetClass = "metal"
+)
+
+// assetMetadata lists the non-fiat assets we know how to quote. Anything not
+// in here is treated as fiat. Metals are priced per troy ounce.
+var assetMetadata = map[string]Currency{
+	"BTC":  {Code: "BTC", Name: "Bitcoin", Symbol: "₿", Class: AssetCrypto},
+	"ETH":  {Code: "ETH", Name: "Ether", Symbol: "Ξ", Class: AssetCrypto},
+	"SOL":  {Code: "SOL", Name: "Solana", Symbol: "◎", Class: AssetCrypto},
+	"XRP":  {Code: "XRP", Name: "XRP", Class: AssetCrypto},
+	"LTC":  {Code: "LTC", Name: "Litecoin", Symbol: "Ł", Class: AssetCrypto},
+	"ADA":  {Code: "ADA", Name: "Cardano", Class: AssetCrypto},
+	"DOGE": {Code: "DOGE", Name: "Dogecoin", Symbol: "Ð", Class: AssetCrypto},
+	"USDT": {Code: "USDT", Name: "Tether", Class: AssetCrypto},
+	"USDC": {Code: "USDC", Name: "USD Coin", Class: AssetCrypto},
+	"XAU":  {Code: "XAU", Name: "Gold (troy ounce)", Class: AssetMetal},
+	"XAG":  {Code: "XAG", Name: "Silver (troy ounce)", Class: AssetMetal},
+}
+
+// ClassOf returns the asset class of code, fiat unless it is a known crypto or metal
+func ClassOf(code string) AssetClass {
+	if asset, found := assetMetadata[normalize(code)]; found {
+		return asset.Class
+	}
+	return AssetFiat
+}
+
+// IsAssetPair reports whether either side of a pair is crypto or a metal
+func IsAssetPair(from, to string) bool {
+	return ClassOf(from) != AssetFiat || ClassOf(to) != AssetFiat
+}
+
+// ValidCode reports whether code looks like something we could quote: a three
+// letter ISO 4217 code or one of the known crypto tickers, which can be longer
+func ValidCode(code string) bool {
+	cleanCode := normalize(code)
+	if _, found := assetMetadata[cleanCode]; found {
+		return true
+	}
+	if len(cleanCode) != 3 {
+		return false
+	}
+	for _, c := range cleanCode {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
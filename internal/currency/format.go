@@ -0,0 +1,143 @@
+package currency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// localeFormat is how one locale writes money amounts
+type localeFormat struct {
+	group   string // thousands separator
+	decimal string // decimal separator
+	// symbol after the number ("1.234,56 €") rather than before ("$1,234.56")
+	symbolAfter bool
+	// space between symbol and number
+	spaced bool
+	// Indian grouping: the last three digits, then pairs (12,34,567.00)
+	indian bool
+}
+
+// locales we can format for - a region-specific entry wins over its language
+var locales = map[string]localeFormat{
+	"en":    {group: ",", decimal: "."},
+	"en-in": {group: ",", decimal: ".", indian: true},
+	"hi":    {group: ",", decimal: ".", indian: true},
+	"de":    {group: ".", decimal: ",", symbolAfter: true, spaced: true},
+	"de-ch": {group: "\u2019", decimal: ".", spaced: true},
+	"fr":    {group: "\u202f", decimal: ",", symbolAfter: true, spaced: true},
+	"es":    {group: ".", decimal: ",", symbolAfter: true, spaced: true},
+	"it":    {group: ".", decimal: ",", symbolAfter: true, spaced: true},
+	"nl":    {group: ".", decimal: ",", spaced: true},
+	"pt":    {group: ".", decimal: ",", spaced: true},
+	"pl":    {group: "\u00a0", decimal: ",", symbolAfter: true, spaced: true},
+	"sv":    {group: "\u00a0", decimal: ",", symbolAfter: true, spaced: true},
+	"ja":    {group: ",", decimal: "."},
+	"zh":    {group: ",", decimal: "."},
+	"ko":    {group: ",", decimal: "."},
+}
+
+// DefaultLocale is used when no locale is asked for
+const DefaultLocale = "en-US"
+
+// Locales lists the locale tags Format understands, language or language-region
+func Locales() []string {
+	tags := make([]string, 0, len(locales))
+	for tag := range locales {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// SymbolOf returns the display symbol of code, empty when we don't know one
+func SymbolOf(code string) string {
+	cleanCode := normalize(code)
+	if asset, found := assetMetadata[cleanCode]; found {
+		return asset.Symbol
+	}
+	return builtinMetadata[cleanCode].Symbol
+}
+
+// Format rounds amount to minorUnits decimal places (half away from zero, like
+// conversions) and writes it the way locale ("de-DE", "en_IN", "fr") writes
+// money, with the currency's symbol, or its code when it has none.
+// Unknown locales are an error; a known language with an unknown region uses
+// the language's conventions
+func Format(amount decimal.Decimal, code string, minorUnits int32, locale string) (string, error) {
+	format, found := lookupLocale(locale)
+	if !found {
+		return "", fmt.Errorf("unsupported locale %q, expected one of %s", locale, strings.Join(Locales(), ", "))
+	}
+
+	rounded := amount.Round(minorUnits)
+	digits := rounded.Abs().StringFixed(minorUnits)
+	whole, fraction, _ := strings.Cut(digits, ".")
+
+	number := groupDigits(whole, format)
+	if fraction != "" {
+		number += format.decimal + fraction
+	}
+
+	symbol := SymbolOf(code)
+	spaced := format.spaced
+	if symbol == "" {
+		// a bare code always needs the space: "XAU 10.00" not "XAU10.00"
+		symbol = normalize(code)
+		spaced = true
+	}
+	separator := ""
+	if spaced {
+		// non-breaking, so the symbol never wraps away from the number
+		separator = "\u00a0"
+	}
+
+	var formatted string
+	if format.symbolAfter {
+		formatted = number + separator + symbol
+	} else {
+		formatted = symbol + separator + number
+	}
+	if rounded.IsNegative() {
+		formatted = "-" + formatted
+	}
+	return formatted, nil
+}
+
+// lookupLocale finds the conventions for a locale tag, falling back from
+// language-region to language
+func lookupLocale(locale string) (localeFormat, bool) {
+	tag := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if tag == "" {
+		tag = strings.ToLower(DefaultLocale)
+	}
+	if format, found := locales[tag]; found {
+		return format, true
+	}
+	language, _, _ := strings.Cut(tag, "-")
+	format, found := locales[language]
+	return format, found
+}
+
+// groupDigits inserts the locale's thousands separator into a run of digits
+func groupDigits(whole string, format localeFormat) string {
+	if len(whole) <= 3 {
+		return whole
+	}
+
+	head, tail := whole[:len(whole)-3], whole[len(whole)-3:]
+	size := 3
+	if format.indian {
+		size = 2
+	}
+
+	groups := []string{tail}
+	for len(head) > size {
+		groups = append([]string{head[len(head)-size:]}, groups...)
+		head = head[:len(head)-size]
+	}
+	groups = append([]string{head}, groups...)
+	return strings.Join(groups, format.group)
+}
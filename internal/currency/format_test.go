@@ -0,0 +1,42 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		amount     string
+		code       string
+		minorUnits int32
+		locale     string
+		want       string
+	}{
+		{"12345.678", "JPY", 0, "en-US", "¥12,346"},
+		{"12345.678", "JPY", 0, "de-DE", "12.346\u00a0¥"},
+		{"1234567.5", "EUR", 2, "de", "1.234.567,50\u00a0€"},
+		{"1234567.5", "EUR", 2, "fr_FR", "1\u202f234\u202f567,50\u00a0€"},
+		{"1234567.891", "INR", 2, "en-IN", "₹12,34,567.89"},
+		{"1234.5", "CHF", 2, "de-CH", "CHF\u00a01\u2019234.50"},
+		{"-1234.5", "USD", 2, "", "-$1,234.50"},
+		{"999.995", "USD", 2, "en-GB", "$1,000.00"},
+		{"1.23456", "XAG", 4, "en", "XAG\u00a01.2346"}, // no symbol, the code stands in
+		{"100", "XYZ", 2, "de", "100,00\u00a0XYZ"},
+	}
+	for _, c := range cases {
+		got, err := Format(decimal.RequireFromString(c.amount), c.code, c.minorUnits, c.locale)
+		if err != nil {
+			t.Errorf("%s %s %s: unexpected error %v", c.amount, c.code, c.locale, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s %s %s: expected %q, got %q", c.amount, c.code, c.locale, c.want, got)
+		}
+	}
+
+	if _, err := Format(decimal.NewFromInt(1), "USD", 2, "xx-YY"); err == nil {
+		t.Error("expected an error for an unknown locale")
+	}
+}
@@ -0,0 +1,59 @@
+package currency
+
+// builtinMetadata covers the currencies people actually ask for. Providers only
+// give us names, so symbols come from here; names here are the fallback when
+// the provider list can't be fetched.
+var builtinMetadata = map[string]Currency{
+	"AED": {Code: "AED", Name: "UAE Dirham", Symbol: "د.إ"},
+	"ARS": {Code: "ARS", Name: "Argentine Peso", Symbol: "$"},
+	"AUD": {Code: "AUD", Name: "Australian Dollar", Symbol: "A$"},
+	"BDT": {Code: "BDT", Name: "Bangladeshi Taka", Symbol: "৳"},
+	"BGN": {Code: "BGN", Name: "Bulgarian Lev", Symbol: "лв"},
+	"BHD": {Code: "BHD", Name: "Bahraini Dinar", Symbol: ".د.ب"},
+	"BRL": {Code: "BRL", Name: "Brazilian Real", Symbol: "R$"},
+	"CAD": {Code: "CAD", Name: "Canadian Dollar", Symbol: "C$"},
+	"CHF": {Code: "CHF", Name: "Swiss Franc", Symbol: "CHF"},
+	"CLP": {Code: "CLP", Name: "Chilean Peso", Symbol: "$"},
+	"CNY": {Code: "CNY", Name: "Chinese Renminbi", Symbol: "¥"},
+	"COP": {Code: "COP", Name: "Colombian Peso", Symbol: "$"},
+	"CZK": {Code: "CZK", Name: "Czech Koruna", Symbol: "Kč"},
+	"DKK": {Code: "DKK", Name: "Danish Krone", Symbol: "kr"},
+	"EGP": {Code: "EGP", Name: "Egyptian Pound", Symbol: "E£"},
+	"EUR": {Code: "EUR", Name: "Euro", Symbol: "€"},
+	"GBP": {Code: "GBP", Name: "Pound Sterling", Symbol: "£"},
+	"HKD": {Code: "HKD", Name: "Hong Kong Dollar", Symbol: "HK$"},
+	"HUF": {Code: "HUF", Name: "Hungarian Forint", Symbol: "Ft"},
+	"IDR": {Code: "IDR", Name: "Indonesian Rupiah", Symbol: "Rp"},
+	"ILS": {Code: "ILS", Name: "Israeli New Shekel", Symbol: "₪"},
+	"INR": {Code: "INR", Name: "Indian Rupee", Symbol: "₹"},
+	"ISK": {Code: "ISK", Name: "Icelandic Króna", Symbol: "kr"},
+	"JOD": {Code: "JOD", Name: "Jordanian Dinar", Symbol: "د.ا"},
+	"JPY": {Code: "JPY", Name: "Japanese Yen", Symbol: "¥"},
+	"KES": {Code: "KES", Name: "Kenyan Shilling", Symbol: "KSh"},
+	"KRW": {Code: "KRW", Name: "South Korean Won", Symbol: "₩"},
+	"KWD": {Code: "KWD", Name: "Kuwaiti Dinar", Symbol: "د.ك"},
+	"LKR": {Code: "LKR", Name: "Sri Lanka Rupee", Symbol: "Rs"},
+	"MXN": {Code: "MXN", Name: "Mexican Peso", Symbol: "$"},
+	"MYR": {Code: "MYR", Name: "Malaysian Ringgit", Symbol: "RM"},
+	"NGN": {Code: "NGN", Name: "Nigerian Naira", Symbol: "₦"},
+	"NOK": {Code: "NOK", Name: "Norwegian Krone", Symbol: "kr"},
+	"NPR": {Code: "NPR", Name: "Nepalese Rupee", Symbol: "Rs"},
+	"NZD": {Code: "NZD", Name: "New Zealand Dollar", Symbol: "NZ$"},
+	"OMR": {Code: "OMR", Name: "Omani Rial", Symbol: "ر.ع."},
+	"PHP": {Code: "PHP", Name: "Philippine Peso", Symbol: "₱"},
+	"PKR": {Code: "PKR", Name: "Pakistani Rupee", Symbol: "Rs"},
+	"PLN": {Code: "PLN", Name: "Polish Złoty", Symbol: "zł"},
+	"QAR": {Code: "QAR", Name: "Qatari Riyal", Symbol: "ر.ق"},
+	"RON": {Code: "RON", Name: "Romanian Leu", Symbol: "lei"},
+	"SAR": {Code: "SAR", Name: "Saudi Riyal", Symbol: "ر.س"},
+	"SEK": {Code: "SEK", Name: "Swedish Krona", Symbol: "kr"},
+	"SGD": {Code: "SGD", Name: "Singapore Dollar", Symbol: "S$"},
+	"THB": {Code: "THB", Name: "Thai Baht", Symbol: "฿"},
+	"TND": {Code: "TND", Name: "Tunisian Dinar", Symbol: "د.ت"},
+	"TRY": {Code: "TRY", Name: "Turkish Lira", Symbol: "₺"},
+	"TWD": {Code: "TWD", Name: "New Taiwan Dollar", Symbol: "NT$"},
+	"UAH": {Code: "UAH", Name: "Ukrainian Hryvnia", Symbol: "₴"},
+	"USD": {Code: "USD", Name: "United States Dollar", Symbol: "$"},
+	"VND": {Code: "VND", Name: "Vietnamese Đồng", Symbol: "₫"},
+	"ZAR": {Code: "ZAR", Name: "South African Rand", Symbol: "R"},
+}
@@ -0,0 +1,126 @@
+package currency
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+)
+
+// Override is an operator's runtime change to the supported set - a fiat
+// currency added (with an optional display name) or removed
+type Override struct {
+	Code      string    `json:"code"`
+	Name      string    `json:"name,omitempty"`
+	Removed   bool      `json:"removed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OverrideStore keeps overrides so they survive restarts
+type OverrideStore interface {
+	CurrencyOverrides(ctx context.Context) ([]Override, error)
+	SaveCurrencyOverride(ctx context.Context, override Override) error
+}
+
+// apply makes override take effect in the registry
+func (r *Registry) apply(override Override) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code := normalize(override.Code)
+	if override.Removed {
+		delete(r.added, code)
+		delete(r.currencies, code)
+		r.removed[code] = true
+		return
+	}
+
+	currency := withMetadata(code, override.Name)
+	delete(r.removed, code)
+	r.added[code] = currency
+	r.currencies[code] = currency
+}
+
+// Manager adds and removes supported currencies at runtime. Each change is
+// saved before it takes effect, so the set an instance serves is the one it
+// comes back with after a restart
+type Manager struct {
+	registry *Registry
+	store    OverrideStore
+	base     string
+}
+
+// NewManager manages registry's currencies, keeping changes in store. base
+// (the refresh base currency) can't be removed - every rate is derived from it
+func NewManager(registry *Registry, store OverrideStore, base string) *Manager {
+	return &Manager{
+		registry: registry,
+		store:    store,
+		base:     normalize(base),
+	}
+}
+
+// Load applies the stored overrides, returning how many there were
+func (m *Manager) Load(ctx context.Context) (int, error) {
+	overrides, err := m.store.CurrencyOverrides(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, override := range overrides {
+		m.registry.apply(override)
+	}
+	return len(overrides), nil
+}
+
+// AddCurrency makes the fiat currency code supported and part of every
+// refresh cycle from the next one on. name is only needed for codes without
+// built-in metadata
+func (m *Manager) AddCurrency(ctx context.Context, code, name string) (Currency, error) {
+	code = normalize(code)
+	if err := checkManagedCode(code); err != nil {
+		return Currency{}, err
+	}
+
+	override := Override{Code: code, Name: strings.TrimSpace(name), UpdatedAt: time.Now().UTC()}
+	if err := m.store.SaveCurrencyOverride(ctx, override); err != nil {
+		return Currency{}, apperrors.Wrap(apperrors.CodeInternal, err, "failed to save currency %s", code)
+	}
+	m.registry.apply(override)
+
+	currency, _ := m.registry.Get(code)
+	return currency, nil
+}
+
+// RemoveCurrency stops supporting code, even when the provider lists it.
+// Its cached rates age out on their own
+func (m *Manager) RemoveCurrency(ctx context.Context, code string) error {
+	code = normalize(code)
+	if err := checkManagedCode(code); err != nil {
+		return err
+	}
+	if code == m.base {
+		return apperrors.New(apperrors.CodeConflict, "%s is the refresh base currency and can't be removed", code)
+	}
+	if !m.registry.IsSupported(code) {
+		return apperrors.New(apperrors.CodeNotFound, "currency %s is not supported", code)
+	}
+
+	override := Override{Code: code, Removed: true, UpdatedAt: time.Now().UTC()}
+	if err := m.store.SaveCurrencyOverride(ctx, override); err != nil {
+		return apperrors.Wrap(apperrors.CodeInternal, err, "failed to save currency %s", code)
+	}
+	m.registry.apply(override)
+	return nil
+}
+
+// checkManagedCode allows fiat codes only - crypto and metals come from CRYPTO_ASSETS
+func checkManagedCode(code string) error {
+	if !ValidCode(code) {
+		return apperrors.New(apperrors.CodeInvalidRequest, "invalid currency code %q, expected 3 letters like USD", code)
+	}
+	if ClassOf(code) != AssetFiat {
+		return apperrors.New(apperrors.CodeInvalidRequest, "%s is a %s asset, set by CRYPTO_ASSETS", code, ClassOf(code))
+	}
+	return nil
+}
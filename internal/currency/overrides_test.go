@@ -0,0 +1,117 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"exchange-rate-service/internal/apperrors"
+)
+
+// fakeOverrideStore keeps overrides in memory
+type fakeOverrideStore struct {
+	overrides []Override
+	err       error
+}
+
+func (f *fakeOverrideStore) CurrencyOverrides(ctx context.Context) ([]Override, error) {
+	return f.overrides, f.err
+}
+
+func (f *fakeOverrideStore) SaveCurrencyOverride(ctx context.Context, override Override) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.overrides = append(f.overrides, override)
+	return nil
+}
+
+func TestManager_AddAndRemove(t *testing.T) {
+	registry := NewRegistry([]string{"USD", "EUR", "GBP"})
+	registry.AddAssets([]string{"BTC"})
+	store := &fakeOverrideStore{}
+	manager := NewManager(registry, store, "USD")
+
+	added, err := manager.AddCurrency(context.Background(), " xts", "Testing code")
+	if err != nil {
+		t.Fatalf("AddCurrency failed: %v", err)
+	}
+	if added.Code != "XTS" || added.Name != "Testing code" || !registry.IsSupported("XTS") {
+		t.Errorf("expected XTS to be supported, got %+v", added)
+	}
+	if err := manager.RemoveCurrency(context.Background(), "GBP"); err != nil {
+		t.Fatalf("RemoveCurrency failed: %v", err)
+	}
+	if registry.IsSupported("GBP") {
+		t.Error("GBP should no longer be supported")
+	}
+
+	// refresh cycles follow the changes, crypto keeps its own loop
+	if codes := registry.CoreCodes(); !reflect.DeepEqual(codes, []string{"USD", "EUR", "XTS"}) {
+		t.Errorf("unexpected core codes %v", codes)
+	}
+
+	// a provider list neither drops the added code nor brings back the removed one
+	registry.Replace(map[string]string{"USD": "US Dollar", "GBP": "Pound Sterling", "CHF": "Swiss Franc"})
+	if !registry.IsSupported("XTS") || registry.IsSupported("GBP") || !registry.IsSupported("CHF") {
+		t.Errorf("overrides should survive a refresh, got %v", registry.Codes())
+	}
+
+	if len(store.overrides) != 2 {
+		t.Errorf("expected both changes saved, got %+v", store.overrides)
+	}
+}
+
+func TestManager_RejectsInvalidChanges(t *testing.T) {
+	registry := NewRegistry([]string{"USD", "EUR"})
+	manager := NewManager(registry, &fakeOverrideStore{}, "USD")
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		err  error
+		code apperrors.Code
+	}{
+		{"bad code", func() error { _, err := manager.AddCurrency(ctx, "EURO", ""); return err }(), apperrors.CodeInvalidRequest},
+		{"asset", func() error { _, err := manager.AddCurrency(ctx, "BTC", ""); return err }(), apperrors.CodeInvalidRequest},
+		{"base currency", manager.RemoveCurrency(ctx, "usd"), apperrors.CodeConflict},
+		{"not supported", manager.RemoveCurrency(ctx, "CHF"), apperrors.CodeNotFound},
+	}
+	for _, tt := range tests {
+		if code := apperrors.CodeOf(tt.err); code != tt.code {
+			t.Errorf("%s: expected %s, got %v", tt.name, tt.code, tt.err)
+		}
+	}
+}
+
+func TestManager_StoreFailureChangesNothing(t *testing.T) {
+	registry := NewRegistry([]string{"USD", "EUR"})
+	manager := NewManager(registry, &fakeOverrideStore{err: errors.New("database is locked")}, "USD")
+
+	if _, err := manager.AddCurrency(context.Background(), "XTS", ""); apperrors.CodeOf(err) != apperrors.CodeInternal {
+		t.Errorf("expected an internal error, got %v", err)
+	}
+	if err := manager.RemoveCurrency(context.Background(), "EUR"); err == nil {
+		t.Error("expected the removal to fail")
+	}
+	if registry.IsSupported("XTS") || !registry.IsSupported("EUR") {
+		t.Errorf("an unsaved change must not take effect, got %v", registry.Codes())
+	}
+}
+
+func TestManager_Load(t *testing.T) {
+	registry := NewRegistry([]string{"USD", "EUR"})
+	store := &fakeOverrideStore{overrides: []Override{
+		{Code: "XTS"},
+		{Code: "EUR", Removed: true},
+	}}
+
+	loaded, err := NewManager(registry, store, "USD").Load(context.Background())
+	if err != nil || loaded != 2 {
+		t.Fatalf("expected 2 overrides loaded, got %d (%v)", loaded, err)
+	}
+	if !registry.IsSupported("XTS") || registry.IsSupported("EUR") {
+		t.Errorf("stored overrides should apply on load, got %v", registry.Codes())
+	}
+}
@@ -0,0 +1,264 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// how long one supported-codes fetch may take
+const fetchTimeout = 15 * time.Second
+
+// Currency is one supported currency with display metadata
+type Currency struct {
+	Code   string     `json:"code"`
+	Name   string     `json:"name,omitempty"`
+	Symbol string     `json:"symbol,omitempty"`
+	Class  AssetClass `json:"asset_class"`
+}
+
+// CodeSource lists the currencies a provider can quote, code -> name
+type CodeSource interface {
+	SupportedCodes(ctx context.Context) (map[string]string, error)
+}
+
+// Registry is the thread-safe set of currencies we accept. It starts from a
+// core list and is replaced wholesale by whatever the provider supports, so a
+// failed refresh never leaves us with fewer currencies than before. Operator
+// overrides (see overrides.go) apply on top of both
+type Registry struct {
+	mu         sync.RWMutex
+	currencies map[string]Currency
+	core       []string
+	added      map[string]Currency
+	removed    map[string]bool
+
+	shutdownChannel   chan struct{}
+	backgroundWorkers sync.WaitGroup
+}
+
+// NewRegistry creates a registry seeded with the core codes - these stay
+// supported even if the provider list omits them
+func NewRegistry(coreCodes []string) *Registry {
+	registry := &Registry{
+		currencies:      make(map[string]Currency, len(coreCodes)),
+		added:           make(map[string]Currency),
+		removed:         make(map[string]bool),
+		shutdownChannel: make(chan struct{}),
+	}
+
+	for _, code := range coreCodes {
+		cleanCode := normalize(code)
+		if cleanCode == "" {
+			continue
+		}
+		registry.core = append(registry.core, cleanCode)
+		registry.currencies[cleanCode] = withMetadata(cleanCode, "")
+	}
+
+	return registry
+}
+
+// IsSupported reports whether code is a currency we accept
+func (r *Registry) IsSupported(code string) bool {
+	cleanCode := normalize(code)
+	if cleanCode == "" {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, found := r.currencies[cleanCode]
+	return found
+}
+
+// Get returns a currency with its metadata
+func (r *Registry) Get(code string) (Currency, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	currency, found := r.currencies[normalize(code)]
+	return currency, found
+}
+
+// List returns every supported currency sorted by code
+func (r *Registry) List() []Currency {
+	r.mu.RLock()
+	list := make([]Currency, 0, len(r.currencies))
+	for _, currency := range r.currencies {
+		list = append(list, currency)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// Codes returns every supported code sorted
+func (r *Registry) Codes() []string {
+	list := r.List()
+	codes := make([]string, len(list))
+	for i, currency := range list {
+		codes[i] = currency.Code
+	}
+	return codes
+}
+
+// Len returns the number of supported currencies
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.currencies)
+}
+
+// AddAssets makes the given crypto and metal codes supported. Like the core
+// codes they survive Replace, since the fiat provider list never includes them.
+// Unknown codes are skipped. Call before StartRefresh.
+func (r *Registry) AddAssets(codes []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, code := range codes {
+		cleanCode := normalize(code)
+		if ClassOf(cleanCode) == AssetFiat {
+			slog.Warn("Ignoring unknown crypto/metal asset", "code", code)
+			continue
+		}
+		if _, found := r.currencies[cleanCode]; found {
+			continue
+		}
+		r.core = append(r.core, cleanCode)
+		r.currencies[cleanCode] = withMetadata(cleanCode, "")
+	}
+}
+
+// Replace swaps in a provider's code -> name list (plus the core codes)
+// Crypto and metal codes in the list are dropped - only AddAssets enables those.
+// Codes an operator added stay, codes an operator removed stay out
+func (r *Registry) Replace(names map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	currencies := make(map[string]Currency, len(names)+len(r.core)+len(r.added))
+	for code, name := range names {
+		cleanCode := normalize(code)
+		if len(cleanCode) != 3 || ClassOf(cleanCode) != AssetFiat {
+			continue
+		}
+		currencies[cleanCode] = withMetadata(cleanCode, name)
+	}
+	for _, code := range r.core {
+		if _, found := currencies[code]; !found {
+			currencies[code] = withMetadata(code, "")
+		}
+	}
+	for code, currency := range r.added {
+		currencies[code] = currency
+	}
+	for code := range r.removed {
+		delete(currencies, code)
+	}
+
+	r.currencies = currencies
+}
+
+// CoreCodes lists the fiat currencies refresh cycles keep cached: the core
+// codes and those an operator added, less those an operator removed
+func (r *Registry) CoreCodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	codes := make([]string, 0, len(r.core)+len(r.added))
+	seen := make(map[string]bool, len(r.core)+len(r.added))
+	for _, code := range r.core {
+		if ClassOf(code) == AssetFiat && !r.removed[code] && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	added := make([]string, 0, len(r.added))
+	for code := range r.added {
+		if !seen[code] {
+			added = append(added, code)
+		}
+	}
+	sort.Strings(added)
+	return append(codes, added...)
+}
+
+// Refresh fetches the supported codes from source and replaces the registry
+// An empty answer is treated as an error rather than wiping the list
+func (r *Registry) Refresh(ctx context.Context, source CodeSource) error {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	names, err := source.SupportedCodes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return errors.New("provider returned no supported codes")
+	}
+
+	r.Replace(names)
+	return nil
+}
+
+// StartRefresh re-fetches from source every interval - call Refresh first
+// for the startup fetch
+func (r *Registry) StartRefresh(source CodeSource, interval time.Duration) {
+	r.backgroundWorkers.Add(1)
+	go r.refreshLoop(source, interval)
+}
+
+// Stop ends the background refresh and waits for it
+func (r *Registry) Stop() {
+	close(r.shutdownChannel)
+	r.backgroundWorkers.Wait()
+}
+
+// refreshLoop keeps the registry in sync with the provider
+func (r *Registry) refreshLoop(source CodeSource, interval time.Duration) {
+	defer r.backgroundWorkers.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Refresh(context.Background(), source); err != nil {
+				slog.Warn("Currency list refresh failed, keeping known currencies", "currencies", r.Len(), "error", err)
+			} else {
+				slog.Info("Currency list refreshed", "currencies", r.Len())
+			}
+		case <-r.shutdownChannel:
+			return
+		}
+	}
+}
+
+// withMetadata fills in the symbol (and name when the provider gave none)
+func withMetadata(code, name string) Currency {
+	if asset, found := assetMetadata[code]; found {
+		return asset
+	}
+
+	currency := Currency{Code: code, Name: strings.TrimSpace(name), Class: AssetFiat}
+	if builtin, found := builtinMetadata[code]; found {
+		currency.Symbol = builtin.Symbol
+		if currency.Name == "" {
+			currency.Name = builtin.Name
+		}
+	}
+	return currency
+}
+
+func normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
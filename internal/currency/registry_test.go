@@ -0,0 +1,156 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeSource struct {
+	codes map[string]string
+	err   error
+}
+
+func (f *fakeSource) SupportedCodes(ctx context.Context) (map[string]string, error) {
+	return f.codes, f.err
+}
+
+func TestRegistry_CoreCodes(t *testing.T) {
+	registry := NewRegistry([]string{"usd", " EUR "})
+
+	if !registry.IsSupported("USD") || !registry.IsSupported(" eur") {
+		t.Error("core codes should be supported regardless of case/whitespace")
+	}
+	if registry.IsSupported("CHF") || registry.IsSupported("") {
+		t.Error("unknown codes should not be supported")
+	}
+
+	usd, found := registry.Get("USD")
+	if !found || usd.Name != "United States Dollar" || usd.Symbol != "$" {
+		t.Errorf("expected builtin metadata for USD, got %+v", usd)
+	}
+}
+
+func TestRegistry_RefreshReplacesList(t *testing.T) {
+	registry := NewRegistry([]string{"USD", "INR"})
+	source := &fakeSource{codes: map[string]string{"USD": "US Dollar", "CHF": "Swiss Franc", "bogus": "x"}}
+
+	if err := registry.Refresh(context.Background(), source); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	if !registry.IsSupported("CHF") {
+		t.Error("provider currencies should be supported after refresh")
+	}
+	if !registry.IsSupported("INR") {
+		t.Error("core currencies stay supported even when the provider omits them")
+	}
+	if registry.IsSupported("BOGUS") {
+		t.Error("malformed codes should be dropped")
+	}
+
+	chf, _ := registry.Get("CHF")
+	if chf.Name != "Swiss Franc" || chf.Symbol != "CHF" {
+		t.Errorf("expected provider name plus builtin symbol, got %+v", chf)
+	}
+
+	codes := registry.Codes()
+	if len(codes) != 3 || codes[0] != "CHF" || codes[2] != "USD" {
+		t.Errorf("expected sorted codes [CHF INR USD], got %v", codes)
+	}
+}
+
+func TestRegistry_FailedRefreshKeepsList(t *testing.T) {
+	registry := NewRegistry([]string{"USD"})
+	registry.Replace(map[string]string{"CHF": "Swiss Franc"})
+
+	if err := registry.Refresh(context.Background(), &fakeSource{err: errors.New("down")}); err == nil {
+		t.Error("expected refresh error")
+	}
+	if err := registry.Refresh(context.Background(), &fakeSource{codes: map[string]string{}}); err == nil {
+		t.Error("an empty provider list should be an error, not wipe the registry")
+	}
+	if !registry.IsSupported("CHF") {
+		t.Error("failed refresh should keep the previous list")
+	}
+}
+
+func TestRegistry_AssetsSurviveRefresh(t *testing.T) {
+	registry := NewRegistry([]string{"USD"})
+	registry.AddAssets([]string{"btc", "XAU", "FOO"})
+
+	if !registry.IsSupported("BTC") || !registry.IsSupported("xau") {
+		t.Error("added assets should be supported")
+	}
+	if registry.IsSupported("FOO") {
+		t.Error("unknown assets should be skipped")
+	}
+
+	// a fiat provider listing a crypto code doesn't enable it
+	registry.Replace(map[string]string{"USD": "US Dollar", "ETH": "Ether"})
+	if registry.IsSupported("ETH") {
+		t.Error("crypto codes from the fiat provider list should be dropped")
+	}
+	if !registry.IsSupported("BTC") {
+		t.Error("added assets should survive Replace")
+	}
+
+	btc, _ := registry.Get("BTC")
+	usd, _ := registry.Get("USD")
+	if btc.Class != AssetCrypto || usd.Class != AssetFiat {
+		t.Errorf("expected crypto BTC and fiat USD, got %q and %q", btc.Class, usd.Class)
+	}
+}
+
+func TestValidCode(t *testing.T) {
+	for code, want := range map[string]bool{
+		"USD": true, "xau": true, "DOGE": true, "USDT": true,
+		"US": false, "ABCD": false, "U$D": false, "": false,
+	} {
+		if got := ValidCode(code); got != want {
+			t.Errorf("ValidCode(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	registry := NewRegistry([]string{"USD", "EUR", "INR", "JPY"})
+	registry.AddAssets([]string{"BTC"})
+	registry.Replace(map[string]string{"AUD": "Australian Dollar", "CHF": "Swiss Franc"})
+
+	cases := map[string]string{
+		"usd":                "USD",
+		" EUR ":              "EUR",
+		"€":                  "EUR",
+		"₹":                  "INR",
+		"₿":                  "BTC",
+		"R$":                 "BRL",
+		"A$":                 "AUD",
+		"euro":               "EUR",
+		"Euros":              "EUR",
+		"rupee":              "INR",
+		"yen":                "JPY",
+		"australian  dollar": "AUD",
+		"Swiss Francs":       "CHF",
+		"xyz":                "XYZ",
+		"monopoly money":     "MONOPOLY MONEY",
+	}
+	for input, want := range cases {
+		got, err := registry.Resolve(input)
+		if err != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+
+	_, err := registry.Resolve("$")
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected an ambiguity error for $, got %v", err)
+	}
+	if got := ambiguous.Error(); got != `"$" is ambiguous, it could be ARS, CLP, COP, MXN, USD - use the ISO code` {
+		t.Errorf("unexpected message %q", got)
+	}
+	if _, err := registry.Resolve("kr"); !errors.As(err, &ambiguous) || len(ambiguous.Codes) != 4 {
+		t.Errorf("expected kr to be ambiguous between 4 currencies, got %v", err)
+	}
+}
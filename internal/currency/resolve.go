@@ -0,0 +1,133 @@
+package currency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// commonNames maps the everyday names of currencies to the one most people
+// mean by them. Full names like "Australian Dollar" come from the registry.
+var commonNames = map[string]string{
+	"dollar":   "USD",
+	"buck":     "USD",
+	"euro":     "EUR",
+	"pound":    "GBP",
+	"sterling": "GBP",
+	"quid":     "GBP",
+	"rupee":    "INR",
+	"yen":      "JPY",
+	"yuan":     "CNY",
+	"renminbi": "CNY",
+	"rmb":      "CNY",
+	"franc":    "CHF",
+	"won":      "KRW",
+	"real":     "BRL",
+	"reais":    "BRL",
+	"rand":     "ZAR",
+	"lira":     "TRY",
+	"baht":     "THB",
+	"bitcoin":  "BTC",
+	"ether":    "ETH",
+	"ethereum": "ETH",
+	"gold":     "XAU",
+	"silver":   "XAG",
+}
+
+// extraSymbols are prefixed symbols in common use that the metadata doesn't carry
+var extraSymbols = map[string]string{
+	"US$": "USD",
+	"A$":  "AUD",
+	"C$":  "CAD",
+	"NZ$": "NZD",
+	"HK$": "HKD",
+	"S$":  "SGD",
+}
+
+// AmbiguousError is returned when a symbol belongs to several currencies
+type AmbiguousError struct {
+	Input string
+	Codes []string
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("%q is ambiguous, it could be %s - use the ISO code", e.Input, strings.Join(e.Codes, ", "))
+}
+
+// Resolve turns a user-typed currency - a code in any case ("usd"), a symbol
+// ("€", "₹") or a name ("euro", "Indian Rupee", "rupees") - into its ISO code.
+// A symbol shared by several currencies ("$", "¥", "kr") is an *AmbiguousError.
+// Input it doesn't recognise comes back trimmed and upper-cased, so the usual
+// code validation reports it.
+func (r *Registry) Resolve(input string) (string, error) {
+	trimmed := strings.TrimSpace(input)
+	code := normalize(trimmed)
+	if trimmed == "" || ValidCode(code) && (r.IsSupported(code) || !hasSymbolOrName(trimmed)) {
+		return code, nil
+	}
+
+	if codes := symbolCodes(trimmed); len(codes) == 1 {
+		return codes[0], nil
+	} else if len(codes) > 1 {
+		return "", &AmbiguousError{Input: trimmed, Codes: codes}
+	}
+
+	name := strings.ToLower(strings.Join(strings.Fields(trimmed), " "))
+	if found, ok := r.codeForName(name); ok {
+		return found, nil
+	}
+	if singular, plural := strings.CutSuffix(name, "s"); plural {
+		if found, ok := r.codeForName(singular); ok {
+			return found, nil
+		}
+	}
+
+	return code, nil
+}
+
+// hasSymbolOrName reports whether input could be something other than a code,
+// so "yen" or "won" aren't taken for three-letter codes nobody supports
+func hasSymbolOrName(input string) bool {
+	_, found := commonNames[strings.ToLower(input)]
+	return found || len(symbolCodes(input)) > 0
+}
+
+// symbolCodes returns every known currency written with symbol, sorted
+func symbolCodes(symbol string) []string {
+	if code, found := extraSymbols[strings.ToUpper(symbol)]; found {
+		return []string{code}
+	}
+
+	codes := make([]string, 0, 1)
+	for _, metadata := range []map[string]Currency{builtinMetadata, assetMetadata} {
+		for code, currency := range metadata {
+			if currency.Symbol != "" && strings.EqualFold(currency.Symbol, symbol) {
+				codes = append(codes, code)
+			}
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// codeForName looks a lower-cased name up in the common names, then in the
+// full names of the supported currencies
+func (r *Registry) codeForName(name string) (string, bool) {
+	if code, found := commonNames[name]; found {
+		return code, true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for code, currency := range r.currencies {
+		if strings.EqualFold(currency.Name, name) {
+			return code, true
+		}
+	}
+	for code, currency := range builtinMetadata {
+		if strings.EqualFold(currency.Name, name) {
+			return code, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,31 @@
+package docs
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openapi.json is hand-maintained - docs_test.go checks its schemas against the Go models
+//
+//go:embed openapi.json
+var spec []byte
+
+//go:embed swagger.html
+var swaggerUI []byte
+
+// SpecHandler serves the OpenAPI 3 spec
+func SpecHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Write(spec)
+	})
+}
+
+// UIHandler serves a Swagger UI page that renders the spec
+func UIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(swaggerUI)
+	})
+}
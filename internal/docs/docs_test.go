@@ -0,0 +1,146 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"exchange-rate-service/internal/alerts"
+	"exchange-rate-service/internal/audit"
+	"exchange-rate-service/internal/backfill"
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/models"
+)
+
+type openAPISpec struct {
+	OpenAPI    string                            `json:"openapi"`
+	Paths      map[string]map[string]interface{} `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadSpec(t *testing.T) openAPISpec {
+	t.Helper()
+
+	var parsed openAPISpec
+	if err := json.Unmarshal(spec, &parsed); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return parsed
+}
+
+// jsonFields lists the JSON names of a struct's fields, skipping json:"-"
+func jsonFields(v interface{}) []string {
+	typ := reflect.TypeOf(v)
+	fields := make([]string, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name == "-" || name == "" {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// TestSpecMatchesModels keeps the documented schemas in sync with what the handlers actually encode
+func TestSpecMatchesModels(t *testing.T) {
+	parsed := loadSpec(t)
+
+	schemaModels := map[string]interface{}{
+		"HealthStatus":            models.HealthStatus{},
+		"ProviderHealth":          models.ProviderHealth{},
+		"CurrencyRate":            models.CurrencyRate{},
+		"ConvertRequest":          models.ConvertRequest{},
+		"ConvertResponse":         models.ConvertResponse{},
+		"MultiConvertResponse":    models.MultiConvertResponse{},
+		"ConversionTableResponse": models.ConversionTableResponse{},
+		"ConversionRow":           models.ConversionRow{},
+		"TargetResult":            models.TargetResult{},
+		"TimeSeriesResponse":      models.TimeSeriesResponse{},
+		"TimeSeriesPoint":         models.TimeSeriesPoint{},
+		"CurrencyInfo":            models.CurrencyInfo{},
+		"CurrencyListResponse":    models.CurrencyListResponse{},
+		"RateRecord":              models.RateRecord{},
+		"RateSummary":             models.RateSummary{},
+		"RateSnapshot":            models.RateSnapshot{},
+		"SnapshotRate":            models.SnapshotRate{},
+		"RateStats":               models.RateStats{},
+		"RateTrend":               models.RateTrend{},
+		"RateTable":               models.RateTable{},
+		"TableRate":               models.TableRate{},
+		"Receipt":                 models.Receipt{},
+		"ReceiptVerification":     models.ReceiptVerification{},
+		"RateChange":              models.RateChange{},
+		"CacheStats":              models.CacheStats{},
+		"CacheLookupStats":        models.CacheLookupStats{},
+		"RateFetchStats":          models.RateFetchStats{},
+		"RefreshStats":            models.RefreshStats{},
+		"FormattedAmount":         models.FormattedAmount{},
+		"Quote":                   models.Quote{},
+		"QuoteExecution":          models.QuoteExecution{},
+		"AlertRequest":            alerts.AlertRequest{},
+		"Alert":                   alerts.Alert{},
+		"Notification":            alerts.Notification{},
+		"AuditEntry":              audit.Entry{},
+		"BackfillRequest":         backfill.Request{},
+		"BackfillJob":             backfill.Job{},
+		"Currency":                currency.Currency{},
+	}
+
+	for name, model := range schemaModels {
+		schema, found := parsed.Components.Schemas[name]
+		if !found {
+			t.Errorf("schema %s missing from openapi.json", name)
+			continue
+		}
+
+		documented := make([]string, 0, len(schema.Properties))
+		for property := range schema.Properties {
+			documented = append(documented, property)
+		}
+		sort.Strings(documented)
+
+		if want := jsonFields(model); !reflect.DeepEqual(documented, want) {
+			t.Errorf("schema %s properties %v don't match the model's JSON fields %v", name, documented, want)
+		}
+	}
+}
+
+func TestSpecDocumentsPublicRoutes(t *testing.T) {
+	parsed := loadSpec(t)
+
+	routes := []string{
+		"/health", "/health/live", "/health/ready", "/metrics", "/stats",
+		"/v1/convert", "/v1/convert/multi", "/v1/convert/table", "/v1/rate/latest", "/v1/rate/historical", "/v1/rate/timeseries", "/v1/rate/stats", "/v1/rate/trend", "/v1/rates", "/v1/rate/snapshot", "/v1/format", "/v1/currencies", "/v1/quote", "/v1/quote/{id}/execute", "/v1/receipts/verify", "/graphql",
+		"/v1/analytics/history", "/v1/analytics/summary", "/v1/alerts", "/v1/alerts/{id}",
+		"/v1/admin/cache/stats", "/v1/admin/cache/refresh", "/v1/admin/cache/{pair}", "/v1/admin/backfill", "/v1/admin/backfill/{id}",
+		"/v1/admin/currencies", "/v1/admin/currencies/{code}",
+	}
+	for _, route := range routes {
+		if _, found := parsed.Paths[route]; !found {
+			t.Errorf("route %s is not documented", route)
+		}
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	rec := httptest.NewRecorder()
+	SpecHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/openapi.json", nil))
+	if rec.Header().Get("Content-Type") != "application/json" || !strings.Contains(rec.Body.String(), `"openapi": "3.0.3"`) {
+		t.Errorf("unexpected spec response: %s", rec.Header().Get("Content-Type"))
+	}
+
+	rec = httptest.NewRecorder()
+	UIHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/docs", nil))
+	if !strings.Contains(rec.Body.String(), "/openapi.json") {
+		t.Error("docs page should load the spec")
+	}
+}
@@ -0,0 +1,130 @@
+package fees
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Rule is the markup charged on one conversion: a percentage spread taken off
+// the mid-market rate, a fixed fee in the source currency, or both
+type Rule struct {
+	Percent decimal.Decimal
+	Fixed   decimal.Decimal
+}
+
+// IsZero reports whether the rule charges nothing
+func (r Rule) IsZero() bool {
+	return r.Percent.IsZero() && r.Fixed.IsZero()
+}
+
+// ParseRule parses "1.5%" (spread), "2" (fixed fee) or "1.5%+2" (both)
+// An empty string is the zero rule
+func ParseRule(raw string) (Rule, error) {
+	var rule Rule
+
+	for _, part := range strings.Split(raw, "+") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		isPercent := strings.HasSuffix(part, "%")
+		value, err := decimal.NewFromString(strings.TrimSuffix(part, "%"))
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid fee %q: %w", part, err)
+		}
+		if value.IsNegative() {
+			return Rule{}, fmt.Errorf("invalid fee %q: cannot be negative", part)
+		}
+
+		if isPercent {
+			if value.GreaterThanOrEqual(hundred) {
+				return Rule{}, fmt.Errorf("invalid fee %q: spread must be below 100%%", part)
+			}
+			rule.Percent = rule.Percent.Add(value)
+		} else {
+			rule.Fixed = rule.Fixed.Add(value)
+		}
+	}
+
+	if rule.Percent.GreaterThanOrEqual(hundred) {
+		return Rule{}, fmt.Errorf("invalid fee %q: spread must be below 100%%", raw)
+	}
+	return rule, nil
+}
+
+// Result is a conversion with the fee taken out
+type Result struct {
+	AppliedRate decimal.Decimal // mid-market rate less the spread
+	Amount      decimal.Decimal // what the customer receives, in the target currency
+	Fee         decimal.Decimal // mid-market amount minus Amount, in the target currency
+}
+
+// Apply converts amount at midRate and charges the rule. The fixed fee comes
+// off the amount before conversion; an amount smaller than the fee converts
+// to zero. Amounts are rounded to minorUnits of the target currency.
+func (r Rule) Apply(amount, midRate decimal.Decimal, minorUnits int32) Result {
+	appliedRate := midRate.Mul(hundred.Sub(r.Percent)).Div(hundred)
+
+	net := amount.Sub(r.Fixed)
+	if net.IsNegative() {
+		net = decimal.Zero
+	}
+
+	gross := amount.Mul(midRate).Round(minorUnits)
+	converted := net.Mul(appliedRate).Round(minorUnits)
+
+	return Result{
+		AppliedRate: appliedRate,
+		Amount:      converted,
+		Fee:         gross.Sub(converted),
+	}
+}
+
+// Schedule holds the default rule and per-pair overrides
+type Schedule struct {
+	defaultRule Rule
+	pairs       map[string]Rule
+}
+
+// NewSchedule parses the default rule and the "FROM-TO" -> rule overrides
+// A pair rule replaces the default rather than adding to it
+func NewSchedule(defaultRule string, pairs map[string]string) (*Schedule, error) {
+	rule, err := ParseRule(defaultRule)
+	if err != nil {
+		return nil, fmt.Errorf("default fee: %w", err)
+	}
+
+	schedule := &Schedule{defaultRule: rule, pairs: make(map[string]Rule, len(pairs))}
+	for pair, raw := range pairs {
+		codes := strings.Split(strings.ToUpper(strings.TrimSpace(pair)), "-")
+		if len(codes) != 2 || codes[0] == "" || codes[1] == "" {
+			return nil, fmt.Errorf("invalid fee pair %q (expected FROM-TO)", pair)
+		}
+
+		pairRule, err := ParseRule(raw)
+		if err != nil {
+			return nil, fmt.Errorf("fee for %s: %w", pair, err)
+		}
+		schedule.pairs[codes[0]+"-"+codes[1]] = pairRule
+	}
+
+	return schedule, nil
+}
+
+// RuleFor returns the rule charged on from -> to
+func (s *Schedule) RuleFor(from, to string) Rule {
+	if rule, found := s.pairs[strings.ToUpper(from)+"-"+strings.ToUpper(to)]; found {
+		return rule
+	}
+	return s.defaultRule
+}
+
+// Len returns the number of pair overrides
+func (s *Schedule) Len() int {
+	return len(s.pairs)
+}
@@ -0,0 +1,90 @@
+package fees
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		raw     string
+		percent string
+		fixed   string
+		wantErr bool
+	}{
+		{"", "0", "0", false},
+		{"1.5%", "1.5", "0", false},
+		{"2", "0", "2", false},
+		{"1.5% + 2", "1.5", "2", false},
+		{"-1%", "", "", true},
+		{"100%", "", "", true},
+		{"abc", "", "", true},
+	}
+
+	for _, tt := range tests {
+		rule, err := ParseRule(tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected an error", tt.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.raw, err)
+			continue
+		}
+		if rule.Percent.String() != tt.percent || rule.Fixed.String() != tt.fixed {
+			t.Errorf("%q: expected %s%% + %s, got %s%% + %s", tt.raw, tt.percent, tt.fixed, rule.Percent, rule.Fixed)
+		}
+	}
+}
+
+func TestRule_Apply(t *testing.T) {
+	rule, _ := ParseRule("1%+2")
+
+	// 100 USD at 80: 2 USD fixed fee, 98 converted at 79.2
+	result := rule.Apply(decimal.NewFromInt(100), decimal.NewFromInt(80), 2)
+	if !result.AppliedRate.Equal(decimal.RequireFromString("79.2")) {
+		t.Errorf("expected applied rate 79.2, got %s", result.AppliedRate)
+	}
+	if !result.Amount.Equal(decimal.RequireFromString("7761.6")) {
+		t.Errorf("expected 7761.6, got %s", result.Amount)
+	}
+	if !result.Fee.Equal(decimal.RequireFromString("238.4")) {
+		t.Errorf("expected fee 238.4 (8000 - 7761.6), got %s", result.Fee)
+	}
+
+	// the fixed fee can't push the amount below zero
+	result = rule.Apply(decimal.NewFromInt(1), decimal.NewFromInt(80), 2)
+	if !result.Amount.IsZero() || !result.Fee.Equal(decimal.NewFromInt(80)) {
+		t.Errorf("expected nothing converted and the whole amount as fee, got %+v", result)
+	}
+
+	// no rule - mid-market conversion
+	result = Rule{}.Apply(decimal.NewFromInt(100), decimal.RequireFromString("0.85"), 2)
+	if !result.Amount.Equal(decimal.NewFromInt(85)) || !result.Fee.IsZero() || !result.AppliedRate.Equal(decimal.RequireFromString("0.85")) {
+		t.Errorf("expected a plain conversion, got %+v", result)
+	}
+}
+
+func TestSchedule_PairOverridesDefault(t *testing.T) {
+	schedule, err := NewSchedule("0.5%", map[string]string{"usd-inr": "2%", "EUR-GBP": ""})
+	if err != nil {
+		t.Fatalf("NewSchedule failed: %v", err)
+	}
+
+	if rule := schedule.RuleFor("USD", "INR"); rule.Percent.String() != "2" {
+		t.Errorf("expected the pair rule for USD-INR, got %+v", rule)
+	}
+	if rule := schedule.RuleFor("INR", "USD"); rule.Percent.String() != "0.5" {
+		t.Errorf("pair rules are directional - expected the default for INR-USD, got %+v", rule)
+	}
+	if rule := schedule.RuleFor("eur", "gbp"); !rule.IsZero() {
+		t.Errorf("an empty pair rule should waive the default, got %+v", rule)
+	}
+
+	if _, err := NewSchedule("", map[string]string{"USDINR": "1%"}); err == nil {
+		t.Error("expected an error for a pair without a dash")
+	}
+}
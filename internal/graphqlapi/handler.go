@@ -0,0 +1,80 @@
+package graphqlapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/utils"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// query limits - the schema is shallow, anything deeper is introspection abuse
+const (
+	maxQueryDepth   = 10
+	maxRequestBytes = 1 << 20
+)
+
+// Handler serves POST /graphql
+type Handler struct {
+	schema   *graphql.Schema
+	resolver *resolver
+}
+
+// request is the standard GraphQL-over-HTTP body
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewHandler parses the schema against the resolvers. Fields of one query
+// resolve concurrently, so several pairs cost one round trip
+func NewHandler(currencyService CurrencyExchangeService) (*Handler, error) {
+	root := &resolver{currencyService: currencyService}
+	schema, err := graphql.ParseSchema(schemaSDL, root,
+		graphql.UseFieldResolvers(),
+		graphql.MaxDepth(maxQueryDepth),
+		graphql.Logger(panicLogger{}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{schema: schema, resolver: root}, nil
+}
+
+// SetAuditor records every convert query to auditor from now on
+func (h *Handler) SetAuditor(auditor ConversionAuditor) {
+	h.resolver.auditor = auditor
+}
+
+// ServeHTTP runs the query. Field errors still answer 200 with "errors" set,
+// as GraphQL clients expect - only an unreadable request gets a 400
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		utils.ErrorRespWithCode(w, http.StatusBadRequest, string(apperrors.CodeInvalidRequest), "invalid GraphQL request body")
+		return
+	}
+	if req.Query == "" {
+		utils.ErrorRespWithCode(w, http.StatusBadRequest, string(apperrors.CodeInvalidRequest), "missing required field: query")
+		return
+	}
+
+	response := h.schema.Exec(r.Context(), req.Query, req.OperationName, req.Variables)
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// panicLogger sends resolver panics to slog instead of the std logger
+type panicLogger struct{}
+
+func (panicLogger) LogPanic(ctx context.Context, value interface{}) {
+	slog.ErrorContext(ctx, "Panic in GraphQL resolver", "panic", value)
+}
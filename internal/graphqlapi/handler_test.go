@@ -0,0 +1,199 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/audit"
+	"exchange-rate-service/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// fakeService answers from a fixed rate table
+type fakeService struct {
+	rates map[string]float64
+}
+
+func (f *fakeService) ConvertCurrencyAmount(ctx context.Context, from, to string, amount decimal.Decimal, date string) (models.ConversionResult, error) {
+	rate, ok := f.rates[from+to]
+	if !ok {
+		return models.ConversionResult{}, apperrors.New(apperrors.CodeUnsupportedCurrency, "unsupported target currency: %s", to)
+	}
+	return models.ConversionResult{
+		Amount:      amount.Mul(decimal.NewFromFloat(rate)).Round(2),
+		Quote:       models.RateQuote{Rate: rate, Source: "fake"},
+		AppliedRate: rate,
+	}, nil
+}
+
+func (f *fakeService) GetHistoricalExchangeRate(ctx context.Context, from, to, date string) (models.RateQuote, error) {
+	return models.RateQuote{Rate: 0.9, Date: "2025-08-01"}, nil
+}
+
+func (f *fakeService) GetHistoricalRateRange(ctx context.Context, from, to, start, end string) (map[string]float64, error) {
+	if from == "XXX" {
+		return nil, apperrors.Wrap(apperrors.CodeUpstreamUnavailable, errors.New("dial tcp 10.0.0.1:443"), "failed to fetch rates")
+	}
+	return map[string]float64{"2025-08-02": 0.91, "2025-08-01": 0.9}, nil
+}
+
+func (f *fakeService) GetDeprecationNotices(codes ...string) []models.DeprecationNotice {
+	return nil
+}
+
+// graphqlResponse is the wire shape clients see
+type graphqlResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Path       []interface{}  `json:"path"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func execute(t *testing.T, query string, variables map[string]interface{}) (int, graphqlResponse) {
+	t.Helper()
+
+	handler, err := NewHandler(&fakeService{rates: map[string]float64{"USDEUR": 0.85, "USDINR": 83.2}})
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+
+	var resp graphqlResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("bad response body %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, resp
+}
+
+func TestQuery_SeveralPairsOneRoundTrip(t *testing.T) {
+	code, resp := execute(t, `{
+		eur: latestRate(from: "USD", to: "EUR") { rate date }
+		inr: convert(from: "USD", to: "INR", amount: "10.50") { amount source }
+		hist: historicalRate(from: "USD", to: "EUR", date: "2025-08-03") { effectiveDate }
+	}`, nil)
+
+	if code != http.StatusOK || len(resp.Errors) != 0 {
+		t.Fatalf("expected clean 200, got %d %+v", code, resp.Errors)
+	}
+	if got := string(resp.Data["eur"]); got != `{"rate":0.85,"date":"latest"}` {
+		t.Errorf("eur = %s", got)
+	}
+	if got := string(resp.Data["inr"]); got != `{"amount":"873.6","source":"fake"}` {
+		t.Errorf("inr = %s", got)
+	}
+	if got := string(resp.Data["hist"]); got != `{"effectiveDate":"2025-08-01"}` {
+		t.Errorf("hist = %s", got)
+	}
+}
+
+func TestQuery_TimeseriesInDateOrder(t *testing.T) {
+	_, resp := execute(t, `query($from: String!) {
+		timeseries(from: $from, to: "EUR", start: "2025-08-01", end: "2025-08-02") { rates { date rate } }
+	}`, map[string]interface{}{"from": "USD"})
+
+	want := `{"rates":[{"date":"2025-08-01","rate":0.9},{"date":"2025-08-02","rate":0.91}]}`
+	if got := string(resp.Data["timeseries"]); got != want {
+		t.Errorf("timeseries = %s, want %s", got, want)
+	}
+}
+
+func TestQuery_FieldErrorsKeepTheRest(t *testing.T) {
+	code, resp := execute(t, `{
+		ok: latestRate(from: "USD", to: "EUR") { rate }
+		bad: latestRate(from: "USD", to: "ZZZ") { rate }
+		down: timeseries(from: "XXX", to: "EUR", start: "2025-08-01", end: "2025-08-02") { start }
+		amount: convert(from: "USD", to: "EUR", amount: "ten") { amount }
+	}`, nil)
+
+	if code != http.StatusOK {
+		t.Fatalf("field errors should still answer 200, got %d", code)
+	}
+	if got := string(resp.Data["ok"]); got != `{"rate":0.85}` {
+		t.Errorf("ok = %s", got)
+	}
+	if got := string(resp.Data["bad"]); got != "null" {
+		t.Errorf("bad = %s, want null", got)
+	}
+
+	codes := make(map[string]string)
+	for _, e := range resp.Errors {
+		codes[e.Path[0].(string)] = e.Extensions["code"].(string)
+		if strings.Contains(e.Message, "10.0.0.1") {
+			t.Errorf("provider details leaked: %q", e.Message)
+		}
+	}
+	want := map[string]string{"bad": "unsupported_currency", "down": "upstream_unavailable", "amount": "invalid_amount"}
+	for field, code := range want {
+		if codes[field] != code {
+			t.Errorf("%s error code = %q, want %q", field, codes[field], code)
+		}
+	}
+}
+
+// recordingAuditor keeps every audit entry - fields resolve concurrently
+type recordingAuditor struct {
+	mu      sync.Mutex
+	entries []audit.Entry
+}
+
+func (a *recordingAuditor) Record(entry audit.Entry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+}
+
+func TestConvertIsAudited(t *testing.T) {
+	handler, err := NewHandler(&fakeService{rates: map[string]float64{"USDEUR": 0.85}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditor := &recordingAuditor{}
+	handler.SetAuditor(auditor)
+
+	body := `{"query":"{ ok: convert(from: \"USD\", to: \"EUR\", amount: \"10\") { amount } bad: convert(from: \"USD\", to: \"XTS\", amount: \"10\") { amount } }"}`
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+
+	auditor.mu.Lock()
+	defer auditor.mu.Unlock()
+	if len(auditor.entries) != 2 {
+		t.Fatalf("expected one audit entry per convert field, got %+v", auditor.entries)
+	}
+	byTarget := map[string]audit.Entry{}
+	for _, entry := range auditor.entries {
+		byTarget[entry.To] = entry
+	}
+	if entry := byTarget["EUR"]; entry.Endpoint != "graphql convert" || entry.Status != "success" || entry.Result != "8.5" {
+		t.Errorf("unexpected entry for the conversion: %+v", entry)
+	}
+	if entry := byTarget["XTS"]; entry.Status != "error" || entry.ErrorCode != "unsupported_currency" {
+		t.Errorf("unexpected entry for the failed conversion: %+v", entry)
+	}
+}
+
+func TestServeHTTP_RejectsBadBody(t *testing.T) {
+	handler, err := NewHandler(&fakeService{})
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+
+	for _, body := range []string{"not json", `{"query": ""}`} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("body %q: expected 400, got %d", body, rec.Code)
+		}
+	}
+}
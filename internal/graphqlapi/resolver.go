@@ -0,0 +1,298 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/audit"
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// CurrencyExchangeService is what the GraphQL API needs from the service layer -
+// the same methods the HTTP handlers use
+type CurrencyExchangeService interface {
+	ConvertCurrencyAmount(ctx context.Context, fromCurrency, toCurrency string, amount decimal.Decimal, dateStr string) (models.ConversionResult, error)
+	GetHistoricalExchangeRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (models.RateQuote, error)
+	GetHistoricalRateRange(ctx context.Context, fromCurrency, toCurrency, startDate, endDate string) (map[string]float64, error)
+	GetDeprecationNotices(codes ...string) []models.DeprecationNotice
+}
+
+// ConversionAuditor receives an entry for every convert query - implemented by audit.Logger
+type ConversionAuditor interface {
+	Record(entry audit.Entry)
+}
+
+// resolver is the Query root. Result types are plain structs, their fields
+// resolved by name
+type resolver struct {
+	currencyService CurrencyExchangeService
+	auditor         ConversionAuditor // nil when auditing is off
+}
+
+type rate struct {
+	From          string
+	To            string
+	Rate          float64
+	Date          string
+	EffectiveDate *string
+	Stale         bool
+	Derived       bool
+	LastUpdated   *string
+	Warnings      []string
+}
+
+type timeSeries struct {
+	From     string
+	To       string
+	Start    string
+	End      string
+	Rates    []models.TimeSeriesPoint
+	Warnings []string
+}
+
+type conversion struct {
+	From           string
+	To             string
+	OriginalAmount string
+	Amount         string
+	Rate           float64
+	AppliedRate    float64
+	Fee            string
+	Date           *string
+	Cached         bool
+	Source         *string
+	Stale          bool
+	Derived        bool
+	LastUpdated    *string
+	Warnings       []string
+}
+
+// LatestRate mirrors GET /rate/latest
+func (r *resolver) LatestRate(ctx context.Context, args struct{ From, To string }) (*rate, error) {
+	if err := requirePair(args.From, args.To); err != nil {
+		return nil, err
+	}
+
+	// get rate by converting 1 unit - use the quote, the amount is rounded to minor units
+	result, err := r.currencyService.ConvertCurrencyAmount(ctx, args.From, args.To, decimal.NewFromInt(1), "")
+	if err != nil {
+		return nil, errorFromService(ctx, err)
+	}
+
+	resp := &rate{
+		From:     strings.ToUpper(args.From),
+		To:       strings.ToUpper(args.To),
+		Rate:     result.Quote.Rate,
+		Date:     "latest",
+		Derived:  result.Quote.Derived,
+		Warnings: r.deprecationWarnings(args.From, args.To),
+	}
+	if result.Quote.Stale {
+		resp.Stale = true
+		resp.LastUpdated = formatTimestamp(result.Quote.LastUpdated)
+	}
+
+	return resp, nil
+}
+
+// HistoricalRate mirrors GET /rate/historical
+func (r *resolver) HistoricalRate(ctx context.Context, args struct{ From, To, Date string }) (*rate, error) {
+	if err := requirePair(args.From, args.To); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Date) == "" {
+		return nil, invalidRequest("missing required argument: date")
+	}
+
+	quote, err := r.currencyService.GetHistoricalExchangeRate(ctx, args.From, args.To, args.Date)
+	if err != nil {
+		return nil, errorFromService(ctx, err)
+	}
+
+	resp := &rate{
+		From:     strings.ToUpper(args.From),
+		To:       strings.ToUpper(args.To),
+		Rate:     quote.Rate,
+		Date:     args.Date,
+		Warnings: r.deprecationWarnings(args.From, args.To),
+	}
+	// no rate that day (weekend, holiday) - say whose rate this is
+	if quote.Date != "" && quote.Date != args.Date {
+		effective := quote.Date
+		resp.EffectiveDate = &effective
+	}
+
+	return resp, nil
+}
+
+// Timeseries mirrors GET /rate/timeseries, points in date order
+func (r *resolver) Timeseries(ctx context.Context, args struct{ From, To, Start, End string }) (*timeSeries, error) {
+	if err := requirePair(args.From, args.To); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Start) == "" || strings.TrimSpace(args.End) == "" {
+		return nil, invalidRequest("missing required argument: start and end")
+	}
+
+	series, err := r.currencyService.GetHistoricalRateRange(ctx, args.From, args.To, args.Start, args.End)
+	if err != nil {
+		return nil, errorFromService(ctx, err)
+	}
+
+	// Points does the date ordering for the CSV/XML forms already
+	ordered := models.TimeSeriesResponse{Rates: series}.Points()
+
+	return &timeSeries{
+		From:     strings.ToUpper(args.From),
+		To:       strings.ToUpper(args.To),
+		Start:    args.Start,
+		End:      args.End,
+		Rates:    ordered,
+		Warnings: r.deprecationWarnings(args.From, args.To),
+	}, nil
+}
+
+// Convert mirrors GET /convert
+func (r *resolver) Convert(ctx context.Context, args struct {
+	From, To, Amount string
+	Date             *string
+}) (*conversion, error) {
+	if err := requirePair(args.From, args.To); err != nil {
+		return nil, err
+	}
+
+	amount, err := currency.ParseAmount(args.Amount, args.From, config.GetMinorUnits(args.From))
+	if err != nil {
+		return nil, &queryError{code: apperrors.CodeInvalidAmount, message: "amount " + err.Error()}
+	}
+
+	var date string
+	if args.Date != nil {
+		date = *args.Date
+	}
+
+	result, err := r.currencyService.ConvertCurrencyAmount(ctx, args.From, args.To, amount, date)
+	if r.auditor != nil {
+		entry := audit.NewEntry(ctx, "graphql convert")
+		entry.SetConversion(args.From, args.To, amount, date, result, err)
+		r.auditor.Record(entry)
+	}
+	if err != nil {
+		return nil, errorFromService(ctx, err)
+	}
+
+	resp := &conversion{
+		From:           strings.ToUpper(args.From),
+		To:             strings.ToUpper(args.To),
+		OriginalAmount: amount.String(),
+		Amount:         result.Amount.String(),
+		Rate:           result.Quote.Rate,
+		AppliedRate:    result.AppliedRate,
+		Fee:            result.Fee.String(),
+		Date:           args.Date,
+		Cached:         result.Quote.Cached,
+		Stale:          result.Quote.Stale,
+		Derived:        result.Quote.Derived,
+		LastUpdated:    formatTimestamp(result.Quote.LastUpdated),
+		Warnings:       r.deprecationWarnings(args.From, args.To),
+	}
+	if result.Quote.Source != "" {
+		source := result.Quote.Source
+		resp.Source = &source
+	}
+
+	return resp, nil
+}
+
+// deprecationWarnings builds the same messages the HTTP API puts in "warnings"
+// Never nil - the schema promises a list
+func (r *resolver) deprecationWarnings(codes ...string) []string {
+	notices := r.currencyService.GetDeprecationNotices(codes...)
+
+	warnings := make([]string, 0, len(notices))
+	for _, notice := range notices {
+		warnings = append(warnings, fmt.Sprintf("currency %s is deprecated and will be removed on %s", notice.Code, notice.SunsetDate.Format("2006-01-02")))
+	}
+	return warnings
+}
+
+// queryError is an error in the GraphQL "errors" list. The code goes in
+// extensions, the same stable string the HTTP API puts in "code"
+type queryError struct {
+	code    apperrors.Code
+	message string
+}
+
+func (e *queryError) Error() string {
+	return e.message
+}
+
+// Extensions is picked up by graphql-go and copied into the error entry
+func (e *queryError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": string(e.code)}
+}
+
+func invalidRequest(message string) error {
+	return &queryError{code: apperrors.CodeInvalidRequest, message: message}
+}
+
+// requirePair checks both currency arguments are present
+func requirePair(from, to string) error {
+	if strings.TrimSpace(from) == "" {
+		return invalidRequest("missing required argument: from")
+	}
+	if strings.TrimSpace(to) == "" {
+		return invalidRequest("missing required argument: to")
+	}
+	return nil
+}
+
+// errorFromService maps service errors by their apperrors code.
+// Internal and provider details only go to the logs
+func errorFromService(ctx context.Context, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return &queryError{code: apperrors.CodeTimeout, message: "request timed out"}
+	}
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		slog.ErrorContext(ctx, "Unexpected GraphQL service error", "error", err)
+		return &queryError{code: apperrors.CodeInternal, message: "internal server error"}
+	}
+
+	msg := err.Error()
+	if appErr.Upstream != "" {
+		msg = appErr.Message + " (" + appErr.Upstream + ")"
+	}
+	switch appErr.Code {
+	case apperrors.CodeReadOnlyReplica:
+		msg = "rate not available on read-only replica"
+	case apperrors.CodePairUnavailable:
+		slog.InfoContext(ctx, "GraphQL pair unavailable", "error", err)
+		msg = appErr.Message
+	case apperrors.CodeUpstreamUnavailable:
+		slog.WarnContext(ctx, "GraphQL upstream failure", "error", err)
+		msg = "exchange rate service temporarily unavailable"
+	case apperrors.CodeUpstreamRejected:
+		slog.ErrorContext(ctx, "GraphQL provider rejected our request", "error", err)
+	}
+
+	return &queryError{code: appErr.Code, message: msg}
+}
+
+func formatTimestamp(ts time.Time) *string {
+	if ts.IsZero() {
+		return nil
+	}
+	formatted := ts.UTC().Format(time.RFC3339)
+	return &formatted
+}
@@ -0,0 +1,252 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             v25.3.0
+// source: exchange/v1/exchange.proto
+
+package exchangepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	ExchangeService_Convert_FullMethodName           = "/exchange.v1.ExchangeService/Convert"
+	ExchangeService_GetLatestRate_FullMethodName     = "/exchange.v1.ExchangeService/GetLatestRate"
+	ExchangeService_GetHistoricalRate_FullMethodName = "/exchange.v1.ExchangeService/GetHistoricalRate"
+	ExchangeService_StreamRates_FullMethodName       = "/exchange.v1.ExchangeService/StreamRates"
+)
+
+// ExchangeServiceClient is the client API for ExchangeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ExchangeServiceClient interface {
+	Convert(ctx context.Context, in *ConvertRequest, opts ...grpc.CallOption) (*ConvertResponse, error)
+	GetLatestRate(ctx context.Context, in *LatestRateRequest, opts ...grpc.CallOption) (*RateResponse, error)
+	GetHistoricalRate(ctx context.Context, in *HistoricalRateRequest, opts ...grpc.CallOption) (*RateResponse, error)
+	StreamRates(ctx context.Context, in *StreamRatesRequest, opts ...grpc.CallOption) (ExchangeService_StreamRatesClient, error)
+}
+
+type exchangeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewExchangeServiceClient(cc grpc.ClientConnInterface) ExchangeServiceClient {
+	return &exchangeServiceClient{cc}
+}
+
+func (c *exchangeServiceClient) Convert(ctx context.Context, in *ConvertRequest, opts ...grpc.CallOption) (*ConvertResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConvertResponse)
+	err := c.cc.Invoke(ctx, ExchangeService_Convert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeServiceClient) GetLatestRate(ctx context.Context, in *LatestRateRequest, opts ...grpc.CallOption) (*RateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RateResponse)
+	err := c.cc.Invoke(ctx, ExchangeService_GetLatestRate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeServiceClient) GetHistoricalRate(ctx context.Context, in *HistoricalRateRequest, opts ...grpc.CallOption) (*RateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RateResponse)
+	err := c.cc.Invoke(ctx, ExchangeService_GetHistoricalRate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeServiceClient) StreamRates(ctx context.Context, in *StreamRatesRequest, opts ...grpc.CallOption) (ExchangeService_StreamRatesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ExchangeService_ServiceDesc.Streams[0], ExchangeService_StreamRates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &exchangeServiceStreamRatesClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ExchangeService_StreamRatesClient interface {
+	Recv() (*RateResponse, error)
+	grpc.ClientStream
+}
+
+type exchangeServiceStreamRatesClient struct {
+	grpc.ClientStream
+}
+
+func (x *exchangeServiceStreamRatesClient) Recv() (*RateResponse, error) {
+	m := new(RateResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ExchangeServiceServer is the server API for ExchangeService service.
+// All implementations must embed UnimplementedExchangeServiceServer
+// for forward compatibility
+type ExchangeServiceServer interface {
+	Convert(context.Context, *ConvertRequest) (*ConvertResponse, error)
+	GetLatestRate(context.Context, *LatestRateRequest) (*RateResponse, error)
+	GetHistoricalRate(context.Context, *HistoricalRateRequest) (*RateResponse, error)
+	StreamRates(*StreamRatesRequest, ExchangeService_StreamRatesServer) error
+	mustEmbedUnimplementedExchangeServiceServer()
+}
+
+// UnimplementedExchangeServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedExchangeServiceServer struct {
+}
+
+func (UnimplementedExchangeServiceServer) Convert(context.Context, *ConvertRequest) (*ConvertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Convert not implemented")
+}
+func (UnimplementedExchangeServiceServer) GetLatestRate(context.Context, *LatestRateRequest) (*RateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLatestRate not implemented")
+}
+func (UnimplementedExchangeServiceServer) GetHistoricalRate(context.Context, *HistoricalRateRequest) (*RateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistoricalRate not implemented")
+}
+func (UnimplementedExchangeServiceServer) StreamRates(*StreamRatesRequest, ExchangeService_StreamRatesServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamRates not implemented")
+}
+func (UnimplementedExchangeServiceServer) mustEmbedUnimplementedExchangeServiceServer() {}
+
+// UnsafeExchangeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ExchangeServiceServer will
+// result in compilation errors.
+type UnsafeExchangeServiceServer interface {
+	mustEmbedUnimplementedExchangeServiceServer()
+}
+
+func RegisterExchangeServiceServer(s grpc.ServiceRegistrar, srv ExchangeServiceServer) {
+	s.RegisterService(&ExchangeService_ServiceDesc, srv)
+}
+
+func _ExchangeService_Convert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeServiceServer).Convert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeService_Convert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeServiceServer).Convert(ctx, req.(*ConvertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeService_GetLatestRate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LatestRateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeServiceServer).GetLatestRate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeService_GetLatestRate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeServiceServer).GetLatestRate(ctx, req.(*LatestRateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeService_GetHistoricalRate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoricalRateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeServiceServer).GetHistoricalRate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeService_GetHistoricalRate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeServiceServer).GetHistoricalRate(ctx, req.(*HistoricalRateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeService_StreamRates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExchangeServiceServer).StreamRates(m, &exchangeServiceStreamRatesServer{ServerStream: stream})
+}
+
+type ExchangeService_StreamRatesServer interface {
+	Send(*RateResponse) error
+	grpc.ServerStream
+}
+
+type exchangeServiceStreamRatesServer struct {
+	grpc.ServerStream
+}
+
+func (x *exchangeServiceStreamRatesServer) Send(m *RateResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ExchangeService_ServiceDesc is the grpc.ServiceDesc for ExchangeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ExchangeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "exchange.v1.ExchangeService",
+	HandlerType: (*ExchangeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Convert",
+			Handler:    _ExchangeService_Convert_Handler,
+		},
+		{
+			MethodName: "GetLatestRate",
+			Handler:    _ExchangeService_GetLatestRate_Handler,
+		},
+		{
+			MethodName: "GetHistoricalRate",
+			Handler:    _ExchangeService_GetHistoricalRate_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamRates",
+			Handler:       _ExchangeService_StreamRates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "exchange/v1/exchange.proto",
+}
@@ -0,0 +1,349 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/auth"
+	"exchange-rate-service/internal/logging"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// apiKeyMetadata is the metadata key clients put their API key in (gRPC lowercases keys)
+const apiKeyMetadata = "x-api-key"
+
+// requestIDMetadata is the gRPC counterpart of the X-Request-ID header
+const requestIDMetadata = "x-request-id"
+
+// authorizationMetadata carries "Bearer <jwt>", like the HTTP Authorization header
+const authorizationMetadata = "authorization"
+
+// forwardedForMetadata is trusted from TRUSTED_PROXIES only, as over http
+const forwardedForMetadata = "x-forwarded-for"
+
+// signing metadata, the gRPC counterparts of the X-Signature headers
+const (
+	signatureMetadata          = "x-signature"
+	signatureTimestampMetadata = "x-signature-timestamp"
+	signatureKeyIDMetadata     = "x-signature-key-id"
+)
+
+// signedMethod stands in for the http method in a gRPC call's signed string -
+// every gRPC call is an http/2 POST
+const signedMethod = "POST"
+
+// Authenticator checks an API key and charges it one request - implemented by middleware.APIKeyAuth
+type Authenticator interface {
+	Authenticate(key string) (*auth.Client, time.Duration, error)
+}
+
+// TokenVerifier checks a bearer JWT - implemented by auth.JWTVerifier
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*auth.Claims, error)
+}
+
+// PeerFilter applies the IP allow and deny lists - implemented by middleware.IPAccessControl
+type PeerFilter interface {
+	AllowsPeer(remoteAddr, forwarded string) (net.IP, bool)
+}
+
+// SignatureVerifier checks HMAC request signatures - implemented by middleware.RequestSigner
+type SignatureVerifier interface {
+	Required() bool
+	VerifySignature(keyID, timestamp, signature, method, requestURI string, body []byte) error
+}
+
+// Options picks the checks NewGRPCServer puts in front of the service, in the
+// order http runs them: IP lists, request signature, then JWT or API key. With
+// none set every call is let through
+type Options struct {
+	Access  PeerFilter        // IP allow and deny lists
+	Signer  SignatureVerifier // partner HMAC signatures
+	KeyAuth Authenticator     // API keys, sharing their budgets with http
+	Tokens  TokenVerifier     // bearer JWTs, which need the reader role
+
+	Auditor ConversionAuditor // conversion audit trail, shared with http
+}
+
+// NewGRPCServer builds a grpc.Server with request ids, logging, recovery and
+// whichever access checks opts configures in front of the exchange service
+func NewGRPCServer(currencyService CurrencyExchangeService, opts Options) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{requestIDUnaryInterceptor, loggingUnaryInterceptor, recoveryUnaryInterceptor}
+	streams := []grpc.StreamServerInterceptor{requestIDStreamInterceptor, loggingStreamInterceptor, recoveryStreamInterceptor}
+	if opts.Access != nil {
+		unary = append(unary, accessUnaryInterceptor(opts.Access))
+		streams = append(streams, accessStreamInterceptor(opts.Access))
+	}
+	if opts.Signer != nil {
+		unary = append(unary, signingUnaryInterceptor(opts.Signer))
+		streams = append(streams, signingStreamInterceptor(opts.Signer))
+	}
+	if opts.KeyAuth != nil || opts.Tokens != nil {
+		unary = append(unary, authUnaryInterceptor(opts))
+		streams = append(streams, authStreamInterceptor(opts))
+	}
+
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(streams...),
+	)
+	server := NewServer(currencyService)
+	if opts.Auditor != nil {
+		server.SetAuditor(opts.Auditor)
+	}
+	server.Register(srv)
+
+	return srv
+}
+
+// checkPeer rejects callers outside the IP lists
+func checkPeer(ctx context.Context, access PeerFilter, method string) error {
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	// every value, in order - a proxy may add its own instead of extending the client's
+	clientIP, allowed := access.AllowsPeer(remoteAddr, strings.Join(md.Get(forwardedForMetadata), ","))
+	if !allowed {
+		slog.WarnContext(ctx, "Access denied", "client_ip", clientIP, "method", method)
+		return status.Error(codes.PermissionDenied, "access denied")
+	}
+	return nil
+}
+
+func accessUnaryInterceptor(access PeerFilter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := checkPeer(ctx, access, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func accessStreamInterceptor(access PeerFilter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkPeer(ss.Context(), access, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// verifySignature checks a call's signature over
+// POST \n FULL_METHOD \n TIMESTAMP \n hex(sha256(request)), where the request is
+// the deterministically marshaled message. Unsigned calls pass unless signing
+// is required
+func verifySignature(ctx context.Context, signer SignatureVerifier, method string, req interface{}) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	signature := firstValue(md, signatureMetadata)
+	if signature == "" {
+		if signer.Required() {
+			return status.Error(codes.Unauthenticated, "missing request signature")
+		}
+		return nil
+	}
+
+	message, ok := req.(proto.Message)
+	if !ok {
+		return status.Error(codes.Internal, "request can't be verified")
+	}
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return status.Error(codes.Internal, "request can't be verified")
+	}
+
+	err = signer.VerifySignature(firstValue(md, signatureKeyIDMetadata), firstValue(md, signatureTimestampMetadata),
+		signature, signedMethod, method, body)
+	if err != nil {
+		slog.WarnContext(ctx, "Signature verification failed", "method", method, "error", err)
+		return status.Error(codes.Unauthenticated, "invalid request signature: "+err.Error())
+	}
+	return nil
+}
+
+func signingUnaryInterceptor(signer SignatureVerifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := verifySignature(ctx, signer, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// signingStreamInterceptor verifies a stream's signature against its first
+// message, which the handler receives before it sends anything
+func signingStreamInterceptor(signer SignatureVerifier) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		if firstValue(md, signatureMetadata) == "" {
+			if signer.Required() {
+				return status.Error(codes.Unauthenticated, "missing request signature")
+			}
+			return handler(srv, ss)
+		}
+		return handler(srv, &signedStream{ServerStream: ss, signer: signer, method: info.FullMethod})
+	}
+}
+
+// signedStream verifies the signature on the first received message
+type signedStream struct {
+	grpc.ServerStream
+	signer   SignatureVerifier
+	method   string
+	verified bool
+}
+
+func (s *signedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.verified {
+		return nil
+	}
+	s.verified = true
+	return verifySignature(s.Context(), s.signer, s.method, m)
+}
+
+// authenticate checks the bearer JWT in the incoming metadata or, without one,
+// the API key. A caller with a valid token skips the key check, as over http
+func authenticate(ctx context.Context, opts Options) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	token, bearer := strings.CutPrefix(firstValue(md, authorizationMetadata), "Bearer ")
+	if opts.Tokens != nil && bearer && strings.Count(token, ".") == 2 {
+		claims, err := opts.Tokens.Verify(ctx, token)
+		if err != nil {
+			slog.WarnContext(ctx, "Rejected bearer token", "error", err)
+			return ctx, status.Error(codes.Unauthenticated, "invalid bearer token")
+		}
+		if !claims.HasRole(auth.RoleReader) {
+			return ctx, status.Error(codes.PermissionDenied, "token lacks the "+auth.RoleReader+" role")
+		}
+		return auth.WithClaims(ctx, claims), nil
+	}
+	if opts.KeyAuth == nil {
+		return ctx, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	client, wait, err := opts.KeyAuth.Authenticate(firstValue(md, apiKeyMetadata))
+	if client == nil {
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		// same hint the HTTP API gives in Retry-After
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(wait.Seconds())+1)))
+		return ctx, status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	return auth.WithClient(ctx, client), nil
+}
+
+// firstValue returns md's first value for key, empty when there is none
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func authUnaryInterceptor(opts Options) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authStreamInterceptor(opts Options) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), opts)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// wrappedStream swaps in a context carrying the request id or authenticated client
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
+
+// withRequestID reuses the caller's x-request-id when sane, otherwise generates
+// one, and sends it back in the response header
+func withRequestID(ctx context.Context) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDMetadata); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if !logging.ValidRequestID(requestID) {
+		requestID = logging.NewRequestID()
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, requestID))
+	return logging.WithRequestID(ctx, requestID)
+}
+
+func requestIDUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withRequestID(ctx), req)
+}
+
+func requestIDStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &wrappedStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+}
+
+func loggingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	slog.InfoContext(ctx, "gRPC request", "method", info.FullMethod,
+		"status", status.Code(err).String(), "latency_ms", time.Since(start).Milliseconds())
+	return resp, err
+}
+
+func loggingStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	slog.InfoContext(ss.Context(), "gRPC stream", "method", info.FullMethod,
+		"status", status.Code(err).String(), "latency_ms", time.Since(start).Milliseconds())
+	return err
+}
+
+func recoveryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "Panic recovered", "method", info.FullMethod, "panic", r)
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func recoveryStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ss.Context(), "Panic recovered", "method", info.FullMethod, "panic", r)
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+	return handler(srv, ss)
+}
@@ -0,0 +1,296 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/audit"
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/grpcserver/exchangepb"
+	"exchange-rate-service/internal/models"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// stream tuning
+const (
+	defaultStreamInterval = 60 * time.Second
+	minStreamInterval     = 5 * time.Second
+	maxStreamPairs        = 50
+)
+
+// CurrencyExchangeService is what the gRPC API needs from the service layer -
+// the same methods the HTTP handlers use
+type CurrencyExchangeService interface {
+	ConvertCurrencyAmount(ctx context.Context, fromCurrency, toCurrency string, amount decimal.Decimal, dateStr string) (models.ConversionResult, error)
+	GetHistoricalExchangeRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (models.RateQuote, error)
+	GetDeprecationNotices(codes ...string) []models.DeprecationNotice
+}
+
+// ConversionAuditor receives an entry for every Convert call - implemented by audit.Logger
+type ConversionAuditor interface {
+	Record(entry audit.Entry)
+}
+
+// Server implements exchangepb.ExchangeServiceServer on top of the exchange service
+type Server struct {
+	exchangepb.UnimplementedExchangeServiceServer
+
+	currencyService CurrencyExchangeService
+	auditor         ConversionAuditor // nil when auditing is off
+}
+
+// NewServer creates the gRPC service implementation
+func NewServer(currencyService CurrencyExchangeService) *Server {
+	return &Server{currencyService: currencyService}
+}
+
+// SetAuditor records every conversion to auditor from now on
+func (s *Server) SetAuditor(auditor ConversionAuditor) {
+	s.auditor = auditor
+}
+
+// Convert converts an amount, mirroring GET /convert
+func (s *Server) Convert(ctx context.Context, req *exchangepb.ConvertRequest) (*exchangepb.ConvertResponse, error) {
+	if err := requirePair(req.GetFrom(), req.GetTo()); err != nil {
+		return nil, err
+	}
+	if req.GetAmount() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing required field: amount")
+	}
+
+	amount, err := currency.ParseAmount(req.GetAmount(), req.GetFrom(), config.GetMinorUnits(req.GetFrom()))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "amount "+err.Error())
+	}
+
+	conversion, err := s.currencyService.ConvertCurrencyAmount(ctx, req.GetFrom(), req.GetTo(), amount, req.GetDate())
+	if s.auditor != nil {
+		entry := audit.NewEntry(ctx, "grpc "+exchangepb.ExchangeService_Convert_FullMethodName)
+		entry.SetConversion(req.GetFrom(), req.GetTo(), amount, req.GetDate(), conversion, err)
+		s.auditor.Record(entry)
+	}
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	resp := &exchangepb.ConvertResponse{
+		Amount:   conversion.Amount.String(),
+		Rate:     conversion.Quote.Rate,
+		Warnings: s.deprecationWarnings(req.GetFrom(), req.GetTo()),
+	}
+	resp.Stale, resp.LastUpdated = staleness(conversion.Quote)
+
+	return resp, nil
+}
+
+// GetLatestRate mirrors GET /rate/latest
+func (s *Server) GetLatestRate(ctx context.Context, req *exchangepb.LatestRateRequest) (*exchangepb.RateResponse, error) {
+	if err := requirePair(req.GetFrom(), req.GetTo()); err != nil {
+		return nil, err
+	}
+
+	return s.latestRate(ctx, req.GetFrom(), req.GetTo())
+}
+
+// GetHistoricalRate mirrors GET /rate/historical
+func (s *Server) GetHistoricalRate(ctx context.Context, req *exchangepb.HistoricalRateRequest) (*exchangepb.RateResponse, error) {
+	if err := requirePair(req.GetFrom(), req.GetTo()); err != nil {
+		return nil, err
+	}
+	if req.GetDate() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing required field: date")
+	}
+
+	quote, err := s.currencyService.GetHistoricalExchangeRate(ctx, req.GetFrom(), req.GetTo(), req.GetDate())
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	// date is the day the rate is for - earlier than requested after a weekend/holiday fallback
+	date := quote.Date
+	if date == "" {
+		date = req.GetDate()
+	}
+
+	return &exchangepb.RateResponse{
+		From:     req.GetFrom(),
+		To:       req.GetTo(),
+		Rate:     quote.Rate,
+		Date:     date,
+		Warnings: s.deprecationWarnings(req.GetFrom(), req.GetTo()),
+	}, nil
+}
+
+// StreamRates sends the latest rate of every requested pair, then re-checks
+// on each interval and only sends the pairs whose rate changed
+func (s *Server) StreamRates(req *exchangepb.StreamRatesRequest, stream exchangepb.ExchangeService_StreamRatesServer) error {
+	pairs := req.GetPairs()
+	if len(pairs) == 0 {
+		return status.Error(codes.InvalidArgument, "at least one currency pair is required")
+	}
+	if len(pairs) > maxStreamPairs {
+		return status.Errorf(codes.InvalidArgument, "too many pairs, maximum %d allowed", maxStreamPairs)
+	}
+	for _, pair := range pairs {
+		if err := requirePair(pair.GetFrom(), pair.GetTo()); err != nil {
+			return err
+		}
+	}
+
+	interval := defaultStreamInterval
+	if req.GetIntervalSeconds() > 0 {
+		interval = time.Duration(req.GetIntervalSeconds()) * time.Second
+	}
+	if interval < minStreamInterval {
+		interval = minStreamInterval
+	}
+
+	lastSent := make(map[string]float64, len(pairs))
+	first := true
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, pair := range pairs {
+			rate, err := s.latestRate(stream.Context(), pair.GetFrom(), pair.GetTo())
+			if err != nil {
+				// bad pairs fail the stream up front, later upstream hiccups just skip a tick
+				if first {
+					return err
+				}
+				slog.WarnContext(stream.Context(), "Rate stream skipping pair this tick",
+					"pair", pair.GetFrom()+"-"+pair.GetTo(), "error", err)
+				continue
+			}
+
+			key := pair.GetFrom() + "-" + pair.GetTo()
+			if previous, sent := lastSent[key]; sent && previous == rate.Rate {
+				continue
+			}
+			if err := stream.Send(rate); err != nil {
+				return err
+			}
+			lastSent[key] = rate.Rate
+		}
+		first = false
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// latestRate converts one unit and reports the quote (the amount is rounded to minor units)
+func (s *Server) latestRate(ctx context.Context, from, to string) (*exchangepb.RateResponse, error) {
+	conversion, err := s.currencyService.ConvertCurrencyAmount(ctx, from, to, decimal.NewFromInt(1), "")
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	resp := &exchangepb.RateResponse{
+		From:     from,
+		To:       to,
+		Rate:     conversion.Quote.Rate,
+		Date:     "latest",
+		Warnings: s.deprecationWarnings(from, to),
+	}
+	resp.Stale, resp.LastUpdated = staleness(conversion.Quote)
+
+	return resp, nil
+}
+
+// deprecationWarnings builds the same messages the HTTP API puts in "warnings"
+func (s *Server) deprecationWarnings(codes ...string) []string {
+	notices := s.currencyService.GetDeprecationNotices(codes...)
+	if len(notices) == 0 {
+		return nil
+	}
+
+	warnings := make([]string, 0, len(notices))
+	for _, notice := range notices {
+		warnings = append(warnings, fmt.Sprintf("currency %s is deprecated and will be removed on %s", notice.Code, notice.SunsetDate.Format("2006-01-02")))
+	}
+	return warnings
+}
+
+// staleness returns the stale flag and last update time for degraded quotes
+func staleness(quote models.RateQuote) (bool, *timestamppb.Timestamp) {
+	if !quote.Stale {
+		return false, nil
+	}
+	return true, timestamppb.New(quote.LastUpdated)
+}
+
+// requirePair checks both currency fields are present
+func requirePair(from, to string) error {
+	if strings.TrimSpace(from) == "" {
+		return status.Error(codes.InvalidArgument, "missing required field: from")
+	}
+	if strings.TrimSpace(to) == "" {
+		return status.Error(codes.InvalidArgument, "missing required field: to")
+	}
+	return nil
+}
+
+// statusFromError maps service errors to gRPC codes by their apperrors code.
+// The stable code string goes in the status message prefix so clients can
+// switch on it the same way they do on the HTTP "code" field
+func statusFromError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		slog.Error("gRPC internal error", "error", err)
+		return status.Errorf(codes.Internal, "%s: internal server error", apperrors.CodeInternal)
+	}
+
+	msg := err.Error()
+	grpcCode := codes.Internal
+	switch appErr.Code {
+	case apperrors.CodeInvalidRequest, apperrors.CodeUnsupportedCurrency, apperrors.CodeInvalidAmount,
+		apperrors.CodeInvalidDate, apperrors.CodeDateOutOfRange:
+		grpcCode = codes.InvalidArgument
+	case apperrors.CodeCurrencySunset:
+		grpcCode = codes.FailedPrecondition
+	case apperrors.CodePairUnavailable:
+		grpcCode = codes.NotFound
+		slog.Info("gRPC pair unavailable", "error", err)
+		msg = appErr.Message
+	case apperrors.CodeReadOnlyReplica:
+		grpcCode = codes.Unavailable
+		msg = "rate not available on read-only replica"
+	case apperrors.CodeUpstreamUnavailable:
+		grpcCode = codes.Unavailable
+		slog.Warn("gRPC upstream failure", "error", err)
+		msg = "exchange rate service temporarily unavailable"
+	case apperrors.CodeUpstreamRejected:
+		grpcCode = codes.Unavailable
+		slog.Error("gRPC provider rejected our request", "error", err)
+		msg = appErr.Message + " (" + appErr.Upstream + ")"
+	case apperrors.CodeRateLimited:
+		grpcCode = codes.ResourceExhausted
+	}
+
+	return status.Errorf(grpcCode, "%s: %s", appErr.Code, msg)
+}
+
+// Register adds the exchange service to a grpc server
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	exchangepb.RegisterExchangeServiceServer(registrar, s)
+}
@@ -0,0 +1,431 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/audit"
+	"exchange-rate-service/internal/auth"
+	"exchange-rate-service/internal/grpcserver/exchangepb"
+	"exchange-rate-service/internal/middleware"
+	"exchange-rate-service/internal/models"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeService answers from a fixed rate table
+type fakeService struct {
+	rates map[string]float64
+	stale bool
+}
+
+func (f *fakeService) ConvertCurrencyAmount(ctx context.Context, from, to string, amount decimal.Decimal, date string) (models.ConversionResult, error) {
+	rate, ok := f.rates[from+to]
+	if !ok {
+		return models.ConversionResult{}, apperrors.New(apperrors.CodeUnsupportedCurrency, "unsupported target currency: %s", to)
+	}
+	quote := models.RateQuote{Rate: rate, Stale: f.stale}
+	if f.stale {
+		quote.LastUpdated = time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	}
+	return models.ConversionResult{Amount: amount.Mul(decimal.NewFromFloat(rate)).Round(2), Quote: quote}, nil
+}
+
+func (f *fakeService) GetHistoricalExchangeRate(ctx context.Context, from, to, date string) (models.RateQuote, error) {
+	return models.RateQuote{}, apperrors.Wrap(apperrors.CodeUpstreamUnavailable, errors.New("api request failed"), "failed to fetch historical rate")
+}
+
+func (f *fakeService) GetDeprecationNotices(codes ...string) []models.DeprecationNotice {
+	return nil
+}
+
+// dial starts the server on an in-memory listener and returns a client
+func dial(t *testing.T, svc CurrencyExchangeService, opts Options) exchangepb.ExchangeServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	srv := NewGRPCServer(svc, opts)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return exchangepb.NewExchangeServiceClient(conn)
+}
+
+func TestConvert(t *testing.T) {
+	client := dial(t, &fakeService{rates: map[string]float64{"USDEUR": 0.85}}, Options{})
+	ctx := context.Background()
+
+	resp, err := client.Convert(ctx, &exchangepb.ConvertRequest{From: "USD", To: "EUR", Amount: "100.10"})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if resp.Amount != "85.09" || resp.Rate != 0.85 {
+		t.Errorf("unexpected response: amount %s rate %v", resp.Amount, resp.Rate)
+	}
+
+	_, err = client.Convert(ctx, &exchangepb.ConvertRequest{From: "USD", To: "EUR", Amount: "abc"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("bad amount should be InvalidArgument, got %v", err)
+	}
+
+	_, err = client.Convert(ctx, &exchangepb.ConvertRequest{From: "USD", To: "XYZ", Amount: "1"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unsupported currency should be InvalidArgument, got %v", err)
+	}
+}
+
+// recordingAuditor keeps every audit entry
+type recordingAuditor struct {
+	mu      sync.Mutex
+	entries []audit.Entry
+}
+
+func (a *recordingAuditor) Record(entry audit.Entry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+}
+
+func TestConvertIsAudited(t *testing.T) {
+	auditor := &recordingAuditor{}
+	client := dial(t, &fakeService{rates: map[string]float64{"USDEUR": 0.85}}, Options{Auditor: auditor})
+
+	client.Convert(context.Background(), &exchangepb.ConvertRequest{From: "USD", To: "EUR", Amount: "100.10"})
+	client.Convert(context.Background(), &exchangepb.ConvertRequest{From: "USD", To: "XYZ", Amount: "1"})
+	// never reaches the service, so there is nothing to audit
+	client.Convert(context.Background(), &exchangepb.ConvertRequest{From: "USD", To: "EUR", Amount: "abc"})
+
+	auditor.mu.Lock()
+	defer auditor.mu.Unlock()
+	if len(auditor.entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %+v", auditor.entries)
+	}
+	ok, failed := auditor.entries[0], auditor.entries[1]
+	if ok.Endpoint != "grpc /exchange.v1.ExchangeService/Convert" || ok.Status != "success" || ok.Result != "85.09" || ok.Amount != "100.1" {
+		t.Errorf("unexpected entry for the conversion: %+v", ok)
+	}
+	if failed.Status != "error" || failed.ErrorCode != "unsupported_currency" || failed.To != "XYZ" {
+		t.Errorf("unexpected entry for the failed conversion: %+v", failed)
+	}
+}
+
+func TestGetLatestRateStale(t *testing.T) {
+	client := dial(t, &fakeService{rates: map[string]float64{"USDEUR": 0.85}, stale: true}, Options{})
+
+	resp, err := client.GetLatestRate(context.Background(), &exchangepb.LatestRateRequest{From: "USD", To: "EUR"})
+	if err != nil {
+		t.Fatalf("GetLatestRate failed: %v", err)
+	}
+	if !resp.Stale || resp.LastUpdated == nil {
+		t.Errorf("stale quote should carry stale flag and last_updated, got %+v", resp)
+	}
+	if resp.Date != "latest" {
+		t.Errorf("date should be latest, got %s", resp.Date)
+	}
+}
+
+func TestGetHistoricalRateUnavailable(t *testing.T) {
+	client := dial(t, &fakeService{}, Options{})
+
+	_, err := client.GetHistoricalRate(context.Background(), &exchangepb.HistoricalRateRequest{From: "USD", To: "EUR", Date: "2025-08-01"})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("upstream failure should be Unavailable, got %v", err)
+	}
+}
+
+func TestStreamRates(t *testing.T) {
+	client := dial(t, &fakeService{rates: map[string]float64{"USDEUR": 0.85, "USDINR": 87.5}}, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.StreamRates(ctx, &exchangepb.StreamRatesRequest{Pairs: []*exchangepb.CurrencyPair{
+		{From: "USD", To: "EUR"},
+		{From: "USD", To: "INR"},
+	}})
+	if err != nil {
+		t.Fatalf("StreamRates failed: %v", err)
+	}
+
+	// the first round sends every pair straight away
+	got := make(map[string]float64)
+	for i := 0; i < 2; i++ {
+		rate, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		got[rate.From+rate.To] = rate.Rate
+	}
+	if got["USDEUR"] != 0.85 || got["USDINR"] != 87.5 {
+		t.Errorf("unexpected initial rates: %v", got)
+	}
+}
+
+func TestStreamRatesRejectsEmptyRequest(t *testing.T) {
+	client := dial(t, &fakeService{}, Options{})
+
+	stream, err := client.StreamRates(context.Background(), &exchangepb.StreamRatesRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("empty pair list should be InvalidArgument, got %v", err)
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	store, err := auth.LoadKeyStore([]string{"good-key:1"}, "", 60)
+	if err != nil {
+		t.Fatalf("LoadKeyStore failed: %v", err)
+	}
+	client := dial(t, &fakeService{rates: map[string]float64{"USDEUR": 0.85}}, Options{KeyAuth: middleware.NewAPIKeyAuth(store)})
+	req := &exchangepb.LatestRateRequest{From: "USD", To: "EUR"}
+
+	if _, err := client.GetLatestRate(context.Background(), req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("missing key should be Unauthenticated, got %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, "good-key")
+	if _, err := client.GetLatestRate(ctx, req); err != nil {
+		t.Fatalf("valid key should pass, got %v", err)
+	}
+	if _, err := client.GetLatestRate(ctx, req); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("over-budget key should be ResourceExhausted, got %v", err)
+	}
+}
+
+// testJWT signs an HS256 token carrying roles
+func testJWT(t *testing.T, secret string, roles ...string) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"sub":   "dashboard",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": roles,
+	})
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTAuthWithoutAPIKeys(t *testing.T) {
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{Secret: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := dial(t, &fakeService{rates: map[string]float64{"USDEUR": 0.85}}, Options{Tokens: verifier})
+	req := &exchangepb.LatestRateRequest{From: "USD", To: "EUR"}
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), authorizationMetadata, "Bearer "+token)
+	}
+
+	if _, err := client.GetLatestRate(context.Background(), req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("missing token should be Unauthenticated, got %v", err)
+	}
+	if _, err := client.GetLatestRate(withToken(testJWT(t, "wrong", "reader")), req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("badly signed token should be Unauthenticated, got %v", err)
+	}
+	if _, err := client.GetLatestRate(withToken(testJWT(t, "s3cret")), req); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("token without the reader role should be PermissionDenied, got %v", err)
+	}
+	if _, err := client.GetLatestRate(withToken(testJWT(t, "s3cret", "reader")), req); err != nil {
+		t.Errorf("reader token should pass, got %v", err)
+	}
+
+	// streams are guarded too
+	stream, err := client.StreamRates(context.Background(), &exchangepb.StreamRatesRequest{Pairs: []*exchangepb.CurrencyPair{{From: "USD", To: "EUR"}}})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("stream without a token should be Unauthenticated, got %v", err)
+	}
+}
+
+// dialTCP is dial over loopback, so calls have a peer address the IP lists can check
+func dialTCP(t *testing.T, svc CurrencyExchangeService, opts Options) exchangepb.ExchangeServiceClient {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	srv := NewGRPCServer(svc, opts)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return exchangepb.NewExchangeServiceClient(conn)
+}
+
+func TestIPAccessControl(t *testing.T) {
+	svc := &fakeService{rates: map[string]float64{"USDEUR": 0.85}}
+	req := &exchangepb.LatestRateRequest{From: "USD", To: "EUR"}
+
+	allowed, err := middleware.NewIPAccessControl([]string{"127.0.0.1"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dialTCP(t, svc, Options{Access: allowed}).GetLatestRate(context.Background(), req); err != nil {
+		t.Errorf("allowlisted peer should pass, got %v", err)
+	}
+
+	denied, err := middleware.NewIPAccessControl(nil, []string{"127.0.0.0/8"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := dialTCP(t, svc, Options{Access: denied})
+	if _, err := client.GetLatestRate(context.Background(), req); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("denylisted peer should be PermissionDenied, got %v", err)
+	}
+	stream, err := client.StreamRates(context.Background(), &exchangepb.StreamRatesRequest{Pairs: []*exchangepb.CurrencyPair{{From: "USD", To: "EUR"}}})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("denylisted stream should be PermissionDenied, got %v", err)
+	}
+
+	// forwarded addresses only count from trusted proxies
+	behindProxy, err := middleware.NewIPAccessControl([]string{"10.0.0.7"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), forwardedForMetadata, "10.0.0.7")
+	if _, err := dialTCP(t, svc, Options{Access: behindProxy}).GetLatestRate(ctx, req); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("x-forwarded-for from an untrusted peer should be ignored, got %v", err)
+	}
+	trusted, err := middleware.NewIPAccessControl([]string{"10.0.0.7"}, nil, []string{"127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dialTCP(t, svc, Options{Access: trusted}).GetLatestRate(ctx, req); err != nil {
+		t.Errorf("x-forwarded-for from a trusted proxy should count, got %v", err)
+	}
+	// a second value added by the proxy wins over the client's own
+	ctx = metadata.AppendToOutgoingContext(ctx, forwardedForMetadata, "203.0.113.7")
+	if _, err := dialTCP(t, svc, Options{Access: trusted}).GetLatestRate(ctx, req); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("every x-forwarded-for value should be read, got %v", err)
+	}
+}
+
+// signCall adds the signing metadata for req sent to method
+func signCall(t *testing.T, keyID, secret, method string, req proto.Message, signedAt time.Time) context.Context {
+	t.Helper()
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	signature := middleware.ComputeSignature(secret, signedMethod, method, timestamp, body)
+	return metadata.AppendToOutgoingContext(context.Background(),
+		signatureKeyIDMetadata, keyID,
+		signatureTimestampMetadata, timestamp,
+		signatureMetadata, hex.EncodeToString(signature))
+}
+
+func TestRequestSigning(t *testing.T) {
+	signer := middleware.NewRequestSigner(map[string]string{"partner": "s3cret"}, true, 5*time.Minute)
+	client := dial(t, &fakeService{rates: map[string]float64{"USDEUR": 0.85}}, Options{Signer: signer})
+	req := &exchangepb.LatestRateRequest{From: "USD", To: "EUR"}
+	method := exchangepb.ExchangeService_GetLatestRate_FullMethodName
+
+	if _, err := client.GetLatestRate(context.Background(), req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("unsigned call should be Unauthenticated when signing is required, got %v", err)
+	}
+
+	ctx := signCall(t, "partner", "s3cret", method, req, time.Now())
+	if _, err := client.GetLatestRate(ctx, req); err != nil {
+		t.Fatalf("signed call should pass, got %v", err)
+	}
+	if _, err := client.GetLatestRate(ctx, req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("replayed signature should be Unauthenticated, got %v", err)
+	}
+
+	// signed for one message, sent with another
+	ctx = signCall(t, "partner", "s3cret", method, req, time.Now().Add(-time.Second))
+	if _, err := client.GetLatestRate(ctx, &exchangepb.LatestRateRequest{From: "USD", To: "INR"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("tampered request should be Unauthenticated, got %v", err)
+	}
+	ctx = signCall(t, "partner", "wrong", method, req, time.Now())
+	if _, err := client.GetLatestRate(ctx, req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("wrong secret should be Unauthenticated, got %v", err)
+	}
+
+	// streams are verified against their request message
+	streamReq := &exchangepb.StreamRatesRequest{Pairs: []*exchangepb.CurrencyPair{{From: "USD", To: "EUR"}}}
+	streamCtx, cancel := context.WithCancel(signCall(t, "partner", "s3cret", exchangepb.ExchangeService_StreamRates_FullMethodName, streamReq, time.Now()))
+	defer cancel()
+	stream, err := client.StreamRates(streamCtx, streamReq)
+	if err != nil {
+		t.Fatalf("StreamRates failed: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Errorf("signed stream should pass, got %v", err)
+	}
+
+	badStream, err := client.StreamRates(signCall(t, "partner", "wrong", exchangepb.ExchangeService_StreamRates_FullMethodName, streamReq, time.Now()), streamReq)
+	if err == nil {
+		_, err = badStream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("badly signed stream should be Unauthenticated, got %v", err)
+	}
+}
+
+func TestRequestIDMetadata(t *testing.T) {
+	client := dial(t, &fakeService{rates: map[string]float64{"USDEUR": 0.85}}, Options{})
+	req := &exchangepb.LatestRateRequest{From: "USD", To: "EUR"}
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestIDMetadata, "trace-7")
+	if _, err := client.GetLatestRate(ctx, req, grpc.Header(&header)); err != nil {
+		t.Fatalf("GetLatestRate failed: %v", err)
+	}
+	if got := header.Get(requestIDMetadata); len(got) != 1 || got[0] != "trace-7" {
+		t.Errorf("expected caller request id echoed back, got %v", got)
+	}
+
+	header = nil
+	if _, err := client.GetLatestRate(context.Background(), req, grpc.Header(&header)); err != nil {
+		t.Fatalf("GetLatestRate failed: %v", err)
+	}
+	if got := header.Get(requestIDMetadata); len(got) != 1 || got[0] == "" {
+		t.Errorf("expected a generated request id, got %v", got)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// CacheAdmin defines the cache operations exposed to operators
+type CacheAdmin interface {
+	GetCacheStats() map[string]interface{}
+	TriggerRefresh() bool
+	DeleteRate(fromCurrency, toCurrency string) (bool, error)
+}
+
+// AdminHandler serves the /admin operator endpoints
+type AdminHandler struct {
+	cache CacheAdmin
+}
+
+// NewAdminHandler creates an admin handler
+func NewAdminHandler(cache CacheAdmin) *AdminHandler {
+	return &AdminHandler{cache: cache}
+}
+
+// CacheStats handles GET /admin/cache/stats
+func (h *AdminHandler) CacheStats(w http.ResponseWriter, r *http.Request) {
+	utils.SendSuccessResponse(w, h.cache.GetCacheStats())
+}
+
+// RefreshCache handles POST /admin/cache/refresh - the cycle runs in the background
+func (h *AdminHandler) RefreshCache(w http.ResponseWriter, r *http.Request) {
+	if !h.cache.TriggerRefresh() {
+		utils.ErrorResp(w, http.StatusConflict, "a refresh is already running")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, map[string]interface{}{
+		"status":  "success",
+		"message": "refresh started",
+	})
+}
+
+// EvictPair handles DELETE /admin/cache/{pair} where pair is FROM-TO (e.g. USD-EUR)
+func (h *AdminHandler) EvictPair(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := strings.Cut(mux.Vars(r)["pair"], "-")
+	if !ok || !currency.ValidCode(from) || !currency.ValidCode(to) {
+		utils.ErrorResp(w, http.StatusBadRequest, "pair must look like USD-EUR")
+		return
+	}
+
+	deleted, err := h.cache.DeleteRate(from, to)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Cache eviction failed", "pair", from+"-"+to, "error", err)
+		utils.ErrorResp(w, http.StatusServiceUnavailable, "cache backend unavailable")
+		return
+	}
+	if !deleted {
+		utils.ErrorResp(w, http.StatusNotFound, "pair not cached: "+strings.ToUpper(from+"-"+to))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"exchange-rate-service/internal/alerts"
+	"exchange-rate-service/internal/auth"
+	"exchange-rate-service/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// max size of an alert request body
+const maxAlertBodyBytes = 16 << 10
+
+// AlertManager defines what the alert handler needs from the alerts package
+type AlertManager interface {
+	Create(owner string, req alerts.AlertRequest) (alerts.Alert, error)
+	Update(owner, id string, req alerts.AlertRequest) (alerts.Alert, error)
+	Get(owner, id string) (alerts.Alert, error)
+	List(owner string) []alerts.Alert
+	Delete(owner, id string) error
+}
+
+// AlertHandler serves the /alerts CRUD endpoints
+type AlertHandler struct {
+	manager AlertManager
+}
+
+// NewAlertHandler creates an alert handler
+func NewAlertHandler(manager AlertManager) *AlertHandler {
+	return &AlertHandler{manager: manager}
+}
+
+// Create handles POST /alerts
+func (h *AlertHandler) Create(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAlertRequest(w, r)
+	if !ok {
+		return
+	}
+
+	alert, err := h.manager.Create(alertOwner(r), req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/alerts/"+alert.ID)
+	utils.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"status": "success",
+		"data":   alert,
+	})
+}
+
+// List handles GET /alerts
+func (h *AlertHandler) List(w http.ResponseWriter, r *http.Request) {
+	list := h.manager.List(alertOwner(r))
+	utils.SendSuccessResponse(w, map[string]interface{}{
+		"alerts": list,
+		"count":  len(list),
+	})
+}
+
+// Get handles GET /alerts/{id}
+func (h *AlertHandler) Get(w http.ResponseWriter, r *http.Request) {
+	alert, err := h.manager.Get(alertOwner(r), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	utils.SendSuccessResponse(w, alert)
+}
+
+// Update handles PUT /alerts/{id}
+func (h *AlertHandler) Update(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAlertRequest(w, r)
+	if !ok {
+		return
+	}
+
+	alert, err := h.manager.Update(alertOwner(r), mux.Vars(r)["id"], req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	utils.SendSuccessResponse(w, alert)
+}
+
+// Delete handles DELETE /alerts/{id}
+func (h *AlertHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.manager.Delete(alertOwner(r), mux.Vars(r)["id"]); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// alertOwner scopes alerts to the calling API key or JWT subject (everyone
+// shares "" when auth is off)
+func alertOwner(r *http.Request) string {
+	if client, ok := auth.ClientFromContext(r.Context()); ok {
+		return client.Name
+	}
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+		return "jwt:" + claims.Subject
+	}
+	return ""
+}
+
+// decodeAlertRequest parses the JSON body, answering 400 itself on failure
+func decodeAlertRequest(w http.ResponseWriter, r *http.Request) (alerts.AlertRequest, bool) {
+	var req alerts.AlertRequest
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAlertBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.ErrorResp(w, http.StatusBadRequest, "invalid alert body: "+err.Error())
+		return alerts.AlertRequest{}, false
+	}
+
+	return req, true
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/utils"
+)
+
+// RateAnalyticsService defines what the analytics handler needs
+type RateAnalyticsService interface {
+	History(ctx context.Context, fromCurrency, toCurrency, startDate, endDate string) ([]models.RateRecord, error)
+	Summary(ctx context.Context, fromCurrency, toCurrency, startDate, endDate string) (models.RateSummary, error)
+}
+
+// AnalyticsHandler serves reports built from the local rate store
+type AnalyticsHandler struct {
+	analytics RateAnalyticsService
+}
+
+// NewAnalyticsHandler creates an analytics handler
+func NewAnalyticsHandler(analytics RateAnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analytics: analytics}
+}
+
+// History handles GET /analytics/history?from=USD&to=EUR&start=...&end=...
+func (h *AnalyticsHandler) History(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if !requirePair(w, q.Get("from"), q.Get("to")) {
+		return
+	}
+
+	records, err := h.analytics.History(r.Context(), q.Get("from"), q.Get("to"), q.Get("start"), q.Get("end"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	utils.SendSuccessResponse(w, map[string]interface{}{
+		"records": records,
+		"count":   len(records),
+	})
+}
+
+// Summary handles GET /analytics/summary?from=USD&to=EUR&start=...&end=...
+func (h *AnalyticsHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if !requirePair(w, q.Get("from"), q.Get("to")) {
+		return
+	}
+
+	summary, err := h.analytics.Summary(r.Context(), q.Get("from"), q.Get("to"), q.Get("start"), q.Get("end"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	utils.SendSuccessResponse(w, summary)
+}
+
+// requirePair answers 400 when from or to is missing
+func requirePair(w http.ResponseWriter, from, to string) bool {
+	if from == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: from")
+		return false
+	}
+	if to == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: to")
+		return false
+	}
+	return true
+}
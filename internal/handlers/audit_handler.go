@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"exchange-rate-service/internal/audit"
+	"exchange-rate-service/internal/utils"
+)
+
+// AuditLog is the searchable side of the conversion audit trail
+type AuditLog interface {
+	Query(ctx context.Context, filter audit.Filter) ([]audit.Entry, error)
+}
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditHandler serves GET /admin/audit
+type AuditHandler struct {
+	log AuditLog
+}
+
+// NewAuditHandler creates an audit handler
+func NewAuditHandler(log AuditLog) *AuditHandler {
+	return &AuditHandler{log: log}
+}
+
+// List handles GET /admin/audit?start=YYYY-MM-DD&end=YYYY-MM-DD&key=<client>&limit=100
+// Both days are inclusive (UTC); key is the API key's client name. Newest first.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := audit.Filter{Client: q.Get("key"), Limit: defaultAuditLimit}
+
+	if start := q.Get("start"); start != "" {
+		day, err := time.Parse("2006-01-02", start)
+		if err != nil {
+			utils.ErrorResp(w, http.StatusBadRequest, "invalid start date, expected YYYY-MM-DD")
+			return
+		}
+		filter.Start = day
+	}
+	if end := q.Get("end"); end != "" {
+		day, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			utils.ErrorResp(w, http.StatusBadRequest, "invalid end date, expected YYYY-MM-DD")
+			return
+		}
+		// inclusive - everything before the next midnight
+		filter.End = day.AddDate(0, 0, 1)
+	}
+	if !filter.Start.IsZero() && !filter.End.IsZero() && !filter.Start.Before(filter.End) {
+		utils.ErrorResp(w, http.StatusBadRequest, "start must not be after end")
+		return
+	}
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			utils.ErrorResp(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxAuditLimit))
+			return
+		}
+		filter.Limit = n
+	}
+
+	entries, err := h.log.Query(r.Context(), filter)
+	if errors.Is(err, audit.ErrNotQueryable) {
+		utils.ErrorResp(w, http.StatusNotImplemented, "the configured audit sink can't be queried, use AUDIT_SINK=file or sqlite")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Audit query failed", "error", err)
+		utils.ErrorResp(w, http.StatusServiceUnavailable, "audit log unavailable")
+		return
+	}
+
+	utils.SendSuccessResponse(w, map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"exchange-rate-service/internal/backfill"
+	"exchange-rate-service/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// max size of a backfill request body
+const maxBackfillBodyBytes = 16 << 10
+
+// BackfillManager runs historical backfill jobs
+type BackfillManager interface {
+	Submit(req backfill.Request) (backfill.Job, error)
+	Get(id string) (backfill.Job, error)
+	List() []backfill.Job
+	Cancel(id string) (backfill.Job, error)
+}
+
+// BackfillHandler serves the /admin/backfill endpoints
+type BackfillHandler struct {
+	manager BackfillManager
+}
+
+// NewBackfillHandler creates a backfill handler
+func NewBackfillHandler(manager BackfillManager) *BackfillHandler {
+	return &BackfillHandler{manager: manager}
+}
+
+// Start handles POST /admin/backfill - the job runs in the background, poll
+// the returned Location for progress
+func (h *BackfillHandler) Start(w http.ResponseWriter, r *http.Request) {
+	var req backfill.Request
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBackfillBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.ErrorResp(w, http.StatusBadRequest, "invalid backfill body: "+err.Error())
+		return
+	}
+
+	job, err := h.manager.Submit(req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/v1/admin/backfill/"+job.ID)
+	utils.WriteJSON(w, http.StatusAccepted, map[string]interface{}{
+		"status": "success",
+		"data":   job,
+	})
+}
+
+// List handles GET /admin/backfill
+func (h *BackfillHandler) List(w http.ResponseWriter, r *http.Request) {
+	jobs := h.manager.List()
+	utils.SendSuccessResponse(w, map[string]interface{}{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// Get handles GET /admin/backfill/{id}
+func (h *BackfillHandler) Get(w http.ResponseWriter, r *http.Request) {
+	job, err := h.manager.Get(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	utils.SendSuccessResponse(w, job)
+}
+
+// Cancel handles DELETE /admin/backfill/{id} - rates stored so far are kept
+func (h *BackfillHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	job, err := h.manager.Cancel(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	utils.SendSuccessResponse(w, job)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// max size of a currency request body
+const maxCurrencyBodyBytes = 4 << 10
+
+// CurrencyManager adds and removes supported currencies at runtime
+type CurrencyManager interface {
+	AddCurrency(ctx context.Context, code, name string) (currency.Currency, error)
+	RemoveCurrency(ctx context.Context, code string) error
+}
+
+// CurrencyAdminHandler serves the /admin/currencies endpoints
+type CurrencyAdminHandler struct {
+	manager CurrencyManager
+}
+
+// NewCurrencyAdminHandler creates a currency admin handler
+func NewCurrencyAdminHandler(manager CurrencyManager) *CurrencyAdminHandler {
+	return &CurrencyAdminHandler{manager: manager}
+}
+
+// addCurrencyRequest is the body of POST /admin/currencies
+type addCurrencyRequest struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// Add handles POST /admin/currencies - the currency is accepted right away
+// and refreshed from the next cycle on
+func (h *CurrencyAdminHandler) Add(w http.ResponseWriter, r *http.Request) {
+	var req addCurrencyRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCurrencyBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.ErrorResp(w, http.StatusBadRequest, "invalid currency body: "+err.Error())
+		return
+	}
+	if req.Code == "" {
+		utils.FieldErrorResp(w, "invalid request body", map[string]string{"code": "is required"})
+		return
+	}
+
+	added, err := h.manager.AddCurrency(r.Context(), req.Code, req.Name)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"status": "success",
+		"data":   added,
+	})
+}
+
+// Remove handles DELETE /admin/currencies/{code}
+func (h *CurrencyAdminHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	if err := h.manager.RemoveCurrency(r.Context(), mux.Vars(r)["code"]); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
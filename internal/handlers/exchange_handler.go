@@ -1,23 +1,71 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/audit"
+	"exchange-rate-service/internal/currency"
 	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/tenant"
 	"exchange-rate-service/internal/utils"
+
+	"github.com/shopspring/decimal"
 )
 
 // CurrencyExchangeService defines the interface for currency exchange operations
 // This interface allows us to keep the handler decoupled from the concrete service implementation
 type CurrencyExchangeService interface {
-	ConvertCurrencyAmount(fromCurrency, toCurrency string, amount float64, dateStr string) (float64, error)
-	GetHistoricalExchangeRate(fromCurrency, toCurrency, dateStr string) (float64, error)
+	ConvertCurrencyAmount(ctx context.Context, fromCurrency, toCurrency string, amount decimal.Decimal, dateStr string) (models.ConversionResult, error)
+	ConvertToMany(ctx context.Context, fromCurrency string, toCurrencies []string, amount decimal.Decimal, dateStr string) ([]models.TargetConversion, error)
+	ConvertAmounts(ctx context.Context, fromCurrency, toCurrency string, amounts []decimal.Decimal, dateStr string) ([]models.ConversionResult, error)
+	GetHistoricalExchangeRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (models.RateQuote, error)
+	GetHistoricalRateRange(ctx context.Context, fromCurrency, toCurrency, startDate, endDate string) (map[string]float64, error)
+	StreamHistoricalRateRange(ctx context.Context, fromCurrency, toCurrency, startDate, endDate string, emit func(date string, rate float64) error) error
+	MarketClosures(fromCurrency, toCurrency, startDate, endDate string) map[string]string
+	GetRateStats(ctx context.Context, fromCurrency, toCurrency, period string) (models.RateStats, error)
+	GetRateTrend(ctx context.Context, fromCurrency, toCurrency string) (models.RateTrend, error)
+	GetRateTable(ctx context.Context, base string) (models.RateTable, error)
+	FormatAmount(code string, amount decimal.Decimal, locale string) (models.FormattedAmount, error)
+	GetDeprecationNotices(codes ...string) []models.DeprecationNotice
+	ListCurrencies() []models.CurrencyInfo
+}
+
+// ConversionAuditor receives an entry for every conversion - one per target
+// or table row for the batch endpoints
+type ConversionAuditor interface {
+	Record(entry audit.Entry)
+}
+
+// CurrencyResolver turns a typed currency - symbol, name or code - into its ISO code
+type CurrencyResolver interface {
+	Resolve(input string) (string, error)
+}
+
+// ReceiptSigner signs conversions and checks the receipts it signed
+type ReceiptSigner interface {
+	Sign(conversion models.ConvertResponse) models.Receipt
+	Verify(receipt models.Receipt) error
 }
 
 // ExchangeHandler handles all HTTP requests related to currency exchange
 type ExchangeHandler struct {
 	currencyService CurrencyExchangeService
+	auditor         ConversionAuditor // nil when auditing is off
+	schedule        RefreshSchedule   // nil leaves rate responses uncacheable
+	resolver        CurrencyResolver  // nil takes codes only
+	receipts        ReceiptSigner     // nil when signed receipts are off
 }
 
 // NewExchangeHandler creates a new handler instance with the provided service
@@ -27,6 +75,22 @@ func NewExchangeHandler(currencyService CurrencyExchangeService) *ExchangeHandle
 	}
 }
 
+// SetAuditor records every conversion to auditor from now on
+func (h *ExchangeHandler) SetAuditor(auditor ConversionAuditor) {
+	h.auditor = auditor
+}
+
+// SetCurrencyResolver lets POST /convert bodies name currencies by symbol or
+// name, as the CurrencyInput middleware does for query strings
+func (h *ExchangeHandler) SetCurrencyResolver(resolver CurrencyResolver) {
+	h.resolver = resolver
+}
+
+// SetReceiptSigner lets conversions ask for a signed receipt with receipt=true
+func (h *ExchangeHandler) SetReceiptSigner(signer ReceiptSigner) {
+	h.receipts = signer
+}
+
 // Convert handles GET /convert requests
 func (h *ExchangeHandler) Convert(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
@@ -50,29 +114,507 @@ func (h *ExchangeHandler) Convert(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// parse amount
-	amount, err := strconv.ParseFloat(amountStr, 64)
+	// parse amount - decimal so we never lose precision on the way in
+	amount, err := parseAmount(amountStr, fromCurrency)
 	if err != nil {
-		utils.ErrorResp(w, http.StatusBadRequest, "invalid amount format")
+		utils.ErrorRespWithCode(w, http.StatusBadRequest, string(apperrors.CodeInvalidAmount), "amount "+err.Error())
 		return
 	}
 
 	// Optional date parameter
 	date := query.Get("date")
 
+	h.writeConversion(w, r, fromCurrency, toCurrency, amount, date, query.Get("locale"))
+}
+
+// ConvertBody handles POST /convert with a JSON body - same conversion as
+// GET /convert, but the amount arrives as a string and every field is checked
+// before anything is converted
+func (h *ExchangeHandler) ConvertBody(w http.ResponseWriter, r *http.Request) {
+	body, ok := readConvertBody(w, r)
+	if !ok {
+		return
+	}
+
+	req, fields := parseConvertRequest(body, h.resolver)
+	if len(fields) > 0 {
+		utils.FieldErrorResp(w, "invalid request body", fields)
+		return
+	}
+
+	// already validated as a plain decimal
+	amount, _ := decimal.NewFromString(req.Amount)
+	h.writeConversion(w, r, req.From, req.To, amount, req.Date, req.Locale)
+}
+
+// writeConversion converts amount and answers with the result in the negotiated
+// format - with the amount also written for locale when one is given
+func (h *ExchangeHandler) writeConversion(w http.ResponseWriter, r *http.Request, fromCurrency, toCurrency string, amount decimal.Decimal, date, locale string) {
+	withReceipt, err := h.wantsReceipt(r)
+	if err != nil {
+		utils.ErrorResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Call our currency service to perform the conversion
-	convertedAmount, err := h.currencyService.ConvertCurrencyAmount(fromCurrency, toCurrency, amount, date)
+	conversion, err := h.currencyService.ConvertCurrencyAmount(r.Context(), fromCurrency, toCurrency, amount, date)
+	h.audit(r, fromCurrency, toCurrency, amount, date, conversion, err)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
-	// Build response
+	// Build response - the applied rate and its provenance save clients a /rate/latest call
 	response := models.ConvertResponse{
-		Amount: convertedAmount,
+		From:           strings.ToUpper(fromCurrency),
+		To:             strings.ToUpper(toCurrency),
+		OriginalAmount: models.NewAmount(amount),
+		Amount:         models.NewAmount(conversion.Amount),
+		Rate:           conversion.Quote.Rate,
+		AppliedRate:    conversion.AppliedRate,
+		Fee:            models.NewAmount(conversion.Fee),
+		Date:           date,
+		Cached:         conversion.Quote.Cached,
+		Source:         conversion.Quote.Source,
+		Derived:        conversion.Quote.Derived,
+		Degraded:       conversion.Quote.Degraded,
+		Route:          conversion.Quote.Route,
+		Warnings:       h.applyDeprecationNotices(w, fromCurrency, toCurrency),
+	}
+	response.Stale, response.LastUpdated = h.applyStaleness(w, conversion.Quote)
+	if !response.Stale && !conversion.Quote.LastUpdated.IsZero() {
+		lastUpdated := conversion.Quote.LastUpdated.UTC()
+		response.LastUpdated = &lastUpdated
+	}
+	if locale != "" {
+		formatted, err := h.currencyService.FormatAmount(toCurrency, conversion.Amount, locale)
+		if err != nil {
+			h.handleServiceError(w, r, err)
+			return
+		}
+		response.Formatted = formatted.Formatted
+	}
+	if withReceipt {
+		receipt := h.receipts.Sign(response)
+		response.Receipt = &receipt
+	}
+
+	utils.WriteFormatted(w, http.StatusOK, utils.NegotiateFormat(r), response)
+}
+
+// wantsReceipt reports whether the conversion gets a signed receipt - asked for
+// with receipt=true, or always for tenants whose policy says so
+func (h *ExchangeHandler) wantsReceipt(r *http.Request) (bool, error) {
+	asked := false
+	if raw := r.URL.Query().Get("receipt"); raw != "" {
+		var err error
+		if asked, err = strconv.ParseBool(raw); err != nil {
+			return false, errors.New("receipt must be true or false")
+		}
+	}
+	if asked && h.receipts == nil {
+		return false, errors.New("signed receipts are not enabled on this service")
+	}
+
+	if policy, ok := tenant.FromContext(r.Context()); ok && policy.SignedReceipts {
+		asked = true
+	}
+	return asked && h.receipts != nil, nil
+}
+
+// audit records the outcome of a conversion when auditing is on
+func (h *ExchangeHandler) audit(r *http.Request, fromCurrency, toCurrency string, amount decimal.Decimal, date string, conversion models.ConversionResult, err error) {
+	if h.auditor == nil {
+		return
+	}
+
+	entry := newAuditEntry(r)
+	entry.SetConversion(fromCurrency, toCurrency, amount, date, conversion, err)
+	h.auditor.Record(entry)
+}
+
+// readConvertBody reads a conversion request body of at most
+// maxConvertBodyBytes, answering the error itself when it can't
+func readConvertBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxConvertBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.ErrorResp(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body too large (max %d bytes)", maxConvertBodyBytes))
+			return nil, false
+		}
+		utils.ErrorResp(w, http.StatusBadRequest, "could not read request body")
+		return nil, false
+	}
+	return body, true
+}
+
+// newAuditEntry starts a successful audit entry for r, with the caller from
+// its API key or JWT
+func newAuditEntry(r *http.Request) audit.Entry {
+	return audit.NewEntry(r.Context(), r.Method+" "+r.URL.Path)
+}
+
+// max size of a POST /convert body
+const maxConvertBodyBytes = 4 << 10
+
+// parseConvertRequest decodes a POST /convert body and validates every field,
+// returning a message per bad field (keyed by its JSON name) rather than
+// stopping at the first one. from and to go through resolver when there is one
+func parseConvertRequest(body []byte, resolver CurrencyResolver) (models.ConvertRequest, map[string]string) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil || raw == nil {
+		return models.ConvertRequest{}, map[string]string{"body": "must be a JSON object"}
+	}
+
+	fields := make(map[string]string)
+	values := make(map[string]string, len(raw))
+	for name, value := range raw {
+		switch name {
+		case "from", "to", "amount", "date", "locale":
+		default:
+			fields[name] = "unknown field"
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			// a JSON number amount would already have been through a float
+			fields[name] = "must be a string"
+			continue
+		}
+		values[name] = strings.TrimSpace(s)
+	}
+
+	if resolver != nil {
+		for _, name := range []string{"from", "to"} {
+			if values[name] == "" {
+				continue
+			}
+			code, err := resolver.Resolve(values[name])
+			if err != nil {
+				fields[name] = err.Error()
+				continue
+			}
+			values[name] = code
+		}
+	}
+
+	req := models.ConvertRequest{
+		From:   strings.ToUpper(values["from"]),
+		To:     strings.ToUpper(values["to"]),
+		Amount: values["amount"],
+		Date:   values["date"],
+		Locale: values["locale"],
+	}
+
+	for _, field := range []struct{ name, code string }{{"from", req.From}, {"to", req.To}} {
+		if _, bad := fields[field.name]; bad {
+			continue
+		}
+		if field.code == "" {
+			fields[field.name] = "is required"
+		} else if !isCurrencyCode(field.code) {
+			fields[field.name] = "must be a 3-letter currency code like USD"
+		}
+	}
+
+	if _, bad := fields["amount"]; !bad {
+		// precision depends on the source currency, so it's only checked for a valid one
+		maxDecimals := int32(-1)
+		if _, badFrom := fields["from"]; !badFrom {
+			maxDecimals = config.GetMinorUnits(req.From)
+		}
+		amount, err := currency.ParseAmount(req.Amount, req.From, maxDecimals)
+		switch {
+		case req.Amount == "":
+			fields["amount"] = "is required"
+		case err != nil:
+			fields["amount"] = err.Error()
+		case amount.IsNegative():
+			fields["amount"] = "must not be negative"
+		}
+	}
+
+	if _, bad := fields["date"]; !bad && req.Date != "" {
+		if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+			fields["date"] = "must be a date in YYYY-MM-DD format"
+		}
+	}
+
+	return req, fields
+}
+
+// parseAmount parses a query string amount of code, with at most the
+// currency's minor units of decimal places
+func parseAmount(raw, code string) (decimal.Decimal, error) {
+	return currency.ParseAmount(raw, code, config.GetMinorUnits(code))
+}
+
+// isCurrencyCode checks the shape of a code - whether we support it is the service's call
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// maxConvertTargets caps GET /convert/multi - each target may cost an upstream call
+const maxConvertTargets = 50
+
+// ConvertMulti handles GET /convert/multi?from=USD&to=EUR,INR,JPY&amount=100
+// Every target is converted even when some fail; failed ones carry their error
+// in the result. Only when all of them fail does the status say so.
+func (h *ExchangeHandler) ConvertMulti(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	fromCurrency := query.Get("from")
+	amountStr := query.Get("amount")
+
+	// de-duplicated, in the order asked for
+	targets := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, code := range strings.Split(query.Get("to"), ",") {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code != "" && !seen[code] {
+			seen[code] = true
+			targets = append(targets, code)
+		}
+	}
+
+	// check required params
+	if fromCurrency == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: from")
+		return
+	}
+	if len(targets) == 0 {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: to")
+		return
+	}
+	if len(targets) > maxConvertTargets {
+		utils.ErrorResp(w, http.StatusBadRequest, fmt.Sprintf("too many target currencies (max %d)", maxConvertTargets))
+		return
+	}
+	if amountStr == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: amount")
+		return
+	}
+
+	amount, err := parseAmount(amountStr, fromCurrency)
+	if err != nil {
+		utils.ErrorRespWithCode(w, http.StatusBadRequest, string(apperrors.CodeInvalidAmount), "amount "+err.Error())
+		return
+	}
+
+	date := query.Get("date")
+
+	conversions, err := h.currencyService.ConvertToMany(r.Context(), fromCurrency, targets, amount, date)
+	if err != nil {
+		for _, target := range targets {
+			h.audit(r, fromCurrency, target, amount, date, models.ConversionResult{}, err)
+		}
+		h.handleServiceError(w, r, err)
+		return
+	}
+	for _, conversion := range conversions {
+		h.audit(r, fromCurrency, conversion.To, amount, date, conversion.Result, conversion.Err)
+	}
+	if errors.Is(r.Context().Err(), context.Canceled) {
+		// client went away - nobody left to answer
+		slog.InfoContext(r.Context(), "Request canceled", "error", r.Context().Err())
+		return
+	}
+
+	response := models.MultiConvertResponse{
+		From:           strings.ToUpper(fromCurrency),
+		OriginalAmount: models.NewAmount(amount),
+		Date:           date,
+		Results:        make([]models.TargetResult, 0, len(conversions)),
+		Warnings:       h.applyDeprecationNotices(w, append([]string{fromCurrency}, targets...)...),
+	}
+
+	// partial success is still a success - only all targets failing takes the first failure's status
+	status := 0
+	for _, conversion := range conversions {
+		result := models.TargetResult{To: conversion.To}
+
+		if conversion.Err != nil {
+			var errStatus int
+			errStatus, result.Code, result.Error = describeServiceError(r, conversion.Err)
+			if status == 0 {
+				status = errStatus
+			}
+			response.Results = append(response.Results, result)
+			continue
+		}
+		status = http.StatusOK
+
+		quote := conversion.Result.Quote
+		converted, fee := models.NewAmount(conversion.Result.Amount), models.NewAmount(conversion.Result.Fee)
+		result.Amount = &converted
+		result.Rate = quote.Rate
+		result.AppliedRate = conversion.Result.AppliedRate
+		result.Fee = &fee
+		result.Cached = quote.Cached
+		result.Source = quote.Source
+		result.Stale = quote.Stale
+		result.Derived = quote.Derived
+		result.Degraded = quote.Degraded
+		result.Route = quote.Route
+		if !quote.LastUpdated.IsZero() {
+			lastUpdated := quote.LastUpdated.UTC()
+			result.LastUpdated = &lastUpdated
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	utils.WriteFormatted(w, status, utils.NegotiateFormat(r), response)
+}
+
+// maxTableRows caps GET /convert/table - rows are cheap, but not free to render
+const maxTableRows = 100
+
+// ConvertTable handles GET /convert/table?from=USD&to=INR&amounts=1,5,10 -
+// or min=10&max=100&step=10 for an evenly spaced range. Every row is
+// converted at one rate lookup, so a point-of-sale price list is one call
+func (h *ExchangeHandler) ConvertTable(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	fromCurrency := query.Get("from")
+	toCurrency := query.Get("to")
+
+	// check required params
+	if fromCurrency == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: from")
+		return
+	}
+	if toCurrency == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: to")
+		return
+	}
+
+	amounts, err := parseTableAmounts(query, fromCurrency)
+	if err != nil {
+		utils.ErrorRespWithCode(w, http.StatusBadRequest, string(apperrors.CodeInvalidAmount), err.Error())
+		return
+	}
+
+	date := query.Get("date")
+
+	conversions, err := h.currencyService.ConvertAmounts(r.Context(), fromCurrency, toCurrency, amounts, date)
+	for i, amount := range amounts {
+		var conversion models.ConversionResult
+		if err == nil {
+			conversion = conversions[i]
+		}
+		h.audit(r, fromCurrency, toCurrency, amount, date, conversion, err)
+	}
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	// every row shares the one quote
+	quote := conversions[0].Quote
+	response := models.ConversionTableResponse{
+		From:        strings.ToUpper(fromCurrency),
+		To:          strings.ToUpper(toCurrency),
+		Rate:        quote.Rate,
+		AppliedRate: conversions[0].AppliedRate,
+		Date:        date,
+		Cached:      quote.Cached,
+		Source:      quote.Source,
+		Derived:     quote.Derived,
+		Degraded:    quote.Degraded,
+		Route:       quote.Route,
+		Rows:        make([]models.ConversionRow, 0, len(conversions)),
+		Warnings:    h.applyDeprecationNotices(w, fromCurrency, toCurrency),
+	}
+	response.Stale, response.LastUpdated = h.applyStaleness(w, quote)
+	if !response.Stale && !quote.LastUpdated.IsZero() {
+		lastUpdated := quote.LastUpdated.UTC()
+		response.LastUpdated = &lastUpdated
+	}
+	for i, conversion := range conversions {
+		response.Rows = append(response.Rows, models.ConversionRow{
+			OriginalAmount: models.NewAmount(amounts[i]),
+			Amount:         models.NewAmount(conversion.Amount),
+			Fee:            models.NewAmount(conversion.Fee),
+		})
 	}
 
-	utils.WriteJSON(w, http.StatusOK, response)
+	utils.WriteFormatted(w, http.StatusOK, utils.NegotiateFormat(r), response)
+}
+
+// parseTableAmounts reads a conversion table's amounts - either listed in
+// amounts, or stepped from min to max inclusive - capped at maxTableRows
+func parseTableAmounts(query url.Values, code string) ([]decimal.Decimal, error) {
+	listed := query.Get("amounts")
+	minStr, maxStr, stepStr := query.Get("min"), query.Get("max"), query.Get("step")
+	ranged := minStr != "" || maxStr != "" || stepStr != ""
+
+	if listed != "" && ranged {
+		return nil, errors.New("use either amounts or min, max and step")
+	}
+
+	if listed != "" {
+		amounts := make([]decimal.Decimal, 0)
+		for _, raw := range strings.Split(listed, ",") {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				continue
+			}
+			amount, err := parseAmount(raw, code)
+			if err != nil {
+				return nil, fmt.Errorf("amount %q %w", raw, err)
+			}
+			amounts = append(amounts, amount)
+		}
+		if len(amounts) == 0 {
+			return nil, errors.New("missing required parameter: amounts")
+		}
+		if len(amounts) > maxTableRows {
+			return nil, fmt.Errorf("too many amounts (max %d)", maxTableRows)
+		}
+		return amounts, nil
+	}
+
+	if !ranged {
+		return nil, errors.New("missing required parameter: amounts (or min, max and step)")
+	}
+	bounds := make([]decimal.Decimal, 3)
+	for i, param := range []struct{ name, raw string }{{"min", minStr}, {"max", maxStr}, {"step", stepStr}} {
+		if param.raw == "" {
+			return nil, fmt.Errorf("missing required parameter: %s", param.name)
+		}
+		value, err := parseAmount(param.raw, code)
+		if err != nil {
+			return nil, fmt.Errorf("%s %w", param.name, err)
+		}
+		bounds[i] = value
+	}
+	minAmount, maxAmount, step := bounds[0], bounds[1], bounds[2]
+	if !step.IsPositive() {
+		return nil, errors.New("step must be greater than zero")
+	}
+	if maxAmount.LessThan(minAmount) {
+		return nil, errors.New("max must not be less than min")
+	}
+
+	// count the rows before building any, so a tiny step can't allocate a huge table
+	rows := maxAmount.Sub(minAmount).Div(step).Floor().IntPart() + 1
+	if rows > maxTableRows {
+		return nil, fmt.Errorf("too many amounts: %d (max %d)", rows, maxTableRows)
+	}
+	amounts := make([]decimal.Decimal, 0, rows)
+	for amount := minAmount; !amount.GreaterThan(maxAmount); amount = amount.Add(step) {
+		amounts = append(amounts, amount)
+	}
+	return amounts, nil
 }
 
 // latest rate endpoint
@@ -92,21 +634,40 @@ func (h *ExchangeHandler) GetLatestRate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// get rate by converting 1 unit
-	rate, err := h.currencyService.ConvertCurrencyAmount(from, to, 1.0, "")
+	// get rate by converting 1 unit - use the quote, the amount is rounded to minor units
+	conversion, err := h.currencyService.ConvertCurrencyAmount(r.Context(), from, to, decimal.NewFromInt(1), "")
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
 	resp := models.CurrencyRate{
-		From: from,
-		To:   to,
-		Rate: rate,
-		Date: "latest",
+		From:     from,
+		To:       to,
+		Rate:     conversion.Quote.Rate,
+		Date:     "latest",
+		Derived:  conversion.Quote.Derived,
+		Degraded: conversion.Quote.Degraded,
+		Route:    conversion.Quote.Route,
+		Warnings: h.applyDeprecationNotices(w, from, to),
 	}
+	resp.Stale, resp.LastUpdated = h.applyStaleness(w, conversion.Quote)
 
-	utils.WriteJSON(w, http.StatusOK, resp)
+	format := utils.NegotiateFormat(r)
+	if lastUpdated := conversion.Quote.LastUpdated; h.schedule != nil && !lastUpdated.IsZero() {
+		// fresh until the refresh loop replaces it; a stale rate could be replaced any moment
+		var maxAge time.Duration
+		if !resp.Stale {
+			maxAge = time.Until(lastUpdated.Add(h.schedule.RefreshInterval(from, to)))
+		}
+		etag := rateETag(strings.ToUpper(from), strings.ToUpper(to), lastUpdated.UTC().Format(time.RFC3339Nano),
+			strconv.FormatFloat(resp.Rate, 'g', -1, 64), string(format))
+		if writeCacheHeaders(w, r, etag, maxAge) {
+			return
+		}
+	}
+
+	utils.WriteFormatted(w, http.StatusOK, format, resp)
 }
 
 // historical rate handler
@@ -131,38 +692,330 @@ func (h *ExchangeHandler) GetHistoricalRate(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	rate, err := h.currencyService.GetHistoricalExchangeRate(from, to, dt)
+	quote, err := h.currencyService.GetHistoricalExchangeRate(r.Context(), from, to, dt)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
 	resp := models.CurrencyRate{
-		From: from,
-		To:   to,
-		Rate: rate,
-		Date: dt,
+		From:     from,
+		To:       to,
+		Rate:     quote.Rate,
+		Date:     dt,
+		Warnings: h.applyDeprecationNotices(w, from, to),
+	}
+	// no rate that day (weekend, holiday) - say whose rate this is
+	if quote.Date != "" && quote.Date != dt {
+		resp.EffectiveDate = quote.Date
+	}
+	resp.MarketClosed = quote.Closure
+	resp.Degraded = quote.Degraded
+	resp.Route = quote.Route
+
+	format := utils.NegotiateFormat(r)
+	if h.schedule != nil {
+		// today's rate can still be corrected, earlier days are settled
+		var maxAge time.Duration
+		if dt < time.Now().UTC().Format("2006-01-02") {
+			maxAge = historicalMaxAge
+		}
+		etag := rateETag(strings.ToUpper(from), strings.ToUpper(to), dt, quote.Date,
+			strconv.FormatFloat(resp.Rate, 'g', -1, 64), string(format))
+		if writeCacheHeaders(w, r, etag, maxAge) {
+			return
+		}
+	}
+
+	utils.WriteFormatted(w, http.StatusOK, format, resp)
+}
+
+// GetTimeSeries handles GET /rate/timeseries
+// Returns a date-keyed object by default, or streams points when ?stream=true
+// or NDJSON is requested so big exports are written as they are fetched
+// instead of built in memory. CSV and XML are written whole - one row per day
+// is small enough
+func (h *ExchangeHandler) GetTimeSeries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	from := q.Get("from")
+	to := q.Get("to")
+	start := q.Get("start")
+	end := q.Get("end")
+
+	// check params
+	if from == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: from")
+		return
+	}
+	if to == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: to")
+		return
+	}
+	if start == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: start")
+		return
+	}
+	if end == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: end")
+		return
+	}
+
+	format := utils.NegotiateFormat(r)
+	streamFormat := utils.NegotiateStreamFormat(r)
+	if format == utils.FormatJSON && (streamFormat == utils.StreamNDJSON || q.Get("stream") == "true") {
+		h.streamTimeSeries(w, r, from, to, start, end, streamFormat)
+		return
+	}
+
+	series, err := h.currencyService.GetHistoricalRateRange(r.Context(), from, to, start, end)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	warnings := h.applyDeprecationNotices(w, from, to)
+
+	resp := models.TimeSeriesResponse{
+		From:     from,
+		To:       to,
+		Start:    start,
+		End:      end,
+		Rates:    series,
+		Warnings: warnings,
+	}
+	if closures := h.currencyService.MarketClosures(from, to, start, end); len(closures) > 0 {
+		resp.ClosedDays = closures
+	}
+
+	utils.WriteFormatted(w, http.StatusOK, format, resp)
+}
+
+// streamTimeSeries writes date-ordered points as the service fetches them
+// A failure before the first point still gets a regular error response
+func (h *ExchangeHandler) streamTimeSeries(w http.ResponseWriter, r *http.Request, from, to, start, end string, format utils.StreamFormat) {
+	stream := utils.NewJSONStreamWriter(w, http.StatusOK, format)
+	err := h.currencyService.StreamHistoricalRateRange(r.Context(), from, to, start, end, func(date string, rate float64) error {
+		if !stream.Started() {
+			h.applyDeprecationNotices(w, from, to)
+		}
+		return stream.WriteItem(models.TimeSeriesPoint{Date: date, Rate: rate})
+	})
+	if err != nil {
+		if !stream.Started() {
+			h.handleServiceError(w, r, err)
+			return
+		}
+		// client probably went away - nothing useful left to send
+		slog.InfoContext(r.Context(), "Time series stream aborted", "items", stream.Count(), "error", err)
+		return
+	}
+	if !stream.Started() {
+		h.applyDeprecationNotices(w, from, to)
+	}
+	stream.Close()
+}
+
+// GetRateStats handles GET /rate/stats?from=USD&to=EUR&period=30d
+func (h *ExchangeHandler) GetRateStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if !requirePair(w, q.Get("from"), q.Get("to")) {
+		return
+	}
+
+	stats, err := h.currencyService.GetRateStats(r.Context(), q.Get("from"), q.Get("to"), q.Get("period"))
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	stats.Warnings = h.applyDeprecationNotices(w, q.Get("from"), q.Get("to"))
+	if stats.Stale {
+		w.Header().Add("Warning", `110 - "Response is Stale"`)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, stats)
+}
+
+// GetRateTrend handles GET /rate/trend?from=USD&to=EUR
+func (h *ExchangeHandler) GetRateTrend(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if !requirePair(w, q.Get("from"), q.Get("to")) {
+		return
+	}
+
+	trend, err := h.currencyService.GetRateTrend(r.Context(), q.Get("from"), q.Get("to"))
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	trend.Warnings = h.applyDeprecationNotices(w, q.Get("from"), q.Get("to"))
+	if trend.Stale {
+		w.Header().Add("Warning", `110 - "Response is Stale"`)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, trend)
+}
+
+// GetRateTable handles GET /rates?base=EUR - the latest rate from base into
+// every cached currency, in one call instead of one /rate/latest per currency.
+// base defaults to REFRESH_BASE_CURRENCY
+func (h *ExchangeHandler) GetRateTable(w http.ResponseWriter, r *http.Request) {
+	base := r.URL.Query().Get("base")
+	if base == "" {
+		base = config.RefreshBaseCurrency
+	}
+
+	table, err := h.currencyService.GetRateTable(r.Context(), base)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	table.Warnings = h.applyDeprecationNotices(w, base)
+	if table.Stale {
+		w.Header().Add("Warning", `110 - "Response is Stale"`)
+	}
+
+	utils.WriteFormatted(w, http.StatusOK, utils.NegotiateFormat(r), table)
+}
+
+// Format handles GET /format?currency=JPY&amount=12345.678&locale=de-DE
+// Rounds to the currency's minor units and writes the amount for the locale
+func (h *ExchangeHandler) Format(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	code := query.Get("currency")
+	amountStr := query.Get("amount")
+	if code == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: currency")
+		return
+	}
+	if amountStr == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: amount")
+		return
+	}
+
+	// any precision - formatting rounds to the currency's minor units
+	amount, err := currency.ParseAmount(amountStr, code, -1)
+	if err != nil {
+		utils.ErrorRespWithCode(w, http.StatusBadRequest, string(apperrors.CodeInvalidAmount), "amount "+err.Error())
+		return
+	}
+
+	formatted, err := h.currencyService.FormatAmount(code, amount, query.Get("locale"))
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+	h.applyDeprecationNotices(w, code)
+
+	utils.WriteJSON(w, http.StatusOK, formatted)
+}
+
+// ListCurrencies handles GET /currencies
+func (h *ExchangeHandler) ListCurrencies(w http.ResponseWriter, r *http.Request) {
+	resp := models.CurrencyListResponse{
+		Currencies: h.currencyService.ListCurrencies(),
 	}
 
 	utils.WriteJSON(w, http.StatusOK, resp)
 }
 
-// map service errors to http codes
-func (h *ExchangeHandler) handleServiceError(w http.ResponseWriter, err error) {
-	msg := err.Error()
+// applyDeprecationNotices sets Deprecation/Sunset/Warning headers for deprecated
+// currencies and returns the warning messages for the response body
+func (h *ExchangeHandler) applyDeprecationNotices(w http.ResponseWriter, codes ...string) []string {
+	notices := h.currencyService.GetDeprecationNotices(codes...)
+	if len(notices) == 0 {
+		return nil
+	}
+
+	warnings := make([]string, 0, len(notices))
+	earliestSunset := notices[0].SunsetDate
+
+	for _, notice := range notices {
+		msg := fmt.Sprintf("currency %s is deprecated and will be removed on %s", notice.Code, notice.SunsetDate.Format("2006-01-02"))
+		warnings = append(warnings, msg)
+		w.Header().Add("Warning", fmt.Sprintf("299 - %q", msg))
+
+		if notice.SunsetDate.Before(earliestSunset) {
+			earliestSunset = notice.SunsetDate
+		}
+	}
+
+	// RFC 8594 - clients that understand these can alert on them automatically
+	w.Header().Set("Deprecation", "true")
+	w.Header().Set("Sunset", earliestSunset.UTC().Format(http.TimeFormat))
+
+	return warnings
+}
+
+// applyStaleness flags degraded responses - when the rate is an expired cache
+// entry we say so in a Warning header and return the fields for the body
+func (h *ExchangeHandler) applyStaleness(w http.ResponseWriter, quote models.RateQuote) (bool, *time.Time) {
+	if !quote.Stale {
+		return false, nil
+	}
 
-	switch {
-	case utils.Contains(msg, "unsupported") || utils.Contains(msg, "invalid"):
-		utils.ErrorResp(w, http.StatusBadRequest, msg)
-	case utils.Contains(msg, "negative"):
-		utils.ErrorResp(w, http.StatusBadRequest, msg)
-	case utils.Contains(msg, "future") || utils.Contains(msg, "too far"):
-		utils.ErrorResp(w, http.StatusBadRequest, msg)
-	case utils.Contains(msg, "format"):
-		utils.ErrorResp(w, http.StatusBadRequest, msg)
-	case utils.Contains(msg, "api request failed") || utils.Contains(msg, "failed to fetch"):
-		utils.ErrorResp(w, http.StatusServiceUnavailable, "exchange rate service temporarily unavailable")
-	default:
-		utils.ErrorResp(w, http.StatusInternalServerError, "internal server error")
+	lastUpdated := quote.LastUpdated.UTC()
+	w.Header().Add("Warning", `110 - "Response is Stale"`)
+	return true, &lastUpdated
+}
+
+// map service errors to http codes by their apperrors code
+func (h *ExchangeHandler) handleServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	writeServiceError(w, r, err)
+}
+
+// writeServiceError is shared by every handler backed by a service returning apperrors
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, context.Canceled) {
+		// client went away - nobody left to answer
+		slog.InfoContext(r.Context(), "Request canceled", "error", err)
+		return
+	}
+
+	status, code, msg := describeServiceError(r, err)
+	if upstream := apperrors.UpstreamOf(err); upstream != "" {
+		utils.UpstreamErrorResp(w, status, code, msg, upstream)
+		return
+	}
+	utils.ErrorRespWithCode(w, status, code, msg)
+}
+
+// describeServiceError maps a service error to the status, code and message
+// clients see. Internal and provider details only go to the logs.
+func describeServiceError(r *http.Request, err error) (int, string, string) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout, string(apperrors.CodeTimeout), "request timed out"
 	}
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		slog.ErrorContext(r.Context(), "Unexpected service error", "error", err)
+		return http.StatusInternalServerError, string(apperrors.CodeInternal), "internal server error"
+	}
+
+	msg := err.Error()
+	if appErr.Upstream != "" {
+		// what the provider said, without the failover trail
+		msg = appErr.Message
+	}
+	switch appErr.Code {
+	case apperrors.CodeReadOnlyReplica:
+		msg = "rate not available on read-only replica"
+	case apperrors.CodePairUnavailable:
+		// which provider said what stays in the logs
+		slog.InfoContext(r.Context(), "Pair unavailable", "error", err)
+		msg = appErr.Message
+	case apperrors.CodeUpstreamUnavailable:
+		// provider details stay in the logs
+		slog.WarnContext(r.Context(), "Upstream failure", "error", err)
+		msg = "exchange rate service temporarily unavailable"
+	case apperrors.CodeUpstreamRejected:
+		slog.ErrorContext(r.Context(), "Provider rejected our request", "error", err)
+	}
+
+	return apperrors.HTTPStatus(appErr.Code), string(appErr.Code), msg
 }
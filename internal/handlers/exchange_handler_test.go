@@ -0,0 +1,453 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/audit"
+	"exchange-rate-service/internal/client"
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/middleware"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/receipts"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+)
+
+func TestParseConvertRequest(t *testing.T) {
+	req, fields := parseConvertRequest([]byte(`{"from":"btc","to":" EUR ","amount":"1234567890.12345678","date":"2024-01-15"}`), nil)
+	if len(fields) != 0 {
+		t.Fatalf("expected a valid body, got %v", fields)
+	}
+	if req.From != "BTC" || req.To != "EUR" || req.Amount != "1234567890.12345678" || req.Date != "2024-01-15" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+
+	cases := map[string]map[string]string{
+		`[]`:   {"body": "must be a JSON object"},
+		`null`: {"body": "must be a JSON object"},
+		`{`:    {"body": "must be a JSON object"},
+		`{"from":"USD","to":"EUR","amount":100}`: {
+			"amount": "must be a string",
+		},
+		`{"to":"EURO","amount":"1e3","date":"15/01/2024","fee":"0"}`: {
+			"from":   "is required",
+			"to":     "must be a 3-letter currency code like USD",
+			"amount": `must be a decimal number like "100.25"`,
+			"date":   "must be a date in YYYY-MM-DD format",
+			"fee":    "unknown field",
+		},
+		`{"from":"USD","to":"EUR","amount":"-5"}`: {
+			"amount": "must not be negative",
+		},
+		`{"from":"USD","to":"EUR","amount":"10.125"}`: {
+			"amount": "must have at most 2 decimal places for USD",
+		},
+		`{"from":"JPY","to":"EUR","amount":"1e308"}`: {
+			"amount": `must be a decimal number like "100.25"`,
+		},
+	}
+	for body, want := range cases {
+		if _, got := parseConvertRequest([]byte(body), nil); !reflect.DeepEqual(got, want) {
+			t.Errorf("body %s: expected %v, got %v", body, want, got)
+		}
+	}
+}
+
+func TestParseConvertRequest_ResolvesSymbolsAndNames(t *testing.T) {
+	registry := currency.NewRegistry([]string{"USD", "EUR", "JPY"})
+
+	req, fields := parseConvertRequest([]byte(`{"from":"€","to":"yen","amount":"10.50"}`), registry)
+	if len(fields) != 0 {
+		t.Fatalf("expected a valid body, got %v", fields)
+	}
+	if req.From != "EUR" || req.To != "JPY" {
+		t.Errorf("expected EUR -> JPY, got %s -> %s", req.From, req.To)
+	}
+
+	_, fields = parseConvertRequest([]byte(`{"from":"$","to":"EUR","amount":"10"}`), registry)
+	want := map[string]string{"from": `"$" is ambiguous, it could be ARS, CLP, COP, MXN, USD - use the ISO code`}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("expected %v, got %v", want, fields)
+	}
+}
+
+func TestParseTableAmounts(t *testing.T) {
+	valid := map[string][]string{
+		"amounts=1,5,%2010,,50.25": {"1", "5", "10", "50.25"},
+		"min=10&max=50&step=20":    {"10", "30", "50"},
+		"min=1&max=2.5&step=0.75":  {"1", "1.75", "2.5"},
+		"min=5&max=5&step=1":       {"5"},
+	}
+	for rawQuery, want := range valid {
+		query, _ := url.ParseQuery(rawQuery)
+		amounts, err := parseTableAmounts(query, "USD")
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", rawQuery, err)
+			continue
+		}
+		got := make([]string, 0, len(amounts))
+		for _, amount := range amounts {
+			got = append(got, amount.String())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", rawQuery, want, got)
+		}
+	}
+
+	invalid := map[string]string{
+		"":                             "missing required parameter: amounts (or min, max and step)",
+		"amounts=,":                    "missing required parameter: amounts",
+		"amounts=1,2.555":              `amount "2.555" must have at most 2 decimal places for USD`,
+		"amounts=1&min=1&max=2&step=1": "use either amounts or min, max and step",
+		"min=1&max=10":                 "missing required parameter: step",
+		"min=1&max=10&step=0":          "step must be greater than zero",
+		"min=10&max=1&step=1":          "max must not be less than min",
+		"min=0&max=1000&step=1":        "too many amounts: 1001 (max 100)",
+	}
+	for rawQuery, want := range invalid {
+		query, _ := url.ParseQuery(rawQuery)
+		if _, err := parseTableAmounts(query, "USD"); err == nil || err.Error() != want {
+			t.Errorf("%s: expected %q, got %v", rawQuery, want, err)
+		}
+	}
+}
+
+func TestWriteServiceError_ProviderAnswers(t *testing.T) {
+	rejected := apperrors.New(apperrors.CodeUnsupportedCurrency, "currency not supported by the exchange rate provider")
+	rejected.Upstream = "unsupported-code"
+	err := fmt.Errorf("failed to fetch rate: api request failed: all providers failed (primary: %v): %w", rejected, rejected)
+
+	rec := httptest.NewRecorder()
+	writeServiceError(rec, httptest.NewRequest("GET", "/v1/rate/latest?from=USD&to=XTS", nil), err)
+
+	var body map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if rec.Code != http.StatusBadRequest || body["code"] != "unsupported_currency" || body["upstream_error"] != "unsupported-code" {
+		t.Errorf("expected a 400 with the provider's error type, got %d %v", rec.Code, body)
+	}
+	if body["error"] != "currency not supported by the exchange rate provider" {
+		t.Errorf("expected the provider's answer without the failover trail, got %q", body["error"])
+	}
+
+	rec = httptest.NewRecorder()
+	writeServiceError(rec, httptest.NewRequest("GET", "/v1/rate/latest", nil), apperrors.New(apperrors.CodeUpstreamUnavailable, "failed to fetch rate"))
+	if strings.Contains(rec.Body.String(), "upstream_error") {
+		t.Errorf("errors without a provider answer should not carry upstream_error: %s", rec.Body)
+	}
+}
+
+// notQuoting is a provider that answers every pair with a not-quoted error and its raw body
+type notQuoting string
+
+func (p notQuoting) Name() string { return string(p) }
+
+func (p notQuoting) GetRate(ctx context.Context, from, to, date string) (float64, error) {
+	return 0, fmt.Errorf("api http 404: {\"message\":\"not found\"}: %w", client.ErrPairNotQuoted)
+}
+
+func TestWriteServiceError_PairNotQuotedHidesProviders(t *testing.T) {
+	chain := client.NewProviderChain(notQuoting("frankfurter"), notQuoting("ecb"))
+	_, chainErr := chain.GetRate(context.Background(), "USD", "XTS", "")
+	err := fmt.Errorf("failed to fetch rate: %w", chainErr)
+
+	rec := httptest.NewRecorder()
+	writeServiceError(rec, httptest.NewRequest("GET", "/v1/rate/latest?from=USD&to=XTS", nil), err)
+
+	var body map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if rec.Code != http.StatusNotFound || body["code"] != "pair_unavailable" || body["error"] != "no provider quotes USD-XTS" {
+		t.Errorf("expected a 404 naming only the pair, got %d %v", rec.Code, body)
+	}
+	for _, leak := range []string{"frankfurter", "ecb", "not found", "404"} {
+		if strings.Contains(body["error"], leak) {
+			t.Errorf("response leaks the failover trail (%q): %s", leak, rec.Body)
+		}
+	}
+}
+
+func TestConvert_SignedReceipt(t *testing.T) {
+	signer, err := receipts.NewSigner(map[string]string{"k1": "secret"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewExchangeHandler(&fakeRates{latest: models.RateQuote{Rate: 0.92}})
+
+	// not offered until a signer is set
+	if rec := get(h.Convert, "/v1/convert?from=USD&to=EUR&amount=10&receipt=true", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a 400 asking for a receipt without signing keys, got %d", rec.Code)
+	}
+
+	h.SetReceiptSigner(signer)
+	rec := get(h.Convert, "/v1/convert?from=USD&to=EUR&amount=10&receipt=true", "")
+	var response models.ConvertResponse
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	if rec.Code != http.StatusOK || response.Receipt == nil {
+		t.Fatalf("expected a receipt, got %d %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(response.Receipt.Payload, "from=USD") || !strings.Contains(response.Receipt.Payload, "original_amount=10") {
+		t.Errorf("expected the request in the signed payload, got %q", response.Receipt.Payload)
+	}
+
+	if rec := get(h.Convert, "/v1/convert?from=USD&to=EUR&amount=10", ""); strings.Contains(rec.Body.String(), "receipt") {
+		t.Errorf("expected no receipt unless asked for, got %s", rec.Body)
+	}
+
+	verify := func(receipt models.Receipt) models.ReceiptVerification {
+		body, _ := json.Marshal(receipt)
+		rec := httptest.NewRecorder()
+		NewReceiptHandler(signer).Verify(rec, httptest.NewRequest("POST", "/v1/receipts/verify", strings.NewReader(string(body))))
+		var result models.ReceiptVerification
+		json.Unmarshal(rec.Body.Bytes(), &result)
+		return result
+	}
+	if result := verify(*response.Receipt); !result.Valid {
+		t.Errorf("expected the receipt to verify, got %+v", result)
+	}
+	tampered := *response.Receipt
+	tampered.Payload = strings.Replace(tampered.Payload, "original_amount=10", "original_amount=100", 1)
+	if result := verify(tampered); result.Valid || result.Reason == "" {
+		t.Errorf("expected an altered receipt to fail with a reason, got %+v", result)
+	}
+}
+
+// streamingRates emits fixed points one at a time, failing the stream when a
+// point isn't in the response yet by the time the next one is produced
+type streamingRates struct {
+	CurrencyExchangeService
+	points  []models.TimeSeriesPoint
+	err     error
+	written func() string
+}
+
+func (f *streamingRates) StreamHistoricalRateRange(ctx context.Context, from, to, start, end string, emit func(date string, rate float64) error) error {
+	if f.err != nil {
+		return f.err
+	}
+	for i, point := range f.points {
+		if i > 0 && !strings.Contains(f.written(), f.points[i-1].Date) {
+			return fmt.Errorf("%s not written before %s was produced", f.points[i-1].Date, point.Date)
+		}
+		if err := emit(point.Date, point.Rate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *streamingRates) GetDeprecationNotices(codes ...string) []models.DeprecationNotice {
+	return nil
+}
+
+func TestGetTimeSeries_StreamsAsFetched(t *testing.T) {
+	rates := &streamingRates{points: []models.TimeSeriesPoint{
+		{Date: "2025-08-01", Rate: 0.91},
+		{Date: "2025-08-04", Rate: 0.92},
+		{Date: "2025-08-05", Rate: 0.93},
+	}}
+	rec := httptest.NewRecorder()
+	rates.written = rec.Body.String
+
+	NewExchangeHandler(rates).GetTimeSeries(rec, httptest.NewRequest("GET", "/v1/rate/timeseries?from=USD&to=EUR&start=2025-08-01&end=2025-08-05&format=ndjson", nil))
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if rec.Code != http.StatusOK || len(lines) != 3 || !strings.Contains(lines[2], "2025-08-05") {
+		t.Fatalf("expected three NDJSON points written as produced, got %d %s", rec.Code, rec.Body)
+	}
+
+	// a failure before the first point is a regular error response
+	rates = &streamingRates{err: apperrors.New(apperrors.CodeDateOutOfRange, "invalid date range")}
+	rec = httptest.NewRecorder()
+	NewExchangeHandler(rates).GetTimeSeries(rec, httptest.NewRequest("GET", "/v1/rate/timeseries?from=USD&to=EUR&start=2025-08-05&end=2025-08-01&stream=true", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "date_out_of_range") {
+		t.Errorf("expected a 400 date_out_of_range, got %d %s", rec.Code, rec.Body)
+	}
+}
+
+// deprecatingRates flags the currencies in sunsets as deprecated and fails
+// every conversion with err when it is set
+type deprecatingRates struct {
+	fakeRates
+	sunsets map[string]time.Time
+	err     error
+}
+
+func (f *deprecatingRates) ConvertCurrencyAmount(ctx context.Context, from, to string, amount decimal.Decimal, dateStr string) (models.ConversionResult, error) {
+	if f.err != nil {
+		return models.ConversionResult{}, f.err
+	}
+	return f.fakeRates.ConvertCurrencyAmount(ctx, from, to, amount, dateStr)
+}
+
+func (f *deprecatingRates) GetDeprecationNotices(codes ...string) []models.DeprecationNotice {
+	var notices []models.DeprecationNotice
+	for _, code := range codes {
+		if sunset, found := f.sunsets[code]; found {
+			notices = append(notices, models.DeprecationNotice{Code: code, SunsetDate: sunset})
+		}
+	}
+	return notices
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	rates := &deprecatingRates{
+		fakeRates: fakeRates{latest: models.RateQuote{Rate: 0.92}},
+		sunsets: map[string]time.Time{
+			"HRK": time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC),
+			"SLL": time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	h := NewExchangeHandler(rates)
+
+	// the same wiring as setupRoutes: /v1 plus the deprecated pre-versioning alias
+	router := mux.NewRouter()
+	register := func(r *mux.Router) {
+		r.HandleFunc("/rate/latest", h.GetLatestRate).Methods("GET")
+	}
+	register(router.PathPrefix("/v1").Subrouter())
+	legacy := router.NewRoute().Subrouter()
+	legacy.Use(middleware.LegacyAlias("/v1", time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC)))
+	register(legacy)
+
+	serve := func(target string) (*httptest.ResponseRecorder, models.CurrencyRate) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		var body models.CurrencyRate
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d %s", target, rec.Code, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: bad body %q: %v", target, rec.Body.String(), err)
+		}
+		return rec, body
+	}
+
+	rec, body := serve("/v1/rate/latest?from=USD&to=EUR")
+	for _, header := range []string{"Deprecation", "Sunset", "Link", "Warning"} {
+		if got := rec.Header().Get(header); got != "" {
+			t.Errorf("versioned path with current currencies should not send %s, got %q", header, got)
+		}
+	}
+	if len(body.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", body.Warnings)
+	}
+
+	rec, _ = serve("/rate/latest?from=USD&to=EUR")
+	if rec.Header().Get("Deprecation") != "true" || rec.Header().Get("Sunset") != "Wed, 30 Jun 2027 00:00:00 GMT" {
+		t.Errorf("legacy alias should announce its own sunset, got %v", rec.Header())
+	}
+	if got := rec.Header().Get("Link"); got != `</v1/rate/latest>; rel="successor-version"` {
+		t.Errorf("unexpected Link header %q", got)
+	}
+	if got := rec.Header().Get("Warning"); got != "" {
+		t.Errorf("legacy alias alone should not warn about currencies, got %q", got)
+	}
+
+	// deprecated currencies are flagged on /v1 too, with the earliest sunset
+	rec, body = serve("/v1/rate/latest?from=HRK&to=SLL")
+	if rec.Header().Get("Deprecation") != "true" || rec.Header().Get("Sunset") != "Thu, 31 Dec 2026 00:00:00 GMT" {
+		t.Errorf("expected the earliest currency sunset, got %v", rec.Header())
+	}
+	wantWarnings := []string{
+		"currency HRK is deprecated and will be removed on 2027-03-01",
+		"currency SLL is deprecated and will be removed on 2026-12-31",
+	}
+	if !reflect.DeepEqual(body.Warnings, wantWarnings) {
+		t.Errorf("expected warnings %v, got %v", wantWarnings, body.Warnings)
+	}
+	if got := rec.Header().Values("Warning"); len(got) != 2 || got[0] != `299 - "`+wantWarnings[0]+`"` {
+		t.Errorf("expected one Warning header per currency, got %v", got)
+	}
+}
+
+func TestGetLatestRate_SunsetCurrency(t *testing.T) {
+	rates := &deprecatingRates{err: apperrors.New(apperrors.CodeCurrencySunset, "currency HRK was retired on 2023-01-01")}
+	h := NewExchangeHandler(rates)
+
+	rec := get(h.GetLatestRate, "/v1/rate/latest?from=HRK&to=EUR", "")
+	var body map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if rec.Code != http.StatusGone || body["code"] != "currency_sunset" {
+		t.Errorf("expected 410 currency_sunset, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Deprecation") != "" {
+		t.Errorf("a retired currency is an error, not a deprecation notice: %v", rec.Header())
+	}
+}
+
+// batchRates converts at 0.9 and fails any XTS target
+type batchRates struct {
+	fakeRates
+}
+
+func (f *batchRates) ConvertToMany(ctx context.Context, from string, targets []string, amount decimal.Decimal, date string) ([]models.TargetConversion, error) {
+	conversions := make([]models.TargetConversion, 0, len(targets))
+	for _, to := range targets {
+		if to == "XTS" {
+			conversions = append(conversions, models.TargetConversion{To: to, Err: apperrors.New(apperrors.CodeUnsupportedCurrency, "unsupported target currency: XTS")})
+			continue
+		}
+		conversions = append(conversions, models.TargetConversion{To: to, Result: models.ConversionResult{
+			Amount: amount.Mul(decimal.NewFromFloat(0.9)), Quote: models.RateQuote{Rate: 0.9}, AppliedRate: 0.9,
+		}})
+	}
+	return conversions, nil
+}
+
+func (f *batchRates) ConvertAmounts(ctx context.Context, from, to string, amounts []decimal.Decimal, date string) ([]models.ConversionResult, error) {
+	if to == "XTS" {
+		return nil, apperrors.New(apperrors.CodeUnsupportedCurrency, "unsupported target currency: XTS")
+	}
+	results := make([]models.ConversionResult, 0, len(amounts))
+	for _, amount := range amounts {
+		results = append(results, models.ConversionResult{Amount: amount.Mul(decimal.NewFromFloat(0.9)), Quote: models.RateQuote{Rate: 0.9}, AppliedRate: 0.9})
+	}
+	return results, nil
+}
+
+// recordingAuditor keeps every audit entry
+type recordingAuditor struct {
+	entries []audit.Entry
+}
+
+func (a *recordingAuditor) Record(entry audit.Entry) {
+	a.entries = append(a.entries, entry)
+}
+
+func TestBatchConversionsAreAudited(t *testing.T) {
+	h := NewExchangeHandler(&batchRates{})
+	auditor := &recordingAuditor{}
+	h.SetAuditor(auditor)
+
+	get(h.ConvertMulti, "/v1/convert/multi?from=USD&to=EUR,XTS&amount=10", "")
+	if len(auditor.entries) != 2 {
+		t.Fatalf("expected one entry per target, got %+v", auditor.entries)
+	}
+	if entry := auditor.entries[0]; entry.Endpoint != "GET /v1/convert/multi" || entry.To != "EUR" || entry.Status != "success" || entry.Result != "9" {
+		t.Errorf("unexpected entry for EUR: %+v", entry)
+	}
+	if entry := auditor.entries[1]; entry.To != "XTS" || entry.Status != "error" || entry.ErrorCode != "unsupported_currency" {
+		t.Errorf("unexpected entry for XTS: %+v", entry)
+	}
+
+	auditor.entries = nil
+	get(h.ConvertTable, "/v1/convert/table?from=USD&to=EUR&amounts=1,5", "")
+	if len(auditor.entries) != 2 || auditor.entries[0].Amount != "1" || auditor.entries[1].Result != "4.5" {
+		t.Errorf("expected one entry per table row, got %+v", auditor.entries)
+	}
+
+	auditor.entries = nil
+	get(h.ConvertTable, "/v1/convert/table?from=USD&to=XTS&amounts=1,5", "")
+	if len(auditor.entries) != 2 || auditor.entries[0].Status != "error" || auditor.entries[1].Amount != "5" {
+		t.Errorf("expected a failed entry per table row, got %+v", auditor.entries)
+	}
+}
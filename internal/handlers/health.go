@@ -1,9 +1,9 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"exchange-rate-service/internal/models"
 	"exchange-rate-service/internal/services"
 	"exchange-rate-service/internal/utils"
 )
@@ -22,11 +22,21 @@ func NewHealthHandler(healthSvc *services.HealthService) *HealthHandler {
 
 // CheckHealth handles GET /health requests
 func (h *HealthHandler) CheckHealth(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	h.writeStatus(w, h.healthSvc.CheckHealth(r.Context()))
+}
+
+// CheckLiveness handles GET /health/live - for restart decisions
+func (h *HealthHandler) CheckLiveness(w http.ResponseWriter, r *http.Request) {
+	h.writeStatus(w, h.healthSvc.CheckLiveness(r.Context()))
+}
 
-	// Perform health check
-	healthStatus := h.healthSvc.CheckHealth(ctx)
+// CheckReadiness handles GET /health/ready - for routing traffic
+func (h *HealthHandler) CheckReadiness(w http.ResponseWriter, r *http.Request) {
+	h.writeStatus(w, h.healthSvc.CheckReadiness(r.Context()))
+}
 
+// writeStatus answers 200 when healthy, 503 otherwise
+func (h *HealthHandler) writeStatus(w http.ResponseWriter, healthStatus *models.HealthStatus) {
 	// Set appropriate HTTP status code
 	statusCode := http.StatusOK
 	if !healthStatus.IsHealthy() {
@@ -39,12 +49,5 @@ func (h *HealthHandler) CheckHealth(w http.ResponseWriter, r *http.Request) {
 
 // sendErrorResponse sends a standardized error response
 func (h *HealthHandler) sendErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	errorResp := map[string]string{
-		"error":  message,
-		"status": "error",
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(errorResp)
+	utils.ErrorResp(w, statusCode, message)
 }
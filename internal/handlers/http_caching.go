@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/auth"
+)
+
+// RefreshSchedule reports how often a pair's latest rate is re-fetched
+type RefreshSchedule interface {
+	RefreshInterval(from, to string) time.Duration
+}
+
+// a past day's rate doesn't change once published
+const historicalMaxAge = 24 * time.Hour
+
+// SetRefreshSchedule enables ETag and Cache-Control on the rate endpoints,
+// with latest rates cacheable until their next scheduled refresh
+func (h *ExchangeHandler) SetRefreshSchedule(schedule RefreshSchedule) {
+	h.schedule = schedule
+}
+
+// rateETag is a strong validator over the parts that identify one representation of a rate
+func rateETag(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// writeCacheHeaders sets ETag and Cache-Control, and answers 304 itself
+// when If-None-Match already names etag - true means the response is done.
+// maxAge <= 0 makes clients revalidate every time. Responses to authenticated
+// callers are private so shared caches don't hand them to anyone else
+func writeCacheHeaders(w http.ResponseWriter, r *http.Request, etag string, maxAge time.Duration) bool {
+	visibility := "public"
+	if _, ok := auth.ClientFromContext(r.Context()); ok {
+		visibility = "private"
+	} else if _, ok := auth.ClaimsFromContext(r.Context()); ok {
+		visibility = "private"
+	}
+
+	header := w.Header()
+	if maxAge > time.Second {
+		header.Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", visibility, int(maxAge.Seconds())))
+	} else {
+		header.Set("Cache-Control", visibility+", no-cache")
+	}
+	header.Set("ETag", etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		// WriteFormatted adds this on a 200 - the same URL has a JSON, CSV and XML representation
+		header.Add("Vary", "Accept")
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// etagMatches applies If-None-Match's weak comparison: any listed tag, W/ or
+// not, or "*"
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
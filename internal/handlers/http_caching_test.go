@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// fakeRates serves fixed quotes; only the rate lookups are used here
+type fakeRates struct {
+	CurrencyExchangeService
+	latest     models.RateQuote
+	historical models.RateQuote
+}
+
+func (f *fakeRates) ConvertCurrencyAmount(ctx context.Context, from, to string, amount decimal.Decimal, dateStr string) (models.ConversionResult, error) {
+	return models.ConversionResult{Quote: f.latest}, nil
+}
+
+func (f *fakeRates) GetHistoricalExchangeRate(ctx context.Context, from, to, dateStr string) (models.RateQuote, error) {
+	return f.historical, nil
+}
+
+func (f *fakeRates) GetDeprecationNotices(codes ...string) []models.DeprecationNotice {
+	return nil
+}
+
+type fixedSchedule time.Duration
+
+func (s fixedSchedule) RefreshInterval(from, to string) time.Duration {
+	return time.Duration(s)
+}
+
+func get(handler http.HandlerFunc, target, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestGetLatestRate_CacheHeaders(t *testing.T) {
+	rates := &fakeRates{latest: models.RateQuote{Rate: 0.92, LastUpdated: time.Now().Add(-20 * time.Minute)}}
+	h := NewExchangeHandler(rates)
+	h.SetRefreshSchedule(fixedSchedule(time.Hour))
+
+	rec := get(h.GetLatestRate, "/v1/rate/latest?from=USD&to=EUR", "")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d %q", rec.Code, etag)
+	}
+	// 40 minutes left until the next refresh
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=2399" && cc != "public, max-age=2400" {
+		t.Errorf("expected max-age up to the next refresh, got %q", cc)
+	}
+
+	rec = get(h.GetLatestRate, "/v1/rate/latest?from=usd&to=eur", `"other", W/`+etag)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("expected 304 with no body, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	// a refresh changes the validator
+	rates.latest.LastUpdated = time.Now()
+	if rec = get(h.GetLatestRate, "/v1/rate/latest?from=USD&to=EUR", etag); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 after a refresh, got %d", rec.Code)
+	}
+
+	rates.latest.Stale = true
+	rec = get(h.GetLatestRate, "/v1/rate/latest?from=USD&to=EUR", "")
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, no-cache" {
+		t.Errorf("expected stale rates to be revalidated, got %q", cc)
+	}
+}
+
+func TestGetHistoricalRate_CacheHeaders(t *testing.T) {
+	rates := &fakeRates{historical: models.RateQuote{Rate: 0.91, Date: "2024-01-12"}}
+	h := NewExchangeHandler(rates)
+	h.SetRefreshSchedule(fixedSchedule(time.Hour))
+
+	rec := get(h.GetHistoricalRate, "/v1/rate/historical?from=USD&to=EUR&date=2024-01-13", "")
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=86400" {
+		t.Errorf("expected past days to be cacheable for a day, got %q", cc)
+	}
+	if rec = get(h.GetHistoricalRate, "/v1/rate/historical?from=USD&to=EUR&date=2024-01-13", rec.Header().Get("ETag")); rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", rec.Code)
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	rec = get(h.GetHistoricalRate, "/v1/rate/historical?from=USD&to=EUR&date="+today, "")
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, no-cache" {
+		t.Errorf("expected today's rate to be revalidated, got %q", cc)
+	}
+}
+
+func TestRateEndpoints_NoCacheHeadersWithoutSchedule(t *testing.T) {
+	h := NewExchangeHandler(&fakeRates{latest: models.RateQuote{Rate: 0.92, LastUpdated: time.Now()}})
+
+	rec := get(h.GetLatestRate, "/v1/rate/latest?from=USD&to=EUR", "*")
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != "" {
+		t.Errorf("expected a plain 200, got %d with ETag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
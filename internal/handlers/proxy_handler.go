@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/client"
+	"exchange-rate-service/internal/utils"
+)
+
+// ProviderProxy defines what the proxy handler needs from the client layer
+type ProviderProxy interface {
+	Fetch(ctx context.Context, providerPath, rawQuery string) (*client.ProxyResponse, time.Duration, error)
+}
+
+// ProxyHandler exposes the upstream provider API through our shared cache and quota
+type ProxyHandler struct {
+	proxy  ProviderProxy
+	prefix string
+}
+
+// NewProxyHandler creates a proxy handler serving requests under prefix
+func NewProxyHandler(proxy ProviderProxy, prefix string) *ProxyHandler {
+	return &ProxyHandler{
+		proxy:  proxy,
+		prefix: prefix,
+	}
+}
+
+// Forward handles GET /proxy/... requests
+func (h *ProxyHandler) Forward(w http.ResponseWriter, r *http.Request) {
+	providerPath := strings.TrimPrefix(r.URL.Path, h.prefix)
+
+	resp, wait, err := h.proxy.Fetch(r.Context(), providerPath, r.URL.RawQuery)
+	if err != nil {
+		switch {
+		case errors.Is(err, client.ErrProxyRateLimited):
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			utils.ErrorResp(w, http.StatusTooManyRequests, "upstream quota exhausted, retry later")
+		case errors.Is(err, client.ErrInvalidProxyPath):
+			utils.ErrorResp(w, http.StatusBadRequest, err.Error())
+		default:
+			slog.WarnContext(r.Context(), "Proxy request failed", "path", providerPath, "error", err)
+			utils.ErrorResp(w, http.StatusBadGateway, "upstream provider unavailable")
+		}
+		return
+	}
+
+	cacheStatus := "MISS"
+	if resp.FromCache {
+		cacheStatus = "HIT"
+	}
+
+	if resp.ContentType != "" {
+		w.Header().Set("Content-Type", resp.ContentType)
+	}
+	w.Header().Set("X-Proxy-Cache", cacheStatus)
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(resp.FetchedAt).Seconds())))
+	w.WriteHeader(resp.StatusCode)
+	w.Write(resp.Body)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/client"
+)
+
+// fakeProxy answers every fetch with the same response or error
+type fakeProxy struct {
+	resp *client.ProxyResponse
+	wait time.Duration
+	err  error
+	path string
+}
+
+func (f *fakeProxy) Fetch(ctx context.Context, providerPath, rawQuery string) (*client.ProxyResponse, time.Duration, error) {
+	f.path = providerPath
+	return f.resp, f.wait, f.err
+}
+
+func forward(proxy ProviderProxy, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	NewProxyHandler(proxy, "/proxy").Forward(rec, httptest.NewRequest("GET", target, nil))
+	return rec
+}
+
+func TestProxyHandler_InvalidPath(t *testing.T) {
+	rec := forward(&fakeProxy{err: fmt.Errorf("%w: empty", client.ErrInvalidProxyPath)}, "/proxy/")
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid path, got %d", rec.Code)
+	}
+}
+
+func TestProxyHandler_CacheHeader(t *testing.T) {
+	for _, fromCache := range []bool{false, true} {
+		proxy := &fakeProxy{resp: &client.ProxyResponse{
+			StatusCode:  http.StatusOK,
+			ContentType: "application/json",
+			Body:        []byte(`{"result":"success"}`),
+			FetchedAt:   time.Now(),
+			FromCache:   fromCache,
+		}}
+		rec := forward(proxy, "/proxy/latest/USD")
+
+		want := "MISS"
+		if fromCache {
+			want = "HIT"
+		}
+		if got := rec.Header().Get("X-Proxy-Cache"); got != want {
+			t.Errorf("fromCache=%v: expected X-Proxy-Cache %s, got %q", fromCache, want, got)
+		}
+		if rec.Code != http.StatusOK || rec.Body.String() != `{"result":"success"}` {
+			t.Errorf("expected the upstream response to be replayed, got %d %s", rec.Code, rec.Body.String())
+		}
+		if proxy.path != "/latest/USD" {
+			t.Errorf("expected the prefix to be stripped, got %q", proxy.path)
+		}
+	}
+}
+
+func TestProxyHandler_RateLimitedSetsRetryAfter(t *testing.T) {
+	rec := forward(&fakeProxy{err: client.ErrProxyRateLimited, wait: 2500 * time.Millisecond}, "/proxy/latest/EUR")
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "3" {
+		t.Errorf("expected Retry-After 3, got %q", got)
+	}
+}
+
+func TestProxyHandler_UpstreamFailure(t *testing.T) {
+	rec := forward(&fakeProxy{err: fmt.Errorf("dial tcp: connection refused")}, "/proxy/latest/EUR")
+
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"exchange-rate-service/internal/auth"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+)
+
+// QuoteService issues conversion quotes and executes them
+type QuoteService interface {
+	CreateQuote(ctx context.Context, owner, from, to string, amount decimal.Decimal) (models.Quote, error)
+	ExecuteQuote(ctx context.Context, owner, id string) (models.QuoteExecution, error)
+}
+
+// QuoteHandler serves POST /quote and POST /quote/{id}/execute
+type QuoteHandler struct {
+	quotes   QuoteService
+	auditor  ConversionAuditor // nil when auditing is off
+	resolver CurrencyResolver  // nil takes codes only
+}
+
+// NewQuoteHandler creates a quote handler
+func NewQuoteHandler(quotes QuoteService) *QuoteHandler {
+	return &QuoteHandler{quotes: quotes}
+}
+
+// SetAuditor records every executed quote to auditor from now on
+func (h *QuoteHandler) SetAuditor(auditor ConversionAuditor) {
+	h.auditor = auditor
+}
+
+// SetCurrencyResolver lets the body's from and to be symbols or names
+func (h *QuoteHandler) SetCurrencyResolver(resolver CurrencyResolver) {
+	h.resolver = resolver
+}
+
+// Create handles POST /quote - the body is a POST /convert body without date
+// or locale. The quote's rate holds until expires_at
+func (h *QuoteHandler) Create(w http.ResponseWriter, r *http.Request) {
+	body, ok := readConvertBody(w, r)
+	if !ok {
+		return
+	}
+
+	req, fields := parseConvertRequest(body, h.resolver)
+	// quotes are priced at the latest rate and carry amounts only
+	if req.Date != "" {
+		fields["date"] = "not allowed - quotes use the latest rate"
+	}
+	if req.Locale != "" {
+		fields["locale"] = "not allowed - quotes carry no formatting"
+	}
+	if len(fields) > 0 {
+		utils.FieldErrorResp(w, "invalid request body", fields)
+		return
+	}
+
+	amount, _ := decimal.NewFromString(req.Amount)
+	quote, err := h.quotes.CreateQuote(r.Context(), auth.CallerID(r.Context()), req.From, req.To, amount)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, quote)
+}
+
+// Execute handles POST /quote/{id}/execute - the conversion at the quoted rate,
+// whatever the rate is now. A quote executes once
+func (h *QuoteHandler) Execute(w http.ResponseWriter, r *http.Request) {
+	execution, err := h.quotes.ExecuteQuote(r.Context(), auth.CallerID(r.Context()), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	// only executions convert anything, so only they reach the audit trail
+	if h.auditor != nil {
+		entry := newAuditEntry(r)
+		entry.From = execution.From
+		entry.To = execution.To
+		entry.Amount = execution.OriginalAmount.String()
+		entry.Rate = execution.Rate
+		entry.AppliedRate = execution.AppliedRate
+		entry.Fee = execution.Fee.String()
+		entry.Result = execution.Amount.String()
+		entry.Source = execution.Source
+		h.auditor.Record(entry)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, execution)
+}
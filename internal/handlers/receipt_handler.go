@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/utils"
+)
+
+// ReceiptHandler checks conversion receipts for callers that don't hold the
+// signing key
+type ReceiptHandler struct {
+	signer ReceiptSigner
+}
+
+// NewReceiptHandler creates a receipt handler
+func NewReceiptHandler(signer ReceiptSigner) *ReceiptHandler {
+	return &ReceiptHandler{signer: signer}
+}
+
+// Verify handles POST /receipts/verify with a receipt as the body, answering
+// whether it was signed by this service and not altered since. A forged or
+// altered receipt is a valid request, so it gets a 200 with valid=false
+func (h *ReceiptHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	body, ok := readConvertBody(w, r)
+	if !ok {
+		return
+	}
+
+	var receipt models.Receipt
+	if err := json.Unmarshal(body, &receipt); err != nil {
+		utils.FieldErrorResp(w, "invalid request body", map[string]string{"body": "must be a receipt object"})
+		return
+	}
+	fields := make(map[string]string)
+	if receipt.KeyID == "" {
+		fields["key_id"] = "is required"
+	}
+	if receipt.Payload == "" {
+		fields["payload"] = "is required"
+	}
+	if receipt.Signature == "" {
+		fields["signature"] = "is required"
+	}
+	if len(fields) > 0 {
+		utils.FieldErrorResp(w, "invalid request body", fields)
+		return
+	}
+
+	result := models.ReceiptVerification{Valid: true, KeyID: receipt.KeyID}
+	if err := h.signer.Verify(receipt); err != nil {
+		result = models.ReceiptVerification{Valid: false, Reason: err.Error()}
+	}
+	utils.WriteJSON(w, http.StatusOK, result)
+}
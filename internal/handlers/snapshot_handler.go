@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/tenant"
+	"exchange-rate-service/internal/utils"
+)
+
+// RateSnapshotService returns archived end-of-day rate tables
+type RateSnapshotService interface {
+	Snapshot(ctx context.Context, date string) (models.RateSnapshot, error)
+}
+
+// SnapshotHandler serves the daily rate snapshots
+type SnapshotHandler struct {
+	snapshots RateSnapshotService
+}
+
+// NewSnapshotHandler creates a snapshot handler
+func NewSnapshotHandler(snapshots RateSnapshotService) *SnapshotHandler {
+	return &SnapshotHandler{snapshots: snapshots}
+}
+
+// Get handles GET /rate/snapshot?date=YYYY-MM-DD - the whole table as JSON,
+// XML or CSV. CSV comes as a file download named after the date
+func (h *SnapshotHandler) Get(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		utils.ErrorResp(w, http.StatusBadRequest, "missing required parameter: date")
+		return
+	}
+
+	snapshot, err := h.snapshots.Snapshot(r.Context(), date)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	// an API key limited to some pairs only sees those
+	if policy, ok := tenant.FromContext(r.Context()); ok {
+		allowed := make([]models.SnapshotRate, 0, len(snapshot.Rates))
+		for _, rate := range snapshot.Rates {
+			if policy.AllowsPair(rate.From, rate.To) {
+				allowed = append(allowed, rate)
+			}
+		}
+		snapshot.Rates, snapshot.Count = allowed, len(allowed)
+	}
+
+	format := utils.NegotiateFormat(r)
+	if format == utils.FormatCSV {
+		w.Header().Set("Content-Disposition", `attachment; filename="rates-`+snapshot.Date+`.csv"`)
+	}
+	utils.WriteFormatted(w, http.StatusOK, format, snapshot)
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/utils"
+)
+
+// CacheStatsReporter is the part of the rate cache GET /stats reads
+type CacheStatsReporter interface {
+	Stats() models.CacheStats
+}
+
+// StatsHandler serves the cache and refresh counters
+type StatsHandler struct {
+	cache CacheStatsReporter
+}
+
+// NewStatsHandler creates a stats handler
+func NewStatsHandler(cache CacheStatsReporter) *StatsHandler {
+	return &StatsHandler{cache: cache}
+}
+
+// GetStats handles GET /stats
+func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, h.cache.Stats())
+}
@@ -0,0 +1,224 @@
+package live
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/metrics"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/tenant"
+	"exchange-rate-service/internal/utils"
+
+	"github.com/gorilla/websocket"
+)
+
+// RateSource gives us the current rate for a pair
+type RateSource interface {
+	GetLatestRate(ctx context.Context, from, to string) (models.RateQuote, error)
+}
+
+// CurrencyChecker tells us which currency codes we accept
+type CurrencyChecker interface {
+	IsSupported(code string) bool
+}
+
+// Options tunes the hub - zero values fall back to the defaults
+type Options struct {
+	// open connections allowed at once, further upgrades get 503
+	MaxConnections int
+	// pairs one connection may subscribe to
+	MaxPairs int
+	// minimum move, in percent of the last rate sent, worth pushing (0 = any change)
+	ChangeThreshold float64
+	// how often connections are pinged; one missed pong later they are dropped
+	PingInterval time.Duration
+}
+
+const (
+	defaultMaxConnections = 1000
+	defaultMaxPairs       = 50
+	defaultPingInterval   = 30 * time.Second
+
+	// time allowed for a single write before the connection counts as dead
+	writeTimeout = 10 * time.Second
+	// largest client message - a subscribe for every pair fits comfortably
+	maxMessageBytes = 4096
+)
+
+// Hub tracks WebSocket subscribers and pushes them rate changes after every
+// cache refresh. Each connection remembers the last rate it was sent per pair,
+// so slow drifts add up until they cross the threshold
+type Hub struct {
+	rates      RateSource
+	currencies CurrencyChecker
+	opts       Options
+	upgrader   websocket.Upgrader
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	// upgrades in progress, already counted against MaxConnections
+	reserved int
+	closed   bool
+}
+
+// NewHub creates a hub - register Publish as a cache refresh hook
+func NewHub(rates RateSource, currencies CurrencyChecker, opts Options) *Hub {
+	if opts.MaxConnections <= 0 {
+		opts.MaxConnections = defaultMaxConnections
+	}
+	if opts.MaxPairs <= 0 {
+		opts.MaxPairs = defaultMaxPairs
+	}
+	if opts.PingInterval <= 0 {
+		opts.PingInterval = defaultPingInterval
+	}
+
+	return &Hub{
+		rates:      rates,
+		currencies: currencies,
+		opts:       opts,
+		upgrader: websocket.Upgrader{
+			// clients authenticate with the API key header, not cookies, so
+			// another site's page gains nothing by opening the socket
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// ServeHTTP upgrades GET /ws to a WebSocket once there is room for it and
+// serves the connection until either side closes it
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.reserve() {
+		utils.ErrorRespWithCode(w, http.StatusServiceUnavailable, string(apperrors.CodeRateLimited), "too many live connections, try again later")
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already answered the client
+		h.mu.Lock()
+		h.reserved--
+		h.mu.Unlock()
+		slog.InfoContext(r.Context(), "WebSocket upgrade failed", "error", err)
+		return
+	}
+
+	sub := newSubscriber(h, conn)
+	// updates are pushed outside any request, so the pair check happens on subscribe
+	sub.policy, _ = tenant.FromContext(r.Context())
+	h.mu.Lock()
+	h.reserved--
+	if h.closed {
+		h.mu.Unlock()
+		conn.Close()
+		return
+	}
+	h.subscribers[sub] = struct{}{}
+	metrics.SetWSConnections(len(h.subscribers))
+	h.mu.Unlock()
+
+	slog.InfoContext(r.Context(), "WebSocket subscriber connected", "remote", r.RemoteAddr)
+
+	go sub.writeLoop()
+	sub.readLoop()
+}
+
+// reserve counts an upgrade against the connection cap
+func (h *Hub) reserve() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed || len(h.subscribers)+h.reserved >= h.opts.MaxConnections {
+		return false
+	}
+	h.reserved++
+	return true
+}
+
+// remove forgets a subscriber once its connection is gone
+func (h *Hub) remove(sub *subscriber) {
+	h.mu.Lock()
+	delete(h.subscribers, sub)
+	metrics.SetWSConnections(len(h.subscribers))
+	h.mu.Unlock()
+}
+
+// Connections returns the number of open connections
+func (h *Hub) Connections() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+// Publish fetches every subscribed pair once and pushes the rates that moved
+// past the threshold since each subscriber last heard about them
+// Runs as a cache refresh hook, so the rates come straight from the warm cache
+func (h *Hub) Publish(ctx context.Context) {
+	h.mu.Lock()
+	subscribers := make([]*subscriber, 0, len(h.subscribers))
+	for sub := range h.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	h.mu.Unlock()
+
+	wanted := make(map[pair]bool)
+	for _, sub := range subscribers {
+		for _, p := range sub.pairs() {
+			wanted[p] = true
+		}
+	}
+
+	quotes := make(map[pair]models.RateQuote, len(wanted))
+	for p := range wanted {
+		if ctx.Err() != nil {
+			return
+		}
+		quote, err := h.rates.GetLatestRate(ctx, p.From, p.To)
+		if err != nil {
+			slog.WarnContext(ctx, "Live feed skipping pair this refresh", "pair", p.String(), "error", err)
+			continue
+		}
+		quotes[p] = quote
+	}
+
+	sent := 0
+	for _, sub := range subscribers {
+		sent += sub.pushChanged(quotes, h.opts.ChangeThreshold)
+	}
+	if sent > 0 {
+		metrics.RecordWSUpdates(sent)
+		slog.DebugContext(ctx, "Live rate updates pushed", "updates", sent, "subscribers", len(subscribers))
+	}
+}
+
+// Close disconnects every subscriber and refuses new ones
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	subscribers := make([]*subscriber, 0, len(h.subscribers))
+	for sub := range h.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	h.mu.Unlock()
+
+	for _, sub := range subscribers {
+		sub.close(websocket.CloseGoingAway, "server shutting down")
+	}
+}
+
+// movedEnough reports whether rate differs from previous by at least threshold percent
+func movedEnough(previous, rate, threshold float64) bool {
+	if previous == 0 {
+		return true
+	}
+	if threshold <= 0 {
+		return rate != previous
+	}
+	return math.Abs(rate-previous)/previous*100 >= threshold
+}
@@ -0,0 +1,212 @@
+package live
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeRates is a mutable rate table
+type fakeRates struct {
+	mu    sync.Mutex
+	rates map[string]float64
+}
+
+func (f *fakeRates) GetLatestRate(ctx context.Context, from, to string) (models.RateQuote, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rate, found := f.rates[from+"-"+to]
+	if !found {
+		return models.RateQuote{}, apperrors.New(apperrors.CodeUpstreamUnavailable, "no rate")
+	}
+	return models.RateQuote{Rate: rate}, nil
+}
+
+func (f *fakeRates) set(pair string, rate float64) {
+	f.mu.Lock()
+	f.rates[pair] = rate
+	f.mu.Unlock()
+}
+
+type allCurrencies struct{}
+
+func (allCurrencies) IsSupported(code string) bool {
+	return len(code) == 3
+}
+
+func startHub(t *testing.T, rates *fakeRates, opts Options) (*Hub, string) {
+	t.Helper()
+	hub := NewHub(rates, allCurrencies{}, opts)
+	srv := httptest.NewServer(hub)
+	t.Cleanup(func() {
+		hub.Close()
+		srv.Close()
+	})
+	return hub, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func read(t *testing.T, conn *websocket.Conn) Message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	return msg
+}
+
+// waitFor polls until cond holds - connections register asynchronously
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHub_SubscribeGetsSnapshotThenChangesPastThreshold(t *testing.T) {
+	rates := &fakeRates{rates: map[string]float64{"USD-EUR": 0.90}}
+	hub, url := startHub(t, rates, Options{ChangeThreshold: 1})
+	conn := dial(t, url)
+
+	conn.WriteJSON(clientMessage{Action: "subscribe", Pairs: []string{"usd-eur"}})
+	if msg := read(t, conn); msg.Type != "subscribed" || len(msg.Pairs) != 1 || msg.Pairs[0] != "USD-EUR" {
+		t.Fatalf("expected subscribed ack, got %+v", msg)
+	}
+	if msg := read(t, conn); msg.Type != "rate" || msg.Rate != 0.90 {
+		t.Fatalf("expected the current rate right away, got %+v", msg)
+	}
+
+	// 0.5% - under the threshold, nothing sent
+	rates.set("USD-EUR", 0.9045)
+	hub.Publish(context.Background())
+
+	// 0.5% more adds up to 1% over the last rate sent
+	rates.set("USD-EUR", 0.909)
+	hub.Publish(context.Background())
+
+	msg := read(t, conn)
+	if msg.Type != "rate" || msg.Rate != 0.909 || msg.Previous != 0.90 {
+		t.Fatalf("expected the accumulated move to be pushed, got %+v", msg)
+	}
+	if msg.ChangePercent < 0.99 || msg.ChangePercent > 1.01 {
+		t.Errorf("expected a ~1%% change, got %f", msg.ChangePercent)
+	}
+}
+
+func TestHub_UnsubscribeAndValidation(t *testing.T) {
+	rates := &fakeRates{rates: map[string]float64{"USD-EUR": 0.90}}
+	hub, url := startHub(t, rates, Options{MaxPairs: 2})
+	conn := dial(t, url)
+
+	conn.WriteJSON(clientMessage{Action: "subscribe", Pairs: []string{"USDEUR"}})
+	if msg := read(t, conn); msg.Type != "error" || msg.Code != "invalid_request" {
+		t.Errorf("expected an invalid pair error, got %+v", msg)
+	}
+
+	conn.WriteJSON(clientMessage{Action: "subscribe", Pairs: []string{"USD-EUR", "USD-INR", "USD-JPY"}})
+	if msg := read(t, conn); msg.Type != "error" || !strings.Contains(msg.Error, "too many pairs") {
+		t.Errorf("expected the pair cap to apply, got %+v", msg)
+	}
+
+	conn.WriteJSON(map[string]string{"action": "dance"})
+	if msg := read(t, conn); msg.Type != "error" {
+		t.Errorf("expected an unknown action error, got %+v", msg)
+	}
+
+	conn.WriteJSON(clientMessage{Action: "subscribe", Pairs: []string{"USD-EUR"}})
+	read(t, conn) // subscribed
+	read(t, conn) // snapshot
+
+	conn.WriteJSON(clientMessage{Action: "unsubscribe", Pairs: []string{"USD-EUR"}})
+	if msg := read(t, conn); msg.Type != "unsubscribed" || len(msg.Pairs) != 0 {
+		t.Fatalf("expected an empty list after unsubscribing, got %+v", msg)
+	}
+
+	rates.set("USD-EUR", 1.5)
+	hub.Publish(context.Background())
+
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	var msg Message
+	if err := conn.ReadJSON(&msg); err == nil {
+		t.Errorf("no update expected after unsubscribing, got %+v", msg)
+	}
+}
+
+func TestHub_ConnectionCap(t *testing.T) {
+	hub, url := startHub(t, &fakeRates{rates: map[string]float64{}}, Options{MaxConnections: 1})
+	dial(t, url)
+	waitFor(t, func() bool { return hub.Connections() == 1 })
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil {
+		t.Fatal("expected the second connection to be refused")
+	}
+	if resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %v", resp)
+	}
+}
+
+func TestHub_DropsConnectionsThatStopAnsweringPings(t *testing.T) {
+	hub, url := startHub(t, &fakeRates{rates: map[string]float64{}}, Options{PingInterval: 20 * time.Millisecond})
+
+	conn := dial(t, url)
+	// a client that never reads never answers pings either
+	waitFor(t, func() bool { return hub.Connections() == 1 })
+	waitFor(t, func() bool { return hub.Connections() == 0 })
+
+	// one that keeps reading answers them and stays
+	conn = dial(t, url)
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	waitFor(t, func() bool { return hub.Connections() == 1 })
+	time.Sleep(100 * time.Millisecond)
+	if hub.Connections() != 1 {
+		t.Error("a responsive client should survive several ping intervals")
+	}
+}
+
+func TestMovedEnough(t *testing.T) {
+	cases := []struct {
+		previous, rate, threshold float64
+		want                      bool
+	}{
+		{0, 1, 5, true}, // nothing sent yet
+		{1, 1, 0, false},
+		{1, 1.0001, 0, true},
+		{1, 1.04, 5, false},
+		{1, 0.95, 5, true},
+	}
+	for _, c := range cases {
+		if got := movedEnough(c.previous, c.rate, c.threshold); got != c.want {
+			t.Errorf("movedEnough(%v, %v, %v) = %v, want %v", c.previous, c.rate, c.threshold, got, c.want)
+		}
+	}
+}
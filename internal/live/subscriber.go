@@ -0,0 +1,324 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/tenant"
+
+	"github.com/gorilla/websocket"
+)
+
+// outgoing messages buffered per connection - a client this far behind is dropped
+const sendBuffer = 64
+
+// how long the first rate of a new subscription may take to fetch
+const snapshotTimeout = 15 * time.Second
+
+// clientMessage is what clients send: {"action":"subscribe","pairs":["USD-EUR"]}
+type clientMessage struct {
+	Action string   `json:"action"`
+	Pairs  []string `json:"pairs"`
+}
+
+// Message is what the server sends. Type is "subscribed" or "unsubscribed"
+// (Pairs is the connection's full list afterwards), "rate" or "error"
+type Message struct {
+	Type          string    `json:"type"`
+	Pairs         []string  `json:"pairs,omitempty"`
+	From          string    `json:"from,omitempty"`
+	To            string    `json:"to,omitempty"`
+	Rate          float64   `json:"rate,omitempty"`
+	Previous      float64   `json:"previous,omitempty"` // last rate sent on this connection
+	ChangePercent float64   `json:"change_percent,omitempty"`
+	Stale         bool      `json:"stale,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Code          string    `json:"code,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+type pair struct {
+	From string
+	To   string
+}
+
+func (p pair) String() string {
+	return p.From + "-" + p.To
+}
+
+// subscriber is one WebSocket connection and the pairs it follows
+type subscriber struct {
+	hub    *Hub
+	conn   *websocket.Conn
+	policy *tenant.Policy // the caller's, nil = every pair
+
+	send      chan Message
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu sync.Mutex
+	// last rate sent per subscribed pair - 0 until the first one goes out
+	lastSent map[pair]float64
+}
+
+func newSubscriber(hub *Hub, conn *websocket.Conn) *subscriber {
+	return &subscriber{
+		hub:      hub,
+		conn:     conn,
+		send:     make(chan Message, sendBuffer),
+		done:     make(chan struct{}),
+		lastSent: make(map[pair]float64),
+	}
+}
+
+// readLoop handles client messages until the connection fails or goes quiet
+// for two ping intervals - pongs count as activity
+func (s *subscriber) readLoop() {
+	defer s.hub.remove(s)
+	defer s.close(websocket.CloseNormalClosure, "")
+
+	idle := 2 * s.hub.opts.PingInterval
+	s.conn.SetReadLimit(maxMessageBytes)
+	s.conn.SetReadDeadline(time.Now().Add(idle))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(idle))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) && !errors.Is(err, websocket.ErrCloseSent) {
+				slog.Info("WebSocket subscriber disconnected", "error", err)
+			}
+			return
+		}
+		s.conn.SetReadDeadline(time.Now().Add(idle))
+
+		var msg clientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.sendError(apperrors.CodeInvalidRequest, "messages must be JSON like {\"action\":\"subscribe\",\"pairs\":[\"USD-EUR\"]}")
+			continue
+		}
+
+		switch strings.ToLower(msg.Action) {
+		case "subscribe":
+			s.subscribe(msg.Pairs)
+		case "unsubscribe":
+			s.unsubscribe(msg.Pairs)
+		default:
+			s.sendError(apperrors.CodeInvalidRequest, fmt.Sprintf("unknown action %q, expected subscribe or unsubscribe", msg.Action))
+		}
+	}
+}
+
+// writeLoop is the only writer of data frames, and pings on every interval
+func (s *subscriber) writeLoop() {
+	ticker := time.NewTicker(s.hub.opts.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := s.conn.WriteJSON(msg); err != nil {
+				s.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				s.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// subscribe adds pairs and sends each new one's current rate right away
+// Nothing is added when any pair is invalid or the cap would be exceeded
+func (s *subscriber) subscribe(raw []string) {
+	if len(raw) == 0 {
+		s.sendError(apperrors.CodeInvalidRequest, "pairs must not be empty")
+		return
+	}
+
+	requested := make([]pair, 0, len(raw))
+	for _, value := range raw {
+		p, err := s.parsePair(value)
+		if err != nil {
+			s.sendError(apperrors.CodeOf(err), err.Error())
+			return
+		}
+		requested = append(requested, p)
+	}
+
+	s.mu.Lock()
+	added := make([]pair, 0, len(requested))
+	for _, p := range requested {
+		if _, found := s.lastSent[p]; !found {
+			s.lastSent[p] = 0
+			added = append(added, p)
+		}
+	}
+	if len(s.lastSent) > s.hub.opts.MaxPairs {
+		for _, p := range added {
+			delete(s.lastSent, p)
+		}
+		s.mu.Unlock()
+		s.sendError(apperrors.CodeInvalidRequest, fmt.Sprintf("too many pairs, maximum %d per connection", s.hub.opts.MaxPairs))
+		return
+	}
+	s.mu.Unlock()
+
+	s.enqueue(Message{Type: "subscribed", Pairs: s.pairNames()})
+
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+	for _, p := range added {
+		quote, err := s.hub.rates.GetLatestRate(ctx, p.From, p.To)
+		if err != nil {
+			s.sendError(apperrors.CodeOf(err), fmt.Sprintf("%s: rate unavailable, updates follow once it is", p))
+			continue
+		}
+		s.pushChanged(map[pair]models.RateQuote{p: quote}, 0)
+	}
+}
+
+// unsubscribe drops pairs - unknown ones are ignored
+func (s *subscriber) unsubscribe(raw []string) {
+	s.mu.Lock()
+	for _, value := range raw {
+		if p, err := s.parsePair(value); err == nil {
+			delete(s.lastSent, p)
+		}
+	}
+	s.mu.Unlock()
+
+	s.enqueue(Message{Type: "unsubscribed", Pairs: s.pairNames()})
+}
+
+// pushChanged sends every subscribed pair in quotes that moved at least
+// threshold percent since the last rate sent, and returns how many went out
+func (s *subscriber) pushChanged(quotes map[pair]models.RateQuote, threshold float64) int {
+	s.mu.Lock()
+	updates := make([]Message, 0)
+	for p, previous := range s.lastSent {
+		quote, found := quotes[p]
+		if !found || !movedEnough(previous, quote.Rate, threshold) {
+			continue
+		}
+
+		update := Message{
+			Type:      "rate",
+			From:      p.From,
+			To:        p.To,
+			Rate:      quote.Rate,
+			Previous:  previous,
+			Stale:     quote.Stale,
+			Timestamp: time.Now().UTC(),
+		}
+		if previous != 0 {
+			update.ChangePercent = (quote.Rate - previous) / previous * 100
+		}
+		updates = append(updates, update)
+		s.lastSent[p] = quote.Rate
+	}
+	s.mu.Unlock()
+
+	sent := 0
+	for _, update := range updates {
+		if s.enqueue(update) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// pairs returns the subscribed pairs
+func (s *subscriber) pairs() []pair {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pairs := make([]pair, 0, len(s.lastSent))
+	for p := range s.lastSent {
+		pairs = append(pairs, p)
+	}
+	return pairs
+}
+
+// pairNames returns the subscribed pairs as sorted "FROM-TO" strings
+func (s *subscriber) pairNames() []string {
+	pairs := s.pairs()
+	names := make([]string, len(pairs))
+	for i, p := range pairs {
+		names[i] = p.String()
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parsePair accepts "USD-EUR" (any case) for supported currencies
+func (s *subscriber) parsePair(raw string) (pair, error) {
+	from, to, ok := strings.Cut(strings.ToUpper(strings.TrimSpace(raw)), "-")
+	if !ok || from == "" || to == "" {
+		return pair{}, apperrors.New(apperrors.CodeInvalidRequest, "invalid pair %q, expected FROM-TO like USD-EUR", raw)
+	}
+	for _, code := range []string{from, to} {
+		if !s.hub.currencies.IsSupported(code) {
+			return pair{}, apperrors.New(apperrors.CodeUnsupportedCurrency, "unsupported currency: %s", code)
+		}
+	}
+	if s.policy != nil && !s.policy.AllowsPair(from, to) {
+		return pair{}, apperrors.New(apperrors.CodeForbidden, "pair %s-%s is not enabled for this API key", from, to)
+	}
+	return pair{From: from, To: to}, nil
+}
+
+func (s *subscriber) sendError(code apperrors.Code, message string) {
+	s.enqueue(Message{Type: "error", Error: message, Code: string(code), Timestamp: time.Now().UTC()})
+}
+
+// enqueue hands msg to the write loop without blocking. A client that lets
+// its buffer fill up is disconnected rather than slowing everyone's updates
+func (s *subscriber) enqueue(msg Message) bool {
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now().UTC()
+	}
+
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+
+	select {
+	case s.send <- msg:
+		return true
+	default:
+		slog.Warn("WebSocket subscriber too slow, disconnecting", "buffered", sendBuffer)
+		s.close(websocket.CloseTryAgainLater, "too slow to keep up")
+		return false
+	}
+}
+
+// close sends a close frame with code and reason (when it still can) and
+// drops the connection. Safe to call more than once, from any goroutine
+func (s *subscriber) close(code int, reason string) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		if code != websocket.CloseAbnormalClosure {
+			s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeTimeout))
+		}
+		s.conn.Close()
+	})
+}
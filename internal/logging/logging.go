@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"exchange-rate-service/internal/utils"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request id in and out (x-request-id in gRPC metadata)
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength caps ids we accept from callers
+const maxRequestIDLength = 64
+
+type contextKey struct{}
+
+// Setup installs the default slog logger. format is "json" or "text", level one
+// of debug/info/warn/error. Plain log.Printf calls end up in the same handler.
+func Setup(out io.Writer, level, format string) *slog.Logger {
+	options := &slog.HandlerOptions{Level: ParseLevel(level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(out, options)
+	} else {
+		handler = slog.NewTextHandler(out, options)
+	}
+
+	logger := slog.New(contextHandler{handler})
+	slog.SetDefault(logger)
+	return logger
+}
+
+// ParseLevel maps a LOG_LEVEL value to a slog level (info when unknown)
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithRequestID returns a context carrying the request id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, requestID)
+}
+
+// NewRequestID generates a random 16 byte hex id
+func NewRequestID() string {
+	id, err := utils.RandomID(16)
+	if err != nil {
+		return ""
+	}
+	return id
+}
+
+// ValidRequestID reports whether a caller supplied id is safe to reuse -
+// it ends up in our logs and response headers, so only short plain tokens
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RequestID returns the request id stored in ctx, if any
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(contextKey{}).(string)
+	return requestID
+}
+
+// contextHandler adds the request id (and trace id) from ctx to every record, so any
+// slog.*Context call made while serving a request is tagged with it
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	// lets a log line be matched to its trace when tracing is on
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
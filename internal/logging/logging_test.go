@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup_JSONWithRequestID(t *testing.T) {
+	var out bytes.Buffer
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+
+	logger := Setup(&out, "info", "json")
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	logger.InfoContext(ctx, "rate fetched", "pair", "USD-EUR")
+	logger.Debug("hidden at info level")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &entry); err != nil {
+		t.Fatalf("expected one JSON line, got %q: %v", out.String(), err)
+	}
+	if entry["request_id"] != "req-123" || entry["pair"] != "USD-EUR" || entry["msg"] != "rate fetched" {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+}
+
+func TestSetup_AddsTraceID(t *testing.T) {
+	var out bytes.Buffer
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+
+	logger := Setup(&out, "info", "json")
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(),
+		trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	logger.InfoContext(ctx, "traced")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &entry); err != nil {
+		t.Fatalf("expected one JSON line, got %q: %v", out.String(), err)
+	}
+	if entry["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the trace id on the line, got %v", entry)
+	}
+}
+
+func TestSetup_RoutesStandardLog(t *testing.T) {
+	var out bytes.Buffer
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+
+	Setup(&out, "info", "text")
+	log.Printf("legacy message")
+
+	if !strings.Contains(out.String(), "legacy message") || !strings.Contains(out.String(), "level=INFO") {
+		t.Errorf("expected log.Printf to go through the slog handler, got %q", out.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"WARN":  slog.LevelWarn,
+		"error": slog.LevelError,
+		"":      slog.LevelInfo,
+		"bogus": slog.LevelInfo,
+	}
+	for input, want := range tests {
+		if got := ParseLevel(input); got != want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
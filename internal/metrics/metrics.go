@@ -0,0 +1,314 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// all our metrics live under this prefix
+const namespace = "exchange_rate"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	upstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_requests_total",
+		Help:      "Calls to upstream rate providers, by provider and outcome.",
+	}, []string{"provider", "outcome"})
+
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Upstream provider call latency.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 15},
+	}, []string{"provider"})
+
+	providerUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "provider_up",
+		Help:      "1 when the provider's last health probe succeeded, 0 when it failed.",
+	}, []string{"provider"})
+
+	providerProbeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "provider_probe_duration_seconds",
+		Help:      "Provider health probe latency.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+	}, []string{"provider"})
+
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Rate cache lookups by result (hit or miss).",
+	}, []string{"result"})
+
+	cacheLookupDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cache_lookup_duration_seconds",
+		Help:      "Latest-rate cache lookup latency, backend round trip included.",
+		Buckets:   []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 2},
+	})
+
+	staleFallbacks = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stale_fallbacks_total",
+		Help:      "Expired cached rates served because the upstream failed.",
+	})
+
+	cacheEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_evictions_total",
+		Help:      "Cached rates evicted for being older than their max age.",
+	})
+
+	pairBackoffs = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pair_backoffs_total",
+		Help:      "Times a pair that kept failing refreshes was backed off (or its backoff extended).",
+	})
+
+	derivedRates = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "derived_rates_total",
+		Help:      "Latest-rate misses answered by inverting the cached opposite pair instead of fetching.",
+	})
+
+	historicalLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "historical_cache_lookups_total",
+		Help:      "Historical rate cache lookups by result (hit or miss).",
+	}, []string{"result"})
+
+	refreshDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cache_refresh_duration_seconds",
+		Help:      "Duration of full cache refresh cycles.",
+		Buckets:   []float64{0.5, 1, 5, 10, 30, 60, 120, 300},
+	})
+
+	refreshCycles = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_refresh_cycles_total",
+		Help:      "Full refresh cycles by outcome - success when at least one pair was updated.",
+	}, []string{"outcome"})
+
+	refreshPairs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_refresh_pairs_total",
+		Help:      "Currency pairs processed by refresh cycles, by outcome.",
+	}, []string{"outcome"})
+
+	refreshLeader = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "refresh_leader",
+		Help:      "1 while this instance holds the refresh lease (always 1 without coordination).",
+	})
+
+	httpInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests being served.",
+	})
+
+	httpShed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_shed_total",
+		Help:      "Requests answered 503 because a concurrency limit was reached, by limit (global or the route).",
+	}, []string{"limit"})
+
+	degradedMode = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "degraded_mode",
+		Help:      "1 while no provider can be called and only cached rates are served.",
+	})
+
+	degradedRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "degraded_rejections_total",
+		Help:      "Rate lookups answered 503 degraded because they needed the upstream, by kind (latest or historical).",
+	}, []string{"kind"})
+
+	wsConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_connections",
+		Help:      "Open WebSocket rate subscription connections.",
+	})
+
+	wsUpdates = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_updates_total",
+		Help:      "Rate updates pushed to WebSocket subscribers.",
+	})
+
+	auditDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_entries_dropped_total",
+		Help:      "Conversion audit entries dropped because the audit queue was full.",
+	})
+
+	fetchDedup = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_fetches_total",
+		Help:      "Rate fetches after a cache miss, by whether the caller made the upstream call or shared another caller's.",
+	}, []string{"result"})
+)
+
+// Handler serves the prometheus scrape endpoint
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveHTTPRequest records one handled request
+func ObserveHTTPRequest(route, method string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// RecordUpstreamCall records one provider call and whether it failed
+func RecordUpstreamCall(provider string, err error, duration time.Duration) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	upstreamRequests.WithLabelValues(provider, outcome).Inc()
+	upstreamDuration.WithLabelValues(provider).Observe(duration.Seconds())
+}
+
+// RecordProviderProbe records one health probe of provider
+func RecordProviderProbe(provider string, err error, duration time.Duration) {
+	if err != nil {
+		providerUp.WithLabelValues(provider).Set(0)
+	} else {
+		providerUp.WithLabelValues(provider).Set(1)
+	}
+	providerProbeDuration.WithLabelValues(provider).Observe(duration.Seconds())
+}
+
+// RecordCacheLookup counts a cache hit or miss
+func RecordCacheLookup(hit bool) {
+	if hit {
+		cacheLookups.WithLabelValues("hit").Inc()
+	} else {
+		cacheLookups.WithLabelValues("miss").Inc()
+	}
+}
+
+// ObserveCacheLookup records how long a latest-rate cache lookup took
+func ObserveCacheLookup(duration time.Duration) {
+	cacheLookupDuration.Observe(duration.Seconds())
+}
+
+// RecordStaleFallback counts an expired rate served during an upstream failure
+func RecordStaleFallback() {
+	staleFallbacks.Inc()
+}
+
+// RecordCacheEviction counts a cached rate evicted past its max age
+func RecordCacheEviction() {
+	cacheEvictions.Inc()
+}
+
+// RecordPairBackoff counts a failing pair being backed off
+func RecordPairBackoff() {
+	pairBackoffs.Inc()
+}
+
+// RecordDerivedRate counts a rate inverted from the cached opposite pair
+func RecordDerivedRate() {
+	derivedRates.Inc()
+}
+
+// RecordHistoricalCacheLookup counts a historical cache hit or miss
+func RecordHistoricalCacheLookup(hit bool) {
+	if hit {
+		historicalLookups.WithLabelValues("hit").Inc()
+	} else {
+		historicalLookups.WithLabelValues("miss").Inc()
+	}
+}
+
+// RecordRateFetch counts a cache-miss fetch - shared means it piggybacked on a concurrent identical fetch
+func RecordRateFetch(shared bool) {
+	if shared {
+		fetchDedup.WithLabelValues("shared").Inc()
+	} else {
+		fetchDedup.WithLabelValues("upstream").Inc()
+	}
+}
+
+// RecordAuditDropped counts an audit entry lost to a full queue
+func RecordAuditDropped() {
+	auditDropped.Inc()
+}
+
+// ObserveRefreshCycle records a finished refresh cycle
+func ObserveRefreshCycle(duration time.Duration, succeeded, failed int) {
+	refreshDuration.Observe(duration.Seconds())
+	if succeeded > 0 {
+		refreshCycles.WithLabelValues("success").Inc()
+	} else {
+		refreshCycles.WithLabelValues("error").Inc()
+	}
+	refreshPairs.WithLabelValues("success").Add(float64(succeeded))
+	refreshPairs.WithLabelValues("error").Add(float64(failed))
+}
+
+// SetRefreshLeader reports whether this instance runs the refresh loops
+func SetRefreshLeader(leader bool) {
+	if leader {
+		refreshLeader.Set(1)
+	} else {
+		refreshLeader.Set(0)
+	}
+}
+
+// SetHTTPInFlight reports the number of requests being served
+func SetHTTPInFlight(n int) {
+	httpInFlight.Set(float64(n))
+}
+
+// RecordShedRequest counts a request turned away at limit ("global" or a route path)
+func RecordShedRequest(limit string) {
+	httpShed.WithLabelValues(limit).Inc()
+}
+
+// SetDegradedMode reports whether the service is in degraded mode
+func SetDegradedMode(degraded bool) {
+	if degraded {
+		degradedMode.Set(1)
+	} else {
+		degradedMode.Set(0)
+	}
+}
+
+// RecordDegradedRejection counts a lookup turned away in degraded mode ("latest" or "historical")
+func RecordDegradedRejection(kind string) {
+	degradedRejections.WithLabelValues(kind).Inc()
+}
+
+// SetWSConnections reports the number of open WebSocket connections
+func SetWSConnections(open int) {
+	wsConnections.Set(float64(open))
+}
+
+// RecordWSUpdates counts rate updates pushed to WebSocket subscribers
+func RecordWSUpdates(sent int) {
+	wsUpdates.Add(float64(sent))
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+	notFound := httpRequests.WithLabelValues("/v1/alerts/{id}", "GET", "404")
+	ok := httpRequests.WithLabelValues("/v1/alerts/{id}", "GET", "200")
+	beforeNotFound, beforeOK := testutil.ToFloat64(notFound), testutil.ToFloat64(ok)
+	beforeSamples := sampleCount(t, "/v1/alerts/{id}", "GET")
+
+	ObserveHTTPRequest("/v1/alerts/{id}", "GET", 404, 30*time.Millisecond)
+	ObserveHTTPRequest("/v1/alerts/{id}", "GET", 404, 2*time.Second)
+	ObserveHTTPRequest("/v1/alerts/{id}", "GET", 200, time.Millisecond)
+
+	if got := testutil.ToFloat64(notFound) - beforeNotFound; got != 2 {
+		t.Errorf("expected 2 more 404s, got %v", got)
+	}
+	if got := testutil.ToFloat64(ok) - beforeOK; got != 1 {
+		t.Errorf("expected 1 more 200, got %v", got)
+	}
+	// status only labels the counter - latency is one series per route and method
+	if got := sampleCount(t, "/v1/alerts/{id}", "GET") - beforeSamples; got != 3 {
+		t.Errorf("expected 3 latency samples, got %d", got)
+	}
+}
+
+func TestHTTPMetricsAreRegistered(t *testing.T) {
+	ObserveHTTPRequest("/v1/convert", "GET", 200, time.Millisecond)
+	count, err := testutil.GatherAndCount(prometheus.DefaultGatherer,
+		namespace+"_http_requests_total", namespace+"_http_request_duration_seconds")
+	if err != nil || count < 2 {
+		t.Errorf("expected both HTTP metrics on the default registry, got %d series (%v)", count, err)
+	}
+}
+
+// sampleCount is the number of observations in the latency series for route and method
+func sampleCount(t *testing.T, route, method string) uint64 {
+	t.Helper()
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(httpDuration.WithLabelValues(route, method).(prometheus.Histogram))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return families[0].GetMetric()[0].GetHistogram().GetSampleCount()
+}
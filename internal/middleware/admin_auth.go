@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"exchange-rate-service/internal/auth"
+	"exchange-rate-service/internal/utils"
+)
+
+// AdminAuth guards operator endpoints with bearer tokens that are separate
+// from client API keys, so handing out an API key never grants admin access
+type AdminAuth struct {
+	tokens [][]byte
+}
+
+// NewAdminAuth creates the middleware - with no tokens every request is rejected
+func NewAdminAuth(tokens []string) *AdminAuth {
+	admin := &AdminAuth{}
+	for _, token := range tokens {
+		if token = strings.TrimSpace(token); token != "" {
+			admin.tokens = append(admin.tokens, []byte(token))
+		}
+	}
+	return admin
+}
+
+// Enabled reports whether any admin token is configured
+func (a *AdminAuth) Enabled() bool {
+	return len(a.tokens) > 0
+}
+
+// Middleware requires "Authorization: Bearer <admin token>", or a JWT with the
+// admin role when JWT auth verified one earlier in the chain
+func (a *AdminAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+			if !claims.HasRole(auth.RoleAdmin) {
+				slog.WarnContext(r.Context(), "Rejected admin request: token lacks the admin role",
+					"method", r.Method, "path", r.URL.Path, "subject", claims.Subject)
+				utils.ErrorResp(w, http.StatusForbidden, "token lacks the admin role")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || token == "" {
+			utils.ErrorResp(w, http.StatusUnauthorized, "missing admin token")
+			return
+		}
+
+		if !a.valid([]byte(token)) {
+			slog.WarnContext(r.Context(), "Rejected admin request: invalid token",
+				"method", r.Method, "path", r.URL.Path, "remote_addr", r.RemoteAddr)
+			utils.ErrorResp(w, http.StatusUnauthorized, "invalid admin token")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// valid compares against every token in constant time
+func (a *AdminAuth) valid(token []byte) bool {
+	match := 0
+	for _, candidate := range a.tokens {
+		match |= subtle.ConstantTimeCompare(token, candidate)
+	}
+	return match == 1
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminAuth(t *testing.T) {
+	admin := NewAdminAuth([]string{"first-token", " second-token ", ""})
+	if !admin.Enabled() {
+		t.Fatal("expected admin auth to be enabled")
+	}
+
+	handler := admin.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"no header", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic first-token", http.StatusUnauthorized},
+		{"empty token", "Bearer ", http.StatusUnauthorized},
+		{"unknown token", "Bearer nope", http.StatusUnauthorized},
+		{"token prefix", "Bearer first", http.StatusUnauthorized},
+		{"first token", "Bearer first-token", http.StatusOK},
+		{"trimmed token", "Bearer second-token", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/admin/cache/stats", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAdminAuth_NoTokens(t *testing.T) {
+	if NewAdminAuth(nil).Enabled() {
+		t.Error("admin auth without tokens should report disabled")
+	}
+}
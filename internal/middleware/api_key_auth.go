@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/auth"
+	"exchange-rate-service/internal/ratelimit"
+	"exchange-rate-service/internal/utils"
+)
+
+// APIKeyHeader carries the client's API key
+const APIKeyHeader = "X-API-Key"
+
+// auth failures - shared with the gRPC server so both transports behave the same
+var (
+	ErrMissingAPIKey     = errors.New("missing API key")
+	ErrInvalidAPIKey     = errors.New("invalid API key")
+	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+)
+
+// APIKeyAuth authenticates requests by X-API-Key and enforces each key's
+// requests-per-minute budget with a token bucket
+type APIKeyAuth struct {
+	keys        *auth.KeyStore
+	publicPaths map[string]bool
+
+	mu       sync.Mutex
+	limiters map[string]*ratelimit.TokenBucket
+}
+
+// NewAPIKeyAuth creates the middleware - publicPaths skip auth entirely
+func NewAPIKeyAuth(keys *auth.KeyStore, publicPaths ...string) *APIKeyAuth {
+	public := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+
+	return &APIKeyAuth{
+		keys:        keys,
+		publicPaths: public,
+		limiters:    make(map[string]*ratelimit.TokenBucket),
+	}
+}
+
+// Authenticate resolves key to a client and charges one request to its budget.
+// retryAfter is set when the key is over budget
+func (a *APIKeyAuth) Authenticate(key string) (client *auth.Client, retryAfter time.Duration, err error) {
+	if key == "" {
+		return nil, 0, ErrMissingAPIKey
+	}
+
+	client, found := a.keys.Lookup(key)
+	if !found {
+		return nil, 0, ErrInvalidAPIKey
+	}
+
+	allowed, wait := a.limiterFor(client).Reserve()
+	if !allowed {
+		return client, wait, ErrRateLimitExceeded
+	}
+
+	return client, 0, nil
+}
+
+// Middleware rejects unknown keys (401) and keys over budget (429)
+func (a *APIKeyAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// public, or already authenticated by a verified JWT
+		if _, ok := auth.ClaimsFromContext(r.Context()); ok || a.publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client, wait, err := a.Authenticate(r.Header.Get(APIKeyHeader))
+		if client == nil {
+			utils.ErrorResp(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(client.RequestsPerMinute))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(a.limiterFor(client).Remaining()))
+
+		if err != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			utils.ErrorResp(w, http.StatusTooManyRequests, err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithClient(r.Context(), client)))
+	})
+}
+
+// limiterFor returns the per-key bucket, creating it on first use
+func (a *APIKeyAuth) limiterFor(client *auth.Client) *ratelimit.TokenBucket {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	limiter, found := a.limiters[client.Key]
+	if !found {
+		limiter = ratelimit.NewTokenBucket(client.RequestsPerMinute, 0)
+		a.limiters[client.Key] = limiter
+	}
+	return limiter
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"exchange-rate-service/internal/auth"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	store, err := auth.LoadKeyStore([]string{"good-key:2"}, "", 60)
+	if err != nil {
+		t.Fatalf("LoadKeyStore failed: %v", err)
+	}
+
+	var seenClient string
+	handler := NewAPIKeyAuth(store, "/health").Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if client, ok := auth.ClientFromContext(r.Context()); ok {
+			seenClient = client.Key
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("/health", ""); rec.Code != http.StatusOK {
+		t.Errorf("public path should not need a key, got %d", rec.Code)
+	}
+	if rec := serve("/rate/latest", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("missing key should be 401, got %d", rec.Code)
+	}
+	if rec := serve("/rate/latest", "bad-key"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown key should be 401, got %d", rec.Code)
+	}
+
+	// burst of 2 allowed, third request is over budget
+	for i := 0; i < 2; i++ {
+		if rec := serve("/rate/latest", "good-key"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d within budget should pass, got %d", i+1, rec.Code)
+		}
+	}
+	if seenClient != "good-key" {
+		t.Errorf("authenticated client should be in the request context")
+	}
+
+	rec := serve("/rate/latest", "good-key")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("over-budget request should be 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("429 response should carry Retry-After")
+	}
+}
@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// content types worth compressing - everything else (images, already
+// compressed payloads) is passed through
+var compressibleTypes = []string{
+	"application/json",
+	"application/xml",
+	"application/javascript",
+	"text/",
+}
+
+// Compress gzips (or deflates) responses for clients that accept it. Bodies
+// under minBytes go out as they are - the headers would outweigh the saving.
+// Upgrades (/ws) and responses a handler already encoded itself (promhttp
+// does) are left alone
+func Compress(minBytes int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minBytes: minBytes}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks gzip, else deflate, from an Accept-Encoding header -
+// "" when neither is acceptable (q=0 rules an encoding out)
+func negotiateEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		accepted[name] = quality > 0
+	}
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if ok, listed := accepted[encoding]; ok || (!listed && accepted["*"]) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// compressWriter holds back the first minBytes of the body so it can tell
+// small responses (sent uncompressed) from large ones
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minBytes int
+
+	status      int
+	wroteHeader bool // status recorded, not yet sent
+	decided     bool // compressing or passing through from here on
+	buffer      []byte
+	encoder     io.WriteCloser
+	hijacked    bool
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader || cw.decided {
+		return
+	}
+	cw.status = status
+	cw.wroteHeader = true
+
+	// informational and bodiless responses can go straight out
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		cw.passThrough()
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.decided {
+		if cw.encoder != nil {
+			return cw.encoder.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buffer = append(cw.buffer, p...)
+	if len(cw.buffer) >= cw.minBytes {
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush commits to a decision with whatever is buffered, so streamed
+// responses (time series) keep streaming
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if !cw.wroteHeader {
+			cw.WriteHeader(http.StatusOK)
+		}
+		if err := cw.decide(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets WebSocket upgrades through the wrapper
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	cw.hijacked = true
+	return hijacker.Hijack()
+}
+
+// Close sends a body that never reached minBytes, or finishes the compressed stream
+func (cw *compressWriter) Close() error {
+	if cw.hijacked {
+		return nil
+	}
+	if !cw.decided {
+		if !cw.wroteHeader {
+			// handler wrote nothing at all - let net/http send its default
+			return nil
+		}
+		cw.passThrough()
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Close()
+	}
+	return nil
+}
+
+// decide compresses when the response is big enough, of a compressible type
+// and not already encoded, then sends the headers and the buffered bytes
+func (cw *compressWriter) decide() error {
+	header := cw.Header()
+	if len(cw.buffer) < cw.minBytes || header.Get("Content-Encoding") != "" || !compressible(header.Get("Content-Type"), cw.buffer) {
+		cw.passThrough()
+		return nil
+	}
+
+	cw.decided = true
+	header.Set("Content-Encoding", cw.encoding)
+	header.Add("Vary", "Accept-Encoding")
+	// the length changes - whatever the handler set no longer holds
+	header.Del("Content-Length")
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	if cw.encoding == "gzip" {
+		cw.encoder = gzip.NewWriter(cw.ResponseWriter)
+	} else {
+		cw.encoder = zlib.NewWriter(cw.ResponseWriter)
+	}
+	buffered := cw.buffer
+	cw.buffer = nil
+	_, err := cw.encoder.Write(buffered)
+	return err
+}
+
+// passThrough sends the headers and buffered bytes as they are
+func (cw *compressWriter) passThrough() {
+	cw.decided = true
+	if cw.status != 0 && cw.status != http.StatusSwitchingProtocols {
+		if compressible(cw.Header().Get("Content-Type"), cw.buffer) {
+			// a cache must not hand this uncompressed copy to a gzip client or vice versa
+			cw.Header().Add("Vary", "Accept-Encoding")
+		}
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+	if len(cw.buffer) > 0 {
+		cw.ResponseWriter.Write(cw.buffer)
+		cw.buffer = nil
+	}
+}
+
+// compressible reports whether a body of this type is worth compressing,
+// sniffing the type when the handler didn't set one
+func compressible(contentType string, body []byte) bool {
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
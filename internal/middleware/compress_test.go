@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func jsonHandler(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+}
+
+func TestCompress_GzipsLargeResponses(t *testing.T) {
+	body := `{"rates":"` + strings.Repeat("1.2345,", 500) + `"}`
+	handler := Compress(1024)(jsonHandler(body))
+
+	req := httptest.NewRequest("GET", "/rate/timeseries", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip encoding, got %q", rec.Header().Get("Content-Encoding"))
+	}
+	if rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("expected Vary: Accept-Encoding, got %q", rec.Header().Get("Vary"))
+	}
+	if rec.Body.Len() >= len(body) {
+		t.Errorf("expected a smaller body, got %d bytes for %d", rec.Body.Len(), len(body))
+	}
+
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("invalid gzip stream: %v", err)
+	}
+	decoded, _ := io.ReadAll(reader)
+	if string(decoded) != body {
+		t.Error("decompressed body doesn't match the original")
+	}
+}
+
+func TestCompress_DeflateWhenGzipRefused(t *testing.T) {
+	body := strings.Repeat("a", 2048)
+	handler := Compress(1024)(jsonHandler(body))
+
+	req := httptest.NewRequest("GET", "/rates", nil)
+	req.Header.Set("Accept-Encoding", "gzip;q=0, deflate")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatalf("expected deflate encoding, got %q", rec.Header().Get("Content-Encoding"))
+	}
+	reader, err := zlib.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("invalid deflate stream: %v", err)
+	}
+	decoded, _ := io.ReadAll(reader)
+	if string(decoded) != body {
+		t.Error("decompressed body doesn't match the original")
+	}
+}
+
+func TestCompress_PassesThroughSmallOrUnacceptedResponses(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		body           string
+	}{
+		{"below min size", "gzip", `{"rate":1.08}`},
+		{"no accept-encoding", "", strings.Repeat("x", 4096)},
+		{"identity only", "identity, *;q=0", strings.Repeat("x", 4096)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Compress(1024)(jsonHandler(tt.body))
+			req := httptest.NewRequest("GET", "/convert", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Header().Get("Content-Encoding") != "" {
+				t.Errorf("expected no encoding, got %q", rec.Header().Get("Content-Encoding"))
+			}
+			if rec.Body.String() != tt.body {
+				t.Error("body should be passed through unchanged")
+			}
+		})
+	}
+}
+
+func TestCompress_LeavesEncodedResponsesAndStatusAlone(t *testing.T) {
+	// promhttp gzips /metrics itself - it mustn't be compressed twice
+	preEncoded := strings.Repeat("z", 2048)
+	handler := Compress(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(preEncoded))
+	}))
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("expected status to be kept, got %d", rec.Code)
+	}
+	if rec.Body.String() != preEncoded {
+		t.Error("pre-encoded body should be passed through")
+	}
+}
+
+func TestCompress_FlushStreams(t *testing.T) {
+	handler := Compress(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"chunk":1}`))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(`{"chunk":2}`))
+	}))
+
+	req := httptest.NewRequest("GET", "/rate/timeseries", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !rec.Flushed {
+		t.Error("expected flush to reach the underlying writer")
+	}
+	if rec.Body.String() != `{"chunk":1}{"chunk":2}` {
+		t.Errorf("expected both chunks uncompressed, got %q", rec.Body.String())
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                      "",
+		"gzip":                  "gzip",
+		"GZIP, deflate":         "gzip",
+		"deflate":               "deflate",
+		"*":                     "gzip",
+		"*, gzip;q=0":           "deflate",
+		"gzip;q=0, deflate;q=0": "",
+		"br":                    "",
+	}
+	for header, want := range tests {
+		if got := negotiateEncoding(header); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/metrics"
+	"exchange-rate-service/internal/utils"
+)
+
+// ConcurrencyLimit caps how many requests are served at once, overall and for
+// individual routes. Over a cap a request is answered 503 with Retry-After
+// straight away rather than queued, so a spike of expensive calls can't use up
+// the provider quota or starve the health probes
+type ConcurrencyLimit struct {
+	global chan struct{}
+	routes map[string]chan struct{}
+	exempt map[string]bool
+}
+
+// NewConcurrencyLimit creates a limiter allowing global requests at once
+// (0 for no overall cap). Requests for the exempt paths are never limited
+func NewConcurrencyLimit(global int, exempt ...string) *ConcurrencyLimit {
+	l := &ConcurrencyLimit{
+		routes: make(map[string]chan struct{}),
+		exempt: make(map[string]bool, len(exempt)),
+	}
+	if global > 0 {
+		l.global = make(chan struct{}, global)
+	}
+	for _, path := range exempt {
+		l.exempt[path] = true
+	}
+	return l
+}
+
+// Add caps requests for path at limit, on top of the overall cap
+func (l *ConcurrencyLimit) Add(path string, limit int) {
+	if limit > 0 {
+		l.routes[path] = make(chan struct{}, limit)
+	}
+}
+
+// Enabled reports whether any cap is configured
+func (l *ConcurrencyLimit) Enabled() bool {
+	return l.global != nil || len(l.routes) > 0
+}
+
+// Middleware takes the route's slot, then an overall one, for the length of the request
+func (l *ConcurrencyLimit) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.exempt[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if route, limited := l.routes[r.URL.Path]; limited {
+			if !acquireSlot(route) {
+				l.shed(w, r, r.URL.Path)
+				return
+			}
+			defer releaseSlot(route)
+		}
+		if l.global != nil {
+			if !acquireSlot(l.global) {
+				l.shed(w, r, "global")
+				return
+			}
+			defer releaseSlot(l.global)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (l *ConcurrencyLimit) shed(w http.ResponseWriter, r *http.Request, limit string) {
+	metrics.RecordShedRequest(limit)
+	slog.WarnContext(r.Context(), "Request shed at concurrency limit", "limit", limit)
+	w.Header().Set("Retry-After", "1")
+	utils.ErrorRespWithCode(w, http.StatusServiceUnavailable, string(apperrors.CodeOverloaded),
+		"too many requests in progress, retry shortly")
+}
+
+// acquireSlot takes a slot without waiting, false when none is free
+func acquireSlot(slots chan struct{}) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func releaseSlot(slots chan struct{}) {
+	<-slots
+}
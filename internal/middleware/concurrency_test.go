@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// blockingHandler holds every request until release is closed, signalling
+// entered as each one arrives
+func blockingHandler(entered chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func serve(handler http.Handler, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	return rec
+}
+
+func TestConcurrencyLimit_RouteCap(t *testing.T) {
+	limit := NewConcurrencyLimit(0, "/health")
+	limit.Add("/v1/rate/timeseries", 1)
+
+	entered := make(chan struct{}, 4)
+	release := make(chan struct{})
+	handler := limit.Middleware(blockingHandler(entered, release))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		serve(handler, "/v1/rate/timeseries")
+	}()
+	<-entered
+
+	rec := serve(handler, "/v1/rate/timeseries")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected a 503 with Retry-After over the route cap, got %d %v", rec.Code, rec.Header())
+	}
+	if !strings.Contains(rec.Body.String(), `"overloaded"`) {
+		t.Errorf("expected the overloaded code, got %s", rec.Body.String())
+	}
+
+	// other routes aren't held by this one's cap
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if rec := serve(handler, "/v1/rate/latest"); rec.Code != http.StatusOK {
+			t.Errorf("expected an uncapped route through, got %d", rec.Code)
+		}
+	}()
+	<-entered
+
+	close(release)
+	wg.Wait()
+
+	// the slot is given back once the request is done
+	if rec := serve(handler, "/v1/rate/timeseries"); rec.Code != http.StatusOK {
+		t.Errorf("expected the route through after the slot was freed, got %d", rec.Code)
+	}
+}
+
+func TestConcurrencyLimit_GlobalCapSparesExemptPaths(t *testing.T) {
+	limit := NewConcurrencyLimit(1, "/health")
+
+	entered := make(chan struct{}, 4)
+	release := make(chan struct{})
+	handler := limit.Middleware(blockingHandler(entered, release))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		serve(handler, "/v1/convert")
+	}()
+	<-entered
+
+	if rec := serve(handler, "/v1/rate/latest"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected a 503 over the global cap, got %d", rec.Code)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if rec := serve(handler, "/health"); rec.Code != http.StatusOK {
+			t.Errorf("expected health checks through while saturated, got %d", rec.Code)
+		}
+	}()
+	<-entered
+
+	close(release)
+	wg.Wait()
+}
+
+func TestConcurrencyLimit_Enabled(t *testing.T) {
+	if NewConcurrencyLimit(0).Enabled() {
+		t.Error("expected no limits without caps")
+	}
+	limit := NewConcurrencyLimit(0)
+	limit.Add("/v1/rate/historical", 0)
+	if limit.Enabled() {
+		t.Error("expected a zero route cap to be ignored")
+	}
+	limit.Add("/v1/rate/historical", 5)
+	if !limit.Enabled() {
+		t.Error("expected a route cap to enable the limiter")
+	}
+}
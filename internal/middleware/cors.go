@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures cross-origin access for browser clients
+type CORSOptions struct {
+	// "*" allows any origin; "https://*.example.com" any subdomain of example.com
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// CORS answers preflight requests and adds the Access-Control headers browsers
+// need to call the API from another origin. It has to wrap the router rather
+// than sit inside it: preflights carry no API key and hit routes registered
+// for other methods only
+type CORS struct {
+	anyOrigin bool
+	origins   map[string]bool
+	wildcards []originPattern
+
+	methods     map[string]bool
+	methodList  string
+	headers     map[string]bool
+	headerList  string
+	exposedList string
+	credentials bool
+	maxAge      string
+}
+
+// originPattern matches any subdomain: scheme "https://", suffix ".example.com"
+type originPattern struct {
+	scheme string
+	suffix string
+}
+
+// NewCORS validates the options - CORS is off when no origin is allowed
+func NewCORS(opts CORSOptions) (*CORS, error) {
+	c := &CORS{
+		origins:     make(map[string]bool),
+		methods:     make(map[string]bool),
+		headers:     make(map[string]bool),
+		credentials: opts.AllowCredentials,
+	}
+
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.TrimRight(strings.ToLower(strings.TrimSpace(origin)), "/")
+		switch {
+		case origin == "":
+			continue
+		case origin == "*":
+			c.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			scheme, host, ok := strings.Cut(origin, "://*.")
+			if !ok || strings.Contains(host, "*") {
+				return nil, fmt.Errorf("invalid origin pattern %q (expected e.g. https://*.example.com)", origin)
+			}
+			c.wildcards = append(c.wildcards, originPattern{scheme: scheme + "://", suffix: "." + host})
+		case !strings.Contains(origin, "://"):
+			return nil, fmt.Errorf("invalid origin %q (expected scheme://host[:port])", origin)
+		default:
+			c.origins[origin] = true
+		}
+	}
+	// reflecting any origin with credentials would let every site make
+	// authenticated calls - the combination browsers refuse for "*"
+	if c.anyOrigin && c.credentials {
+		return nil, fmt.Errorf("origin \"*\" can't be combined with credentials, list the allowed origins instead")
+	}
+
+	methods := make([]string, 0, len(opts.AllowedMethods))
+	for _, method := range opts.AllowedMethods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if method != "" && !c.methods[method] {
+			c.methods[method] = true
+			methods = append(methods, method)
+		}
+	}
+	c.methodList = strings.Join(methods, ", ")
+
+	headers := make([]string, 0, len(opts.AllowedHeaders))
+	for _, header := range opts.AllowedHeaders {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		if header != "" && !c.headers[header] {
+			c.headers[header] = true
+			headers = append(headers, header)
+		}
+	}
+	c.headerList = strings.Join(headers, ", ")
+
+	exposed := make([]string, 0, len(opts.ExposedHeaders))
+	for _, header := range opts.ExposedHeaders {
+		if header = http.CanonicalHeaderKey(strings.TrimSpace(header)); header != "" {
+			exposed = append(exposed, header)
+		}
+	}
+	c.exposedList = strings.Join(exposed, ", ")
+
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(opts.MaxAge.Seconds()))
+	}
+	return c, nil
+}
+
+// Enabled reports whether any origin is allowed
+func (c *CORS) Enabled() bool {
+	return c.anyOrigin || len(c.origins) > 0 || len(c.wildcards) > 0
+}
+
+// Middleware answers preflights itself and decorates every other
+// cross-origin response. Disallowed origins get no CORS headers, which the
+// browser enforces - the request itself is not rejected
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if c.allowedOrigin(origin) && c.allowedPreflight(r) {
+				c.setOriginHeaders(w, origin)
+				w.Header().Set("Access-Control-Allow-Methods", c.methodList)
+				if c.headerList != "" {
+					w.Header().Set("Access-Control-Allow-Headers", c.headerList)
+				}
+				if c.maxAge != "" {
+					w.Header().Set("Access-Control-Max-Age", c.maxAge)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if c.allowedOrigin(origin) {
+			c.setOriginHeaders(w, origin)
+			if c.exposedList != "" {
+				w.Header().Set("Access-Control-Expose-Headers", c.exposedList)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setOriginHeaders names the allowed origin - "*" when any origin is allowed
+// (never with credentials, NewCORS refuses that), the caller's otherwise
+func (c *CORS) setOriginHeaders(w http.ResponseWriter, origin string) {
+	if c.anyOrigin {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowedOrigin matches origin exactly, or against a subdomain pattern
+func (c *CORS) allowedOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if c.origins[origin] {
+		return true
+	}
+	for _, pattern := range c.wildcards {
+		if strings.HasPrefix(origin, pattern.scheme) && strings.HasSuffix(origin, pattern.suffix) &&
+			len(origin) > len(pattern.scheme)+len(pattern.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedPreflight checks the method and every header the browser asks to send
+func (c *CORS) allowedPreflight(r *http.Request) bool {
+	if !c.methods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] {
+		return false
+	}
+	for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		if header != "" && !c.headers[header] {
+			return false
+		}
+	}
+	return true
+}
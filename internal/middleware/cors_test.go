@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestCORS(t *testing.T, opts CORSOptions) http.Handler {
+	t.Helper()
+	if opts.AllowedMethods == nil {
+		opts.AllowedMethods = []string{"GET", "POST"}
+	}
+	if opts.AllowedHeaders == nil {
+		opts.AllowedHeaders = []string{"Content-Type", "X-API-Key"}
+	}
+	cors, err := NewCORS(opts)
+	if err != nil {
+		t.Fatalf("NewCORS: %v", err)
+	}
+	return cors.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	handler := newTestCORS(t, CORSOptions{
+		AllowedOrigins: []string{"https://dashboard.example.com"},
+		MaxAge:         10 * time.Minute,
+	})
+
+	req := httptest.NewRequest("OPTIONS", "/v1/rate/timeseries", nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	req.Header.Set("Access-Control-Request-Headers", "x-api-key")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://dashboard.example.com" {
+		t.Errorf("unexpected allow-origin %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Errorf("unexpected allow-methods %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type, X-Api-Key" {
+		t.Errorf("unexpected allow-headers %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("unexpected max-age %q", got)
+	}
+}
+
+func TestCORS_PreflightRejections(t *testing.T) {
+	handler := newTestCORS(t, CORSOptions{AllowedOrigins: []string{"https://dashboard.example.com"}})
+
+	tests := []struct {
+		name    string
+		origin  string
+		method  string
+		headers string
+	}{
+		{"unknown origin", "https://evil.example.net", "GET", ""},
+		{"method not allowed", "https://dashboard.example.com", "DELETE", ""},
+		{"header not allowed", "https://dashboard.example.com", "GET", "X-Custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("OPTIONS", "/v1/convert", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+				t.Errorf("expected no allow-origin, got %q", rec.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+func TestCORS_ActualRequests(t *testing.T) {
+	handler := newTestCORS(t, CORSOptions{
+		AllowedOrigins: []string{"https://*.example.com"},
+		ExposedHeaders: []string{"X-Request-ID"},
+	})
+
+	req := httptest.NewRequest("GET", "/v1/rates", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("expected subdomain to be allowed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "X-Request-Id" {
+		t.Errorf("unexpected expose-headers %q", got)
+	}
+	if rec.Header().Get("Vary") != "Origin" {
+		t.Errorf("expected Vary: Origin, got %q", rec.Header().Get("Vary"))
+	}
+
+	// the bare domain doesn't match a subdomain pattern, but is still served
+	req = httptest.NewRequest("GET", "/v1/rates", nil)
+	req.Header.Set("Origin", "https://example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("expected bare domain not to match the subdomain pattern")
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected request to reach the handler, got %d", rec.Code)
+	}
+}
+
+func TestCORS_CredentialsNeedListedOrigins(t *testing.T) {
+	if _, err := NewCORS(CORSOptions{AllowedOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true}); err == nil {
+		t.Error("expected any origin with credentials to be rejected")
+	}
+
+	handler := newTestCORS(t, CORSOptions{AllowedOrigins: []string{"http://localhost:3000"}, AllowCredentials: true})
+	req := httptest.NewRequest("GET", "/v1/rates", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+		t.Errorf("expected the listed origin to be echoed, got %q", got)
+	}
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("expected allow-credentials")
+	}
+}
+
+func TestNewCORS_Validation(t *testing.T) {
+	for _, origin := range []string{"example.com", "https://*", "https://a.*.example.com"} {
+		if _, err := NewCORS(CORSOptions{AllowedOrigins: []string{origin}}); err == nil {
+			t.Errorf("expected origin %q to be rejected", origin)
+		}
+	}
+
+	cors, err := NewCORS(CORSOptions{})
+	if err != nil {
+		t.Fatalf("NewCORS: %v", err)
+	}
+	if cors.Enabled() {
+		t.Error("expected CORS to be off without origins")
+	}
+}
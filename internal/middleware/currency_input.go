@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// CurrencyResolver turns a typed currency into its ISO code
+type CurrencyResolver interface {
+	Resolve(input string) (string, error)
+}
+
+// CurrencyInput rewrites the currency parameters of a query string - symbols
+// like € and names like "euro" become ISO codes - before validation and the
+// handler see them. A symbol shared by several currencies is answered 400.
+// Routes without a rule are left alone
+type CurrencyInput struct {
+	resolver CurrencyResolver
+	rules    map[string]QueryRule // "GET /v1/convert"
+}
+
+// NewCurrencyInput resolves currencies through resolver
+func NewCurrencyInput(resolver CurrencyResolver) *CurrencyInput {
+	return &CurrencyInput{
+		resolver: resolver,
+		rules:    make(map[string]QueryRule),
+	}
+}
+
+// Add resolves rule's Currencies and CurrencyLists parameters for method on
+// the route registered as path
+func (c *CurrencyInput) Add(method, path string, rule QueryRule) {
+	if len(rule.Currencies) == 0 && len(rule.CurrencyLists) == 0 {
+		return
+	}
+	c.rules[strings.ToUpper(method)+" "+path] = rule
+}
+
+// Middleware must run as router middleware, after mux has matched, and ahead
+// of the QueryValidator so it checks the resolved codes
+func (c *CurrencyInput) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil || r.URL.RawQuery == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rule, found := c.rules[r.Method+" "+path]
+		if !found {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		query := r.URL.Query()
+		changed := false
+		fields := make(map[string]string)
+		resolve := func(name string, list bool) {
+			values, present := query[name]
+			if !present || len(values) != 1 {
+				return // missing and repeated parameters are the validator's business
+			}
+			inputs := []string{values[0]}
+			if list {
+				inputs = strings.Split(values[0], ",")
+			}
+			for i, input := range inputs {
+				code, err := c.resolver.Resolve(input)
+				var ambiguous *currency.AmbiguousError
+				if errors.As(err, &ambiguous) {
+					fields[name] = ambiguous.Error()
+					return
+				}
+				if err == nil && code != strings.TrimSpace(input) {
+					inputs[i] = code
+					changed = true
+				}
+			}
+			query.Set(name, strings.Join(inputs, ","))
+		}
+		for _, name := range rule.Currencies {
+			resolve(name, false)
+		}
+		for _, name := range rule.CurrencyLists {
+			resolve(name, true)
+		}
+
+		if len(fields) > 0 {
+			utils.FieldErrorResp(w, "invalid query parameters", fields)
+			return
+		}
+		if changed {
+			r.URL.RawQuery = query.Encode()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"exchange-rate-service/internal/currency"
+
+	"github.com/gorilla/mux"
+)
+
+func newCurrencyInputRouter(seen *url.Values) *mux.Router {
+	registry := currency.NewRegistry([]string{"USD", "EUR", "INR", "GBP", "JPY"})
+	input := NewCurrencyInput(registry)
+	input.Add("GET", "/v1/convert", QueryRule{Currencies: []string{"from", "to"}})
+	input.Add("GET", "/v1/convert/multi", QueryRule{Currencies: []string{"from"}, CurrencyLists: []string{"to"}})
+
+	record := func(w http.ResponseWriter, r *http.Request) {
+		*seen = r.URL.Query()
+		w.WriteHeader(http.StatusOK)
+	}
+	router := mux.NewRouter()
+	v1 := router.PathPrefix("/v1").Subrouter()
+	v1.HandleFunc("/convert", record).Methods("GET")
+	v1.HandleFunc("/convert/multi", record).Methods("GET")
+	router.Use(input.Middleware)
+	return router
+}
+
+func TestCurrencyInput_ResolvesSymbolsAndNames(t *testing.T) {
+	var seen url.Values
+	router := newCurrencyInputRouter(&seen)
+
+	tests := []struct {
+		url      string
+		from, to string
+	}{
+		{"/v1/convert?from=%E2%82%AC&to=rupee&amount=10", "EUR", "INR"},
+		{"/v1/convert?from=usd&to=Pounds&amount=10", "USD", "GBP"},
+		{"/v1/convert?from=USD&to=EUR&amount=10", "USD", "EUR"},
+		{"/v1/convert/multi?from=euro&to=%C2%A3,yen,USD", "EUR", "GBP,JPY,USD"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", tt.url, nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", tt.url, rec.Code)
+		}
+		if seen.Get("from") != tt.from || seen.Get("to") != tt.to {
+			t.Errorf("%s: handler saw from=%q to=%q", tt.url, seen.Get("from"), seen.Get("to"))
+		}
+		if seen.Get("amount") != "" && seen.Get("amount") != "10" {
+			t.Errorf("%s: other parameters should pass through, got amount=%q", tt.url, seen.Get("amount"))
+		}
+	}
+}
+
+func TestCurrencyInput_RejectsAmbiguousSymbols(t *testing.T) {
+	var seen url.Values
+	router := newCurrencyInputRouter(&seen)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/convert/multi?from=EUR&to=GBP,%C2%A5", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	var body struct {
+		Fields map[string]string `json:"fields"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("invalid error body: %v", err)
+	}
+	if want := `"¥" is ambiguous, it could be CNY, JPY - use the ISO code`; body.Fields["to"] != want {
+		t.Errorf("expected %q, got %v", want, body.Fields)
+	}
+}
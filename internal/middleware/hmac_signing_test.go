@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signedRequest(secret, timestamp, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/convert?from=USD&to=EUR", strings.NewReader(body))
+	sig := ComputeSignature(secret, req.Method, req.URL.RequestURI(), timestamp, []byte(body))
+
+	req.Header.Set(SignatureKeyIDHeader, "partner")
+	req.Header.Set(SignatureTimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, hex.EncodeToString(sig))
+	return req
+}
+
+func serveSigned(rs *RequestSigner, req *http.Request) int {
+	handler := rs.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRequestSigner_ValidAndReplay(t *testing.T) {
+	rs := NewRequestSigner(map[string]string{"partner": "s3cret"}, true, time.Minute)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	if code := serveSigned(rs, signedRequest("s3cret", now, `{"amount":"10"}`)); code != http.StatusOK {
+		t.Fatalf("valid signature should pass, got %d", code)
+	}
+
+	// Same signed request again must be rejected
+	if code := serveSigned(rs, signedRequest("s3cret", now, `{"amount":"10"}`)); code != http.StatusUnauthorized {
+		t.Errorf("replayed request should be rejected, got %d", code)
+	}
+}
+
+func TestRequestSigner_Rejections(t *testing.T) {
+	rs := NewRequestSigner(map[string]string{"partner": "s3cret"}, true, time.Minute)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	if code := serveSigned(rs, signedRequest("wrong", now, "")); code != http.StatusUnauthorized {
+		t.Errorf("wrong secret should be rejected, got %d", code)
+	}
+	if code := serveSigned(rs, signedRequest("s3cret", stale, "")); code != http.StatusUnauthorized {
+		t.Errorf("stale timestamp should be rejected, got %d", code)
+	}
+
+	// Body tampered after signing
+	req := signedRequest("s3cret", now, `{"amount":"10"}`)
+	req.Body = httptest.NewRequest("POST", "/", strings.NewReader(`{"amount":"99"}`)).Body
+	if code := serveSigned(rs, req); code != http.StatusUnauthorized {
+		t.Errorf("tampered body should be rejected, got %d", code)
+	}
+
+	unsigned := httptest.NewRequest("GET", "/rate/latest", nil)
+	if code := serveSigned(rs, unsigned); code != http.StatusUnauthorized {
+		t.Errorf("unsigned request should be rejected when required, got %d", code)
+	}
+}
+
+func TestRequestSigner_OptionalAllowsUnsigned(t *testing.T) {
+	rs := NewRequestSigner(map[string]string{"partner": "s3cret"}, false, time.Minute)
+
+	unsigned := httptest.NewRequest("GET", "/rate/latest", nil)
+	if code := serveSigned(rs, unsigned); code != http.StatusOK {
+		t.Errorf("unsigned request should pass when signing is optional, got %d", code)
+	}
+}
@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/auth"
+	"exchange-rate-service/internal/logging"
+	"exchange-rate-service/internal/utils"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client's key for a retryable POST
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response replayed from the first request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// bodies are read up front to fingerprint them - POST bodies here are small
+	maxIdempotentBodyBytes = 64 << 10
+	// how long a first request may run before a retry is let through again
+	idempotencyLockTTL = time.Minute
+)
+
+// IdempotencyStore keeps the first response to each caller's key and locks a
+// key while its first request runs
+type IdempotencyStore interface {
+	Lock(ctx context.Context, owner, key, token string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, owner, key, token string) error
+	Get(ctx context.Context, owner, key string) ([]byte, bool, error)
+	Save(ctx context.Context, owner, key string, response []byte) error
+}
+
+// storedResponse is what gets replayed. Fingerprint identifies the request,
+// so a key reused for a different one is caught
+type storedResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body"`
+}
+
+// Idempotency honors Idempotency-Key on the requests it wraps: the first
+// response for a (caller, key) is stored and replayed to retries, a key reused
+// with a different method, path, query or body is answered 409, and so is a
+// retry while the first request is still running. Requests without the header
+// pass straight through
+type Idempotency struct {
+	store IdempotencyStore
+}
+
+// NewIdempotency keeps responses in store
+func NewIdempotency(store IdempotencyStore) *Idempotency {
+	return &Idempotency{store: store}
+}
+
+// Middleware wraps a POST handler
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.FieldErrorResp(w, "invalid request headers", map[string]string{
+				IdempotencyKeyHeader: fmt.Sprintf("must be at most %d characters", maxIdempotencyKeyLength),
+			})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				utils.ErrorResp(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body too large (max %d bytes)", maxIdempotentBodyBytes))
+				return
+			}
+			utils.ErrorResp(w, http.StatusBadRequest, "could not read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := r.Context()
+		owner := auth.CallerID(ctx)
+		fingerprint := requestFingerprint(r, body)
+
+		if i.answerFromStore(w, r, owner, key, fingerprint) {
+			return
+		}
+
+		token := logging.NewRequestID()
+		locked, err := i.store.Lock(ctx, owner, key, token, idempotencyLockTTL)
+		if err != nil {
+			storeUnavailable(w, r, err)
+			return
+		}
+		if !locked {
+			utils.ErrorRespWithCode(w, http.StatusConflict, string(apperrors.CodeConflict), "a request with this Idempotency-Key is still in progress")
+			return
+		}
+		defer func() {
+			// the request may be cancelled by now, the unlock must still happen
+			if err := i.store.Unlock(context.WithoutCancel(ctx), owner, key, token); err != nil {
+				slog.WarnContext(ctx, "Failed to release Idempotency-Key lock", "error", err)
+			}
+		}()
+
+		// the first request may have finished between the lookup and the lock
+		if i.answerFromStore(w, r, owner, key, fingerprint) {
+			return
+		}
+
+		rec := &bodyRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// errors worth retrying aren't pinned to the key
+		if rec.status >= http.StatusInternalServerError || rec.status == http.StatusTooManyRequests {
+			return
+		}
+		data, err := json.Marshal(storedResponse{
+			Fingerprint: fingerprint,
+			Status:      rec.status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		})
+		if err == nil {
+			err = i.store.Save(context.WithoutCancel(ctx), owner, key, data)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to store idempotent response", "error", err)
+		}
+	})
+}
+
+// answerFromStore replays the response stored for owner's key, if there is
+// one. Reports whether it answered the request
+func (i *Idempotency) answerFromStore(w http.ResponseWriter, r *http.Request, owner, key, fingerprint string) bool {
+	data, found, err := i.store.Get(r.Context(), owner, key)
+	if err != nil {
+		storeUnavailable(w, r, err)
+		return true
+	}
+	if found {
+		replay(w, data, fingerprint)
+	}
+	return found
+}
+
+// storeUnavailable answers 503 - without the store a retry could run twice,
+// so refusing is the safe side
+func storeUnavailable(w http.ResponseWriter, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), "Idempotency store unavailable", "error", err)
+	utils.ErrorRespWithCode(w, http.StatusServiceUnavailable, string(apperrors.CodeUpstreamUnavailable), "could not check Idempotency-Key, retry shortly")
+}
+
+// replay writes a stored response, or a 409 when it answered another request
+func replay(w http.ResponseWriter, data []byte, fingerprint string) {
+	var stored storedResponse
+	if err := json.Unmarshal(data, &stored); err != nil {
+		utils.ErrorResp(w, http.StatusInternalServerError, "could not read stored response")
+		return
+	}
+	if stored.Fingerprint != fingerprint {
+		utils.ErrorRespWithCode(w, http.StatusConflict, string(apperrors.CodeConflict), "Idempotency-Key was already used with a different request")
+		return
+	}
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// requestFingerprint hashes what makes two requests the same one
+func requestFingerprint(r *http.Request, body []byte) string {
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s %s?%s\n", r.Method, r.URL.Path, r.URL.RawQuery)
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// bodyRecorder passes a response through while keeping a copy
+type bodyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *bodyRecorder) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *bodyRecorder) Write(p []byte) (int, error) {
+	rec.body.Write(p)
+	return rec.ResponseWriter.Write(p)
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/auth"
+	"exchange-rate-service/internal/cache"
+)
+
+func TestIdempotency_ReplaysFirstResponse(t *testing.T) {
+	calls := 0
+	handler := NewIdempotency(cache.NewIdempotencyStore(cache.NewMemoryCache(), "test:", time.Hour)).Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"q1"}`))
+		}))
+
+	send := func(key, body string, ctx context.Context) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/v1/quote", strings.NewReader(body)).WithContext(ctx)
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	ctx := context.Background()
+
+	first := send("k1", `{"from":"USD"}`, ctx)
+	retry := send("k1", `{"from":"USD"}`, ctx)
+	if calls != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() ||
+		retry.Header().Get("Content-Type") != "application/json" || retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("expected the first response replayed, got %d %v %s", retry.Code, retry.Header(), retry.Body)
+	}
+
+	if reused := send("k1", `{"from":"EUR"}`, ctx); reused.Code != http.StatusConflict || calls != 1 {
+		t.Errorf("expected a 409 for a different payload, got %d", reused.Code)
+	}
+
+	// keys are per caller, and requests without one always run
+	other := auth.WithClient(ctx, &auth.Client{Name: "partner"})
+	if send("k1", `{"from":"EUR"}`, other).Code != http.StatusCreated || calls != 2 {
+		t.Error("another caller's key should not collide")
+	}
+	send("", `{"from":"USD"}`, ctx)
+	send("", `{"from":"USD"}`, ctx)
+	if calls != 4 {
+		t.Errorf("requests without a key should always run, ran %d times", calls)
+	}
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	handler := NewIdempotency(cache.NewIdempotencyStore(cache.NewMemoryCache(), "test:", time.Hour)).Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(status) }))
+
+	send := func() int {
+		req := httptest.NewRequest("POST", "/v1/convert", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "k1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	send()
+	status = http.StatusOK
+	if code := send(); code != http.StatusOK {
+		t.Errorf("a retry after a 503 should run again, got %d", code)
+	}
+}
+
+func TestIdempotency_RejectsConcurrentRetry(t *testing.T) {
+	store := cache.NewIdempotencyStore(cache.NewMemoryCache(), "test:", time.Hour)
+	handler := NewIdempotency(store).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// a first request still running holds the lock
+	if locked, _ := store.Lock(context.Background(), "", "k1", "first", time.Minute); !locked {
+		t.Fatal("expected to take the lock")
+	}
+
+	req := httptest.NewRequest("POST", "/v1/quote/q1/execute", nil)
+	req.Header.Set(IdempotencyKeyHeader, "k1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "in progress") {
+		t.Errorf("expected a 409 while the first request runs, got %d %s", rec.Code, rec.Body)
+	}
+}
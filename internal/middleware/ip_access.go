@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"exchange-rate-service/internal/utils"
+)
+
+// IPAccessControl restricts the API to configured network ranges
+// Deny rules always win; when an allowlist is set, everything else is rejected
+type IPAccessControl struct {
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	trusted []*net.IPNet
+}
+
+// NewIPAccessControl parses the CIDR lists (bare IPs are accepted too)
+func NewIPAccessControl(allow, deny, trustedProxies []string) (*IPAccessControl, error) {
+	allowNets, err := parseCIDRList(allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowlist: %w", err)
+	}
+
+	denyNets, err := parseCIDRList(deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid denylist: %w", err)
+	}
+
+	trustedNets, err := parseCIDRList(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	return &IPAccessControl{
+		allow:   allowNets,
+		deny:    denyNets,
+		trusted: trustedNets,
+	}, nil
+}
+
+// Enabled reports whether any allow/deny rule is configured
+func (ac *IPAccessControl) Enabled() bool {
+	return len(ac.allow) > 0 || len(ac.deny) > 0
+}
+
+// Middleware rejects requests from addresses outside the allowed ranges
+func (ac *IPAccessControl) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientIP := ac.ClientIP(r)
+
+		if !ac.isAllowed(clientIP) {
+			slog.WarnContext(r.Context(), "Access denied", "client_ip", clientIP, "method", r.Method, "path", r.URL.Path)
+			utils.ErrorResp(w, http.StatusForbidden, "access denied")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AllowsPeer applies the rules to a caller that isn't an http.Request - the
+// gRPC API passes the peer address and its x-forwarded-for metadata
+func (ac *IPAccessControl) AllowsPeer(remoteAddr, forwarded string) (net.IP, bool) {
+	clientIP := ac.clientIP(remoteAddr, forwarded)
+	return clientIP, ac.isAllowed(clientIP)
+}
+
+// ClientIP works out the real client address. X-Forwarded-For is only trusted
+// when the direct peer is a trusted proxy, and we walk it right to left so a
+// client can't spoof its way in by prepending addresses. Proxies that add
+// their own header line instead of extending the client's are read as one
+// chain, so the client's line can't stand in for the whole chain.
+func (ac *IPAccessControl) ClientIP(r *http.Request) net.IP {
+	return ac.clientIP(r.RemoteAddr, strings.Join(r.Header.Values("X-Forwarded-For"), ","))
+}
+
+func (ac *IPAccessControl) clientIP(remoteAddr, forwarded string) net.IP {
+	remote := parseRemoteAddr(remoteAddr)
+	if remote == nil || !ac.isTrustedProxy(remote) {
+		return remote
+	}
+
+	if forwarded == "" {
+		return remote
+	}
+
+	hops := strings.Split(forwarded, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			// garbage in the chain - stop at the last address we could trust
+			return remote
+		}
+		if !ac.isTrustedProxy(hop) {
+			return hop
+		}
+		remote = hop
+	}
+
+	return remote
+}
+
+// isAllowed applies deny then allow rules
+func (ac *IPAccessControl) isAllowed(ip net.IP) bool {
+	if ip == nil {
+		// can't tell who this is - only let it through when no rules exist
+		return !ac.Enabled()
+	}
+
+	if containsIP(ac.deny, ip) {
+		return false
+	}
+
+	if len(ac.allow) > 0 {
+		return containsIP(ac.allow, ip)
+	}
+
+	return true
+}
+
+func (ac *IPAccessControl) isTrustedProxy(ip net.IP) bool {
+	return containsIP(ac.trusted, ip)
+}
+
+// parseCIDRList turns "10.0.0.0/8, 192.168.1.5" into networks
+func parseCIDRList(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		// bare IP - turn into a single-host network
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", entry)
+			}
+			if ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", entry)
+		}
+		nets = append(nets, network)
+	}
+
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, network := range nets {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRemoteAddr(remoteAddr string) net.IP {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return net.ParseIP(host)
+}
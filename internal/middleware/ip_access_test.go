@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestAccessControl(t *testing.T, allow, deny, trusted []string) *IPAccessControl {
+	t.Helper()
+	ac, err := NewIPAccessControl(allow, deny, trusted)
+	if err != nil {
+		t.Fatalf("NewIPAccessControl failed: %v", err)
+	}
+	return ac
+}
+
+func serveWithAccess(ac *IPAccessControl, remoteAddr, forwardedFor string) int {
+	handler := ac.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/rate/latest", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestIPAccessControl_Allowlist(t *testing.T) {
+	ac := newTestAccessControl(t, []string{"10.0.0.0/8"}, nil, nil)
+
+	if code := serveWithAccess(ac, "10.1.2.3:5000", ""); code != http.StatusOK {
+		t.Errorf("internal address should be allowed, got %d", code)
+	}
+	if code := serveWithAccess(ac, "203.0.113.7:5000", ""); code != http.StatusForbidden {
+		t.Errorf("external address should be rejected, got %d", code)
+	}
+}
+
+func TestIPAccessControl_DenyWins(t *testing.T) {
+	ac := newTestAccessControl(t, []string{"10.0.0.0/8"}, []string{"10.6.6.6"}, nil)
+
+	if code := serveWithAccess(ac, "10.6.6.6:5000", ""); code != http.StatusForbidden {
+		t.Errorf("denied address should be rejected even inside allowlist, got %d", code)
+	}
+}
+
+func TestIPAccessControl_ForwardedForOnlyFromTrustedProxy(t *testing.T) {
+	ac := newTestAccessControl(t, []string{"10.0.0.0/8"}, nil, []string{"192.168.0.1"})
+
+	// Trusted proxy forwarding an internal client
+	if code := serveWithAccess(ac, "192.168.0.1:443", "10.1.1.1"); code != http.StatusOK {
+		t.Errorf("forwarded internal client should be allowed, got %d", code)
+	}
+
+	// Untrusted peer trying to spoof an internal address
+	if code := serveWithAccess(ac, "203.0.113.7:443", "10.1.1.1"); code != http.StatusForbidden {
+		t.Errorf("spoofed X-Forwarded-For should be ignored, got %d", code)
+	}
+
+	// Client prepends a fake internal hop; the proxy appends the real one
+	if code := serveWithAccess(ac, "192.168.0.1:443", "10.1.1.1, 203.0.113.7"); code != http.StatusForbidden {
+		t.Errorf("rightmost untrusted hop should be used, got %d", code)
+	}
+}
+
+func TestIPAccessControl_ForwardedForAcrossHeaderLines(t *testing.T) {
+	ac := newTestAccessControl(t, nil, []string{"203.0.113.7"}, []string{"192.168.0.1"})
+	handler := ac.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// the client sends its own line; the proxy adds the real address as a second line
+	req := httptest.NewRequest("GET", "/rate/latest", nil)
+	req.RemoteAddr = "192.168.0.1:443"
+	req.Header.Add("X-Forwarded-For", "10.1.1.1")
+	req.Header.Add("X-Forwarded-For", "203.0.113.7")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("denylisted client should be refused behind a spoofed first line, got %d", rec.Code)
+	}
+	if ip := ac.ClientIP(req); ip.String() != "203.0.113.7" {
+		t.Errorf("expected the proxy-added address, got %v", ip)
+	}
+}
+
+func TestNewIPAccessControl_InvalidCIDR(t *testing.T) {
+	if _, err := NewIPAccessControl([]string{"not-an-ip"}, nil, nil); err == nil {
+		t.Error("expected error for invalid CIDR")
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"exchange-rate-service/internal/auth"
+	"exchange-rate-service/internal/utils"
+)
+
+// JWTAuth authenticates "Authorization: Bearer <jwt>" requests and puts the
+// verified claims in the request context. Requests without a JWT pass through
+// untouched - API keys and admin tokens still work next to it, and
+// RequireRole decides what each route needs
+type JWTAuth struct {
+	verifier    *auth.JWTVerifier
+	publicPaths map[string]bool
+}
+
+// NewJWTAuth creates the middleware - publicPaths skip verification entirely
+func NewJWTAuth(verifier *auth.JWTVerifier, publicPaths ...string) *JWTAuth {
+	public := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+	return &JWTAuth{verifier: verifier, publicPaths: public}
+}
+
+// Middleware rejects bearer JWTs that fail verification (401). Opaque bearer
+// tokens (admin tokens) are left for the admin middleware
+func (a *JWTAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if a.publicPaths[r.URL.Path] || !found || strings.Count(token, ".") != 2 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := a.verifier.Verify(r.Context(), token)
+		if err != nil {
+			slog.WarnContext(r.Context(), "Rejected bearer token",
+				"method", r.Method, "path", r.URL.Path, "remote_addr", r.RemoteAddr, "error", err)
+			utils.ErrorResp(w, http.StatusUnauthorized, "invalid bearer token")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
+	})
+}
+
+// RequireRole lets a request through when its JWT grants role (403 when it
+// doesn't) or when it was authenticated by API key instead. Anything else is 401
+func (a *JWTAuth) RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+				if !claims.HasRole(role) {
+					utils.ErrorResp(w, http.StatusForbidden, "token lacks the "+role+" role")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if _, ok := auth.ClientFromContext(r.Context()); !ok {
+				utils.ErrorResp(w, http.StatusUnauthorized, "missing bearer token or API key")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/auth"
+)
+
+func testJWT(t *testing.T, secret string, roles ...string) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"sub":   "dashboard",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": roles,
+	})
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTAuth_Roles(t *testing.T) {
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{Secret: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtAuth := NewJWTAuth(verifier, "/health")
+	keys, _ := auth.LoadKeyStore([]string{"client-key"}, "", 60)
+	apiKeys := NewAPIKeyAuth(keys, "/health")
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	// same order as the server: JWT, then API keys, then the route's role check
+	reader := jwtAuth.Middleware(apiKeys.Middleware(jwtAuth.RequireRole(auth.RoleReader)(ok)))
+	admin := jwtAuth.Middleware(apiKeys.Middleware(NewAdminAuth([]string{"static-admin"}).Middleware(ok)))
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		auth    string
+		apiKey  string
+		want    int
+	}{
+		{"reader token on rate route", reader, "Bearer " + testJWT(t, "s3cret", "reader"), "", http.StatusOK},
+		{"admin token on rate route", reader, "Bearer " + testJWT(t, "s3cret", "admin"), "", http.StatusOK},
+		{"token without role", reader, "Bearer " + testJWT(t, "s3cret"), "", http.StatusForbidden},
+		{"badly signed token", reader, "Bearer " + testJWT(t, "wrong", "reader"), "", http.StatusUnauthorized},
+		{"API key still works", reader, "", "client-key", http.StatusOK},
+		{"no credentials", reader, "", "", http.StatusUnauthorized},
+		{"reader token on admin route", admin, "Bearer " + testJWT(t, "s3cret", "reader"), "", http.StatusForbidden},
+		{"admin token on admin route", admin, "Bearer " + testJWT(t, "s3cret", "admin"), "", http.StatusOK},
+		{"static admin token", admin, "Bearer static-admin", "client-key", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/rate/latest", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestJWTAuth_ClaimsInContext(t *testing.T) {
+	verifier, _ := auth.NewJWTVerifier(auth.JWTConfig{Secret: "s3cret"})
+	var subject string
+	handler := NewJWTAuth(verifier).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+			subject = claims.Subject
+		}
+	}))
+
+	req := httptest.NewRequest("GET", "/v1/rate/latest", nil)
+	req.Header.Set("Authorization", "Bearer "+testJWT(t, "s3cret", "reader"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if subject != "dashboard" {
+		t.Errorf("expected claims in the request context, got subject %q", subject)
+	}
+}
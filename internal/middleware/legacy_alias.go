@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// LegacyAlias marks responses served on a pre-versioning path as deprecated
+// and points clients at the versioned successor (RFC 8594 / RFC 8288).
+// sunset is announced when set
+func LegacyAlias(versionPrefix string, sunset time.Time) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Add("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", versionPrefix, r.URL.Path))
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestLegacyAlias_RoutesBothPathsToSameHandler(t *testing.T) {
+	router := mux.NewRouter()
+	register := func(r *mux.Router) {
+		r.HandleFunc("/convert", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}).Methods("GET")
+	}
+	register(router.PathPrefix("/v1").Subrouter())
+	legacy := router.NewRoute().Subrouter()
+	legacy.Use(LegacyAlias("/v1", time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)))
+	register(legacy)
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/convert", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Deprecation") != "" {
+		t.Errorf("versioned path should be served without deprecation, got %d %v", rec.Code, rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/convert", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Fatalf("legacy alias should reach the same handler, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Deprecation") != "true" {
+		t.Error("expected Deprecation header on the legacy path")
+	}
+	if got := rec.Header().Get("Link"); got != `</v1/convert>; rel="successor-version"` {
+		t.Errorf("unexpected Link header %q", got)
+	}
+	if got := rec.Header().Get("Sunset"); got != "Thu, 31 Dec 2026 00:00:00 GMT" {
+		t.Errorf("unexpected Sunset header %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Deprecation") != "" {
+		t.Errorf("routes after the alias subrouter should still match, got %d %v", rec.Code, rec.Header())
+	}
+}
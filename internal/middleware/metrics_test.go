@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// series finds the HTTP metric series with exactly these label values on the
+// default registry: the counter value, or the sample count for the histogram
+func series(t *testing.T, name string, labels map[string]string) (float64, bool) {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			if len(metric.GetLabel()) != len(labels) {
+				continue
+			}
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+			if histogram := metric.GetHistogram(); histogram != nil {
+				return float64(histogram.GetSampleCount()), true
+			}
+			return metric.GetCounter().GetValue(), true
+		}
+	}
+	return 0, false
+}
+
+func TestMetrics_LabelsByRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Metrics)
+	router.HandleFunc("/v1/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "missing" {
+			http.NotFound(w, r)
+		}
+	}).Methods("GET")
+
+	counter := map[string]string{"route": "/v1/metrics-test/{id}", "method": "GET", "status": "200"}
+	latency := map[string]string{"route": "/v1/metrics-test/{id}", "method": "GET"}
+	beforeOK, _ := series(t, "exchange_rate_http_requests_total", counter)
+	beforeSamples, _ := series(t, "exchange_rate_http_request_duration_seconds", latency)
+
+	for _, target := range []string{"/v1/metrics-test/1", "/v1/metrics-test/2", "/v1/metrics-test/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+
+	if got, _ := series(t, "exchange_rate_http_requests_total", counter); got-beforeOK != 2 {
+		t.Errorf("expected 2 more 200s under the route template, got %v", got-beforeOK)
+	}
+	counter["status"] = "404"
+	if got, found := series(t, "exchange_rate_http_requests_total", counter); !found || got < 1 {
+		t.Errorf("expected the 404 counted under the route template, got %v", got)
+	}
+	if got, _ := series(t, "exchange_rate_http_request_duration_seconds", latency); got-beforeSamples != 3 {
+		t.Errorf("expected 3 latency samples under the route template, got %v", got-beforeSamples)
+	}
+
+	// raw paths must never become label values
+	for _, path := range []string{"/v1/metrics-test/1", "/v1/metrics-test/2", "/v1/metrics-test/missing"} {
+		if _, found := series(t, "exchange_rate_http_request_duration_seconds", map[string]string{"route": path, "method": "GET"}); found {
+			t.Errorf("request path %s leaked into the route label", path)
+		}
+	}
+}
+
+func TestMetrics_UnmatchedRoute(t *testing.T) {
+	labels := map[string]string{"route": "unknown", "method": "GET", "status": "404"}
+	before, _ := series(t, "exchange_rate_http_requests_total", labels)
+
+	// wrapped directly, outside any mux route
+	handler := Metrics(http.NotFoundHandler())
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/no/such/path/42", nil))
+
+	if got, _ := series(t, "exchange_rate_http_requests_total", labels); got-before != 1 {
+		t.Errorf("expected requests without a route counted as unknown, got %v", got-before)
+	}
+}
@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+)
+
+// QueryRule is the query string one route accepts
+type QueryRule struct {
+	// every parameter the route reads - anything else is rejected
+	Params   []string
+	Required []string
+	// parameters holding one currency code, or a comma-separated list of them
+	Currencies    []string
+	CurrencyLists []string
+	// parameters holding an amount, or a comma-separated list of them, each
+	// capped at the validator's maximum
+	Amounts     []string
+	AmountLists []string
+}
+
+// QueryValidator checks query strings against per-route rules before the
+// handler runs, answering 400 with every bad parameter at once. A typo like
+// form=USD is reported as such instead of surfacing as a missing "from".
+// Routes without a rule are not checked
+type QueryValidator struct {
+	rules     map[string]QueryRule // "GET /v1/convert"
+	maxAmount decimal.Decimal
+}
+
+// NewQueryValidator rejects amounts above maxAmount in magnitude (no cap when zero)
+func NewQueryValidator(maxAmount decimal.Decimal) *QueryValidator {
+	return &QueryValidator{
+		rules:     make(map[string]QueryRule),
+		maxAmount: maxAmount.Abs(),
+	}
+}
+
+// Add sets the rule for method on the route registered as path
+func (v *QueryValidator) Add(method, path string, rule QueryRule) {
+	v.rules[strings.ToUpper(method)+" "+path] = rule
+}
+
+// Middleware looks the rule up by the matched route's template, so it must run
+// as router middleware, after mux has matched
+func (v *QueryValidator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rule, found := v.rules[r.Method+" "+path]
+		if !found {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if fields := v.validate(rule, r); len(fields) > 0 {
+			utils.FieldErrorResp(w, "invalid query parameters", fields)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validate returns a message per bad parameter, keyed by its name
+func (v *QueryValidator) validate(rule QueryRule, r *http.Request) map[string]string {
+	query := r.URL.Query()
+	fields := make(map[string]string)
+
+	allowed := make(map[string]bool, len(rule.Params))
+	for _, name := range rule.Params {
+		allowed[name] = true
+	}
+	for name, values := range query {
+		switch {
+		case !allowed[name]:
+			fields[name] = unknownParamMessage(name, rule.Params)
+		case len(values) > 1:
+			fields[name] = "must be given only once"
+		}
+	}
+
+	for _, name := range rule.Required {
+		if _, bad := fields[name]; !bad && strings.TrimSpace(query.Get(name)) == "" {
+			fields[name] = "is required"
+		}
+	}
+
+	for _, name := range rule.Currencies {
+		if _, bad := fields[name]; bad || query.Get(name) == "" {
+			continue
+		}
+		if !validCurrencyCode(query.Get(name)) {
+			fields[name] = "must be a 3-letter currency code like USD"
+		}
+	}
+
+	for _, name := range rule.CurrencyLists {
+		if _, bad := fields[name]; bad || query.Get(name) == "" {
+			continue
+		}
+		invalid := make([]string, 0)
+		for _, code := range strings.Split(query.Get(name), ",") {
+			if strings.TrimSpace(code) != "" && !validCurrencyCode(code) {
+				invalid = append(invalid, code)
+			}
+		}
+		if len(invalid) > 0 {
+			fields[name] = fmt.Sprintf("must be 3-letter currency codes like EUR,GBP (invalid: %s)", strings.Join(invalid, ", "))
+		}
+	}
+
+	for _, name := range rule.Amounts {
+		if _, bad := fields[name]; bad || query.Get(name) == "" {
+			continue
+		}
+		if problem := v.checkAmount(query.Get(name)); problem != "" {
+			fields[name] = problem
+		}
+	}
+
+	for _, name := range rule.AmountLists {
+		if _, bad := fields[name]; bad || query.Get(name) == "" {
+			continue
+		}
+		for _, raw := range strings.Split(query.Get(name), ",") {
+			if strings.TrimSpace(raw) == "" {
+				continue
+			}
+			if problem := v.checkAmount(raw); problem != "" {
+				fields[name] = fmt.Sprintf("%q %s", strings.TrimSpace(raw), problem)
+				break
+			}
+		}
+	}
+
+	return fields
+}
+
+// checkAmount describes what is wrong with an amount, "" when nothing is.
+// Shape only - the handler checks precision against the source currency
+func (v *QueryValidator) checkAmount(raw string) string {
+	amount, err := currency.ParseAmount(raw, "", -1)
+	switch {
+	case err != nil:
+		return err.Error()
+	case !v.maxAmount.IsZero() && amount.Abs().GreaterThan(v.maxAmount):
+		return fmt.Sprintf("must not exceed %s in magnitude", v.maxAmount.String())
+	}
+	return ""
+}
+
+// validCurrencyCode checks the ^[A-Z]{3}$ shape after trimming and upper-casing,
+// the same normalization the service applies
+func validCurrencyCode(code string) bool {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// unknownParamMessage suggests the closest accepted parameter when the name
+// looks like a typo of one
+func unknownParamMessage(name string, accepted []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range accepted {
+		if d := editDistance(strings.ToLower(name), candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best != "" && bestDistance < len(best) {
+		return fmt.Sprintf("unknown parameter, did you mean %q?", best)
+	}
+
+	sorted := append([]string(nil), accepted...)
+	sort.Strings(sorted)
+	if len(sorted) == 0 {
+		return "unknown parameter, this endpoint takes none"
+	}
+	return "unknown parameter, expected one of " + strings.Join(sorted, ", ")
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+)
+
+func newValidatedRouter() *mux.Router {
+	validator := NewQueryValidator(decimal.NewFromInt(1000000))
+	validator.Add("GET", "/v1/convert", QueryRule{
+		Params:     []string{"from", "to", "amount", "date"},
+		Required:   []string{"from", "to", "amount"},
+		Currencies: []string{"from", "to"},
+		Amounts:    []string{"amount"},
+	})
+	validator.Add("GET", "/v1/convert/multi", QueryRule{
+		Params:        []string{"from", "to"},
+		CurrencyLists: []string{"to"},
+	})
+	validator.Add("GET", "/v1/convert/table", QueryRule{
+		Params:      []string{"from", "to", "amounts"},
+		AmountLists: []string{"amounts"},
+	})
+	validator.Add("GET", "/v1/currencies", QueryRule{})
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router := mux.NewRouter()
+	v1 := router.PathPrefix("/v1").Subrouter()
+	v1.HandleFunc("/convert", ok).Methods("GET")
+	v1.HandleFunc("/convert", ok).Methods("POST")
+	v1.HandleFunc("/convert/multi", ok).Methods("GET")
+	v1.HandleFunc("/convert/table", ok).Methods("GET")
+	v1.HandleFunc("/currencies", ok).Methods("GET")
+	router.HandleFunc("/health", ok).Methods("GET")
+	router.Use(validator.Middleware)
+	return router
+}
+
+func TestQueryValidator_ReportsEveryBadParameter(t *testing.T) {
+	router := newValidatedRouter()
+
+	tests := []struct {
+		name   string
+		url    string
+		fields map[string]string
+	}{
+		{
+			name: "typo",
+			url:  "/v1/convert?form=USD&to=EUR&amount=10",
+			fields: map[string]string{
+				"form": `unknown parameter, did you mean "from"?`,
+				"from": "is required",
+			},
+		},
+		{
+			name: "bad codes and amount",
+			url:  "/v1/convert?from=US&to=eur1&amount=abc",
+			fields: map[string]string{
+				"from":   "must be a 3-letter currency code like USD",
+				"to":     "must be a 3-letter currency code like USD",
+				"amount": `must be a decimal number like "100.25"`,
+			},
+		},
+		{
+			name: "scientific notation",
+			url:  "/v1/convert?from=USD&to=EUR&amount=1e308",
+			fields: map[string]string{
+				"amount": `must be a decimal number like "100.25"`,
+			},
+		},
+		{
+			name: "amount over the cap",
+			url:  "/v1/convert?from=USD&to=EUR&amount=-1000000.01",
+			fields: map[string]string{
+				"amount": "must not exceed 1000000 in magnitude",
+			},
+		},
+		{
+			name: "repeated and unrelated parameters",
+			url:  "/v1/convert?from=USD&from=GBP&to=EUR&amount=1&callback=x",
+			fields: map[string]string{
+				"from":     "must be given only once",
+				"callback": "unknown parameter, expected one of amount, date, from, to",
+			},
+		},
+		{
+			name: "currency list",
+			url:  "/v1/convert/multi?from=USD&to=EUR,,GBPX,jp",
+			fields: map[string]string{
+				"to": "must be 3-letter currency codes like EUR,GBP (invalid: GBPX, jp)",
+			},
+		},
+		{
+			name: "amount list",
+			url:  "/v1/convert/table?from=USD&to=EUR&amounts=1,,5,2000000",
+			fields: map[string]string{
+				"amounts": `"2000000" must not exceed 1000000 in magnitude`,
+			},
+		},
+		{
+			name: "endpoint without parameters",
+			url:  "/v1/currencies?page=2",
+			fields: map[string]string{
+				"page": "unknown parameter, this endpoint takes none",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest("GET", tt.url, nil))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", rec.Code)
+			}
+			var body struct {
+				Code   string            `json:"code"`
+				Fields map[string]string `json:"fields"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("invalid error body: %v", err)
+			}
+			if body.Code != "invalid_request" {
+				t.Errorf("expected invalid_request, got %q", body.Code)
+			}
+			if !reflect.DeepEqual(body.Fields, tt.fields) {
+				t.Errorf("expected fields %v, got %v", tt.fields, body.Fields)
+			}
+		})
+	}
+}
+
+func TestQueryValidator_PassesValidAndUnruledRequests(t *testing.T) {
+	router := newValidatedRouter()
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/v1/convert?from=usd&to=%20EUR&amount=1000000&date=2024-01-15", nil),
+		httptest.NewRequest("GET", "/v1/convert/multi?from=USD&to=EUR,gbp", nil),
+		httptest.NewRequest("GET", "/v1/convert/table?from=USD&to=EUR&amounts=1,%205,10.50", nil),
+		// no rule for POST /convert or /health - left to the handler
+		httptest.NewRequest("POST", "/v1/convert?anything=1", nil),
+		httptest.NewRequest("GET", "/health?verbose=1", nil),
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s %s: expected 200, got %d: %s", req.Method, req.URL, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"form", "from", 2},
+		{"amout", "amount", 1},
+		{"", "to", 2},
+		{"date", "date", 0},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
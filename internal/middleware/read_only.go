@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/utils"
+)
+
+// ReadOnlyReplica refuses requests that would change state - anything but
+// GET, HEAD and OPTIONS - so changes go to the writer instance instead of
+// being applied to one replica only
+func ReadOnlyReplica(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+		default:
+			utils.ErrorRespWithCode(w, apperrors.HTTPStatus(apperrors.CodeReadOnlyReplica), string(apperrors.CodeReadOnlyReplica),
+				"read-only replica: send changes to the writer instance")
+		}
+	})
+}
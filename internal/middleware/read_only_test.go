@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadOnlyReplica(t *testing.T) {
+	calls := 0
+	handler := ReadOnlyReplica(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, method := range []string{"GET", "HEAD", "OPTIONS"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/admin/cache/stats", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s should reach the handler on a replica, got %d", method, rec.Code)
+		}
+	}
+
+	for _, method := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/admin/cache/refresh", nil))
+		var body map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if rec.Code != http.StatusServiceUnavailable || body["code"] != "read_only_replica" {
+			t.Errorf("%s should be refused on a replica, got %d %s", method, rec.Code, rec.Body.String())
+		}
+	}
+
+	if calls != 3 {
+		t.Errorf("expected only the reads to reach the handler, got %d calls", calls)
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"exchange-rate-service/internal/logging"
+)
+
+func TestRequestID_GeneratesAndPropagates(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/convert", nil))
+
+	if seen == "" || len(seen) != 32 {
+		t.Fatalf("expected a generated 32 char id in the context, got %q", seen)
+	}
+	if rec.Header().Get("X-Request-ID") != seen {
+		t.Errorf("response header %q should match context id %q", rec.Header().Get("X-Request-ID"), seen)
+	}
+}
+
+func TestRequestID_ReusesValidIncomingID(t *testing.T) {
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/convert", nil)
+	req.Header.Set("X-Request-ID", "upstream-trace.42")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("X-Request-ID"); got != "upstream-trace.42" {
+		t.Errorf("expected caller id to be reused, got %q", got)
+	}
+
+	req = httptest.NewRequest("GET", "/convert", nil)
+	req.Header.Set("X-Request-ID", "bad id\r\ninjected")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("X-Request-ID"); got == "" || strings.Contains(got, "injected") {
+		t.Errorf("expected unsafe caller id to be replaced, got %q", got)
+	}
+}
+
+func TestAccessLog_IncludesRequestIDAndStatus(t *testing.T) {
+	var out bytes.Buffer
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+	logging.Setup(&out, "info", "text")
+
+	handler := RequestID(AccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})))
+
+	req := httptest.NewRequest("GET", "/rate/latest", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := out.String()
+	for _, want := range []string{"path=/rate/latest", "status=418", "request_id=abc123", "latency_ms="} {
+		if !strings.Contains(line, want) {
+			t.Errorf("access log %q missing %q", line, want)
+		}
+	}
+}
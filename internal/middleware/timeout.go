@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/metrics"
+	"exchange-rate-service/internal/utils"
+)
+
+// Timeout gives each request a deadline of d, visible to handlers through the
+// request context. A handler that hasn't started its response by then is
+// answered 504 with a timeout error on its behalf, and whatever it writes
+// afterwards is dropped. Responses already under way are left to finish, so
+// streams are only cut by the server's WriteTimeout. Requests for the exempt
+// paths (long-lived WebSockets) get no deadline
+func Timeout(d time.Duration, exempt ...string) func(http.Handler) http.Handler {
+	skip := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		skip[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{ResponseWriter: w, header: w.Header().Clone()}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				tw.finish()
+			case p := <-panicked:
+				// re-raised here so the recovery middleware sees it
+				panic(p)
+			case <-ctx.Done():
+				if !tw.timeout() {
+					// the response has started - let the handler finish it
+					select {
+					case <-done:
+					case p := <-panicked:
+						panic(p)
+					}
+					return
+				}
+				if ctx.Err() == context.DeadlineExceeded {
+					slog.WarnContext(r.Context(), "Request timed out", "timeout", d.String())
+					utils.ErrorRespWithCode(w, http.StatusGatewayTimeout, string(apperrors.CodeTimeout), "request timed out")
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter lets the handler goroutine and the timeout race safely for the
+// response. The handler gets its own header map, copied over when it starts
+// writing, so a late handler never touches headers the 504 is using
+type timeoutWriter struct {
+	http.ResponseWriter
+	header http.Header
+
+	mu       sync.Mutex
+	started  bool
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.started {
+		return
+	}
+	tw.start()
+	tw.ResponseWriter.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.started {
+		tw.start()
+	}
+	return tw.ResponseWriter.Write(b)
+}
+
+// Flush keeps streaming responses working through the wrapper
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	if !tw.started {
+		tw.start()
+	}
+	if flusher, ok := tw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// start copies the handler's headers to the real response - caller holds mu
+func (tw *timeoutWriter) start() {
+	tw.started = true
+	dst := tw.ResponseWriter.Header()
+	for key := range dst {
+		if _, kept := tw.header[key]; !kept {
+			delete(dst, key)
+		}
+	}
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+}
+
+// finish copies the headers of a handler that returned without writing
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.started {
+		tw.start()
+	}
+}
+
+// timeout claims the response for the 504, false when the handler already started it
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.started {
+		return false
+	}
+	tw.timedOut = true
+	return true
+}
+
+// InFlight counts requests being served, so shutdown can say how many it
+// waited for
+type InFlight struct {
+	count atomic.Int64
+}
+
+// Middleware must wrap the whole handler to count every request
+func (f *InFlight) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metrics.SetHTTPInFlight(int(f.count.Add(1)))
+		defer func() { metrics.SetHTTPInFlight(int(f.count.Add(-1))) }()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Count is the number of requests being served right now
+func (f *InFlight) Count() int {
+	return int(f.count.Load())
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout_AnswersSlowHandlers(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	handler := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release // ignores the deadline, like a handler stuck on a call without ctx
+		w.Header().Set("X-Late", "1")
+		w.Write([]byte("too late"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/convert", nil))
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", rec.Code)
+	}
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Code != "timeout" {
+		t.Errorf("expected a timeout error body, got %q (%v)", body.Code, err)
+	}
+}
+
+func TestTimeout_PassesFastResponsesThrough(t *testing.T) {
+	handler := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("expected the request context to carry a deadline")
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("ok"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/convert", nil))
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "ok" || rec.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("unexpected response %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+
+	// a handler that sets headers but writes nothing still gets them sent
+	handler = Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Empty", "1")
+	}))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/convert", nil))
+	if rec.Header().Get("X-Empty") != "1" {
+		t.Errorf("expected headers of an empty response to be kept, got %v", rec.Header())
+	}
+}
+
+func TestTimeout_LetsStartedResponsesFinish(t *testing.T) {
+	handler := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first,"))
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("second"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/rate/timeseries", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "first,second" {
+		t.Errorf("expected the stream to finish, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTimeout_ExemptPathsAndPanics(t *testing.T) {
+	handler := Timeout(time.Second, "/ws")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws" {
+			if _, ok := r.Context().Deadline(); ok {
+				t.Error("exempt paths should get no deadline")
+			}
+			return
+		}
+		panic("boom")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ws", nil))
+
+	defer func() {
+		if recover() != "boom" {
+			t.Error("expected the handler's panic to reach the caller")
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/convert", nil))
+}
+
+func TestInFlight(t *testing.T) {
+	inFlight := &InFlight{}
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := inFlight.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		close(done)
+	}()
+
+	<-entered
+	if inFlight.Count() != 1 {
+		t.Errorf("expected 1 request in flight, got %d", inFlight.Count())
+	}
+	close(release)
+	<-done
+	if inFlight.Count() != 0 {
+		t.Errorf("expected no requests in flight, got %d", inFlight.Count())
+	}
+}
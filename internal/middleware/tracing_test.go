@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"exchange-rate-service/internal/tracing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing_ServerSpanPerRoute(t *testing.T) {
+	if _, err := tracing.Setup(context.Background(), "", "test", 1); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	var handlerSpan trace.SpanContext
+	router := mux.NewRouter()
+	router.Use(Tracing)
+	router.HandleFunc("/v1/alerts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	req := httptest.NewRequest("GET", "/v1/alerts/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected one server span, got %d", len(spans))
+	}
+	span := spans[0]
+
+	if span.Name() != "GET /v1/alerts/{id}" || span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("expected server span named after the route, got %q (%v)", span.Name(), span.SpanKind())
+	}
+	if span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Error("server span should continue the caller's trace")
+	}
+	if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Error("the handler context should carry the server span")
+	}
+	if span.Status().Code != codes.Error {
+		t.Error("5xx responses should mark the span failed")
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/utils"
+)
+
+// Warmer reports whether the service is done warming its cache
+type Warmer interface {
+	Warm() bool
+}
+
+// WarmupGate answers 503 with a short Retry-After until warmer is warm, so
+// the first requests after a deploy don't all go to the providers while the
+// initial refresh is still running
+func WarmupGate(warmer Warmer) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !warmer.Warm() {
+				w.Header().Set("Retry-After", "1")
+				utils.ErrorRespWithCode(w, http.StatusServiceUnavailable, string(apperrors.CodeUpstreamUnavailable),
+					"service is warming up its rate cache, retry shortly")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeWarmer bool
+
+func (f *fakeWarmer) Warm() bool { return bool(*f) }
+
+func TestWarmupGate(t *testing.T) {
+	warmer := fakeWarmer(false)
+	handler := WarmupGate(&warmer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/rate/latest?from=USD&to=EUR", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected a 503 with Retry-After while warming up, got %d %v", rec.Code, rec.Header())
+	}
+
+	warmer = true
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/rate/latest?from=USD&to=EUR", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected requests through once warm, got %d", rec.Code)
+	}
+}
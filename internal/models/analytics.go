@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// RateRecord is one rate as fetched from a provider and kept in the rate store
+type RateRecord struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Date      string    `json:"date"` // YYYY-MM-DD the rate applies to
+	Rate      float64   `json:"rate"`
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// RateSummary aggregates the stored daily rates of a pair over a date range
+type RateSummary struct {
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	Start         string  `json:"start"`
+	End           string  `json:"end"`
+	Days          int     `json:"days"`
+	Min           float64 `json:"min,omitempty"`
+	Max           float64 `json:"max,omitempty"`
+	Average       float64 `json:"average,omitempty"`
+	First         float64 `json:"first,omitempty"`
+	Last          float64 `json:"last,omitempty"`
+	ChangePercent float64 `json:"change_percent"`
+}
+
+// RateStats describes how a pair moved over a trailing period, from the daily
+// rates plus the live one - GET /rate/stats
+type RateStats struct {
+	From          string   `json:"from"`
+	To            string   `json:"to"`
+	Period        string   `json:"period"`
+	Start         string   `json:"start"`
+	End           string   `json:"end"`
+	Days          int      `json:"days"` // rates the figures are computed from
+	Open          float64  `json:"open"`
+	Min           float64  `json:"min"`
+	Max           float64  `json:"max"`
+	Mean          float64  `json:"mean"`
+	Latest        float64  `json:"latest"`
+	ChangePercent float64  `json:"change_percent"`
+	Volatility    float64  `json:"volatility"` // standard deviation of day-over-day % changes
+	Stale         bool     `json:"stale,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+}
+
+// RateTrend is the live rate with how far it moved over standard windows -
+// GET /rate/trend
+type RateTrend struct {
+	From     string       `json:"from"`
+	To       string       `json:"to"`
+	Rate     float64      `json:"rate"`
+	Changes  []RateChange `json:"changes"`
+	Stale    bool         `json:"stale,omitempty"`
+	Warnings []string     `json:"warnings,omitempty"`
+}
+
+// RateChange is the move of the live rate against the daily rate at the
+// start of a window ("24h", "7d", "30d")
+type RateChange struct {
+	Window        string  `json:"window"`
+	Since         string  `json:"since"` // day of the rate compared against
+	Rate          float64 `json:"rate"`
+	Change        float64 `json:"change"`
+	ChangePercent float64 `json:"change_percent"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Amount is a money amount in a response. It encodes as a JSON number
+// ({"amount":84.5}) like the float64 API did, where decimal.Decimal on its own
+// would quote it as a string - without touching decimal's package-wide setting
+type Amount struct {
+	decimal.Decimal
+}
+
+// NewAmount wraps d for a response
+func NewAmount(d decimal.Decimal) Amount {
+	return Amount{Decimal: d}
+}
+
+// MarshalJSON writes the amount as an exact, unquoted number
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// RateQuote is an exchange rate plus what we know about its freshness
+type RateQuote struct {
+	Rate        float64
+	LastUpdated time.Time // fetch time - now for a fresh provider answer, the cache entry's otherwise; zero for historical rates
+	Stale       bool      // served from an expired cache entry because the upstream failed
+	Cached      bool      // served from the cache or the local rate store
+	Source      string    // provider that supplied the rate, empty when unknown
+	Date        string    // day a historical rate is for - earlier than the requested day after a weekend/holiday fallback
+	Derived     bool      // 1/rate of the cached opposite pair rather than a quote for this pair
+	Closure     string    // why the market was closed on the requested day ("weekend", "holiday"), empty when it traded
+	Degraded    bool      // answered in degraded mode, from the cache only
+	Route       []string  // FROM, intermediate, TO when no provider quotes the pair directly
+}
+
+// ConversionResult is the outcome of converting an amount
+// Amount is already rounded to the target currency's minor units and net of
+// any fee. Quote.Rate stays the mid-market rate; AppliedRate is what was charged.
+type ConversionResult struct {
+	Amount      decimal.Decimal
+	Quote       RateQuote
+	AppliedRate float64
+	Fee         decimal.Decimal // in the target currency
+}
+
+// FormattedAmount is returned by GET /format. Rounded keeps its trailing
+// zeros ("12.50"), so it is a string rather than a number
+type FormattedAmount struct {
+	Currency   string `json:"currency"`
+	Amount     Amount `json:"amount"`
+	Rounded    string `json:"rounded"`
+	MinorUnits int32  `json:"minor_units"`
+	Symbol     string `json:"symbol,omitempty"`
+	Locale     string `json:"locale"`
+	Formatted  string `json:"formatted"`
+}
+
+// TargetConversion is one target of a multi-target conversion - Err is set
+// instead of Result when that target failed
+type TargetConversion struct {
+	To     string
+	Result ConversionResult
+	Err    error
+}
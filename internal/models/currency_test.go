@@ -3,6 +3,8 @@ package models
 import (
 	"encoding/json"
 	"testing"
+
+	"github.com/shopspring/decimal"
 )
 
 func TestCurrencyRate_JSONSerialization(t *testing.T) {
@@ -51,7 +53,15 @@ func TestCurrencyRate_JSONSerialization(t *testing.T) {
 func TestConvertResponse_JSONSerialization(t *testing.T) {
 	// Test that ConvertResponse serializes to JSON correctly
 	response := ConvertResponse{
-		Amount: 123.45,
+		From:           "USD",
+		To:             "EUR",
+		OriginalAmount: NewAmount(decimal.RequireFromString("134")),
+		Amount:         NewAmount(decimal.RequireFromString("123.45")),
+		Rate:           0.9213,
+		AppliedRate:    0.9121,
+		Fee:            NewAmount(decimal.RequireFromString("1.23")),
+		Cached:         true,
+		Source:         "frankfurter",
 	}
 
 	// Marshal to JSON
@@ -61,7 +71,7 @@ func TestConvertResponse_JSONSerialization(t *testing.T) {
 	}
 
 	// Verify JSON structure
-	expected := `{"amount":123.45}`
+	expected := `{"from":"USD","to":"EUR","original_amount":134,"amount":123.45,"rate":0.9213,"applied_rate":0.9121,"fee":1.23,"cached":true,"source":"frankfurter"}`
 	if string(jsonData) != expected {
 		t.Errorf("JSON serialization mismatch.\nExpected: %s\nActual: %s", expected, string(jsonData))
 	}
@@ -74,8 +84,13 @@ func TestConvertResponse_JSONSerialization(t *testing.T) {
 	}
 
 	// Verify amount matches
-	if unmarshaled.Amount != response.Amount {
-		t.Errorf("Amount field mismatch: expected %f, got %f", response.Amount, unmarshaled.Amount)
+	if !unmarshaled.Amount.Equal(response.Amount.Decimal) {
+		t.Errorf("Amount field mismatch: expected %s, got %s", response.Amount, unmarshaled.Amount)
+	}
+
+	// the package-wide decimal setting is left alone for everyone else
+	if plain, _ := json.Marshal(decimal.RequireFromString("1.23")); string(plain) != `"1.23"` {
+		t.Errorf("expected a bare decimal.Decimal to keep decimal's quoted default, got %s", plain)
 	}
 }
 
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"encoding/xml"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV and XML forms of the rate responses, for clients pulling rates into
+// spreadsheets. JSON stays the canonical format.
+
+// CSVRecords returns a header row and a single data row
+func (c CurrencyRate) CSVRecords() [][]string {
+	return [][]string{
+		{"from", "to", "rate", "date", "effective_date", "stale", "last_updated", "derived", "market_closed", "route"},
+		{c.From, c.To, formatRate(c.Rate), c.Date, c.EffectiveDate, strconv.FormatBool(c.Stale), formatTimestamp(c.LastUpdated), strconv.FormatBool(c.Derived), c.MarketClosed, formatRoute(c.Route)},
+	}
+}
+
+// CSVRecords returns a header row and a single data row
+func (c ConvertResponse) CSVRecords() [][]string {
+	return [][]string{
+		{"from", "to", "original_amount", "amount", "rate", "applied_rate", "fee", "date", "last_updated", "cached", "source", "stale", "derived", "route"},
+		{
+			c.From, c.To, c.OriginalAmount.String(), c.Amount.String(), formatRate(c.Rate), formatRate(c.AppliedRate), c.Fee.String(), c.Date,
+			formatTimestamp(c.LastUpdated), strconv.FormatBool(c.Cached), c.Source, strconv.FormatBool(c.Stale), strconv.FormatBool(c.Derived),
+			formatRoute(c.Route),
+		},
+	}
+}
+
+// CSVRecords returns one row per pair, all carrying the snapshot date
+func (s RateSnapshot) CSVRecords() [][]string {
+	records := [][]string{{"date", "from", "to", "rate", "source", "last_updated"}}
+	for _, rate := range s.Rates {
+		lastUpdated := rate.LastUpdated
+		records = append(records, []string{s.Date, rate.From, rate.To, formatRate(rate.Rate), rate.Source, formatTimestamp(&lastUpdated)})
+	}
+	return records
+}
+
+// CSVRecords returns one row per target currency
+func (t RateTable) CSVRecords() [][]string {
+	records := [][]string{{"base", "to", "rate", "source", "last_updated", "stale"}}
+	for _, rate := range t.Rates {
+		records = append(records, []string{t.Base, rate.To, formatRate(rate.Rate), rate.Source, formatTimestamp(rate.LastUpdated), strconv.FormatBool(rate.Stale)})
+	}
+	return records
+}
+
+// CSVRecords returns one row per target, failed targets with error and code filled in
+func (m MultiConvertResponse) CSVRecords() [][]string {
+	records := [][]string{{"from", "original_amount", "to", "amount", "rate", "applied_rate", "fee", "last_updated", "cached", "source", "stale", "error", "code", "derived", "route"}}
+	for _, result := range m.Results {
+		amount, fee := "", ""
+		if result.Amount != nil {
+			amount = result.Amount.String()
+		}
+		if result.Fee != nil {
+			fee = result.Fee.String()
+		}
+		records = append(records, []string{
+			m.From, m.OriginalAmount.String(), result.To, amount, formatRate(result.Rate), formatRate(result.AppliedRate), fee,
+			formatTimestamp(result.LastUpdated), strconv.FormatBool(result.Cached), result.Source, strconv.FormatBool(result.Stale),
+			result.Error, result.Code, strconv.FormatBool(result.Derived), formatRoute(result.Route),
+		})
+	}
+	return records
+}
+
+// CSVRecords returns one row per amount, each repeating the shared rate
+func (t ConversionTableResponse) CSVRecords() [][]string {
+	records := [][]string{{"from", "to", "original_amount", "amount", "fee", "rate", "applied_rate", "date", "last_updated", "cached", "source", "stale", "derived", "route"}}
+	for _, row := range t.Rows {
+		records = append(records, []string{
+			t.From, t.To, row.OriginalAmount.String(), row.Amount.String(), row.Fee.String(), formatRate(t.Rate), formatRate(t.AppliedRate), t.Date,
+			formatTimestamp(t.LastUpdated), strconv.FormatBool(t.Cached), t.Source, strconv.FormatBool(t.Stale), strconv.FormatBool(t.Derived),
+			formatRoute(t.Route),
+		})
+	}
+	return records
+}
+
+// CSVRecords returns one date-ordered row per day
+func (t TimeSeriesResponse) CSVRecords() [][]string {
+	records := [][]string{{"date", "from", "to", "rate"}}
+	for _, point := range t.Points() {
+		records = append(records, []string{point.Date, t.From, t.To, formatRate(point.Rate)})
+	}
+	return records
+}
+
+// Points returns the series as date-ordered points
+func (t TimeSeriesResponse) Points() []TimeSeriesPoint {
+	dates := make([]string, 0, len(t.Rates))
+	for date := range t.Rates {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	points := make([]TimeSeriesPoint, len(dates))
+	for i, date := range dates {
+		points[i] = TimeSeriesPoint{Date: date, Rate: t.Rates[date]}
+	}
+	return points
+}
+
+// MarshalXML writes the series as <rates><rate date="...">...</rate></rates>
+func (t TimeSeriesResponse) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	doc := struct {
+		XMLName  xml.Name          `xml:"timeseries"`
+		From     string            `xml:"from"`
+		To       string            `xml:"to"`
+		Start    string            `xml:"start"`
+		End      string            `xml:"end"`
+		Rates    []TimeSeriesPoint `xml:"rates>rate"`
+		Warnings []string          `xml:"warnings>warning,omitempty"`
+	}{
+		From:     t.From,
+		To:       t.To,
+		Start:    t.Start,
+		End:      t.End,
+		Rates:    t.Points(),
+		Warnings: t.Warnings,
+	}
+	return e.Encode(doc)
+}
+
+// formatRate keeps full precision without exponents, spreadsheets choke on 1e-05
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
+
+// formatRoute writes a multi-hop route as "NGN>USD>KES", empty for a direct rate
+func formatRoute(route []string) string {
+	return strings.Join(route, ">")
+}
+
+func formatTimestamp(ts *time.Time) string {
+	if ts == nil {
+		return ""
+	}
+	return ts.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestConvertResponse_Formats(t *testing.T) {
+	lastUpdated := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	resp := ConvertResponse{
+		From:           "USD",
+		To:             "INR",
+		OriginalAmount: NewAmount(decimal.NewFromInt(100)),
+		Amount:         NewAmount(decimal.RequireFromString("8345.5")),
+		Rate:           83.455,
+		AppliedRate:    83.455,
+		LastUpdated:    &lastUpdated,
+		Cached:         true,
+		Source:         "exchangerate-api",
+		Route:          []string{"USD", "EUR", "INR"},
+		Warnings:       []string{"currency X is deprecated"},
+	}
+
+	records := resp.CSVRecords()
+	if len(records) != 2 || len(records[0]) != len(records[1]) {
+		t.Fatalf("expected a header and one row of equal width, got %v", records)
+	}
+	if strings.Join(records[1], ",") != "USD,INR,100,8345.5,83.455,83.455,0,,2024-01-15T10:00:00Z,true,exchangerate-api,false,false,USD>EUR>INR" {
+		t.Errorf("unexpected csv row: %v", records[1])
+	}
+
+	body, err := xml.Marshal(resp)
+	if err != nil {
+		t.Fatalf("xml marshal failed: %v", err)
+	}
+	for _, want := range []string{"<conversion>", "<amount>8345.5</amount>", "<warnings><warning>currency X is deprecated</warning></warnings>",
+		"<route><currency>USD</currency><currency>EUR</currency><currency>INR</currency></route>"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected %s in %s", want, body)
+		}
+	}
+}
+
+func TestTimeSeriesResponse_Formats(t *testing.T) {
+	resp := TimeSeriesResponse{
+		From:  "USD",
+		To:    "EUR",
+		Start: "2024-01-01",
+		End:   "2024-01-03",
+		Rates: map[string]float64{"2024-01-03": 0.92, "2024-01-01": 0.91},
+	}
+
+	records := resp.CSVRecords()
+	if len(records) != 3 || records[1][0] != "2024-01-01" || records[2][0] != "2024-01-03" {
+		t.Errorf("expected date-ordered rows, got %v", records)
+	}
+
+	body, err := xml.Marshal(resp)
+	if err != nil {
+		t.Fatalf("xml marshal failed: %v", err)
+	}
+	want := `<rates><rate date="2024-01-01">0.91</rate><rate date="2024-01-03">0.92</rate></rates>`
+	if !strings.HasPrefix(string(body), "<timeseries>") || !strings.Contains(string(body), want) {
+		t.Errorf("unexpected xml: %s", body)
+	}
+}
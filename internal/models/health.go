@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/xml"
+	"time"
+)
 
 // HealthStatus represents the health check response structure
 type HealthStatus struct {
@@ -8,6 +11,26 @@ type HealthStatus struct {
 	Timestamp time.Time         `json:"timestamp,omitempty"`
 	Version   string            `json:"version,omitempty"`
 	Checks    map[string]string `json:"checks,omitempty"`
+	Providers []ProviderHealth  `json:"providers,omitempty"`
+	Degraded  *DegradedStatus   `json:"degraded,omitempty"`
+}
+
+// DegradedStatus is set in health output while the service is in degraded
+// mode - no provider can be called, so only cached rates are served
+type DegradedStatus struct {
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+}
+
+// ProviderHealth is the outcome of the last background probe of one rate
+// provider. Failures counts the probes failed in a row
+type ProviderHealth struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"` // "up" or "down"
+	LatencyMs float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	Failures  int       `json:"consecutive_failures"`
+	CheckedAt time.Time `json:"checked_at"`
 }
 
 // NewHealthStatus creates a new health status with current timestamp
@@ -32,15 +55,234 @@ func (h *HealthStatus) IsHealthy() bool {
 	return h.Status == "ok"
 }
 
+// CacheStats is returned by GET /stats - counters since this instance started
+type CacheStats struct {
+	Lookups CacheLookupStats `json:"lookups"`
+	Fetches RateFetchStats   `json:"fetches"`
+	Refresh RefreshStats     `json:"refresh"`
+	TTL     CacheTTLStats    `json:"ttl"`
+}
+
+// CacheLookupStats counts latest-rate cache lookups. Stale entries were found
+// but are past their TTL, so they only count as hits when the upstream is down.
+// Evicted entries were past their max age and got removed - counted as misses,
+// plus the ones refresh cycles removed
+type CacheLookupStats struct {
+	Hits         int64   `json:"hits"`
+	Misses       int64   `json:"misses"`
+	Stale        int64   `json:"stale"`
+	Evicted      int64   `json:"evicted"`
+	HitRatio     float64 `json:"hit_ratio"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	MaxLatencyMs float64 `json:"max_latency_ms"`
+}
+
+// CacheTTLStats are the configured thresholds: past Soft a cached rate is
+// stale, served only when the upstream fails; past Hard it is evicted ("0s" = never)
+type CacheTTLStats struct {
+	Soft       string `json:"soft"`
+	Hard       string `json:"hard"`
+	CryptoSoft string `json:"crypto_soft"`
+	CryptoHard string `json:"crypto_hard"`
+}
+
+// RateFetchStats counts what happened after a lookup missed: a provider call,
+// a wait on someone else's identical call, or a fallback to the stale entry
+// because the provider failed. Derived misses were answered from the cached
+// opposite pair without a fetch. Latency is how long callers waited
+type RateFetchStats struct {
+	Upstream       int64   `json:"upstream"`
+	Deduplicated   int64   `json:"deduplicated"`
+	StaleFallbacks int64   `json:"stale_fallbacks"`
+	Derived        int64   `json:"derived"`
+	AvgLatencyMs   float64 `json:"avg_latency_ms"`
+}
+
+// RefreshStats counts full refresh cycles - a cycle succeeds when it updates
+// at least one pair. PairsBackedOff is how many pairs are being skipped right
+// now for failing cycle after cycle
+type RefreshStats struct {
+	Succeeded           int64      `json:"succeeded"`
+	Failed              int64      `json:"failed"`
+	ConsecutiveFailures int64      `json:"consecutive_failures"`
+	PairsUpdated        int64      `json:"pairs_updated"`
+	PairsFailed         int64      `json:"pairs_failed"`
+	PairsBackedOff      int        `json:"pairs_backed_off"`
+	LastRefresh         *time.Time `json:"last_refresh,omitempty"`
+	LastDurationMs      int64      `json:"last_duration_ms"`
+}
+
 // CurrencyRate represents an exchange rate between two currencies
+// Stale/LastUpdated are set when an expired cached rate is served during an upstream outage
+// Derived is set when the rate is 1/rate of the cached opposite pair
+// Degraded is set when the service answered in degraded mode, from the cache only
+// Route is set when the pair was priced through an intermediate currency
+// EffectiveDate is set when a historical rate is from an earlier day than Date,
+// MarketClosed when that is because the market was closed ("weekend", "holiday")
 type CurrencyRate struct {
-	From string  `json:"from"`
-	To   string  `json:"to"`
-	Rate float64 `json:"rate"`
-	Date string  `json:"date"`
+	XMLName       xml.Name   `json:"-" xml:"exchange_rate"`
+	From          string     `json:"from" xml:"from"`
+	To            string     `json:"to" xml:"to"`
+	Rate          float64    `json:"rate" xml:"rate"`
+	Date          string     `json:"date" xml:"date"`
+	EffectiveDate string     `json:"effective_date,omitempty" xml:"effective_date,omitempty"`
+	MarketClosed  string     `json:"market_closed,omitempty" xml:"market_closed,omitempty"`
+	Stale         bool       `json:"stale,omitempty" xml:"stale,omitempty"`
+	Derived       bool       `json:"derived,omitempty" xml:"derived,omitempty"`
+	Degraded      bool       `json:"degraded,omitempty" xml:"degraded,omitempty"`
+	Route         []string   `json:"route,omitempty" xml:"route>currency,omitempty"`
+	LastUpdated   *time.Time `json:"last_updated,omitempty" xml:"last_updated,omitempty"`
+	Warnings      []string   `json:"warnings,omitempty" xml:"warnings>warning,omitempty"`
 }
 
 // ConvertResponse represents the response for currency conversion
+// Carries the rate and where it came from so clients don't need a second
+// /rate/latest call. Rate is mid-market; AppliedRate and Fee show the markup
 type ConvertResponse struct {
-	Amount float64 `json:"amount"`
+	XMLName        xml.Name   `json:"-" xml:"conversion"`
+	From           string     `json:"from" xml:"from"`
+	To             string     `json:"to" xml:"to"`
+	OriginalAmount Amount     `json:"original_amount" xml:"original_amount"`
+	Amount         Amount     `json:"amount" xml:"amount"`
+	Rate           float64    `json:"rate" xml:"rate"`
+	AppliedRate    float64    `json:"applied_rate" xml:"applied_rate"`
+	Fee            Amount     `json:"fee" xml:"fee"`
+	Date           string     `json:"date,omitempty" xml:"date,omitempty"`
+	LastUpdated    *time.Time `json:"last_updated,omitempty" xml:"last_updated,omitempty"`
+	Cached         bool       `json:"cached" xml:"cached"`
+	Source         string     `json:"source,omitempty" xml:"source,omitempty"`
+	Stale          bool       `json:"stale,omitempty" xml:"stale,omitempty"`
+	Derived        bool       `json:"derived,omitempty" xml:"derived,omitempty"`
+	Degraded       bool       `json:"degraded,omitempty" xml:"degraded,omitempty"`
+	Route          []string   `json:"route,omitempty" xml:"route>currency,omitempty"`
+	Formatted      string     `json:"formatted,omitempty" xml:"formatted,omitempty"` // amount written for the requested locale
+	Warnings       []string   `json:"warnings,omitempty" xml:"warnings>warning,omitempty"`
+	Receipt        *Receipt   `json:"receipt,omitempty" xml:"receipt,omitempty"`
+}
+
+// Receipt signs a conversion so it can be checked later. Payload is the exact
+// string signed - the request and result fields, query-encoded in key order -
+// and Signature is hex(HMAC-SHA256(key, payload)) under the key named by KeyID
+type Receipt struct {
+	KeyID     string    `json:"key_id" xml:"key_id"`
+	Algorithm string    `json:"algorithm" xml:"algorithm"`
+	IssuedAt  time.Time `json:"issued_at" xml:"issued_at"`
+	Payload   string    `json:"payload" xml:"payload"`
+	Signature string    `json:"signature" xml:"signature"`
+}
+
+// ReceiptVerification is the answer of POST /receipts/verify
+type ReceiptVerification struct {
+	Valid  bool   `json:"valid"`
+	KeyID  string `json:"key_id,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ConvertRequest is the body of POST /convert. Amount is a string so no
+// precision is lost to float parsing on either side
+type ConvertRequest struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount string `json:"amount"`
+	Date   string `json:"date,omitempty"`
+	Locale string `json:"locale,omitempty"`
+}
+
+// MultiConvertResponse is returned by GET /convert/multi - one result per
+// requested target, in request order
+type MultiConvertResponse struct {
+	XMLName        xml.Name       `json:"-" xml:"multi_conversion"`
+	From           string         `json:"from" xml:"from"`
+	OriginalAmount Amount         `json:"original_amount" xml:"original_amount"`
+	Date           string         `json:"date,omitempty" xml:"date,omitempty"`
+	Results        []TargetResult `json:"results" xml:"results>result"`
+	Warnings       []string       `json:"warnings,omitempty" xml:"warnings>warning,omitempty"`
+}
+
+// TargetResult is one target of a multi-target conversion
+// A failed target has Error and Code set and no amount
+type TargetResult struct {
+	To          string     `json:"to" xml:"to"`
+	Amount      *Amount    `json:"amount,omitempty" xml:"amount,omitempty"`
+	Rate        float64    `json:"rate,omitempty" xml:"rate,omitempty"`
+	AppliedRate float64    `json:"applied_rate,omitempty" xml:"applied_rate,omitempty"`
+	Fee         *Amount    `json:"fee,omitempty" xml:"fee,omitempty"`
+	LastUpdated *time.Time `json:"last_updated,omitempty" xml:"last_updated,omitempty"`
+	Cached      bool       `json:"cached,omitempty" xml:"cached,omitempty"`
+	Source      string     `json:"source,omitempty" xml:"source,omitempty"`
+	Stale       bool       `json:"stale,omitempty" xml:"stale,omitempty"`
+	Derived     bool       `json:"derived,omitempty" xml:"derived,omitempty"`
+	Degraded    bool       `json:"degraded,omitempty" xml:"degraded,omitempty"`
+	Route       []string   `json:"route,omitempty" xml:"route>currency,omitempty"`
+	Error       string     `json:"error,omitempty" xml:"error,omitempty"`
+	Code        string     `json:"code,omitempty" xml:"code,omitempty"`
+}
+
+// ConversionTableResponse is returned by GET /convert/table - every row
+// converted at the same rate, in the order the amounts were asked for
+type ConversionTableResponse struct {
+	XMLName     xml.Name        `json:"-" xml:"conversion_table"`
+	From        string          `json:"from" xml:"from"`
+	To          string          `json:"to" xml:"to"`
+	Rate        float64         `json:"rate" xml:"rate"`
+	AppliedRate float64         `json:"applied_rate" xml:"applied_rate"`
+	Date        string          `json:"date,omitempty" xml:"date,omitempty"`
+	LastUpdated *time.Time      `json:"last_updated,omitempty" xml:"last_updated,omitempty"`
+	Cached      bool            `json:"cached" xml:"cached"`
+	Source      string          `json:"source,omitempty" xml:"source,omitempty"`
+	Stale       bool            `json:"stale,omitempty" xml:"stale,omitempty"`
+	Derived     bool            `json:"derived,omitempty" xml:"derived,omitempty"`
+	Degraded    bool            `json:"degraded,omitempty" xml:"degraded,omitempty"`
+	Route       []string        `json:"route,omitempty" xml:"route>currency,omitempty"`
+	Rows        []ConversionRow `json:"rows" xml:"rows>row"`
+	Warnings    []string        `json:"warnings,omitempty" xml:"warnings>warning,omitempty"`
+}
+
+// ConversionRow is one amount of a conversion table
+type ConversionRow struct {
+	OriginalAmount Amount `json:"original_amount" xml:"original_amount"`
+	Amount         Amount `json:"amount" xml:"amount"`
+	Fee            Amount `json:"fee" xml:"fee"`
+}
+
+// TimeSeriesResponse is returned by GET /rate/timeseries
+// Rates is keyed by YYYY-MM-DD; days without a fixing are omitted
+// XML has no maps, so there the rates are written as date-ordered points (see MarshalXML)
+type TimeSeriesResponse struct {
+	From  string             `json:"from"`
+	To    string             `json:"to"`
+	Start string             `json:"start"`
+	End   string             `json:"end"`
+	Rates map[string]float64 `json:"rates"`
+	// days in the range the market was closed, and why - they have no rate
+	ClosedDays map[string]string `json:"closed_days,omitempty"`
+	Warnings   []string          `json:"warnings,omitempty"`
+}
+
+// TimeSeriesPoint is one entry of a streamed time series
+type TimeSeriesPoint struct {
+	Date string  `json:"date" xml:"date,attr"`
+	Rate float64 `json:"rate" xml:",chardata"`
+}
+
+// CurrencyInfo describes one supported currency for the /currencies listing
+type CurrencyInfo struct {
+	Code       string `json:"code"`
+	Name       string `json:"name,omitempty"`
+	Symbol     string `json:"symbol,omitempty"`
+	AssetClass string `json:"asset_class,omitempty"`
+	Deprecated bool   `json:"deprecated"`
+	SunsetDate string `json:"sunset_date,omitempty"`
+	Retired    bool   `json:"retired,omitempty"`
+}
+
+// CurrencyListResponse is returned by GET /currencies
+type CurrencyListResponse struct {
+	Currencies []CurrencyInfo `json:"currencies"`
+}
+
+// DeprecationNotice tells clients a currency they used is going away
+type DeprecationNotice struct {
+	Code       string
+	SunsetDate time.Time
 }
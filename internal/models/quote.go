@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Quote is a conversion locked at one rate until ExpiresAt - returned by
+// POST /quote and carried out, once, by POST /quote/{id}/execute
+type Quote struct {
+	ID             string    `json:"quote_id"`
+	From           string    `json:"from"`
+	To             string    `json:"to"`
+	OriginalAmount Amount    `json:"original_amount"`
+	Amount         Amount    `json:"amount"`
+	Rate           float64   `json:"rate"`
+	AppliedRate    float64   `json:"applied_rate"`
+	Fee            Amount    `json:"fee"`
+	Source         string    `json:"source,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// QuoteExecution is the conversion a quote was executed at
+type QuoteExecution struct {
+	QuoteID        string    `json:"quote_id"`
+	From           string    `json:"from"`
+	To             string    `json:"to"`
+	OriginalAmount Amount    `json:"original_amount"`
+	Amount         Amount    `json:"amount"`
+	Rate           float64   `json:"rate"`
+	AppliedRate    float64   `json:"applied_rate"`
+	Fee            Amount    `json:"fee"`
+	Source         string    `json:"source,omitempty"`
+	QuotedAt       time.Time `json:"quoted_at"`
+	ExecutedAt     time.Time `json:"executed_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// RateTable is the latest rate from Base into every currency with a cached
+// quote, answered by GET /rates
+type RateTable struct {
+	XMLName  xml.Name    `json:"-" xml:"rate_table"`
+	Base     string      `json:"base" xml:"base"`
+	Count    int         `json:"count" xml:"count"`
+	Stale    bool        `json:"stale,omitempty" xml:"stale,omitempty"` // at least one rate is stale
+	Degraded bool        `json:"degraded,omitempty" xml:"degraded,omitempty"`
+	Rates    []TableRate `json:"rates" xml:"rates>rate"`
+	Warnings []string    `json:"warnings,omitempty" xml:"warnings>warning,omitempty"`
+}
+
+// TableRate is one Base->To rate of a RateTable. LastUpdated is when the older
+// of the two quotes it was derived from was fetched
+type TableRate struct {
+	To          string     `json:"to" xml:"to"`
+	Rate        float64    `json:"rate" xml:"rate"`
+	Source      string     `json:"source,omitempty" xml:"source,omitempty"`
+	LastUpdated *time.Time `json:"last_updated,omitempty" xml:"last_updated,omitempty"`
+	Stale       bool       `json:"stale,omitempty" xml:"stale,omitempty"`
+}
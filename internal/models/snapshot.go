@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// RateSnapshot is the full cached rate table as it stood at the daily snapshot
+// time - the end-of-day table accounting reconciles against
+type RateSnapshot struct {
+	XMLName xml.Name       `json:"-" xml:"rate_snapshot"`
+	Date    string         `json:"date" xml:"date"`
+	TakenAt time.Time      `json:"taken_at" xml:"taken_at"`
+	Count   int            `json:"count" xml:"count"`
+	Rates   []SnapshotRate `json:"rates" xml:"rates>rate"`
+}
+
+// SnapshotRate is one pair of a RateSnapshot. LastUpdated is when the cached
+// rate was fetched, which can be earlier than the snapshot itself
+type SnapshotRate struct {
+	From        string    `json:"from" xml:"from"`
+	To          string    `json:"to" xml:"to"`
+	Rate        float64   `json:"rate" xml:"rate"`
+	Source      string    `json:"source,omitempty" xml:"source,omitempty"`
+	LastUpdated time.Time `json:"last_updated" xml:"last_updated"`
+}
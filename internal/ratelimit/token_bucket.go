@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenBucket is a simple thread-safe token bucket limiter
+// Tokens refill continuously at ratePerSecond up to capacity
+type TokenBucket struct {
+	mu            sync.Mutex
+	capacity      float64
+	tokens        float64
+	ratePerSecond float64
+	lastRefill    time.Time
+}
+
+// NewTokenBucket creates a full bucket that allows `perMinute` requests per minute
+// with bursts up to `burst` requests
+func NewTokenBucket(perMinute, burst int) *TokenBucket {
+	if burst <= 0 {
+		burst = perMinute
+	}
+
+	return &TokenBucket{
+		capacity:      float64(burst),
+		tokens:        float64(burst),
+		ratePerSecond: float64(perMinute) / 60.0,
+		lastRefill:    time.Now(),
+	}
+}
+
+// Allow consumes one token if available
+func (b *TokenBucket) Allow() bool {
+	ok, _ := b.Reserve()
+	return ok
+}
+
+// Reserve consumes one token if available, otherwise reports how long until
+// the next token will be ready (useful for Retry-After headers)
+func (b *TokenBucket) Reserve() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if b.ratePerSecond <= 0 {
+		return false, time.Minute
+	}
+
+	missing := 1 - b.tokens
+	wait := time.Duration(missing / b.ratePerSecond * float64(time.Second))
+	return false, wait
+}
+
+// Remaining returns the number of whole tokens currently available
+func (b *TokenBucket) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	return int(b.tokens)
+}
+
+// refill adds tokens for the time elapsed since the last refill - caller holds the lock
+func (b *TokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	b.tokens += elapsed * b.ratePerSecond
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.lastRefill = now
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Burst(t *testing.T) {
+	bucket := NewTokenBucket(60, 3)
+
+	for i := 0; i < 3; i++ {
+		if !bucket.Allow() {
+			t.Fatalf("expected request %d of the burst to pass", i+1)
+		}
+	}
+
+	ok, wait := bucket.Reserve()
+	if ok {
+		t.Fatal("expected the bucket to be empty after the burst")
+	}
+	// one token a second
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("expected a wait of up to a second, got %v", wait)
+	}
+}
+
+func TestTokenBucket_BurstDefaultsToRate(t *testing.T) {
+	bucket := NewTokenBucket(5, 0)
+	if got := bucket.Remaining(); got != 5 {
+		t.Errorf("expected a full bucket of 5, got %d", got)
+	}
+}
+
+func TestTokenBucket_Refill(t *testing.T) {
+	bucket := NewTokenBucket(60, 3)
+	for bucket.Allow() {
+	}
+
+	// two seconds pass
+	bucket.mu.Lock()
+	bucket.lastRefill = bucket.lastRefill.Add(-2 * time.Second)
+	bucket.mu.Unlock()
+	if got := bucket.Remaining(); got != 2 {
+		t.Errorf("expected 2 tokens after 2s at 1/s, got %d", got)
+	}
+
+	// refills stop at the burst size
+	bucket.mu.Lock()
+	bucket.lastRefill = bucket.lastRefill.Add(-time.Hour)
+	bucket.mu.Unlock()
+	if got := bucket.Remaining(); got != 3 {
+		t.Errorf("expected refills capped at the burst (3), got %d", got)
+	}
+}
+
+func TestTokenBucket_ZeroRate(t *testing.T) {
+	bucket := NewTokenBucket(0, 1)
+	if !bucket.Allow() {
+		t.Fatal("expected the single burst token to pass")
+	}
+	if ok, wait := bucket.Reserve(); ok || wait != time.Minute {
+		t.Errorf("expected a bucket that never refills to ask for a minute, got ok=%v wait=%v", ok, wait)
+	}
+}
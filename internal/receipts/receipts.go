@@ -0,0 +1,117 @@
+// Package receipts signs conversion results with a service-held HMAC key, so a
+// system that stores or forwards a conversion can later show it wasn't altered
+// on the way. Verifying needs the key, so callers without it ask the service.
+package receipts
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"exchange-rate-service/internal/models"
+)
+
+// Algorithm is the only signature scheme receipts use
+const Algorithm = "hmac-sha256"
+
+// verification failures
+var (
+	ErrUnknownKey   = errors.New("receipt signed with an unknown key")
+	ErrBadSignature = errors.New("receipt signature does not match its payload")
+)
+
+// Signer signs receipts with the active key and verifies them against any of
+// its keys, so receipts issued before a key rotation can still be checked
+type Signer struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// NewSigner signs with keys[activeID]. activeID may be empty when there is
+// only one key
+func NewSigner(keys map[string]string, activeID string) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no receipt signing keys")
+	}
+	if activeID == "" {
+		if len(keys) > 1 {
+			return nil, errors.New("several receipt signing keys, name the one to sign with")
+		}
+		for id := range keys {
+			activeID = id
+		}
+	}
+	if _, found := keys[activeID]; !found {
+		return nil, fmt.Errorf("receipt signing key %q is not configured", activeID)
+	}
+
+	signer := &Signer{activeID: activeID, keys: make(map[string][]byte, len(keys))}
+	for id, secret := range keys {
+		signer.keys[id] = []byte(secret)
+	}
+	return signer, nil
+}
+
+// Sign issues a receipt for conversion, dated now
+func (s *Signer) Sign(conversion models.ConvertResponse) models.Receipt {
+	issuedAt := time.Now().UTC().Truncate(time.Second)
+	payload := Payload(conversion, issuedAt)
+	return models.Receipt{
+		KeyID:     s.activeID,
+		Algorithm: Algorithm,
+		IssuedAt:  issuedAt,
+		Payload:   payload,
+		Signature: sign(s.keys[s.activeID], payload),
+	}
+}
+
+// Verify checks receipt's signature over its payload. Whether the payload
+// matches the conversion at hand is the caller's to compare
+func (s *Signer) Verify(receipt models.Receipt) error {
+	if receipt.Algorithm != "" && receipt.Algorithm != Algorithm {
+		return fmt.Errorf("unsupported receipt algorithm %q", receipt.Algorithm)
+	}
+	secret, found := s.keys[receipt.KeyID]
+	if !found {
+		return ErrUnknownKey
+	}
+
+	got, err := hex.DecodeString(receipt.Signature)
+	if err != nil {
+		return ErrBadSignature
+	}
+	want, _ := hex.DecodeString(sign(secret, receipt.Payload))
+	if !hmac.Equal(got, want) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Payload is the string a receipt signs: the conversion's request and result
+// fields, query-encoded in key order
+func Payload(conversion models.ConvertResponse, issuedAt time.Time) string {
+	values := url.Values{}
+	values.Set("from", conversion.From)
+	values.Set("to", conversion.To)
+	values.Set("original_amount", conversion.OriginalAmount.String())
+	values.Set("amount", conversion.Amount.String())
+	values.Set("rate", strconv.FormatFloat(conversion.Rate, 'f', -1, 64))
+	values.Set("applied_rate", strconv.FormatFloat(conversion.AppliedRate, 'f', -1, 64))
+	values.Set("fee", conversion.Fee.String())
+	values.Set("date", conversion.Date)
+	values.Set("source", conversion.Source)
+	values.Set("issued_at", issuedAt.UTC().Format(time.RFC3339))
+	return values.Encode()
+}
+
+// sign returns hex(HMAC-SHA256(secret, payload))
+func sign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,73 @@
+package receipts
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSigner_SignAndVerify(t *testing.T) {
+	signer, err := NewSigner(map[string]string{"2024": "old", "2025": "new"}, "2025")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receipt := signer.Sign(models.ConvertResponse{
+		From:           "USD",
+		To:             "EUR",
+		OriginalAmount: models.NewAmount(decimal.RequireFromString("100")),
+		Amount:         models.NewAmount(decimal.RequireFromString("92.15")),
+		Rate:           0.9215,
+		AppliedRate:    0.9215,
+		Fee:            models.NewAmount(decimal.Zero),
+		Source:         "exchangerate-api",
+	})
+	if receipt.KeyID != "2025" || receipt.Algorithm != Algorithm {
+		t.Errorf("expected a receipt under the active key, got %+v", receipt)
+	}
+	want := "amount=92.15&applied_rate=0.9215&date=&fee=0&from=USD&issued_at=" + url.QueryEscape(receipt.IssuedAt.Format(time.RFC3339)) +
+		"&original_amount=100&rate=0.9215&source=exchangerate-api&to=EUR"
+	if receipt.Payload != want {
+		t.Errorf("expected payload %q, got %q", want, receipt.Payload)
+	}
+	if err := signer.Verify(receipt); err != nil {
+		t.Errorf("expected the receipt to verify, got %v", err)
+	}
+
+	tampered := receipt
+	tampered.Payload = strings.Replace(receipt.Payload, "amount=92.15", "amount=95", 1)
+	if err := signer.Verify(tampered); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected an altered payload to fail, got %v", err)
+	}
+
+	// receipts from before a rotation still verify under their own key
+	rotated, _ := NewSigner(map[string]string{"2024": "old"}, "")
+	old := rotated.Sign(models.ConvertResponse{From: "USD", To: "GBP"})
+	if err := signer.Verify(old); err != nil {
+		t.Errorf("expected a receipt under a retired key to verify, got %v", err)
+	}
+
+	unknown := receipt
+	unknown.KeyID = "2023"
+	if err := signer.Verify(unknown); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected an unknown key to fail, got %v", err)
+	}
+}
+
+func TestNewSigner_Config(t *testing.T) {
+	if _, err := NewSigner(nil, ""); err == nil {
+		t.Error("expected an error without keys")
+	}
+	if _, err := NewSigner(map[string]string{"a": "1", "b": "2"}, ""); err == nil {
+		t.Error("expected an error when several keys leave the active one unnamed")
+	}
+	if _, err := NewSigner(map[string]string{"a": "1"}, "b"); err == nil {
+		t.Error("expected an error naming a key that isn't configured")
+	}
+}
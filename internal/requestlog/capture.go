@@ -0,0 +1,230 @@
+package requestlog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/logging"
+)
+
+// credential headers and query parameters that are always redacted
+var (
+	secretHeaders = []string{"Authorization", "Proxy-Authorization", "X-API-Key", "Cookie", "Set-Cookie", "X-Signature"}
+	secretParams  = []string{"api_key", "apikey", "access_token", "token"}
+)
+
+// Options control what Capture records
+type Options struct {
+	// share of requests recorded, 0-1 - ErrorSampleRate applies to 4xx and 5xx answers
+	SampleRate      float64
+	ErrorSampleRate float64
+	// longest request or response body kept - longer ones are cut and flagged
+	MaxBodyBytes int
+	// redacted on top of the credential headers
+	RedactHeaders []string
+	// paths never recorded (probes, long-lived WebSockets)
+	Exempt []string
+}
+
+// Recorder takes finished records - a *Logger
+type Recorder interface {
+	Record(record Record)
+}
+
+// Capture records sampled requests with their responses. Bodies are copied as
+// the handler reads and writes them, so nothing is buffered beyond MaxBodyBytes
+type Capture struct {
+	recorder Recorder
+	opts     Options
+	redact   map[string]bool
+	exempt   map[string]bool
+	random   func() float64
+}
+
+// NewCapture records to recorder with opts
+func NewCapture(recorder Recorder, opts Options) *Capture {
+	c := &Capture{
+		recorder: recorder,
+		opts:     opts,
+		redact:   make(map[string]bool),
+		exempt:   make(map[string]bool, len(opts.Exempt)),
+		random:   rand.Float64,
+	}
+	for _, name := range append(secretHeaders, opts.RedactHeaders...) {
+		c.redact[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+	}
+	for _, path := range opts.Exempt {
+		c.exempt[path] = true
+	}
+	return c
+}
+
+// Middleware captures the exchange and hands it to the recorder when sampled
+func (c *Capture) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.exempt[r.URL.Path] || (c.opts.SampleRate <= 0 && c.opts.ErrorSampleRate <= 0) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		requestBody := &cappedBuffer{max: c.opts.MaxBodyBytes}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = &teeBody{ReadCloser: r.Body, copy: requestBody}
+		}
+		rec := &captureWriter{ResponseWriter: w, status: http.StatusOK, body: &cappedBuffer{max: c.opts.MaxBodyBytes}}
+
+		next.ServeHTTP(rec, r)
+
+		rate := c.opts.SampleRate
+		if rec.status >= http.StatusBadRequest {
+			rate = c.opts.ErrorSampleRate
+		}
+		if rate < 1 && c.random() >= rate {
+			return
+		}
+
+		c.recorder.Record(Record{
+			Time:                  start.UTC(),
+			RequestID:             logging.RequestID(r.Context()),
+			Method:                r.Method,
+			Path:                  r.URL.Path,
+			Query:                 redactQuery(r.URL.RawQuery),
+			RemoteAddr:            r.RemoteAddr,
+			RequestHeaders:        c.headers(r.Header),
+			RequestBody:           requestBody.String(),
+			RequestBodyTruncated:  requestBody.truncated,
+			Status:                rec.status,
+			ResponseHeaders:       c.headers(w.Header()),
+			ResponseBody:          rec.body.String(),
+			ResponseBodyTruncated: rec.body.truncated,
+			LatencyMs:             time.Since(start).Milliseconds(),
+		})
+	})
+}
+
+// headers flattens h, masking the redacted ones down to their last 4
+// characters - enough to tell which API key was used, not enough to use it
+func (c *Capture) headers(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	flat := make(map[string]string, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		if c.redact[name] {
+			value = mask(value)
+		}
+		flat[name] = value
+	}
+	return flat
+}
+
+// mask keeps an auth scheme ("Bearer") and the last 4 characters of the secret
+func mask(value string) string {
+	scheme, secret, found := strings.Cut(value, " ")
+	if !found {
+		scheme, secret = "", value
+	} else {
+		scheme += " "
+	}
+	if len(secret) <= 8 {
+		return scheme + "****"
+	}
+	return scheme + "****" + secret[len(secret)-4:]
+}
+
+// redactQuery masks credential parameters, leaving the rest as sent
+func redactQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return raw
+	}
+	redacted := false
+	for _, name := range secretParams {
+		if _, found := values[name]; found {
+			values.Set(name, mask(values.Get(name)))
+			redacted = true
+		}
+	}
+	if !redacted {
+		return raw
+	}
+	return values.Encode()
+}
+
+// cappedBuffer keeps the first max bytes written to it
+type cappedBuffer struct {
+	max       int
+	data      []byte
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) {
+	room := b.max - len(b.data)
+	if len(p) > room {
+		p = p[:max(room, 0)]
+		b.truncated = true
+	}
+	b.data = append(b.data, p...)
+}
+
+func (b *cappedBuffer) String() string {
+	return string(b.data)
+}
+
+// teeBody copies what the handler reads of the request body
+type teeBody struct {
+	io.ReadCloser
+	copy *cappedBuffer
+}
+
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.copy.Write(p[:n])
+	return n, err
+}
+
+// captureWriter passes the response through while keeping its status and the
+// start of its body
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   *cappedBuffer
+}
+
+func (rec *captureWriter) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *captureWriter) Write(p []byte) (int, error) {
+	rec.body.Write(p)
+	return rec.ResponseWriter.Write(p)
+}
+
+// Flush keeps streaming responses working through the wrapper
+func (rec *captureWriter) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets WebSocket upgrades through the wrapper
+func (rec *captureWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
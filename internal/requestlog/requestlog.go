@@ -0,0 +1,180 @@
+// Package requestlog keeps full request/response records - headers, bodies,
+// status and timing - as JSON lines, for settling disputes about what the
+// service actually answered. It is separate from the one-line access log:
+// records are sampled, capped in size and have credentials redacted before
+// they are written.
+package requestlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is one logged request and the response it got
+type Record struct {
+	Time                  time.Time         `json:"time"`
+	RequestID             string            `json:"request_id,omitempty"`
+	Method                string            `json:"method"`
+	Path                  string            `json:"path"`
+	Query                 string            `json:"query,omitempty"`
+	RemoteAddr            string            `json:"remote_addr,omitempty"`
+	RequestHeaders        map[string]string `json:"request_headers,omitempty"`
+	RequestBody           string            `json:"request_body,omitempty"`
+	RequestBodyTruncated  bool              `json:"request_body_truncated,omitempty"`
+	Status                int               `json:"status"`
+	ResponseHeaders       map[string]string `json:"response_headers,omitempty"`
+	ResponseBody          string            `json:"response_body,omitempty"`
+	ResponseBodyTruncated bool              `json:"response_body_truncated,omitempty"`
+	LatencyMs             int64             `json:"latency_ms"`
+}
+
+// Sink is where records end up
+type Sink interface {
+	Write(records []Record) error
+	Close() error
+}
+
+// NewSink opens a sink by kind: "stdout" or "file" (JSON lines appended to path)
+func NewSink(kind, path string) (Sink, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "stdout":
+		return &lineSink{out: os.Stdout}, nil
+	case "file":
+		if path == "" {
+			path = "requests.log"
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open request log file: %w", err)
+		}
+		return &lineSink{out: file, file: file}, nil
+	default:
+		return nil, fmt.Errorf("unknown request log sink %q (expected stdout or file)", kind)
+	}
+}
+
+// lineSink writes records as JSON lines, closing the file when it owns one
+type lineSink struct {
+	mu   sync.Mutex
+	out  io.Writer
+	file *os.File
+}
+
+func (s *lineSink) Write(records []Record) error {
+	var buf strings.Builder
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode request record: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := io.WriteString(s.out, buf.String()); err != nil {
+		return fmt.Errorf("failed to write request records: %w", err)
+	}
+	return nil
+}
+
+func (s *lineSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// most records written to the sink in one go
+const maxBatch = 100
+
+// Logger queues records and writes them to the sink in the background, so a
+// request never waits on the log. When the queue is full records are dropped
+type Logger struct {
+	sink  Sink
+	queue chan Record
+
+	shutdownChannel   chan struct{}
+	backgroundWorkers sync.WaitGroup
+}
+
+// NewLogger creates a logger with room for queueSize pending records
+func NewLogger(sink Sink, queueSize int) *Logger {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	return &Logger{
+		sink:            sink,
+		queue:           make(chan Record, queueSize),
+		shutdownChannel: make(chan struct{}),
+	}
+}
+
+// Record queues a record for writing
+func (l *Logger) Record(record Record) {
+	select {
+	case l.queue <- record:
+	default:
+		slog.Warn("Request log queue full, dropping record", "path", record.Path, "request_id", record.RequestID)
+	}
+}
+
+// Start runs the writer goroutine
+func (l *Logger) Start() {
+	l.backgroundWorkers.Add(1)
+	go l.writeLoop()
+}
+
+// Stop writes out queued records, waits for the writer and closes the sink
+func (l *Logger) Stop() {
+	close(l.shutdownChannel)
+	l.backgroundWorkers.Wait()
+	if err := l.sink.Close(); err != nil {
+		slog.Warn("Failed to close request log sink", "error", err)
+	}
+}
+
+func (l *Logger) writeLoop() {
+	defer l.backgroundWorkers.Done()
+
+	for {
+		select {
+		case record := <-l.queue:
+			l.write(l.batch(record))
+		case <-l.shutdownChannel:
+			for {
+				select {
+				case record := <-l.queue:
+					l.write(l.batch(record))
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// batch collects whatever else is already queued behind first
+func (l *Logger) batch(first Record) []Record {
+	records := []Record{first}
+	for len(records) < maxBatch {
+		select {
+		case record := <-l.queue:
+			records = append(records, record)
+		default:
+			return records
+		}
+	}
+	return records
+}
+
+func (l *Logger) write(records []Record) {
+	if err := l.sink.Write(records); err != nil {
+		slog.Error("Failed to write request records", "records", len(records), "error", err)
+	}
+}
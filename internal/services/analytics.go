@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+)
+
+// how far back analytics look when no start date is given
+const defaultAnalyticsWindow = 30
+
+// widest range a single analytics query may cover
+const maxAnalyticsDays = 366
+
+// RateArchive is what analytics needs from the rate store
+type RateArchive interface {
+	History(ctx context.Context, fromCurrency, toCurrency, startDate, endDate string) ([]models.RateRecord, error)
+	Series(ctx context.Context, fromCurrency, toCurrency, startDate, endDate string) (map[string]float64, error)
+}
+
+// AnalyticsService answers questions about rates we've stored
+// Unlike the historical endpoints it never calls the upstream
+type AnalyticsService struct {
+	archive    RateArchive
+	currencies CurrencyRegistry
+}
+
+// NewAnalyticsService creates an analytics service on top of the rate store
+func NewAnalyticsService(archive RateArchive, currencies CurrencyRegistry) *AnalyticsService {
+	return &AnalyticsService{
+		archive:    archive,
+		currencies: currencies,
+	}
+}
+
+// History returns every stored fetch for a pair in the range, oldest first
+func (s *AnalyticsService) History(ctx context.Context, fromCurrency, toCurrency, startStr, endStr string) ([]models.RateRecord, error) {
+	from, to, start, end, err := s.validateQuery(fromCurrency, toCurrency, startStr, endStr)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.archive.History(ctx, from, to, start, end)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err, "failed to read rate history")
+	}
+	return records, nil
+}
+
+// Summary returns min/max/average and the change over the range, using the
+// last stored rate of each day
+func (s *AnalyticsService) Summary(ctx context.Context, fromCurrency, toCurrency, startStr, endStr string) (models.RateSummary, error) {
+	from, to, start, end, err := s.validateQuery(fromCurrency, toCurrency, startStr, endStr)
+	if err != nil {
+		return models.RateSummary{}, err
+	}
+
+	series, err := s.archive.Series(ctx, from, to, start, end)
+	if err != nil {
+		return models.RateSummary{}, apperrors.Wrap(apperrors.CodeInternal, err, "failed to read rate history")
+	}
+
+	summary := models.RateSummary{From: from, To: to, Start: start, End: end, Days: len(series)}
+	if len(series) == 0 {
+		return summary, nil
+	}
+
+	days := make([]string, 0, len(series))
+	for day := range series {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	total := 0.0
+	summary.Min = series[days[0]]
+	summary.Max = series[days[0]]
+	for _, day := range days {
+		rate := series[day]
+		total += rate
+		if rate < summary.Min {
+			summary.Min = rate
+		}
+		if rate > summary.Max {
+			summary.Max = rate
+		}
+	}
+
+	summary.Average = total / float64(len(days))
+	summary.First = series[days[0]]
+	summary.Last = series[days[len(days)-1]]
+	summary.ChangePercent = (summary.Last - summary.First) / summary.First * 100
+
+	return summary, nil
+}
+
+// validateQuery normalizes the pair and range - end defaults to today, start to 30 days before end
+func (s *AnalyticsService) validateQuery(fromCurrency, toCurrency, startStr, endStr string) (string, string, string, string, error) {
+	from := strings.ToUpper(strings.TrimSpace(fromCurrency))
+	to := strings.ToUpper(strings.TrimSpace(toCurrency))
+
+	if !s.currencies.IsSupported(from) {
+		return "", "", "", "", apperrors.New(apperrors.CodeUnsupportedCurrency, "unsupported source currency: %s", from)
+	}
+	if !s.currencies.IsSupported(to) {
+		return "", "", "", "", apperrors.New(apperrors.CodeUnsupportedCurrency, "unsupported target currency: %s", to)
+	}
+
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	if endStr != "" {
+		parsed, err := time.Parse("2006-01-02", endStr)
+		if err != nil {
+			return "", "", "", "", apperrors.New(apperrors.CodeInvalidDate, "invalid end date format, expected YYYY-MM-DD: %s", endStr)
+		}
+		end = parsed
+	}
+
+	start := end.AddDate(0, 0, -defaultAnalyticsWindow)
+	if startStr != "" {
+		parsed, err := time.Parse("2006-01-02", startStr)
+		if err != nil {
+			return "", "", "", "", apperrors.New(apperrors.CodeInvalidDate, "invalid start date format, expected YYYY-MM-DD: %s", startStr)
+		}
+		start = parsed
+	}
+
+	if end.Before(start) {
+		return "", "", "", "", apperrors.New(apperrors.CodeDateOutOfRange, "invalid date range: end is before start")
+	}
+	if end.Sub(start) > maxAnalyticsDays*24*time.Hour {
+		return "", "", "", "", apperrors.New(apperrors.CodeDateOutOfRange, "date range too large: max %d days", maxAnalyticsDays)
+	}
+
+	return from, to, start.Format("2006-01-02"), end.Format("2006-01-02"), nil
+}
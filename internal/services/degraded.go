@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/metrics"
+)
+
+// DegradedReporter says whether the service is in degraded mode, and why
+type DegradedReporter interface {
+	Degraded() (reason string, since time.Time, degraded bool)
+}
+
+// DegradedMode declares the service degraded while no provider can be called -
+// every circuit breaker open, every provider cooling down after a rate limit
+// or out of quota. While it lasts requests never wait on the upstream: cached
+// and derived rates are served flagged degraded, everything else fails fast.
+// It ends on its own once a breaker's cooldown passes or a quota resets, and
+// the next call probes the provider.
+type DegradedMode struct {
+	check    func(ctx context.Context) error
+	interval time.Duration
+
+	mu     sync.RWMutex
+	reason string
+	since  time.Time
+	active bool
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// NewDegradedMode re-evaluates check every interval - check must be cheap and
+// must not call the providers (ProviderChain.CheckUpstream)
+func NewDegradedMode(check func(ctx context.Context) error, interval time.Duration) *DegradedMode {
+	return &DegradedMode{
+		check:    check,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start evaluates right away, then every interval until Stop
+func (d *DegradedMode) Start() {
+	d.done.Add(1)
+	go func() {
+		defer d.done.Done()
+
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		d.Evaluate(context.Background())
+		for {
+			select {
+			case <-ticker.C:
+				d.Evaluate(context.Background())
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the evaluation loop
+func (d *DegradedMode) Stop() {
+	close(d.stop)
+	d.done.Wait()
+}
+
+// Evaluate runs the check once, entering or leaving degraded mode
+func (d *DegradedMode) Evaluate(ctx context.Context) {
+	err := d.check(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch {
+	case err != nil && !d.active:
+		d.active, d.reason, d.since = true, err.Error(), time.Now().UTC()
+		slog.Warn("Entering degraded mode, serving cached rates only", "reason", d.reason)
+	case err != nil:
+		// still degraded, possibly for a different mix of reasons
+		d.reason = err.Error()
+	case d.active:
+		slog.Info("Leaving degraded mode, upstream available again", "degraded_for", time.Since(d.since).Round(time.Second).String())
+		d.active, d.reason, d.since = false, "", time.Time{}
+	}
+	metrics.SetDegradedMode(d.active)
+}
+
+// Degraded reports the current mode
+func (d *DegradedMode) Degraded() (string, time.Time, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.reason, d.since, d.active
+}
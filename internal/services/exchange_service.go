@@ -1,170 +1,983 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
 	"exchange-rate-service/config"
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/calendar"
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/fees"
+	"exchange-rate-service/internal/metrics"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/tenant"
+	"exchange-rate-service/internal/tracing"
+
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
 )
 
 // main service for currency ops
 type CurrencyExchangeService struct {
-	cache     ExchangeRateCache
-	apiClient ExchangeRateAPIClient
+	cache      ExchangeRateCache
+	apiClient  ExchangeRateAPIClient
+	currencies CurrencyRegistry
+	history    RateHistory
+	fees       FeeSchedule
+	calendar   *calendar.Calendar
+	degraded   DegradedReporter
+
+	// concurrent misses for the same pair+date share one upstream call
+	flights singleflight.Group
+
+	// pairs no provider quotes, routed without trying them first
+	unquoted unquotedPairs
 }
 
 // ExchangeRateCache defines what we need from our caching layer
+// GetRateEntry returns entries of any age, flagged Stale once past the TTL
 type ExchangeRateCache interface {
-	GetRate(fromCurrency, toCurrency string) (float64, bool)
-	SetRate(fromCurrency, toCurrency string, rate float64)
+	GetRateEntry(fromCurrency, toCurrency string) (models.RateQuote, bool)
+	SetRate(fromCurrency, toCurrency string, rate float64, source string)
+}
+
+// PairAvailability is optionally implemented by caches that back off pairs the
+// provider keeps failing - misses for them don't call the upstream
+type PairAvailability interface {
+	PairUnavailable(fromCurrency, toCurrency string) (retryAt time.Time, unavailable bool)
 }
 
 // ExchangeRateAPIClient defines what we need from our API client
 type ExchangeRateAPIClient interface {
-	GetRate(fromCurrency, toCurrency, dateStr string) (float64, error)
+	GetRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, error)
+}
+
+// SourcedRateClient is optionally implemented by API clients that can say
+// which provider answered (the provider chain does)
+type SourcedRateClient interface {
+	GetRateWithSource(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, string, error)
+}
+
+// ExchangeRateRangeClient is optionally implemented by API clients that can
+// fetch a whole date range in one call
+type ExchangeRateRangeClient interface {
+	GetRateRange(ctx context.Context, fromCurrency, toCurrency, startDate, endDate string) (map[string]float64, error)
+}
+
+// HistoricalRateCache is optionally implemented by caches that also keep past
+// days' rates - those never change, so a hit needs no store or upstream call
+type HistoricalRateCache interface {
+	GetHistoricalRate(fromCurrency, toCurrency, date string) (models.RateQuote, bool)
+	SetHistoricalRate(fromCurrency, toCurrency, date string, quote models.RateQuote)
 }
 
-// create new service
-func NewCurrencyExchangeService(cache ExchangeRateCache, apiClient ExchangeRateAPIClient) *CurrencyExchangeService {
+// CurrencyRegistry is the set of currencies we accept, kept in sync with the provider
+type CurrencyRegistry interface {
+	IsSupported(code string) bool
+	List() []currency.Currency
+}
+
+// RateHistory is the local store of previously fetched rates
+// Historical lookups try it before the upstream
+type RateHistory interface {
+	GetRate(ctx context.Context, fromCurrency, toCurrency, date string) (float64, bool, error)
+	Series(ctx context.Context, fromCurrency, toCurrency, startDate, endDate string) (map[string]float64, error)
+}
+
+// FeeSchedule picks the markup charged on a conversion
+type FeeSchedule interface {
+	RuleFor(fromCurrency, toCurrency string) fees.Rule
+}
+
+// create new service - history may be nil (no local store)
+func NewCurrencyExchangeService(cache ExchangeRateCache, apiClient ExchangeRateAPIClient, currencies CurrencyRegistry, history RateHistory) *CurrencyExchangeService {
 	return &CurrencyExchangeService{
-		cache:     cache,
-		apiClient: apiClient,
+		cache:      cache,
+		apiClient:  apiClient,
+		currencies: currencies,
+		history:    history,
+		calendar:   calendar.Default(),
+	}
+}
+
+// SetCalendar replaces the default weekends-only, UTC market calendar - call
+// before serving traffic
+func (s *CurrencyExchangeService) SetCalendar(c *calendar.Calendar) {
+	s.calendar = c
+}
+
+// SetDegradedMode makes lookups stop calling the upstream while reporter says
+// the service is degraded - call before serving traffic
+func (s *CurrencyExchangeService) SetDegradedMode(reporter DegradedReporter) {
+	s.degraded = reporter
+}
+
+// isDegraded reports whether the service is in degraded mode right now
+func (s *CurrencyExchangeService) isDegraded() bool {
+	if s.degraded == nil {
+		return false
 	}
+	_, _, degraded := s.degraded.Degraded()
+	return degraded
+}
+
+// SetFees charges schedule on every conversion - call before serving traffic
+// Without one conversions are at the mid-market rate
+func (s *CurrencyExchangeService) SetFees(schedule FeeSchedule) {
+	s.fees = schedule
 }
 
 // convert currency amount
-func (s *CurrencyExchangeService) ConvertCurrencyAmount(from, to string, amt float64, dt string) (float64, error) {
+// Uses decimal math so 100 INR doesn't come back as 84.99999999999999, and
+// rounds to the target currency's minor units (JPY 0, most others 2).
+// ctx bounds any upstream call - pass the request context. It also carries
+// the caller's tenant policy, if any: its pairs, markup and history limit
+func (s *CurrencyExchangeService) ConvertCurrencyAmount(ctx context.Context, from, to string, amt decimal.Decimal, dt string) (models.ConversionResult, error) {
+	conversions, err := s.ConvertAmounts(ctx, from, to, []decimal.Decimal{amt}, dt)
+	if err != nil {
+		return models.ConversionResult{}, err
+	}
+	return conversions[0], nil
+}
+
+// ConvertAmounts converts each of amounts from one currency to another at a
+// single rate lookup, fees and rounding applied per amount as
+// ConvertCurrencyAmount does. Results keep the order of amounts; one negative
+// amount fails the whole call
+func (s *CurrencyExchangeService) ConvertAmounts(ctx context.Context, from, to string, amounts []decimal.Decimal, dt string) ([]models.ConversionResult, error) {
 	// validate inputs
 	if err := s.validateCurrencyPair(from, to); err != nil {
-		return 0, err
+		return nil, err
+	}
+	if err := s.enforcePolicy(ctx, from, to, dt); err != nil {
+		return nil, err
 	}
 
-	if amt < 0 {
-		return 0, fmt.Errorf("amount cannot be negative: %f", amt)
+	for _, amt := range amounts {
+		if amt.IsNegative() {
+			return nil, apperrors.New(apperrors.CodeInvalidAmount, "amount cannot be negative: %s", amt.String())
+		}
 	}
 
-	// same currency = no conversion needed
+	minorUnits := config.GetMinorUnits(to)
+	conversions := make([]models.ConversionResult, 0, len(amounts))
+
+	// same currency = no conversion needed, and nothing to charge for
 	if from == to {
-		return amt, nil
+		for _, amt := range amounts {
+			conversions = append(conversions, models.ConversionResult{Amount: amt.Round(minorUnits), Quote: models.RateQuote{Rate: 1.0}, AppliedRate: 1.0})
+		}
+		return conversions, nil
 	}
 
 	// get rate for this pair
-	rate, err := s.getExchangeRateForPair(from, to, dt)
+	quote, err := s.getExchangeRateForPair(ctx, from, to, dt)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get exchange rate: %w", err)
+		return nil, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+
+	// NewFromFloat uses the shortest representation, so 0.85 stays exactly 0.85
+	midRate := decimal.NewFromFloat(quote.Rate)
+
+	var rule fees.Rule
+	if schedule := s.feesFor(ctx); schedule != nil {
+		rule = schedule.RuleFor(from, to)
+	}
+	for _, amt := range amounts {
+		charged := rule.Apply(amt, midRate, minorUnits)
+		appliedRate, _ := charged.AppliedRate.Float64()
+		conversions = append(conversions, models.ConversionResult{
+			Amount:      charged.Amount,
+			Quote:       quote,
+			AppliedRate: appliedRate,
+			Fee:         charged.Fee,
+		})
 	}
 
-	result := amt * rate
-	return result, nil
+	return conversions, nil
+}
+
+// ConvertToMany converts amt from one currency into each of targets, resolving
+// the rates concurrently. Results keep the order of targets. A target that
+// fails carries its error in its result and doesn't fail the others; only an
+// invalid source currency or amount fails the whole call.
+func (s *CurrencyExchangeService) ConvertToMany(ctx context.Context, from string, targets []string, amt decimal.Decimal, dt string) ([]models.TargetConversion, error) {
+	// the source on its own - targets are checked per result
+	if err := s.validateCurrencyPair(from, from); err != nil {
+		return nil, err
+	}
+	if amt.IsNegative() {
+		return nil, apperrors.New(apperrors.CodeInvalidAmount, "amount cannot be negative: %s", amt.String())
+	}
+
+	results := make([]models.TargetConversion, len(targets))
+	var wg sync.WaitGroup
+	for i, to := range targets {
+		wg.Add(1)
+		go func(i int, to string) {
+			defer wg.Done()
+			result, err := s.ConvertCurrencyAmount(ctx, from, to, amt, dt)
+			results[i] = models.TargetConversion{To: to, Result: result, Err: err}
+		}(i, to)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// GetLatestRate returns the current rate for a pair (cached when fresh)
+func (service *CurrencyExchangeService) GetLatestRate(ctx context.Context, fromCurrency, toCurrency string) (models.RateQuote, error) {
+	if err := service.validateCurrencyPair(fromCurrency, toCurrency); err != nil {
+		return models.RateQuote{}, err
+	}
+	if err := service.enforcePolicy(ctx, fromCurrency, toCurrency, ""); err != nil {
+		return models.RateQuote{}, err
+	}
+	if fromCurrency == toCurrency {
+		return models.RateQuote{Rate: 1.0}, nil
+	}
+
+	return service.getExchangeRateForPair(ctx, fromCurrency, toCurrency, "")
 }
 
 // GetHistoricalRate retrieves historical exchange rate for a specific date
-func (service *CurrencyExchangeService) GetHistoricalExchangeRate(fromCurrency, toCurrency, dateStr string) (float64, error) {
+// When nobody has a rate for that day (weekend, holiday) the most recent prior
+// trading day's rate is returned instead - quote.Date says which day was used,
+// quote.Closure why, when the market calendar knew the day was closed
+func (service *CurrencyExchangeService) GetHistoricalExchangeRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (models.RateQuote, error) {
+	quote, err := service.historicalExchangeRate(ctx, fromCurrency, toCurrency, dateStr)
+	quote.Degraded = err == nil && service.isDegraded()
+	return quote, err
+}
+
+// historicalExchangeRate is GetHistoricalExchangeRate before the degraded flag
+func (service *CurrencyExchangeService) historicalExchangeRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (models.RateQuote, error) {
 	// Validate the currency pair first
 	if err := service.validateCurrencyPair(fromCurrency, toCurrency); err != nil {
-		return 0, err
+		return models.RateQuote{}, err
 	}
 
 	// Same currency is always 1:1, even historically
 	if fromCurrency == toCurrency {
-		return 1.0, nil
+		return models.RateQuote{Rate: 1.0, Date: dateStr}, nil
 	}
 
-	// Parse and validate the date
-	parsedDate, err := service.validateAndParseDate(dateStr)
+	// Parse and validate the date against today where the pair is fixed
+	today := service.calendar.Today(fromCurrency, toCurrency)
+	parsedDate, err := service.validateAndParseDate(dateStr, today)
 	if err != nil {
-		return 0, err
+		return models.RateQuote{}, err
 	}
 
 	// Check if the date is within our allowed historical range
-	if err := service.validateHistoricalRange(parsedDate); err != nil {
-		return 0, err
+	if err := service.validateHistoricalRange(parsedDate, today); err != nil {
+		return models.RateQuote{}, err
+	}
+	if err := service.enforcePolicy(ctx, fromCurrency, toCurrency, dateStr); err != nil {
+		return models.RateQuote{}, err
+	}
+
+	// a closed market publishes nothing, so go straight to the last trading
+	// day. That answer is final and is cached under the requested day too
+	closure := service.calendar.PairClosure(fromCurrency, toCurrency, parsedDate)
+	if closure != "" {
+		historical, cacheable := service.cache.(HistoricalRateCache)
+		if cacheable {
+			if quote, found := historical.GetHistoricalRate(fromCurrency, toCurrency, dateStr); found {
+				quote.Closure = closure
+				return quote, nil
+			}
+		}
+		if fallback, found := service.priorTradingDayQuote(ctx, fromCurrency, toCurrency, parsedDate, today); found {
+			if cacheable {
+				historical.SetHistoricalRate(fromCurrency, toCurrency, dateStr, fallback)
+			}
+			fallback.Closure = closure
+			return fallback, nil
+		}
+		// nothing in the window - some providers carry a rate over closed days
+	}
+
+	quote, err := service.historicalQuote(ctx, fromCurrency, toCurrency, dateStr)
+	if err == nil || closure != "" || !errors.Is(err, apperrors.ErrUpstreamUnavailable) || ctx.Err() != nil {
+		quote.Closure = closure
+		return quote, err
 	}
 
-	// get historical rate - no caching for historical data
-	historicalRate, err := service.apiClient.GetRate(fromCurrency, toCurrency, dateStr)
+	// a trading day without a rate may be a holiday the calendar doesn't know,
+	// or just an outage - asked again next time either way
+	fallback, found := service.priorTradingDayQuote(ctx, fromCurrency, toCurrency, parsedDate, today)
+	if !found {
+		return models.RateQuote{}, err
+	}
+	slog.DebugContext(ctx, "No rate for requested day, using prior trading day",
+		"pair", fromCurrency+"-"+toCurrency, "date", dateStr, "effective_date", fallback.Date)
+	return fallback, nil
+}
+
+// priorTradingDayQuote walks back from date, at most config.HistoricalFallbackDays
+// days, to the latest earlier day with a rate. Days the market calendar has
+// the pair closed are skipped since nothing is published then.
+func (service *CurrencyExchangeService) priorTradingDayQuote(ctx context.Context, fromCurrency, toCurrency string, date, today time.Time) (models.RateQuote, bool) {
+	for back := 1; back <= config.HistoricalFallbackDays; back++ {
+		day := date.AddDate(0, 0, -back)
+		if service.calendar.PairClosure(fromCurrency, toCurrency, day) != "" {
+			continue
+		}
+		dayStr := day.Format("2006-01-02")
+		if ctx.Err() != nil || service.validateHistoricalRange(day, today) != nil || service.enforcePolicy(ctx, fromCurrency, toCurrency, dayStr) != nil {
+			break
+		}
+
+		quote, err := service.historicalQuote(ctx, fromCurrency, toCurrency, dayStr)
+		if err == nil {
+			return quote, true
+		}
+		if errors.Is(err, apperrors.ErrDegraded) {
+			break
+		}
+	}
+
+	return models.RateQuote{}, false
+}
+
+// historicalQuote looks a validated past date up in the historical cache, then
+// the rate store, then the upstream (the provider chain records the answer).
+// Whatever is found is kept in the historical cache for the next caller.
+func (service *CurrencyExchangeService) historicalQuote(ctx context.Context, fromCurrency, toCurrency, dateStr string) (models.RateQuote, error) {
+	historical, cacheable := service.cache.(HistoricalRateCache)
+	if cacheable {
+		if quote, found := historical.GetHistoricalRate(fromCurrency, toCurrency, dateStr); found {
+			return quote, nil
+		}
+	}
+
+	quote := models.RateQuote{Cached: true, Date: dateStr}
+	if rate, found := service.storedRate(ctx, fromCurrency, toCurrency, dateStr); found {
+		quote.Rate = rate
+	} else {
+		if service.isDegraded() {
+			metrics.RecordDegradedRejection("historical")
+			return models.RateQuote{}, apperrors.New(apperrors.CodeDegraded,
+				"service is in degraded mode: no stored %s-%s rate for %s", strings.ToUpper(fromCurrency), strings.ToUpper(toCurrency), dateStr)
+		}
+		fetched, err := service.quoteUpstream(ctx, fromCurrency, toCurrency, dateStr)
+		if err != nil {
+			return models.RateQuote{}, upstreamError(err, "failed to fetch historical rate")
+		}
+		quote = fetched
+	}
+
+	if cacheable {
+		historical.SetHistoricalRate(fromCurrency, toCurrency, dateStr, quote)
+	}
+	return quote, nil
+}
+
+// timeSeriesWindowDays is how much of a streamed time series is fetched at a
+// time - one range request, or daily ones through the worker pool, per window
+const timeSeriesWindowDays = 31
+
+// GetHistoricalRateRange returns a date-keyed series of rates for [startStr, endStr]
+// Days the providers have no data for (weekends, holidays) are left out
+func (service *CurrencyExchangeService) GetHistoricalRateRange(ctx context.Context, fromCurrency, toCurrency, startStr, endStr string) (map[string]float64, error) {
+	startDate, endDate, err := service.validateRange(ctx, fromCurrency, toCurrency, startStr, endStr)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch historical rate: %w", err)
+		return nil, err
 	}
+	return service.rangeSeries(ctx, fromCurrency, toCurrency, startDate, endDate)
+}
+
+// StreamHistoricalRateRange walks [startStr, endStr] in date order and calls
+// emit with each day's rate once the window holding it is fetched, so the
+// caller can write points out while later windows are still to come and
+// memory stays flat however long the range is. A failed window leaves a gap,
+// like a failed day does. Validation errors, and a range without a single
+// rate, are returned before emit is first called; an error from emit stops
+// the walk and is returned
+func (service *CurrencyExchangeService) StreamHistoricalRateRange(ctx context.Context, fromCurrency, toCurrency, startStr, endStr string, emit func(date string, rate float64) error) error {
+	startDate, endDate, err := service.validateRange(ctx, fromCurrency, toCurrency, startStr, endStr)
+	if err != nil {
+		return err
+	}
+
+	emitted := 0
+	var lastErr error
+	for windowStart := startDate; !windowStart.After(endDate); windowStart = windowStart.AddDate(0, 0, timeSeriesWindowDays) {
+		windowEnd := windowStart.AddDate(0, 0, timeSeriesWindowDays-1)
+		if windowEnd.After(endDate) {
+			windowEnd = endDate
+		}
 
-	return historicalRate, nil
+		series, err := service.rangeSeries(ctx, fromCurrency, toCurrency, windowStart, windowEnd)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			lastErr = err
+			continue
+		}
+		for day := windowStart; !day.After(windowEnd); day = day.AddDate(0, 0, 1) {
+			date := day.Format("2006-01-02")
+			rate, found := series[date]
+			if !found {
+				continue
+			}
+			if err := emit(date, rate); err != nil {
+				return err
+			}
+			emitted++
+		}
+	}
+
+	if emitted == 0 && lastErr != nil {
+		return lastErr
+	}
+	return nil
 }
 
-// getExchangeRateForPair retrieves exchange rate, using cache for latest rates
+// validateRange checks a time series request and parses its dates
+func (service *CurrencyExchangeService) validateRange(ctx context.Context, fromCurrency, toCurrency, startStr, endStr string) (time.Time, time.Time, error) {
+	if err := service.validateCurrencyPair(fromCurrency, toCurrency); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
 
-func (service *CurrencyExchangeService) getExchangeRateForPair(fromCurrency, toCurrency, dateStr string) (float64, error) {
-	// For historical dates, we always fetch fresh from the API (no caching)
-	if dateStr != "" {
-		parsedDate, err := service.validateAndParseDate(dateStr)
+	today := service.calendar.Today(fromCurrency, toCurrency)
+	startDate, err := service.validateAndParseDate(startStr, today)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	endDate, err := service.validateAndParseDate(endStr, today)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if endDate.Before(startDate) {
+		return time.Time{}, time.Time{}, apperrors.New(apperrors.CodeDateOutOfRange, "invalid date range: end %s is before start %s", endStr, startStr)
+	}
+
+	// the whole range has to be inside the allowed window, so checking start is enough
+	if err := service.validateHistoricalRange(startDate, today); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if err := service.enforcePolicy(ctx, fromCurrency, toCurrency, startStr); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return startDate, endDate, nil
+}
+
+// rangeSeries fetches [startDate, endDate] of an already validated range:
+// stored days from the rate store, the rest from the providers
+func (service *CurrencyExchangeService) rangeSeries(ctx context.Context, fromCurrency, toCurrency string, startDate, endDate time.Time) (map[string]float64, error) {
+	startStr, endStr := startDate.Format("2006-01-02"), endDate.Format("2006-01-02")
+
+	days := make([]string, 0)
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format("2006-01-02"))
+	}
+
+	// same currency is trivially 1:1 every day
+	if strings.EqualFold(fromCurrency, toCurrency) {
+		series := make(map[string]float64, len(days))
+		for _, day := range days {
+			series[day] = 1.0
+		}
+		return series, nil
+	}
+
+	// days we already have stored don't need fetching again
+	stored := service.storedSeries(ctx, fromCurrency, toCurrency, startStr, endStr)
+	missing := make([]string, 0, len(days))
+	for _, day := range days {
+		if _, found := stored[day]; !found {
+			missing = append(missing, day)
+		}
+	}
+	if len(stored) > 0 && service.allClosed(fromCurrency, toCurrency, missing) {
+		return stored, nil
+	}
+
+	// degraded: the stored days are all there is
+	if service.isDegraded() {
+		metrics.RecordDegradedRejection("historical")
+		if len(stored) > 0 {
+			slog.WarnContext(ctx, "Degraded mode, serving stored days only",
+				"pair", fromCurrency+"-"+toCurrency, "days", len(stored))
+			return stored, nil
+		}
+		return nil, apperrors.New(apperrors.CodeDegraded,
+			"service is in degraded mode: no stored %s-%s rates for %s to %s", strings.ToUpper(fromCurrency), strings.ToUpper(toCurrency), startStr, endStr)
+	}
+
+	// one range request is far cheaper than N daily ones when the provider supports it
+	if rangeClient, ok := service.apiClient.(ExchangeRateRangeClient); ok {
+		series, err := rangeClient.GetRateRange(ctx, fromCurrency, toCurrency, startStr, endStr)
+		if err == nil && len(series) > 0 {
+			return series, nil
+		}
 		if err != nil {
-			return 0, err
+			slog.WarnContext(ctx, "Range fetch failed, falling back to daily fetches",
+				"pair", fromCurrency+"-"+toCurrency, "error", err)
 		}
+	}
 
-		if err := service.validateHistoricalRange(parsedDate); err != nil {
-			return 0, err
+	series, err := service.fetchDailyRates(ctx, fromCurrency, toCurrency, missing)
+	if err != nil {
+		if len(stored) > 0 && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			slog.WarnContext(ctx, "Upstream failed, serving stored days",
+				"pair", fromCurrency+"-"+toCurrency, "days", len(stored), "error", err)
+			return stored, nil
 		}
+		return nil, err
+	}
+	for day, rate := range stored {
+		series[day] = rate
+	}
+	return series, nil
+}
 
-		return service.apiClient.GetRate(fromCurrency, toCurrency, dateStr)
+// storedRate looks a past day's rate up in the local store
+// Today is never served from the store - the day's rate isn't final yet
+func (service *CurrencyExchangeService) storedRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, bool) {
+	if service.history == nil || dateStr >= time.Now().UTC().Format("2006-01-02") {
+		return 0, false
+	}
+
+	rate, found, err := service.history.GetRate(ctx, strings.ToUpper(fromCurrency), strings.ToUpper(toCurrency), dateStr)
+	if err != nil {
+		slog.WarnContext(ctx, "Rate store lookup failed", "pair", fromCurrency+"-"+toCurrency, "date", dateStr, "error", err)
+		return 0, false
 	}
+	return rate, found
+}
 
-	// check cache first
-	if rate, found := service.cache.GetRate(fromCurrency, toCurrency); found {
-		return rate, nil
+// storedSeries returns the past days of [startStr, endStr] we have stored
+// Store errors are logged and treated as "nothing stored"
+func (service *CurrencyExchangeService) storedSeries(ctx context.Context, fromCurrency, toCurrency, startStr, endStr string) map[string]float64 {
+	if service.history == nil {
+		return nil
 	}
 
-	// cache miss - fetch from api
-	rate, err := service.apiClient.GetRate(fromCurrency, toCurrency, "")
+	series, err := service.history.Series(ctx, strings.ToUpper(fromCurrency), strings.ToUpper(toCurrency), startStr, endStr)
 	if err != nil {
-		return 0, err
+		slog.WarnContext(ctx, "Rate store range lookup failed", "pair", fromCurrency+"-"+toCurrency, "error", err)
+		return nil
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	delete(series, today)
+	return series
+}
+
+// allClosed reports whether the pair's market was closed on every day in days
+// (providers publish nothing then, so a store missing them is still complete)
+func (service *CurrencyExchangeService) allClosed(fromCurrency, toCurrency string, days []string) bool {
+	for _, day := range days {
+		parsed, err := time.Parse("2006-01-02", day)
+		if err != nil || service.calendar.PairClosure(fromCurrency, toCurrency, parsed) == "" {
+			return false
+		}
 	}
+	return true
+}
+
+// MarketClosures returns the days of [startStr, endStr] the pair's market was
+// closed, with why - the gaps a time series has by design rather than by outage
+func (service *CurrencyExchangeService) MarketClosures(fromCurrency, toCurrency, startStr, endStr string) map[string]string {
+	closures := make(map[string]string)
+	start, startErr := time.Parse("2006-01-02", startStr)
+	end, endErr := time.Parse("2006-01-02", endStr)
+	if startErr != nil || endErr != nil {
+		return closures
+	}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if closure := service.calendar.PairClosure(fromCurrency, toCurrency, day); closure != "" {
+			closures[day.Format("2006-01-02")] = closure
+		}
+	}
+	return closures
+}
+
+// fetchDailyRates fetches each day separately through a bounded worker pool
+// Stops handing out days once ctx is done
+func (service *CurrencyExchangeService) fetchDailyRates(ctx context.Context, fromCurrency, toCurrency string, days []string) (map[string]float64, error) {
+	workers := config.TimeSeriesWorkers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan string)
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		series  = make(map[string]float64, len(days))
+		lastErr error
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for day := range jobs {
+				rate, err := service.apiClient.GetRate(ctx, fromCurrency, toCurrency, day)
+
+				mu.Lock()
+				if err != nil {
+					lastErr = err
+				} else {
+					series[day] = rate
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for _, day := range days {
+		select {
+		case jobs <- day:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// a few missing days (weekends) are fine, nothing at all is an outage
+	if len(series) == 0 && lastErr != nil {
+		return nil, upstreamError(lastErr, "failed to fetch historical rates")
+	}
+
+	return series, nil
+}
+
+// getExchangeRateForPair retrieves exchange rate, using cache for latest rates
+// Traced as the service span the cache and upstream spans hang off
+func (service *CurrencyExchangeService) getExchangeRateForPair(ctx context.Context, fromCurrency, toCurrency, dateStr string) (models.RateQuote, error) {
+	ctx, span := tracing.Start(ctx, "ExchangeService.GetRate", tracing.Pair(fromCurrency, toCurrency, dateStr)...)
+	quote, err := service.lookupRate(ctx, fromCurrency, toCurrency, dateStr)
+	quote.Degraded = err == nil && service.isDegraded()
+	span.SetAttributes(attribute.Bool("rate.cached", quote.Cached), attribute.Bool("rate.stale", quote.Stale))
+	tracing.End(span, err)
+	return quote, err
+}
+
+// lookupRate is getExchangeRateForPair without the span
+// Concurrent misses for the same pair and date wait on a single fetch
+func (service *CurrencyExchangeService) lookupRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (models.RateQuote, error) {
+	// fresh cache hits don't need deduplicating. A stale entry is handed to the
+	// fetch as the fallback for an upstream failure
+	var cached models.RateQuote
+	var found bool
+	if dateStr == "" {
+		cached, found = service.cachedRate(ctx, fromCurrency, toCurrency)
+		if found && !cached.Stale {
+			return cached, nil
+		}
+		if inverse, ok := service.inverseRate(ctx, fromCurrency, toCurrency); ok {
+			return inverse, nil
+		}
+		// degraded: whatever the cache has, however old, rather than a call
+		// that can't succeed
+		if service.isDegraded() {
+			if found {
+				return service.staleFallback(ctx, fromCurrency, toCurrency, cached, errors.New("degraded mode")), nil
+			}
+			// a pair only ever routed can still be, from its cached legs
+			if service.unquoted.has(strings.ToUpper(strings.TrimSpace(fromCurrency)) + "-" + strings.ToUpper(strings.TrimSpace(toCurrency))) {
+				if routed, ok := service.routedQuote(ctx, fromCurrency, toCurrency, ""); ok {
+					return routed, nil
+				}
+			}
+			metrics.RecordDegradedRejection("latest")
+			return models.RateQuote{}, apperrors.New(apperrors.CodeDegraded,
+				"service is in degraded mode: no cached %s-%s rate", strings.ToUpper(fromCurrency), strings.ToUpper(toCurrency))
+		}
+		if availability, ok := service.cache.(PairAvailability); ok {
+			if retryAt, unavailable := availability.PairUnavailable(fromCurrency, toCurrency); unavailable {
+				if found {
+					return service.staleFallback(ctx, fromCurrency, toCurrency, cached, errors.New("pair backed off")), nil
+				}
+				return models.RateQuote{}, apperrors.New(apperrors.CodePairUnavailable,
+					"rate for %s-%s is unavailable, the provider keeps failing it - next attempt after %s",
+					strings.ToUpper(fromCurrency), strings.ToUpper(toCurrency), retryAt.UTC().Format(time.RFC3339))
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return models.RateQuote{}, err
+	}
+
+	// the shared fetch must not die with whichever caller started it - it gets
+	// its own deadline, and each caller stops waiting when its own ctx ends
+	leader := false
+	fetchStart := time.Now()
+	key := strings.ToUpper(strings.TrimSpace(fromCurrency)) + "-" + strings.ToUpper(strings.TrimSpace(toCurrency)) + "|" + dateStr
+	if ctx.Value(routeLegKey{}) != nil {
+		// a leg never routes, so it mustn't hand its answer to a caller that would
+		key += "|leg"
+	}
+	resultChannel := service.flights.DoChan(key, func() (interface{}, error) {
+		leader = true
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), config.DefaultAPITimeout)
+		defer cancel()
+		return service.fetchExchangeRateForPair(fetchCtx, fromCurrency, toCurrency, dateStr, cached, found)
+	})
+
+	select {
+	case result := <-resultChannel:
+		if recorder, ok := service.cache.(interface {
+			RecordFetch(shared bool, latency time.Duration)
+		}); ok {
+			recorder.RecordFetch(!leader, time.Since(fetchStart))
+		}
+		if result.Err != nil {
+			return models.RateQuote{}, result.Err
+		}
+		return result.Val.(models.RateQuote), nil
+	case <-ctx.Done():
+		return models.RateQuote{}, ctx.Err()
+	}
+}
+
+// fetchExchangeRateForPair does the actual lookup behind getExchangeRateForPair
+// When the upstream is down we degrade to the expired cache entry the caller
+// found (flagged stale) rather than failing the request outright
+func (service *CurrencyExchangeService) fetchExchangeRateForPair(ctx context.Context, fromCurrency, toCurrency, dateStr string, cached models.RateQuote, found bool) (models.RateQuote, error) {
+	// historical dates skip the latest-rate cache - they have their own
+	if dateStr != "" {
+		today := service.calendar.Today(fromCurrency, toCurrency)
+		parsedDate, err := service.validateAndParseDate(dateStr, today)
+		if err != nil {
+			return models.RateQuote{}, err
+		}
+
+		if err := service.validateHistoricalRange(parsedDate, today); err != nil {
+			return models.RateQuote{}, err
+		}
+
+		return service.historicalQuote(ctx, fromCurrency, toCurrency, dateStr)
+	}
+
+	// cache miss (or expired) - fetch from api
+	start := time.Now()
+	quote, err := service.quoteUpstream(ctx, fromCurrency, toCurrency, "")
+	if err != nil {
+		if found {
+			return service.staleFallback(ctx, fromCurrency, toCurrency, cached, err), nil
+		}
+		return models.RateQuote{}, upstreamError(err, "failed to fetch rate")
+	}
+	if quote.Route != nil {
+		// its legs are cached, the route itself is worked out again each time
+		return quote, nil
+	}
+	slog.DebugContext(ctx, "Fetched rate from upstream",
+		"pair", fromCurrency+"-"+toCurrency, "source", quote.Source, "latency_ms", time.Since(start).Milliseconds())
 
 	// cache the result
-	service.cache.SetRate(fromCurrency, toCurrency, rate)
+	service.cache.SetRate(fromCurrency, toCurrency, quote.Rate, quote.Source)
 
-	return rate, nil
+	quote.LastUpdated = time.Now()
+	return quote, nil
+}
+
+// staleFallback hands out the expired cached quote because a fresh one can't be had
+func (service *CurrencyExchangeService) staleFallback(ctx context.Context, fromCurrency, toCurrency string, cached models.RateQuote, err error) models.RateQuote {
+	slog.WarnContext(ctx, "Upstream unavailable, serving stale rate",
+		"pair", fromCurrency+"-"+toCurrency, "last_updated", cached.LastUpdated.Format(time.RFC3339), "error", err)
+	if recorder, ok := service.cache.(interface{ RecordStaleFallback() }); ok {
+		recorder.RecordStaleFallback()
+	}
+	return cached
+}
+
+// cachedRate is cache.GetRateEntry in its own span - shows how much of a
+// request went to the cache (backend round trip, lock contention)
+func (service *CurrencyExchangeService) cachedRate(ctx context.Context, fromCurrency, toCurrency string) (models.RateQuote, bool) {
+	_, span := tracing.Start(ctx, "cache.GetRateEntry", tracing.Pair(fromCurrency, toCurrency, "")...)
+	defer span.End()
+
+	quote, found := service.cache.GetRateEntry(fromCurrency, toCurrency)
+	span.SetAttributes(attribute.Bool("cache.hit", found && !quote.Stale), attribute.Bool("cache.stale", quote.Stale))
+	return quote, found
+}
+
+// inverseRate answers FROM-TO as 1/rate of a fresh cached TO-FROM, saving the
+// upstream call for a pair the cache effectively already has
+func (service *CurrencyExchangeService) inverseRate(ctx context.Context, fromCurrency, toCurrency string) (models.RateQuote, bool) {
+	if !config.DeriveInverseRates {
+		return models.RateQuote{}, false
+	}
+	opposite, found := service.cachedRate(ctx, toCurrency, fromCurrency)
+	if !found || opposite.Stale || opposite.Rate <= 0 {
+		return models.RateQuote{}, false
+	}
+
+	if recorder, ok := service.cache.(interface{ RecordDerived() }); ok {
+		recorder.RecordDerived()
+	}
+	return models.RateQuote{
+		Rate:        1 / opposite.Rate,
+		LastUpdated: opposite.LastUpdated,
+		Cached:      true,
+		Source:      opposite.Source,
+		Derived:     true,
+	}, true
+}
+
+// fetchRate asks the upstream for a rate, with the answering provider when the client can tell
+func (service *CurrencyExchangeService) fetchRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, string, error) {
+	if sourced, ok := service.apiClient.(SourcedRateClient); ok {
+		return sourced.GetRateWithSource(ctx, fromCurrency, toCurrency, dateStr)
+	}
+	rate, err := service.apiClient.GetRate(ctx, fromCurrency, toCurrency, dateStr)
+	return rate, "", err
 }
 
 // validateCurrencies checks if both currencies are supported
 func (service *CurrencyExchangeService) validateCurrencyPair(fromCurrency, toCurrency string) error {
-	if !config.IsSupportedCurrency(fromCurrency) {
-		return fmt.Errorf("unsupported source currency: %s", fromCurrency)
+	if !service.currencies.IsSupported(fromCurrency) {
+		return apperrors.New(apperrors.CodeUnsupportedCurrency, "unsupported source currency: %s", fromCurrency)
+	}
+
+	if !service.currencies.IsSupported(toCurrency) {
+		return apperrors.New(apperrors.CodeUnsupportedCurrency, "unsupported target currency: %s", toCurrency)
 	}
 
-	if !config.IsSupportedCurrency(toCurrency) {
-		return fmt.Errorf("unsupported target currency: %s", toCurrency)
+	// deprecated currencies keep working until their sunset date
+	for _, code := range []string{fromCurrency, toCurrency} {
+		if config.IsCurrencySunset(code) {
+			sunset, _ := config.GetCurrencySunset(code)
+			return apperrors.New(apperrors.CodeCurrencySunset, "currency %s was retired on %s", strings.ToUpper(code), sunset.Format("2006-01-02"))
+		}
 	}
 
 	return nil
 }
 
+// enforcePolicy applies the caller's tenant policy: the pair has to be one it
+// may use and date (when not "") within its history limit. Callers without a
+// policy pass
+func (service *CurrencyExchangeService) enforcePolicy(ctx context.Context, fromCurrency, toCurrency, dateStr string) error {
+	policy, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	if !policy.AllowsPair(fromCurrency, toCurrency) {
+		return apperrors.New(apperrors.CodeForbidden, "pair %s-%s is not enabled for this API key", strings.ToUpper(fromCurrency), strings.ToUpper(toCurrency))
+	}
+
+	// a malformed date is reported by the date validation instead
+	if date, err := time.Parse("2006-01-02", dateStr); err == nil && policy.MaxHistoricalDays > 0 &&
+		date.Before(service.calendar.Today(fromCurrency, toCurrency).AddDate(0, 0, 1-policy.MaxHistoricalDays)) {
+		return apperrors.New(apperrors.CodeDateOutOfRange, "date is too far in the past, maximum %d days allowed for this API key", policy.MaxHistoricalDays)
+	}
+	return nil
+}
+
+// feesFor returns the markup charged to the caller - its tenant's own, or the
+// service-wide schedule (nil = none)
+func (service *CurrencyExchangeService) feesFor(ctx context.Context) FeeSchedule {
+	if policy, ok := tenant.FromContext(ctx); ok {
+		if schedule, ok := policy.Fees(); ok {
+			return schedule
+		}
+	}
+	return service.fees
+}
+
+// GetDeprecationNotices returns a notice for every deprecated currency among codes
+func (service *CurrencyExchangeService) GetDeprecationNotices(codes ...string) []models.DeprecationNotice {
+	notices := make([]models.DeprecationNotice, 0)
+	seen := make(map[string]bool)
+
+	for _, code := range codes {
+		cleanCode := strings.ToUpper(strings.TrimSpace(code))
+		if seen[cleanCode] {
+			continue
+		}
+		seen[cleanCode] = true
+
+		if sunset, deprecated := config.GetCurrencySunset(cleanCode); deprecated {
+			notices = append(notices, models.DeprecationNotice{Code: cleanCode, SunsetDate: sunset})
+		}
+	}
+
+	return notices
+}
+
+// ListCurrencies returns all supported currencies with names, symbols and deprecation status
+func (service *CurrencyExchangeService) ListCurrencies() []models.CurrencyInfo {
+	supported := service.currencies.List()
+	currencies := make([]models.CurrencyInfo, 0, len(supported))
+
+	for _, entry := range supported {
+		info := models.CurrencyInfo{Code: entry.Code, Name: entry.Name, Symbol: entry.Symbol, AssetClass: string(entry.Class)}
+		if sunset, deprecated := config.GetCurrencySunset(entry.Code); deprecated {
+			info.Deprecated = true
+			info.SunsetDate = sunset.Format("2006-01-02")
+			info.Retired = config.IsCurrencySunset(entry.Code)
+		}
+		currencies = append(currencies, info)
+	}
+
+	return currencies
+}
+
 // validateAndParseDate validates date format and parses it
-func (service *CurrencyExchangeService) validateAndParseDate(dateStr string) (time.Time, error) {
+// today is the pair's current date from the market calendar
+func (service *CurrencyExchangeService) validateAndParseDate(dateStr string, today time.Time) (time.Time, error) {
 	if dateStr == "" {
-		return time.Time{}, fmt.Errorf("date cannot be empty")
+		return time.Time{}, apperrors.New(apperrors.CodeInvalidDate, "date cannot be empty")
 	}
 
 	// Parse the date string using the standard ISO format (YYYY-MM-DD)
 	parsedDate, err := time.Parse("2006-01-02", dateStr)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("invalid date format, expected YYYY-MM-DD: %s", dateStr)
+		return time.Time{}, apperrors.New(apperrors.CodeInvalidDate, "invalid date format, expected YYYY-MM-DD: %s", dateStr)
 	}
 
 	// Don't allow future dates - that doesn't make business sense
-	if parsedDate.After(time.Now()) {
-		return time.Time{}, fmt.Errorf("date cannot be in the future: %s", dateStr)
+	if parsedDate.After(today) {
+		return time.Time{}, apperrors.New(apperrors.CodeInvalidDate, "date cannot be in the future: %s", dateStr)
 	}
 
 	return parsedDate, nil
 }
 
 // validateHistoricalRange checks if the date is within allowed historical range
-func (service *CurrencyExchangeService) validateHistoricalRange(requestedDate time.Time) error {
+// The window is MaxHistoricalDays whole dates, today (the pair's) included
+func (service *CurrencyExchangeService) validateHistoricalRange(requestedDate, today time.Time) error {
 	// Calculate the oldest date we allow based on our business rules
-	oldestAllowedDate := time.Now().AddDate(0, 0, -config.MaxHistoricalDays)
+	oldestAllowedDate := today.AddDate(0, 0, 1-config.MaxHistoricalDays)
 
 	if requestedDate.Before(oldestAllowedDate) {
-		return fmt.Errorf("date is too far in the past, maximum %d days allowed", config.MaxHistoricalDays)
+		return apperrors.New(apperrors.CodeDateOutOfRange, "date is too far in the past, maximum %d days allowed", config.MaxHistoricalDays)
 	}
 
 	return nil
 }
+
+// upstreamError tags provider failures as upstream_unavailable, keeping any
+// code the client already set (e.g. read-only replica)
+func upstreamError(err error, msg string) error {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	return apperrors.Wrap(apperrors.CodeUpstreamUnavailable, err, msg)
+}
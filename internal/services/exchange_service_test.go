@@ -0,0 +1,978 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/calendar"
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/fees"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/tenant"
+
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMain(m *testing.M) {
+	// config.Load isn't called in tests, so set the globals the service reads
+	config.MaxHistoricalDays = config.MaxAllowedHistoryDays
+	config.TimeSeriesWorkers = 2
+	os.Exit(m.Run())
+}
+
+// fakeCache is a minimal in-memory ExchangeRateCache
+type fakeCache struct {
+	mu    sync.Mutex
+	rates map[string]models.RateQuote
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{rates: make(map[string]models.RateQuote)}
+}
+
+func (c *fakeCache) GetRateEntry(fromCurrency, toCurrency string) (models.RateQuote, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	quote, found := c.rates[fromCurrency+"-"+toCurrency]
+	return quote, found
+}
+
+func (c *fakeCache) SetRate(fromCurrency, toCurrency string, rate float64, source string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rates[fromCurrency+"-"+toCurrency] = models.RateQuote{Rate: rate, LastUpdated: time.Now(), Cached: true, Source: source}
+}
+
+// fakeAPIClient answers per-day requests from a fixed table
+type fakeAPIClient struct {
+	mu    sync.Mutex
+	daily map[string]float64
+	calls int
+}
+
+func (c *fakeAPIClient) GetRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+
+	if rate, found := c.daily[dateStr]; found {
+		return rate, nil
+	}
+	return 0, errors.New("api request failed: no data")
+}
+
+// fakeRangeClient also supports range queries
+type fakeRangeClient struct {
+	fakeAPIClient
+	rangeCalls int
+}
+
+func (c *fakeRangeClient) GetRateRange(ctx context.Context, fromCurrency, toCurrency, startDate, endDate string) (map[string]float64, error) {
+	c.rangeCalls++
+	return map[string]float64{startDate: 0.9}, nil
+}
+
+// testCurrencies is the default core list
+var testCurrencies = currency.NewRegistry(config.CoreCurrencies)
+
+func daysAgo(n int) string {
+	return time.Now().AddDate(0, 0, -n).Format("2006-01-02")
+}
+
+func TestGetHistoricalRateRange_DailyFallback(t *testing.T) {
+	api := &fakeAPIClient{daily: map[string]float64{
+		daysAgo(5): 0.91,
+		daysAgo(3): 0.93,
+	}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, nil)
+
+	series, err := service.GetHistoricalRateRange(context.Background(), "USD", "EUR", daysAgo(5), daysAgo(1))
+	if err != nil {
+		t.Fatalf("GetHistoricalRateRange failed: %v", err)
+	}
+
+	// 5 days requested, only 2 have data - missing days are skipped, not errors
+	if api.calls != 5 {
+		t.Errorf("expected one fetch per day (5), got %d", api.calls)
+	}
+	if len(series) != 2 || series[daysAgo(3)] != 0.93 {
+		t.Errorf("unexpected series: %v", series)
+	}
+}
+
+func TestGetHistoricalRateRange_PrefersRangeClient(t *testing.T) {
+	api := &fakeRangeClient{}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, nil)
+
+	if _, err := service.GetHistoricalRateRange(context.Background(), "USD", "EUR", daysAgo(10), daysAgo(1)); err != nil {
+		t.Fatalf("GetHistoricalRateRange failed: %v", err)
+	}
+	if api.rangeCalls != 1 || api.calls != 0 {
+		t.Errorf("expected a single range call and no daily calls, got %d range / %d daily", api.rangeCalls, api.calls)
+	}
+}
+
+func TestGetHistoricalRateRange_Validation(t *testing.T) {
+	service := NewCurrencyExchangeService(newFakeCache(), &fakeAPIClient{}, testCurrencies, nil)
+
+	if _, err := service.GetHistoricalRateRange(context.Background(), "USD", "EUR", daysAgo(1), daysAgo(5)); !errors.Is(err, apperrors.ErrDateOutOfRange) {
+		t.Errorf("expected date_out_of_range when end is before start, got %v", err)
+	}
+	if _, err := service.GetHistoricalRateRange(context.Background(), "USD", "EUR", "2000-01-01", daysAgo(1)); !errors.Is(err, apperrors.ErrDateOutOfRange) {
+		t.Errorf("expected date_out_of_range outside the historical window, got %v", err)
+	}
+	if _, err := service.GetHistoricalRateRange(context.Background(), "USD", "EUR", "01-08-2025", daysAgo(1)); !errors.Is(err, apperrors.ErrInvalidDate) {
+		t.Errorf("expected invalid_date for a malformed date, got %v", err)
+	}
+	if _, err := service.GetHistoricalRateRange(context.Background(), "USD", "XXX", daysAgo(5), daysAgo(1)); !errors.Is(err, apperrors.ErrUnsupportedCurrency) {
+		t.Errorf("expected unsupported_currency, got %v", err)
+	}
+}
+
+func TestConvertCurrencyAmount_ServesStaleWhenUpstreamDown(t *testing.T) {
+	cache := newFakeCache()
+	lastUpdated := time.Now().Add(-5 * time.Hour)
+	cache.rates["USD-EUR"] = models.RateQuote{Rate: 0.9, LastUpdated: lastUpdated, Stale: true}
+
+	service := NewCurrencyExchangeService(cache, &fakeAPIClient{}, testCurrencies, nil)
+
+	result, err := service.ConvertCurrencyAmount(context.Background(), "USD", "EUR", decimal.NewFromInt(100), "")
+	if err != nil {
+		t.Fatalf("expected stale fallback instead of error, got %v", err)
+	}
+	if !result.Quote.Stale || !result.Quote.LastUpdated.Equal(lastUpdated) {
+		t.Errorf("expected stale quote with original timestamp, got %+v", result.Quote)
+	}
+	if !result.Amount.Equal(decimal.NewFromInt(90)) {
+		t.Errorf("expected 90, got %s", result.Amount)
+	}
+}
+
+func TestConvertCurrencyAmount_AppliesFees(t *testing.T) {
+	cache := newFakeCache()
+	cache.SetRate("USD", "EUR", 0.9, "test")
+
+	service := NewCurrencyExchangeService(cache, &fakeAPIClient{}, testCurrencies, nil)
+	schedule, err := fees.NewSchedule("", map[string]string{"USD-EUR": "2%+1"})
+	if err != nil {
+		t.Fatalf("NewSchedule failed: %v", err)
+	}
+	service.SetFees(schedule)
+
+	result, err := service.ConvertCurrencyAmount(context.Background(), "USD", "EUR", decimal.NewFromInt(101), "")
+	if err != nil {
+		t.Fatalf("ConvertCurrencyAmount failed: %v", err)
+	}
+	// 1 USD fixed, 100 USD at 0.882
+	if !result.Amount.Equal(decimal.RequireFromString("88.2")) || result.AppliedRate != 0.882 || result.Quote.Rate != 0.9 {
+		t.Errorf("expected 88.2 at 0.882 (mid 0.9), got %s at %v (mid %v)", result.Amount, result.AppliedRate, result.Quote.Rate)
+	}
+	if !result.Fee.Equal(decimal.RequireFromString("2.7")) {
+		t.Errorf("expected fee 2.7 (90.9 - 88.2), got %s", result.Fee)
+	}
+
+	// pairs without a rule convert at mid-market
+	cache.SetRate("USD", "GBP", 0.8, "test")
+	result, _ = service.ConvertCurrencyAmount(context.Background(), "USD", "GBP", decimal.NewFromInt(100), "")
+	if !result.Amount.Equal(decimal.NewFromInt(80)) || !result.Fee.IsZero() {
+		t.Errorf("expected 80 with no fee, got %s (fee %s)", result.Amount, result.Fee)
+	}
+}
+
+func TestConvertAmounts_OneRateLookup(t *testing.T) {
+	apiClient := &fakeAPIClient{daily: map[string]float64{"": 150}}
+	service := NewCurrencyExchangeService(newFakeCache(), apiClient, testCurrencies, nil)
+
+	amounts := []decimal.Decimal{decimal.NewFromInt(1), decimal.RequireFromString("5.55"), decimal.NewFromInt(10)}
+	results, err := service.ConvertAmounts(context.Background(), "USD", "JPY", amounts, "")
+	if err != nil {
+		t.Fatalf("ConvertAmounts failed: %v", err)
+	}
+	if apiClient.calls != 1 {
+		t.Errorf("expected one upstream call for the whole table, got %d", apiClient.calls)
+	}
+
+	// rounded to JPY's 0 minor units, row by row
+	want := []string{"150", "833", "1500"}
+	for i, result := range results {
+		if !result.Amount.Equal(decimal.RequireFromString(want[i])) || result.Quote.Rate != 150 {
+			t.Errorf("row %d: expected %s at 150, got %s at %v", i, want[i], result.Amount, result.Quote.Rate)
+		}
+	}
+
+	amounts = append(amounts, decimal.NewFromInt(-1))
+	if _, err := service.ConvertAmounts(context.Background(), "USD", "JPY", amounts, ""); !errors.Is(err, apperrors.ErrInvalidAmount) {
+		t.Errorf("expected a negative amount to fail the table, got %v", err)
+	}
+}
+
+func TestTenantPolicy(t *testing.T) {
+	cache := newFakeCache()
+	cache.SetRate("USD", "EUR", 0.9, "test")
+	cache.SetRate("USD", "GBP", 0.8, "test")
+
+	service := NewCurrencyExchangeService(cache, &fakeAPIClient{daily: map[string]float64{daysAgo(40): 0.91}}, testCurrencies, nil)
+	schedule, _ := fees.NewSchedule("1%", nil)
+	service.SetFees(schedule)
+
+	policy, err := tenant.NewPolicy("partner", tenant.Config{AllowedPairs: []string{"USD-EUR"}, Markup: "2%", MaxHistoricalDays: 30})
+	if err != nil {
+		t.Fatalf("NewPolicy failed: %v", err)
+	}
+	ctx := tenant.WithPolicy(context.Background(), policy)
+
+	// the tenant's markup replaces the service-wide one
+	result, err := service.ConvertCurrencyAmount(ctx, "USD", "EUR", decimal.NewFromInt(100), "")
+	if err != nil || !result.Amount.Equal(decimal.RequireFromString("88.2")) {
+		t.Errorf("expected 88.2 after a 2%% markup, got %s (%v)", result.Amount, err)
+	}
+	result, _ = service.ConvertCurrencyAmount(context.Background(), "USD", "EUR", decimal.NewFromInt(100), "")
+	if !result.Amount.Equal(decimal.RequireFromString("89.1")) {
+		t.Errorf("expected callers without a policy to pay the default 1%%, got %s", result.Amount)
+	}
+
+	if _, err := service.GetLatestRate(ctx, "USD", "GBP"); apperrors.CodeOf(err) != apperrors.CodeForbidden {
+		t.Errorf("expected a pair outside the policy to be forbidden, got %v", err)
+	}
+	if _, err := service.GetHistoricalExchangeRate(ctx, "USD", "EUR", daysAgo(40)); !errors.Is(err, apperrors.ErrDateOutOfRange) {
+		t.Errorf("expected the tenant's history limit to apply, got %v", err)
+	}
+	if _, err := service.GetHistoricalExchangeRate(context.Background(), "USD", "EUR", daysAgo(40)); err != nil {
+		t.Errorf("expected the service-wide limit without a policy, got %v", err)
+	}
+}
+
+func TestConvertToMany_ReportsPerTargetErrors(t *testing.T) {
+	cache := newFakeCache()
+	cache.SetRate("USD", "EUR", 0.9, "test")
+	cache.SetRate("USD", "JPY", 150, "test")
+
+	service := NewCurrencyExchangeService(cache, &fakeAPIClient{}, testCurrencies, nil)
+
+	results, err := service.ConvertToMany(context.Background(), "USD", []string{"JPY", "XXX", "EUR", "GBP"}, decimal.NewFromInt(10), "")
+	if err != nil {
+		t.Fatalf("ConvertToMany failed: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected a result per target, got %d", len(results))
+	}
+
+	if results[0].To != "JPY" || results[0].Err != nil || !results[0].Result.Amount.Equal(decimal.NewFromInt(1500)) {
+		t.Errorf("unexpected JPY result: %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, apperrors.ErrUnsupportedCurrency) {
+		t.Errorf("expected unsupported_currency for XXX, got %v", results[1].Err)
+	}
+	if results[2].To != "EUR" || !results[2].Result.Amount.Equal(decimal.NewFromInt(9)) {
+		t.Errorf("unexpected EUR result: %+v", results[2])
+	}
+	// nothing cached and the upstream has nothing either
+	if !errors.Is(results[3].Err, apperrors.ErrUpstreamUnavailable) {
+		t.Errorf("expected upstream_unavailable for GBP, got %v", results[3].Err)
+	}
+
+	// a bad source fails the whole call
+	if _, err := service.ConvertToMany(context.Background(), "XXX", []string{"EUR"}, decimal.NewFromInt(10), ""); !errors.Is(err, apperrors.ErrUnsupportedCurrency) {
+		t.Errorf("expected unsupported_currency for the source, got %v", err)
+	}
+}
+
+func TestConvertCurrencyAmount_RefreshesExpiredEntry(t *testing.T) {
+	cache := newFakeCache()
+	cache.rates["USD-EUR"] = models.RateQuote{Rate: 0.9, LastUpdated: time.Now().Add(-5 * time.Hour), Stale: true}
+
+	api := &fakeAPIClient{daily: map[string]float64{"": 0.95}}
+	service := NewCurrencyExchangeService(cache, api, testCurrencies, nil)
+
+	result, err := service.ConvertCurrencyAmount(context.Background(), "USD", "EUR", decimal.NewFromInt(1), "")
+	if err != nil {
+		t.Fatalf("ConvertCurrencyAmount failed: %v", err)
+	}
+	if result.Quote.Stale || result.Quote.Rate != 0.95 {
+		t.Errorf("expected fresh upstream rate 0.95, got %+v", result)
+	}
+}
+
+// derivedCache counts the misses answered from the opposite pair
+type derivedCache struct {
+	*fakeCache
+	derived int
+}
+
+func (c *derivedCache) RecordDerived() {
+	c.derived++
+}
+
+func TestConvertCurrencyAmount_DerivesInverseRate(t *testing.T) {
+	config.DeriveInverseRates = true
+	defer func() { config.DeriveInverseRates = false }()
+
+	cache := &derivedCache{fakeCache: newFakeCache()}
+	cache.SetRate("USD", "EUR", 0.8, "frankfurter")
+	cache.rates["USD-GBP"] = models.RateQuote{Rate: 0.75, LastUpdated: time.Now().Add(-5 * time.Hour), Stale: true}
+
+	api := &fakeAPIClient{daily: map[string]float64{"": 1.3}}
+	service := NewCurrencyExchangeService(cache, api, testCurrencies, nil)
+	ctx := context.Background()
+
+	result, err := service.ConvertCurrencyAmount(ctx, "EUR", "USD", decimal.NewFromInt(100), "")
+	if err != nil {
+		t.Fatalf("ConvertCurrencyAmount failed: %v", err)
+	}
+	if !result.Quote.Derived || !result.Quote.Cached || result.Quote.Rate != 1.25 || result.Quote.Source != "frankfurter" {
+		t.Errorf("expected 1/0.8 derived from the cached USD-EUR, got %+v", result.Quote)
+	}
+	if !result.Amount.Equal(decimal.NewFromInt(125)) {
+		t.Errorf("expected 125, got %s", result.Amount)
+	}
+	if api.calls != 0 || cache.derived != 1 {
+		t.Errorf("expected no upstream call and one derived lookup, got %d calls and %d derived", api.calls, cache.derived)
+	}
+
+	// a stale opposite isn't worth inverting - the upstream is asked
+	result, err = service.ConvertCurrencyAmount(ctx, "GBP", "USD", decimal.NewFromInt(1), "")
+	if err != nil {
+		t.Fatalf("ConvertCurrencyAmount failed: %v", err)
+	}
+	if result.Quote.Derived || result.Quote.Rate != 1.3 || api.calls != 1 {
+		t.Errorf("expected the upstream rate for GBP-USD, got %+v after %d calls", result.Quote, api.calls)
+	}
+
+	// and turned off, every miss goes upstream
+	config.DeriveInverseRates = false
+	result, err = service.ConvertCurrencyAmount(ctx, "EUR", "USD", decimal.NewFromInt(1), "")
+	if err != nil {
+		t.Fatalf("ConvertCurrencyAmount failed: %v", err)
+	}
+	if result.Quote.Derived || api.calls != 2 {
+		t.Errorf("expected an upstream fetch with derivation off, got %+v after %d calls", result.Quote, api.calls)
+	}
+}
+
+// sourcedAPIClient names the provider, like the provider chain does
+type sourcedAPIClient struct {
+	fakeAPIClient
+	source string
+}
+
+func (c *sourcedAPIClient) GetRateWithSource(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, string, error) {
+	rate, err := c.GetRate(ctx, fromCurrency, toCurrency, dateStr)
+	return rate, c.source, err
+}
+
+func TestConvertCurrencyAmount_ReportsSourceAndCacheHits(t *testing.T) {
+	api := &sourcedAPIClient{fakeAPIClient: fakeAPIClient{daily: map[string]float64{"": 0.95}}, source: "frankfurter"}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, nil)
+	ctx := context.Background()
+
+	first, err := service.ConvertCurrencyAmount(ctx, "USD", "EUR", decimal.NewFromInt(1), "")
+	if err != nil {
+		t.Fatalf("ConvertCurrencyAmount failed: %v", err)
+	}
+	if first.Quote.Cached || first.Quote.Source != "frankfurter" || first.Quote.LastUpdated.IsZero() {
+		t.Errorf("expected a fresh upstream quote from frankfurter, got %+v", first.Quote)
+	}
+
+	second, err := service.ConvertCurrencyAmount(ctx, "USD", "EUR", decimal.NewFromInt(1), "")
+	if err != nil {
+		t.Fatalf("ConvertCurrencyAmount failed: %v", err)
+	}
+	if !second.Quote.Cached || second.Quote.Source != "frankfurter" {
+		t.Errorf("expected a cached quote remembering its source, got %+v", second.Quote)
+	}
+	if api.calls != 1 {
+		t.Errorf("expected the second conversion to hit the cache, got %d upstream calls", api.calls)
+	}
+}
+
+func TestConvertCurrencyAmount_NoCacheAndUpstreamDown(t *testing.T) {
+	service := NewCurrencyExchangeService(newFakeCache(), &fakeAPIClient{}, testCurrencies, nil)
+
+	_, err := service.ConvertCurrencyAmount(context.Background(), "USD", "EUR", decimal.NewFromInt(1), "")
+	if !errors.Is(err, apperrors.ErrUpstreamUnavailable) {
+		t.Errorf("expected upstream_unavailable when nothing is cached and the upstream fails, got %v", err)
+	}
+}
+
+// backedOffCache is a fakeCache with every pair backed off
+type backedOffCache struct {
+	*fakeCache
+}
+
+func (c backedOffCache) PairUnavailable(fromCurrency, toCurrency string) (time.Time, bool) {
+	return time.Now().Add(time.Hour), true
+}
+
+func TestConvertCurrencyAmount_BackedOffPair(t *testing.T) {
+	api := &fakeAPIClient{daily: map[string]float64{"": 0.95}}
+	cache := backedOffCache{newFakeCache()}
+	service := NewCurrencyExchangeService(cache, api, testCurrencies, nil)
+
+	_, err := service.ConvertCurrencyAmount(context.Background(), "USD", "EUR", decimal.NewFromInt(1), "")
+	if apperrors.CodeOf(err) != apperrors.CodePairUnavailable {
+		t.Errorf("expected pair_unavailable for a backed off pair, got %v", err)
+	}
+
+	// an expired entry beats the error
+	cache.rates["USD-EUR"] = models.RateQuote{Rate: 0.9, LastUpdated: time.Now().Add(-48 * time.Hour), Stale: true, Cached: true}
+	result, err := service.ConvertCurrencyAmount(context.Background(), "USD", "EUR", decimal.NewFromInt(1), "")
+	if err != nil || !result.Quote.Stale {
+		t.Errorf("expected the stale rate, got %+v, %v", result.Quote, err)
+	}
+	if api.calls != 0 {
+		t.Errorf("expected no upstream calls for a backed off pair, got %d", api.calls)
+	}
+}
+
+func TestConvertCurrencyAmount_DecimalRounding(t *testing.T) {
+	cache := newFakeCache()
+	cache.SetRate("INR", "USD", 0.012, "")
+	cache.SetRate("USD", "JPY", 151.237, "")
+	service := NewCurrencyExchangeService(cache, &fakeAPIClient{}, testCurrencies, nil)
+
+	// float64 would give 84.99999999999999 style results here
+	result, err := service.ConvertCurrencyAmount(context.Background(), "INR", "USD", decimal.RequireFromString("7083.33"), "")
+	if err != nil {
+		t.Fatalf("ConvertCurrencyAmount failed: %v", err)
+	}
+	if result.Amount.String() != "85" {
+		t.Errorf("expected 85 (rounded to cents), got %s", result.Amount)
+	}
+
+	// JPY has no minor units
+	result, err = service.ConvertCurrencyAmount(context.Background(), "USD", "JPY", decimal.RequireFromString("10.50"), "")
+	if err != nil {
+		t.Fatalf("ConvertCurrencyAmount failed: %v", err)
+	}
+	if result.Amount.String() != "1588" {
+		t.Errorf("expected 1588 JPY, got %s", result.Amount)
+	}
+}
+
+func TestGetHistoricalRateRange_CanceledContext(t *testing.T) {
+	api := &fakeAPIClient{daily: map[string]float64{daysAgo(1): 0.9}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := service.GetHistoricalRateRange(ctx, "USD", "EUR", daysAgo(30), daysAgo(1)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if api.calls >= 30 {
+		t.Errorf("canceled request should not fetch every day, got %d calls", api.calls)
+	}
+}
+
+// blockingAPIClient holds every call until release is closed
+type blockingAPIClient struct {
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (c *blockingAPIClient) GetRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, error) {
+	c.calls.Add(1)
+	select {
+	case <-c.release:
+		return 0.92, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// recordingCache also counts deduplicated fetches
+type recordingCache struct {
+	*fakeCache
+	upstream, shared atomic.Int32
+}
+
+func (c *recordingCache) RecordFetch(shared bool, latency time.Duration) {
+	if shared {
+		c.shared.Add(1)
+	} else {
+		c.upstream.Add(1)
+	}
+}
+
+func TestConvertCurrencyAmount_ConcurrentMissesShareOneFetch(t *testing.T) {
+	api := &blockingAPIClient{release: make(chan struct{})}
+	rateCache := &recordingCache{fakeCache: newFakeCache()}
+	service := NewCurrencyExchangeService(rateCache, api, testCurrencies, nil)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.ConvertCurrencyAmount(context.Background(), "USD", "EUR", decimal.NewFromInt(1), "")
+			errs <- err
+		}()
+	}
+
+	// let every caller join the flight before the upstream answers
+	time.Sleep(50 * time.Millisecond)
+	close(api.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := api.calls.Load(); got != 1 {
+		t.Errorf("expected one upstream call for %d concurrent misses, got %d", callers, got)
+	}
+	if rateCache.upstream.Load() != 1 || rateCache.shared.Load() != callers-1 {
+		t.Errorf("expected 1 upstream / %d shared fetches recorded, got %d / %d",
+			callers-1, rateCache.upstream.Load(), rateCache.shared.Load())
+	}
+}
+
+func TestConvertCurrencyAmount_CanceledCallerDoesNotFailSharedFetch(t *testing.T) {
+	api := &blockingAPIClient{release: make(chan struct{})}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, nil)
+
+	// the first caller starts the fetch, then gives up
+	ctx, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error, 1)
+	go func() {
+		_, err := service.ConvertCurrencyAmount(ctx, "USD", "EUR", decimal.NewFromInt(1), "")
+		firstDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	secondDone := make(chan error, 1)
+	go func() {
+		_, err := service.ConvertCurrencyAmount(context.Background(), "USD", "EUR", decimal.NewFromInt(1), "")
+		secondDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-firstDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the canceled caller to get context.Canceled, got %v", err)
+	}
+
+	close(api.release)
+	if err := <-secondDone; err != nil {
+		t.Errorf("the other caller should still get the rate, got %v", err)
+	}
+	if got := api.calls.Load(); got != 1 {
+		t.Errorf("expected a single upstream call, got %d", got)
+	}
+}
+
+// fakeHistory is an in-memory RateHistory keyed by date
+type fakeHistory struct {
+	rates map[string]float64
+}
+
+func (h *fakeHistory) GetRate(ctx context.Context, fromCurrency, toCurrency, date string) (float64, bool, error) {
+	rate, found := h.rates[date]
+	return rate, found, nil
+}
+
+func (h *fakeHistory) Series(ctx context.Context, fromCurrency, toCurrency, startDate, endDate string) (map[string]float64, error) {
+	series := make(map[string]float64)
+	for day, rate := range h.rates {
+		if day >= startDate && day <= endDate {
+			series[day] = rate
+		}
+	}
+	return series, nil
+}
+
+func TestGetHistoricalExchangeRate_PrefersStore(t *testing.T) {
+	api := &fakeAPIClient{daily: map[string]float64{daysAgo(3): 0.95}}
+	history := &fakeHistory{rates: map[string]float64{daysAgo(3): 0.91}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, history)
+
+	quote, err := service.GetHistoricalExchangeRate(context.Background(), "USD", "EUR", daysAgo(3))
+	if err != nil {
+		t.Fatalf("GetHistoricalExchangeRate failed: %v", err)
+	}
+	if quote.Rate != 0.91 || api.calls != 0 {
+		t.Errorf("expected the stored rate without an upstream call, got %v (%d calls)", quote.Rate, api.calls)
+	}
+
+	// days not in the store still go upstream
+	api.daily[daysAgo(4)] = 0.94
+	if quote, _ := service.GetHistoricalExchangeRate(context.Background(), "USD", "EUR", daysAgo(4)); quote.Rate != 0.94 || api.calls != 1 {
+		t.Errorf("expected an upstream fetch for an unstored day, got %v (%d calls)", quote.Rate, api.calls)
+	}
+}
+
+// fakeHistoricalCache adds the optional historical cache to fakeCache
+type fakeHistoricalCache struct {
+	*fakeCache
+	historical map[string]models.RateQuote
+}
+
+func (c *fakeHistoricalCache) GetHistoricalRate(fromCurrency, toCurrency, date string) (models.RateQuote, bool) {
+	quote, found := c.historical[fromCurrency+"-"+toCurrency+"|"+date]
+	return quote, found
+}
+
+func (c *fakeHistoricalCache) SetHistoricalRate(fromCurrency, toCurrency, date string, quote models.RateQuote) {
+	quote.Cached = true
+	c.historical[fromCurrency+"-"+toCurrency+"|"+date] = quote
+}
+
+func TestGetHistoricalExchangeRate_CachesPastDays(t *testing.T) {
+	api := &fakeAPIClient{daily: map[string]float64{daysAgo(3): 0.95}}
+	cache := &fakeHistoricalCache{fakeCache: newFakeCache(), historical: make(map[string]models.RateQuote)}
+	service := NewCurrencyExchangeService(cache, api, testCurrencies, nil)
+
+	for i := 0; i < 3; i++ {
+		if quote, err := service.GetHistoricalExchangeRate(context.Background(), "USD", "EUR", daysAgo(3)); err != nil || quote.Rate != 0.95 {
+			t.Fatalf("call %d: expected 0.95, got %v (%v)", i, quote.Rate, err)
+		}
+	}
+	if api.calls != 1 {
+		t.Errorf("expected repeat lookups to be served from the historical cache, got %d upstream calls", api.calls)
+	}
+
+	// dated conversions share the same cache
+	result, err := service.ConvertCurrencyAmount(context.Background(), "USD", "EUR", decimal.NewFromInt(100), daysAgo(3))
+	if err != nil || !result.Quote.Cached || api.calls != 1 {
+		t.Errorf("expected a cached quote without another upstream call, got %+v (%v, %d calls)", result.Quote, err, api.calls)
+	}
+}
+
+func TestGetHistoricalExchangeRate_FallsBackToPriorBusinessDay(t *testing.T) {
+	defer func(days int) { config.HistoricalFallbackDays = days }(config.HistoricalFallbackDays)
+	config.HistoricalFallbackDays = 4
+
+	// a Sunday far enough back that the whole walk stays in the window
+	sunday := time.Now().AddDate(0, 0, -14)
+	for sunday.Weekday() != time.Sunday {
+		sunday = sunday.AddDate(0, 0, -1)
+	}
+	day := func(n int) string { return sunday.AddDate(0, 0, -n).Format("2006-01-02") }
+
+	// Friday (2 days back) is a holiday, Thursday has a rate
+	api := &fakeAPIClient{daily: map[string]float64{day(3): 0.92}}
+	cache := &fakeHistoricalCache{fakeCache: newFakeCache(), historical: make(map[string]models.RateQuote)}
+	service := NewCurrencyExchangeService(cache, api, testCurrencies, nil)
+
+	quote, err := service.GetHistoricalExchangeRate(context.Background(), "USD", "EUR", day(0))
+	if err != nil {
+		t.Fatalf("expected a fallback rate, got %v", err)
+	}
+	if quote.Rate != 0.92 || quote.Date != day(3) || quote.Closure != calendar.Weekend {
+		t.Errorf("expected Thursday's 0.92 for a weekend, got %v for %s (%q)", quote.Rate, quote.Date, quote.Closure)
+	}
+	// Friday and Thursday - the weekend is never asked for
+	if api.calls != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", api.calls)
+	}
+
+	// the weekend answer is final, so it is cached under the requested day
+	if quote, _ := service.GetHistoricalExchangeRate(context.Background(), "USD", "EUR", day(0)); quote.Date != day(3) || api.calls != 2 {
+		t.Errorf("expected the cached fallback without upstream calls, got %s (%d calls)", quote.Date, api.calls)
+	}
+
+	// outside the window it's still an error
+	config.HistoricalFallbackDays = 1
+	if _, err := service.GetHistoricalExchangeRate(context.Background(), "USD", "GBP", day(0)); !errors.Is(err, apperrors.ErrUpstreamUnavailable) {
+		t.Errorf("expected upstream_unavailable when no day in the window has a rate, got %v", err)
+	}
+}
+
+func TestGetHistoricalRateRange_FetchesOnlyUnstoredDays(t *testing.T) {
+	// a Monday-Thursday run, so no day is skipped as a weekend
+	thursday := time.Now().AddDate(0, 0, -7)
+	for thursday.Weekday() != time.Thursday {
+		thursday = thursday.AddDate(0, 0, -1)
+	}
+	day := func(n int) string { return thursday.AddDate(0, 0, -n).Format("2006-01-02") }
+
+	api := &fakeAPIClient{daily: map[string]float64{day(1): 0.93, day(0): 0.94}}
+	history := &fakeHistory{rates: map[string]float64{day(3): 0.91, day(2): 0.92}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, history)
+
+	series, err := service.GetHistoricalRateRange(context.Background(), "USD", "EUR", day(3), day(0))
+	if err != nil {
+		t.Fatalf("GetHistoricalRateRange failed: %v", err)
+	}
+	if len(series) != 4 {
+		t.Errorf("expected stored and fetched days merged, got %v", series)
+	}
+	if api.calls != 2 {
+		t.Errorf("expected only the 2 unstored days fetched, got %d calls", api.calls)
+	}
+}
+
+// loggingAPIClient answers every day and logs each fetch to events
+type loggingAPIClient struct {
+	mu     sync.Mutex
+	events *[]string
+}
+
+func (c *loggingAPIClient) GetRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.events = append(*c.events, "fetch "+dateStr)
+	return 0.9, nil
+}
+
+func TestStreamHistoricalRateRange_EmitsBeforeRangeIsFetched(t *testing.T) {
+	var events []string
+	api := &loggingAPIClient{events: &events}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, nil)
+
+	start, end := daysAgo(45), daysAgo(1)
+	var dates []string
+	err := service.StreamHistoricalRateRange(context.Background(), "USD", "EUR", start, end, func(date string, rate float64) error {
+		api.mu.Lock()
+		events = append(events, "emit "+date)
+		api.mu.Unlock()
+		dates = append(dates, date)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamHistoricalRateRange failed: %v", err)
+	}
+
+	if len(dates) != 45 || dates[0] != start || dates[44] != end || !sort.StringsAreSorted(dates) {
+		t.Fatalf("expected all 45 days in date order, got %v", dates)
+	}
+	// the first window's points go out before the last window is fetched
+	firstEmit, lastFetch := -1, -1
+	for i, event := range events {
+		if event == "emit "+start && firstEmit < 0 {
+			firstEmit = i
+		}
+		if event == "fetch "+end {
+			lastFetch = i
+		}
+	}
+	if firstEmit < 0 || lastFetch < 0 || firstEmit > lastFetch {
+		t.Errorf("expected the first point emitted before the end of the range was fetched, got %v", events)
+	}
+}
+
+func TestStreamHistoricalRateRange_Errors(t *testing.T) {
+	service := NewCurrencyExchangeService(newFakeCache(), &fakeAPIClient{}, testCurrencies, nil)
+	emit := func(date string, rate float64) error {
+		t.Errorf("expected nothing emitted, got %s", date)
+		return nil
+	}
+
+	if err := service.StreamHistoricalRateRange(context.Background(), "USD", "EUR", daysAgo(1), daysAgo(5), emit); !errors.Is(err, apperrors.ErrDateOutOfRange) {
+		t.Errorf("expected date_out_of_range for a reversed range, got %v", err)
+	}
+	// nothing at all is an outage, reported before anything is written
+	if err := service.StreamHistoricalRateRange(context.Background(), "USD", "EUR", daysAgo(5), daysAgo(1), emit); !errors.Is(err, apperrors.ErrUpstreamUnavailable) {
+		t.Errorf("expected upstream_unavailable when no day has a rate, got %v", err)
+	}
+
+	// an error from emit stops the walk
+	api := &fakeAPIClient{daily: map[string]float64{daysAgo(5): 0.91, daysAgo(4): 0.92}}
+	service = NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, nil)
+	stop := errors.New("client went away")
+	calls := 0
+	err := service.StreamHistoricalRateRange(context.Background(), "USD", "EUR", daysAgo(5), daysAgo(1), func(date string, rate float64) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("expected the emit error after one point, got %v after %d", err, calls)
+	}
+}
+
+func TestConvertCurrencyAmount_TracesCacheAndUpstream(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	api := &fakeAPIClient{daily: map[string]float64{"": 0.95}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, nil)
+	if _, err := service.ConvertCurrencyAmount(context.Background(), "USD", "EUR", decimal.NewFromInt(1), ""); err != nil {
+		t.Fatalf("ConvertCurrencyAmount failed: %v", err)
+	}
+
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		byName[span.Name()] = span
+	}
+	serviceSpan, found := byName["ExchangeService.GetRate"]
+	if !found {
+		t.Fatalf("expected a service span, got %v", byName)
+	}
+	cacheSpan, found := byName["cache.GetRateEntry"]
+	if !found {
+		t.Fatalf("expected a cache lookup span, got %v", byName)
+	}
+	if cacheSpan.Parent().SpanID() != serviceSpan.SpanContext().SpanID() {
+		t.Error("cache lookup should be a child of the service span")
+	}
+}
+
+func TestGetHistoricalExchangeRate_MarketHolidays(t *testing.T) {
+	defer func(days int) { config.HistoricalFallbackDays = days }(config.HistoricalFallbackDays)
+	config.HistoricalFallbackDays = 4
+
+	// a Wednesday far enough back that the walk stays in the window
+	wednesday := time.Now().AddDate(0, 0, -14)
+	for wednesday.Weekday() != time.Wednesday {
+		wednesday = wednesday.AddDate(0, 0, -1)
+	}
+	day := func(n int) string { return wednesday.AddDate(0, 0, -n).Format("2006-01-02") }
+
+	// Wednesday is a EUR holiday, Tuesday has a rate
+	markets, err := calendar.New("", nil, map[string]string{"EUR": day(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := &fakeAPIClient{daily: map[string]float64{day(1): 0.92, day(0): 0.99}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, nil)
+	service.SetCalendar(markets)
+
+	quote, err := service.GetHistoricalExchangeRate(context.Background(), "USD", "EUR", day(0))
+	if err != nil {
+		t.Fatalf("expected the prior trading day's rate, got %v", err)
+	}
+	if quote.Rate != 0.92 || quote.Date != day(1) || quote.Closure != calendar.Holiday || api.calls != 1 {
+		t.Errorf("expected Tuesday's 0.92 for a holiday in one call, got %v for %s (%q, %d calls)", quote.Rate, quote.Date, quote.Closure, api.calls)
+	}
+
+	// only a market that observes it is closed
+	if quote, _ := service.GetHistoricalExchangeRate(context.Background(), "USD", "GBP", day(0)); quote.Closure != "" || quote.Date != day(0) {
+		t.Errorf("expected USD-GBP to trade on a EUR holiday, got %+v", quote)
+	}
+
+	closures := service.MarketClosures("USD", "EUR", day(5), day(0))
+	want := map[string]string{day(0): calendar.Holiday, day(3): calendar.Weekend, day(4): calendar.Weekend}
+	if !reflect.DeepEqual(closures, want) {
+		t.Errorf("expected %v, got %v", want, closures)
+	}
+}
+
+// fakeDegraded is a DegradedReporter stuck in one mode
+type fakeDegraded bool
+
+func (d fakeDegraded) Degraded() (string, time.Time, bool) {
+	return "all providers unavailable", time.Now(), bool(d)
+}
+
+func TestConvertCurrencyAmount_DegradedServesCacheOnly(t *testing.T) {
+	cache := newFakeCache()
+	cache.rates["USD-EUR"] = models.RateQuote{Rate: 0.9, LastUpdated: time.Now().Add(-5 * time.Hour), Stale: true}
+	api := &fakeAPIClient{daily: map[string]float64{"": 0.95}}
+	service := NewCurrencyExchangeService(cache, api, testCurrencies, nil)
+	service.SetDegradedMode(fakeDegraded(true))
+
+	result, err := service.ConvertCurrencyAmount(context.Background(), "USD", "EUR", decimal.NewFromInt(100), "")
+	if err != nil {
+		t.Fatalf("expected the cached rate in degraded mode, got %v", err)
+	}
+	if result.Quote.Rate != 0.9 || !result.Quote.Stale || !result.Quote.Degraded {
+		t.Errorf("expected a stale, degraded quote, got %+v", result.Quote)
+	}
+
+	// nothing cached - fail fast rather than call the upstream
+	_, err = service.ConvertCurrencyAmount(context.Background(), "USD", "GBP", decimal.NewFromInt(100), "")
+	if !errors.Is(err, apperrors.ErrDegraded) {
+		t.Errorf("expected ErrDegraded for an uncached pair, got %v", err)
+	}
+	if api.calls != 0 {
+		t.Errorf("expected no upstream calls in degraded mode, got %d", api.calls)
+	}
+}
+
+func TestGetHistoricalExchangeRate_DegradedRejectsUnstoredDays(t *testing.T) {
+	api := &fakeAPIClient{daily: map[string]float64{daysAgo(4): 0.94}}
+	history := &fakeHistory{rates: map[string]float64{daysAgo(3): 0.91}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, history)
+	service.SetDegradedMode(fakeDegraded(true))
+
+	quote, err := service.GetHistoricalExchangeRate(context.Background(), "USD", "EUR", daysAgo(3))
+	if err != nil || quote.Rate != 0.91 || !quote.Degraded {
+		t.Errorf("expected the stored rate flagged degraded, got %+v, %v", quote, err)
+	}
+
+	if _, err := service.GetHistoricalExchangeRate(context.Background(), "USD", "EUR", daysAgo(4)); !errors.Is(err, apperrors.ErrDegraded) {
+		t.Errorf("expected ErrDegraded for an unstored day, got %v", err)
+	}
+	if api.calls != 0 {
+		t.Errorf("expected no upstream calls in degraded mode, got %d", api.calls)
+	}
+}
+
+func TestDegradedMode_FollowsCheck(t *testing.T) {
+	var upstreamErr error
+	mode := NewDegradedMode(func(ctx context.Context) error { return upstreamErr }, time.Minute)
+
+	mode.Evaluate(context.Background())
+	if _, _, degraded := mode.Degraded(); degraded {
+		t.Fatal("expected normal mode while the upstream is available")
+	}
+
+	upstreamErr = errors.New("all providers unavailable: mock: circuit open")
+	mode.Evaluate(context.Background())
+	reason, since, degraded := mode.Degraded()
+	if !degraded || reason != upstreamErr.Error() || since.IsZero() {
+		t.Fatalf("expected degraded mode with the check's reason, got %q since %v (%v)", reason, since, degraded)
+	}
+
+	// staying degraded keeps the original start
+	mode.Evaluate(context.Background())
+	if _, again, _ := mode.Degraded(); !again.Equal(since) {
+		t.Errorf("expected since to stay %v, got %v", since, again)
+	}
+
+	upstreamErr = nil
+	mode.Evaluate(context.Background())
+	if _, _, degraded := mode.Degraded(); degraded {
+		t.Error("expected degraded mode to end once the upstream recovers")
+	}
+}
+
+func TestCurrencyDeprecation_SunsetDate(t *testing.T) {
+	saved := config.DeprecatedCurrencies
+	defer func() { config.DeprecatedCurrencies = saved }()
+	config.DeprecatedCurrencies = map[string]time.Time{
+		"INR": time.Now().Add(30 * 24 * time.Hour),
+		"GBP": time.Now().Add(-time.Minute),
+	}
+
+	cache := newFakeCache()
+	cache.SetRate("USD", "INR", 83, "test")
+	cache.SetRate("USD", "GBP", 0.79, "test")
+	service := NewCurrencyExchangeService(cache, &fakeAPIClient{}, testCurrencies, nil)
+
+	// before the sunset the currency keeps working, with a notice
+	if _, err := service.ConvertCurrencyAmount(context.Background(), "USD", "INR", decimal.NewFromInt(1), ""); err != nil {
+		t.Fatalf("deprecated currency should work until its sunset, got %v", err)
+	}
+	notices := service.GetDeprecationNotices("usd", "inr", "INR")
+	if len(notices) != 1 || notices[0].Code != "INR" || !notices[0].SunsetDate.Equal(config.DeprecatedCurrencies["INR"]) {
+		t.Errorf("expected one INR notice, got %+v", notices)
+	}
+
+	// from the sunset on it is refused
+	_, err := service.ConvertCurrencyAmount(context.Background(), "USD", "GBP", decimal.NewFromInt(1), "")
+	if !errors.Is(err, apperrors.ErrCurrencySunset) {
+		t.Errorf("expected currency_sunset after the sunset date, got %v", err)
+	}
+	if _, err := service.GetHistoricalRateRange(context.Background(), "GBP", "USD", daysAgo(5), daysAgo(1)); !errors.Is(err, apperrors.ErrCurrencySunset) {
+		t.Errorf("expected retired currencies to be refused for history too, got %v", err)
+	}
+}
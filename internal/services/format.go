@@ -0,0 +1,40 @@
+package services
+
+import (
+	"strings"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// FormatAmount rounds amount to the currency's minor units - the same
+// rounding conversions use - and writes it for locale (en-US when empty)
+func (service *CurrencyExchangeService) FormatAmount(code string, amount decimal.Decimal, locale string) (models.FormattedAmount, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !service.currencies.IsSupported(code) {
+		return models.FormattedAmount{}, apperrors.New(apperrors.CodeUnsupportedCurrency, "unsupported currency: %s", code)
+	}
+	if locale == "" {
+		locale = currency.DefaultLocale
+	}
+
+	minorUnits := config.GetMinorUnits(code)
+	formatted, err := currency.Format(amount, code, minorUnits, locale)
+	if err != nil {
+		return models.FormattedAmount{}, apperrors.New(apperrors.CodeInvalidRequest, "%s", err.Error())
+	}
+
+	return models.FormattedAmount{
+		Currency:   code,
+		Amount:     models.NewAmount(amount),
+		Rounded:    amount.Round(minorUnits).StringFixed(minorUnits),
+		MinorUnits: minorUnits,
+		Symbol:     currency.SymbolOf(code),
+		Locale:     locale,
+		Formatted:  formatted,
+	}, nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+
+	"exchange-rate-service/internal/apperrors"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestFormatAmount(t *testing.T) {
+	service := NewCurrencyExchangeService(newFakeCache(), &fakeAPIClient{}, testCurrencies, nil)
+
+	formatted, err := service.FormatAmount("jpy", decimal.RequireFromString("12345.678"), "")
+	if err != nil {
+		t.Fatalf("FormatAmount failed: %v", err)
+	}
+	if formatted.Currency != "JPY" || formatted.Rounded != "12346" || formatted.MinorUnits != 0 ||
+		formatted.Locale != "en-US" || formatted.Formatted != "¥12,346" {
+		t.Errorf("unexpected result: %+v", formatted)
+	}
+
+	if formatted, _ := service.FormatAmount("USD", decimal.RequireFromString("12.5"), "en"); formatted.Rounded != "12.50" {
+		t.Errorf("rounded should keep trailing zeros, got %q", formatted.Rounded)
+	}
+
+	if _, err := service.FormatAmount("XYZ", decimal.NewFromInt(1), ""); apperrors.CodeOf(err) != apperrors.CodeUnsupportedCurrency {
+		t.Errorf("expected unsupported_currency, got %v", err)
+	}
+	if _, err := service.FormatAmount("USD", decimal.NewFromInt(1), "klingon"); apperrors.CodeOf(err) != apperrors.CodeInvalidRequest {
+		t.Errorf("expected invalid_request for an unknown locale, got %v", err)
+	}
+}
@@ -2,43 +2,175 @@ package services
 
 import (
 	"context"
+	"sync"
+	"time"
+
+	"exchange-rate-service/config"
 	"exchange-rate-service/internal/models"
 )
 
+// how long a single readiness check may take before it counts as failed
+const healthCheckTimeout = 2 * time.Second
+
 // HealthService handles health check operations
 type HealthService struct {
-	version string
+	version  string
+	breakers CircuitBreakerReporter
+	checkers []HealthChecker
+	probe    ProviderProbeReporter
+	degraded DegradedReporter
+}
+
+// CircuitBreakerReporter exposes upstream circuit breaker states by provider name
+type CircuitBreakerReporter interface {
+	BreakerStates() map[string]string
+}
+
+// ProviderProbeReporter exposes the last background probe of each provider
+type ProviderProbeReporter interface {
+	Results() []models.ProviderHealth
+}
+
+// HealthChecker is one readiness dependency (cache, upstream, store...)
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// healthCheck adapts a function to HealthChecker
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (c healthCheck) Name() string                    { return c.name }
+func (c healthCheck) Check(ctx context.Context) error { return c.check(ctx) }
+
+// NewHealthCheck wraps check as a named HealthChecker
+func NewHealthCheck(name string, check func(ctx context.Context) error) HealthChecker {
+	return healthCheck{name: name, check: check}
 }
 
 // NewHealthService creates a new health service instance
-func NewHealthService() *HealthService {
+// breakers may be nil (read-only replicas have no upstream); checkers decide readiness
+func NewHealthService(breakers CircuitBreakerReporter, checkers ...HealthChecker) *HealthService {
 	return &HealthService{
-		version: "1.0.0", // This could be injected from build info
+		version:  "1.0.0", // This could be injected from build info
+		breakers: breakers,
+		checkers: checkers,
 	}
 }
 
+// SetProviderProbe adds the provider probes to readiness and health output
+func (s *HealthService) SetProviderProbe(probe ProviderProbeReporter) {
+	s.probe = probe
+}
+
+// SetDegradedMode adds degraded mode to readiness and health output
+func (s *HealthService) SetDegradedMode(reporter DegradedReporter) {
+	s.degraded = reporter
+}
+
+// CheckLiveness only says the process is up and serving - it never looks at
+// dependencies, so an upstream outage doesn't get the instance restarted
+func (s *HealthService) CheckLiveness(ctx context.Context) *models.HealthStatus {
+	healthStatus := models.NewHealthStatus("ok")
+	healthStatus.Version = s.version
+	healthStatus.AddCheck("service", "ok")
+	return healthStatus
+}
+
+// CheckReadiness runs every injected checker - any failure makes the instance unready
+func (s *HealthService) CheckReadiness(ctx context.Context) *models.HealthStatus {
+	healthStatus := models.NewHealthStatus("ok")
+	healthStatus.Version = s.version
+	s.runCheckers(ctx, healthStatus)
+	s.addProviders(healthStatus)
+	s.addDegraded(healthStatus)
+	return healthStatus
+}
+
 // CheckHealth performs comprehensive health checks
+// Readiness checks plus informational ones (mode, breaker states)
 func (s *HealthService) CheckHealth(ctx context.Context) *models.HealthStatus {
 	healthStatus := models.NewHealthStatus("ok")
 	healthStatus.Version = s.version
 
 	// Perform various health checks
 	s.checkServiceHealth(healthStatus)
+	s.runCheckers(ctx, healthStatus)
+	s.addProviders(healthStatus)
+	s.addDegraded(healthStatus)
 
 	return healthStatus
 }
 
+// runCheckers runs the checkers concurrently, each under its own timeout
+func (s *HealthService) runCheckers(ctx context.Context, status *models.HealthStatus) {
+	results := make([]error, len(s.checkers))
+
+	var wg sync.WaitGroup
+	for i, checker := range s.checkers {
+		wg.Add(1)
+		go func(i int, checker HealthChecker) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			results[i] = checker.Check(checkCtx)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	for i, checker := range s.checkers {
+		if results[i] != nil {
+			status.Status = "unavailable"
+			status.AddCheck(checker.Name(), "error: "+results[i].Error())
+			continue
+		}
+		status.AddCheck(checker.Name(), "ok")
+	}
+}
+
+// addProviders lists each provider's latency and availability from its last probe
+func (s *HealthService) addProviders(status *models.HealthStatus) {
+	if s.probe != nil {
+		status.Providers = s.probe.Results()
+	}
+}
+
+// addDegraded says whether the service is in degraded mode, and since when
+// It doesn't decide readiness - the upstream check already fails then
+func (s *HealthService) addDegraded(status *models.HealthStatus) {
+	if s.degraded == nil {
+		return
+	}
+	reason, since, degraded := s.degraded.Degraded()
+	if !degraded {
+		status.AddCheck("degraded_mode", "inactive")
+		return
+	}
+	status.AddCheck("degraded_mode", "active")
+	status.Degraded = &models.DegradedStatus{Reason: reason, Since: since}
+}
+
 // checkServiceHealth performs internal service health checks
 func (s *HealthService) checkServiceHealth(status *models.HealthStatus) {
-	// Basic service health - always healthy for now
+	// Basic service health - the process is up
 	status.AddCheck("service", "ok")
 
-	// Add more checks here as the service grows:
-	// - Database connectivity
-	// - External API availability
-	// - Cache connectivity
-	// - Memory usage
-	// - Disk space
+	// let operators see which role this instance is playing
+	if config.ReadOnlyMode {
+		status.AddCheck("mode", "read-only")
+	} else {
+		status.AddCheck("mode", "writer")
+	}
+
+	// an open breaker isn't unhealthy - we still serve from cache or other providers
+	if s.breakers != nil {
+		for provider, state := range s.breakers.BreakerStates() {
+			status.AddCheck("circuit_breaker:"+provider, state)
+		}
+	}
 }
 
 // GetVersion returns the service version
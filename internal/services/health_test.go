@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/models"
+)
+
+func TestHealthService_LivenessIgnoresDependencies(t *testing.T) {
+	failing := NewHealthCheck("upstream", func(ctx context.Context) error { return errors.New("down") })
+	service := NewHealthService(nil, failing)
+
+	if status := service.CheckLiveness(context.Background()); !status.IsHealthy() {
+		t.Errorf("liveness should stay ok when a dependency fails, got %+v", status)
+	}
+}
+
+func TestHealthService_Readiness(t *testing.T) {
+	ok := NewHealthCheck("cache", func(ctx context.Context) error { return nil })
+	failing := NewHealthCheck("upstream", func(ctx context.Context) error { return errors.New("no provider available") })
+
+	ready := NewHealthService(nil, ok).CheckReadiness(context.Background())
+	if !ready.IsHealthy() || ready.Checks["cache"] != "ok" {
+		t.Errorf("expected ready with passing checks, got %+v", ready)
+	}
+
+	unready := NewHealthService(nil, ok, failing).CheckReadiness(context.Background())
+	if unready.IsHealthy() {
+		t.Error("a failing check should make the instance unready")
+	}
+	if unready.Checks["upstream"] != "error: no provider available" || unready.Checks["cache"] != "ok" {
+		t.Errorf("expected per-check results, got %v", unready.Checks)
+	}
+}
+
+func TestHealthService_SlowCheckTimesOut(t *testing.T) {
+	slow := NewHealthCheck("storage", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Minute):
+			return nil
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	status := NewHealthService(nil, slow).CheckReadiness(ctx)
+	if status.IsHealthy() || time.Since(start) > time.Second {
+		t.Errorf("a hung check should fail fast, got %+v after %v", status, time.Since(start))
+	}
+}
+
+// fakeProbe reports fixed provider probe results
+type fakeProbe []models.ProviderHealth
+
+func (f fakeProbe) Results() []models.ProviderHealth { return f }
+
+func TestHealthService_ListsProviderProbes(t *testing.T) {
+	service := NewHealthService(nil)
+	if status := service.CheckReadiness(context.Background()); status.Providers != nil {
+		t.Errorf("expected no providers without a probe, got %+v", status.Providers)
+	}
+
+	service.SetProviderProbe(fakeProbe{{Name: "frankfurter", Status: "up", LatencyMs: 42}})
+	for _, status := range []*models.HealthStatus{
+		service.CheckReadiness(context.Background()),
+		service.CheckHealth(context.Background()),
+	} {
+		if len(status.Providers) != 1 || status.Providers[0].Name != "frankfurter" || status.Providers[0].LatencyMs != 42 {
+			t.Errorf("expected the probe results, got %+v", status.Providers)
+		}
+	}
+}
+
+func TestHealthService_ReportsDegradedMode(t *testing.T) {
+	service := NewHealthService(nil)
+	service.SetDegradedMode(fakeDegraded(false))
+	if status := service.CheckHealth(context.Background()); status.Degraded != nil || status.Checks["degraded_mode"] != "inactive" {
+		t.Errorf("expected degraded mode inactive, got %+v", status)
+	}
+
+	service.SetDegradedMode(fakeDegraded(true))
+	for _, status := range []*models.HealthStatus{
+		service.CheckReadiness(context.Background()),
+		service.CheckHealth(context.Background()),
+	} {
+		if status.Degraded == nil || status.Degraded.Reason == "" || status.Checks["degraded_mode"] != "active" {
+			t.Errorf("expected degraded mode reported, got %+v", status)
+		}
+	}
+}
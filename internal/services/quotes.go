@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// Converter is what quoting needs from the exchange service
+type Converter interface {
+	ConvertCurrencyAmount(ctx context.Context, fromCurrency, toCurrency string, amount decimal.Decimal, dateStr string) (models.ConversionResult, error)
+}
+
+// QuoteStore keeps issued quotes until they expire, each takeable once by its owner
+type QuoteStore interface {
+	Save(ctx context.Context, owner string, quote models.Quote) error
+	Take(ctx context.Context, owner, id string) (models.Quote, bool, error)
+}
+
+// QuoteService locks a conversion's rate for a short window, so a client can
+// show the price and then convert at exactly that price
+type QuoteService struct {
+	converter Converter
+	store     QuoteStore
+	validity  time.Duration
+}
+
+// NewQuoteService issues quotes valid for validity
+func NewQuoteService(converter Converter, store QuoteStore, validity time.Duration) *QuoteService {
+	return &QuoteService{
+		converter: converter,
+		store:     store,
+		validity:  validity,
+	}
+}
+
+// CreateQuote prices amount at the latest rate, fees and tenant markup
+// included, and holds that price for owner until the quote expires. A rate
+// only available stale is not quoted - the price would be locked in
+func (s *QuoteService) CreateQuote(ctx context.Context, owner, from, to string, amount decimal.Decimal) (models.Quote, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	conversion, err := s.converter.ConvertCurrencyAmount(ctx, from, to, amount, "")
+	if err != nil {
+		return models.Quote{}, err
+	}
+	if conversion.Quote.Stale {
+		return models.Quote{}, apperrors.New(apperrors.CodeUpstreamUnavailable, "no fresh rate for %s-%s to quote", from, to)
+	}
+
+	id, err := newQuoteID()
+	if err != nil {
+		return models.Quote{}, apperrors.Wrap(apperrors.CodeInternal, err, "failed to create quote id")
+	}
+
+	now := time.Now().UTC()
+	quote := models.Quote{
+		ID:             id,
+		From:           from,
+		To:             to,
+		OriginalAmount: models.NewAmount(amount),
+		Amount:         models.NewAmount(conversion.Amount),
+		Rate:           conversion.Quote.Rate,
+		AppliedRate:    conversion.AppliedRate,
+		Fee:            models.NewAmount(conversion.Fee),
+		Source:         conversion.Quote.Source,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.validity),
+	}
+	if err := s.store.Save(ctx, owner, quote); err != nil {
+		return models.Quote{}, apperrors.Wrap(apperrors.CodeInternal, err, "failed to store quote")
+	}
+
+	return quote, nil
+}
+
+// ExecuteQuote carries out owner's quote at its locked rate. A quote runs at
+// most once - unknown, expired, already executed and other callers' quotes
+// are all not found
+func (s *QuoteService) ExecuteQuote(ctx context.Context, owner, id string) (models.QuoteExecution, error) {
+	quote, found, err := s.store.Take(ctx, owner, id)
+	if err != nil {
+		return models.QuoteExecution{}, apperrors.Wrap(apperrors.CodeInternal, err, "failed to read quote")
+	}
+	if !found {
+		return models.QuoteExecution{}, apperrors.New(apperrors.CodeNotFound, "quote %s not found, expired or already executed", id)
+	}
+
+	return models.QuoteExecution{
+		QuoteID:        quote.ID,
+		From:           quote.From,
+		To:             quote.To,
+		OriginalAmount: quote.OriginalAmount,
+		Amount:         quote.Amount,
+		Rate:           quote.Rate,
+		AppliedRate:    quote.AppliedRate,
+		Fee:            quote.Fee,
+		Source:         quote.Source,
+		QuotedAt:       quote.CreatedAt,
+		ExecutedAt:     time.Now().UTC(),
+	}, nil
+}
+
+// newQuoteID is 128 random bits - unguessable, so ids can travel in URLs
+func newQuoteID() (string, error) {
+	id, err := utils.RandomID(16)
+	if err != nil {
+		return "", err
+	}
+	return "q_" + id, nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// fakeConverter answers every conversion with result
+type fakeConverter struct {
+	result models.ConversionResult
+	err    error
+}
+
+func (c *fakeConverter) ConvertCurrencyAmount(ctx context.Context, fromCurrency, toCurrency string, amount decimal.Decimal, dateStr string) (models.ConversionResult, error) {
+	return c.result, c.err
+}
+
+// fakeQuoteStore keeps quotes in a map, keyed by owner and id
+type fakeQuoteStore struct {
+	mu     sync.Mutex
+	quotes map[string]models.Quote
+}
+
+func (s *fakeQuoteStore) Save(ctx context.Context, owner string, quote models.Quote) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.quotes == nil {
+		s.quotes = make(map[string]models.Quote)
+	}
+	s.quotes[owner+"/"+quote.ID] = quote
+	return nil
+}
+
+func (s *fakeQuoteStore) Take(ctx context.Context, owner, id string) (models.Quote, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	quote, found := s.quotes[owner+"/"+id]
+	delete(s.quotes, owner+"/"+id)
+	if found && time.Now().After(quote.ExpiresAt) {
+		return models.Quote{}, false, nil
+	}
+	return quote, found, nil
+}
+
+func TestQuoteService_CreateAndExecute(t *testing.T) {
+	converter := &fakeConverter{result: models.ConversionResult{
+		Amount:      decimal.RequireFromString("91.50"),
+		Quote:       models.RateQuote{Rate: 0.92, Source: "mock"},
+		AppliedRate: 0.915,
+		Fee:         decimal.RequireFromString("0.50"),
+	}}
+	service := NewQuoteService(converter, &fakeQuoteStore{}, time.Minute)
+
+	quote, err := service.CreateQuote(context.Background(), "alice", "usd", "eur", decimal.NewFromInt(100))
+	if err != nil {
+		t.Fatalf("CreateQuote() error = %v", err)
+	}
+	if quote.ID == "" || quote.From != "USD" || quote.To != "EUR" {
+		t.Fatalf("quote = %+v, want an id and upper-cased codes", quote)
+	}
+	if got := quote.ExpiresAt.Sub(quote.CreatedAt); got != time.Minute {
+		t.Errorf("validity = %v, want 1m", got)
+	}
+
+	// the rate moves - the quote must not
+	converter.result.Quote.Rate = 0.5
+	if _, err := service.ExecuteQuote(context.Background(), "mallory", quote.ID); apperrors.CodeOf(err) != apperrors.CodeNotFound {
+		t.Errorf("executing another caller's quote: error = %v, want not found", err)
+	}
+	execution, err := service.ExecuteQuote(context.Background(), "alice", quote.ID)
+	if err != nil {
+		t.Fatalf("ExecuteQuote() error = %v", err)
+	}
+	if execution.Rate != 0.92 || !execution.Amount.Equal(quote.Amount.Decimal) || !execution.QuotedAt.Equal(quote.CreatedAt) {
+		t.Errorf("execution = %+v, want the quoted rate and amount", execution)
+	}
+
+	if _, err := service.ExecuteQuote(context.Background(), "alice", quote.ID); apperrors.CodeOf(err) != apperrors.CodeNotFound {
+		t.Errorf("second execution: error = %v, want not found", err)
+	}
+}
+
+func TestQuoteService_RefusesStaleRates(t *testing.T) {
+	converter := &fakeConverter{result: models.ConversionResult{
+		Amount: decimal.NewFromInt(92),
+		Quote:  models.RateQuote{Rate: 0.92, Stale: true},
+	}}
+	store := &fakeQuoteStore{}
+	service := NewQuoteService(converter, store, time.Minute)
+
+	_, err := service.CreateQuote(context.Background(), "", "USD", "EUR", decimal.NewFromInt(100))
+	if apperrors.CodeOf(err) != apperrors.CodeUpstreamUnavailable {
+		t.Fatalf("error = %v, want upstream unavailable", err)
+	}
+	if len(store.quotes) != 0 {
+		t.Errorf("stored %d quotes, want none", len(store.quotes))
+	}
+}
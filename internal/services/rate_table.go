@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/tenant"
+)
+
+// BaseQuoteLister is optionally implemented by caches that can list every
+// cached quote from one base in a single pass. Without it, GetRateTable looks
+// up each supported currency on its own
+type BaseQuoteLister interface {
+	QuotesFrom(base string) (map[string]models.RateQuote, error)
+}
+
+// GetRateTable rebases the cached RefreshBaseCurrency quotes onto base: the
+// base->X rate is refresh base->X / refresh base->base. Only the base's own
+// quote may be fetched upstream - currencies without a cached quote are left
+// out rather than fetched one by one. A tenant only sees the pairs its policy allows
+func (service *CurrencyExchangeService) GetRateTable(ctx context.Context, base string) (models.RateTable, error) {
+	base = strings.ToUpper(strings.TrimSpace(base))
+	if err := service.validateCurrencyPair(base, base); err != nil {
+		return models.RateTable{}, err
+	}
+
+	refreshBase := config.RefreshBaseCurrency
+	quotes, err := service.baseQuotes(ctx, refreshBase)
+	if err != nil {
+		return models.RateTable{}, apperrors.Wrap(apperrors.CodeUpstreamUnavailable, err, "failed to read cached rates")
+	}
+	quotes[refreshBase] = models.RateQuote{Rate: 1}
+
+	baseLeg := quotes[base]
+	if base != refreshBase {
+		baseLeg, err = service.getExchangeRateForPair(ctx, refreshBase, base, "")
+		if err != nil {
+			return models.RateTable{}, err
+		}
+	}
+
+	policy, _ := tenant.FromContext(ctx)
+	table := models.RateTable{Base: base, Rates: make([]models.TableRate, 0, len(quotes))}
+	for to, leg := range quotes {
+		if to == base || !service.currencies.IsSupported(to) || config.IsCurrencySunset(to) {
+			continue
+		}
+		if policy != nil && !policy.AllowsPair(base, to) {
+			continue
+		}
+
+		rate := models.TableRate{
+			To:          to,
+			Rate:        leg.Rate / baseLeg.Rate,
+			Source:      joinSources(baseLeg.Source, leg.Source),
+			LastUpdated: olderOf(baseLeg.LastUpdated, leg.LastUpdated),
+			Stale:       baseLeg.Stale || leg.Stale,
+		}
+		table.Stale = table.Stale || rate.Stale
+		table.Rates = append(table.Rates, rate)
+	}
+
+	sort.Slice(table.Rates, func(i, j int) bool { return table.Rates[i].To < table.Rates[j].To })
+	table.Count = len(table.Rates)
+	table.Degraded = service.isDegraded()
+	return table, nil
+}
+
+// baseQuotes returns the cached base->X quotes keyed by X
+func (service *CurrencyExchangeService) baseQuotes(ctx context.Context, base string) (map[string]models.RateQuote, error) {
+	if lister, ok := service.cache.(BaseQuoteLister); ok {
+		return lister.QuotesFrom(base)
+	}
+
+	quotes := make(map[string]models.RateQuote)
+	for _, entry := range service.currencies.List() {
+		if entry.Code == base {
+			continue
+		}
+		if quote, found := service.cachedRate(ctx, base, entry.Code); found && quote.Rate > 0 {
+			quotes[entry.Code] = quote
+		}
+	}
+	return quotes, nil
+}
+
+// joinSources names the providers behind a rate built from two quotes, "+"
+// joined when they differ
+func joinSources(first, second string) string {
+	switch {
+	case first == "" || first == second:
+		return second
+	case second == "":
+		return first
+	default:
+		return first + "+" + second
+	}
+}
+
+// olderOf returns the earlier of two fetch times, ignoring unknown (zero) ones
+func olderOf(a, b time.Time) *time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		a = b
+	}
+	if a.IsZero() {
+		return nil
+	}
+	utc := a.UTC()
+	return &utc
+}
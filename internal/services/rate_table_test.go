@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/tenant"
+)
+
+func TestGetRateTable_RebasesCachedQuotes(t *testing.T) {
+	cache := newFakeCache()
+	cache.SetRate("USD", "EUR", 0.9, "exchangerate-api")
+	cache.SetRate("USD", "INR", 83, "exchangerate-api")
+	cache.rates["USD-GBP"] = models.RateQuote{Rate: 0.8, LastUpdated: time.Now().Add(-3 * time.Hour), Stale: true, Cached: true, Source: "frankfurter"}
+	api := &fakeAPIClient{}
+	service := NewCurrencyExchangeService(cache, api, testCurrencies, nil)
+
+	table, err := service.GetRateTable(context.Background(), "eur")
+	if err != nil {
+		t.Fatalf("GetRateTable failed: %v", err)
+	}
+	if api.calls != 0 {
+		t.Errorf("expected the table from cached quotes only, got %d upstream calls", api.calls)
+	}
+
+	want := map[string]float64{"GBP": 0.8 / 0.9, "INR": 83 / 0.9, "USD": 1 / 0.9}
+	if table.Base != "EUR" || table.Count != len(want) || !table.Stale {
+		t.Fatalf("expected 3 rates from EUR flagged stale, got %+v", table)
+	}
+	for i, to := range []string{"GBP", "INR", "USD"} {
+		got := table.Rates[i]
+		if got.To != to || math.Abs(got.Rate-want[to]) > 1e-12 {
+			t.Errorf("expected EUR-%s at %v, got %+v", to, want[to], got)
+		}
+	}
+	if gbp := table.Rates[0]; !gbp.Stale || gbp.Source != "exchangerate-api+frankfurter" {
+		t.Errorf("expected the stale GBP leg to show, got %+v", gbp)
+	}
+}
+
+func TestGetRateTable_OnlyPolicyPairs(t *testing.T) {
+	cache := newFakeCache()
+	cache.SetRate("USD", "EUR", 0.9, "")
+	cache.SetRate("USD", "INR", 83, "")
+	policy, err := tenant.NewPolicy("partner", tenant.Config{AllowedPairs: []string{"USD-INR"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewCurrencyExchangeService(cache, &fakeAPIClient{}, testCurrencies, nil)
+
+	table, err := service.GetRateTable(tenant.WithPolicy(context.Background(), policy), "USD")
+	if err != nil {
+		t.Fatalf("GetRateTable failed: %v", err)
+	}
+	if table.Count != 1 || table.Rates[0].To != "INR" || table.Rates[0].Rate != 83 {
+		t.Errorf("expected only USD-INR, got %+v", table.Rates)
+	}
+
+	if _, err := service.GetRateTable(context.Background(), "XYZ"); err == nil {
+		t.Error("expected an unsupported base to fail")
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+)
+
+// routeLegKey marks the context of a route's legs, so a leg no provider
+// quotes either fails instead of being routed in turn
+type routeLegKey struct{}
+
+// unquotedPairs remembers pairs no provider quotes for a refresh interval, so
+// their lookups go straight to a route instead of spending a call on the
+// direct pair each time. Providers adding a currency are noticed once it lapses
+type unquotedPairs struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+func (p *unquotedPairs) mark(pair string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.until == nil {
+		p.until = make(map[string]time.Time)
+	}
+	p.until[pair] = time.Now().Add(config.CacheRefreshInterval)
+}
+
+func (p *unquotedPairs) has(pair string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	until, found := p.until[pair]
+	if found && time.Now().After(until) {
+		delete(p.until, pair)
+		return false
+	}
+	return found
+}
+
+// quoteUpstream fetches FROM-TO from the providers or, when none of them
+// quotes it, routes it through an intermediate currency. The returned error
+// is the direct fetch's when the route fails as well
+func (service *CurrencyExchangeService) quoteUpstream(ctx context.Context, fromCurrency, toCurrency, dateStr string) (models.RateQuote, error) {
+	pair := strings.ToUpper(strings.TrimSpace(fromCurrency)) + "-" + strings.ToUpper(strings.TrimSpace(toCurrency))
+	leg := ctx.Value(routeLegKey{}) != nil
+	if !leg && service.unquoted.has(pair) {
+		if routed, ok := service.routedQuote(ctx, fromCurrency, toCurrency, dateStr); ok {
+			return routed, nil
+		}
+	}
+
+	rate, source, err := service.fetchRate(ctx, fromCurrency, toCurrency, dateStr)
+	if err == nil {
+		return models.RateQuote{Rate: rate, Source: source, Date: dateStr}, nil
+	}
+	if leg || !errors.Is(err, apperrors.ErrPairUnavailable) {
+		return models.RateQuote{}, err
+	}
+
+	service.unquoted.mark(pair)
+	if routed, ok := service.routedQuote(ctx, fromCurrency, toCurrency, dateStr); ok {
+		return routed, nil
+	}
+	return models.RateQuote{}, err
+}
+
+// routedQuote prices FROM-TO as FROM-X times X-TO through the first of
+// config.RoutingCurrencies both legs can be had for. Legs are looked up like
+// any other pair - cache, inverse, rate store, upstream - so a route through
+// cached legs costs no upstream call. dateStr is empty for the latest rate
+func (service *CurrencyExchangeService) routedQuote(ctx context.Context, fromCurrency, toCurrency, dateStr string) (models.RateQuote, bool) {
+	if ctx.Value(routeLegKey{}) != nil {
+		return models.RateQuote{}, false
+	}
+	from, to := strings.ToUpper(strings.TrimSpace(fromCurrency)), strings.ToUpper(strings.TrimSpace(toCurrency))
+	legCtx := context.WithValue(ctx, routeLegKey{}, true)
+
+	for _, via := range config.RoutingCurrencies {
+		if via == from || via == to {
+			continue
+		}
+		first, err := service.routeLeg(legCtx, from, via, dateStr)
+		if err != nil {
+			slog.DebugContext(ctx, "Route leg unavailable", "pair", from+"-"+to, "leg", from+"-"+via, "error", err)
+			continue
+		}
+		second, err := service.routeLeg(legCtx, via, to, dateStr)
+		if err != nil {
+			slog.DebugContext(ctx, "Route leg unavailable", "pair", from+"-"+to, "leg", via+"-"+to, "error", err)
+			continue
+		}
+
+		slog.DebugContext(ctx, "Pair not quoted directly, routed", "pair", from+"-"+to, "via", via, "date", dateStr)
+		return joinLegs(from, via, to, dateStr, first, second), true
+	}
+	return models.RateQuote{}, false
+}
+
+// routeLeg looks up one leg of a route
+func (service *CurrencyExchangeService) routeLeg(ctx context.Context, fromCurrency, toCurrency, dateStr string) (models.RateQuote, error) {
+	if dateStr == "" {
+		return service.lookupRate(ctx, fromCurrency, toCurrency, "")
+	}
+	return service.historicalQuote(ctx, fromCurrency, toCurrency, dateStr)
+}
+
+// joinLegs multiplies two legs into the routed quote - as old as its older
+// leg, stale when either is
+func joinLegs(from, via, to, dateStr string, first, second models.RateQuote) models.RateQuote {
+	quote := models.RateQuote{
+		Rate:        first.Rate * second.Rate,
+		LastUpdated: first.LastUpdated,
+		Stale:       first.Stale || second.Stale,
+		Cached:      first.Cached && second.Cached,
+		Source:      first.Source,
+		Date:        dateStr,
+		Route:       []string{from, via, to},
+	}
+	if !second.LastUpdated.IsZero() && (quote.LastUpdated.IsZero() || second.LastUpdated.Before(quote.LastUpdated)) {
+		quote.LastUpdated = second.LastUpdated
+	}
+	if second.Source != "" && second.Source != first.Source {
+		if quote.Source != "" {
+			quote.Source += "+"
+		}
+		quote.Source += second.Source
+	}
+	return quote
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+
+	"exchange-rate-service/internal/apperrors"
+
+	"github.com/shopspring/decimal"
+)
+
+// pairAPIClient quotes a fixed set of pairs for any date, and answers the
+// rest the way the provider chain does when no provider quotes them
+type pairAPIClient struct {
+	mu    sync.Mutex
+	rates map[string]float64
+	calls map[string]int
+}
+
+func (c *pairAPIClient) GetRate(ctx context.Context, fromCurrency, toCurrency, dateStr string) (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pair := fromCurrency + "-" + toCurrency
+	c.calls[pair]++
+
+	if rate, found := c.rates[pair]; found {
+		return rate, nil
+	}
+	return 0, fmt.Errorf("api request failed: no provider quotes %s: %w", pair, apperrors.ErrPairUnavailable)
+}
+
+func TestConvertCurrencyAmount_RoutesUnquotedPair(t *testing.T) {
+	api := &pairAPIClient{rates: map[string]float64{"INR-USD": 0.012, "USD-JPY": 150}, calls: map[string]int{}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, nil)
+
+	result, err := service.ConvertCurrencyAmount(context.Background(), "INR", "JPY", decimal.NewFromInt(1000), "")
+	if err != nil {
+		t.Fatalf("expected a routed conversion, got %v", err)
+	}
+	if !reflect.DeepEqual(result.Quote.Route, []string{"INR", "USD", "JPY"}) || math.Abs(result.Quote.Rate-1.8) > 1e-9 {
+		t.Errorf("expected INR-JPY through USD at 1.8, got %+v", result.Quote)
+	}
+
+	// the legs are cached now, and the pair is known to need a route
+	if _, err := service.ConvertCurrencyAmount(context.Background(), "INR", "JPY", decimal.NewFromInt(1000), ""); err != nil {
+		t.Fatalf("expected the second conversion to route as well, got %v", err)
+	}
+	if api.calls["INR-JPY"] != 1 || api.calls["INR-USD"] != 1 || api.calls["USD-JPY"] != 1 {
+		t.Errorf("expected one upstream call per pair, got %v", api.calls)
+	}
+}
+
+func TestGetHistoricalExchangeRate_RoutesUnquotedPair(t *testing.T) {
+	// nothing through USD - EUR is next
+	api := &pairAPIClient{rates: map[string]float64{"INR-EUR": 0.011, "EUR-JPY": 160, "INR-USD": 0.012}, calls: map[string]int{}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, nil)
+
+	quote, err := service.GetHistoricalExchangeRate(context.Background(), "INR", "JPY", daysAgo(3))
+	if err != nil {
+		t.Fatalf("expected a routed historical rate, got %v", err)
+	}
+	if !reflect.DeepEqual(quote.Route, []string{"INR", "EUR", "JPY"}) || math.Abs(quote.Rate-1.76) > 1e-9 {
+		t.Errorf("expected INR-JPY through EUR at 1.76, got %+v", quote)
+	}
+}
+
+func TestGetLatestRate_NoRouteKeepsDirectError(t *testing.T) {
+	api := &pairAPIClient{rates: map[string]float64{"INR-USD": 0.012}, calls: map[string]int{}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, nil)
+
+	_, err := service.GetLatestRate(context.Background(), "INR", "JPY")
+	if !errors.Is(err, apperrors.ErrPairUnavailable) {
+		t.Errorf("expected the direct pair_unavailable error, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/apperrors"
+	"exchange-rate-service/internal/models"
+)
+
+// period used by GET /rate/stats when none is given
+const defaultStatsPeriod = "30d"
+
+// GetRateStats summarizes how a pair moved over the trailing period ("7d",
+// "4w", "3m", "1y"): min, max, mean, change and volatility over the daily
+// rates, with the live rate standing in for today. Days come from the rate
+// store when we have them, otherwise from the providers
+func (service *CurrencyExchangeService) GetRateStats(ctx context.Context, fromCurrency, toCurrency, period string) (models.RateStats, error) {
+	if period == "" {
+		period = defaultStatsPeriod
+	}
+	days, err := parseStatsPeriod(period)
+	if err != nil {
+		return models.RateStats{}, err
+	}
+	if days > config.MaxHistoricalDays {
+		return models.RateStats{}, apperrors.New(apperrors.CodeDateOutOfRange, "period too long, maximum %d days allowed", config.MaxHistoricalDays)
+	}
+
+	latest, err := service.GetLatestRate(ctx, fromCurrency, toCurrency)
+	if err != nil {
+		return models.RateStats{}, err
+	}
+
+	today := service.calendar.Today(fromCurrency, toCurrency)
+	start := today.AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+	end := today.Format("2006-01-02")
+
+	series, err := service.GetHistoricalRateRange(ctx, fromCurrency, toCurrency, start, end)
+	if err != nil {
+		return models.RateStats{}, err
+	}
+
+	// today's daily rate isn't final - the live one is what clients compare against
+	daily := make(map[string]float64, len(series)+1)
+	for day, rate := range series {
+		daily[day] = rate
+	}
+	daily[end] = latest.Rate
+
+	ordered := models.TimeSeriesResponse{Rates: daily}.Points()
+	rates := make([]float64, len(ordered))
+	for i, point := range ordered {
+		rates[i] = point.Rate
+	}
+
+	stats := models.RateStats{
+		From:       strings.ToUpper(fromCurrency),
+		To:         strings.ToUpper(toCurrency),
+		Period:     period,
+		Start:      start,
+		End:        end,
+		Days:       len(rates),
+		Open:       rates[0],
+		Min:        rates[0],
+		Max:        rates[0],
+		Latest:     latest.Rate,
+		Volatility: volatility(rates),
+		Stale:      latest.Stale,
+	}
+
+	total := 0.0
+	for _, rate := range rates {
+		total += rate
+		stats.Min = math.Min(stats.Min, rate)
+		stats.Max = math.Max(stats.Max, rate)
+	}
+	stats.Mean = total / float64(len(rates))
+	stats.ChangePercent = (stats.Latest - stats.Open) / stats.Open * 100
+
+	return stats, nil
+}
+
+// parseStatsPeriod turns "30d", "4w", "3m" or "1y" into a number of days
+// A month counts as 30 days, a year as 365
+func parseStatsPeriod(period string) (int, error) {
+	invalid := apperrors.New(apperrors.CodeInvalidRequest, "invalid period %q, expected a number of days, weeks, months or years like 30d, 4w, 3m, 1y", period)
+
+	period = strings.ToLower(strings.TrimSpace(period))
+	if len(period) < 2 {
+		return 0, invalid
+	}
+
+	count, err := strconv.Atoi(period[:len(period)-1])
+	if err != nil || count < 1 {
+		return 0, invalid
+	}
+
+	switch period[len(period)-1] {
+	case 'd':
+		return count, nil
+	case 'w':
+		return count * 7, nil
+	case 'm':
+		return count * 30, nil
+	case 'y':
+		return count * 365, nil
+	default:
+		return 0, invalid
+	}
+}
+
+// volatility is the sample standard deviation of the day-over-day percentage
+// changes - 0 with fewer than two changes to compare
+func volatility(rates []float64) float64 {
+	if len(rates) < 3 {
+		return 0
+	}
+
+	changes := make([]float64, 0, len(rates)-1)
+	mean := 0.0
+	for i := 1; i < len(rates); i++ {
+		change := (rates[i] - rates[i-1]) / rates[i-1] * 100
+		changes = append(changes, change)
+		mean += change
+	}
+	mean /= float64(len(changes))
+
+	variance := 0.0
+	for _, change := range changes {
+		variance += (change - mean) * (change - mean)
+	}
+	return math.Sqrt(variance / float64(len(changes)-1))
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+)
+
+func utcDaysAgo(n int) string {
+	return time.Now().UTC().AddDate(0, 0, -n).Format("2006-01-02")
+}
+
+func TestGetRateStats_FromStoredDaysAndLiveRate(t *testing.T) {
+	history := &fakeHistory{rates: map[string]float64{
+		utcDaysAgo(6): 0.90,
+		utcDaysAgo(5): 0.91,
+		utcDaysAgo(4): 0.92,
+		utcDaysAgo(3): 0.93,
+		utcDaysAgo(2): 0.94,
+		utcDaysAgo(1): 0.95,
+		utcDaysAgo(9): 0.50, // outside the period
+	}}
+	// "" is the live rate, today's dated rate is replaced by it
+	api := &fakeAPIClient{daily: map[string]float64{"": 0.99, utcDaysAgo(0): 0.97}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, history)
+
+	stats, err := service.GetRateStats(context.Background(), "USD", "EUR", "1w")
+	if err != nil {
+		t.Fatalf("GetRateStats failed: %v", err)
+	}
+
+	if stats.Start != utcDaysAgo(6) || stats.End != utcDaysAgo(0) || stats.Days != 7 {
+		t.Errorf("expected 7 days %s..%s, got %d days %s..%s", utcDaysAgo(6), utcDaysAgo(0), stats.Days, stats.Start, stats.End)
+	}
+	if stats.Open != 0.90 || stats.Min != 0.90 || stats.Max != 0.99 || stats.Latest != 0.99 {
+		t.Errorf("unexpected open/min/max/latest: %+v", stats)
+	}
+	if math.Abs(stats.Mean-6.54/7) > 1e-9 {
+		t.Errorf("expected mean %f, got %f", 6.54/7, stats.Mean)
+	}
+	if math.Abs(stats.ChangePercent-10) > 1e-9 {
+		t.Errorf("expected a 10%% change, got %f", stats.ChangePercent)
+	}
+	if stats.Volatility <= 0 {
+		t.Errorf("expected a positive volatility, got %f", stats.Volatility)
+	}
+}
+
+func TestGetRateStats_Validation(t *testing.T) {
+	service := NewCurrencyExchangeService(newFakeCache(), &fakeAPIClient{}, testCurrencies, nil)
+
+	cases := map[string]apperrors.Code{
+		"30":  apperrors.CodeInvalidRequest,
+		"0d":  apperrors.CodeInvalidRequest,
+		"3x":  apperrors.CodeInvalidRequest,
+		"1y":  apperrors.CodeDateOutOfRange,
+		"13w": apperrors.CodeDateOutOfRange,
+	}
+	for period, want := range cases {
+		if _, err := service.GetRateStats(context.Background(), "USD", "EUR", period); apperrors.CodeOf(err) != want {
+			t.Errorf("period %q: expected %s, got %v", period, want, err)
+		}
+	}
+}
+
+func TestVolatility(t *testing.T) {
+	// steady 1% growth every day - no variation at all
+	if got := volatility([]float64{100, 101, 102.01, 103.0301}); math.Abs(got) > 1e-9 {
+		t.Errorf("expected 0 volatility for a constant change, got %f", got)
+	}
+	if got := volatility([]float64{1, 1.1}); got != 0 {
+		t.Errorf("a single change has no spread, got %f", got)
+	}
+	// +10% then -10%: changes 10 and -10, sample deviation sqrt(200)
+	if got := volatility([]float64{1, 1.1, 0.99}); math.Abs(got-math.Sqrt(200)) > 1e-9 {
+		t.Errorf("expected %f, got %f", math.Sqrt(200), got)
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"exchange-rate-service/config"
+	"exchange-rate-service/internal/models"
+)
+
+// trendWindows are the windows GET /rate/trend reports, in days back from today
+var trendWindows = []struct {
+	name string
+	days int
+}{
+	{"24h", 1},
+	{"7d", 7},
+	{"30d", 30},
+}
+
+// trendLookbackDays is how far before a window's start we look for a rate
+// when that day has none - covers weekends and most bank holidays
+const trendLookbackDays = 4
+
+// GetRateTrend compares the live rate with the daily rate 1, 7 and 30 days
+// ago. When a window starts on a day without a fixing, the last rate before
+// it is used. Windows reaching past MAX_HISTORICAL_DAYS, or without any rate
+// to compare against, are left out
+func (service *CurrencyExchangeService) GetRateTrend(ctx context.Context, fromCurrency, toCurrency string) (models.RateTrend, error) {
+	latest, err := service.GetLatestRate(ctx, fromCurrency, toCurrency)
+	if err != nil {
+		return models.RateTrend{}, err
+	}
+
+	trend := models.RateTrend{
+		From:    strings.ToUpper(fromCurrency),
+		To:      strings.ToUpper(toCurrency),
+		Rate:    latest.Rate,
+		Changes: make([]models.RateChange, 0, len(trendWindows)),
+		Stale:   latest.Stale,
+	}
+
+	// the oldest day validateHistoricalRange still accepts
+	span := min(trendWindows[len(trendWindows)-1].days+trendLookbackDays, config.MaxHistoricalDays-1)
+	if span < 1 {
+		return trend, nil
+	}
+
+	today := service.calendar.Today(fromCurrency, toCurrency)
+	start := today.AddDate(0, 0, -span).Format("2006-01-02")
+	end := today.AddDate(0, 0, -1).Format("2006-01-02")
+
+	series, err := service.GetHistoricalRateRange(ctx, fromCurrency, toCurrency, start, end)
+	if err != nil {
+		return models.RateTrend{}, err
+	}
+
+	for _, window := range trendWindows {
+		if window.days > span {
+			break
+		}
+		for back := 0; back <= trendLookbackDays && window.days+back <= span; back++ {
+			day := today.AddDate(0, 0, -(window.days + back)).Format("2006-01-02")
+			rate, found := series[day]
+			if !found || rate == 0 {
+				continue
+			}
+			trend.Changes = append(trend.Changes, models.RateChange{
+				Window:        window.name,
+				Since:         day,
+				Rate:          rate,
+				Change:        latest.Rate - rate,
+				ChangePercent: (latest.Rate - rate) / rate * 100,
+			})
+			break
+		}
+	}
+
+	return trend, nil
+}
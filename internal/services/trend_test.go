@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"exchange-rate-service/config"
+)
+
+func TestGetRateTrend_ComparesLiveRateWithWindowStarts(t *testing.T) {
+	history := &fakeHistory{rates: map[string]float64{
+		utcDaysAgo(1):  0.98,
+		utcDaysAgo(8):  0.95, // no rate 7 days ago, the day before stands in
+		utcDaysAgo(30): 0.80,
+	}}
+	api := &fakeAPIClient{daily: map[string]float64{"": 1.0}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, history)
+
+	trend, err := service.GetRateTrend(context.Background(), "usd", "eur")
+	if err != nil {
+		t.Fatalf("GetRateTrend failed: %v", err)
+	}
+	if trend.From != "USD" || trend.To != "EUR" || trend.Rate != 1.0 {
+		t.Errorf("unexpected pair or rate: %+v", trend)
+	}
+
+	want := []struct {
+		window  string
+		since   string
+		percent float64
+	}{
+		{"24h", utcDaysAgo(1), 2 / 0.98},
+		{"7d", utcDaysAgo(8), 5 / 0.95},
+		{"30d", utcDaysAgo(30), 25},
+	}
+	if len(trend.Changes) != len(want) {
+		t.Fatalf("expected %d windows, got %+v", len(want), trend.Changes)
+	}
+	for i, w := range want {
+		got := trend.Changes[i]
+		if got.Window != w.window || got.Since != w.since || math.Abs(got.ChangePercent-w.percent) > 1e-9 {
+			t.Errorf("expected %s since %s at %.4f%%, got %+v", w.window, w.since, w.percent, got)
+		}
+	}
+}
+
+func TestGetRateTrend_SkipsWindowsPastTheHistoryLimit(t *testing.T) {
+	defer func(days int) { config.MaxHistoricalDays = days }(config.MaxHistoricalDays)
+	config.MaxHistoricalDays = 10
+
+	history := &fakeHistory{rates: map[string]float64{
+		utcDaysAgo(1): 0.98,
+		utcDaysAgo(7): 0.95,
+	}}
+	api := &fakeAPIClient{daily: map[string]float64{"": 1.0}}
+	service := NewCurrencyExchangeService(newFakeCache(), api, testCurrencies, history)
+
+	trend, err := service.GetRateTrend(context.Background(), "USD", "EUR")
+	if err != nil {
+		t.Fatalf("GetRateTrend failed: %v", err)
+	}
+	if len(trend.Changes) != 2 || trend.Changes[1].Window != "7d" {
+		t.Errorf("expected only the 24h and 7d windows, got %+v", trend.Changes)
+	}
+}
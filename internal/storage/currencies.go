@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"exchange-rate-service/internal/currency"
+)
+
+// CurrencyOverrides returns every stored runtime change to the supported
+// currencies, oldest first
+func (s *SQLStore) CurrencyOverrides(ctx context.Context) ([]currency.Override, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT code, name, removed, updated_at FROM currency_overrides ORDER BY updated_at, code`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query currency overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := make([]currency.Override, 0)
+	for rows.Next() {
+		var override currency.Override
+		var removed int
+		var updatedAt int64
+		if err := rows.Scan(&override.Code, &override.Name, &removed, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read currency overrides: %w", err)
+		}
+		override.Removed = removed != 0
+		override.UpdatedAt = time.UnixMilli(updatedAt).UTC()
+		overrides = append(overrides, override)
+	}
+
+	return overrides, rows.Err()
+}
+
+// SaveCurrencyOverride records override, replacing any earlier one for its code
+func (s *SQLStore) SaveCurrencyOverride(ctx context.Context, override currency.Override) error {
+	removed := 0
+	if override.Removed {
+		removed = 1
+	}
+
+	_, err := s.db.ExecContext(ctx, s.rebind(
+		`INSERT INTO currency_overrides (code, name, removed, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (code) DO UPDATE SET name = excluded.name, removed = excluded.removed, updated_at = excluded.updated_at`),
+		override.Code, override.Name, removed, override.UpdatedAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to save currency override: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-rate-service/internal/models"
+)
+
+// Recorder writes fetched rates to the store in the background so request
+// paths never wait on the database. When the queue is full new records are
+// dropped (and logged) rather than blocking.
+type Recorder struct {
+	store *SQLStore
+	queue chan models.RateRecord
+
+	shutdownChannel   chan struct{}
+	backgroundWorkers sync.WaitGroup
+}
+
+// NewRecorder creates a recorder with room for queueSize pending writes
+func NewRecorder(store *SQLStore, queueSize int) *Recorder {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	return &Recorder{
+		store:           store,
+		queue:           make(chan models.RateRecord, queueSize),
+		shutdownChannel: make(chan struct{}),
+	}
+}
+
+// RecordRate queues a rate a provider just returned - an empty date means latest (today)
+func (r *Recorder) RecordRate(source, from, to, date string, rate float64) {
+	now := time.Now().UTC()
+	if date == "" {
+		date = now.Format("2006-01-02")
+	}
+
+	record := models.RateRecord{
+		From:      strings.ToUpper(strings.TrimSpace(from)),
+		To:        strings.ToUpper(strings.TrimSpace(to)),
+		Date:      date,
+		Rate:      rate,
+		Source:    source,
+		FetchedAt: now,
+	}
+
+	select {
+	case r.queue <- record:
+	default:
+		slog.Warn("Rate store queue full, dropping record", "pair", record.From+"-"+record.To, "date", record.Date)
+	}
+}
+
+// Start runs the writer goroutine
+func (r *Recorder) Start() {
+	r.backgroundWorkers.Add(1)
+	go r.writeLoop()
+}
+
+// Stop flushes queued records and waits for the writer to exit
+func (r *Recorder) Stop() {
+	close(r.shutdownChannel)
+	r.backgroundWorkers.Wait()
+}
+
+func (r *Recorder) writeLoop() {
+	defer r.backgroundWorkers.Done()
+
+	for {
+		select {
+		case record := <-r.queue:
+			r.write(record)
+		case <-r.shutdownChannel:
+			// drain whatever is left before exiting
+			for {
+				select {
+				case record := <-r.queue:
+					r.write(record)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *Recorder) write(record models.RateRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := r.store.Record(ctx, record); err != nil {
+		slog.Warn("Failed to store rate", "pair", record.From+"-"+record.To, "error", err)
+	}
+}
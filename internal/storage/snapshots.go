@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"exchange-rate-service/internal/models"
+)
+
+// SaveRateSnapshot stores the rate table of snapshot.Date, replacing an
+// earlier snapshot of the same day (a re-run or a second replica) as a whole
+func (s *SQLStore) SaveRateSnapshot(ctx context.Context, snapshot models.RateSnapshot) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to save rate snapshot: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM rate_snapshots WHERE snapshot_date = ?`), snapshot.Date); err != nil {
+		return fmt.Errorf("failed to replace rate snapshot: %w", err)
+	}
+
+	insert, err := tx.PrepareContext(ctx, s.rebind(
+		`INSERT INTO rate_snapshots (snapshot_date, from_currency, to_currency, rate, source, last_updated, taken_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`))
+	if err != nil {
+		return fmt.Errorf("failed to save rate snapshot: %w", err)
+	}
+	defer insert.Close()
+
+	for _, rate := range snapshot.Rates {
+		if _, err := insert.ExecContext(ctx, snapshot.Date, rate.From, rate.To, rate.Rate, rate.Source,
+			rate.LastUpdated.UnixMilli(), snapshot.TakenAt.UnixMilli()); err != nil {
+			return fmt.Errorf("failed to save rate snapshot: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save rate snapshot: %w", err)
+	}
+	return nil
+}
+
+// RateSnapshot returns the snapshot taken for date, sorted by pair. found is
+// false when none was taken that day
+func (s *SQLStore) RateSnapshot(ctx context.Context, date string) (snapshot models.RateSnapshot, found bool, err error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT from_currency, to_currency, rate, source, last_updated, taken_at FROM rate_snapshots
+		WHERE snapshot_date = ?
+		ORDER BY from_currency, to_currency`),
+		date)
+	if err != nil {
+		return models.RateSnapshot{}, false, fmt.Errorf("failed to query rate snapshot: %w", err)
+	}
+	defer rows.Close()
+
+	snapshot = models.RateSnapshot{Date: date, Rates: make([]models.SnapshotRate, 0)}
+	for rows.Next() {
+		var rate models.SnapshotRate
+		var lastUpdated, takenAt int64
+		if err := rows.Scan(&rate.From, &rate.To, &rate.Rate, &rate.Source, &lastUpdated, &takenAt); err != nil {
+			return models.RateSnapshot{}, false, fmt.Errorf("failed to read rate snapshot: %w", err)
+		}
+		rate.LastUpdated = time.UnixMilli(lastUpdated).UTC()
+		snapshot.TakenAt = time.UnixMilli(takenAt).UTC()
+		snapshot.Rates = append(snapshot.Rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return models.RateSnapshot{}, false, fmt.Errorf("failed to read rate snapshot: %w", err)
+	}
+
+	snapshot.Count = len(snapshot.Rates)
+	return snapshot, snapshot.Count > 0, nil
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"exchange-rate-service/internal/models"
+
+	// database/sql drivers - pure Go sqlite so CGO_ENABLED=0 builds keep working
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// dialect covers the few places sqlite and postgres SQL differ
+type dialect struct {
+	driver     string
+	idColumn   string
+	positional bool // $1, $2... instead of ?
+}
+
+var dialects = map[string]dialect{
+	"sqlite":   {driver: "sqlite", idColumn: "INTEGER PRIMARY KEY AUTOINCREMENT"},
+	"postgres": {driver: "postgres", idColumn: "BIGSERIAL PRIMARY KEY", positional: true},
+}
+
+// SQLStore keeps every fetched rate in a SQL database (sqlite or postgres)
+type SQLStore struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// Open connects to the database and creates the schema if needed
+// driver is "sqlite" (dsn is a file path) or "postgres" (dsn is a connection URL)
+func Open(driver, dsn string) (*SQLStore, error) {
+	d, ok := dialects[strings.ToLower(strings.TrimSpace(driver))]
+	if !ok {
+		return nil, fmt.Errorf("unknown storage driver %q (expected sqlite or postgres)", driver)
+	}
+
+	db, err := sql.Open(d.driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s store: %w", d.driver, err)
+	}
+	if d.driver == "sqlite" {
+		// sqlite allows one writer - serialize instead of failing with SQLITE_BUSY
+		db.SetMaxOpenConns(1)
+	}
+
+	store := &SQLStore{db: db, dialect: d}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := store.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// rateHistoryUniqueIndex keeps one row per pair, day and source - refetches
+// update it instead of piling up copies
+const rateHistoryUniqueIndex = `CREATE UNIQUE INDEX IF NOT EXISTS idx_rate_history_pair_date_source
+	ON rate_history (from_currency, to_currency, rate_date, source)`
+
+// migrate creates the table and index - safe to run on every start
+func (s *SQLStore) migrate(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS rate_history (
+			id ` + s.dialect.idColumn + `,
+			from_currency TEXT NOT NULL,
+			to_currency TEXT NOT NULL,
+			rate_date TEXT NOT NULL,
+			rate DOUBLE PRECISION NOT NULL,
+			source TEXT NOT NULL,
+			fetched_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_rate_history_pair_date
+			ON rate_history (from_currency, to_currency, rate_date)`,
+		// daily copies of the whole cached rate table - see snapshots.go
+		`CREATE TABLE IF NOT EXISTS rate_snapshots (
+			snapshot_date TEXT NOT NULL,
+			from_currency TEXT NOT NULL,
+			to_currency TEXT NOT NULL,
+			rate DOUBLE PRECISION NOT NULL,
+			source TEXT NOT NULL,
+			last_updated BIGINT NOT NULL,
+			taken_at BIGINT NOT NULL,
+			PRIMARY KEY (snapshot_date, from_currency, to_currency)
+		)`,
+		// per-API-key policies, keyed by the key's name - see tenants.go
+		`CREATE TABLE IF NOT EXISTS tenant_policies (
+			tenant TEXT PRIMARY KEY,
+			policy TEXT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`,
+		// currencies operators added or removed at runtime - see currencies.go
+		`CREATE TABLE IF NOT EXISTS currency_overrides (
+			code TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			removed INTEGER NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`,
+	}
+
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to migrate rate store: %w", err)
+		}
+	}
+	return s.migrateUniqueHistory(ctx)
+}
+
+// migrateUniqueHistory adds the unique index. Stores written before it may
+// hold duplicates, which would fail the index - those keep their latest row
+func (s *SQLStore) migrateUniqueHistory(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, rateHistoryUniqueIndex); err == nil {
+		return nil
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM rate_history WHERE id NOT IN (
+			SELECT MAX(id) FROM rate_history GROUP BY from_currency, to_currency, rate_date, source
+		)`); err != nil {
+		return fmt.Errorf("failed to deduplicate rate history: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, rateHistoryUniqueIndex); err != nil {
+		return fmt.Errorf("failed to migrate rate store: %w", err)
+	}
+	return nil
+}
+
+// Record stores one fetched rate, replacing the source's earlier fetch for the same day
+func (s *SQLStore) Record(ctx context.Context, record models.RateRecord) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(
+		`INSERT INTO rate_history (from_currency, to_currency, rate_date, rate, source, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (from_currency, to_currency, rate_date, source)
+		DO UPDATE SET rate = excluded.rate, fetched_at = excluded.fetched_at`),
+		record.From, record.To, record.Date, record.Rate, record.Source, record.FetchedAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to record rate: %w", err)
+	}
+	return nil
+}
+
+// GetRate returns the most recently fetched rate for a pair on date
+func (s *SQLStore) GetRate(ctx context.Context, from, to, date string) (float64, bool, error) {
+	var rate float64
+	err := s.db.QueryRowContext(ctx, s.rebind(
+		`SELECT rate FROM rate_history
+		WHERE from_currency = ? AND to_currency = ? AND rate_date = ?
+		ORDER BY fetched_at DESC LIMIT 1`),
+		from, to, date).Scan(&rate)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("failed to read stored rate: %w", err)
+	}
+	return rate, true, nil
+}
+
+// Series returns the most recently fetched rate per day for [start, end]
+func (s *SQLStore) Series(ctx context.Context, from, to, start, end string) (map[string]float64, error) {
+	records, err := s.History(ctx, from, to, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// records are ordered by fetch time, so later fetches win
+	series := make(map[string]float64)
+	for _, record := range records {
+		series[record.Date] = record.Rate
+	}
+	return series, nil
+}
+
+// History returns each source's latest fetch per day for a pair in [start, end], oldest first
+func (s *SQLStore) History(ctx context.Context, from, to, start, end string) ([]models.RateRecord, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT from_currency, to_currency, rate_date, rate, source, fetched_at FROM rate_history
+		WHERE from_currency = ? AND to_currency = ? AND rate_date >= ? AND rate_date <= ?
+		ORDER BY fetched_at, id`),
+		from, to, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rate history: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]models.RateRecord, 0)
+	for rows.Next() {
+		var record models.RateRecord
+		var fetchedAt int64
+		if err := rows.Scan(&record.From, &record.To, &record.Date, &record.Rate, &record.Source, &fetchedAt); err != nil {
+			return nil, fmt.Errorf("failed to read rate history: %w", err)
+		}
+		record.FetchedAt = time.UnixMilli(fetchedAt).UTC()
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// Ping checks the database is reachable
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the database
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// rebind turns ? placeholders into $n for postgres
+func (s *SQLStore) rebind(query string) string {
+	if !s.dialect.positional {
+		return query
+	}
+
+	var rebound strings.Builder
+	n := 0
+	for _, ch := range query {
+		if ch == '?' {
+			n++
+			fmt.Fprintf(&rebound, "$%d", n)
+			continue
+		}
+		rebound.WriteRune(ch)
+	}
+	return rebound.String()
+}
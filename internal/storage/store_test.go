@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/models"
+	"exchange-rate-service/internal/tenant"
+)
+
+func openTestStore(t *testing.T) *SQLStore {
+	t.Helper()
+
+	store, err := Open("sqlite", filepath.Join(t.TempDir(), "rates.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLStore_LatestFetchWins(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+	fetched := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	records := []models.RateRecord{
+		{From: "USD", To: "EUR", Date: "2024-03-01", Rate: 0.91, Source: "frankfurter", FetchedAt: fetched},
+		{From: "USD", To: "EUR", Date: "2024-03-01", Rate: 0.92, Source: "ecb", FetchedAt: fetched.Add(time.Hour)},
+		{From: "USD", To: "EUR", Date: "2024-03-02", Rate: 0.93, Source: "ecb", FetchedAt: fetched.Add(24 * time.Hour)},
+		{From: "USD", To: "GBP", Date: "2024-03-01", Rate: 0.79, Source: "ecb", FetchedAt: fetched},
+	}
+	for _, record := range records {
+		if err := store.Record(ctx, record); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	rate, found, err := store.GetRate(ctx, "USD", "EUR", "2024-03-01")
+	if err != nil || !found || rate != 0.92 {
+		t.Errorf("expected the later fetch (0.92), got %v found=%v err=%v", rate, found, err)
+	}
+
+	if _, found, _ := store.GetRate(ctx, "USD", "EUR", "2024-02-28"); found {
+		t.Error("expected a miss for a day that was never stored")
+	}
+
+	series, err := store.Series(ctx, "USD", "EUR", "2024-03-01", "2024-03-31")
+	if err != nil {
+		t.Fatalf("Series failed: %v", err)
+	}
+	if len(series) != 2 || series["2024-03-01"] != 0.92 || series["2024-03-02"] != 0.93 {
+		t.Errorf("unexpected series: %v", series)
+	}
+
+	history, err := store.History(ctx, "USD", "EUR", "2024-03-01", "2024-03-01")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 2 || history[0].Source != "frankfurter" || !history[0].FetchedAt.Equal(fetched) {
+		t.Errorf("unexpected history: %+v", history)
+	}
+}
+
+func TestSQLStore_RefetchReplacesRow(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+	fetched := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	// the hourly refresh fetching today's rate again and again
+	for i, rate := range []float64{0.91, 0.92, 0.93, 0.94, 0.95} {
+		record := models.RateRecord{From: "USD", To: "EUR", Date: "2024-03-01", Rate: rate, Source: "ecb", FetchedAt: fetched.Add(time.Duration(i) * time.Hour)}
+		if err := store.Record(ctx, record); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	history, err := store.History(ctx, "USD", "EUR", "2024-03-01", "2024-03-01")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 1 || history[0].Rate != 0.95 || !history[0].FetchedAt.Equal(fetched.Add(4*time.Hour)) {
+		t.Errorf("expected one row holding the latest fetch, got %+v", history)
+	}
+}
+
+func TestOpen_DeduplicatesOldRateHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.db")
+	ctx := context.Background()
+
+	// a store from before the unique index, with the same fetch stored twice
+	store, err := Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := store.db.ExecContext(ctx, "DROP INDEX idx_rate_history_pair_date_source"); err != nil {
+		t.Fatal(err)
+	}
+	for _, rate := range []float64{0.91, 0.92} {
+		if _, err := store.db.ExecContext(ctx, `INSERT INTO rate_history (from_currency, to_currency, rate_date, rate, source, fetched_at)
+			VALUES ('USD', 'EUR', '2024-03-01', ?, 'ecb', 0)`, rate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.Close()
+
+	reopened, err := Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+
+	history, err := reopened.History(ctx, "USD", "EUR", "2024-03-01", "2024-03-01")
+	if err != nil || len(history) != 1 || history[0].Rate != 0.92 {
+		t.Errorf("expected duplicates collapsed to the latest row, got %+v err=%v", history, err)
+	}
+}
+
+func TestSQLStore_ReopenKeepsData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.db")
+	ctx := context.Background()
+
+	store, err := Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	store.Record(ctx, models.RateRecord{From: "USD", To: "INR", Date: "2024-03-01", Rate: 83, Source: "ecb", FetchedAt: time.Now()})
+	store.Close()
+
+	reopened, err := Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+
+	if _, found, _ := reopened.GetRate(ctx, "USD", "INR", "2024-03-01"); !found {
+		t.Error("expected the rate to survive a reopen")
+	}
+}
+
+func TestRecorder_FlushesOnStop(t *testing.T) {
+	store := openTestStore(t)
+	recorder := NewRecorder(store, 16)
+	recorder.Start()
+
+	recorder.RecordRate("frankfurter", "usd", "eur", "2024-03-01", 0.92)
+	recorder.RecordRate("frankfurter", "USD", "EUR", "", 0.93)
+	recorder.Stop()
+
+	ctx := context.Background()
+	if rate, found, _ := store.GetRate(ctx, "USD", "EUR", "2024-03-01"); !found || rate != 0.92 {
+		t.Errorf("expected the dated record to be written, got %v (found %v)", rate, found)
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	if _, found, _ := store.GetRate(ctx, "USD", "EUR", today); !found {
+		t.Error("latest rates should be stored under today's date")
+	}
+}
+
+func TestOpen_UnknownDriver(t *testing.T) {
+	if _, err := Open("mysql", "x"); err == nil {
+		t.Error("expected an error for an unsupported driver")
+	}
+}
+
+func TestRebind(t *testing.T) {
+	postgres := &SQLStore{dialect: dialects["postgres"]}
+	if got := postgres.rebind("a = ? AND b = ?"); got != "a = $1 AND b = $2" {
+		t.Errorf("unexpected postgres query: %s", got)
+	}
+
+	sqlite := &SQLStore{dialect: dialects["sqlite"]}
+	if got := sqlite.rebind("a = ?"); got != "a = ?" {
+		t.Errorf("sqlite queries should be left alone, got %s", got)
+	}
+}
+
+func TestSQLStore_TenantPolicies(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+
+	if err := store.SaveTenantPolicy(ctx, "partner-a", tenant.Config{AllowedPairs: []string{"USD-EUR"}, Markup: "1%"}); err != nil {
+		t.Fatalf("SaveTenantPolicy failed: %v", err)
+	}
+	// saving again replaces the policy
+	if err := store.SaveTenantPolicy(ctx, "partner-a", tenant.Config{Markup: "0.5%", MaxHistoricalDays: 30}); err != nil {
+		t.Fatalf("SaveTenantPolicy failed: %v", err)
+	}
+
+	policies, err := store.TenantPolicies(ctx)
+	if err != nil {
+		t.Fatalf("TenantPolicies failed: %v", err)
+	}
+	got := policies["partner-a"]
+	if len(policies) != 1 || got.Markup != "0.5%" || got.MaxHistoricalDays != 30 || len(got.AllowedPairs) != 0 {
+		t.Errorf("unexpected policies %+v", policies)
+	}
+}
+
+func TestSQLStore_CurrencyOverrides(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+
+	added := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	for _, override := range []currency.Override{
+		{Code: "XTS", Name: "Testing code", UpdatedAt: added},
+		{Code: "GBP", Removed: true, UpdatedAt: added.Add(time.Minute)},
+		// a later change to the same code replaces it
+		{Code: "XTS", Removed: true, UpdatedAt: added.Add(2 * time.Minute)},
+	} {
+		if err := store.SaveCurrencyOverride(ctx, override); err != nil {
+			t.Fatalf("SaveCurrencyOverride failed: %v", err)
+		}
+	}
+
+	overrides, err := store.CurrencyOverrides(ctx)
+	if err != nil {
+		t.Fatalf("CurrencyOverrides failed: %v", err)
+	}
+	want := []currency.Override{
+		{Code: "GBP", Removed: true, UpdatedAt: added.Add(time.Minute)},
+		{Code: "XTS", Removed: true, UpdatedAt: added.Add(2 * time.Minute)},
+	}
+	if !reflect.DeepEqual(overrides, want) {
+		t.Errorf("expected %+v, got %+v", want, overrides)
+	}
+}
+
+func TestSQLStore_RateSnapshots(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+	takenAt := time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC)
+
+	if _, found, err := store.RateSnapshot(ctx, "2024-03-01"); err != nil || found {
+		t.Fatalf("expected no snapshot yet, got found=%v err=%v", found, err)
+	}
+
+	first := models.RateSnapshot{Date: "2024-03-01", TakenAt: takenAt, Rates: []models.SnapshotRate{
+		{From: "USD", To: "INR", Rate: 83.1, Source: "exchangerate-api", LastUpdated: takenAt.Add(-time.Hour)},
+		{From: "EUR", To: "USD", Rate: 1.09, Source: "frankfurter", LastUpdated: takenAt.Add(-time.Minute)},
+	}}
+	if err := store.SaveRateSnapshot(ctx, first); err != nil {
+		t.Fatalf("SaveRateSnapshot failed: %v", err)
+	}
+
+	// a second run the same day replaces the table instead of merging into it
+	second := models.RateSnapshot{Date: "2024-03-01", TakenAt: takenAt.Add(time.Minute), Rates: []models.SnapshotRate{
+		{From: "USD", To: "INR", Rate: 83.2, Source: "exchangerate-api", LastUpdated: takenAt},
+	}}
+	if err := store.SaveRateSnapshot(ctx, second); err != nil {
+		t.Fatalf("SaveRateSnapshot failed: %v", err)
+	}
+
+	snapshot, found, err := store.RateSnapshot(ctx, "2024-03-01")
+	if err != nil || !found {
+		t.Fatalf("RateSnapshot = found %v, %v", found, err)
+	}
+	if snapshot.Count != 1 || snapshot.Rates[0].Rate != 83.2 || !snapshot.TakenAt.Equal(second.TakenAt) {
+		t.Errorf("expected only the second run's table, got %+v", snapshot)
+	}
+	if !snapshot.Rates[0].LastUpdated.Equal(takenAt) {
+		t.Errorf("expected last_updated %v, got %v", takenAt, snapshot.Rates[0].LastUpdated)
+	}
+
+	if _, found, _ := store.RateSnapshot(ctx, "2024-03-02"); found {
+		t.Error("expected no snapshot for a day that wasn't archived")
+	}
+}
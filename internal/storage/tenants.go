@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"exchange-rate-service/internal/tenant"
+)
+
+// TenantPolicies returns every stored policy by tenant (API key name)
+// Policies are kept as JSON in the same shape as the API keys file entries
+func (s *SQLStore) TenantPolicies(ctx context.Context) (map[string]tenant.Config, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT tenant, policy FROM tenant_policies`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tenant policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := make(map[string]tenant.Config)
+	for rows.Next() {
+		var name, raw string
+		if err := rows.Scan(&name, &raw); err != nil {
+			return nil, fmt.Errorf("failed to read tenant policies: %w", err)
+		}
+
+		var cfg tenant.Config
+		if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+			return nil, fmt.Errorf("invalid stored policy for tenant %s: %w", name, err)
+		}
+		policies[name] = cfg
+	}
+
+	return policies, rows.Err()
+}
+
+// SaveTenantPolicy inserts or replaces the policy of tenant
+func (s *SQLStore) SaveTenantPolicy(ctx context.Context, name string, cfg tenant.Config) error {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode tenant policy: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, s.rebind(
+		`INSERT INTO tenant_policies (tenant, policy, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (tenant) DO UPDATE SET policy = excluded.policy, updated_at = excluded.updated_at`),
+		name, string(raw), time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to save tenant policy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Package tenant holds the per-API-key policies the exchange service applies:
+// which pairs a key may use, the markup it is charged and how far back it may
+// look up rates. Keys without a policy get the service-wide behaviour.
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"exchange-rate-service/internal/currency"
+	"exchange-rate-service/internal/fees"
+)
+
+// Config is a policy as written in the API keys file or the rate store
+type Config struct {
+	// "FROM-TO" pairs the key may use, "USD-*" or "*-EUR" for every pair from
+	// or to a currency. Empty allows every pair
+	AllowedPairs []string `json:"allowed_pairs,omitempty"`
+	// fee rule ("1.5%", "2", "1.5%+2") replacing FEE_DEFAULT for this key
+	Markup string `json:"markup,omitempty"`
+	// "FROM-TO" -> rule, replacing Markup for those pairs
+	PairMarkups map[string]string `json:"pair_markups,omitempty"`
+	// how many days back historical lookups may go - only ever narrows
+	// MAX_HISTORICAL_DAYS (0 = no limit of its own)
+	MaxHistoricalDays int `json:"max_historical_days,omitempty"`
+	// sign every conversion for this key, as if it asked with receipt=true
+	SignedReceipts bool `json:"signed_receipts,omitempty"`
+}
+
+// IsZero reports whether the config changes nothing
+func (c Config) IsZero() bool {
+	return len(c.AllowedPairs) == 0 && c.Markup == "" && len(c.PairMarkups) == 0 && c.MaxHistoricalDays == 0 && !c.SignedReceipts
+}
+
+// Policy is a validated Config for one tenant
+type Policy struct {
+	Tenant            string
+	MaxHistoricalDays int
+	SignedReceipts    bool
+
+	pairs map[string]bool // nil = every pair
+	fees  *fees.Schedule  // nil = the service-wide fees
+}
+
+// NewPolicy validates cfg for tenant
+func NewPolicy(tenant string, cfg Config) (*Policy, error) {
+	if cfg.MaxHistoricalDays < 0 {
+		return nil, fmt.Errorf("tenant %s: max_historical_days cannot be negative", tenant)
+	}
+	policy := &Policy{Tenant: tenant, MaxHistoricalDays: cfg.MaxHistoricalDays, SignedReceipts: cfg.SignedReceipts}
+
+	if len(cfg.AllowedPairs) > 0 {
+		policy.pairs = make(map[string]bool, len(cfg.AllowedPairs))
+		for _, pair := range cfg.AllowedPairs {
+			pair = strings.ToUpper(strings.TrimSpace(pair))
+			from, to, ok := strings.Cut(pair, "-")
+			if !ok || (from != "*" && !currency.ValidCode(from)) || (to != "*" && !currency.ValidCode(to)) {
+				return nil, fmt.Errorf("tenant %s: invalid allowed pair %q (expected FROM-TO, FROM-* or *-TO)", tenant, pair)
+			}
+			policy.pairs[pair] = true
+		}
+	}
+
+	if cfg.Markup != "" || len(cfg.PairMarkups) > 0 {
+		schedule, err := fees.NewSchedule(cfg.Markup, cfg.PairMarkups)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenant, err)
+		}
+		policy.fees = schedule
+	}
+
+	return policy, nil
+}
+
+// AllowsPair reports whether the tenant may use from -> to. Converting a
+// currency into itself is always allowed
+func (p *Policy) AllowsPair(from, to string) bool {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if p.pairs == nil || from == to {
+		return true
+	}
+	return p.pairs[from+"-"+to] || p.pairs[from+"-*"] || p.pairs["*-"+to]
+}
+
+// Fees returns the tenant's own markup, if it has one
+func (p *Policy) Fees() (*fees.Schedule, bool) {
+	return p.fees, p.fees != nil
+}
+
+type contextKey struct{}
+
+// WithPolicy stores the caller's policy in ctx
+func WithPolicy(ctx context.Context, policy *Policy) context.Context {
+	return context.WithValue(ctx, contextKey{}, policy)
+}
+
+// FromContext returns the caller's policy, if it has one
+func FromContext(ctx context.Context) (*Policy, bool) {
+	policy, ok := ctx.Value(contextKey{}).(*Policy)
+	return policy, ok && policy != nil
+}
@@ -0,0 +1,73 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewPolicy_Validation(t *testing.T) {
+	cases := map[string]Config{
+		"bad pair":         {AllowedPairs: []string{"USDEUR"}},
+		"bad code":         {AllowedPairs: []string{"USD-EURO"}},
+		"bad markup":       {Markup: "abc"},
+		"bad pair markup":  {PairMarkups: map[string]string{"USD": "1%"}},
+		"negative history": {MaxHistoricalDays: -1},
+	}
+	for name, cfg := range cases {
+		if _, err := NewPolicy("partner", cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPolicy_AllowsPair(t *testing.T) {
+	policy, err := NewPolicy("partner", Config{AllowedPairs: []string{"usd-eur", "GBP-*", "*-JPY"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"USD", "EUR", true},
+		{"EUR", "USD", false}, // pairs are one-way
+		{"GBP", "INR", true},
+		{"CHF", "JPY", true},
+		{"USD", "INR", false},
+		{"INR", "INR", true},
+	}
+	for _, tt := range tests {
+		if got := policy.AllowsPair(tt.from, tt.to); got != tt.want {
+			t.Errorf("%s-%s: expected %v, got %v", tt.from, tt.to, tt.want, got)
+		}
+	}
+
+	open, _ := NewPolicy("partner", Config{Markup: "1%"})
+	if !open.AllowsPair("USD", "INR") {
+		t.Error("expected a policy without allowed pairs to allow every pair")
+	}
+}
+
+func TestPolicy_Fees(t *testing.T) {
+	policy, _ := NewPolicy("partner", Config{Markup: "1%", PairMarkups: map[string]string{"USD-EUR": "0.5%"}})
+	schedule, ok := policy.Fees()
+	if !ok || schedule.RuleFor("USD", "EUR").Percent.String() != "0.5" || schedule.RuleFor("USD", "GBP").Percent.String() != "1" {
+		t.Errorf("unexpected fees %+v", schedule)
+	}
+
+	limitOnly, _ := NewPolicy("partner", Config{MaxHistoricalDays: 30})
+	if _, ok := limitOnly.Fees(); ok {
+		t.Error("expected no markup of its own")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("expected no policy")
+	}
+	policy, _ := NewPolicy("partner", Config{Markup: "1%"})
+	if got, ok := FromContext(WithPolicy(context.Background(), policy)); !ok || got.Tenant != "partner" {
+		t.Errorf("expected the stored policy, got %+v", got)
+	}
+}
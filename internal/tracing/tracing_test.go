@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup_NoEndpointIsNoop(t *testing.T) {
+	shutdown, err := Setup(context.Background(), "", "test", 1)
+	if err != nil {
+		t.Fatalf("Setup without endpoint failed: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("no-op shutdown should not fail: %v", err)
+	}
+
+	_, span := Start(context.Background(), "noop")
+	if span.SpanContext().IsValid() {
+		t.Error("spans should be no-ops without an exporter")
+	}
+	span.End()
+}
+
+func TestStartAndEnd_NestAndRecordErrors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	ctx, parent := Start(context.Background(), "parent", Pair("USD", "EUR", "2024-01-15")...)
+	_, child := Start(ctx, "child")
+	End(child, errors.New("upstream down"))
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	childSpan, parentSpan := spans[0], spans[1]
+
+	if childSpan.Parent().SpanID() != parentSpan.SpanContext().SpanID() {
+		t.Error("child span should hang off the parent")
+	}
+	if childSpan.Status().Code != codes.Error || len(childSpan.Events()) != 1 {
+		t.Errorf("expected an error status and recorded error, got %+v", childSpan.Status())
+	}
+	if parentSpan.Status().Code == codes.Error {
+		t.Error("parent ended without error should not be marked failed")
+	}
+	if len(parentSpan.Attributes()) != 2 {
+		t.Errorf("expected pair and date attributes, got %v", parentSpan.Attributes())
+	}
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ResponseFormat is the body encoding picked for a response
+type ResponseFormat string
+
+const (
+	FormatJSON ResponseFormat = "json"
+	FormatCSV  ResponseFormat = "csv"
+	FormatXML  ResponseFormat = "xml"
+)
+
+// CSVRecords is implemented by payloads that can be written as a CSV table
+// The first record is the header row
+type CSVRecords interface {
+	CSVRecords() [][]string
+}
+
+// NegotiateFormat picks the response format: ?format=csv|xml|json wins, then the
+// first Accept media type we can produce. Anything else gets JSON
+func NegotiateFormat(r *http.Request) ResponseFormat {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "csv":
+		return FormatCSV
+	case "xml":
+		return FormatXML
+	case "json", "ndjson":
+		// ndjson is still JSON - NegotiateStreamFormat takes it from here
+		return FormatJSON
+	}
+
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/csv":
+			return FormatCSV
+		case "application/xml", "text/xml":
+			return FormatXML
+		case "application/json", "*/*":
+			return FormatJSON
+		}
+	}
+
+	return FormatJSON
+}
+
+// WriteFormatted writes payload as JSON, CSV or XML
+// Payloads without a CSV form fall back to JSON rather than failing the request
+func WriteFormatted(w http.ResponseWriter, code int, format ResponseFormat, payload interface{}) {
+	// the same URL answers in several formats - caches must key on Accept
+	w.Header().Add("Vary", "Accept")
+
+	switch format {
+	case FormatCSV:
+		if table, ok := payload.(CSVRecords); ok {
+			WriteCSV(w, code, table.CSVRecords())
+			return
+		}
+	case FormatXML:
+		WriteXML(w, code, payload)
+		return
+	}
+
+	WriteJSON(w, code, payload)
+}
+
+// WriteCSV - helper for csv responses
+func WriteCSV(w http.ResponseWriter, code int, records [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(code)
+
+	// headers are out already - all we can do on failure is log it
+	if err := csv.NewWriter(w).WriteAll(records); err != nil {
+		slog.Error("CSV encode failed", "error", err)
+	}
+}
+
+// WriteXML - helper for xml responses
+func WriteXML(w http.ResponseWriter, code int, payload interface{}) {
+	body, err := xml.Marshal(payload)
+	if err != nil {
+		slog.Error("XML encode failed", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write([]byte(xml.Header))
+	w.Write(body)
+	w.Write([]byte("\n"))
+}
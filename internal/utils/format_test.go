@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type csvPayload struct {
+	Rate float64 `json:"rate" xml:"rate"`
+}
+
+func (p csvPayload) CSVRecords() [][]string {
+	return [][]string{{"rate"}, {"0.5"}}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		url    string
+		accept string
+		want   ResponseFormat
+	}{
+		{"/convert", "", FormatJSON},
+		{"/convert", "text/csv", FormatCSV},
+		{"/convert", "application/xml;q=0.9", FormatXML},
+		{"/convert", "text/html, text/xml", FormatXML},
+		{"/convert", "application/json, text/csv", FormatJSON},
+		{"/convert", "image/png", FormatJSON},
+		{"/convert?format=csv", "application/xml", FormatCSV},
+		{"/convert?format=JSON", "text/csv", FormatJSON},
+		{"/convert?format=ndjson", "text/csv", FormatJSON},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		if got := NegotiateFormat(req); got != tt.want {
+			t.Errorf("%s Accept=%q: expected %s, got %s", tt.url, tt.accept, tt.want, got)
+		}
+	}
+}
+
+func TestWriteFormatted(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteFormatted(rec, http.StatusOK, FormatCSV, csvPayload{Rate: 0.5})
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+	if rec.Body.String() != "rate\n0.5\n" {
+		t.Errorf("unexpected csv body: %q", rec.Body.String())
+	}
+	if rec.Header().Get("Vary") != "Accept" {
+		t.Errorf("expected Vary: Accept, got %q", rec.Header().Get("Vary"))
+	}
+
+	rec = httptest.NewRecorder()
+	WriteFormatted(rec, http.StatusOK, FormatXML, csvPayload{Rate: 0.5})
+	if !strings.Contains(rec.Body.String(), "<csvPayload><rate>0.5</rate></csvPayload>") {
+		t.Errorf("unexpected xml body: %q", rec.Body.String())
+	}
+
+	// no CSV form - JSON rather than an error
+	rec = httptest.NewRecorder()
+	WriteFormatted(rec, http.StatusOK, FormatCSV, map[string]string{"status": "ok"})
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected json fallback, got %s", ct)
+	}
+}
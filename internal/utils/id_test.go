@@ -0,0 +1,14 @@
+package utils
+
+import "testing"
+
+func TestRandomID(t *testing.T) {
+	first, err := RandomID(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := RandomID(8)
+	if len(first) != 16 || first == second {
+		t.Errorf("expected distinct 16 char ids, got %q and %q", first, second)
+	}
+}
@@ -2,8 +2,10 @@ package utils
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+
+	"exchange-rate-service/internal/apperrors"
 )
 
 // WriteJSON - helper for json responses
@@ -12,19 +14,15 @@ func WriteJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.WriteHeader(code)
 
 	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		log.Printf("json encode failed: %v", err)
+		slog.Error("JSON encode failed", "error", err)
 		// fallback error
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
 
-// send error resp
+// send error resp - every error body carries a code, derived from the status when the caller has none
 func sendErr(w http.ResponseWriter, code int, msg string) {
-	errData := map[string]interface{}{
-		"error":  msg,
-		"status": "error",
-	}
-	WriteJSON(w, code, errData)
+	ErrorRespWithCode(w, code, string(apperrors.CodeForStatus(code)), msg)
 }
 
 // success response wrapper
@@ -41,6 +39,38 @@ func ErrorResp(w http.ResponseWriter, code int, msg string) {
 	sendErr(w, code, msg)
 }
 
+// ErrorRespWithCode - error response with a machine readable code for clients to switch on
+func ErrorRespWithCode(w http.ResponseWriter, code int, errCode, msg string) {
+	errData := map[string]interface{}{
+		"error":  msg,
+		"code":   errCode,
+		"status": "error",
+	}
+	WriteJSON(w, code, errData)
+}
+
+// UpstreamErrorResp - error response for a provider's answer, with the provider's own error type
+func UpstreamErrorResp(w http.ResponseWriter, code int, errCode, msg, upstream string) {
+	errData := map[string]interface{}{
+		"error":          msg,
+		"code":           errCode,
+		"status":         "error",
+		"upstream_error": upstream,
+	}
+	WriteJSON(w, code, errData)
+}
+
+// FieldErrorResp - 400 for a request body or query string that failed validation, with one message per bad field
+func FieldErrorResp(w http.ResponseWriter, msg string, fields map[string]string) {
+	errData := map[string]interface{}{
+		"error":  msg,
+		"code":   string(apperrors.CodeInvalidRequest),
+		"status": "error",
+		"fields": fields,
+	}
+	WriteJSON(w, http.StatusBadRequest, errData)
+}
+
 // Contains check - todo: maybe use strings.Contains instead?
 func Contains(str, sub string) bool {
 	for i := 0; i <= len(str)-len(sub); i++ {
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// StreamFormat selects how a streamed collection is encoded on the wire
+type StreamFormat int
+
+const (
+	// StreamJSONArray writes a regular JSON array, one element at a time
+	StreamJSONArray StreamFormat = iota
+	// StreamNDJSON writes newline-delimited JSON, one object per line
+	StreamNDJSON
+)
+
+// flush every N items - flushing on every item is too chatty for big exports
+const streamFlushInterval = 64
+
+// NegotiateStreamFormat picks NDJSON when the client asks for it via
+// ?format=ndjson or an Accept header, otherwise a plain JSON array
+func NegotiateStreamFormat(r *http.Request) StreamFormat {
+	if strings.EqualFold(r.URL.Query().Get("format"), "ndjson") {
+		return StreamNDJSON
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.Contains(accept, "application/x-ndjson") || strings.Contains(accept, "application/ndjson") {
+		return StreamNDJSON
+	}
+
+	return StreamJSONArray
+}
+
+// JSONStreamWriter writes a collection to the response incrementally using
+// chunked transfer encoding, so memory stays flat no matter how many items
+// we send. Not safe for concurrent use.
+type JSONStreamWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	enc     *json.Encoder
+	format  StreamFormat
+	code    int
+
+	count   int
+	started bool
+	closed  bool
+}
+
+// NewJSONStreamWriter prepares a streaming response - headers are only sent
+// on the first write so callers can still fall back to ErrorResp if the
+// producer fails before any item is ready
+func NewJSONStreamWriter(w http.ResponseWriter, code int, format StreamFormat) *JSONStreamWriter {
+	flusher, _ := w.(http.Flusher)
+
+	return &JSONStreamWriter{
+		w:       w,
+		flusher: flusher,
+		enc:     json.NewEncoder(w),
+		format:  format,
+		code:    code,
+	}
+}
+
+// Started reports whether any bytes have been written to the client yet
+func (s *JSONStreamWriter) Started() bool {
+	return s.started
+}
+
+// Count returns the number of items written so far
+func (s *JSONStreamWriter) Count() int {
+	return s.count
+}
+
+// WriteItem encodes a single element and periodically flushes it to the client
+func (s *JSONStreamWriter) WriteItem(item interface{}) error {
+	if s.closed {
+		return fmt.Errorf("stream already closed")
+	}
+
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	if s.format == StreamJSONArray && s.count > 0 {
+		if _, err := s.w.Write([]byte(",")); err != nil {
+			return err
+		}
+	}
+
+	// Encode appends a newline which doubles as the NDJSON record separator
+	if err := s.enc.Encode(item); err != nil {
+		return fmt.Errorf("stream encode failed: %w", err)
+	}
+	s.count++
+
+	if s.count%streamFlushInterval == 0 {
+		s.flush()
+	}
+
+	return nil
+}
+
+// Close terminates the collection and flushes whatever is left
+func (s *JSONStreamWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+
+	if err := s.start(); err != nil {
+		return err
+	}
+	s.closed = true
+
+	if s.format == StreamJSONArray {
+		if _, err := s.w.Write([]byte("]\n")); err != nil {
+			return err
+		}
+	}
+
+	s.flush()
+	return nil
+}
+
+// start sends headers and the opening bracket on first use
+func (s *JSONStreamWriter) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+
+	if s.format == StreamNDJSON {
+		s.w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		s.w.Header().Set("Content-Type", "application/json")
+	}
+	// length is unknown up front so net/http switches to chunked encoding
+	s.w.Header().Del("Content-Length")
+	s.w.WriteHeader(s.code)
+
+	if s.format == StreamJSONArray {
+		if _, err := s.w.Write([]byte("[")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *JSONStreamWriter) flush() {
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
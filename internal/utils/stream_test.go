@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type streamItem struct {
+	Date string  `json:"date"`
+	Rate float64 `json:"rate"`
+}
+
+func TestJSONStreamWriter_Array(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := NewJSONStreamWriter(rec, http.StatusOK, StreamJSONArray)
+
+	for i := 0; i < 100; i++ {
+		if err := stream.WriteItem(streamItem{Date: "2024-01-01", Rate: float64(i)}); err != nil {
+			t.Fatalf("WriteItem failed: %v", err)
+		}
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// The output must still be a valid JSON array
+	var decoded []streamItem
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("streamed body is not a valid JSON array: %v", err)
+	}
+	if len(decoded) != 100 {
+		t.Errorf("expected 100 items, got %d", len(decoded))
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+}
+
+func TestJSONStreamWriter_EmptyArray(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := NewJSONStreamWriter(rec, http.StatusOK, StreamJSONArray)
+
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("expected empty array, got %q", rec.Body.String())
+	}
+}
+
+func TestJSONStreamWriter_NDJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := NewJSONStreamWriter(rec, http.StatusOK, StreamNDJSON)
+
+	stream.WriteItem(streamItem{Date: "2024-01-01", Rate: 1.1})
+	stream.WriteItem(streamItem{Date: "2024-01-02", Rate: 1.2})
+	stream.Close()
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+}
+
+func TestNegotiateStreamFormat(t *testing.T) {
+	req := httptest.NewRequest("GET", "/export?format=ndjson", nil)
+	if NegotiateStreamFormat(req) != StreamNDJSON {
+		t.Error("format=ndjson should select NDJSON")
+	}
+
+	req = httptest.NewRequest("GET", "/export", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	if NegotiateStreamFormat(req) != StreamNDJSON {
+		t.Error("Accept: application/x-ndjson should select NDJSON")
+	}
+
+	req = httptest.NewRequest("GET", "/export", nil)
+	if NegotiateStreamFormat(req) != StreamJSONArray {
+		t.Error("default should be a JSON array")
+	}
+}
@@ -0,0 +1,338 @@
+// Package client is a typed Go client for the exchange rate service's v1 HTTP
+// API. Calls take a context, are retried with backoff on transient failures
+// (network errors, 429, 502, 503, 504) and return *Error for error responses,
+// which errors.Is matches against the Err* sentinels by code:
+//
+//	c, err := client.New("http://rates.internal:8080", client.WithAPIKey(key))
+//	conv, err := c.Convert(ctx, client.ConvertRequest{From: "USD", To: "EUR", Amount: decimal.RequireFromString("100")})
+//	if errors.Is(err, client.ErrUnsupportedCurrency) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	dateLayout   = "2006-01-02"
+
+	// error bodies are small - anything bigger isn't ours
+	maxErrorBodyBytes = 64 << 10
+
+	defaultTimeout     = 10 * time.Second
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = 200 * time.Millisecond
+	defaultMaxDelay    = 5 * time.Second
+)
+
+// Client calls the service. It is safe for concurrent use
+type Client struct {
+	baseURL     *url.URL
+	apiKey      string
+	userAgent   string
+	httpClient  *http.Client
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithAPIKey sends key in X-API-Key on every call
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient replaces the default http.Client (10s timeout)
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithUserAgent sets the User-Agent header
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// WithRetries sets how many times a call is attempted in total and the first
+// backoff delay, which doubles per attempt up to 5s. 1 disables retries
+func WithRetries(maxAttempts int, baseDelay time.Duration) Option {
+	return func(c *Client) {
+		c.maxAttempts, c.baseDelay = maxAttempts, baseDelay
+	}
+}
+
+// New creates a client for the service at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:     parsed,
+		userAgent:   "exchange-rate-service-go-client",
+		httpClient:  &http.Client{Timeout: defaultTimeout},
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   defaultBaseDelay,
+		maxDelay:    defaultMaxDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.maxAttempts < 1 {
+		c.maxAttempts = 1
+	}
+	return c, nil
+}
+
+// ConvertRequest is a conversion. A zero Date converts at the latest rate
+type ConvertRequest struct {
+	From   string
+	To     string
+	Amount decimal.Decimal
+	Date   time.Time
+	Locale string // optional, fills Conversion.Formatted
+}
+
+// Conversion is the result of Convert. Rate is mid-market; AppliedRate and
+// Fee show any markup configured on the service
+type Conversion struct {
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	OriginalAmount decimal.Decimal `json:"original_amount"`
+	Amount         decimal.Decimal `json:"amount"`
+	Rate           float64         `json:"rate"`
+	AppliedRate    float64         `json:"applied_rate"`
+	Fee            decimal.Decimal `json:"fee"`
+	Date           string          `json:"date,omitempty"`
+	LastUpdated    *time.Time      `json:"last_updated,omitempty"`
+	Cached         bool            `json:"cached"`
+	Source         string          `json:"source,omitempty"`
+	Stale          bool            `json:"stale,omitempty"`
+	Formatted      string          `json:"formatted,omitempty"`
+	Warnings       []string        `json:"warnings,omitempty"`
+}
+
+// Rate is the result of LatestRate and HistoricalRate. Date is "latest" for
+// latest rates; EffectiveDate is the day actually served when it differs
+type Rate struct {
+	From          string     `json:"from"`
+	To            string     `json:"to"`
+	Rate          float64    `json:"rate"`
+	Date          string     `json:"date"`
+	EffectiveDate string     `json:"effective_date,omitempty"`
+	Stale         bool       `json:"stale,omitempty"`
+	LastUpdated   *time.Time `json:"last_updated,omitempty"`
+	Warnings      []string   `json:"warnings,omitempty"`
+}
+
+// TimeSeries is the result of Timeseries - rates keyed by YYYY-MM-DD
+type TimeSeries struct {
+	From     string             `json:"from"`
+	To       string             `json:"to"`
+	Start    string             `json:"start"`
+	End      string             `json:"end"`
+	Rates    map[string]float64 `json:"rates"`
+	Warnings []string           `json:"warnings,omitempty"`
+}
+
+// convertBody is the body of POST /v1/convert - the amount goes as a string so
+// no precision is lost on the way
+type convertBody struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount string `json:"amount"`
+	Date   string `json:"date,omitempty"`
+	Locale string `json:"locale,omitempty"`
+}
+
+// Convert converts an amount between two currencies
+func (c *Client) Convert(ctx context.Context, req ConvertRequest) (*Conversion, error) {
+	body := convertBody{From: req.From, To: req.To, Amount: req.Amount.String(), Locale: req.Locale}
+	if !req.Date.IsZero() {
+		body.Date = req.Date.Format(dateLayout)
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode conversion: %w", err)
+	}
+
+	var conv Conversion
+	if err := c.do(ctx, http.MethodPost, "/v1/convert", nil, payload, &conv); err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// LatestRate returns the current rate from one currency to another
+func (c *Client) LatestRate(ctx context.Context, from, to string) (*Rate, error) {
+	query := url.Values{"from": {from}, "to": {to}}
+	var rate Rate
+	if err := c.do(ctx, http.MethodGet, "/v1/rate/latest", query, nil, &rate); err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// HistoricalRate returns the rate on a past day
+func (c *Client) HistoricalRate(ctx context.Context, from, to string, date time.Time) (*Rate, error) {
+	query := url.Values{"from": {from}, "to": {to}, "date": {date.Format(dateLayout)}}
+	var rate Rate
+	if err := c.do(ctx, http.MethodGet, "/v1/rate/historical", query, nil, &rate); err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// Timeseries returns the daily rates between start and end, inclusive
+func (c *Client) Timeseries(ctx context.Context, from, to string, start, end time.Time) (*TimeSeries, error) {
+	query := url.Values{
+		"from":  {from},
+		"to":    {to},
+		"start": {start.Format(dateLayout)},
+		"end":   {end.Format(dateLayout)},
+	}
+	var series TimeSeries
+	if err := c.do(ctx, http.MethodGet, "/v1/rate/timeseries", query, nil, &series); err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// do sends the request, retrying transient failures, and decodes a 2xx body into out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, payload []byte, out interface{}) error {
+	target := *c.baseURL
+	target.Path += path
+	target.RawQuery = query.Encode()
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = c.attempt(ctx, method, target.String(), payload, out)
+		if !retry || attempt >= c.maxAttempts {
+			return err
+		}
+
+		delay, ok := c.delay(ctx, attempt, err)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt makes one call. retry reports whether the failure is worth another try
+func (c *Client) attempt(ctx context.Context, method, target string, payload []byte, out interface{}) (retry bool, err error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return false, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// the caller's own cancellation or deadline isn't transient
+		return ctx.Err() == nil, fmt.Errorf("%s %s: %w", method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		apiErr := newError(resp, data)
+		// a provider refusing the service's key or plan won't change its mind on a retry
+		return retryableStatus(resp.StatusCode) && apiErr.Code != CodeUpstreamRejected, apiErr
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("failed to decode %s response: %w", req.URL.Path, err)
+	}
+	return false, nil
+}
+
+// retryableStatus reports whether a response status is worth retrying
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// delay is the jittered backoff after the given failed attempt, or the
+// service's Retry-After when longer. false when the wait isn't worth it: the
+// service asked for longer than the max delay, or ctx would expire first
+func (c *Client) delay(ctx context.Context, attempt int, err error) (time.Duration, bool) {
+	delay := c.baseDelay
+	for i := 1; i < attempt && delay < c.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > c.maxDelay {
+		delay = c.maxDelay
+	}
+	// ±20% so callers failing together don't retry together
+	delay += time.Duration(float64(delay) * 0.2 * (2*rand.Float64() - 1))
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > c.maxDelay {
+			return 0, false
+		}
+		if apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return 0, false
+	}
+	return delay, true
+}
+
+// parseRetryAfter reads a Retry-After value - delay seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
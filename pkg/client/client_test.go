@@ -0,0 +1,220 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"exchange-rate-service/internal/apperrors"
+
+	"github.com/shopspring/decimal"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	opts = append([]Option{WithRetries(3, time.Millisecond)}, opts...)
+	c, err := New(server.URL, opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestConvert_SendsAmountAsStringAndDecodes(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/convert" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("X-API-Key") != "secret" {
+			t.Errorf("expected API key header, got %q", r.Header.Get("X-API-Key"))
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["amount"] != "100.123456789" || body["date"] != "2024-03-01" {
+			t.Errorf("unexpected body %v", body)
+		}
+		w.Write([]byte(`{"from":"USD","to":"EUR","original_amount":100.123456789,"amount":92.11,"rate":0.92,"applied_rate":0.92,"fee":0,"cached":true,"source":"mock"}`))
+	}, WithAPIKey("secret"))
+
+	conv, err := c.Convert(context.Background(), ConvertRequest{
+		From:   "USD",
+		To:     "EUR",
+		Amount: decimal.RequireFromString("100.123456789"),
+		Date:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if !conv.Amount.Equal(decimal.RequireFromString("92.11")) || conv.Rate != 0.92 || !conv.Cached || conv.Source != "mock" {
+		t.Errorf("unexpected conversion %+v", conv)
+	}
+}
+
+func TestRates_QueryParameters(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/v1/rate/latest":
+			w.Write([]byte(`{"from":"` + q.Get("from") + `","to":"` + q.Get("to") + `","rate":0.9,"date":"latest"}`))
+		case "/v1/rate/historical":
+			w.Write([]byte(`{"from":"USD","to":"EUR","rate":0.8,"date":"` + q.Get("date") + `"}`))
+		case "/v1/rate/timeseries":
+			w.Write([]byte(`{"from":"USD","to":"EUR","start":"` + q.Get("start") + `","end":"` + q.Get("end") + `","rates":{"2024-01-02":0.91}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	latest, err := c.LatestRate(ctx, "USD", "EUR")
+	if err != nil || latest.Rate != 0.9 || latest.From != "USD" || latest.Date != "latest" {
+		t.Errorf("LatestRate: %+v (%v)", latest, err)
+	}
+	historical, err := c.HistoricalRate(ctx, "USD", "EUR", day)
+	if err != nil || historical.Date != "2024-01-02" {
+		t.Errorf("HistoricalRate: %+v (%v)", historical, err)
+	}
+	series, err := c.Timeseries(ctx, "USD", "EUR", day, day.AddDate(0, 0, 3))
+	if err != nil || series.End != "2024-01-05" || series.Rates["2024-01-02"] != 0.91 {
+		t.Errorf("Timeseries: %+v (%v)", series, err)
+	}
+}
+
+func TestErrors_AreTypedAndNotRetried(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("X-Request-ID", "req-1")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"invalid_request","error":"invalid query parameters","status":"error","fields":{"form":"unknown parameter, did you mean \"from\"?"}}`))
+	})
+
+	_, err := c.LatestRate(context.Background(), "USD", "EUR")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	if !errors.Is(err, ErrInvalidRequest) || errors.Is(err, ErrNotFound) {
+		t.Errorf("errors.Is should match by code, got %v", err)
+	}
+	if apiErr.StatusCode != 400 || apiErr.RequestID != "req-1" || apiErr.Fields["form"] == "" {
+		t.Errorf("unexpected error %+v", apiErr)
+	}
+	if calls != 1 {
+		t.Errorf("expected a 400 not to be retried, got %d calls", calls)
+	}
+}
+
+func TestUpstreamRejected_IsNotRetried(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"code":"upstream_rejected","error":"exchange rate provider rejected our credentials","status":"error","upstream_error":"invalid-key"}`))
+	})
+
+	_, err := c.LatestRate(context.Background(), "USD", "EUR")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || !errors.Is(err, ErrUpstreamRejected) || apiErr.Upstream != "invalid-key" {
+		t.Fatalf("expected upstream_rejected with the provider's error type, got %+v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected a rejected call not to be retried, got %d calls", calls)
+	}
+}
+
+func TestTransientFailures_AreRetried(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			// not the service's envelope - the code comes from the status
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"from":"USD","to":"EUR","rate":0.9,"date":"latest"}`))
+	})
+
+	if _, err := c.LatestRate(context.Background(), "USD", "EUR"); err != nil || calls != 3 {
+		t.Fatalf("expected success on the third attempt, got %v after %d calls", err, calls)
+	}
+}
+
+func TestTransientFailures_GiveUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := c.LatestRate(context.Background(), "USD", "EUR")
+	if !errors.Is(err, ErrUpstreamUnavailable) || calls != 3 {
+		t.Errorf("expected upstream_unavailable after 3 attempts, got %v after %d calls", err, calls)
+	}
+}
+
+func TestRetryAfter_LongerThanMaxDelayGivesUp(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"code":"rate_limited","error":"rate limit exceeded","status":"error"}`))
+	})
+
+	_, err := c.LatestRate(context.Background(), "USD", "EUR")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != CodeRateLimited || apiErr.RetryAfter != time.Minute {
+		t.Fatalf("expected rate_limited with a 60s hint, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected no retry for a minute-long Retry-After, got %d calls", calls)
+	}
+}
+
+func TestNew_RejectsRelativeURL(t *testing.T) {
+	if _, err := New("localhost:8080"); err == nil {
+		t.Error("expected an error for a base URL without a scheme")
+	}
+}
+
+// codes are copied rather than imported so the package has no internal
+// dependencies - keep them in step with the service
+func TestCodes_MirrorService(t *testing.T) {
+	pairs := map[Code]apperrors.Code{
+		CodeInvalidRequest:      apperrors.CodeInvalidRequest,
+		CodeUnsupportedCurrency: apperrors.CodeUnsupportedCurrency,
+		CodeCurrencySunset:      apperrors.CodeCurrencySunset,
+		CodeInvalidAmount:       apperrors.CodeInvalidAmount,
+		CodeInvalidDate:         apperrors.CodeInvalidDate,
+		CodeDateOutOfRange:      apperrors.CodeDateOutOfRange,
+		CodeUpstreamUnavailable: apperrors.CodeUpstreamUnavailable,
+		CodeReadOnlyReplica:     apperrors.CodeReadOnlyReplica,
+		CodePairUnavailable:     apperrors.CodePairUnavailable,
+		CodeUnauthorized:        apperrors.CodeUnauthorized,
+		CodeForbidden:           apperrors.CodeForbidden,
+		CodeNotFound:            apperrors.CodeNotFound,
+		CodeConflict:            apperrors.CodeConflict,
+		CodeRateLimited:         apperrors.CodeRateLimited,
+		CodeTimeout:             apperrors.CodeTimeout,
+		CodeInternal:            apperrors.CodeInternal,
+	}
+	for ours, theirs := range pairs {
+		if string(ours) != string(theirs) {
+			t.Errorf("code %q differs from the service's %q", ours, theirs)
+		}
+	}
+	for _, status := range []int{400, 401, 403, 404, 409, 422, 429, 500, 502, 503, 504} {
+		if string(codeForStatus(status)) != string(apperrors.CodeForStatus(status)) {
+			t.Errorf("status %d maps to %q, the service uses %q", status, codeForStatus(status), apperrors.CodeForStatus(status))
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Code is the machine-readable error code the service returns in every error
+// body. The values mirror the service's own codes and never change once shipped
+type Code string
+
+const (
+	CodeInvalidRequest      Code = "invalid_request"
+	CodeUnsupportedCurrency Code = "unsupported_currency"
+	CodeCurrencySunset      Code = "currency_sunset"
+	CodeInvalidAmount       Code = "invalid_amount"
+	CodeInvalidDate         Code = "invalid_date"
+	CodeDateOutOfRange      Code = "date_out_of_range"
+	CodeUpstreamUnavailable Code = "upstream_unavailable"
+	CodeUpstreamRejected    Code = "upstream_rejected"
+	CodeReadOnlyReplica     Code = "read_only_replica"
+	CodePairUnavailable     Code = "pair_unavailable"
+	CodeUnauthorized        Code = "unauthorized"
+	CodeForbidden           Code = "forbidden"
+	CodeNotFound            Code = "not_found"
+	CodeConflict            Code = "conflict"
+	CodeRateLimited         Code = "rate_limited"
+	CodeTimeout             Code = "timeout"
+	CodeOverloaded          Code = "overloaded"
+	CodeDegraded            Code = "degraded"
+	CodeInternal            Code = "internal_error"
+)
+
+// Error is an error response from the service
+type Error struct {
+	StatusCode int
+	Code       Code
+	Message    string
+	Fields     map[string]string // per-parameter messages on validation errors
+	RequestID  string            // X-Request-ID of the failed call, for support
+	RetryAfter time.Duration     // the wait the service asked for on 429/503, if any
+	Upstream   string            // the provider's error type when a provider rejected the call
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("exchange rate service: %s (%d %s)", e.Message, e.StatusCode, e.Code)
+}
+
+// Is matches any *Error with the same code, so errors.Is(err, ErrRateLimited)
+// holds for every rate limited response regardless of message
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// sentinels for errors.Is checks
+var (
+	ErrInvalidRequest      = &Error{Code: CodeInvalidRequest, Message: "invalid request"}
+	ErrUnsupportedCurrency = &Error{Code: CodeUnsupportedCurrency, Message: "unsupported currency"}
+	ErrCurrencySunset      = &Error{Code: CodeCurrencySunset, Message: "currency retired"}
+	ErrInvalidAmount       = &Error{Code: CodeInvalidAmount, Message: "invalid amount"}
+	ErrInvalidDate         = &Error{Code: CodeInvalidDate, Message: "invalid date"}
+	ErrDateOutOfRange      = &Error{Code: CodeDateOutOfRange, Message: "date out of range"}
+	ErrUpstreamUnavailable = &Error{Code: CodeUpstreamUnavailable, Message: "upstream unavailable"}
+	ErrUpstreamRejected    = &Error{Code: CodeUpstreamRejected, Message: "upstream rejected the request"}
+	ErrReadOnlyReplica     = &Error{Code: CodeReadOnlyReplica, Message: "rate not available on read-only replica"}
+	ErrPairUnavailable     = &Error{Code: CodePairUnavailable, Message: "pair unavailable"}
+	ErrUnauthorized        = &Error{Code: CodeUnauthorized, Message: "unauthorized"}
+	ErrForbidden           = &Error{Code: CodeForbidden, Message: "forbidden"}
+	ErrNotFound            = &Error{Code: CodeNotFound, Message: "not found"}
+	ErrConflict            = &Error{Code: CodeConflict, Message: "conflict"}
+	ErrRateLimited         = &Error{Code: CodeRateLimited, Message: "rate limited"}
+	ErrTimeout             = &Error{Code: CodeTimeout, Message: "timeout"}
+	ErrOverloaded          = &Error{Code: CodeOverloaded, Message: "overloaded"}
+	ErrDegraded            = &Error{Code: CodeDegraded, Message: "degraded mode"}
+	ErrInternal            = &Error{Code: CodeInternal, Message: "internal error"}
+)
+
+// errorBody is the service's error envelope
+type errorBody struct {
+	Error    string            `json:"error"`
+	Code     Code              `json:"code"`
+	Fields   map[string]string `json:"fields"`
+	Upstream string            `json:"upstream_error"`
+}
+
+// newError builds an *Error from a non-2xx response body. Bodies that aren't
+// the service's envelope (a proxy's error page) get a code from the status
+func newError(resp *http.Response, body []byte) *Error {
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+
+	var parsed errorBody
+	if json.Unmarshal(body, &parsed) == nil && parsed.Code != "" {
+		e.Code, e.Message, e.Fields, e.Upstream = parsed.Code, parsed.Error, parsed.Fields, parsed.Upstream
+		return e
+	}
+	e.Code = codeForStatus(resp.StatusCode)
+	e.Message = http.StatusText(resp.StatusCode)
+	return e
+}
+
+// codeForStatus mirrors the service's status to code mapping
+func codeForStatus(status int) Code {
+	switch {
+	case status == http.StatusUnauthorized:
+		return CodeUnauthorized
+	case status == http.StatusForbidden:
+		return CodeForbidden
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status == http.StatusConflict:
+		return CodeConflict
+	case status == http.StatusTooManyRequests:
+		return CodeRateLimited
+	case status == http.StatusGatewayTimeout:
+		return CodeTimeout
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable:
+		return CodeUpstreamUnavailable
+	case status >= 400 && status < 500:
+		return CodeInvalidRequest
+	default:
+		return CodeInternal
+	}
+}